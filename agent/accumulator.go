@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
@@ -14,9 +16,15 @@ type MetricMaker interface {
 }
 
 type accumulator struct {
+	dropped   int64 // must be 64-bit aligned
 	maker     MetricMaker
 	metrics   chan<- cua.Metric
 	precision time.Duration
+
+	// closed is set once the accumulator should no longer write to the
+	// metrics channel, metrics added afterwards are dropped and counted.
+	mu     sync.RWMutex
+	closed bool
 }
 
 func NewAccumulator(
@@ -88,7 +96,7 @@ func (ac *accumulator) AddCumulativeHistogram(
 func (ac *accumulator) AddMetric(m cua.Metric) {
 	m.SetTime(m.Time().Round(ac.precision))
 	if m := ac.maker.MakeMetric(m); m != nil {
-		ac.metrics <- m
+		ac.send(m)
 	}
 }
 
@@ -104,8 +112,35 @@ func (ac *accumulator) addFields(
 		return
 	}
 	if m := ac.maker.MakeMetric(m); m != nil {
-		ac.metrics <- m
+		ac.send(m)
+	}
+}
+
+// send writes the metric to the metrics channel unless the accumulator has
+// been closed, in which case the metric is dropped.
+func (ac *accumulator) send(m cua.Metric) {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if ac.closed {
+		atomic.AddInt64(&ac.dropped, 1)
+		m.Drop()
+		return
 	}
+	ac.metrics <- m
+}
+
+// close stops the accumulator from writing to the metrics channel, it blocks
+// until any in progress writes complete.  Once closed it is safe to close the
+// metrics channel even if a plugin continues to add metrics.
+func (ac *accumulator) close() {
+	ac.mu.Lock()
+	ac.closed = true
+	ac.mu.Unlock()
+}
+
+// droppedCount returns the number of metrics dropped after close.
+func (ac *accumulator) droppedCount() int64 {
+	return atomic.LoadInt64(&ac.dropped)
 }
 
 // AddError passes a runtime error to the accumulator.
@@ -141,6 +141,27 @@ func TestAddTrackingMetricGroupEmpty(t *testing.T) {
 	}
 }
 
+func TestAddFieldsAfterClose(t *testing.T) {
+	metrics := make(chan cua.Metric, 10)
+	a := NewAccumulator(&TestMetricMaker{}, metrics)
+
+	fields := map[string]interface{}{
+		"usage": float64(99),
+	}
+	a.AddGauge("acctest", fields, nil)
+
+	acc := a.(*accumulator)
+	acc.close()
+	close(metrics)
+
+	// must not panic writing to the closed channel
+	a.AddGauge("acctest", fields, nil)
+	a.AddGauge("acctest", fields, nil)
+
+	require.Len(t, metrics, 1)
+	require.Equal(t, int64(2), acc.droppedCount())
+}
+
 type TestMetricMaker struct {
 }
 
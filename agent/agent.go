@@ -107,6 +107,11 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
+	err = a.restoreStates()
+	if err != nil {
+		return err
+	}
+
 	startTime := time.Now()
 
 	log.Printf("D! [agent] Connecting outputs")
@@ -146,7 +151,7 @@ func (a *Agent) Run(ctx context.Context) error {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		a.runOutputs(ou)
+		a.runOutputs(ctx, ou)
 	}()
 
 	if au != nil {
@@ -179,6 +184,8 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	wg.Wait()
 
+	a.saveStates()
+
 	log.Printf("D! [agent] Stopped Successfully")
 	return err
 }
@@ -256,13 +263,14 @@ func (a *Agent) startInputs(
 // runInputs starts and triggers the periodic gather for Inputs.
 //
 // When the context is done the timers are stopped and this function returns
-// after all ongoing Gather calls complete.
+// after all ongoing Gather calls complete or the shutdown timeout elapses.
 func (a *Agent) runInputs(
 	ctx context.Context,
 	startTime time.Time,
 	unit *inputUnit,
 ) {
 	var wg sync.WaitGroup
+	accs := make([]*accumulator, 0, len(unit.inputs))
 	for _, input := range unit.inputs {
 		// Overwrite agent interval if this plugin has its own.
 		interval := a.Config.Agent.Interval.Duration
@@ -292,6 +300,7 @@ func (a *Agent) runInputs(
 
 		acc := NewAccumulator(input, unit.dst)
 		acc.SetPrecision(getPrecision(precision, interval))
+		accs = append(accs, acc.(*accumulator))
 
 		wg.Add(1)
 		go func(input *models.RunningInput) {
@@ -305,6 +314,17 @@ func (a *Agent) runInputs(
 	log.Printf("D! [agent] Stopping service inputs")
 	stopServiceInputs(unit.inputs)
 
+	// Any collection still running was abandoned at the shutdown timeout,
+	// close the accumulators so late metrics are dropped rather than sent on
+	// the closed channel.
+	for i, acc := range accs {
+		acc.close()
+		if n := acc.droppedCount(); n > 0 {
+			log.Printf("W! [agent] [%s] dropped %d metrics from collection abandoned at shutdown",
+				unit.inputs[i].LogName(), n)
+		}
+	}
+
 	close(unit.dst)
 	log.Printf("D! [agent] Input channel closed")
 }
@@ -443,7 +463,9 @@ func (a *Agent) gatherLoop(
 }
 
 // gatherOnce runs the input's Gather function once, logging a warning each
-// interval it fails to complete before.
+// interval it fails to complete before.  If the context is done while Gather
+// is running it is given up to the shutdown timeout to complete before it is
+// abandoned.
 func (a *Agent) gatherOnce(
 	ctx context.Context,
 	acc cua.Accumulator,
@@ -451,7 +473,7 @@ func (a *Agent) gatherOnce(
 	ticker Ticker,
 	interval time.Duration,
 ) error {
-	done := make(chan error)
+	done := make(chan error, 1)
 	go func() {
 		done <- input.Gather(ctx, acc)
 	}()
@@ -462,10 +484,24 @@ func (a *Agent) gatherOnce(
 	slowWarning := time.NewTicker(interval)
 	defer slowWarning.Stop()
 
+	shutdown := ctx.Done()
+	var deadline <-chan time.Time
+
 	for {
 		select {
 		case err := <-done:
 			return err
+		case <-shutdown:
+			shutdown = nil
+			timeout := a.Config.Agent.ShutdownTimeout.Duration
+			log.Printf("D! [%s] Waiting up to %s for collection to complete", input.LogName(), timeout)
+			t := time.NewTimer(timeout)
+			defer t.Stop()
+			deadline = t.C
+		case <-deadline:
+			log.Printf("W! [%s] Collection did not complete within shutdown timeout of %s; abandoning",
+				input.LogName(), a.Config.Agent.ShutdownTimeout.Duration)
+			return nil
 		case <-slowWarning.C:
 			log.Printf("W! [%s] Collection took longer than expected; not complete after interval of %s",
 				input.LogName(), interval)
@@ -704,15 +740,25 @@ func (a *Agent) connectOutput(ctx context.Context, output *models.RunningOutput)
 // closed and all metrics have been written.  On shutdown metrics will be
 // written one last time and dropped if unsuccessful.
 func (a *Agent) runOutputs(
+	ctx context.Context,
 	unit *outputUnit,
 ) {
 	var wg sync.WaitGroup
 
+	// Outputs using the block overflow policy stop waiting for room in the
+	// buffer on shutdown so that the pipeline can drain.
+	go func() {
+		<-ctx.Done()
+		for _, output := range unit.outputs {
+			output.Release()
+		}
+	}()
+
 	// Start flush loop
 	interval := a.Config.Agent.FlushInterval.Duration
 	jitter := a.Config.Agent.FlushJitter.Duration
 
-	ctx, cancel := context.WithCancel(context.Background())
+	flushCtx, cancel := context.WithCancel(context.Background())
 
 	for _, output := range unit.outputs {
 		interval := interval
@@ -734,13 +780,13 @@ func (a *Agent) runOutputs(
 			ticker := NewRollingTicker(interval, jitter)
 			defer ticker.Stop()
 
-			a.flushLoop(ctx, output, ticker)
+			a.flushLoop(flushCtx, output, ticker)
 		}(output)
 	}
 
 	for metric := range unit.src {
 		for i, output := range unit.outputs {
-			if i == len(a.Config.Outputs)-1 {
+			if i == len(unit.outputs)-1 {
 				output.AddMetric(metric)
 			} else {
 				output.AddMetric(metric.Copy())
@@ -751,6 +797,12 @@ func (a *Agent) runOutputs(
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
 	cancel()
 	wg.Wait()
+
+	for _, output := range unit.outputs {
+		if n := output.BufferLength(); n > 0 {
+			log.Printf("W! [agent] [%s] dropped %d unwritten metrics at shutdown", output.LogName(), n)
+		}
+	}
 }
 
 // flushLoop runs an output's flush function periodically until the context is
@@ -775,14 +827,14 @@ func (a *Agent) flushLoop(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.finalFlush(output))
 			return
 		default:
 		}
 
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.finalFlush(output))
 			return
 		case <-ticker.Elapsed():
 			logError(a.flushOnce(output, ticker, output.Write))
@@ -825,6 +877,27 @@ func (a *Agent) flushOnce(
 	}
 }
 
+// finalFlush runs the output's Write function one last time on shutdown,
+// giving up if it does not complete within the shutdown timeout.
+func (a *Agent) finalFlush(output *models.RunningOutput) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- output.Write()
+	}()
+
+	timeout := time.NewTimer(a.Config.Agent.ShutdownTimeout.Duration)
+	defer timeout.Stop()
+
+	select {
+	case err := <-done:
+		output.LogBufferStatus()
+		return err
+	case <-timeout.C:
+		return fmt.Errorf("final flush did not complete within shutdown timeout of %s",
+			a.Config.Agent.ShutdownTimeout.Duration)
+	}
+}
+
 // Test runs the inputs, processors and aggregators for a single gather and
 // writes the metrics to stdout.
 func (a *Agent) Test(ctx context.Context, wait time.Duration) error {
@@ -873,6 +946,11 @@ func (a *Agent) test(ctx context.Context, wait time.Duration, outputC chan<- cua
 		return err
 	}
 
+	err = a.restoreStates()
+	if err != nil {
+		return err
+	}
+
 	startTime := time.Now()
 
 	next := outputC
@@ -933,6 +1011,8 @@ func (a *Agent) test(ctx context.Context, wait time.Duration, outputC chan<- cua
 
 	wg.Wait()
 
+	a.saveStates()
+
 	log.Printf("D! [agent] Stopped Successfully")
 
 	return nil
@@ -969,6 +1049,11 @@ func (a *Agent) once(ctx context.Context, wait time.Duration) error {
 		return err
 	}
 
+	err = a.restoreStates()
+	if err != nil {
+		return err
+	}
+
 	startTime := time.Now()
 
 	log.Printf("D! [agent] Connecting outputs")
@@ -1005,7 +1090,7 @@ func (a *Agent) once(ctx context.Context, wait time.Duration) error {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		a.runOutputs(ou)
+		a.runOutputs(ctx, ou)
 	}()
 
 	if au != nil {
@@ -1038,6 +1123,8 @@ func (a *Agent) once(ctx context.Context, wait time.Duration) error {
 
 	wg.Wait()
 
+	a.saveStates()
+
 	log.Printf("D! [agent] Stopped Successfully")
 
 	return nil
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal/state"
+	"github.com/circonus-labs/circonus-unified-agent/models"
+)
+
+// stateInputs returns the inputs keeping their state across restarts, keyed
+// by the log name of the instance.  Instances sharing a name are left out
+// as their states could not be told apart.
+func stateInputs(inputs []*models.RunningInput) map[string]cua.PersistentState {
+	states := make(map[string]cua.PersistentState)
+	shared := make(map[string]bool)
+	for _, input := range inputs {
+		ps, ok := input.Input.(cua.PersistentState)
+		if !ok {
+			continue
+		}
+		key := input.LogName()
+		if _, ok := states[key]; ok || shared[key] {
+			log.Printf("W! [agent] Not saving the state of %s, set a distinct alias on each instance", key)
+			delete(states, key)
+			shared[key] = true
+			continue
+		}
+		states[key] = ps
+	}
+	return states
+}
+
+// restoreStates passes the state saved by the previous run to the inputs, an
+// input whose state cannot be loaded or restored starts without it.
+func (a *Agent) restoreStates() error {
+	if a.Config.Agent.StateDirectory == "" {
+		return nil
+	}
+	store, err := state.NewStore(a.Config.Agent.StateDirectory)
+	if err != nil {
+		return fmt.Errorf("state store: %w", err)
+	}
+	for key, ps := range stateInputs(a.Config.Inputs) {
+		data, err := store.Load(key)
+		if err != nil {
+			log.Printf("W! [agent] Could not load state of input %s, starting without it: %v", key, err)
+			continue
+		}
+		if data == nil {
+			continue
+		}
+		if err := ps.SetState(data); err != nil {
+			log.Printf("W! [agent] Could not restore state of input %s, starting without it: %v", key, err)
+			continue
+		}
+		log.Printf("D! [agent] Restored state of %s", key)
+	}
+	return nil
+}
+
+// saveStates saves the state of the stopped inputs, failures are logged so
+// that the other states are still saved.
+func (a *Agent) saveStates() {
+	if a.Config.Agent.StateDirectory == "" {
+		return
+	}
+	store, err := state.NewStore(a.Config.Agent.StateDirectory)
+	if err != nil {
+		log.Printf("E! [agent] Could not save states: %v", err)
+		return
+	}
+	for key, ps := range stateInputs(a.Config.Inputs) {
+		data, err := ps.GetState()
+		if err != nil {
+			log.Printf("E! [agent] Could not get state of input %s: %v", key, err)
+			continue
+		}
+		if err := store.Save(key, data); err != nil {
+			log.Printf("E! [agent] Could not save state of input %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/models"
+	"github.com/stretchr/testify/require"
+)
+
+type statefulInput struct {
+	state string
+}
+
+func (i *statefulInput) SampleConfig() string                          { return "" }
+func (i *statefulInput) Description() string                           { return "" }
+func (i *statefulInput) Gather(context.Context, cua.Accumulator) error { return nil }
+func (i *statefulInput) GetState() ([]byte, error)                     { return []byte(i.state), nil }
+func (i *statefulInput) SetState(state []byte) error {
+	if string(state) == "corrupt" {
+		return errors.New("corrupt state")
+	}
+	i.state = string(state)
+	return nil
+}
+
+func newStateAgent(dir string, inputs ...*statefulInput) *Agent {
+	c := config.NewConfig()
+	c.Agent.StateDirectory = dir
+	for i, input := range inputs {
+		alias := ""
+		if i > 0 {
+			alias = "second"
+		}
+		c.Inputs = append(c.Inputs, models.NewRunningInput(input, &models.InputConfig{Name: "stateful", Alias: alias}))
+	}
+	return &Agent{Config: c}
+}
+
+func TestAgent_States(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	first, second := &statefulInput{state: "offset=10"}, &statefulInput{state: "offset=20"}
+	newStateAgent(dir, first, second).saveStates()
+
+	first, second = &statefulInput{}, &statefulInput{}
+	require.NoError(t, newStateAgent(dir, first, second).restoreStates())
+	require.Equal(t, "offset=10", first.state)
+	require.Equal(t, "offset=20", second.state)
+
+	// nothing is saved without a state directory
+	none := &statefulInput{}
+	require.NoError(t, newStateAgent("", none).restoreStates())
+	require.Equal(t, "", none.state)
+}
+
+func TestAgent_StatesCorrupt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	newStateAgent(dir, &statefulInput{state: "corrupt"}, &statefulInput{state: "offset=20"}).saveStates()
+
+	// the other inputs are still restored
+	first, second := &statefulInput{}, &statefulInput{}
+	require.NoError(t, newStateAgent(dir, first, second).restoreStates())
+	require.Equal(t, "", first.state)
+	require.Equal(t, "offset=20", second.state)
+}
+
+func TestAgent_StatesSharedName(t *testing.T) {
+	c := config.NewConfig()
+	for i := 0; i < 2; i++ {
+		c.Inputs = append(c.Inputs, models.NewRunningInput(&statefulInput{}, &models.InputConfig{Name: "stateful"}))
+	}
+	require.Empty(t, stateInputs(c.Inputs))
+}
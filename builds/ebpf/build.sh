@@ -0,0 +1,78 @@
+#!/usr/bin/env bash
+#
+# Compiles the CO-RE object of the ebpf_tcp input for each package arch into
+# objects/, the packages install the directory as etc/ebpf. Run by the
+# goreleaser before hooks, the build is optional: an arch is skipped with a
+# warning when the toolchain or its vmlinux.h is not available and the
+# packages are built without its object. Set EBPF_REQUIRED=1 to fail instead
+# (e.g. in a CI step dedicated to the objects).
+#
+# Requirements:
+# - clang
+# - libbpf-dev (bpf_helpers.h, bpf_tracing.h, bpf_core_read.h)
+# - bpftool, when VMLINUX_H_<arch> is not set
+#
+# VMLINUX_H_amd64 and VMLINUX_H_arm64 may be set to the vmlinux.h of each
+# arch, otherwise the vmlinux.h of the build host arch is dumped from its BTF.
+
+set -euo pipefail
+
+cd "$(dirname "$0")"
+
+SRC=../../plugins/inputs/ebpf_tcp/bpf/ebpf_tcp.bpf.c
+OUT=objects
+CLANG="${CLANG:-clang}"
+
+skip() {
+	if [[ "${EBPF_REQUIRED:-}" == "1" ]]; then
+		echo "error: $*" >&2
+		exit 1
+	fi
+	echo "warning: $*" >&2
+}
+
+case "$(uname -m)" in
+x86_64) HOST_ARCH=amd64 ;;
+aarch64) HOST_ARCH=arm64 ;;
+*) HOST_ARCH="" ;;
+esac
+
+rm -f "$OUT"/*.bpf.o
+
+if ! command -v "$CLANG" >/dev/null 2>&1; then
+	skip "$CLANG not found, ebpf_tcp objects not built"
+	exit 0
+fi
+if ! echo '#include <bpf/bpf_helpers.h>' | "$CLANG" -target bpf -x c -E - >/dev/null 2>&1; then
+	skip "libbpf headers not found, ebpf_tcp objects not built"
+	exit 0
+fi
+
+tmpdir="$(mktemp -d -t ebpf.XXXXXXXXXX)"
+
+on_exit() {
+	rm -rf "$tmpdir"
+}
+trap on_exit EXIT
+
+for arch in amd64 arm64; do
+	case "$arch" in
+	amd64) target=x86 ;;
+	arm64) target=arm64 ;;
+	esac
+
+	mkdir -p "$tmpdir/$arch"
+	header_var="VMLINUX_H_${arch}"
+	if [[ -n "${!header_var:-}" ]]; then
+		cp "${!header_var}" "$tmpdir/$arch/vmlinux.h"
+	elif [[ "$arch" == "$HOST_ARCH" ]] && command -v bpftool >/dev/null 2>&1 && [[ -r /sys/kernel/btf/vmlinux ]]; then
+		bpftool btf dump file /sys/kernel/btf/vmlinux format c >"$tmpdir/$arch/vmlinux.h"
+	else
+		skip "no vmlinux.h for ${arch} (set ${header_var}), ebpf_tcp_${arch}.bpf.o not built"
+		continue
+	fi
+
+	echo "Building ebpf_tcp_${arch}.bpf.o"
+	"$CLANG" -O2 -g -target bpf "-D__TARGET_ARCH_${target}" -I "$tmpdir/$arch" \
+		-c "$SRC" -o "$OUT/ebpf_tcp_${arch}.bpf.o"
+done
@@ -22,10 +22,12 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/config"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
 	"github.com/circonus-labs/circonus-unified-agent/internal/circonus"
+	"github.com/circonus-labs/circonus-unified-agent/internal/diagnostics"
 	"github.com/circonus-labs/circonus-unified-agent/internal/goplugin"
 	"github.com/circonus-labs/circonus-unified-agent/internal/release"
 	"github.com/circonus-labs/circonus-unified-agent/logger"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/aggregators/all"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/all"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
@@ -74,6 +76,8 @@ var fPlugins = flag.String("plugin-directory", "",
 	"path to directory containing external plugins")
 var fRunOnce = flag.Bool("once", false,
 	"run one gather and exit")
+var fSupervise = flag.Bool("supervise", false,
+	"run the agent as a child process, restarted with a backoff when it exits abnormally")
 
 var (
 	version   string
@@ -126,6 +130,7 @@ func runAgent(ctx context.Context,
 	outputFilters []string,
 ) error {
 	log.Printf("I! Starting Circonus Unified Agent %s", version)
+	reportSupervisorRestarts()
 
 	// If no other options are specified, load the config file and run.
 	c := config.NewConfig()
@@ -148,6 +153,9 @@ func runAgent(ctx context.Context,
 	if err := c.LoadDefaultPlugins(); err != nil {
 		return fmt.Errorf("loading defaults: %w", err)
 	}
+	if err := tls.SetPolicy(c.Agent.TLSPolicy); err != nil {
+		return fmt.Errorf("agent.tls_policy: %w", err)
+	}
 	// mgm: initialize the internal circonus cgm instance creator used by high-perf
 	// input plugins (ending in "_hp"). these input plugins send directly to circonus
 	// and DO NOT go through the normal agent pipeline (no aggregators, processors,
@@ -158,6 +166,7 @@ func runAgent(ctx context.Context,
 	if len(c.Tags) > 0 {
 		circonus.AddGlobalTags(c.Tags)
 	}
+	circonus.StartMetricFilterSync(ctx)
 
 	if !*fTest && len(c.Outputs) == 0 {
 		return fmt.Errorf("Error: no outputs found, did you provide a valid config file?")
@@ -202,6 +211,16 @@ func runAgent(ctx context.Context,
 		return ag.Test(ctx, wait)
 	}
 
+	diag, err := diagnostics.Start(c.Agent.Diagnostics)
+	if err != nil {
+		return fmt.Errorf("diagnostics: %w", err)
+	}
+	defer func() {
+		if err := diag.Close(); err != nil {
+			log.Printf("E! %s", err)
+		}
+	}()
+
 	log.Printf("I! Loaded inputs: %s", strings.Join(c.InputNames(), " "))
 	log.Printf("I! Loaded aggregators: %s", strings.Join(c.AggregatorNames(), " "))
 	log.Printf("I! Loaded processors: %s", strings.Join(c.ProcessorNames(), " "))
@@ -332,6 +351,8 @@ func main() {
 		case "version":
 			fmt.Println(formatFullVersion())
 			return
+		case "doctor":
+			os.Exit(runDoctor(args[1:], inputFilters, outputFilters))
 		case "config":
 			config.PrintSampleConfig(
 				sectionFilters,
@@ -5,6 +5,10 @@ package main
 
 func run(inputFilters, outputFilters, aggregatorFilters, processorFilters []string) {
 	stop = make(chan struct{})
+	if *fSupervise {
+		supervise()
+		return
+	}
 	reloadLoop(
 		inputFilters,
 		outputFilters,
@@ -28,6 +28,10 @@ func run(inputFilters, outputFilters, aggregatorFilters, processorFilters []stri
 		)
 	} else {
 		stop = make(chan struct{})
+		if *fSupervise {
+			supervise()
+			return
+		}
 		reloadLoop(
 			inputFilters,
 			outputFilters,
@@ -50,6 +54,10 @@ func (p *program) Start(s service.Service) error {
 }
 func (p *program) run() {
 	stop = make(chan struct{})
+	if *fSupervise {
+		supervise()
+		return
+	}
 	reloadLoop(
 		p.inputFilters,
 		p.outputFilters,
@@ -96,6 +104,9 @@ func runAsWindowsService(inputFilters, outputFilters, aggregatorFilters, process
 		}
 		// set servicename to service cmd line, to have a custom name after relaunch as a service
 		svcConfig.Arguments = append(svcConfig.Arguments, "--service-name", *fServiceName)
+		if *fSupervise {
+			svcConfig.Arguments = append(svcConfig.Arguments, "--supervise")
+		}
 
 		err := service.Control(s, *fService)
 		if err != nil {
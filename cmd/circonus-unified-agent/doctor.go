@@ -0,0 +1,146 @@
+//go:build go1.17
+// +build go1.17
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/internal/circonus"
+	"github.com/circonus-labs/circonus-unified-agent/internal/doctor"
+	"github.com/circonus-labs/circonus-unified-agent/logger"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+)
+
+// runDoctor runs the self-test of the configuration, credentials, outputs
+// and inputs, prints the report and returns the exit code, 1 when a check
+// failed
+func runDoctor(args []string, inputFilters, outputFilters []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	format := fs.String("format", "text", "report format, text or json")
+	timeout := fs.Duration("timeout", 30*time.Second, "maximum time of each connectivity probe")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "invalid format (%s), must be text or json\n", *format)
+		return 2
+	}
+
+	logger.SetupLogging(logger.LogConfig{Debug: *fDebug, Quiet: !*fDebug})
+
+	r := &doctor.Report{}
+	doctorChecks(r, inputFilters, outputFilters, *timeout)
+
+	var err error
+	if *format == "json" {
+		err = r.WriteJSON(os.Stdout)
+	} else {
+		err = r.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if r.Failed() {
+		return 1
+	}
+	return 0
+}
+
+func doctorChecks(r *doctor.Report, inputFilters, outputFilters []string, timeout time.Duration) {
+	r.Run("agent", "version", 0, func() (string, error) {
+		return formatFullVersion(), nil
+	})
+
+	c := config.NewConfig()
+	c.OutputFilters = outputFilters
+	c.InputFilters = inputFilters
+
+	loaded := r.Run("config", "load", 0, func() (string, error) {
+		if err := c.LoadConfig(*fConfig); err != nil {
+			return "", fmt.Errorf("loadconfig (%s): %w", *fConfig, err)
+		}
+		if *fConfigDirectory != "" {
+			if err := c.LoadDirectory(*fConfigDirectory); err != nil {
+				return "", fmt.Errorf("loaddir (%s): %w", *fConfigDirectory, err)
+			}
+		}
+		if err := c.LoadDefaultPlugins(); err != nil {
+			return "", fmt.Errorf("loading defaults: %w", err)
+		}
+		return fmt.Sprintf("%d inputs, %d processors, %d aggregators, %d outputs",
+			len(c.Inputs), len(c.Processors), len(c.Aggregators), len(c.Outputs)), nil
+	})
+	if !loaded {
+		return
+	}
+
+	if len(c.Outputs) == 0 {
+		r.Fail("config", "outputs", fmt.Errorf("no outputs found"))
+	}
+	if *fPlugins == "" && len(c.Inputs) == 0 {
+		r.Fail("config", "inputs", fmt.Errorf("no inputs found"))
+	}
+	if err := tls.SetPolicy(c.Agent.TLSPolicy); err != nil {
+		r.Fail("config", "tls_policy", err)
+	}
+	if c.Agent.Interval.Duration <= 0 || c.Agent.FlushInterval.Duration <= 0 {
+		r.Fail("config", "intervals", fmt.Errorf("interval (%s) and flush_interval (%s) must be positive",
+			c.Agent.Interval.Duration, c.Agent.FlushInterval.Duration))
+	}
+
+	initialized := r.Run("credentials", "circonus", 0, func() (string, error) {
+		if err := circonus.Initialize(c.GetGlobalCirconusConfig()); err != nil {
+			return "", fmt.Errorf("agent.circonus: %w", err)
+		}
+		return "api token configured", nil
+	})
+	if initialized {
+		r.Run("credentials", "api", timeout, func() (string, error) {
+			name, err := circonus.CheckAPI()
+			if err != nil {
+				return "", err
+			}
+			return "account " + name, nil
+		})
+	}
+
+	for _, output := range c.Outputs {
+		output := output
+		r.Run("outputs", output.LogName(), timeout, func() (string, error) {
+			if err := output.Init(); err != nil {
+				return "", err
+			}
+			if err := output.Output.Connect(); err != nil {
+				return "", fmt.Errorf("connect: %w", err)
+			}
+			output.Close()
+			return "connected", nil
+		})
+	}
+
+	for _, input := range c.Inputs {
+		input := input
+		r.Run("inputs", input.LogName(), 0, func() (string, error) {
+			return "initialized", input.Init()
+		})
+	}
+	for _, processor := range c.Processors {
+		processor := processor
+		r.Run("processors", processor.LogName(), 0, func() (string, error) {
+			return "initialized", processor.Init()
+		})
+	}
+	for _, aggregator := range c.Aggregators {
+		aggregator := aggregator
+		r.Run("aggregators", aggregator.LogName(), 0, func() (string, error) {
+			return "initialized", aggregator.Init()
+		})
+	}
+}
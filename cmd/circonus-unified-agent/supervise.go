@@ -0,0 +1,77 @@
+//go:build go1.17
+// +build go1.17
+
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal/supervisor"
+	"github.com/circonus-labs/circonus-unified-agent/selfstat"
+)
+
+// supervise runs the agent as a child process, restarted with a backoff
+// when it exits abnormally, until the supervisor is stopped
+func supervise() {
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("E! [supervisor] Unable to find executable: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+		case <-stop:
+		}
+		cancel()
+	}()
+
+	args := childArgs(os.Args[1:])
+	if runtime.GOOS == "windows" {
+		// the child of a supervising service is not started by the service
+		// manager
+		args = append(args, "--console")
+	}
+
+	s := &supervisor.Supervisor{
+		Command: append([]string{exe}, args...),
+	}
+	if err := s.Run(ctx); err != nil {
+		log.Fatalf("E! [supervisor] %v", err)
+	}
+}
+
+// childArgs returns the arguments of the supervised agent, without the
+// supervise flag
+func childArgs(args []string) []string {
+	child := make([]string, 0, len(args))
+	for _, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if strings.HasPrefix(arg, "-") && (name == "supervise" || strings.HasPrefix(name, "supervise=")) {
+			continue
+		}
+		child = append(child, arg)
+	}
+	return child
+}
+
+// reportSupervisorRestarts sets the internal_agent supervisor_restarts stat
+// of a supervised agent
+func reportSupervisorRestarts() {
+	if _, ok := os.LookupEnv(supervisor.RestartsEnv); !ok {
+		return
+	}
+	restarts := selfstat.Register("agent", "supervisor_restarts", map[string]string{})
+	restarts.Set(int64(supervisor.RestartsFromEnv()))
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/internal"
 	"github.com/circonus-labs/circonus-unified-agent/models"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/aggregators"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
@@ -91,6 +92,7 @@ func NewConfig() *Config {
 			Interval:                   internal.Duration{Duration: 10 * time.Second},
 			RoundInterval:              true,
 			FlushInterval:              internal.Duration{Duration: 10 * time.Second},
+			ShutdownTimeout:            internal.Duration{Duration: 10 * time.Second},
 			LogTarget:                  "file",
 			LogfileRotationMaxArchives: 5,
 		},
@@ -131,6 +133,11 @@ type AgentConfig struct {
 
 	Circonus CirconusConfig `toml:"circonus"`
 
+	Diagnostics DiagnosticsConfig `toml:"diagnostics"`
+
+	// TLSPolicy is enforced on the tls settings of all the plugins
+	TLSPolicy tls.Policy `toml:"tls_policy"`
+
 	// FlushInterval is the Interval at which to flush data
 	FlushInterval internal.Duration
 
@@ -180,6 +187,16 @@ type AgentConfig struct {
 	// Interval at which to gather information
 	Interval internal.Duration
 
+	// ShutdownTimeout is the maximum time to wait for in-progress collections
+	// to complete, and for outputs to perform their final flush, when the
+	// agent is stopped.  Metrics still pending after the timeout are dropped.
+	ShutdownTimeout internal.Duration `toml:"shutdown_timeout"`
+
+	// StateDirectory is the directory where inputs implementing
+	// cua.PersistentState save their state across restarts.  When empty the
+	// state is not saved.
+	StateDirectory string `toml:"state_directory"`
+
 	// Quiet is the option for running in quiet mode
 	Quiet bool `toml:"quiet"`
 
@@ -207,20 +224,48 @@ type AgentConfig struct {
 // TraceMetrics    - optional: output json sent to broker (path to write files to or `-` for logger)
 // DebugChecks     - optional: use when instructed by circonus support
 // CheckSearchTags - optional: set of tags to use when searching for checks (default: service:circonus-unified-agentd)
+// MetricFilters   - optional: check bundle metric filters, [["allow"|"deny", "regex", "comment"], ...]
+// MetricFilterSync         - optional: "push" metric_filters to check bundles or "pull" check bundle filters to apply locally
+// MetricFilterSyncInterval - optional: how often to sync metric filters (default: 5m)
+// SubmitMaxPayloadSize     - optional: split submissions larger than this into multiple payloads (default: no limit)
+// SubmitWorkers            - optional: number of payloads of a submission sent concurrently (default: 1)
+// SubmitRetries            - optional: number of times a failed payload is resubmitted, 0-3 (default: 0)
+// SubmitCompression        - optional: "auto" gzip payloads over 1KB, "gzip" all payloads or "none" (default: auto)
+// SubmitCompressionLevel   - optional: gzip level 1-9 with submit_compression "gzip" (default: 6)
+// BrokerCASHA256           - optional: SHA-256 fingerprints the broker CA cert fetched from the api must match
 type CirconusConfig struct {
-	DebugChecks     map[string]string `toml:"debug_checks"`
-	TraceMetrics    string            `toml:"trace_metrics"`
-	APIURL          string            `toml:"api_url"`
-	APIToken        string            `toml:"api_token"`
-	APIApp          string            `toml:"api_app"`
-	APITLSCA        string            `toml:"api_tls_ca"`
-	CacheDir        string            `toml:"cache_dir"`
-	Broker          string            `toml:"broker"`
-	Hostname        string            `toml:"-"`
-	CheckSearchTags []string          `toml:"check_search_tags"`
-	DebugAPI        bool              `toml:"debug_api"`
-	CacheNoVerify   bool              `toml:"cache_no_verify"`
-	CacheConfigs    bool              `toml:"cache_configs"`
+	DebugChecks              map[string]string `toml:"debug_checks"`
+	TraceMetrics             string            `toml:"trace_metrics"`
+	APIURL                   string            `toml:"api_url"`
+	APIToken                 string            `toml:"api_token"`
+	APIApp                   string            `toml:"api_app"`
+	APITLSCA                 string            `toml:"api_tls_ca"`
+	CacheDir                 string            `toml:"cache_dir"`
+	Broker                   string            `toml:"broker"`
+	Hostname                 string            `toml:"-"`
+	MetricFilterSync         string            `toml:"metric_filter_sync"`
+	CheckSearchTags          []string          `toml:"check_search_tags"`
+	BrokerCASHA256           []string          `toml:"broker_ca_sha256"`
+	MetricFilters            [][]string        `toml:"metric_filters"`
+	MetricFilterSyncInterval internal.Duration `toml:"metric_filter_sync_interval"`
+	SubmitMaxPayloadSize     internal.Size     `toml:"submit_max_payload_size"`
+	SubmitWorkers            int               `toml:"submit_workers"`
+	SubmitRetries            int               `toml:"submit_retries"`
+	SubmitCompression        string            `toml:"submit_compression"`
+	SubmitCompressionLevel   int               `toml:"submit_compression_level"`
+	DebugAPI                 bool              `toml:"debug_api"`
+	CacheNoVerify            bool              `toml:"cache_no_verify"`
+	CacheConfigs             bool              `toml:"cache_configs"`
+}
+
+// DiagnosticsConfig configures the diagnostics http server
+// Enabled   - optional: serve pprof, expvar and goroutine dumps (default: false)
+// Address   - optional: address to listen on, a missing host is localhost (default: localhost:6060)
+// AuthToken - optional: token required in requests, REQUIRED when address is not a loopback address
+type DiagnosticsConfig struct {
+	Address   string `toml:"address"`
+	AuthToken string `toml:"auth_token"`
+	Enabled   bool   `toml:"enabled"`
 }
 
 // InputNames returns a list of strings of the configured inputs.
@@ -356,6 +401,15 @@ var agentConfig = `
   ## ie, a jitter of 5s and interval 10s means flushes will happen every 10-15s
   flush_jitter = "0s"
 
+  ## Maximum time to wait on shutdown for in-progress collections to complete
+  ## and for outputs to flush their buffers.  Metrics not written before the
+  ## timeout are dropped and reported in the log.
+  # shutdown_timeout = "10s"
+
+  ## Directory where inputs save the state they resume from after a restart,
+  ## e.g. file offsets.  When empty the state is not saved.
+  # state_directory = ""
+
   ## By default or when set to "0s", precision will be set to the same
   ## timestamp order as the collection interval, with the maximum being 1s.
   ##   ie, when interval = "10s", precision will be "1s"
@@ -415,6 +469,15 @@ var agentConfig = `
     ## Explicit broker id or blank (default blank, auto select)
     # broker = "/broker/35"
 
+    ## Broker CA certificate pinning
+    ## Optional
+    ## The broker CA certificate is fetched from the Circonus API. When set,
+    ## the certificate must match one of these SHA-256 fingerprints, list the
+    ## fingerprint of a new certificate before it is rotated in. With
+    ## cache_configs the certificate is cached in cache_dir and used when the
+    ## API is unavailable.
+    # broker_ca_sha256 = []
+
     ## Cache check configurations
     ## Optional
     ## Performance optimization with lots of plugins (or instances of plugins)
@@ -434,6 +497,69 @@ var agentConfig = `
     ## Note: directory to write metrics sent to broker (must be writeable by user running cua process)
     ##       output json sent to broker (path to write files to or '-' for logger)
     # trace_metrics = "/opt/circonus/trace.d"
+
+    ## Metric filter sync
+    ## Optional
+    ## Manage the metric filters (allow/deny rules) of the check bundles the
+    ## agent sends metrics to. Rules are evaluated in order, the first rule
+    ## matching a metric name applies and metrics matching no rule are denied.
+    ##   "push" - set the metric_filters below on the check bundles
+    ##   "pull" - download the metric filters of the check bundles
+    ## In either mode the filters are also applied by the agent, so denied
+    ## metrics are not sent.
+    # metric_filter_sync = ""
+    # metric_filter_sync_interval = "5m"
+    # metric_filters = [
+    #   ["deny", "^cpu_time_", "cpu times"],
+    #   ["allow", ".+", "everything else"],
+    # ]
+
+    ## Metric submission
+    ## Optional
+    ## Submissions larger than submit_max_payload_size are split into multiple
+    ## payloads, submit_workers payloads are sent concurrently and a payload
+    ## that fails is resubmitted up to submit_retries times (0-3).
+    ## submit_compression "auto" gzips payloads over 1KB, "gzip" compresses
+    ## all payloads with submit_compression_level (1-9) and "none" sends them
+    ## uncompressed. With "auto" each submission of a payload is retried up
+    ## to 7 more times by the trap client, up to 8*(submit_retries+1)
+    ## requests, "gzip" and "none" make one request per submission.
+    # submit_max_payload_size = "0MB"
+    # submit_workers = 1
+    # submit_retries = 0
+    # submit_compression = "auto"
+    # submit_compression_level = 6
+
+  ## Diagnostics http server, serves net/http/pprof profiles under
+  ## /debug/pprof/, expvar under /debug/vars and goroutine stack dumps under
+  ## /debug/goroutines
+  # [agent.diagnostics]
+    ## Enable the diagnostics http server
+    # enabled = false
+
+    ## Address to listen on, without a host only localhost is listened on
+    # address = "localhost:6060"
+
+    ## Token required in requests as "Authorization: Bearer <token>" or a
+    ## "token" query parameter.
+    ## Optional (required if address is not a loopback address)
+    # auth_token = ""
+
+  ## TLS policy enforced on the tls settings of all plugins, plugin settings
+  ## weaker than the policy fail the plugin unless allow_plugin_override
+  # [agent.tls_policy]
+    ## Minimum TLS version, used by plugins not setting one
+    # min_version = "TLS12"
+
+    ## Allowed cipher suites, used by plugins not setting any
+    # cipher_suites = []
+
+    ## Reject insecure_skip_verify
+    # require_verify = false
+
+    ## Allow plugins to set a lower min version, other ciphers or
+    ## insecure_skip_verify
+    # allow_plugin_override = false
 `
 
 var outputHeader = `
@@ -1238,6 +1364,15 @@ func (c *Config) buildAggregator(name string, tbl *ast.Table) (*models.Aggregato
 		}
 	}
 
+	if node, ok := tbl.Fields["instance_labels"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			conf.InstanceLabels = make(map[string]string)
+			if err := c.toml.UnmarshalTable(subtbl, conf.InstanceLabels); err != nil {
+				return nil, fmt.Errorf("could not parse instance_labels for aggregator %s", name)
+			}
+		}
+	}
+
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
@@ -1328,6 +1463,15 @@ func (c *Config) buildInput(name string, tbl *ast.Table) (*models.InputConfig, e
 		}
 	}
 
+	if node, ok := tbl.Fields["instance_labels"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			cp.InstanceLabels = make(map[string]string)
+			if err := c.toml.UnmarshalTable(subtbl, cp.InstanceLabels); err != nil {
+				return nil, fmt.Errorf("could not parse instance_labels for input %s", name)
+			}
+		}
+	}
+
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
@@ -1480,22 +1624,31 @@ func (c *Config) buildOutput(name string, tbl *ast.Table) (*models.OutputConfig,
 		Filter: filter,
 	}
 
-	// TODO: support FieldPass/FieldDrop on outputs
-
 	c.getFieldDuration(tbl, "flush_interval", &oc.FlushInterval)
-	c.getFieldDuration(tbl, "flush_jitter", oc.FlushJitter)
+	c.getFieldDuration(tbl, "flush_jitter", &oc.FlushJitter)
 
 	c.getFieldInt(tbl, "metric_buffer_limit", &oc.MetricBufferLimit)
 	c.getFieldInt(tbl, "metric_batch_size", &oc.MetricBatchSize)
 	c.getFieldString(tbl, "alias", &oc.Alias)
+	// backfill alias with `instance_id` like inputs, so that instances of the
+	// same output with distinct filters are told apart in logs and stats
+	c.getFieldString(tbl, "instance_id", &oc.InstanceID)
+	if oc.Alias == "" {
+		oc.Alias = oc.InstanceID
+	}
 	c.getFieldString(tbl, "name_override", &oc.NameOverride)
 	c.getFieldString(tbl, "name_suffix", &oc.NameSuffix)
 	c.getFieldString(tbl, "name_prefix", &oc.NamePrefix)
+	c.getFieldString(tbl, "overflow_policy", &oc.OverflowPolicy)
 
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
 
+	if !models.ValidOverflowPolicy(oc.OverflowPolicy) {
+		return nil, fmt.Errorf("invalid overflow_policy (%s), must be drop-oldest, drop-newest or block", oc.OverflowPolicy)
+	}
+
 	return oc, nil
 }
 
@@ -1512,10 +1665,10 @@ func (c *Config) missingTomlField(typ reflect.Type, key string) error {
 		"grace", "graphite_separator", "graphite_tag_support", "grok_custom_pattern_files",
 		"grok_custom_patterns", "grok_named_patterns", "grok_patterns", "grok_timezone",
 		"grok_unique_timestamp", "influx_max_line_bytes", "influx_sort_fields", "influx_uint_support",
-		"interval", "json_name_key", "json_query", "json_strict", "json_string_fields",
-		"json_time_format", "json_time_key", "json_timestamp_units", "json_timezone",
+		"instance_labels", "interval", "json_name_key", "json_query", "json_strict",
+		"json_string_fields", "json_time_format", "json_time_key", "json_timestamp_units", "json_timezone",
 		"metric_batch_size", "metric_buffer_limit", "name_override", "name_prefix",
-		"name_suffix", "namedrop", "namepass", "order", "pass", "period", "precision",
+		"name_suffix", "namedrop", "namepass", "order", "overflow_policy", "pass", "period", "precision",
 		"prefix", "prometheus_export_timestamp", "prometheus_sort_metrics", "prometheus_string_as_label",
 		"separator", "splunkmetric_hec_routing", "splunkmetric_multimetric", "tag_keys",
 		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags", "template", "templates",
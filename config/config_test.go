@@ -13,7 +13,9 @@ import (
 	httplistenerv2 "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/http_listener_v2"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/memcached"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/procstat"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/discard"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -276,3 +278,41 @@ func TestConfig_BadOrdering(t *testing.T) {
 // 	assert.Equal(t, "", azureMonitor.NamespacePrefix)
 // 	assert.Equal(t, true, ok)
 // }
+
+func TestConfig_OutputRouting(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[outputs.discard]]
+  alias = "security"
+  namepass = ["auth*"]
+  [outputs.discard.tagpass]
+    team = ["secops"]
+
+[[outputs.discard]]
+  instance_id = "everything"
+  namedrop = ["debug*"]
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Outputs, 2)
+
+	security, everything := c.Outputs[0], c.Outputs[1]
+	require.Equal(t, "security", security.Config.Alias)
+	require.Equal(t, "everything", everything.Config.Alias)
+	require.Equal(t, "everything", everything.Config.InstanceID)
+
+	now := time.Now()
+	auth := testutil.MustMetric("auth_failures", map[string]string{"team": "secops"}, map[string]interface{}{"value": 1}, now)
+	authOther := testutil.MustMetric("auth_failures", map[string]string{"team": "web"}, map[string]interface{}{"value": 1}, now)
+	cpu := testutil.MustMetric("cpu", map[string]string{"team": "secops"}, map[string]interface{}{"value": 1}, now)
+	debug := testutil.MustMetric("debug_trace", map[string]string{}, map[string]interface{}{"value": 1}, now)
+
+	require.True(t, security.Config.Filter.Select(auth))
+	require.False(t, security.Config.Filter.Select(authOther))
+	require.False(t, security.Config.Filter.Select(cpu))
+	require.False(t, security.Config.Filter.Select(debug))
+
+	require.True(t, everything.Config.Filter.Select(auth))
+	require.True(t, everything.Config.Filter.Select(authOther))
+	require.True(t, everything.Config.Filter.Select(cpu))
+	require.False(t, everything.Config.Filter.Select(debug))
+}
@@ -23,3 +23,17 @@ type ServiceInput interface {
 	// to the accumulator before returning.
 	Stop()
 }
+
+// PersistentState is an interface that inputs can optionally implement to
+// keep a small state across restarts, e.g. file offsets or the end of the
+// last queried window.  It is only used when the agent state_directory is
+// set.
+type PersistentState interface {
+	// GetState returns the state to save, it is called once the input is
+	// stopped.
+	GetState() ([]byte, error)
+
+	// SetState restores the state saved by a previous run, it is called
+	// after Init and before the input is started.
+	SetState(state []byte) error
+}
@@ -0,0 +1,159 @@
+package circonus
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/go-apiclient"
+)
+
+//
+// Broker CA - trapcheck fetches the broker CA certificate from the api
+// when it builds the tls config for a broker. brokerCAClient intercepts that
+// request to verify the certificate against the configured SHA-256 pins and
+// to cache it, the cached certificate is used when the api is unavailable.
+// When the broker certificate is rotated trapcheck refreshes the check and
+// fetches the certificate again.
+//
+
+const (
+	brokerCAPath      = "/pki/ca.crt"
+	brokerCACacheFile = "broker_ca.pem"
+)
+
+// brokerCAClient satisfies trapcheck.API
+type brokerCAClient struct {
+	*apiclient.API
+}
+
+type brokerCACert struct {
+	Contents string `json:"contents"`
+}
+
+// validateBrokerCAConfig verifies the broker ca pins
+func validateBrokerCAConfig(cfg *config.CirconusConfig) error {
+	for _, pin := range cfg.BrokerCASHA256 {
+		if len(normalizePin(pin)) != sha256.Size*2 {
+			return fmt.Errorf("invalid broker_ca_sha256 (%s), expected a hex encoded SHA-256 fingerprint", pin)
+		}
+		if _, err := hex.DecodeString(normalizePin(pin)); err != nil {
+			return fmt.Errorf("invalid broker_ca_sha256 (%s): %w", pin, err)
+		}
+	}
+	return nil
+}
+
+// normalizePin lower cases a fingerprint and removes separators, so that
+// the output of e.g. openssl x509 -fingerprint -sha256 can be used
+func normalizePin(pin string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(pin))
+}
+
+func (c *brokerCAClient) Get(reqURL string) ([]byte, error) {
+	if reqURL != brokerCAPath {
+		return c.API.Get(reqURL)
+	}
+
+	data, err := c.API.Get(reqURL)
+	if err == nil {
+		var ca brokerCACert
+		if err = json.Unmarshal(data, &ca); err == nil {
+			if err = checkBrokerCAPins([]byte(ca.Contents)); err == nil {
+				saveBrokerCA([]byte(ca.Contents))
+				return data, nil
+			}
+			ch.logger.Errorf("broker ca cert from api rejected: %s", err)
+		}
+	}
+
+	cert := loadBrokerCA()
+	if cert == nil {
+		return nil, err
+	}
+	ch.logger.Warnf("using cached broker ca cert (%s)", err)
+
+	data, jerr := json.Marshal(brokerCACert{Contents: string(cert)})
+	if jerr != nil {
+		return nil, fmt.Errorf("json marshal cached broker ca cert: %w", jerr)
+	}
+	return data, nil
+}
+
+// checkBrokerCAPins verifies that a certificate in the pem data matches one
+// of the configured pins, more than one pin can be configured so that a new
+// certificate can be pinned before it is rotated in
+func checkBrokerCAPins(data []byte) error {
+	if len(ch.circCfg.BrokerCASHA256) == 0 {
+		return nil
+	}
+
+	pins := make(map[string]bool, len(ch.circCfg.BrokerCASHA256))
+	for _, pin := range ch.circCfg.BrokerCASHA256 {
+		pins[normalizePin(pin)] = true
+	}
+
+	var fingerprints []string
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("parse broker ca cert: %w", err)
+		}
+		sum := sha256.Sum256(block.Bytes)
+		fp := hex.EncodeToString(sum[:])
+		if pins[fp] {
+			return nil
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+
+	if len(fingerprints) == 0 {
+		return fmt.Errorf("no certificate found in broker ca cert")
+	}
+	return fmt.Errorf("broker ca cert sha256 %s does not match broker_ca_sha256", strings.Join(fingerprints, ","))
+}
+
+// loadBrokerCA returns the cached broker ca cert, if it is still pinned
+func loadBrokerCA() []byte {
+	if !ch.circCfg.CacheConfigs || ch.circCfg.CacheDir == "" {
+		return nil
+	}
+
+	file := filepath.Join(ch.circCfg.CacheDir, brokerCACacheFile)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ch.logger.Warnf("unable to read %s: %s", file, err)
+		}
+		return nil
+	}
+	if err := checkBrokerCAPins(data); err != nil {
+		ch.logger.Warnf("cached broker ca cert %s: %s", file, err)
+		return nil
+	}
+
+	return data
+}
+
+func saveBrokerCA(data []byte) {
+	if !ch.circCfg.CacheConfigs || ch.circCfg.CacheDir == "" {
+		return
+	}
+
+	file := filepath.Join(ch.circCfg.CacheDir, brokerCACacheFile)
+	if current, err := os.ReadFile(file); err == nil && string(current) == string(data) {
+		return
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil { //nolint:gosec
+		ch.logger.Warnf("save broker ca cert %s: %s", file, err)
+	}
+}
@@ -0,0 +1,96 @@
+package circonus
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA returns a pem encoded self signed certificate and its fingerprint
+func testCA(t *testing.T) ([]byte, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Circonus CA"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	sum := sha256.Sum256(der)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), hex.EncodeToString(sum[:])
+}
+
+func setTestConfig(t *testing.T, cfg *config.CirconusConfig) {
+	prev := ch
+	ch = &Circonus{circCfg: cfg, logger: testutil.Logger{}, ready: true}
+	t.Cleanup(func() { ch = prev })
+}
+
+func TestValidateBrokerCAConfig(t *testing.T) {
+	_, fp := testCA(t)
+	require.NoError(t, validateBrokerCAConfig(&config.CirconusConfig{}))
+	require.NoError(t, validateBrokerCAConfig(&config.CirconusConfig{BrokerCASHA256: []string{fp}}))
+
+	// openssl x509 -fingerprint -sha256 format
+	var parts []string
+	for i := 0; i < len(fp); i += 2 {
+		parts = append(parts, strings.ToUpper(fp[i:i+2]))
+	}
+	require.NoError(t, validateBrokerCAConfig(&config.CirconusConfig{BrokerCASHA256: []string{strings.Join(parts, ":")}}))
+
+	require.Error(t, validateBrokerCAConfig(&config.CirconusConfig{BrokerCASHA256: []string{"abc123"}}))
+	require.Error(t, validateBrokerCAConfig(&config.CirconusConfig{BrokerCASHA256: []string{strings.Repeat("z", 64)}}))
+}
+
+func TestCheckBrokerCAPins(t *testing.T) {
+	ca, fp := testCA(t)
+	newCA, newFP := testCA(t)
+
+	// no pins, anything goes
+	setTestConfig(t, &config.CirconusConfig{})
+	require.NoError(t, checkBrokerCAPins(ca))
+
+	setTestConfig(t, &config.CirconusConfig{BrokerCASHA256: []string{strings.ToUpper(fp)}})
+	require.NoError(t, checkBrokerCAPins(ca))
+	require.Error(t, checkBrokerCAPins(newCA))
+	require.Error(t, checkBrokerCAPins([]byte("not a cert")))
+
+	// rotation, both pinned
+	setTestConfig(t, &config.CirconusConfig{BrokerCASHA256: []string{fp, newFP}})
+	require.NoError(t, checkBrokerCAPins(newCA))
+}
+
+func TestBrokerCACache(t *testing.T) {
+	ca, fp := testCA(t)
+	newCA, _ := testCA(t)
+
+	setTestConfig(t, &config.CirconusConfig{
+		CacheConfigs:   true,
+		CacheDir:       t.TempDir(),
+		BrokerCASHA256: []string{fp},
+	})
+	require.Nil(t, loadBrokerCA())
+
+	saveBrokerCA(ca)
+	require.Equal(t, ca, loadBrokerCA())
+
+	// a cached cert no longer pinned is not used
+	saveBrokerCA(newCA)
+	require.Nil(t, loadBrokerCA())
+}
@@ -148,6 +148,16 @@ func Initialize(cfg *config.CirconusConfig, err error) error {
 	// 	c.circCfg.CheckSearchTags = []string{"_service:" + an}
 	// }
 
+	if err := validateMetricFilterConfig(c.circCfg); err != nil {
+		return fmt.Errorf("circonus metric destination management module: %w", err)
+	}
+	if err := validateSubmitConfig(c.circCfg); err != nil {
+		return fmt.Errorf("circonus metric destination management module: %w", err)
+	}
+	if err := validateBrokerCAConfig(c.circCfg); err != nil {
+		return fmt.Errorf("circonus metric destination management module: %w", err)
+	}
+
 	if c.circCfg.Hostname == "" {
 		hn, err := os.Hostname()
 		if err != nil || hn == "" {
@@ -212,6 +222,22 @@ func getAPIClient(opts *MetricDestConfig) (*apiclient.API, error) {
 	return client, nil
 }
 
+// CheckAPI verifies the API token and url by fetching the current account,
+// it returns the name of the account
+func CheckAPI() (string, error) {
+	client, err := getAPIClient(nil)
+	if err != nil {
+		return "", err
+	}
+
+	account, err := client.FetchAccount(nil)
+	if err != nil {
+		return "", fmt.Errorf("circonus metric destination management module: fetching account: %w", err)
+	}
+
+	return account.Name, nil
+}
+
 // createCheck retrieves, finds, or creates a Check bundle in Circonus and returns a trap check or an error
 func createCheck(cfg *trapcheck.Config) (*trapcheck.TrapCheck, error) {
 	if ch == nil {
@@ -382,7 +408,7 @@ func NewMetricDestination(opts *MetricDestConfig, logger cua.Logger) (*trapmetri
 
 	// Trap Check
 	tc := &trapcheck.Config{
-		Client:          circAPI,
+		Client:          &brokerCAClient{API: circAPI},
 		Logger:          instanceLogger,
 		CheckSearchTags: searchTags,
 		TraceMetrics:    traceMetrics,
@@ -482,6 +508,8 @@ func NewMetricDestination(opts *MetricDestConfig, logger cua.Logger) (*trapmetri
 		}
 	}
 
+	registerMetricFilters(destKey, circAPI, bundle, logger)
+
 	// if checks are going to a non-public trap
 	// cache the brokerTLS to use for other checks
 	// so that the api isn't hit for evevry check to pull the broker
@@ -500,7 +528,7 @@ func NewMetricDestination(opts *MetricDestConfig, logger cua.Logger) (*trapmetri
 
 	// Trap Metrics
 	tm := &trapmetrics.Config{
-		Trap:   tch,
+		Trap:   newSubmitter(tch, ch.circCfg, logger),
 		Logger: instanceLogger,
 	}
 	metrics, err := createMetrics(tm)
@@ -0,0 +1,237 @@
+package circonus
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/go-apiclient"
+)
+
+//
+// Metric filter sync - keeps the metric filters (allow/deny rules) of the
+// check bundles used as metric destinations in step with the agent config
+// (push), or downloads the check bundle filters (pull). The filters in effect
+// are also applied locally, see MetricAllowed.
+//
+
+const (
+	MetricFilterSyncPush = "push"
+	MetricFilterSyncPull = "pull"
+
+	defaultMetricFilterSyncInterval = 5 * time.Minute
+)
+
+type metricFilterRule struct {
+	rx    *regexp.Regexp
+	allow bool
+}
+
+type metricFilterDest struct {
+	client *apiclient.API
+	logger cua.Logger
+	cid    string
+	rules  []metricFilterRule
+}
+
+var (
+	metricFilterDests   = make(map[string]*metricFilterDest)
+	metricFilterDestsMu sync.RWMutex
+)
+
+// validateMetricFilterConfig verifies the metric filter sync settings
+func validateMetricFilterConfig(cfg *config.CirconusConfig) error {
+	switch cfg.MetricFilterSync {
+	case "", MetricFilterSyncPull:
+	case MetricFilterSyncPush:
+		if len(cfg.MetricFilters) == 0 {
+			return fmt.Errorf("metric_filter_sync push, no metric_filters set")
+		}
+	default:
+		return fmt.Errorf("invalid metric_filter_sync (%s), must be push or pull", cfg.MetricFilterSync)
+	}
+
+	if _, err := compileMetricFilters(cfg.MetricFilters); err != nil {
+		return fmt.Errorf("metric_filters: %w", err)
+	}
+
+	return nil
+}
+
+// compileMetricFilters compiles check bundle style metric filters,
+// [type, regex, comment] where type is allow or deny
+func compileMetricFilters(filters [][]string) ([]metricFilterRule, error) {
+	rules := make([]metricFilterRule, 0, len(filters))
+	for i, filter := range filters {
+		if len(filter) < 2 {
+			return nil, fmt.Errorf("filter %d: expected [type, regex, comment], got %v", i, filter)
+		}
+		var allow bool
+		switch filter[0] {
+		case "allow":
+			allow = true
+		case "deny":
+		default:
+			return nil, fmt.Errorf("filter %d: invalid type (%s), must be allow or deny", i, filter[0])
+		}
+		rx, err := regexp.Compile(filter[1])
+		if err != nil {
+			return nil, fmt.Errorf("filter %d: %w", i, err)
+		}
+		rules = append(rules, metricFilterRule{rx: rx, allow: allow})
+	}
+	return rules, nil
+}
+
+// normalizeMetricFilters returns filters with the optional comment filled in
+// so that filters from the config and the api can be compared
+func normalizeMetricFilters(filters [][]string) [][]string {
+	normalized := make([][]string, 0, len(filters))
+	for _, filter := range filters {
+		f := []string{"", "", ""}
+		copy(f, filter)
+		normalized = append(normalized, f)
+	}
+	return normalized
+}
+
+func metricFiltersEqual(a, b [][]string) bool {
+	a, b = normalizeMetricFilters(a), normalizeMetricFilters(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// registerMetricFilters adds a metric destination to the metric filter sync
+// and brings its check bundle filters in step with the configured mode
+func registerMetricFilters(destKey string, client *apiclient.API, bundle *apiclient.CheckBundle, logger cua.Logger) {
+	if ch.circCfg.MetricFilterSync == "" {
+		return
+	}
+
+	fd := &metricFilterDest{
+		client: client,
+		logger: logger,
+		cid:    bundle.CID,
+	}
+
+	metricFilterDestsMu.Lock()
+	metricFilterDests[destKey] = fd
+	metricFilterDestsMu.Unlock()
+
+	if err := syncMetricFilters(destKey, fd, bundle); err != nil {
+		logger.Warnf("circonus metric destination management module: metric filter sync %s: %s", bundle.CID, err)
+	}
+}
+
+// syncMetricFilters pushes the configured metric filters to, or pulls the
+// metric filters from, the check bundle of a metric destination
+func syncMetricFilters(destKey string, fd *metricFilterDest, bundle *apiclient.CheckBundle) error {
+	if bundle == nil {
+		cid := fd.cid
+		b, err := fd.client.FetchCheckBundle(apiclient.CIDType(&cid))
+		if err != nil {
+			return fmt.Errorf("fetch check bundle: %w", err)
+		}
+		bundle = b
+	}
+
+	var filters [][]string
+	switch ch.circCfg.MetricFilterSync {
+	case MetricFilterSyncPush:
+		filters = ch.circCfg.MetricFilters
+		if !metricFiltersEqual(bundle.MetricFilters, filters) {
+			fd.logger.Infof("updating metric filters of check bundle %s", bundle.CID)
+			bundle.MetricFilters = filters
+			b, err := fd.client.UpdateCheckBundle(bundle)
+			if err != nil {
+				return fmt.Errorf("update check bundle: %w", err)
+			}
+			saveCheckConfig(destKey, b)
+		}
+	case MetricFilterSyncPull:
+		filters = bundle.MetricFilters
+	}
+
+	rules, err := compileMetricFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	metricFilterDestsMu.Lock()
+	fd.rules = rules
+	metricFilterDestsMu.Unlock()
+
+	return nil
+}
+
+// StartMetricFilterSync periodically syncs the metric filters of the
+// registered metric destinations until ctx is done, it does nothing when
+// metric_filter_sync is not set
+func StartMetricFilterSync(ctx context.Context) {
+	if ch == nil || !ch.ready || ch.circCfg.MetricFilterSync == "" {
+		return
+	}
+
+	interval := ch.circCfg.MetricFilterSyncInterval.Duration
+	if interval <= 0 {
+		interval = defaultMetricFilterSyncInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				metricFilterDestsMu.RLock()
+				dests := make(map[string]*metricFilterDest, len(metricFilterDests))
+				for k, v := range metricFilterDests {
+					dests[k] = v
+				}
+				metricFilterDestsMu.RUnlock()
+
+				for destKey, fd := range dests {
+					if err := syncMetricFilters(destKey, fd, nil); err != nil {
+						fd.logger.Warnf("circonus metric destination management module: metric filter sync %s: %s", fd.cid, err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// MetricAllowed reports whether the metric filters of a metric destination
+// allow a metric. The first rule matching the name applies, a name matching
+// no rule is denied. Destinations without filters allow all metrics.
+func MetricAllowed(destKey, name string) bool {
+	metricFilterDestsMu.RLock()
+	defer metricFilterDestsMu.RUnlock()
+
+	fd, ok := metricFilterDests[destKey]
+	if !ok || len(fd.rules) == 0 {
+		return true
+	}
+
+	for _, rule := range fd.rules {
+		if rule.rx.MatchString(name) {
+			return rule.allow
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package circonus
+
+import (
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMetricFilterConfig(t *testing.T) {
+	filters := [][]string{{"deny", "^cpu_time_", "cpu times"}, {"allow", ".+"}}
+
+	require.NoError(t, validateMetricFilterConfig(&config.CirconusConfig{}))
+	require.NoError(t, validateMetricFilterConfig(&config.CirconusConfig{MetricFilterSync: "pull"}))
+	require.NoError(t, validateMetricFilterConfig(&config.CirconusConfig{MetricFilterSync: "push", MetricFilters: filters}))
+
+	require.Error(t, validateMetricFilterConfig(&config.CirconusConfig{MetricFilterSync: "push"}))
+	require.Error(t, validateMetricFilterConfig(&config.CirconusConfig{MetricFilterSync: "both"}))
+	require.Error(t, validateMetricFilterConfig(&config.CirconusConfig{MetricFilters: [][]string{{"permit", ".+"}}}))
+	require.Error(t, validateMetricFilterConfig(&config.CirconusConfig{MetricFilters: [][]string{{"allow", "("}}}))
+	require.Error(t, validateMetricFilterConfig(&config.CirconusConfig{MetricFilters: [][]string{{"allow"}}}))
+}
+
+func TestMetricFiltersEqual(t *testing.T) {
+	require.True(t, metricFiltersEqual(
+		[][]string{{"deny", "^$", ""}, {"allow", "^.+$", ""}},
+		[][]string{{"deny", "^$"}, {"allow", "^.+$"}}))
+	require.False(t, metricFiltersEqual(
+		[][]string{{"deny", "^$", ""}, {"allow", "^.+$", ""}},
+		[][]string{{"allow", "^.+$"}}))
+	require.False(t, metricFiltersEqual(
+		[][]string{{"allow", "^.+$", "all"}},
+		[][]string{{"allow", "^.+$", "everything"}}))
+}
+
+func TestMetricAllowed(t *testing.T) {
+	rules, err := compileMetricFilters([][]string{
+		{"allow", "^cpu_time_idle$"},
+		{"deny", "^cpu_time_"},
+		{"allow", "^cpu_"},
+	})
+	require.NoError(t, err)
+
+	metricFilterDestsMu.Lock()
+	metricFilterDests["cpu:host:::"] = &metricFilterDest{rules: rules}
+	metricFilterDests["mem:host:::"] = &metricFilterDest{}
+	metricFilterDestsMu.Unlock()
+	defer func() {
+		metricFilterDestsMu.Lock()
+		delete(metricFilterDests, "cpu:host:::")
+		delete(metricFilterDests, "mem:host:::")
+		metricFilterDestsMu.Unlock()
+	}()
+
+	require.True(t, MetricAllowed("cpu:host:::", "cpu_time_idle"))
+	require.False(t, MetricAllowed("cpu:host:::", "cpu_time_user"))
+	require.True(t, MetricAllowed("cpu:host:::", "cpu_usage_user"))
+	// no matching rule
+	require.False(t, MetricAllowed("cpu:host:::", "load1"))
+	// destinations without filters, or not synced, allow everything
+	require.True(t, MetricAllowed("mem:host:::", "used"))
+	require.True(t, MetricAllowed("disk:host:::", "used"))
+}
@@ -0,0 +1,388 @@
+package circonus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/selfstat"
+	"github.com/circonus-labs/go-apiclient"
+	apiclicfg "github.com/circonus-labs/go-apiclient/config"
+	"github.com/circonus-labs/go-trapcheck"
+	"github.com/circonus-labs/go-trapmetrics"
+)
+
+//
+// Metric submission - splits submissions larger than the maximum payload
+// size into multiple payloads, sends the payloads concurrently and
+// resubmits failed payloads.
+//
+// With the default compression the payloads are sent by go-trapcheck, it
+// gzips payloads over 1KB and retries each request up to 7 times itself, so
+// every (re)submission of a payload may be up to 8 requests. With
+// submit_compression set to gzip or none the payloads are put to the broker
+// directly with one request per submission, only a 404 (check moved) is
+// handed to go-trapcheck to refresh the check.
+//
+
+const (
+	submitRetryDelay = time.Second
+	// maxSubmitRetries caps submit_retries, go-trapcheck already retries
+	// each request so at most 8*(maxSubmitRetries+1) requests are made
+	// for a payload
+	maxSubmitRetries = 3
+
+	compressionAuto = "auto"
+	compressionGzip = "gzip"
+	compressionNone = "none"
+)
+
+// brokerTrap is a trap which can be used to put payloads to the broker
+// directly, satisfied by trapcheck.TrapCheck
+type brokerTrap interface {
+	trapmetrics.Trap
+	GetCheckBundle() (apiclient.CheckBundle, error)
+	GetBrokerTLSConfig() (*tls.Config, error)
+}
+
+// submitter satisfies trapmetrics.Trap, it sends metrics using a trap check
+type submitter struct {
+	trap        trapmetrics.Trap
+	logger      cua.Logger
+	payloads    selfstat.Stat
+	bytesSent   selfstat.Stat
+	splits      selfstat.Stat
+	retries     selfstat.Stat
+	errors      selfstat.Stat
+	compression string
+	level       int
+	maxPayload  int
+	workers     int
+	maxRetries  int
+}
+
+// validateSubmitConfig verifies the submission settings
+func validateSubmitConfig(cfg *config.CirconusConfig) error {
+	if cfg.SubmitMaxPayloadSize.Size < 0 {
+		return fmt.Errorf("invalid submit_max_payload_size (%d)", cfg.SubmitMaxPayloadSize.Size)
+	}
+	if cfg.SubmitWorkers < 0 {
+		return fmt.Errorf("invalid submit_workers (%d)", cfg.SubmitWorkers)
+	}
+	if cfg.SubmitRetries < 0 || cfg.SubmitRetries > maxSubmitRetries {
+		return fmt.Errorf("invalid submit_retries (%d), must be 0-%d", cfg.SubmitRetries, maxSubmitRetries)
+	}
+	switch cfg.SubmitCompression {
+	case "", compressionAuto, compressionGzip, compressionNone:
+	default:
+		return fmt.Errorf("invalid submit_compression (%s), must be auto, gzip or none", cfg.SubmitCompression)
+	}
+	if cfg.SubmitCompressionLevel < 0 || cfg.SubmitCompressionLevel > gzip.BestCompression {
+		return fmt.Errorf("invalid submit_compression_level (%d), must be 1-9", cfg.SubmitCompressionLevel)
+	}
+	return nil
+}
+
+func newSubmitter(trap trapmetrics.Trap, cfg *config.CirconusConfig, logger cua.Logger) *submitter {
+	workers := cfg.SubmitWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	compression := cfg.SubmitCompression
+	if compression == "" {
+		compression = compressionAuto
+	}
+	level := cfg.SubmitCompressionLevel
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return &submitter{
+		trap:        trap,
+		logger:      logger,
+		compression: compression,
+		level:       level,
+		maxPayload:  int(cfg.SubmitMaxPayloadSize.Size),
+		workers:     workers,
+		maxRetries:  cfg.SubmitRetries,
+		payloads:    selfstat.Register("circonus_submit", "payloads", nil),
+		bytesSent:   selfstat.Register("circonus_submit", "bytes_sent", nil),
+		splits:      selfstat.Register("circonus_submit", "splits", nil),
+		retries:     selfstat.Register("circonus_submit", "retries", nil),
+		errors:      selfstat.Register("circonus_submit", "errors", nil),
+	}
+}
+
+// SendMetrics submits metrics, a JSON object in httptrap format, as one or
+// more payloads. The result combines the results of all of the payloads.
+func (s *submitter) SendMetrics(ctx context.Context, metrics bytes.Buffer) (*trapcheck.TrapResult, error) {
+	payloads := [][]byte{metrics.Bytes()}
+	if s.maxPayload > 0 && metrics.Len() > s.maxPayload {
+		var err error
+		payloads, err = splitPayload(metrics.Bytes(), s.maxPayload)
+		if err != nil {
+			return nil, err
+		}
+		s.splits.Incr(1)
+		s.logger.Debugf("split %d byte submission into %d payloads", metrics.Len(), len(payloads))
+	}
+
+	results := make([]*trapcheck.TrapResult, len(payloads))
+	errs := make([]error, len(payloads))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.workers)
+	for i, payload := range payloads {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, payload []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = s.send(ctx, payload)
+		}(i, payload)
+	}
+	wg.Wait()
+
+	return mergeResults(results, errs)
+}
+
+// send submits a payload, resubmitting it up to maxRetries times
+func (s *submitter) send(ctx context.Context, payload []byte) (*trapcheck.TrapResult, error) {
+	var err error
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		if attempt > 0 {
+			s.retries.Incr(1)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(submitRetryDelay * time.Duration(attempt)):
+			}
+		}
+
+		var result *trapcheck.TrapResult
+		if bt, ok := s.trap.(brokerTrap); ok && s.compression != compressionAuto {
+			result, err = s.put(ctx, bt, payload)
+		} else {
+			result, err = s.trap.SendMetrics(ctx, *bytes.NewBuffer(payload))
+		}
+		if err == nil {
+			s.payloads.Incr(1)
+			s.bytesSent.Incr(int64(result.BytesSent))
+			return result, nil
+		}
+		s.logger.Debugf("submitting payload (attempt %d of %d): %s", attempt+1, s.maxRetries+1, err)
+	}
+
+	s.errors.Incr(1)
+	return nil, err
+}
+
+// put sends a payload to the submission url of the check with the configured
+// compression, a 404 is handed to the trap check so the check is refreshed
+func (s *submitter) put(ctx context.Context, bt brokerTrap, payload []byte) (*trapcheck.TrapResult, error) {
+	start := time.Now()
+
+	bundle, err := bt.GetCheckBundle()
+	if err != nil {
+		return nil, fmt.Errorf("submitting payload: %w", err)
+	}
+	submitURL, ok := bundle.Config[apiclicfg.SubmissionURL]
+	if !ok {
+		return nil, fmt.Errorf("submitting payload: no submission url in check bundle %s", bundle.CID)
+	}
+	tlsConfig, err := bt.GetBrokerTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("submitting payload: %w", err)
+	}
+
+	data := payload
+	if s.compression == compressionGzip {
+		var buf bytes.Buffer
+		zw, err := gzip.NewWriterLevel(&buf, s.level)
+		if err != nil {
+			return nil, fmt.Errorf("compressing payload: %w", err)
+		}
+		if _, err := zw.Write(payload); err != nil {
+			return nil, fmt.Errorf("compressing payload: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("compressing payload: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, submitURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", internal.ProductToken())
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	if s.compression == compressionGzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout: 10 * time.Second,
+			}).DialContext,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+			DisableKeepAlives:   true,
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	reqStart := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		s.logger.Warnf("%s - %s: refreshing check", resp.Status, submitURL)
+		return s.trap.SendMetrics(ctx, *bytes.NewBuffer(payload))
+	default:
+		return nil, fmt.Errorf("%s - %s", resp.Status, submitURL)
+	}
+
+	var result trapcheck.TrapResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parsing response (%s): %w", string(body), err)
+	}
+	if len(bundle.CheckUUIDs) > 0 {
+		result.CheckUUID = bundle.CheckUUIDs[0]
+	}
+	result.SubmitUUID = "n/a"
+	result.SubmitDuration = time.Since(start)
+	result.LastReqDuration = time.Since(reqStart)
+	result.BytesSent = len(data)
+	if result.Error == "" {
+		result.Error = "none"
+	}
+
+	return &result, nil
+}
+
+// mergeResults combines the results of the payloads of a submission, if any
+// payload failed the submission fails
+func mergeResults(results []*trapcheck.TrapResult, errs []error) (*trapcheck.TrapResult, error) {
+	var failed int
+	var firstErr error
+	for _, err := range errs {
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if failed > 0 {
+		if len(errs) == 1 {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("%d of %d payloads failed: %w", failed, len(errs), firstErr)
+	}
+
+	merged := &trapcheck.TrapResult{}
+	for _, r := range results {
+		if merged.CheckUUID == "" {
+			merged.CheckUUID = r.CheckUUID
+			merged.SubmitUUID = r.SubmitUUID
+		}
+		if merged.Error == "" {
+			merged.Error = r.Error
+		}
+		merged.Stats += r.Stats
+		merged.Filtered += r.Filtered
+		merged.BytesSent += r.BytesSent
+		if r.SubmitDuration > merged.SubmitDuration {
+			merged.SubmitDuration = r.SubmitDuration
+		}
+		if r.LastReqDuration > merged.LastReqDuration {
+			merged.LastReqDuration = r.LastReqDuration
+		}
+	}
+
+	return merged, nil
+}
+
+// splitPayload splits a JSON object in httptrap format into objects of at
+// most maxSize bytes. A metric may appear more than once (samples with
+// different timestamps) so the object is read as a stream rather than into
+// a map. A single metric larger than maxSize is sent in a payload by itself.
+func splitPayload(data []byte, maxSize int) ([][]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("splitting payload: expected JSON object")
+	}
+
+	var payloads [][]byte
+	var buf bytes.Buffer
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		buf.WriteByte('}')
+		payloads = append(payloads, append([]byte(nil), buf.Bytes()...))
+		buf.Reset()
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("splitting payload: %w", err)
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("splitting payload: expected metric name, got %v", tok)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("splitting payload (%s): %w", key, err)
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, fmt.Errorf("splitting payload (%s): %w", key, err)
+		}
+
+		// separator (or opening brace), name:value and closing brace
+		size := 1 + len(name) + 1 + len(value) + 1
+		if buf.Len() > 0 && buf.Len()+size > maxSize {
+			flush()
+		}
+		if buf.Len() == 0 {
+			buf.WriteByte('{')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	flush()
+
+	return payloads, nil
+}
@@ -0,0 +1,213 @@
+package circonus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/circonus-labs/go-apiclient"
+	apiclicfg "github.com/circonus-labs/go-apiclient/config"
+	"github.com/circonus-labs/go-trapcheck"
+	"github.com/stretchr/testify/require"
+)
+
+const testPayload = `{"cpu|ST[host:a]":{"_type":"n","_ts":1,"_value":1},` +
+	`"cpu|ST[host:a]":{"_type":"n","_ts":2,"_value":2},` +
+	`"mem":{"_type":"L","_ts":1,"_value":1024},` +
+	`"msg":{"_type":"s","_ts":1,"_value":"a \"quoted\" value"}}`
+
+func TestSplitPayload(t *testing.T) {
+	payloads, err := splitPayload([]byte(testPayload), 100)
+	require.NoError(t, err)
+	require.Len(t, payloads, 3)
+
+	var names []string
+	for _, p := range payloads {
+		require.LessOrEqual(t, len(p), 100)
+		require.True(t, json.Valid(p), string(p))
+		dec := json.NewDecoder(bytes.NewReader(p))
+		_, _ = dec.Token()
+		for dec.More() {
+			tok, err := dec.Token()
+			require.NoError(t, err)
+			names = append(names, tok.(string))
+			var v json.RawMessage
+			require.NoError(t, dec.Decode(&v))
+		}
+	}
+	// duplicate names (samples) are kept
+	require.Equal(t, []string{"cpu|ST[host:a]", "cpu|ST[host:a]", "mem", "msg"}, names)
+
+	payloads, err = splitPayload([]byte(testPayload), 1024)
+	require.NoError(t, err)
+	require.Len(t, payloads, 1)
+
+	// a metric larger than the limit is sent by itself
+	payloads, err = splitPayload([]byte(testPayload), 10)
+	require.NoError(t, err)
+	require.Len(t, payloads, 4)
+
+	_, err = splitPayload([]byte(`["not an object"]`), 10)
+	require.Error(t, err)
+}
+
+type fakeTrap struct {
+	fail     map[int]bool
+	payloads []string
+	calls    int
+	mu       sync.Mutex
+}
+
+func (f *fakeTrap) SendMetrics(ctx context.Context, metrics bytes.Buffer) (*trapcheck.TrapResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.fail[f.calls] {
+		return nil, errors.New("broker unavailable")
+	}
+	f.payloads = append(f.payloads, metrics.String())
+	return &trapcheck.TrapResult{CheckUUID: "abc", Stats: 2, BytesSent: metrics.Len()}, nil
+}
+
+func TestSubmitterSplitsPayloads(t *testing.T) {
+	trap := &fakeTrap{}
+	s := newSubmitter(trap, &config.CirconusConfig{
+		SubmitMaxPayloadSize: internal.Size{Size: 150},
+		SubmitWorkers:        2,
+	}, testutil.Logger{})
+
+	result, err := s.SendMetrics(context.Background(), *bytes.NewBufferString(testPayload))
+	require.NoError(t, err)
+	require.Len(t, trap.payloads, 2)
+	require.Equal(t, "abc", result.CheckUUID)
+	require.Equal(t, uint64(4), result.Stats)
+	require.Equal(t, len(trap.payloads[0])+len(trap.payloads[1]), result.BytesSent)
+}
+
+func TestSubmitterRetries(t *testing.T) {
+	trap := &fakeTrap{fail: map[int]bool{1: true}}
+	s := newSubmitter(trap, &config.CirconusConfig{SubmitRetries: 1}, testutil.Logger{})
+
+	_, err := s.SendMetrics(context.Background(), *bytes.NewBufferString(testPayload))
+	require.NoError(t, err)
+	require.Equal(t, 2, trap.calls)
+	require.Equal(t, []string{testPayload}, trap.payloads)
+
+	trap = &fakeTrap{fail: map[int]bool{1: true}}
+	s = newSubmitter(trap, &config.CirconusConfig{}, testutil.Logger{})
+	_, err = s.SendMetrics(context.Background(), *bytes.NewBufferString(testPayload))
+	require.Error(t, err)
+	require.Equal(t, 1, trap.calls)
+}
+
+// fakeBrokerTrap puts payloads to a test broker, SendMetrics is the
+// go-trapcheck path
+type fakeBrokerTrap struct {
+	fakeTrap
+	url string
+}
+
+func (f *fakeBrokerTrap) GetCheckBundle() (apiclient.CheckBundle, error) {
+	return apiclient.CheckBundle{
+		CID:        "/check_bundle/1",
+		CheckUUIDs: []string{"abc"},
+		Config:     apiclient.CheckBundleConfig{apiclicfg.SubmissionURL: f.url},
+	}, nil
+}
+
+func (f *fakeBrokerTrap) GetBrokerTLSConfig() (*tls.Config, error) {
+	return nil, nil
+}
+
+func TestSubmitterCompression(t *testing.T) {
+	var encoding string
+	var received []byte
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		encoding = r.Header.Get("Content-Encoding")
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if encoding == "gzip" {
+			zr, err := gzip.NewReader(bytes.NewReader(body))
+			require.NoError(t, err)
+			body, err = ioutil.ReadAll(zr)
+			require.NoError(t, err)
+		}
+		received = body
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"stats":4}`))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		compression string
+		level       int
+		encoding    string
+	}{
+		{compression: "gzip", encoding: "gzip"},
+		{compression: "gzip", level: 9, encoding: "gzip"},
+		{compression: "none", encoding: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			trap := &fakeBrokerTrap{url: ts.URL}
+			s := newSubmitter(trap, &config.CirconusConfig{
+				SubmitCompression:      tt.compression,
+				SubmitCompressionLevel: tt.level,
+			}, testutil.Logger{})
+
+			result, err := s.SendMetrics(context.Background(), *bytes.NewBufferString(testPayload))
+			require.NoError(t, err)
+			require.Equal(t, tt.encoding, encoding)
+			require.Equal(t, testPayload, string(received))
+			require.Equal(t, "abc", result.CheckUUID)
+			require.Equal(t, uint64(4), result.Stats)
+			require.Equal(t, 0, trap.calls)
+		})
+	}
+
+	// the default is sent by the trap check
+	trap := &fakeBrokerTrap{url: ts.URL}
+	s := newSubmitter(trap, &config.CirconusConfig{}, testutil.Logger{})
+	_, err := s.SendMetrics(context.Background(), *bytes.NewBufferString(testPayload))
+	require.NoError(t, err)
+	require.Equal(t, 1, trap.calls)
+
+	// a 404 is handed to the trap check to refresh the check
+	status = http.StatusNotFound
+	trap = &fakeBrokerTrap{url: ts.URL}
+	s = newSubmitter(trap, &config.CirconusConfig{SubmitCompression: "none"}, testutil.Logger{})
+	_, err = s.SendMetrics(context.Background(), *bytes.NewBufferString(testPayload))
+	require.NoError(t, err)
+	require.Equal(t, 1, trap.calls)
+
+	status = http.StatusInternalServerError
+	trap = &fakeBrokerTrap{url: ts.URL}
+	s = newSubmitter(trap, &config.CirconusConfig{SubmitCompression: "gzip"}, testutil.Logger{})
+	_, err = s.SendMetrics(context.Background(), *bytes.NewBufferString(testPayload))
+	require.Error(t, err)
+	require.Equal(t, 0, trap.calls)
+}
+
+func TestValidateSubmitConfig(t *testing.T) {
+	require.NoError(t, validateSubmitConfig(&config.CirconusConfig{}))
+	require.NoError(t, validateSubmitConfig(&config.CirconusConfig{SubmitCompression: "gzip", SubmitCompressionLevel: 1}))
+	require.Error(t, validateSubmitConfig(&config.CirconusConfig{SubmitWorkers: -1}))
+	require.Error(t, validateSubmitConfig(&config.CirconusConfig{SubmitRetries: -1}))
+	require.Error(t, validateSubmitConfig(&config.CirconusConfig{SubmitRetries: maxSubmitRetries + 1}))
+	require.Error(t, validateSubmitConfig(&config.CirconusConfig{SubmitMaxPayloadSize: internal.Size{Size: -1}}))
+	require.Error(t, validateSubmitConfig(&config.CirconusConfig{SubmitCompression: "zstd"}))
+	require.Error(t, validateSubmitConfig(&config.CirconusConfig{SubmitCompressionLevel: 10}))
+}
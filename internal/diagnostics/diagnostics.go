@@ -0,0 +1,179 @@
+// Package diagnostics provides an http server exposing runtime diagnostics,
+// net/http/pprof profiles, expvar variables and goroutine stack dumps, so that
+// an agent can be debugged in the field without a custom build.
+package diagnostics
+
+import (
+	"context"
+	"crypto/subtle"
+	"expvar"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+)
+
+const (
+	defaultAddress  = "localhost:6060"
+	shutdownTimeout = 5 * time.Second
+)
+
+var publishOnce sync.Once
+
+// Server is a running diagnostics http server
+type Server struct {
+	srv      *http.Server
+	listener net.Listener
+}
+
+// Start starts the diagnostics http server, it returns nil when the server
+// is not enabled
+func Start(cfg config.DiagnosticsConfig) (*Server, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	addr, err := listenAddress(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	publishOnce.Do(func() {
+		expvar.Publish("goroutines", expvar.Func(func() interface{} {
+			return runtime.NumGoroutine()
+		}))
+	})
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("diagnostics listen (%s): %w", addr, err)
+	}
+
+	s := &Server{
+		listener: l,
+		srv: &http.Server{
+			Handler:           newHandler(cfg.AuthToken),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+
+	go func() {
+		if err := s.srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] diagnostics server: %s", err)
+		}
+	}()
+
+	log.Printf("I! [agent] Started diagnostics server at: http://%s/debug/", l.Addr())
+
+	return s, nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	if s == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("diagnostics shutdown: %w", err)
+	}
+	return nil
+}
+
+// listenAddress returns the address to listen on, listening on anything
+// other than a loopback address requires an auth token
+func listenAddress(cfg config.DiagnosticsConfig) (string, error) {
+	addr := cfg.Address
+	if addr == "" {
+		addr = defaultAddress
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid diagnostics address (%s): %w", addr, err)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+
+	if !isLoopback(host) && cfg.AuthToken == "" {
+		return "", fmt.Errorf("diagnostics address (%s) is not a loopback address, auth_token is required", addr)
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func newHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/goroutines", goroutines)
+
+	if token == "" {
+		return mux
+	}
+	return authenticate(token, mux)
+}
+
+// authenticate requires the token as a bearer token or as the token query
+// parameter, the latter so that go tool pprof can be pointed at a url
+func authenticate(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqToken := r.URL.Query().Get("token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			reqToken = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// goroutines writes the stacks of all goroutines, with log=true the dump is
+// also written to the agent log
+func goroutines(w http.ResponseWriter, r *http.Request) {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	if r.URL.Query().Get("log") == "true" {
+		log.Printf("I! [agent] goroutine dump requested by %s\n%s", r.RemoteAddr, buf)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(buf)
+}
@@ -0,0 +1,139 @@
+package diagnostics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.DiagnosticsConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default",
+			cfg:  config.DiagnosticsConfig{},
+			want: "localhost:6060",
+		},
+		{
+			name: "port only",
+			cfg:  config.DiagnosticsConfig{Address: ":7070"},
+			want: "localhost:7070",
+		},
+		{
+			name: "loopback ip",
+			cfg:  config.DiagnosticsConfig{Address: "127.0.0.1:7070"},
+			want: "127.0.0.1:7070",
+		},
+		{
+			name:    "all interfaces without token",
+			cfg:     config.DiagnosticsConfig{Address: "0.0.0.0:7070"},
+			wantErr: true,
+		},
+		{
+			name: "all interfaces with token",
+			cfg:  config.DiagnosticsConfig{Address: "0.0.0.0:7070", AuthToken: "secret"},
+			want: "0.0.0.0:7070",
+		},
+		{
+			name:    "invalid",
+			cfg:     config.DiagnosticsConfig{Address: "localhost"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := listenAddress(tt.cfg)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, addr)
+		})
+	}
+}
+
+func TestHandlerAuth(t *testing.T) {
+	ts := httptest.NewServer(newHandler("secret"))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		url    string
+		header string
+		status int
+	}{
+		{
+			name:   "no token",
+			url:    ts.URL + "/debug/vars",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "wrong token",
+			url:    ts.URL + "/debug/vars",
+			header: "Bearer wrong",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "bearer token",
+			url:    ts.URL + "/debug/vars",
+			header: "Bearer secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "query token",
+			url:    ts.URL + "/debug/pprof/?token=secret",
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.url, nil)
+			require.NoError(t, err)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	s, err := Start(config.DiagnosticsConfig{})
+	require.NoError(t, err)
+	require.Nil(t, s)
+	require.NoError(t, s.Close())
+
+	s, err = Start(config.DiagnosticsConfig{Enabled: true, Address: "127.0.0.1:0"})
+	require.NoError(t, err)
+	defer s.Close()
+
+	resp, err := http.Get("http://" + s.Addr() + "/debug/goroutines")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, strings.Contains(string(body), "goroutine "))
+
+	resp, err = http.Get("http://" + s.Addr() + "/debug/vars")
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"goroutines"`)
+}
@@ -0,0 +1,137 @@
+// Package doctor provides the report of the agent self-test, a list of checks
+// of the configuration, credentials, outputs and inputs with their verdict,
+// printed as text for a technician or as JSON for tooling.
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Status is the verdict of a check
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Check is the result of one check of the report
+type Check struct {
+	Section  string        `json:"section"`
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Report is the list of checks run by the self-test
+type Report struct {
+	Checks []Check `json:"checks"`
+	Status Status  `json:"status"`
+}
+
+// Run runs fn and records its result as a check, fn returns a message
+// describing the success or an error. The check fails when fn does not
+// return within timeout, a zero timeout waits for fn to return.
+func (r *Report) Run(section, name string, timeout time.Duration, fn func() (string, error)) bool {
+	type result struct {
+		msg string
+		err error
+	}
+
+	start := time.Now()
+	done := make(chan result, 1)
+	go func() {
+		msg, err := fn()
+		done <- result{msg: msg, err: err}
+	}()
+
+	var res result
+	if timeout > 0 {
+		select {
+		case res = <-done:
+		case <-time.After(timeout):
+			res.err = fmt.Errorf("timed out after %s", timeout)
+		}
+	} else {
+		res = <-done
+	}
+
+	c := Check{
+		Section:  section,
+		Name:     name,
+		Status:   StatusOK,
+		Message:  res.msg,
+		Duration: time.Since(start),
+	}
+	if res.err != nil {
+		c.Status = StatusFail
+		c.Message = res.err.Error()
+	}
+	r.add(c)
+	return res.err == nil
+}
+
+// Warn records a check which did not fail but needs attention
+func (r *Report) Warn(section, name, msg string) {
+	r.add(Check{Section: section, Name: name, Status: StatusWarn, Message: msg})
+}
+
+// Fail records a failed check
+func (r *Report) Fail(section, name string, err error) {
+	r.add(Check{Section: section, Name: name, Status: StatusFail, Message: err.Error()})
+}
+
+func (r *Report) add(c Check) {
+	r.Checks = append(r.Checks, c)
+	switch {
+	case c.Status == StatusFail:
+		r.Status = StatusFail
+	case c.Status == StatusWarn && r.Status != StatusFail:
+		r.Status = StatusWarn
+	case r.Status == "":
+		r.Status = StatusOK
+	}
+}
+
+// Failed returns true when at least one check failed
+func (r *Report) Failed() bool {
+	return r.Status == StatusFail
+}
+
+// WriteText writes the report as a table followed by the verdict
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECTION\tCHECK\tSTATUS\tDURATION\tMESSAGE")
+	for _, c := range r.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Section, c.Name, c.Status, c.Duration.Round(time.Millisecond), c.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	status := r.Status
+	if status == "" {
+		status = StatusOK
+	}
+	_, err := fmt.Fprintf(w, "\nverdict: %s\n", status)
+	if err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
+
+// WriteJSON writes the report as a JSON document
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package doctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportStatus(t *testing.T) {
+	r := &Report{}
+	require.False(t, r.Failed())
+
+	require.True(t, r.Run("config", "load", 0, func() (string, error) {
+		return "2 inputs", nil
+	}))
+	require.Equal(t, StatusOK, r.Status)
+
+	r.Warn("inputs", "none", "no inputs")
+	require.Equal(t, StatusWarn, r.Status)
+
+	require.False(t, r.Run("outputs", "circonus", 0, func() (string, error) {
+		return "", errors.New("connection refused")
+	}))
+	require.Equal(t, StatusFail, r.Status)
+	require.True(t, r.Failed())
+
+	r.Warn("inputs", "other", "still failed")
+	require.Equal(t, StatusFail, r.Status)
+
+	require.Len(t, r.Checks, 4)
+	require.Equal(t, "2 inputs", r.Checks[0].Message)
+	require.Equal(t, StatusFail, r.Checks[2].Status)
+	require.Equal(t, "connection refused", r.Checks[2].Message)
+}
+
+func TestReportTimeout(t *testing.T) {
+	r := &Report{}
+	block := make(chan struct{})
+	defer close(block)
+
+	ok := r.Run("outputs", "slow", 10*time.Millisecond, func() (string, error) {
+		<-block
+		return "", nil
+	})
+	require.False(t, ok)
+	require.Equal(t, StatusFail, r.Checks[0].Status)
+	require.Contains(t, r.Checks[0].Message, "timed out")
+}
+
+func TestReportWrite(t *testing.T) {
+	r := &Report{}
+	r.Run("config", "load", 0, func() (string, error) { return "loaded", nil })
+	r.Fail("outputs", "circonus", errors.New("unreachable"))
+
+	var text bytes.Buffer
+	require.NoError(t, r.WriteText(&text))
+	require.Contains(t, text.String(), "SECTION")
+	require.Contains(t, text.String(), "unreachable")
+	require.True(t, strings.HasSuffix(text.String(), "verdict: fail\n"))
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteJSON(&buf))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, StatusFail, decoded.Status)
+	require.Len(t, decoded.Checks, 2)
+	require.Equal(t, "circonus", decoded.Checks[1].Name)
+}
@@ -0,0 +1,80 @@
+// Package state keeps small state blobs of plugins in files of a directory,
+// so that plugins can resume where they stopped across restarts.
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store saves each state in its own file of the directory.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store of the directory, creating it when missing.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("state directory (%s): %w", dir, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Load returns the state saved with the key, nil when there is none.
+func (s *Store) Load(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read state (%s): %w", key, err)
+	}
+	return data, nil
+}
+
+// Save replaces the state of the key, the file is written to a temporary
+// file first and renamed so that a crash never leaves a partial state.
+func (s *Store) Save(key string, data []byte) error {
+	path := s.path(key)
+	tmp, err := ioutil.TempFile(s.dir, filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("write state (%s): %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write state (%s): %w", key, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write state (%s): %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write state (%s): %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write state (%s): %w", key, err)
+	}
+	return nil
+}
+
+// path maps the key to a file name, the bytes not safe in file names and
+// the '_' escape itself are escaped as _XX so that distinct keys never share
+// a file
+func (s *Store) path(key string) string {
+	var name strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '-':
+			name.WriteByte(c)
+		default:
+			fmt.Fprintf(&name, "_%02x", c)
+		}
+	}
+	return filepath.Join(s.dir, name.String()+".state")
+}
@@ -0,0 +1,54 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := NewStore(filepath.Join(dir, "sub"))
+	require.NoError(t, err)
+
+	data, err := s.Load("inputs.tail::app")
+	require.NoError(t, err)
+	require.Nil(t, data)
+
+	require.NoError(t, s.Save("inputs.tail::app", []byte("offset=1")))
+	require.NoError(t, s.Save("inputs.tail::app", []byte("offset=2")))
+
+	data, err = s.Load("inputs.tail::app")
+	require.NoError(t, err)
+	require.Equal(t, "offset=2", string(data))
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "sub"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "inputs.tail_3a_3aapp.state", files[0].Name())
+}
+
+func TestStoreDistinctKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := NewStore(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Save("a/b", []byte("1")))
+	require.NoError(t, s.Save("a_b", []byte("2")))
+	require.NoError(t, s.Save("a_2fb", []byte("3")))
+
+	for key, expected := range map[string]string{"a/b": "1", "a_b": "2", "a_2fb": "3"} {
+		data, err := s.Load(key)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(data))
+	}
+}
@@ -0,0 +1,157 @@
+// Package supervisor runs the agent as a child process and restarts it with
+// an exponential backoff when it exits abnormally, e.g. on a panic or when
+// killed by the OOM killer, for platforms without a service manager doing so.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// RestartsEnv is the environment variable passing the number of restarts to
+// the child process
+const RestartsEnv = "CUA_SUPERVISOR_RESTARTS"
+
+const (
+	defaultMinBackoff  = time.Second
+	defaultMaxBackoff  = 5 * time.Minute
+	defaultStableAfter = 10 * time.Minute
+	defaultStopTimeout = 2 * time.Minute
+)
+
+// Supervisor runs a command until it exits successfully or the context is
+// done, restarting it when it fails
+type Supervisor struct {
+	// Command is the path and arguments of the child process
+	Command []string
+	// MinBackoff and MaxBackoff bound the delay before a restart, doubled
+	// on each consecutive failure
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// StableAfter is the run time after which a child is considered
+	// healthy, the backoff is then reset
+	StableAfter time.Duration
+	// StopTimeout is the time given to the child to shut down once
+	// signaled before it is killed
+	StopTimeout time.Duration
+
+	restarts int
+}
+
+// Restarts returns the number of restarts of the child process
+func (s *Supervisor) Restarts() int {
+	return s.restarts
+}
+
+// Run starts the child process and restarts it until it exits successfully
+// or the context is done, in which case the child is stopped
+func (s *Supervisor) Run(ctx context.Context) error {
+	if len(s.Command) == 0 {
+		return errors.New("no command")
+	}
+
+	b := &backoff{
+		min: durationOrDefault(s.MinBackoff, defaultMinBackoff),
+		max: durationOrDefault(s.MaxBackoff, defaultMaxBackoff),
+	}
+	stableAfter := durationOrDefault(s.StableAfter, defaultStableAfter)
+
+	for {
+		start := time.Now()
+		err := s.runOnce(ctx)
+		if ctx.Err() != nil {
+			log.Printf("I! [supervisor] Agent stopped")
+			return nil
+		}
+		if err == nil {
+			log.Printf("I! [supervisor] Agent exited")
+			return nil
+		}
+
+		if time.Since(start) >= stableAfter {
+			b.reset()
+		}
+		delay := b.next()
+		s.restarts++
+		log.Printf("E! [supervisor] Agent exited: %v, restart %d in %s", err, s.restarts, delay)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runOnce runs the child process until it exits or the context is done
+func (s *Supervisor) runOnce(ctx context.Context) error {
+	cmd := exec.Command(s.Command[0], s.Command[1:]...) //nolint:gosec // G204
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), RestartsEnv+"="+strconv.Itoa(s.restarts))
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting agent: %w", err)
+	}
+	log.Printf("I! [supervisor] Started agent, pid %d", cmd.Process.Pid)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	stopForwarding := forwardSignals(cmd.Process)
+	defer stopForwarding()
+
+	select {
+	case err := <-done:
+		return err //nolint:wrapcheck
+	case <-ctx.Done():
+		stop(cmd.Process, done, durationOrDefault(s.StopTimeout, defaultStopTimeout))
+		return nil
+	}
+}
+
+// RestartsFromEnv returns the number of restarts passed by the supervisor to the
+// agent, 0 when the agent is not supervised
+func RestartsFromEnv() int {
+	n, err := strconv.Atoi(os.Getenv(RestartsEnv))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+type backoff struct {
+	min, max, cur time.Duration
+}
+
+func (b *backoff) next() time.Duration {
+	if b.cur == 0 {
+		b.cur = b.min
+	} else {
+		b.cur *= 2
+	}
+	if b.cur > b.max {
+		b.cur = b.max
+	}
+	return b.cur
+}
+
+func (b *backoff) reset() {
+	b.cur = 0
+}
+
+func durationOrDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
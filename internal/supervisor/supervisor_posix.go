@@ -0,0 +1,45 @@
+//go:build !windows
+// +build !windows
+
+package supervisor
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// forwardSignals forwards SIGHUP to the child so the config is reloaded,
+// the returned function stops forwarding
+func forwardSignals(p *os.Process) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = p.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// stop sends SIGTERM to the child, which is killed if it did not exit
+// within timeout
+func stop(p *os.Process, done <-chan error, timeout time.Duration) {
+	_ = p.Signal(syscall.SIGTERM)
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		_ = p.Kill()
+		<-done
+	}
+}
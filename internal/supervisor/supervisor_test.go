@@ -0,0 +1,90 @@
+package supervisor
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const helperEnv = "CUA_SUPERVISOR_TEST_HELPER"
+
+// TestHelperProcess is the child process of the tests, it fails until it has
+// been restarted twice
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv(helperEnv) {
+	case "fail":
+		if RestartsFromEnv() < 2 {
+			os.Exit(2)
+		}
+		os.Exit(0)
+	case "block":
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+}
+
+func helperCommand(t *testing.T, mode string) []string {
+	require.NoError(t, os.Setenv(helperEnv, mode))
+	t.Cleanup(func() { os.Unsetenv(helperEnv) })
+	return []string{os.Args[0], "-test.run=TestHelperProcess"}
+}
+
+func TestBackoff(t *testing.T) {
+	b := &backoff{min: time.Second, max: 5 * time.Second}
+	require.Equal(t, time.Second, b.next())
+	require.Equal(t, 2*time.Second, b.next())
+	require.Equal(t, 4*time.Second, b.next())
+	require.Equal(t, 5*time.Second, b.next())
+	require.Equal(t, 5*time.Second, b.next())
+	b.reset()
+	require.Equal(t, time.Second, b.next())
+}
+
+func TestRunRestartsFailingChild(t *testing.T) {
+	s := &Supervisor{
+		Command:    helperCommand(t, "fail"),
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	require.NoError(t, s.Run(ctx))
+	require.Equal(t, 2, s.Restarts())
+}
+
+func TestRunStopsChild(t *testing.T) {
+	s := &Supervisor{
+		Command:     helperCommand(t, "block"),
+		StopTimeout: time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	require.NoError(t, s.Run(ctx))
+	require.Less(t, int64(time.Since(start)), int64(30*time.Second))
+	require.Equal(t, 0, s.Restarts())
+}
+
+func TestRunNoCommand(t *testing.T) {
+	s := &Supervisor{}
+	require.Error(t, s.Run(context.Background()))
+}
+
+func TestRestartsFromEnv(t *testing.T) {
+	require.NoError(t, os.Setenv(RestartsEnv, "3"))
+	defer os.Unsetenv(RestartsEnv)
+	require.Equal(t, 3, RestartsFromEnv())
+
+	require.NoError(t, os.Setenv(RestartsEnv, "x"))
+	require.Equal(t, 0, RestartsFromEnv())
+}
@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package supervisor
+
+import (
+	"os"
+	"time"
+)
+
+func forwardSignals(p *os.Process) func() {
+	return func() {}
+}
+
+// stop kills the child, signals other than kill can't be sent to a process
+// on windows
+func stop(p *os.Process, done <-chan error, timeout time.Duration) {
+	_ = p.Kill()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
@@ -13,6 +13,9 @@ The commands & flags are:
 
   config              print out full sample configuration to stdout
   version             print the version to stdout
+  doctor              check the config, credentials, output connectivity and
+                      input initialization, print a report and exit non-zero
+                      on failure. Options: --format text|json, --timeout 30s
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
   --config <file>                configuration file to load
@@ -31,6 +34,8 @@ The commands & flags are:
                                  Valid values are 'agent', 'global_tags', 'outputs',
                                  'processors', 'aggregators' and 'inputs'
   --once                         enable once mode: gather metrics once, write them, and exit
+  --supervise                    run the agent as a child process, restarted with an
+                                 exponential backoff when it exits abnormally
   --test                         enable test mode: gather metrics once and print them
   --test-wait                    wait up to this many seconds for service
                                  inputs to complete in test or once mode
@@ -53,6 +58,9 @@ Examples:
   # run a single collection, outputting metrics to stdout
   circonus-unified-agentd.exe --config circonus-unfied-agent.conf --test
 
+  # verify an installation, checking the config, credentials and outputs
+  circonus-unified-agentd.exe --config circonus-unified-agent.conf doctor
+
   # run with all plugins defined in config file
   circonus-unified-agentd.exe --config circonus-unified-agent.conf
 
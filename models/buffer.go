@@ -7,6 +7,15 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/selfstat"
 )
 
+const (
+	// OverflowDropOldest drops the oldest metrics when the buffer is full.
+	OverflowDropOldest = "drop-oldest"
+	// OverflowDropNewest drops the metrics being added when the buffer is full.
+	OverflowDropNewest = "drop-newest"
+	// OverflowBlock waits for room in the buffer when it is full.
+	OverflowBlock = "block"
+)
+
 var (
 	AgentMetricsWritten = selfstat.Register("agent", "metrics_written", map[string]string{})
 	AgentMetricsDropped = selfstat.Register("agent", "metrics_dropped", map[string]string{})
@@ -20,13 +29,28 @@ type Buffer struct {
 	MetricsWritten selfstat.Stat
 	MetricsAdded   selfstat.Stat
 	BufferLimit    selfstat.Stat
+	MetricsBlocked selfstat.Stat
 	buf            []cua.Metric
+	cond           *sync.Cond
 	cap            int // the capacity of the buffer
 	batchSize      int // number of metrics currently in the batch
 	size           int // number of metrics currently in the buffer
 	last           int // one after the index of the last/newest metric
 	first          int // index of the first/oldest metric
 	batchFirst     int // index of the first metric in the batch
+
+	overflow string // the overflow policy
+	released bool   // no longer wait for room in the buffer
+}
+
+// ValidOverflowPolicy reports whether policy is a known overflow policy, an
+// empty policy is the default, drop-oldest.
+func ValidOverflowPolicy(policy string) bool {
+	switch policy {
+	case "", OverflowDropOldest, OverflowDropNewest, OverflowBlock:
+		return true
+	}
+	return false
 }
 
 // NewBuffer returns a new empty Buffer with the given capacity.
@@ -68,7 +92,13 @@ func NewBuffer(name string, alias string, capacity int) *Buffer {
 			"buffer_limit",
 			tags,
 		),
+		MetricsBlocked: selfstat.Register(
+			"write",
+			"metrics_blocked",
+			tags,
+		),
 	}
+	b.cond = sync.NewCond(&b.Mutex)
 	b.BufferSize.Set(int64(0))
 	b.BufferLimit.Set(int64(capacity))
 	return b
@@ -128,13 +158,61 @@ func (b *Buffer) add(m cua.Metric) int {
 	return dropped
 }
 
+// full reports whether there is no room for a metric without overwriting
+// either unsent metrics or the metrics in the batch.
+func (b *Buffer) full() bool {
+	return b.size+b.batchSize >= b.cap
+}
+
+// SetOverflowPolicy sets what happens when metrics are added to a full
+// buffer, see the Overflow constants. The default is drop-oldest.
+func (b *Buffer) SetOverflowPolicy(policy string) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.overflow = policy
+}
+
+// Release stops Add from waiting for room in the buffer with the block
+// overflow policy, waiting and future calls to Add drop the oldest metrics.
+func (b *Buffer) Release() {
+	b.Lock()
+	defer b.Unlock()
+
+	b.released = true
+	b.cond.Broadcast()
+}
+
 // Add adds metrics to the buffer and returns number of dropped metrics.
 func (b *Buffer) Add(metrics ...cua.Metric) int {
+	return b.addMetrics(true, metrics...)
+}
+
+// addMetrics adds metrics to the buffer, applying the overflow policy when
+// the buffer is full. With the block policy it waits for room only when wait
+// is set, otherwise the oldest metrics are dropped.
+func (b *Buffer) addMetrics(wait bool, metrics ...cua.Metric) int {
 	b.Lock()
 	defer b.Unlock()
 
 	dropped := 0
 	for i := range metrics {
+		switch b.overflow {
+		case OverflowDropNewest:
+			if b.full() {
+				b.metricDropped(metrics[i])
+				dropped++
+				continue
+			}
+		case OverflowBlock:
+			if wait && !b.released && b.full() {
+				b.MetricsBlocked.Incr(1)
+				b.BufferSize.Set(int64(b.length()))
+				for !b.released && b.full() {
+					b.cond.Wait()
+				}
+			}
+		}
 		if n := b.add(metrics[i]); n != 0 {
 			dropped += n
 		}
@@ -183,6 +261,7 @@ func (b *Buffer) Accept(batch []cua.Metric) {
 
 	b.resetBatch()
 	b.BufferSize.Set(int64(b.length()))
+	b.cond.Broadcast()
 }
 
 // Reject returns the batch, acquired from Batch(), to the buffer and marks it
@@ -216,6 +295,7 @@ func (b *Buffer) Reject(batch []cua.Metric) {
 
 	b.resetBatch()
 	b.BufferSize.Set(int64(b.length()))
+	b.cond.Broadcast()
 }
 
 // // dist returns the distance between two indexes.  Because this data structure
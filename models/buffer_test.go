@@ -726,3 +726,89 @@ func TestBuffer_RejectEmptyBatch(t *testing.T) {
 		require.NotNil(t, m)
 	}
 }
+
+func TestBuffer_DropNewestKeepsOldest(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	b.SetOverflowPolicy(OverflowDropNewest)
+
+	dropped := b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4), MetricTime(5))
+	require.Equal(t, 2, dropped)
+	require.Equal(t, int64(2), b.MetricsDropped.Get())
+
+	batch := b.Batch(3)
+	testutil.RequireMetricsEqual(t,
+		[]cua.Metric{
+			MetricTime(1),
+			MetricTime(2),
+			MetricTime(3),
+		}, batch)
+}
+
+func TestBuffer_DropNewestKeepsBatch(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	b.SetOverflowPolicy(OverflowDropNewest)
+
+	b.Add(MetricTime(1), MetricTime(2))
+	batch := b.Batch(2)
+	b.Add(MetricTime(3), MetricTime(4))
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+
+	b.Reject(batch)
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+
+	batch = b.Batch(3)
+	testutil.RequireMetricsEqual(t,
+		[]cua.Metric{
+			MetricTime(1),
+			MetricTime(2),
+			MetricTime(3),
+		}, batch)
+}
+
+func TestBuffer_BlockWaitsForAccept(t *testing.T) {
+	b := setup(NewBuffer("test", "", 2))
+	b.MetricsBlocked.Set(0)
+	b.SetOverflowPolicy(OverflowBlock)
+
+	b.Add(MetricTime(1), MetricTime(2))
+	batch := b.Batch(2)
+
+	done := make(chan int)
+	go func() {
+		done <- b.Add(MetricTime(3))
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("add did not block on a full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b.Accept(batch)
+	require.Equal(t, 0, <-done)
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+	require.Equal(t, int64(1), b.MetricsBlocked.Get())
+	require.Equal(t, 1, b.Len())
+}
+
+func TestBuffer_BlockRelease(t *testing.T) {
+	b := setup(NewBuffer("test", "", 2))
+	b.SetOverflowPolicy(OverflowBlock)
+
+	b.Add(MetricTime(1), MetricTime(2))
+
+	done := make(chan int)
+	go func() {
+		done <- b.Add(MetricTime(3))
+	}()
+
+	b.Release()
+	require.Equal(t, 1, <-done)
+
+	batch := b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]cua.Metric{
+			MetricTime(2),
+			MetricTime(3),
+		}, batch)
+}
@@ -132,7 +132,7 @@ func (f *Filter) shouldNamePass(key string) bool {
 	}
 
 	drop := func(f *Filter) bool {
-		return f.nameDrop.Match(key)
+		return !f.nameDrop.Match(key)
 	}
 
 	switch {
@@ -10,6 +10,7 @@ func makemetric(
 	namePrefix string,
 	nameSuffix string,
 	tags map[string]string,
+	labels map[string]string,
 	globalTags map[string]string,
 ) cua.Metric {
 	if len(nameOverride) != 0 {
@@ -23,6 +24,10 @@ func makemetric(
 		metric.AddSuffix(nameSuffix)
 	}
 
+	// Apply instance labels, replacing the tags set by the plugin
+	for k, v := range labels {
+		metric.AddTag(k, v)
+	}
 	// Apply plugin-wide tags
 	for k, v := range tags {
 		if _, ok := metric.GetTag(k); !ok {
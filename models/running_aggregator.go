@@ -68,6 +68,7 @@ func NewRunningAggregator(aggregator cua.Aggregator, config *AggregatorConfig) *
 // AggregatorConfig is the common config for all aggregators.
 type AggregatorConfig struct {
 	Tags              map[string]string
+	InstanceLabels    map[string]string
 	Name              string
 	Alias             string
 	MeasurementSuffix string
@@ -115,6 +116,7 @@ func (r *RunningAggregator) MakeMetric(metric cua.Metric) cua.Metric {
 		r.Config.MeasurementPrefix,
 		r.Config.MeasurementSuffix,
 		r.Config.Tags,
+		r.Config.InstanceLabels,
 		nil)
 
 	if m != nil {
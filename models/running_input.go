@@ -68,6 +68,7 @@ func NewRunningInput(input cua.Input, config *InputConfig) *RunningInput {
 // InputConfig is the common config for all inputs.
 type InputConfig struct {
 	Tags              map[string]string
+	InstanceLabels    map[string]string
 	Name              string
 	InstanceID        string
 	Alias             string
@@ -110,6 +111,7 @@ func (r *RunningInput) MakeMetric(metric cua.Metric) cua.Metric {
 		r.Config.MeasurementPrefix,
 		r.Config.MeasurementSuffix,
 		r.Config.Tags,
+		r.Config.InstanceLabels,
 		r.defaultTags)
 
 	m.SetOrigin(r.Config.Name)
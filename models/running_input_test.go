@@ -123,6 +123,48 @@ func TestMakeMetricWithPluginTags(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestMakeMetricWithInstanceLabels(t *testing.T) {
+	now := time.Now()
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name: "TestRunningInput",
+		Tags: map[string]string{
+			"foo":  "bar",
+			"site": "plugin",
+		},
+		InstanceLabels: map[string]string{
+			"site":   "label",
+			"server": "label",
+		},
+	})
+	ri.SetDefaultTags(map[string]string{"region": "global"})
+
+	m := testutil.MustMetric("RITest",
+		map[string]string{
+			"server": "metric",
+		},
+		map[string]interface{}{
+			"value": int64(101),
+		},
+		now,
+		cua.Untyped)
+	m = ri.MakeMetric(m)
+
+	expected, err := metric.New("RITest",
+		map[string]string{
+			"foo":    "bar",
+			"site":   "label",
+			"server": "label",
+			"region": "global",
+		},
+		map[string]interface{}{
+			"value": 101,
+		},
+		now,
+	)
+	require.NoError(t, err)
+	require.Equal(t, expected, m)
+}
+
 func TestMakeMetricFilteredOut(t *testing.T) {
 	now := time.Now()
 	ri := NewRunningInput(&testInput{}, &InputConfig{
@@ -22,6 +22,7 @@ const (
 type OutputConfig struct {
 	Name              string
 	Alias             string
+	InstanceID        string
 	NamePrefix        string
 	NameSuffix        string
 	NameOverride      string
@@ -30,6 +31,7 @@ type OutputConfig struct {
 	MetricBufferLimit int
 	MetricBatchSize   int
 	FlushInterval     time.Duration
+	OverflowPolicy    string
 }
 
 // RunningOutput contains the output configuration
@@ -81,6 +83,9 @@ func NewRunningOutput(
 	if config.Alias != "" {
 		tags["alias"] = config.Alias
 	}
+	if config.InstanceID != "" {
+		tags["instance_id"] = config.InstanceID
+	}
 
 	writeErrorsRegister := selfstat.Register("write", "errors", tags)
 	logger := NewLogger("outputs", config.Name, config.Alias)
@@ -102,8 +107,11 @@ func NewRunningOutput(
 		batchSize = DefaultMetricBatchSize
 	}
 
+	buffer := NewBuffer(config.Name, config.Alias, bufferLimit)
+	buffer.SetOverflowPolicy(config.OverflowPolicy)
+
 	ro := &RunningOutput{
-		buffer:            NewBuffer(config.Name, config.Alias, bufferLimit),
+		buffer:            buffer,
 		BatchReady:        make(chan time.Time, 1),
 		Output:            output,
 		Config:            config,
@@ -198,7 +206,8 @@ func (ro *RunningOutput) Write() error {
 	if output, ok := ro.Output.(cua.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		metrics := output.Push()
-		ro.buffer.Add(metrics...)
+		// never wait for room, the buffer is emptied by this goroutine
+		ro.buffer.addMetrics(false, metrics...)
 		output.Reset()
 		ro.aggMutex.Unlock()
 	}
@@ -271,6 +280,12 @@ func (ro *RunningOutput) write(metrics []cua.Metric) error {
 	return nil
 }
 
+// Release stops AddMetric from waiting for room in the buffer, used at
+// shutdown with the block overflow policy.
+func (ro *RunningOutput) Release() {
+	ro.buffer.Release()
+}
+
 func (ro *RunningOutput) LogBufferStatus() {
 	nBuffer := ro.buffer.Len()
 	ro.log.Debugf("Buffer fullness: %d / %d batches", nBuffer, ro.MetricBufferLimit)
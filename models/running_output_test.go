@@ -492,6 +492,7 @@ func TestInternalMetrics(t *testing.T) {
 				"buffer_size":      0,
 				"errors":           0,
 				"metrics_added":    0,
+				"metrics_blocked":  0,
 				"metrics_dropped":  0,
 				"metrics_filtered": 0,
 				"metrics_written":  0,
@@ -0,0 +1,141 @@
+package latch
+
+import (
+	"fmt"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/aggregators"
+)
+
+// Latch keeps the worst value of the selected fields over the period, so
+// the spikes between two flushes are not lost by the outputs keeping the
+// last value
+type Latch struct {
+	Max []string `toml:"max"`
+	Min []string `toml:"min"`
+
+	maxFilter filter.Filter
+	minFilter filter.Filter
+	cache     map[uint64]aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]latched
+}
+
+// latched is the worst value of a field, the original value is kept so the
+// field keeps its type
+type latched struct {
+	value interface{}
+	fv    float64
+}
+
+func NewLatch() cua.Aggregator {
+	l := &Latch{}
+	l.Reset()
+	return l
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Fields latched at their highest value of the period, globs are
+  ## supported, e.g. queue depths and latencies
+  max = []
+
+  ## Fields latched at their lowest value of the period, globs are
+  ## supported, e.g. free memory. A field matching max and min is latched
+  ## at its highest value.
+  min = []
+`
+
+func (l *Latch) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Latch) Description() string {
+	return "Latch the highest or lowest value of fields over the period."
+}
+
+func (l *Latch) Init() error {
+	if len(l.Max) == 0 && len(l.Min) == 0 {
+		return fmt.Errorf("no max or min fields configured")
+	}
+
+	var err error
+	if l.maxFilter, err = filter.Compile(l.Max); err != nil {
+		return fmt.Errorf("max: %w", err)
+	}
+	if l.minFilter, err = filter.Compile(l.Min); err != nil {
+		return fmt.Errorf("min: %w", err)
+	}
+	return nil
+}
+
+func (l *Latch) Add(in cua.Metric) {
+	id := in.HashID()
+	a := l.cache[id]
+	for _, field := range in.FieldList() {
+		isMax := l.maxFilter != nil && l.maxFilter.Match(field.Key)
+		if !isMax && (l.minFilter == nil || !l.minFilter.Match(field.Key)) {
+			continue
+		}
+		fv, ok := convert(field.Value)
+		if !ok {
+			continue
+		}
+
+		if a.fields == nil {
+			a = aggregate{
+				name:   in.Name(),
+				tags:   in.Tags(),
+				fields: make(map[string]latched),
+			}
+			l.cache[id] = a
+		}
+
+		cur, seen := a.fields[field.Key]
+		if !seen || (isMax && fv > cur.fv) || (!isMax && fv < cur.fv) {
+			a.fields[field.Key] = latched{value: field.Value, fv: fv}
+		}
+	}
+}
+
+func (l *Latch) Push(acc cua.Accumulator) {
+	for _, a := range l.cache {
+		fields := make(map[string]interface{}, len(a.fields))
+		for k, v := range a.fields {
+			fields[k] = v.value
+		}
+		acc.AddFields(a.name, fields, a.tags)
+	}
+}
+
+func (l *Latch) Reset() {
+	l.cache = make(map[uint64]aggregate)
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("latch", NewLatch)
+}
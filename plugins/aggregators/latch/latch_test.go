@@ -0,0 +1,83 @@
+package latch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newLatch(t *testing.T, max, min []string) *Latch {
+	l := &Latch{Max: max, Min: min}
+	require.NoError(t, l.Init())
+	l.Reset()
+	return l
+}
+
+func memMetric(queue int64, free uint64, latency float64) cua.Metric {
+	return testutil.MustMetric("app",
+		map[string]string{"host": "a"},
+		map[string]interface{}{
+			"queue_depth":   queue,
+			"mem_free":      free,
+			"read_latency":  latency,
+			"write_latency": latency * 2,
+			"state":         "ok",
+		},
+		time.Now(),
+	)
+}
+
+func TestLatch(t *testing.T) {
+	l := newLatch(t, []string{"queue_depth", "*_latency"}, []string{"mem_free"})
+
+	l.Add(memMetric(2, 4096, 0.5))
+	l.Add(memMetric(40, 512, 0.1))
+	l.Add(memMetric(3, 2048, 0.2))
+
+	var acc testutil.Accumulator
+	l.Push(&acc)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("app",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"queue_depth":   int64(40),
+				"mem_free":      uint64(512),
+				"read_latency":  0.5,
+				"write_latency": 1.0,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.IgnoreTime())
+}
+
+func TestLatchReset(t *testing.T) {
+	l := newLatch(t, []string{"queue_depth"}, nil)
+
+	l.Add(memMetric(40, 0, 0))
+	l.Reset()
+	l.Add(memMetric(2, 0, 0))
+
+	var acc testutil.Accumulator
+	l.Push(&acc)
+	acc.AssertContainsFields(t, "app", map[string]interface{}{"queue_depth": int64(2)})
+}
+
+func TestLatchNoMatch(t *testing.T) {
+	l := newLatch(t, []string{"state", "missing"}, nil)
+
+	l.Add(memMetric(1, 0, 0))
+
+	var acc testutil.Accumulator
+	l.Push(&acc)
+	require.Empty(t, acc.GetCUAMetrics())
+}
+
+func TestLatchNoFields(t *testing.T) {
+	l := &Latch{}
+	require.EqualError(t, l.Init(), "no max or min fields configured")
+}
@@ -0,0 +1,119 @@
+package quantile
+
+import (
+	"math"
+	"sort"
+)
+
+// minIndexable is the smallest magnitude given a bucket of its own, smaller
+// values are counted as zero
+const minIndexable = 1e-9
+
+// ddsketch is a DDSketch (Masson, Rim & Lee). Values are counted in buckets
+// with logarithmically growing bounds so that the estimated quantiles are
+// within the relative accuracy of the actual values.
+type ddsketch struct {
+	gamma    float64
+	logGamma float64
+	positive map[int]float64
+	negative map[int]float64
+	zero     float64
+	count    float64
+	min      float64
+	max      float64
+}
+
+func newDDSketch(relativeAccuracy float64) *ddsketch {
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &ddsketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		positive: make(map[int]float64),
+		negative: make(map[int]float64),
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+	}
+}
+
+func (d *ddsketch) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / d.logGamma))
+}
+
+// value returns the value representing a bucket, the point with the same
+// relative distance to both bounds
+func (d *ddsketch) value(index int) float64 {
+	return 2 * math.Pow(d.gamma, float64(index)) / (d.gamma + 1)
+}
+
+func (d *ddsketch) add(v float64) {
+	switch {
+	case v > minIndexable:
+		d.positive[d.index(v)]++
+	case v < -minIndexable:
+		d.negative[d.index(-v)]++
+	default:
+		d.zero++
+	}
+	d.count++
+	if v < d.min {
+		d.min = v
+	}
+	if v > d.max {
+		d.max = v
+	}
+}
+
+func (d *ddsketch) quantile(q float64) float64 {
+	switch {
+	case d.count == 0:
+		return math.NaN()
+	case q <= 0:
+		return d.min
+	case q >= 1:
+		return d.max
+	}
+
+	rank := q * (d.count - 1)
+	cum := 0.0
+
+	v, found := d.walk(d.negative, true, rank, &cum)
+	if !found {
+		cum += d.zero
+		if cum > rank {
+			v, found = 0, true
+		}
+	}
+	if !found {
+		v, found = d.walk(d.positive, false, rank, &cum)
+	}
+	if !found {
+		return d.max
+	}
+
+	return math.Max(d.min, math.Min(d.max, v))
+}
+
+// walk adds the bucket counts to cum in ascending order of value until it
+// exceeds rank, returning the value of that bucket
+func (d *ddsketch) walk(buckets map[int]float64, negative bool, rank float64, cum *float64) (float64, bool) {
+	keys := make([]int, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	if negative {
+		sort.Sort(sort.Reverse(sort.IntSlice(keys)))
+	} else {
+		sort.Ints(keys)
+	}
+
+	for _, k := range keys {
+		*cum += buckets[k]
+		if *cum > rank {
+			if negative {
+				return -d.value(k), true
+			}
+			return d.value(k), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,206 @@
+package quantile
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/aggregators"
+)
+
+const (
+	algorithmTDigest  = "t-digest"
+	algorithmDDSketch = "ddsketch"
+)
+
+type Quantile struct {
+	Fields           []string  `toml:"fields"`
+	Quantiles        []float64 `toml:"quantiles"`
+	Algorithm        string    `toml:"algorithm"`
+	Compression      float64   `toml:"compression"`
+	RelativeAccuracy float64   `toml:"relative_accuracy"`
+
+	fieldFilter filter.Filter
+	suffixes    []string
+	newSketch   func() sketch
+	cache       map[uint64]aggregate
+}
+
+type aggregate struct {
+	name     string
+	tags     map[string]string
+	sketches map[string]sketch
+}
+
+// sketch is a summary of a stream of values from which quantiles can be
+// estimated
+type sketch interface {
+	add(v float64)
+	quantile(q float64) float64
+}
+
+func NewQuantile() cua.Aggregator {
+	q := &Quantile{
+		Fields:           []string{"*"},
+		Quantiles:        []float64{0.25, 0.5, 0.75},
+		Algorithm:        algorithmTDigest,
+		Compression:      100,
+		RelativeAccuracy: 0.01,
+	}
+	q.Reset()
+	return q
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Fields to estimate quantiles of, globs are supported
+  # fields = ["*"]
+
+  ## Quantiles to emit, each is emitted as <field>_p<quantile*100>
+  # quantiles = [0.25, 0.5, 0.75]
+
+  ## Sketch algorithm, "t-digest" or "ddsketch"
+  # algorithm = "t-digest"
+
+  ## t-digest compression, higher values are more accurate and use more memory
+  # compression = 100.0
+
+  ## ddsketch relative accuracy of the estimated quantiles
+  # relative_accuracy = 0.01
+`
+
+func (q *Quantile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (q *Quantile) Description() string {
+	return "Keep a t-digest or DDSketch of each field and emit quantiles."
+}
+
+func (q *Quantile) Init() error {
+	switch q.Algorithm {
+	case algorithmTDigest:
+		if q.Compression < 1 {
+			return fmt.Errorf("invalid compression (%v), must be at least 1", q.Compression)
+		}
+		compression := q.Compression
+		q.newSketch = func() sketch { return newTDigest(compression) }
+	case algorithmDDSketch:
+		if q.RelativeAccuracy <= 0 || q.RelativeAccuracy >= 1 {
+			return fmt.Errorf("invalid relative_accuracy (%v), must be between 0 and 1", q.RelativeAccuracy)
+		}
+		accuracy := q.RelativeAccuracy
+		q.newSketch = func() sketch { return newDDSketch(accuracy) }
+	default:
+		return fmt.Errorf("unknown algorithm (%s), must be t-digest or ddsketch", q.Algorithm)
+	}
+
+	if len(q.Quantiles) == 0 {
+		return fmt.Errorf("no quantiles configured")
+	}
+	q.suffixes = make([]string, 0, len(q.Quantiles))
+	seen := make(map[string]bool)
+	for _, v := range q.Quantiles {
+		if v < 0 || v > 1 {
+			return fmt.Errorf("invalid quantile (%v), must be between 0 and 1", v)
+		}
+		suffix := quantileSuffix(v)
+		if seen[suffix] {
+			return fmt.Errorf("duplicate quantile (%v)", v)
+		}
+		seen[suffix] = true
+		q.suffixes = append(q.suffixes, suffix)
+	}
+
+	f, err := filter.Compile(q.Fields)
+	if err != nil {
+		return fmt.Errorf("fields: %w", err)
+	}
+	q.fieldFilter = f
+
+	return nil
+}
+
+// quantileSuffix returns the field name suffix of a quantile, e.g. _p99.9
+// for 0.999
+func quantileSuffix(q float64) string {
+	pct := math.Round(q*100*1e6) / 1e6
+	return "_p" + strconv.FormatFloat(pct, 'f', -1, 64)
+}
+
+func (q *Quantile) Add(in cua.Metric) {
+	if q.fieldFilter == nil {
+		return
+	}
+
+	id := in.HashID()
+	a, ok := q.cache[id]
+	if !ok {
+		a = aggregate{
+			name:     in.Name(),
+			tags:     in.Tags(),
+			sketches: make(map[string]sketch),
+		}
+		q.cache[id] = a
+	}
+
+	for _, field := range in.FieldList() {
+		if !q.fieldFilter.Match(field.Key) {
+			continue
+		}
+		v, ok := convert(field.Value)
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		s, ok := a.sketches[field.Key]
+		if !ok {
+			s = q.newSketch()
+			a.sketches[field.Key] = s
+		}
+		s.add(v)
+	}
+}
+
+func (q *Quantile) Push(acc cua.Accumulator) {
+	for _, a := range q.cache {
+		if len(a.sketches) == 0 {
+			continue
+		}
+		fields := make(map[string]interface{}, len(a.sketches)*len(q.Quantiles))
+		for name, s := range a.sketches {
+			for i, v := range q.Quantiles {
+				fields[name+q.suffixes[i]] = s.quantile(v)
+			}
+		}
+		acc.AddFields(a.name, fields, a.tags)
+	}
+}
+
+func (q *Quantile) Reset() {
+	q.cache = make(map[uint64]aggregate)
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("quantile", NewQuantile)
+}
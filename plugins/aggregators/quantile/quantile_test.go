@@ -0,0 +1,224 @@
+package quantile
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkTDigestAdd(b *testing.B) {
+	t := newTDigest(100)
+	for n := 0; n < b.N; n++ {
+		t.add(float64(n))
+	}
+}
+
+func BenchmarkDDSketchAdd(b *testing.B) {
+	d := newDDSketch(0.01)
+	for n := 0; n < b.N; n++ {
+		d.add(float64(n))
+	}
+}
+
+// shuffled returns 1..n in random order
+func shuffled(n int) []float64 {
+	r := rand.New(rand.NewSource(42)) //nolint:gosec
+	values := make([]float64, n)
+	for i, j := range r.Perm(n) {
+		values[i] = float64(j + 1)
+	}
+	return values
+}
+
+func TestTDigestAccuracy(t *testing.T) {
+	td := newTDigest(100)
+	for _, v := range shuffled(10000) {
+		td.add(v)
+	}
+
+	require.Equal(t, 1.0, td.quantile(0))
+	require.Equal(t, 10000.0, td.quantile(1))
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.75, 0.99, 0.999} {
+		// within 1% of the rank, tighter in the tails
+		require.InDelta(t, q*10000, td.quantile(q), 100, "q=%v", q)
+	}
+	require.InDelta(t, 9990, td.quantile(0.999), 5)
+	require.Less(t, len(td.centroids), 500)
+}
+
+func TestTDigestSmall(t *testing.T) {
+	td := newTDigest(100)
+	for _, v := range []float64{5, 1, 4, 2, 3} {
+		td.add(v)
+	}
+	require.Equal(t, 3.0, td.quantile(0.5))
+	require.Equal(t, 1.0, td.quantile(0))
+	require.Equal(t, 5.0, td.quantile(1))
+
+	one := newTDigest(100)
+	one.add(42)
+	require.Equal(t, 42.0, one.quantile(0.99))
+
+	require.True(t, math.IsNaN(newTDigest(100).quantile(0.5)))
+}
+
+func TestDDSketchAccuracy(t *testing.T) {
+	dd := newDDSketch(0.01)
+	values := shuffled(10000)
+	for _, v := range values {
+		dd.add(v)
+	}
+
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.75, 0.99, 0.999} {
+		expected := math.Floor(q*9999) + 1
+		require.InEpsilon(t, expected, dd.quantile(q), 0.01, "q=%v", q)
+	}
+}
+
+func TestDDSketchNegativeAndZero(t *testing.T) {
+	dd := newDDSketch(0.01)
+	for _, v := range []float64{-100, -10, 0, 0, 10, 100} {
+		dd.add(v)
+	}
+
+	require.Equal(t, -100.0, dd.quantile(0))
+	require.InEpsilon(t, -10, dd.quantile(0.2), 0.01)
+	require.Equal(t, 0.0, dd.quantile(0.5))
+	require.InEpsilon(t, 10, dd.quantile(0.8), 0.01)
+	require.Equal(t, 100.0, dd.quantile(1))
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(q *Quantile)
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			modify: func(q *Quantile) {},
+		},
+		{
+			name:   "ddsketch",
+			modify: func(q *Quantile) { q.Algorithm = "ddsketch" },
+		},
+		{
+			name:    "unknown algorithm",
+			modify:  func(q *Quantile) { q.Algorithm = "gk" },
+			wantErr: true,
+		},
+		{
+			name:    "compression",
+			modify:  func(q *Quantile) { q.Compression = 0 },
+			wantErr: true,
+		},
+		{
+			name: "relative accuracy",
+			modify: func(q *Quantile) {
+				q.Algorithm = "ddsketch"
+				q.RelativeAccuracy = 1
+			},
+			wantErr: true,
+		},
+		{
+			name:    "quantile range",
+			modify:  func(q *Quantile) { q.Quantiles = []float64{1.5} },
+			wantErr: true,
+		},
+		{
+			name:    "duplicate quantile",
+			modify:  func(q *Quantile) { q.Quantiles = []float64{0.5, 0.50} },
+			wantErr: true,
+		},
+		{
+			name:    "no quantiles",
+			modify:  func(q *Quantile) { q.Quantiles = nil },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuantile().(*Quantile)
+			tt.modify(q)
+			err := q.Init()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestQuantileSuffix(t *testing.T) {
+	require.Equal(t, "_p50", quantileSuffix(0.5))
+	require.Equal(t, "_p99", quantileSuffix(0.99))
+	require.Equal(t, "_p99.9", quantileSuffix(0.999))
+	require.Equal(t, "_p0", quantileSuffix(0))
+	require.Equal(t, "_p100", quantileSuffix(1))
+}
+
+func TestPush(t *testing.T) {
+	for _, algorithm := range []string{algorithmTDigest, algorithmDDSketch} {
+		t.Run(algorithm, func(t *testing.T) {
+			q := NewQuantile().(*Quantile)
+			q.Algorithm = algorithm
+			q.Fields = []string{"latency*"}
+			q.Quantiles = []float64{0, 0.5, 1}
+			require.NoError(t, q.Init())
+
+			for i := 1; i <= 5; i++ {
+				q.Add(testutil.MustMetric("http",
+					map[string]string{"server": "a"},
+					map[string]interface{}{
+						"latency_ms": int64(i * 10),
+						"status":     int64(200),
+						"method":     "GET",
+					},
+					time.Unix(0, 0),
+				))
+			}
+
+			acc := testutil.Accumulator{}
+			q.Push(&acc)
+
+			require.Len(t, acc.Metrics, 1)
+			m := acc.Metrics[0]
+			require.Equal(t, "http", m.Measurement)
+			require.Equal(t, map[string]string{"server": "a"}, m.Tags)
+			require.Len(t, m.Fields, 3)
+			require.Equal(t, 10.0, m.Fields["latency_ms_p0"])
+			require.InEpsilon(t, 30.0, m.Fields["latency_ms_p50"], 0.01)
+			require.Equal(t, 50.0, m.Fields["latency_ms_p100"])
+
+			q.Reset()
+			acc.ClearMetrics()
+			q.Push(&acc)
+			require.Empty(t, acc.Metrics)
+		})
+	}
+}
+
+func TestNoMatchingFields(t *testing.T) {
+	q := NewQuantile().(*Quantile)
+	q.Fields = []string{"latency"}
+	require.NoError(t, q.Init())
+
+	q.Add(testutil.MustMetric("http",
+		map[string]string{},
+		map[string]interface{}{"status": int64(200)},
+		time.Unix(0, 0),
+	))
+
+	acc := testutil.Accumulator{}
+	q.Push(&acc)
+	require.Empty(t, acc.Metrics)
+}
+
+var _ cua.Aggregator = &Quantile{}
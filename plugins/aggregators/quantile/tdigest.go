@@ -0,0 +1,120 @@
+package quantile
+
+import (
+	"math"
+	"sort"
+)
+
+// tdigest is a merging t-digest (Dunning & Ertl). Values are buffered and
+// merged into centroids, a centroid may hold at most 4*n*q*(1-q)/compression
+// values, where q is its position in the distribution, so that the centroids
+// near the tails stay small and the tail quantiles accurate.
+type tdigest struct {
+	compression float64
+	centroids   []centroid // sorted by mean
+	buffer      []centroid
+	count       float64
+	min         float64
+	max         float64
+}
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+func newTDigest(compression float64) *tdigest {
+	return &tdigest{
+		compression: compression,
+		buffer:      make([]centroid, 0, int(math.Ceil(compression))*5),
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+func (t *tdigest) add(v float64) {
+	t.buffer = append(t.buffer, centroid{mean: v, weight: 1})
+	t.count++
+	if v < t.min {
+		t.min = v
+	}
+	if v > t.max {
+		t.max = v
+	}
+	if len(t.buffer) == cap(t.buffer) {
+		t.compress()
+	}
+}
+
+// compress merges the buffered values into the centroids
+func (t *tdigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+
+	all := make([]centroid, 0, len(t.buffer)+len(t.centroids))
+	all = append(all, t.buffer...)
+	all = append(all, t.centroids...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	cur := all[0]
+	soFar := 0.0
+	for _, c := range all[1:] {
+		proposed := cur.weight + c.weight
+		q0 := soFar / t.count
+		q2 := (soFar + proposed) / t.count
+		limit := 4 * t.count * math.Min(q0*(1-q0), q2*(1-q2)) / t.compression
+		if proposed <= limit {
+			cur.mean += (c.mean - cur.mean) * c.weight / proposed
+			cur.weight = proposed
+			continue
+		}
+		soFar += cur.weight
+		merged = append(merged, cur)
+		cur = c
+	}
+	merged = append(merged, cur)
+
+	t.centroids = merged
+	t.buffer = t.buffer[:0]
+}
+
+// quantile estimates the value at quantile q, interpolating between the
+// centers of adjacent centroids and the min and max at the ends
+func (t *tdigest) quantile(q float64) float64 {
+	t.compress()
+
+	switch {
+	case len(t.centroids) == 0:
+		return math.NaN()
+	case q <= 0:
+		return t.min
+	case q >= 1:
+		return t.max
+	case len(t.centroids) == 1:
+		return t.centroids[0].mean
+	}
+
+	rank := q * t.count
+
+	first := t.centroids[0]
+	if rank < first.weight/2 {
+		return t.min + (first.mean-t.min)*rank/(first.weight/2)
+	}
+
+	cum := 0.0
+	for i := 0; i < len(t.centroids)-1; i++ {
+		c, next := t.centroids[i], t.centroids[i+1]
+		left := cum + c.weight/2
+		right := cum + c.weight + next.weight/2
+		if rank < right {
+			return c.mean + (next.mean-c.mean)*(rank-left)/(right-left)
+		}
+		cum += c.weight
+	}
+
+	last := t.centroids[len(t.centroids)-1]
+	center := t.count - last.weight/2
+	return last.mean + (t.max-last.mean)*(rank-center)/(last.weight/2)
+}
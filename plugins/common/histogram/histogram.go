@@ -0,0 +1,77 @@
+// Package histogram converts the bucketed histograms of other systems, such
+// as Prometheus and OpenTelemetry, into the fields of Circonus histogram
+// metrics, so that the distribution is kept instead of being flattened into
+// gauges.
+package histogram
+
+import (
+	"fmt"
+	"math"
+)
+
+// FromBounds returns the fields of a cua.Histogram metric from buckets with
+// explicit upper bounds, as used by OpenTelemetry: counts[i] is the number of
+// values in (bounds[i-1], bounds[i]] and the optional counts[len(bounds)] is
+// the number of values greater than the last bound.
+//
+// The field keys are the values the buckets are recorded at, formatted like
+// the keys of distributionToCircHisto in stackdriver_circonus, the Circonus
+// output places them in the log-linear bins.  A bucket is recorded at its
+// upper bound and the overflow bucket at the last bound, the lowest value it
+// can hold.  Empty buckets are omitted.
+func FromBounds(bounds []float64, counts []uint64) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		var v float64
+		switch {
+		case i < len(bounds):
+			v = bounds[i]
+		case len(bounds) > 0:
+			v = bounds[len(bounds)-1]
+		default:
+			// a single bucket holding all the values has no bound to
+			// record them at
+			continue
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		key := fmt.Sprintf("%e", v)
+		n, _ := fields[key].(int64)
+		fields[key] = n + int64(count)
+	}
+	return fields
+}
+
+// FromCumulative returns the fields of a cua.Histogram metric from buckets
+// with cumulative counts, as used by Prometheus: cumulative[i] is the number
+// of values less than or equal to bounds[i].  The last bound may be +Inf,
+// its bucket is then the overflow bucket of FromBounds.
+func FromCumulative(bounds []float64, cumulative []uint64) map[string]interface{} {
+	n := len(bounds)
+	if len(cumulative) < n {
+		n = len(cumulative)
+	}
+
+	finite := make([]float64, 0, n)
+	counts := make([]uint64, 0, n)
+	var prev uint64
+	for i := 0; i < n; i++ {
+		var count uint64
+		// counts decreasing in broken expositions are ignored
+		if cumulative[i] > prev {
+			count = cumulative[i] - prev
+			prev = cumulative[i]
+		}
+		if math.IsInf(bounds[i], 1) {
+			counts = append(counts, count)
+			break
+		}
+		finite = append(finite, bounds[i])
+		counts = append(counts, count)
+	}
+	return FromBounds(finite, counts)
+}
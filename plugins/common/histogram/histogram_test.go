@@ -0,0 +1,43 @@
+package histogram
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromBounds(t *testing.T) {
+	fields := FromBounds([]float64{0.1, 0.5, 1}, []uint64{3, 0, 2, 4})
+	require.Equal(t, map[string]interface{}{
+		"1.000000e-01": int64(3),
+		"1.000000e+00": int64(6),
+	}, fields)
+
+	// without the overflow bucket
+	fields = FromBounds([]float64{0.1, 0.5}, []uint64{1, 2})
+	require.Equal(t, map[string]interface{}{
+		"1.000000e-01": int64(1),
+		"5.000000e-01": int64(2),
+	}, fields)
+
+	require.Empty(t, FromBounds(nil, []uint64{5}))
+}
+
+func TestFromCumulative(t *testing.T) {
+	fields := FromCumulative(
+		[]float64{0.05, 0.1, 0.5, math.Inf(1)},
+		[]uint64{2, 2, 7, 9},
+	)
+	require.Equal(t, map[string]interface{}{
+		"5.000000e-02": int64(2),
+		"5.000000e-01": int64(7),
+	}, fields)
+
+	// decreasing counts are ignored
+	fields = FromCumulative([]float64{1, 2, 3}, []uint64{4, 3, 5})
+	require.Equal(t, map[string]interface{}{
+		"1.000000e+00": int64(4),
+		"3.000000e+00": int64(1),
+	}, fields)
+}
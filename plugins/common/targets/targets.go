@@ -0,0 +1,154 @@
+// Package targets loads the targets of check-style inputs from a remote
+// source, so that synthetic targets can be managed centrally.
+package targets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+)
+
+const (
+	defaultRefreshInterval = 5 * time.Minute
+	requestTimeout         = 10 * time.Second
+	maxResponseSize        = 10 * 1024 * 1024
+)
+
+// Source is embedded in the configuration of inputs, the targets of the
+// source are read as a JSON list of strings from:
+//
+//	file:///path/to/targets.json
+//	http(s)://host/path
+//	consul://host:8500/path/of/key (the raw value of the Consul KV key)
+type Source struct {
+	TargetsSource          string            `toml:"targets_source"`
+	TargetsRefreshInterval internal.Duration `toml:"targets_refresh_interval"`
+	TargetsToken           string            `toml:"targets_token"`
+
+	client  *http.Client
+	read    time.Time
+	targets []string
+}
+
+// Enabled reports whether a source is configured
+func (s *Source) Enabled() bool {
+	return s.TargetsSource != ""
+}
+
+// Targets returns the static targets followed by the targets of the source
+// not already listed, the source is read again once the refresh interval
+// elapsed.  When the source could not be read the targets of the last read
+// are used and the error is returned along with them.
+func (s *Source) Targets(ctx context.Context, static []string) ([]string, error) {
+	if !s.Enabled() {
+		return static, nil
+	}
+
+	interval := s.TargetsRefreshInterval.Duration
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+
+	var err error
+	if s.read.IsZero() || time.Since(s.read) >= interval {
+		var targets []string
+		targets, err = s.load(ctx)
+		if err == nil {
+			s.targets = targets
+			s.read = time.Now()
+		}
+	}
+
+	return merge(static, s.targets), err
+}
+
+func (s *Source) load(ctx context.Context) ([]string, error) {
+	u, err := url.Parse(s.TargetsSource)
+	if err != nil {
+		return nil, fmt.Errorf("targets source (%s): %w", s.TargetsSource, err)
+	}
+
+	var data []byte
+	switch u.Scheme {
+	case "file":
+		data, err = ioutil.ReadFile(u.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read targets: %w", err)
+		}
+	case "http", "https":
+		data, err = s.get(ctx, u.String(), "Authorization", "Bearer "+s.TargetsToken)
+		if err != nil {
+			return nil, err
+		}
+	case "consul":
+		key := strings.TrimPrefix(u.Path, "/")
+		data, err = s.get(ctx, "http://"+u.Host+"/v1/kv/"+key+"?raw", "X-Consul-Token", s.TargetsToken)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported targets source scheme %q", u.Scheme)
+	}
+
+	var targets []string
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("parse targets, expected a JSON list of strings: %w", err)
+	}
+	return targets, nil
+}
+
+func (s *Source) get(ctx context.Context, u, header, token string) ([]byte, error) {
+	if s.client == nil {
+		s.client = &http.Client{Timeout: requestTimeout}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("targets request: %w", err)
+	}
+	if s.TargetsToken != "" {
+		req.Header.Set(header, token)
+	}
+	req.Header.Set("User-Agent", internal.ProductToken())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get targets: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get targets: %s", resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("read targets: %w", err)
+	}
+	return data, nil
+}
+
+func merge(static, targets []string) []string {
+	if len(targets) == 0 {
+		return static
+	}
+	merged := make([]string, 0, len(static)+len(targets))
+	seen := make(map[string]bool, len(static)+len(targets))
+	for _, list := range [][]string{static, targets} {
+		for _, t := range list {
+			if t == "" || seen[t] {
+				continue
+			}
+			seen[t] = true
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
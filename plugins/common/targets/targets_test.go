@@ -0,0 +1,108 @@
+package targets
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetsDisabled(t *testing.T) {
+	s := &Source{}
+	targets, err := s.Targets(context.Background(), []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, targets)
+}
+
+func TestTargetsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "targets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "targets.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`["b", "c", "a"]`), 0600))
+
+	s := &Source{TargetsSource: "file://" + path}
+	targets, err := s.Targets(context.Background(), []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, targets)
+}
+
+func TestTargetsHTTP(t *testing.T) {
+	list := `["https://example.com"]`
+	status := http.StatusOK
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(list))
+	}))
+	defer ts.Close()
+
+	s := &Source{
+		TargetsSource:          ts.URL + "/targets",
+		TargetsToken:           "secret",
+		TargetsRefreshInterval: internal.Duration{Duration: time.Hour},
+	}
+	targets, err := s.Targets(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com"}, targets)
+
+	// not read again before the refresh interval
+	list = `["https://example.org"]`
+	targets, err = s.Targets(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.com"}, targets)
+	require.Equal(t, 1, requests)
+
+	s.read = s.read.Add(-time.Hour)
+	targets, err = s.Targets(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://example.org"}, targets)
+
+	// the last targets are kept when the source fails
+	status = http.StatusInternalServerError
+	s.read = s.read.Add(-time.Hour)
+	targets, err = s.Targets(context.Background(), nil)
+	require.Error(t, err)
+	require.Equal(t, []string{"https://example.org"}, targets)
+}
+
+func TestTargetsConsul(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/kv/probes/ping", r.URL.Path)
+		require.Equal(t, "raw", r.URL.RawQuery)
+		require.Equal(t, "secret", r.Header.Get("X-Consul-Token"))
+		_, _ = w.Write([]byte(`["10.0.0.1", "10.0.0.2"]`))
+	}))
+	defer ts.Close()
+
+	s := &Source{
+		TargetsSource: "consul://" + ts.Listener.Addr().String() + "/probes/ping",
+		TargetsToken:  "secret",
+	}
+	targets, err := s.Targets(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, targets)
+}
+
+func TestTargetsInvalid(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"targets": "a"}`))
+	}))
+	defer ts.Close()
+
+	for _, source := range []string{ts.URL, "ftp://example.com/targets"} {
+		s := &Source{TargetsSource: source}
+		_, err := s.Targets(context.Background(), nil)
+		require.Error(t, err)
+	}
+}
@@ -14,6 +14,11 @@ type ClientConfig struct {
 	TLSCert            string `toml:"tls_cert"`
 	TLSKey             string `toml:"tls_key"`
 	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
+
+	// SpiffeSocket is the address of a SPIFFE workload API providing the
+	// client certificate, e.g. unix:///run/spire/sockets/agent.sock
+	SpiffeSocket    string   `toml:"spiffe_socket"`
+	SpiffeServerIDs []string `toml:"spiffe_server_ids"`
 }
 
 // ServerConfig represents the standard server TLS config.
@@ -27,14 +32,15 @@ type ServerConfig struct {
 }
 
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
-// configured.
+// configured. The plugins building their own tls.Config in that case apply
+// the agent tls_policy with ApplyPolicy.
 func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 
 	// TODO: return default tls.Config; plugins should not call if they don't
 	// want TLS, this will require using another option to determine.  In the
 	// case of an HTTP plugin, you could use `https`.  Other plugins may need
 	// the dedicated option `TLSEnable`.
-	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify {
+	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify && c.SpiffeSocket == "" {
 		return nil, nil
 	}
 
@@ -51,13 +57,23 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 		tlsConfig.RootCAs = pool
 	}
 
-	if c.TLSCert != "" && c.TLSKey != "" {
+	if c.SpiffeSocket != "" {
+		if err := c.loadSpiffe(tlsConfig); err != nil {
+			return nil, err
+		}
+	} else if c.TLSCert != "" && c.TLSKey != "" {
 		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if p := currentPolicy(); p != nil {
+		if err := p.apply(tlsConfig, false, false); err != nil {
+			return nil, err
+		}
+	}
+
 	return tlsConfig, nil
 }
 
@@ -118,6 +134,12 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 			"tls min version %q can't be greater than tls max version %q", tlsConfig.MinVersion, tlsConfig.MaxVersion)
 	}
 
+	if p := currentPolicy(); p != nil {
+		if err := p.apply(tlsConfig, c.TLSMinVersion != "", len(c.TLSCipherSuites) != 0); err != nil {
+			return nil, err
+		}
+	}
+
 	return tlsConfig, nil
 }
 
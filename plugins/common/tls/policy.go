@@ -0,0 +1,128 @@
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Policy is the agent wide TLS policy enforced on the client and server TLS
+// configs of all the plugins. The settings of a plugin weaker than the
+// policy are rejected unless AllowPluginOverride is set.
+type Policy struct {
+	MinVersion          string   `toml:"min_version"`
+	CipherSuites        []string `toml:"cipher_suites"`
+	RequireVerify       bool     `toml:"require_verify"`
+	AllowPluginOverride bool     `toml:"allow_plugin_override"`
+
+	minVersion   uint16
+	cipherSuites []uint16
+}
+
+var (
+	policyMu sync.RWMutex
+	policy   *Policy
+)
+
+// SetPolicy validates and sets the agent wide TLS policy, an empty policy
+// removes it
+func SetPolicy(p Policy) error {
+	if p.MinVersion == "" && len(p.CipherSuites) == 0 && !p.RequireVerify {
+		policyMu.Lock()
+		policy = nil
+		policyMu.Unlock()
+		return nil
+	}
+
+	if p.MinVersion != "" {
+		version, err := ParseTLSVersion(p.MinVersion)
+		if err != nil {
+			return fmt.Errorf("tls_policy min_version: %w", err)
+		}
+		p.minVersion = version
+	}
+	if len(p.CipherSuites) != 0 {
+		suites, err := ParseCiphers(p.CipherSuites)
+		if err != nil {
+			return fmt.Errorf("tls_policy cipher_suites %s: %w", strings.Join(p.CipherSuites, ","), err)
+		}
+		p.cipherSuites = suites
+	}
+
+	policyMu.Lock()
+	policy = &p
+	policyMu.Unlock()
+	return nil
+}
+
+func currentPolicy() *Policy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return policy
+}
+
+// ApplyPolicy enforces the agent tls_policy on a tls.Config built by a
+// plugin without the tls options, its min version and cipher suites are
+// replaced by those of the policy
+func ApplyPolicy(cfg *tls.Config) error {
+	if p := currentPolicy(); p != nil {
+		return p.apply(cfg, false, false)
+	}
+	return nil
+}
+
+// apply enforces the policy on the tls.Config of a plugin. The plugin set
+// the minimum version and the cipher suites when minSet and ciphersSet, they
+// are otherwise those of the policy.
+func (p *Policy) apply(cfg *tls.Config, minSet, ciphersSet bool) error {
+	// a custom VerifyPeerCertificate, e.g. SPIFFE, replaces the default verification
+	insecure := cfg.InsecureSkipVerify && cfg.VerifyPeerCertificate == nil
+	if p.RequireVerify && insecure && !p.AllowPluginOverride {
+		return fmt.Errorf("insecure_skip_verify is not allowed by the agent tls_policy")
+	}
+
+	if p.minVersion != 0 && cfg.MinVersion < p.minVersion {
+		if minSet && !p.AllowPluginOverride {
+			return fmt.Errorf("tls min version %s is lower than the agent tls_policy min_version %s",
+				versionName(cfg.MinVersion), p.MinVersion)
+		}
+		if !minSet {
+			cfg.MinVersion = p.minVersion
+		}
+		if cfg.MaxVersion != 0 && cfg.MaxVersion < cfg.MinVersion {
+			return fmt.Errorf("tls max version %s is lower than the agent tls_policy min_version %s",
+				versionName(cfg.MaxVersion), p.MinVersion)
+		}
+	}
+
+	if len(p.cipherSuites) != 0 {
+		if !ciphersSet {
+			cfg.CipherSuites = p.cipherSuites
+			return nil
+		}
+		if p.AllowPluginOverride {
+			return nil
+		}
+		allowed := make(map[uint16]bool, len(p.cipherSuites))
+		for _, id := range p.cipherSuites {
+			allowed[id] = true
+		}
+		for _, id := range cfg.CipherSuites {
+			if !allowed[id] {
+				return fmt.Errorf("tls cipher suite %s is not allowed by the agent tls_policy", tls.CipherSuiteName(id))
+			}
+		}
+	}
+
+	return nil
+}
+
+func versionName(version uint16) string {
+	for name, v := range tlsVersionMap {
+		if v == version {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
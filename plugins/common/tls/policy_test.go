@@ -0,0 +1,167 @@
+package tls_test
+
+import (
+	cryptotls "crypto/tls"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPolicy(t *testing.T) {
+	defer func() { require.NoError(t, tls.SetPolicy(tls.Policy{})) }()
+
+	require.NoError(t, tls.SetPolicy(tls.Policy{MinVersion: "TLS12", CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}}))
+	require.Error(t, tls.SetPolicy(tls.Policy{MinVersion: "SSL3"}))
+	require.Error(t, tls.SetPolicy(tls.Policy{CipherSuites: []string{"NOPE"}}))
+}
+
+func TestPolicyClientConfig(t *testing.T) {
+	defer func() { require.NoError(t, tls.SetPolicy(tls.Policy{})) }()
+
+	tests := []struct {
+		name       string
+		policy     tls.Policy
+		client     tls.ClientConfig
+		expErr     bool
+		expNil     bool
+		expMin     uint16
+		expCiphers []uint16
+	}{
+		{
+			name:   "unset stays nil",
+			policy: tls.Policy{RequireVerify: true},
+			client: tls.ClientConfig{},
+			expNil: true,
+		},
+		{
+			// plain connections must not switch to TLS because of the policy
+			name:   "unset stays nil with min version and ciphers",
+			policy: tls.Policy{MinVersion: "TLS13", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+			client: tls.ClientConfig{},
+			expNil: true,
+		},
+		{
+			name:       "min version and ciphers",
+			policy:     tls.Policy{MinVersion: "TLS12", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+			client:     tls.ClientConfig{TLSCA: pki.CACertPath()},
+			expMin:     cryptotls.VersionTLS12,
+			expCiphers: []uint16{cryptotls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		},
+		{
+			name:   "insecure rejected",
+			policy: tls.Policy{RequireVerify: true},
+			client: tls.ClientConfig{InsecureSkipVerify: true},
+			expErr: true,
+		},
+		{
+			name:   "insecure override",
+			policy: tls.Policy{RequireVerify: true, AllowPluginOverride: true},
+			client: tls.ClientConfig{InsecureSkipVerify: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tls.SetPolicy(tt.policy))
+			tlsConfig, err := tt.client.TLSConfig()
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expNil {
+				require.Nil(t, tlsConfig)
+				return
+			}
+			if tt.expMin == 0 && tt.expCiphers == nil {
+				return
+			}
+			require.NotNil(t, tlsConfig)
+			require.Equal(t, tt.expMin, tlsConfig.MinVersion)
+			require.Equal(t, tt.expCiphers, tlsConfig.CipherSuites)
+		})
+	}
+}
+
+func TestApplyPolicy(t *testing.T) {
+	defer func() { require.NoError(t, tls.SetPolicy(tls.Policy{})) }()
+
+	cfg := &cryptotls.Config{MinVersion: cryptotls.VersionTLS12}
+	require.NoError(t, tls.ApplyPolicy(cfg))
+	require.Equal(t, uint16(cryptotls.VersionTLS12), cfg.MinVersion)
+
+	require.NoError(t, tls.SetPolicy(tls.Policy{
+		MinVersion:   "TLS13",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}))
+	require.NoError(t, tls.ApplyPolicy(cfg))
+	require.Equal(t, uint16(cryptotls.VersionTLS13), cfg.MinVersion)
+	require.Equal(t, []uint16{cryptotls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, cfg.CipherSuites)
+
+	require.NoError(t, tls.SetPolicy(tls.Policy{RequireVerify: true}))
+	require.Error(t, tls.ApplyPolicy(&cryptotls.Config{InsecureSkipVerify: true})) //nolint:gosec // rejected by the policy
+}
+
+func TestPolicyServerConfig(t *testing.T) {
+	defer func() { require.NoError(t, tls.SetPolicy(tls.Policy{})) }()
+
+	server := func(minVersion string, ciphers ...string) tls.ServerConfig {
+		return tls.ServerConfig{
+			TLSCert:         pki.ServerCertPath(),
+			TLSKey:          pki.ServerKeyPath(),
+			TLSMinVersion:   minVersion,
+			TLSCipherSuites: ciphers,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		policy tls.Policy
+		server tls.ServerConfig
+		expErr bool
+		expMin uint16
+	}{
+		{
+			name:   "default min version raised",
+			policy: tls.Policy{MinVersion: "TLS13"},
+			server: server(""),
+			expMin: cryptotls.VersionTLS13,
+		},
+		{
+			name:   "lower min version rejected",
+			policy: tls.Policy{MinVersion: "TLS13"},
+			server: server("TLS12"),
+			expErr: true,
+		},
+		{
+			name:   "lower min version override",
+			policy: tls.Policy{MinVersion: "TLS13", AllowPluginOverride: true},
+			server: server("TLS12"),
+			expMin: cryptotls.VersionTLS12,
+		},
+		{
+			name:   "cipher outside policy rejected",
+			policy: tls.Policy{CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+			server: server("", "TLS_RSA_WITH_AES_128_CBC_SHA"),
+			expErr: true,
+		},
+		{
+			name:   "cipher inside policy",
+			policy: tls.Policy{CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_AES_128_CBC_SHA"}},
+			server: server("", "TLS_RSA_WITH_AES_128_CBC_SHA"),
+			expMin: cryptotls.VersionTLS12,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tls.SetPolicy(tt.policy))
+			tlsConfig, err := tt.server.TLSConfig()
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expMin, tlsConfig.MinVersion)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package tls_test
+
+import (
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientConfigSpiffeInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		client tls.ClientConfig
+	}{
+		{
+			name: "with cert and key",
+			client: tls.ClientConfig{
+				SpiffeSocket: "unix:///tmp/agent.sock",
+				TLSCert:      pki.ClientCertPath(),
+				TLSKey:       pki.ClientKeyPath(),
+			},
+		},
+		{
+			name: "insecure without ca",
+			client: tls.ClientConfig{
+				SpiffeSocket:       "unix:///tmp/agent.sock",
+				InsecureSkipVerify: true,
+			},
+		},
+		{
+			name: "invalid server id",
+			client: tls.ClientConfig{
+				SpiffeSocket:    "unix:///tmp/agent.sock",
+				SpiffeServerIDs: []string{"https://example.org/server"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := tt.client.TLSConfig()
+			require.Error(t, err)
+			require.Nil(t, tlsConfig)
+		})
+	}
+}
@@ -4,30 +4,37 @@ package all
 import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/activemq"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/aerospike"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/amd_gpu"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/amqp_consumer"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/apache"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/apcupsd"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/audit_denials"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/aurora"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/azure_storage_queue"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/bcache"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/beanstalkd"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/bgp"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/bind"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/bond"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/burrow"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cassandra"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/ceph"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cgroup"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/chef"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/chrony"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/circ_http_json"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cisco_telemetry_mdt"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/clickhouse"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cloud_pubsub"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cloud_pubsub_push"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cloudflare"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cloudwatch"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/conntrack"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/consul"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/couchbase"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/couchdb"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cpu"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cups"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/dcos"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/disk"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/diskio"
@@ -36,19 +43,25 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/dns_query"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/docker"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/docker_log"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/domain_expiry"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/dovecot"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/ebpf_tcp"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/ecs"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/elasticsearch"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/env_sensors"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/etcd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/ethtool"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/eventhub_consumer"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/exec"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/execd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fail2ban"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fastly"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fibaro"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/file"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/filecount"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/filestat"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fireboard"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/flow_collector"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fluentd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/github"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/gnmi"
@@ -85,11 +98,14 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/lanz"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/leofs"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/linux_sysctl_fs"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/log_query"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/logstash"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/lustre2"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/macos"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/mailchimp"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/marklogic"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/mcrouter"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/mdraid"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/mem"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/memcached"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/mesos"
@@ -100,11 +116,14 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/mqtt_consumer"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/multifile"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/mysql"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nas"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nats"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nats_consumer"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/neptune_apex"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/net"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/net_response"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nfsstat"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nftables"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nginx"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nginx_plus"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nginx_plus_api"
@@ -116,12 +135,18 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nsq_consumer"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nstat"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/ntpq"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/numa"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nut"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nvidia_smi"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/opcua"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/openldap"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/openntpd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/opensmtpd"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/openstack"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/openvpn"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/openweathermap"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/oracle"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/package_updates"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/passenger"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/pf"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/pgbouncer"
@@ -130,11 +155,13 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/postfix"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/postgresql"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/postgresql_extensible"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/power"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/powerdns"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/powerdns_recursor"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/processes"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/procstat"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/prometheus"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/protocol_audit"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/proxmox"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/puppetagent"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/rabbitmq"
@@ -144,6 +171,7 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/rethinkdb"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/riak"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/salesforce"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/script_suite"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sensors"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sflow"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/smart"
@@ -151,10 +179,14 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/snmp_trap"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/socket_listener"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/solr"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sql"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sqlserver"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sqs_consumer"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/stackdriver"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/stackdriver_circonus"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/statsd"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/storage_sessions"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/strongswan"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/suricata"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/swap"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/synproxy"
@@ -168,10 +200,13 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/tengine"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/tomcat"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/trig"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/truenas"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/twemproxy"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/unbound"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/uwsgi"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/varnish"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/vault"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/voip"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/vsphere"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_eventlog"
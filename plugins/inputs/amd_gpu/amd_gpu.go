@@ -0,0 +1,371 @@
+//go:build linux
+// +build linux
+
+// Package amdgpu reports AMD GPU statistics from the amdgpu driver's sysfs
+// interface.
+package amdgpu
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const (
+	measurement        = "amd_gpu"
+	processMeasurement = "amd_gpu_process"
+	amdVendorID        = "0x1002"
+)
+
+type AMDGPU struct {
+	Log           cua.Logger `toml:"-"`
+	SysfsPath     string     `toml:"sysfs_path"`
+	ProcessMemory bool       `toml:"process_memory"`
+	procPath      string
+}
+
+const sampleConfig = `
+  ## Path to the drm class in sysfs
+  # sysfs_path = "/sys/class/drm"
+
+  ## Report the VRAM used by each process from the drm fdinfo of
+  ## /proc/<pid>/fdinfo, requires Linux 5.14 or later. Only processes the
+  ## agent is permitted to inspect are reported.
+  # process_memory = false
+`
+
+func (a *AMDGPU) Description() string {
+	return "Read AMD GPU utilization, memory, temperature, power and ECC errors from sysfs"
+}
+
+func (a *AMDGPU) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *AMDGPU) Gather(ctx context.Context, acc cua.Accumulator) error {
+	cards, err := filepath.Glob(filepath.Join(a.SysfsPath, "card[0-9]*"))
+	if err != nil {
+		return fmt.Errorf("listing cards: %w", err)
+	}
+
+	// cards by PCI address, for attributing process memory
+	slots := make(map[string]string)
+	for _, card := range cards {
+		// connectors, e.g. card0-DP-1, are listed alongside the cards
+		if strings.Contains(filepath.Base(card), "-") {
+			continue
+		}
+		device := filepath.Join(card, "device")
+		if vendor, err := readString(filepath.Join(device, "vendor")); err != nil || vendor != amdVendorID {
+			continue
+		}
+		slot := pciSlot(device)
+		if slot != "" {
+			slots[slot] = filepath.Base(card)
+		}
+		a.gatherCard(acc, filepath.Base(card), slot, device)
+	}
+
+	if a.ProcessMemory && len(slots) > 0 {
+		a.gatherProcesses(acc, slots)
+	}
+
+	return nil
+}
+
+func (a *AMDGPU) gatherCard(acc cua.Accumulator, card, slot, device string) {
+	tags := map[string]string{"card": card}
+	if slot != "" {
+		tags["pci_bus_id"] = slot
+	}
+	if name, err := readString(filepath.Join(device, "product_name")); err == nil && name != "" {
+		tags["name"] = name
+	}
+
+	fields := make(map[string]interface{})
+
+	if v, err := readInt(filepath.Join(device, "gpu_busy_percent")); err == nil {
+		fields["utilization_gpu"] = v
+	}
+	if v, err := readInt(filepath.Join(device, "mem_busy_percent")); err == nil {
+		fields["utilization_memory"] = v
+	}
+
+	// memory is reported in MiB to match nvidia_smi
+	total, terr := readInt(filepath.Join(device, "mem_info_vram_total"))
+	used, uerr := readInt(filepath.Join(device, "mem_info_vram_used"))
+	if terr == nil {
+		fields["memory_total"] = total >> 20
+	}
+	if uerr == nil {
+		fields["memory_used"] = used >> 20
+	}
+	if terr == nil && uerr == nil {
+		fields["memory_free"] = (total - used) >> 20
+	}
+
+	a.gatherHwmon(fields, device)
+
+	// ras/umc_err_count is only present on boards with ECC memory
+	if counts, err := readKeyValues(filepath.Join(device, "ras", "umc_err_count")); err == nil {
+		if v, ok := counts["ce"]; ok {
+			fields["ecc_errors_corrected"] = v
+		}
+		if v, ok := counts["ue"]; ok {
+			fields["ecc_errors_uncorrected"] = v
+		}
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+	acc.AddGauge(measurement, fields, tags)
+}
+
+// hwmonTemps maps the amdgpu temperature labels to field names
+var hwmonTemps = map[string]string{
+	"edge":     "temperature_gpu",
+	"junction": "temperature_junction",
+	"mem":      "temperature_memory",
+}
+
+// gatherHwmon reads temperatures, power draw and fan speed from the card's
+// hwmon device
+func (a *AMDGPU) gatherHwmon(fields map[string]interface{}, device string) {
+	hwmons, _ := filepath.Glob(filepath.Join(device, "hwmon", "hwmon*"))
+	for _, hwmon := range hwmons {
+		temps, _ := filepath.Glob(filepath.Join(hwmon, "temp*_input"))
+		for _, input := range temps {
+			label, err := readString(strings.TrimSuffix(input, "_input") + "_label")
+			if err != nil {
+				label = "edge"
+			}
+			field, ok := hwmonTemps[label]
+			if !ok {
+				continue
+			}
+			if v, err := readInt(input); err == nil {
+				fields[field] = float64(v) / 1000
+			}
+		}
+
+		// power1_average is reported by older boards, power1_input by newer ones
+		for _, name := range []string{"power1_average", "power1_input"} {
+			if v, err := readInt(filepath.Join(hwmon, name)); err == nil {
+				fields["power_draw"] = float64(v) / 1000000
+				break
+			}
+		}
+
+		if v, err := readInt(filepath.Join(hwmon, "fan1_input")); err == nil {
+			fields["fan_speed_rpm"] = v
+		}
+	}
+}
+
+// processVRAM is the VRAM used by a process on one card
+type processVRAM struct {
+	slot string
+	pid  string
+	kib  int64
+}
+
+// gatherProcesses reports the VRAM used by each process with an open amdgpu
+// device. A drm client can be shared by several descriptors, or processes
+// after a fork, so each client is only counted once.
+func (a *AMDGPU) gatherProcesses(acc cua.Accumulator, slots map[string]string) {
+	pids, err := filepath.Glob(filepath.Join(a.procPath, "[0-9]*"))
+	if err != nil {
+		return
+	}
+
+	clients := make(map[string]bool)
+	var usage []*processVRAM
+	for _, dir := range pids {
+		pid := filepath.Base(dir)
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			// the process exited or is not ours to inspect
+			continue
+		}
+		perSlot := make(map[string]*processVRAM)
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "/dev/dri/") {
+				continue
+			}
+			info, err := readFdinfo(filepath.Join(dir, "fdinfo", fd.Name()))
+			if err != nil || info["drm-driver"] != "amdgpu" {
+				continue
+			}
+			slot := info["drm-pdev"]
+			if _, ok := slots[slot]; !ok {
+				continue
+			}
+			client := slot + "/" + info["drm-client-id"]
+			if clients[client] {
+				continue
+			}
+			clients[client] = true
+
+			vram, ok := info["drm-memory-vram"]
+			if !ok {
+				vram, ok = info["drm-total-vram"]
+			}
+			if !ok {
+				continue
+			}
+			kib, err := parseKiB(vram)
+			if err != nil {
+				continue
+			}
+			u, ok := perSlot[slot]
+			if !ok {
+				u = &processVRAM{slot: slot, pid: pid}
+				perSlot[slot] = u
+				usage = append(usage, u)
+			}
+			u.kib += kib
+		}
+	}
+
+	for _, u := range usage {
+		tags := map[string]string{
+			"card":       slots[u.slot],
+			"pci_bus_id": u.slot,
+		}
+		if name, err := readString(filepath.Join(a.procPath, u.pid, "comm")); err == nil && name != "" {
+			tags["process_name"] = name
+		}
+		fields := map[string]interface{}{
+			"used_memory": u.kib >> 10,
+		}
+		if pid, err := strconv.ParseInt(u.pid, 10, 64); err == nil {
+			fields["pid"] = pid
+		}
+		acc.AddGauge(processMeasurement, fields, tags)
+	}
+}
+
+// readFdinfo parses the "key:\tvalue" lines of a fdinfo file
+func readFdinfo(path string) (map[string]string, error) {
+	s, err := readString(path)
+	if err != nil {
+		return nil, err
+	}
+	info := make(map[string]string)
+	for _, line := range strings.Split(s, "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		info[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return info, nil
+}
+
+// parseKiB parses a drm memory value, e.g. "524288 KiB", into KiB
+func parseKiB(s string) (int64, error) {
+	f := strings.Fields(s)
+	if len(f) == 0 {
+		return 0, fmt.Errorf("empty memory value")
+	}
+	v, err := strconv.ParseInt(f[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse memory value %q: %w", s, err)
+	}
+	if len(f) == 1 {
+		return v >> 10, nil
+	}
+	switch f[1] {
+	case "KiB":
+		return v, nil
+	case "MiB":
+		return v << 10, nil
+	case "GiB":
+		return v << 20, nil
+	default:
+		return 0, fmt.Errorf("unknown memory unit %q", f[1])
+	}
+}
+
+// pciSlot returns the PCI address of the device from its uevent file
+func pciSlot(device string) string {
+	f, err := os.Open(filepath.Join(device, "uevent"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v := strings.TrimPrefix(scanner.Text(), "PCI_SLOT_NAME="); v != scanner.Text() {
+			return v
+		}
+	}
+	return ""
+}
+
+func readString(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func readInt(path string) (int64, error) {
+	s, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// readKeyValues parses files of "key: value" lines, e.g. "ue: 0"
+func readKeyValues(path string) (map[string]int64, error) {
+	s, err := readString(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]int64)
+	for _, line := range strings.Split(s, "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSpace(kv[0])] = v
+	}
+	return values, nil
+}
+
+func getHostProc() string {
+	if procPath := os.Getenv("HOST_PROC"); procPath != "" {
+		return procPath
+	}
+	return "/proc"
+}
+
+func init() {
+	inputs.Add("amd_gpu", func() cua.Input {
+		return &AMDGPU{
+			SysfsPath: "/sys/class/drm",
+			procPath:  getHostProc(),
+		}
+	})
+}
@@ -0,0 +1,81 @@
+//go:build linux
+// +build linux
+
+package amdgpu
+
+import (
+	"context"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	a := &AMDGPU{
+		Log:       testutil.Logger{},
+		SysfsPath: "testdata/drm",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	// card0-DP-1 is a connector and card1 is not an AMD GPU
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "amd_gpu",
+		map[string]interface{}{
+			"utilization_gpu":        int64(73),
+			"utilization_memory":     int64(12),
+			"memory_total":           int64(16368),
+			"memory_used":            int64(4096),
+			"memory_free":            int64(12272),
+			"temperature_gpu":        float64(54),
+			"temperature_junction":   float64(61),
+			"temperature_memory":     float64(58),
+			"power_draw":             float64(187),
+			"fan_speed_rpm":          int64(1250),
+			"ecc_errors_corrected":   int64(3),
+			"ecc_errors_uncorrected": int64(0),
+		},
+		map[string]string{"card": "card0", "pci_bus_id": "0000:03:00.0"})
+}
+
+func TestGatherNoCards(t *testing.T) {
+	a := &AMDGPU{
+		Log:       testutil.Logger{},
+		SysfsPath: "testdata/missing",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Metrics)
+}
+
+func TestGatherProcessMemory(t *testing.T) {
+	a := &AMDGPU{
+		Log:           testutil.Logger{},
+		SysfsPath:     "testdata/drm",
+		ProcessMemory: true,
+		procPath:      "testdata/proc",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	// fd 3 and 4 of 1234 share drm client 42, which is only counted once
+	acc.AssertContainsTaggedFields(t, "amd_gpu_process",
+		map[string]interface{}{
+			"used_memory": int64(8192),
+			"pid":         int64(1234),
+		},
+		map[string]string{"card": "card0", "pci_bus_id": "0000:03:00.0", "process_name": "python3"})
+	acc.AssertContainsTaggedFields(t, "amd_gpu_process",
+		map[string]interface{}{
+			"used_memory": int64(256),
+			"pid":         int64(1300),
+		},
+		map[string]string{"card": "card0", "pci_bus_id": "0000:03:00.0", "process_name": "Xorg"})
+	require.Len(t, acc.Metrics, 3)
+}
@@ -38,9 +38,10 @@ type AMQPConsumer struct {
 	MaxUndeliveredMessages int               `toml:"max_undelivered_messages"`
 
 	// Queue Name
-	Queue           string `toml:"queue"`
-	QueueDurability string `toml:"queue_durability"`
-	QueuePassive    bool   `toml:"queue_passive"`
+	Queue           string                 `toml:"queue"`
+	QueueDurability string                 `toml:"queue_durability"`
+	QueuePassive    bool                   `toml:"queue_passive"`
+	QueueArguments  map[string]interface{} `toml:"queue_arguments"`
 
 	// Binding Key
 	BindingKey string `toml:"binding_key"`
@@ -127,6 +128,10 @@ func (a *AMQPConsumer) SampleConfig() string {
   ## If true, queue will be passively declared.
   # queue_passive = false
 
+  ## Additional queue arguments.
+  # queue_arguments = { }
+  # queue_arguments = {"x-queue-type" = "quorum", "x-max-length" = 100000}
+
   ## A binding between the exchange and queue using this binding key is
   ## created.  If unset, no binding is created.
   binding_key = "#"
@@ -321,7 +326,8 @@ func (a *AMQPConsumer) connect(amqpConf *amqp.Config) (<-chan amqp.Delivery, err
 		ch,
 		a.Queue,
 		a.QueueDurability,
-		a.QueuePassive)
+		a.QueuePassive,
+		amqp.Table(a.QueueArguments))
 	if err != nil {
 		return nil, err
 	}
@@ -361,7 +367,7 @@ func (a *AMQPConsumer) connect(amqpConf *amqp.Config) (<-chan amqp.Delivery, err
 		return nil, fmt.Errorf("Failed establishing connection to queue: %w", err)
 	}
 
-	return msgs, fmt.Errorf("amqp chan consume: %w", err)
+	return msgs, nil
 }
 
 func declareExchange(
@@ -405,6 +411,7 @@ func declareQueue(
 	queueName string,
 	queueDurability string,
 	queuePassive bool,
+	queueArguments amqp.Table,
 ) (*amqp.Queue, error) {
 	var queue amqp.Queue
 	var err error
@@ -424,7 +431,7 @@ func declareQueue(
 			false,        // delete when unused
 			false,        // exclusive
 			false,        // no-wait
-			nil,          // arguments
+			queueArguments,
 		)
 	} else {
 		queue, err = channel.QueueDeclare(
@@ -433,7 +440,7 @@ func declareQueue(
 			false,        // delete when unused
 			false,        // exclusive
 			false,        // no-wait
-			nil,          // arguments
+			queueArguments,
 		)
 	}
 	if err != nil {
@@ -16,6 +16,9 @@ import (
 
 const defaultAddress = "tcp://127.0.0.1:3551"
 
+// statusOnBattery is the ONBATT bit of the status flags
+const statusOnBattery = 0x10
+
 var defaultTimeout = internal.Duration{Duration: time.Second * 5}
 
 type ApcUpsd struct {
@@ -73,6 +76,7 @@ func (h *ApcUpsd) Gather(ctx context.Context, acc cua.Accumulator) error {
 
 		fields := map[string]interface{}{
 			"status_flags":            flags,
+			"on_battery":              int((flags & statusOnBattery) >> 4),
 			"input_voltage":           status.LineVoltage,
 			"load_percent":            status.LoadPercent,
 			"battery_charge_percent":  status.BatteryChargePercent,
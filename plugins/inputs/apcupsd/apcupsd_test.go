@@ -129,6 +129,7 @@ func TestApcupsdGather(t *testing.T) {
 				},
 				fields: map[string]interface{}{
 					"status_flags":            uint64(8),
+					"on_battery":              0,
 					"battery_charge_percent":  float64(0),
 					"battery_voltage":         float64(0),
 					"input_frequency":         float64(0),
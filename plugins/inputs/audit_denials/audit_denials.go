@@ -0,0 +1,194 @@
+package auditdenials
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const (
+	macSELinux  = "selinux"
+	macAppArmor = "apparmor"
+)
+
+var (
+	scontextRE = regexp.MustCompile(`\bscontext=(\S+)`)
+	profileRE  = regexp.MustCompile(`\bprofile=(?:"([^"]*)"|(\S+))`)
+)
+
+// AuditDenials counts the SELinux AVC and AppArmor denials logged since the
+// previous gather
+type AuditDenials struct {
+	Files         []string   `toml:"files"`
+	FromBeginning bool       `toml:"from_beginning"`
+	Log           cua.Logger `toml:"-"`
+
+	files map[string]*logFile
+	// denial keys reported before, they are reported with 0 when there
+	// are no new denials
+	seen map[denialKey]bool
+}
+
+type logFile struct {
+	info   os.FileInfo
+	offset int64
+}
+
+type denialKey struct {
+	mac     string
+	context string
+}
+
+var sampleConfig = `
+  ## Logs with the audit records, the audit log of auditd, or the kernel log
+  ## when auditd is not running. The files are read from the end on start
+  ## and rotated files are followed.
+  # files = ["/var/log/audit/audit.log"]
+
+  ## Count the denials already in the files on start
+  # from_beginning = false
+`
+
+// SampleConfig returns sample configuration message
+func (a *AuditDenials) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns description of AuditDenials plugin
+func (a *AuditDenials) Description() string {
+	return "Count SELinux AVC and AppArmor denials from the audit log"
+}
+
+// Init sets the default file
+func (a *AuditDenials) Init() error {
+	if len(a.Files) == 0 {
+		a.Files = []string{"/var/log/audit/audit.log"}
+	}
+	a.files = make(map[string]*logFile)
+	a.seen = make(map[denialKey]bool)
+	return nil
+}
+
+// Gather counts the denials logged since the previous gather
+func (a *AuditDenials) Gather(ctx context.Context, acc cua.Accumulator) error {
+	counts := make(map[denialKey]int64)
+	for key := range a.seen {
+		counts[key] = 0
+	}
+
+	for _, file := range a.Files {
+		if err := a.readFile(file, counts); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	for key, count := range counts {
+		a.seen[key] = true
+		tags := map[string]string{"mac": key.mac}
+		if key.mac == macSELinux {
+			tags["scontext"] = key.context
+		} else {
+			tags["profile"] = key.context
+		}
+		acc.AddFields("audit_denials", map[string]interface{}{"denials": count}, tags)
+	}
+
+	return nil
+}
+
+// readFile counts the denials in the lines added to the file since the
+// previous read, a new or truncated file is read from the beginning
+func (a *AuditDenials) readFile(path string, counts map[denialKey]int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat (%s): %w", path, err)
+	}
+
+	lf, ok := a.files[path]
+	switch {
+	case !ok:
+		lf = &logFile{}
+		if !a.FromBeginning {
+			lf.offset = info.Size()
+		}
+		a.files[path] = lf
+	case !os.SameFile(lf.info, info) || info.Size() < lf.offset:
+		// rotated or truncated, the lines written to the old file after
+		// the previous read are lost
+		lf.offset = 0
+	}
+	lf.info = info
+
+	if info.Size() == lf.offset {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open (%s): %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(lf.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek (%s): %w", path, err)
+	}
+
+	rdr := bufio.NewReader(f)
+	for {
+		line, err := rdr.ReadBytes('\n')
+		if err != nil {
+			// a partial line is read again once it is complete
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("read (%s): %w", path, err)
+		}
+		lf.offset += int64(len(line))
+
+		if key, ok := parseDenial(line); ok {
+			counts[key]++
+		}
+	}
+}
+
+// parseDenial returns the SELinux source context or AppArmor profile of a
+// denial, ie:
+//
+//	type=AVC msg=audit(1602669642.123:456): avc:  denied  { read } for  pid=1234 comm="nginx" name="index.html" scontext=system_u:system_r:httpd_t:s0 tcontext=unconfined_u:object_r:user_home_t:s0 tclass=file permissive=0
+//	type=AVC msg=audit(1602669642.123:457): apparmor="DENIED" operation="open" profile="/usr/sbin/ntpd" name="/etc/ssl/openssl.cnf" pid=1234 comm="ntpd"
+func parseDenial(line []byte) (denialKey, bool) {
+	switch {
+	case bytes.Contains(line, []byte(`apparmor="DENIED"`)):
+		m := profileRE.FindSubmatch(line)
+		if m == nil {
+			return denialKey{}, false
+		}
+		profile := string(m[1])
+		if len(m[2]) > 0 {
+			// profile names with spaces are hex encoded, not quoted
+			profile = string(m[2])
+		}
+		return denialKey{mac: macAppArmor, context: profile}, true
+	case bytes.Contains(line, []byte("avc:")) && bytes.Contains(line, []byte(" denied ")):
+		m := scontextRE.FindSubmatch(line)
+		if m == nil {
+			return denialKey{}, false
+		}
+		return denialKey{mac: macSELinux, context: string(m[1])}, true
+	}
+	return denialKey{}, false
+}
+
+func init() {
+	inputs.Add("audit_denials", func() cua.Input {
+		return &AuditDenials{}
+	})
+}
@@ -0,0 +1,108 @@
+package auditdenials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const auditLog = `type=SYSCALL msg=audit(1602669642.123:456): arch=c000003e syscall=2 success=no exit=-13 comm="nginx" exe="/usr/sbin/nginx" subj=system_u:system_r:httpd_t:s0 key=(null)
+type=AVC msg=audit(1602669642.123:456): avc:  denied  { read } for  pid=1234 comm="nginx" name="index.html" dev="dm-0" ino=1234 scontext=system_u:system_r:httpd_t:s0 tcontext=unconfined_u:object_r:user_home_t:s0 tclass=file permissive=0
+type=AVC msg=audit(1602669643.123:457): avc:  denied  { name_connect } for  pid=1234 comm="nginx" dest=8080 scontext=system_u:system_r:httpd_t:s0 tcontext=system_u:object_r:http_cache_port_t:s0 tclass=tcp_socket permissive=0
+type=AVC msg=audit(1602669644.123:458): avc:  granted  { setenforce } for  pid=1 comm="load_policy" scontext=system_u:system_r:init_t:s0 tcontext=system_u:object_r:security_t:s0 tclass=security
+type=AVC msg=audit(1602669645.123:459): apparmor="DENIED" operation="open" profile="/usr/sbin/ntpd" name="/etc/ssl/openssl.cnf" pid=1234 comm="ntpd" requested_mask="r" denied_mask="r" fsuid=0 ouid=0
+`
+
+const kernLog = `Oct 14 10:00:00 host kernel: [ 12.345678] audit: type=1400 audit(1602669600.123:12): apparmor="DENIED" operation="capable" profile=2F7573722F62696E2F6D7920617070 pid=99 comm="my app" capability=12 capname="net_admin"
+`
+
+func TestParseDenial(t *testing.T) {
+	key, ok := parseDenial([]byte(kernLog))
+	require.True(t, ok)
+	require.Equal(t, denialKey{mac: macAppArmor, context: "2F7573722F62696E2F6D7920617070"}, key)
+
+	_, ok = parseDenial([]byte("type=USER_LOGIN msg=audit(1602669600.123:13): pid=1 res=success\n"))
+	require.False(t, ok)
+}
+
+func TestGather(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(t, os.WriteFile(file, []byte(auditLog), 0600))
+
+	a := &AuditDenials{Files: []string{file}, FromBeginning: true}
+	require.NoError(t, a.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+	acc.AssertContainsTaggedFields(t, "audit_denials",
+		map[string]interface{}{"denials": int64(2)},
+		map[string]string{"mac": "selinux", "scontext": "system_u:system_r:httpd_t:s0"})
+	acc.AssertContainsTaggedFields(t, "audit_denials",
+		map[string]interface{}{"denials": int64(1)},
+		map[string]string{"mac": "apparmor", "profile": "/usr/sbin/ntpd"})
+	require.Len(t, acc.Metrics, 2)
+
+	// only the lines added since, a partial line waits for its newline
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(auditLog[:len(auditLog)-20])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	acc.ClearMetrics()
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	acc.AssertContainsTaggedFields(t, "audit_denials",
+		map[string]interface{}{"denials": int64(2)},
+		map[string]string{"mac": "selinux", "scontext": "system_u:system_r:httpd_t:s0"})
+	acc.AssertContainsTaggedFields(t, "audit_denials",
+		map[string]interface{}{"denials": int64(0)},
+		map[string]string{"mac": "apparmor", "profile": "/usr/sbin/ntpd"})
+
+	f, err = os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(auditLog[len(auditLog)-20:])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	acc.ClearMetrics()
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	acc.AssertContainsTaggedFields(t, "audit_denials",
+		map[string]interface{}{"denials": int64(1)},
+		map[string]string{"mac": "apparmor", "profile": "/usr/sbin/ntpd"})
+}
+
+func TestGatherRotated(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "audit.log")
+	require.NoError(t, os.WriteFile(file, []byte(auditLog), 0600))
+
+	a := &AuditDenials{Files: []string{file}}
+	require.NoError(t, a.Init())
+
+	// the existing denials are skipped on start
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	require.Len(t, acc.Metrics, 0)
+
+	require.NoError(t, os.Rename(file, file+".1"))
+	require.NoError(t, os.WriteFile(file, []byte(kernLog), 0600))
+
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	acc.AssertContainsTaggedFields(t, "audit_denials",
+		map[string]interface{}{"denials": int64(1)},
+		map[string]string{"mac": "apparmor", "profile": "2F7573722F62696E2F6D7920617070"})
+}
+
+func TestGatherMissingFile(t *testing.T) {
+	a := &AuditDenials{Files: []string{filepath.Join(t.TempDir(), "audit.log")}}
+	require.NoError(t, a.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+}
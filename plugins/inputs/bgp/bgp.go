@@ -0,0 +1,175 @@
+// Package bgp implements a plugin reporting the state, prefix counts and
+// flaps of BGP peers, from the BMP sessions of the routers or from the FRR
+// and GoBGP daemons.
+package bgp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const (
+	sourceBMP   = "bmp"
+	sourceFRR   = "frr"
+	sourceGoBGP = "gobgp"
+)
+
+// runner runs a command and returns its standard output
+type runner func(timeout time.Duration, useSudo bool, name string, args ...string) ([]byte, error)
+
+// BGP reports the state of the BGP peers
+type BGP struct {
+	Source         string            `toml:"source"`
+	ServiceAddress string            `toml:"service_address"`
+	VtyshPath      string            `toml:"vtysh_path"`
+	GoBGPPath      string            `toml:"gobgp_path"`
+	UseSudo        bool              `toml:"use_sudo"`
+	Timeout        internal.Duration `toml:"timeout"`
+
+	Log cua.Logger `toml:"-"`
+
+	run runner
+
+	// BMP sessions
+	sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	routers  map[string]*bmpRouter
+	peers    map[bmpPeerKey]*bmpPeer
+	wg       sync.WaitGroup
+}
+
+const sampleConfig = `
+  ## Source of the peer states, one of:
+  ##   "bmp"   - listen for the BGP Monitoring Protocol (RFC 7854) sessions
+  ##             of the routers
+  ##   "frr"   - run "show bgp vrf all summary json" with vtysh
+  ##   "gobgp" - run "gobgp neighbor -j"
+  source = "frr"
+
+  ## Address to listen for the BMP sessions, the routers connect to it
+  # service_address = "tcp://:11019"
+
+  ## Paths of the vtysh and gobgp commands
+  # vtysh_path = "vtysh"
+  # gobgp_path = "gobgp"
+
+  ## vtysh usually requires root or the frrvty group, adjust your sudo
+  ## settings appropriately if using this option ("sudo vtysh")
+  # use_sudo = false
+
+  ## Timeout of the vtysh and gobgp commands
+  # timeout = "5s"
+`
+
+func (*BGP) Description() string {
+	return "Read the state, prefix counts and flaps of BGP peers from BMP, FRR or GoBGP"
+}
+
+func (*BGP) SampleConfig() string {
+	return sampleConfig
+}
+
+func (b *BGP) Init() error {
+	switch b.Source {
+	case sourceBMP, sourceFRR, sourceGoBGP:
+	default:
+		return fmt.Errorf("invalid source %q, must be one of bmp, frr or gobgp", b.Source)
+	}
+
+	b.conns = make(map[net.Conn]struct{})
+	b.routers = make(map[string]*bmpRouter)
+	b.peers = make(map[bmpPeerKey]*bmpPeer)
+	return nil
+}
+
+// Start listens for the BMP sessions, it does nothing for the other sources
+func (b *BGP) Start(_ context.Context, acc cua.Accumulator) error {
+	if b.Source != sourceBMP {
+		return nil
+	}
+	return b.listen(acc)
+}
+
+func (b *BGP) Stop() {
+	if b.listener == nil {
+		return
+	}
+	b.listener.Close()
+	b.Lock()
+	for conn := range b.conns {
+		conn.Close()
+	}
+	b.Unlock()
+	b.wg.Wait()
+	b.listener = nil
+}
+
+func (b *BGP) Gather(_ context.Context, acc cua.Accumulator) error {
+	switch b.Source {
+	case sourceBMP:
+		b.gatherBMP(acc, time.Now())
+	case sourceFRR:
+		out, err := b.run(b.Timeout.Duration, b.UseSudo, b.VtyshPath, "-c", "show bgp vrf all summary json")
+		if err != nil {
+			return err
+		}
+		return gatherFRR(acc, out)
+	case sourceGoBGP:
+		out, err := b.run(b.Timeout.Duration, b.UseSudo, b.GoBGPPath, "neighbor", "-j")
+		if err != nil {
+			return err
+		}
+		return gatherGoBGP(acc, out, time.Now())
+	}
+	return nil
+}
+
+// established returns 1 for the Established state
+func established(ok bool) int {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+func runCommand(timeout time.Duration, useSudo bool, name string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found: %w", name, err)
+	}
+	if useSudo {
+		args = append([]string{path}, args...)
+		path = "sudo"
+	}
+
+	cmd := exec.Command(path, args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := internal.RunTimeout(cmd, timeout); err != nil {
+		return nil, fmt.Errorf("run %s: %w", name, err)
+	}
+	return out.Bytes(), nil
+}
+
+func init() {
+	inputs.Add("bgp", func() cua.Input {
+		return &BGP{
+			Source:         sourceFRR,
+			ServiceAddress: "tcp://:11019",
+			VtyshPath:      "vtysh",
+			GoBGPPath:      "gobgp",
+			Timeout:        internal.Duration{Duration: 5 * time.Second},
+			run:            runCommand,
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package bgp
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const frrOutput = `{
+"default":{
+  "ipv4Unicast":{
+    "routerId":"10.0.0.1",
+    "as":65001,
+    "vrfId":0,
+    "vrfName":"default",
+    "peers":{
+      "10.0.0.2":{
+        "remoteAs":65002,
+        "msgRcvd":1024,
+        "msgSent":1030,
+        "peerUptime":"01:00:00",
+        "peerUptimeMsec":3600000,
+        "pfxRcd":120,
+        "pfxSnt":4,
+        "state":"Established",
+        "peerState":"OK",
+        "connectionsEstablished":3,
+        "connectionsDropped":2
+      },
+      "10.0.0.3":{
+        "remoteAs":65003,
+        "peerUptime":"never",
+        "peerUptimeMsec":0,
+        "state":"Active",
+        "peerState":"OK",
+        "connectionsEstablished":0,
+        "connectionsDropped":0
+      }
+    },
+    "failedPeers":1,
+    "totalPeers":2
+  }
+},
+"blue":{
+  "vrfId":7,
+  "vrfName":"blue"
+}
+}`
+
+const gobgpOutput = `[
+  {
+    "conf": {"neighbor_address": "192.0.2.2", "peer_asn": 65002},
+    "state": {"session_state": 6, "flops": 1},
+    "timers": {"state": {"uptime": {"seconds": 1600000000}}},
+    "afi_safis": [
+      {"state": {"received": 10, "accepted": 8, "advertised": 3}},
+      {"state": {"received": 5, "accepted": 5, "advertised": 1}}
+    ]
+  },
+  {
+    "conf": {"neighbor_address": "192.0.2.3", "peer_as": 65003},
+    "state": {"session_state": "ACTIVE"}
+  }
+]`
+
+func TestFRR(t *testing.T) {
+	b := &BGP{
+		Source:    sourceFRR,
+		VtyshPath: "vtysh",
+		run: func(_ time.Duration, _ bool, name string, args ...string) ([]byte, error) {
+			require.Equal(t, "vtysh", name)
+			require.Equal(t, []string{"-c", "show bgp vrf all summary json"}, args)
+			return []byte(frrOutput), nil
+		},
+	}
+	require.NoError(t, b.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, b.Gather(context.Background(), &acc))
+
+	expected := []cua.Metric{
+		testutil.MustMetric("bgp_peer",
+			map[string]string{"peer": "10.0.0.2", "peer_as": "65002", "vrf": "default", "afi_safi": "ipv4Unicast"},
+			map[string]interface{}{
+				"state":             "Established",
+				"established":       1,
+				"uptime":            int64(3600),
+				"flaps":             uint64(2),
+				"connections":       uint64(3),
+				"prefixes_received": uint64(120),
+				"prefixes_sent":     uint64(4),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("bgp_peer",
+			map[string]string{"peer": "10.0.0.3", "peer_as": "65003", "vrf": "default", "afi_safi": "ipv4Unicast"},
+			map[string]interface{}{
+				"state":       "Active",
+				"established": 0,
+				"flaps":       uint64(0),
+				"connections": uint64(0),
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+}
+
+func TestGoBGP(t *testing.T) {
+	var acc testutil.Accumulator
+	now := time.Unix(1600000600, 0)
+	require.NoError(t, gatherGoBGP(&acc, []byte(gobgpOutput), now))
+
+	expected := []cua.Metric{
+		testutil.MustMetric("bgp_peer",
+			map[string]string{"peer": "192.0.2.2", "peer_as": "65002"},
+			map[string]interface{}{
+				"state":             "Established",
+				"established":       1,
+				"uptime":            int64(600),
+				"flaps":             uint64(1),
+				"prefixes_received": uint64(15),
+				"prefixes_accepted": uint64(13),
+				"prefixes_sent":     uint64(4),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("bgp_peer",
+			map[string]string{"peer": "192.0.2.3", "peer_as": "65003"},
+			map[string]interface{}{
+				"state":       "Active",
+				"established": 0,
+				"flaps":       uint64(0),
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+}
+
+func TestInvalidSource(t *testing.T) {
+	b := &BGP{Source: "bird"}
+	require.EqualError(t, b.Init(), `invalid source "bird", must be one of bmp, frr or gobgp`)
+}
+
+// bmpMessage returns a BMP message with the common header
+func bmpMessage(msgType uint8, body []byte) []byte {
+	msg := []byte{bmpVersion, 0, 0, 0, 0, msgType}
+	binary.BigEndian.PutUint32(msg[1:], uint32(bmpHeaderLength+len(body)))
+	return append(msg, body...)
+}
+
+// peerHeader returns the per-peer header of an IPv4 peer
+func peerHeader(address string, as uint32, sec uint32) []byte {
+	h := make([]byte, bmpPeerHeaderLen)
+	copy(h[22:], net.ParseIP(address).To4())
+	binary.BigEndian.PutUint32(h[26:], as)
+	binary.BigEndian.PutUint32(h[34:], sec)
+	return h
+}
+
+func TestBMP(t *testing.T) {
+	b := &BGP{
+		Source:         sourceBMP,
+		ServiceAddress: "tcp://127.0.0.1:0",
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, b.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, b.Start(context.Background(), &acc))
+	defer b.Stop()
+
+	conn, err := net.Dial("tcp", b.Address().String())
+	require.NoError(t, err)
+
+	// initiation with the sysName
+	info := []byte{0, bmpInfoSysName, 0, 4}
+	info = append(info, "edge"...)
+	msgs := bmpMessage(bmpInitiation, info)
+
+	// peer up, down and up again
+	up := peerHeader("198.51.100.1", 64512, 1600000000)
+	msgs = append(msgs, bmpMessage(bmpPeerUp, up)...)
+	msgs = append(msgs, bmpMessage(bmpPeerDown, append(peerHeader("198.51.100.1", 64512, 1600000100), 1))...)
+	msgs = append(msgs, bmpMessage(bmpPeerUp, peerHeader("198.51.100.1", 64512, 1600000200))...)
+	msgs = append(msgs, bmpMessage(bmpRouteMonitoring, peerHeader("198.51.100.1", 64512, 1600000300))...)
+
+	// statistics with the Adj-RIB-In routes per AFI/SAFI
+	stats := peerHeader("198.51.100.1", 64512, 1600000300)
+	stats = append(stats, 0, 0, 0, 2)
+	for _, n := range []uint64{100, 20} {
+		tlv := make([]byte, 4+11)
+		binary.BigEndian.PutUint16(tlv, bmpStatAdjRIBInFamily)
+		binary.BigEndian.PutUint16(tlv[2:], 11)
+		binary.BigEndian.PutUint64(tlv[7:], n)
+		stats = append(stats, tlv...)
+	}
+	msgs = append(msgs, bmpMessage(bmpStatisticsReport, stats)...)
+
+	_, err = conn.Write(msgs)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	// the router keeps its peers after the session is closed
+	require.Eventually(t, func() bool {
+		b.Lock()
+		defer b.Unlock()
+		r, ok := b.routers["127.0.0.1"]
+		return ok && !r.connected
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, acc.Errors)
+
+	b.gatherBMP(&acc, time.Unix(1600000260, 0))
+	expected := []cua.Metric{
+		testutil.MustMetric("bgp_bmp",
+			map[string]string{"router": "127.0.0.1", "router_name": "edge"},
+			map[string]interface{}{
+				"connected": 0,
+				"messages":  uint64(6),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("bgp_peer",
+			map[string]string{"router": "127.0.0.1", "router_name": "edge", "peer": "198.51.100.1", "peer_as": "64512"},
+			map[string]interface{}{
+				"state":                     "Established",
+				"established":               1,
+				"uptime":                    int64(60),
+				"flaps":                     uint64(1),
+				"route_monitoring_messages": uint64(1),
+				"prefixes_received":         uint64(120),
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+}
+
+func TestBMPInvalidVersion(t *testing.T) {
+	b := &BGP{
+		Source:         sourceBMP,
+		ServiceAddress: "tcp://127.0.0.1:0",
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, b.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, b.Start(context.Background(), &acc))
+	defer b.Stop()
+
+	conn, err := net.Dial("tcp", b.Address().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte{1, 0, 0, 0, 6, bmpInitiation})
+	require.NoError(t, err)
+
+	acc.WaitError(1)
+	require.EqualError(t, acc.Errors[0], "BMP session of 127.0.0.1: version 1 not supported")
+}
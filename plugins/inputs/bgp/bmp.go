@@ -0,0 +1,350 @@
+package bgp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// message types of RFC 7854
+const (
+	bmpRouteMonitoring  = 0
+	bmpStatisticsReport = 1
+	bmpPeerDown         = 2
+	bmpPeerUp           = 3
+	bmpInitiation       = 4
+	bmpTermination      = 5
+)
+
+const (
+	bmpVersion       = 3
+	bmpHeaderLength  = 6
+	bmpPeerHeaderLen = 42
+	// maxBMPMessage limits the length of the messages, a route monitoring
+	// message carries a BGP update of at most 64KiB
+	maxBMPMessage = 1 << 20
+
+	// statistics of the Adj-RIB-In routes, in total and per AFI/SAFI
+	bmpStatAdjRIBIn       = 7
+	bmpStatAdjRIBInFamily = 8
+
+	// sysName of the information TLVs of the initiation message
+	bmpInfoSysName = 2
+)
+
+type bmpRouter struct {
+	name      string
+	connected bool
+	messages  uint64
+}
+
+type bmpPeerKey struct {
+	router        string
+	address       string
+	distinguisher uint64
+}
+
+type bmpPeer struct {
+	as       uint32
+	up       bool
+	upSince  time.Time
+	downs    uint64
+	prefixes *uint64
+	// routeMonitoring is the number of route monitoring messages, which
+	// are not decoded
+	routeMonitoring uint64
+}
+
+// bmpPeerHeader is the per-peer header of the peer messages
+type bmpPeerHeader struct {
+	distinguisher uint64
+	address       string
+	as            uint32
+	timestamp     time.Time
+}
+
+func (b *BGP) listen(acc cua.Accumulator) error {
+	u, err := url.Parse(b.ServiceAddress)
+	if err != nil {
+		return fmt.Errorf("url parse (%s): %w", b.ServiceAddress, err)
+	}
+	switch u.Scheme {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return fmt.Errorf("unsupported network type: %s", u.Scheme)
+	}
+
+	l, err := net.Listen(u.Scheme, u.Host)
+	if err != nil {
+		return fmt.Errorf("listen (%s): %w", b.ServiceAddress, err)
+	}
+	b.listener = l
+	b.Log.Infof("Listening for BMP sessions on %s://%s", l.Addr().Network(), l.Addr().String())
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+					acc.AddError(err)
+				}
+				return
+			}
+			b.Lock()
+			b.conns[conn] = struct{}{}
+			b.Unlock()
+
+			b.wg.Add(1)
+			go func() {
+				defer b.wg.Done()
+				b.handleSession(acc, conn)
+			}()
+		}
+	}()
+	return nil
+}
+
+// Address returns the address of the BMP listener
+func (b *BGP) Address() net.Addr {
+	return b.listener.Addr()
+}
+
+// handleSession reads the messages of a router until it closes the session,
+// the peers of the router keep their last state
+func (b *BGP) handleSession(acc cua.Accumulator, conn net.Conn) {
+	router, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	b.Log.Debugf("BMP session of %s established", router)
+
+	defer func() {
+		conn.Close()
+		b.Lock()
+		delete(b.conns, conn)
+		if r, ok := b.routers[router]; ok {
+			r.connected = false
+		}
+		b.Unlock()
+		b.Log.Debugf("BMP session of %s closed", router)
+	}()
+
+	r := bufio.NewReader(conn)
+	header := make([]byte, bmpHeaderLength)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if !errors.Is(err, io.EOF) && !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+				acc.AddError(fmt.Errorf("BMP session of %s: %w", router, err))
+			}
+			return
+		}
+		if header[0] != bmpVersion {
+			acc.AddError(fmt.Errorf("BMP session of %s: version %d not supported", router, header[0]))
+			return
+		}
+		length := binary.BigEndian.Uint32(header[1:])
+		if length < bmpHeaderLength || length > maxBMPMessage {
+			acc.AddError(fmt.Errorf("BMP session of %s: invalid message length %d", router, length))
+			return
+		}
+		body := make([]byte, length-bmpHeaderLength)
+		if _, err := io.ReadFull(r, body); err != nil {
+			acc.AddError(fmt.Errorf("BMP session of %s: %w", router, err))
+			return
+		}
+
+		if err := b.handleMessage(router, header[5], body); err != nil {
+			acc.AddError(fmt.Errorf("BMP session of %s: %w", router, err))
+		}
+	}
+}
+
+func (b *BGP) handleMessage(router string, msgType uint8, body []byte) error {
+	b.Lock()
+	defer b.Unlock()
+
+	r, ok := b.routers[router]
+	if !ok {
+		r = &bmpRouter{}
+		b.routers[router] = r
+	}
+	r.connected = true
+	r.messages++
+
+	switch msgType {
+	case bmpInitiation:
+		r.name = sysName(body)
+		return nil
+	case bmpTermination:
+		return nil
+	case bmpRouteMonitoring, bmpStatisticsReport, bmpPeerDown, bmpPeerUp:
+	default:
+		return fmt.Errorf("unknown message type %d", msgType)
+	}
+
+	h, err := parsePeerHeader(body)
+	if err != nil {
+		return err
+	}
+	key := bmpPeerKey{router: router, address: h.address, distinguisher: h.distinguisher}
+	p, ok := b.peers[key]
+	if !ok {
+		p = &bmpPeer{}
+		b.peers[key] = p
+	}
+	p.as = h.as
+
+	switch msgType {
+	case bmpRouteMonitoring:
+		p.routeMonitoring++
+	case bmpStatisticsReport:
+		if n, ok := adjRIBIn(body[bmpPeerHeaderLen:]); ok {
+			p.prefixes = &n
+		}
+	case bmpPeerDown:
+		if p.up {
+			p.downs++
+		}
+		p.up = false
+		p.prefixes = nil
+	case bmpPeerUp:
+		p.up = true
+		p.upSince = h.timestamp
+	}
+	return nil
+}
+
+func parsePeerHeader(b []byte) (bmpPeerHeader, error) {
+	var h bmpPeerHeader
+	if len(b) < bmpPeerHeaderLen {
+		return h, errors.New("per-peer header too short")
+	}
+
+	h.distinguisher = binary.BigEndian.Uint64(b[2:])
+	// the V flag is set for the IPv6 peers, the IPv4 addresses are in the
+	// last 4 bytes of the address
+	if b[1]&0x80 != 0 {
+		h.address = net.IP(b[10:26]).String()
+	} else {
+		h.address = net.IP(b[22:26]).String()
+	}
+	h.as = binary.BigEndian.Uint32(b[26:])
+	sec := binary.BigEndian.Uint32(b[34:])
+	usec := binary.BigEndian.Uint32(b[38:])
+	if sec != 0 {
+		h.timestamp = time.Unix(int64(sec), int64(usec)*int64(time.Microsecond))
+	}
+	return h, nil
+}
+
+// adjRIBIn returns the number of Adj-RIB-In routes of a statistics report,
+// the sum of the address families when the router does not report the
+// total
+func adjRIBIn(b []byte) (uint64, bool) {
+	if len(b) < 4 {
+		return 0, false
+	}
+	count := binary.BigEndian.Uint32(b)
+	b = b[4:]
+
+	var total, families uint64
+	haveTotal, haveFamilies := false, false
+	for i := uint32(0); i < count && len(b) >= 4; i++ {
+		statType := binary.BigEndian.Uint16(b)
+		length := int(binary.BigEndian.Uint16(b[2:]))
+		if len(b) < 4+length {
+			break
+		}
+		v := b[4 : 4+length]
+		b = b[4+length:]
+
+		switch {
+		case statType == bmpStatAdjRIBIn && length == 8:
+			total = binary.BigEndian.Uint64(v)
+			haveTotal = true
+		case statType == bmpStatAdjRIBInFamily && length == 11:
+			families += binary.BigEndian.Uint64(v[3:])
+			haveFamilies = true
+		}
+	}
+
+	if haveTotal {
+		return total, true
+	}
+	return families, haveFamilies
+}
+
+// sysName returns the sysName of the information TLVs of an initiation
+// message
+func sysName(b []byte) string {
+	for len(b) >= 4 {
+		infoType := binary.BigEndian.Uint16(b)
+		length := int(binary.BigEndian.Uint16(b[2:]))
+		if len(b) < 4+length {
+			break
+		}
+		if infoType == bmpInfoSysName {
+			return string(b[4 : 4+length])
+		}
+		b = b[4+length:]
+	}
+	return ""
+}
+
+// gatherBMP reports the peers of all the routers and the state of the BMP
+// sessions
+func (b *BGP) gatherBMP(acc cua.Accumulator, now time.Time) {
+	b.Lock()
+	defer b.Unlock()
+
+	for address, r := range b.routers {
+		tags := map[string]string{"router": address}
+		if r.name != "" {
+			tags["router_name"] = r.name
+		}
+		acc.AddFields("bgp_bmp", map[string]interface{}{
+			"connected": established(r.connected),
+			"messages":  r.messages,
+		}, tags)
+	}
+
+	for key, p := range b.peers {
+		tags := map[string]string{
+			"router":  key.router,
+			"peer":    key.address,
+			"peer_as": strconv.FormatUint(uint64(p.as), 10),
+		}
+		if r, ok := b.routers[key.router]; ok && r.name != "" {
+			tags["router_name"] = r.name
+		}
+		if key.distinguisher != 0 {
+			tags["peer_distinguisher"] = strconv.FormatUint(key.distinguisher, 10)
+		}
+
+		state := "Down"
+		if p.up {
+			state = "Established"
+		}
+		fields := map[string]interface{}{
+			"state":                     state,
+			"established":               established(p.up),
+			"flaps":                     p.downs,
+			"route_monitoring_messages": p.routeMonitoring,
+		}
+		if p.up && !p.upSince.IsZero() {
+			fields["uptime"] = int64(now.Sub(p.upSince).Seconds())
+		}
+		if p.prefixes != nil {
+			fields["prefixes_received"] = *p.prefixes
+		}
+		acc.AddFields("bgp_peer", fields, tags)
+	}
+}
@@ -0,0 +1,176 @@
+package bgp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// frrSummary is the summary of an address family of a VRF in the output of
+// "show bgp vrf all summary json"
+type frrSummary struct {
+	Peers map[string]struct {
+		RemoteAs               json.RawMessage `json:"remoteAs"`
+		State                  string          `json:"state"`
+		PeerUptimeMsec         int64           `json:"peerUptimeMsec"`
+		PfxRcd                 *uint64         `json:"pfxRcd"`
+		PfxSnt                 *uint64         `json:"pfxSnt"`
+		ConnectionsEstablished uint64          `json:"connectionsEstablished"`
+		ConnectionsDropped     uint64          `json:"connectionsDropped"`
+	} `json:"peers"`
+}
+
+// gatherFRR reports the peers of each VRF and address family, the flaps are
+// the dropped connections
+func gatherFRR(acc cua.Accumulator, out []byte) error {
+	var vrfs map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(out, &vrfs); err != nil {
+		return fmt.Errorf("parsing vtysh output: %w", err)
+	}
+
+	for vrf, families := range vrfs {
+		for family, raw := range families {
+			var summary frrSummary
+			// the families are mixed with the attributes of the VRF
+			if err := json.Unmarshal(raw, &summary); err != nil || summary.Peers == nil {
+				continue
+			}
+			for address, p := range summary.Peers {
+				tags := map[string]string{
+					"peer":     address,
+					"peer_as":  strings.Trim(string(p.RemoteAs), `"`),
+					"vrf":      vrf,
+					"afi_safi": family,
+				}
+				up := p.State == "Established"
+				fields := map[string]interface{}{
+					"state":       p.State,
+					"established": established(up),
+					"flaps":       p.ConnectionsDropped,
+					"connections": p.ConnectionsEstablished,
+				}
+				if up {
+					fields["uptime"] = p.PeerUptimeMsec / 1000
+				}
+				if p.PfxRcd != nil {
+					fields["prefixes_received"] = *p.PfxRcd
+				}
+				if p.PfxSnt != nil {
+					fields["prefixes_sent"] = *p.PfxSnt
+				}
+				acc.AddFields("bgp_peer", fields, tags)
+			}
+		}
+	}
+	return nil
+}
+
+// gobgpStates are the names of the session states of the GoBGP API
+var gobgpStates = map[int]string{
+	0: "Unknown",
+	1: "Idle",
+	2: "Connect",
+	3: "Active",
+	4: "OpenSent",
+	5: "OpenConfirm",
+	6: "Established",
+}
+
+// gobgpPeer is a peer in the output of "gobgp neighbor -j", the peer AS is
+// peer_as before GoBGP 3
+type gobgpPeer struct {
+	Conf struct {
+		NeighborAddress string `json:"neighbor_address"`
+		PeerAs          uint32 `json:"peer_as"`
+		PeerAsn         uint32 `json:"peer_asn"`
+	} `json:"conf"`
+	State struct {
+		SessionState json.RawMessage `json:"session_state"`
+		Flops        uint64          `json:"flops"`
+	} `json:"state"`
+	Timers struct {
+		State struct {
+			Uptime struct {
+				Seconds int64 `json:"seconds"`
+			} `json:"uptime"`
+		} `json:"state"`
+	} `json:"timers"`
+	AfiSafis []struct {
+		State struct {
+			Received   uint64 `json:"received"`
+			Accepted   uint64 `json:"accepted"`
+			Advertised uint64 `json:"advertised"`
+		} `json:"state"`
+	} `json:"afi_safis"`
+}
+
+// gatherGoBGP reports the peers, the prefix counts are the sums of the
+// address families
+func gatherGoBGP(acc cua.Accumulator, out []byte, now time.Time) error {
+	var peers []gobgpPeer
+	if err := json.Unmarshal(out, &peers); err != nil {
+		return fmt.Errorf("parsing gobgp output: %w", err)
+	}
+
+	for _, p := range peers {
+		as := p.Conf.PeerAsn
+		if as == 0 {
+			as = p.Conf.PeerAs
+		}
+		tags := map[string]string{
+			"peer":    p.Conf.NeighborAddress,
+			"peer_as": fmt.Sprint(as),
+		}
+
+		state := gobgpState(p.State.SessionState)
+		up := state == "Established"
+		fields := map[string]interface{}{
+			"state":       state,
+			"established": established(up),
+			"flaps":       p.State.Flops,
+		}
+		// the uptime of the timers is the time the session was established
+		if up && p.Timers.State.Uptime.Seconds > 0 {
+			fields["uptime"] = now.Unix() - p.Timers.State.Uptime.Seconds
+		}
+		if len(p.AfiSafis) > 0 {
+			var received, accepted, advertised uint64
+			for _, f := range p.AfiSafis {
+				received += f.State.Received
+				accepted += f.State.Accepted
+				advertised += f.State.Advertised
+			}
+			fields["prefixes_received"] = received
+			fields["prefixes_accepted"] = accepted
+			fields["prefixes_sent"] = advertised
+		}
+		acc.AddFields("bgp_peer", fields, tags)
+	}
+	return nil
+}
+
+// gobgpState returns the name of the session state, which is a number or
+// the name of the enum depending on the encoding of GoBGP
+func gobgpState(raw json.RawMessage) string {
+	var n int
+	if err := json.Unmarshal(raw, &n); err == nil {
+		if s, ok := gobgpStates[n]; ok {
+			return s
+		}
+		return gobgpStates[0]
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil && s != "" {
+		// e.g. ESTABLISHED or SESSION_STATE_ESTABLISHED
+		s = strings.TrimPrefix(strings.ToLower(s), "session_state_")
+		for _, name := range gobgpStates {
+			if strings.ToLower(name) == s {
+				return name
+			}
+		}
+	}
+	return gobgpStates[0]
+}
@@ -21,6 +21,8 @@ type Bind struct {
 	Urls                 []string
 	GatherMemoryContexts bool
 	GatherViews          bool
+
+	cache viewCache
 }
 
 var sampleConfig = `
@@ -5,9 +5,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBindJsonStats(t *testing.T) {
@@ -618,3 +621,54 @@ func TestBindUnparseableURL(t *testing.T) {
 	err := acc.GatherError(b.Gather)
 	assert.Contains(t, err.Error(), "Unable to parse address")
 }
+
+func TestBindViewStats(t *testing.T) {
+	ts := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	url := ts.Listener.Addr().String()
+	host, port, _ := net.SplitHostPort(url)
+	defer ts.Close()
+
+	b := Bind{
+		Urls:        []string{ts.URL + "/json/v1"},
+		GatherViews: true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(b.Gather))
+
+	tags := map[string]string{"url": url, "source": host, "port": port, "view": "_default"}
+	var found bool
+	for _, m := range acc.GetCUAMetrics() {
+		if m.Name() != "bind_query_rtt" || m.Tags()["view"] != "_default" {
+			continue
+		}
+		found = true
+		require.Equal(t, cua.CumulativeHistogram, m.Type())
+		require.Equal(t, tags, m.Tags())
+		require.Equal(t, map[string]interface{}{
+			"1.000000e-01": int64(287),
+			"5.000000e-01": int64(152),
+			"8.000000e-01": int64(4),
+		}, m.Fields())
+	}
+	require.True(t, found)
+	// no ratio before a second gather
+	require.False(t, acc.HasMeasurement("bind_cache"))
+}
+
+func TestBindCacheHitRatio(t *testing.T) {
+	var b Bind
+	var acc testutil.Accumulator
+	tags := map[string]string{"url": "localhost:8053", "view": "_default"}
+
+	b.addViewStats(&acc, tags, nil, map[string]int64{"QueryHits": 10, "QueryMisses": 10}, time.Now())
+	require.False(t, acc.HasMeasurement("bind_cache"))
+
+	b.addViewStats(&acc, tags, nil, map[string]int64{"QueryHits": 40, "QueryMisses": 20}, time.Now())
+	acc.AssertContainsTaggedFields(t, "bind_cache", map[string]interface{}{"query_hit_ratio": 75.0}, tags)
+
+	// restarted
+	acc.ClearMetrics()
+	b.addViewStats(&acc, tags, nil, map[string]int64{"QueryHits": 1, "QueryMisses": 0}, time.Now())
+	require.False(t, acc.HasMeasurement("bind_cache"))
+}
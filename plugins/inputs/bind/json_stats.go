@@ -67,6 +67,14 @@ func addJSONCounter(acc cua.Accumulator, commonTags map[string]string, stats map
 	}
 }
 
+func toInt64(counters map[string]int) map[string]int64 {
+	m := make(map[string]int64, len(counters))
+	for name, value := range counters {
+		m[name] = int64(value)
+	}
+	return m
+}
+
 // addStatsJson walks a jsonStats struct and adds the values to the cua.Accumulator.
 func (b *Bind) addStatsJSON(stats jsonStats, acc cua.Accumulator, urlTag string) {
 	grouper := metric.NewSeriesGrouper()
@@ -123,6 +131,10 @@ func (b *Bind) addStatsJSON(stats jsonStats, acc cua.Accumulator, urlTag string)
 	// Detailed, per-view stats
 	if b.GatherViews {
 		for vName, view := range stats.Views {
+			b.addViewStats(acc,
+				map[string]string{"url": urlTag, "source": host, "port": port, "view": vName},
+				toInt64(view.Resolver["stats"]), toInt64(view.Resolver["cachestats"]), ts)
+
 			for cntrType, counters := range view.Resolver {
 				for cntrName, value := range counters {
 					tags := map[string]string{
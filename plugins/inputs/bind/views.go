@@ -0,0 +1,73 @@
+package bind
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// rttBuckets are the upper bounds, in seconds, of the QryRTT resolver stats,
+// QryRTT1600+ goes to the overflow bucket
+var rttBuckets = map[string]float64{
+	"QryRTT10":    0.01,
+	"QryRTT100":   0.1,
+	"QryRTT500":   0.5,
+	"QryRTT800":   0.8,
+	"QryRTT1600":  1.6,
+	"QryRTT1600+": 10e+127,
+}
+
+// cacheQueries are the cache query hits and misses of a view at the
+// previous gather
+type cacheQueries struct {
+	hits   int64
+	misses int64
+}
+
+type viewCache struct {
+	sync.Mutex
+	last map[string]cacheQueries
+}
+
+// addViewStats adds the recursion round trip time histogram and the cache
+// hit ratio since the previous gather of a view
+func (b *Bind) addViewStats(acc cua.Accumulator, tags map[string]string, resstats, cachestats map[string]int64, ts time.Time) {
+	buckets := make(map[string]interface{})
+	for name, bound := range rttBuckets {
+		if count, ok := resstats[name]; ok {
+			buckets[fmt.Sprintf("%e", bound)] = count
+		}
+	}
+	if len(buckets) > 0 {
+		acc.AddCumulativeHistogram("bind_query_rtt", buckets, tags, ts)
+	}
+
+	hits, hitsOK := cachestats["QueryHits"]
+	misses, missesOK := cachestats["QueryMisses"]
+	if !hitsOK || !missesOK {
+		return
+	}
+
+	key := tags["url"] + "/" + tags["view"]
+	b.cache.Lock()
+	if b.cache.last == nil {
+		b.cache.last = make(map[string]cacheQueries)
+	}
+	last, ok := b.cache.last[key]
+	b.cache.last[key] = cacheQueries{hits: hits, misses: misses}
+	b.cache.Unlock()
+
+	// the counters restart with named
+	if !ok || hits < last.hits || misses < last.misses {
+		return
+	}
+	queries := hits - last.hits + misses - last.misses
+	if queries == 0 {
+		return
+	}
+	acc.AddFields("bind_cache", map[string]interface{}{
+		"query_hit_ratio": 100 * float64(hits-last.hits) / float64(queries),
+	}, tags, ts)
+}
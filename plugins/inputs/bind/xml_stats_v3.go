@@ -108,6 +108,17 @@ func (b *Bind) addStatsXMLv3(stats v3Stats, acc cua.Accumulator, hostPort string
 	// Detailed, per-view stats
 	if b.GatherViews {
 		for _, v := range stats.Views {
+			groups := make(map[string]map[string]int64)
+			for _, cg := range v.CounterGroups {
+				groups[cg.Type] = make(map[string]int64, len(cg.Counters))
+				for _, c := range cg.Counters {
+					groups[cg.Type][c.Name] = c.Value
+				}
+			}
+			b.addViewStats(acc,
+				map[string]string{"url": hostPort, "source": host, "port": port, "view": v.Name},
+				groups["resstats"], groups["cachestats"], ts)
+
 			for _, cg := range v.CounterGroups {
 				for _, c := range cg.Counters {
 					tags := map[string]string{
@@ -0,0 +1,523 @@
+package cassandra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/histogram"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/jolokia2"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	sourceJolokia = "jolokia"
+	sourceScylla  = "scylla"
+
+	// overflowBucket is the bucket used for values above the largest
+	// bound, as in stackdriver_circonus
+	overflowBucket = 10e+127
+)
+
+const sampleConfig = `
+  ## Where the metrics are read from, one of "jolokia" or "scylla"
+  ##   jolokia - Apache Cassandra with the Jolokia JVM agent, urls are the
+  ##             Jolokia agent urls
+  ##   scylla  - ScyllaDB, urls are the prometheus endpoint urls
+  # source = "jolokia"
+  urls = ["http://localhost:8778/jolokia"]
+
+  ## Jolokia agent HTTP basic auth
+  # username = ""
+  # password = ""
+
+  ## Scylla REST API port on the host of each url, the datacenter and rack
+  ## of the node are read from it
+  # scylla_api_port = 10000
+
+  ## Datacenter and rack tags, read from the node when not set
+  # datacenter = ""
+  # rack = ""
+
+  ## Timeout for each request
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+const (
+	mbeanPendingCompactions = "org.apache.cassandra.metrics:type=Compaction,name=PendingTasks"
+	mbeanDroppedMutations   = "org.apache.cassandra.metrics:type=DroppedMessage,scope=MUTATION,name=Dropped"
+	mbeanReadLatency        = "org.apache.cassandra.metrics:type=ClientRequest,scope=Read,name=Latency"
+	mbeanWriteLatency       = "org.apache.cassandra.metrics:type=ClientRequest,scope=Write,name=Latency"
+	mbeanReadTimeouts       = "org.apache.cassandra.metrics:type=ClientRequest,scope=Read,name=Timeouts"
+	mbeanWriteTimeouts      = "org.apache.cassandra.metrics:type=ClientRequest,scope=Write,name=Timeouts"
+	mbeanReadUnavailables   = "org.apache.cassandra.metrics:type=ClientRequest,scope=Read,name=Unavailables"
+	mbeanWriteUnavailables  = "org.apache.cassandra.metrics:type=ClientRequest,scope=Write,name=Unavailables"
+	mbeanMemory             = "java.lang:type=Memory"
+	mbeanSnitch             = "org.apache.cassandra.db:type=EndpointSnitchInfo"
+)
+
+var latencyAttributes = []string{"Count", "Mean", "Max", "50thPercentile", "99thPercentile", "RecentValues"}
+
+var jolokiaRequests = []jolokia2.ReadRequest{
+	{Mbean: mbeanPendingCompactions, Attributes: []string{"Value"}},
+	{Mbean: mbeanDroppedMutations, Attributes: []string{"Count"}},
+	{Mbean: mbeanReadLatency, Attributes: latencyAttributes},
+	{Mbean: mbeanWriteLatency, Attributes: latencyAttributes},
+	{Mbean: mbeanReadTimeouts, Attributes: []string{"Count"}},
+	{Mbean: mbeanWriteTimeouts, Attributes: []string{"Count"}},
+	{Mbean: mbeanReadUnavailables, Attributes: []string{"Count"}},
+	{Mbean: mbeanWriteUnavailables, Attributes: []string{"Count"}},
+	{Mbean: mbeanMemory, Attributes: []string{"HeapMemoryUsage"}},
+	{Mbean: mbeanSnitch, Attributes: []string{"Datacenter", "Rack"}},
+}
+
+// countFields are the jolokia mbeans read as a single value
+var countFields = map[string]string{
+	mbeanPendingCompactions: "pending_compactions",
+	mbeanDroppedMutations:   "dropped_mutations",
+	mbeanReadTimeouts:       "read_timeouts",
+	mbeanWriteTimeouts:      "write_timeouts",
+	mbeanReadUnavailables:   "read_unavailables",
+	mbeanWriteUnavailables:  "write_unavailables",
+}
+
+// scyllaFields maps the scylla prometheus metrics to field names, values are
+// summed over the shards
+var scyllaFields = map[string]string{
+	"scylla_compaction_manager_pending_compactions":      "pending_compactions",
+	"scylla_storage_proxy_coordinator_read_timeouts":     "read_timeouts",
+	"scylla_storage_proxy_coordinator_write_timeouts":    "write_timeouts",
+	"scylla_storage_proxy_coordinator_read_unavailable":  "read_unavailables",
+	"scylla_storage_proxy_coordinator_write_unavailable": "write_unavailables",
+	"scylla_memory_allocated_memory":                     "memory_allocated",
+	"scylla_memory_total_memory":                         "memory_total",
+}
+
+// scyllaHistograms maps the scylla latency histograms to measurements
+var scyllaHistograms = map[string]string{
+	"scylla_storage_proxy_coordinator_read_latency":  "cassandra_read_latency",
+	"scylla_storage_proxy_coordinator_write_latency": "cassandra_write_latency",
+}
+
+type Cassandra struct {
+	Log           cua.Logger        `toml:"-"`
+	Source        string            `toml:"source"`
+	URLs          []string          `toml:"urls"`
+	Username      string            `toml:"username"`
+	Password      string            `toml:"password"`
+	ScyllaAPIPort int               `toml:"scylla_api_port"`
+	Datacenter    string            `toml:"datacenter"`
+	Rack          string            `toml:"rack"`
+	Timeout       internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client  *http.Client
+	jolokia map[string]*jolokia2.Client
+
+	mu       sync.Mutex
+	topology map[string]topology
+}
+
+type topology struct {
+	datacenter string
+	rack       string
+}
+
+func (c *Cassandra) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cassandra) Description() string {
+	return "Read compaction, dropped mutation, latency and heap metrics from Cassandra or Scylla"
+}
+
+func (c *Cassandra) Init() error {
+	if len(c.URLs) == 0 {
+		return fmt.Errorf("no urls configured")
+	}
+
+	switch c.Source {
+	case "":
+		c.Source = sourceJolokia
+	case sourceJolokia, sourceScylla:
+	default:
+		return fmt.Errorf("unknown source (%s)", c.Source)
+	}
+
+	tlsCfg, err := c.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	c.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: c.Timeout.Duration,
+	}
+
+	c.jolokia = make(map[string]*jolokia2.Client)
+	if c.Source == sourceJolokia {
+		for _, u := range c.URLs {
+			client, err := jolokia2.NewClient(u, &jolokia2.ClientConfig{
+				ResponseTimeout: c.Timeout.Duration,
+				Username:        c.Username,
+				Password:        c.Password,
+				ClientConfig:    c.ClientConfig,
+			})
+			if err != nil {
+				return fmt.Errorf("jolokia client (%s): %w", u, err)
+			}
+			c.jolokia[u] = client
+		}
+	}
+
+	c.topology = make(map[string]topology)
+
+	return nil
+}
+
+func (c *Cassandra) Gather(ctx context.Context, acc cua.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, u := range c.URLs {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			var err error
+			if c.Source == sourceScylla {
+				err = c.gatherScylla(ctx, acc, u)
+			} else {
+				err = c.gatherJolokia(acc, u)
+			}
+			if err != nil {
+				acc.AddError(fmt.Errorf("%s: %w", u, err))
+			}
+		}(u)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (c *Cassandra) gatherJolokia(acc cua.Accumulator, u string) error {
+	responses, err := c.jolokia[u].Read(jolokiaRequests)
+	if err != nil {
+		return fmt.Errorf("jolokia read: %w", err)
+	}
+
+	now := time.Now()
+	topo := topology{datacenter: c.Datacenter, rack: c.Rack}
+	fields := make(map[string]interface{})
+	histograms := make(map[string]map[string]interface{})
+
+	for _, resp := range responses {
+		if resp.Status != http.StatusOK {
+			c.Log.Debugf("%s: reading %s returned status %d", u, resp.RequestMbean, resp.Status)
+			continue
+		}
+
+		switch resp.RequestMbean {
+		case mbeanReadLatency, mbeanWriteLatency:
+			prefix := "read_latency"
+			if resp.RequestMbean == mbeanWriteLatency {
+				prefix = "write_latency"
+			}
+			values, ok := resp.Value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			addNumber(fields, prefix+"_count", values["Count"])
+			addNumber(fields, prefix+"_mean", values["Mean"])
+			addNumber(fields, prefix+"_max", values["Max"])
+			addNumber(fields, prefix+"_p50", values["50thPercentile"])
+			addNumber(fields, prefix+"_p99", values["99thPercentile"])
+			if buckets := estimatedHistogram(values["RecentValues"]); len(buckets) > 0 {
+				histograms["cassandra_"+prefix] = buckets
+			}
+		case mbeanMemory:
+			values, ok := resp.Value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			heap, ok := values["HeapMemoryUsage"].(map[string]interface{})
+			if !ok {
+				heap = values
+			}
+			addNumber(fields, "heap_used", heap["used"])
+			addNumber(fields, "heap_committed", heap["committed"])
+			addNumber(fields, "heap_max", heap["max"])
+		case mbeanSnitch:
+			values, ok := resp.Value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if dc, ok := values["Datacenter"].(string); ok && topo.datacenter == "" {
+				topo.datacenter = dc
+			}
+			if rack, ok := values["Rack"].(string); ok && topo.rack == "" {
+				topo.rack = rack
+			}
+		default:
+			field, ok := countFields[resp.RequestMbean]
+			if !ok {
+				continue
+			}
+			addNumber(fields, field, resp.Value)
+		}
+	}
+
+	tags := c.tags(u, topo)
+	if len(fields) > 0 {
+		acc.AddFields("cassandra", fields, tags, now)
+	}
+	for name, buckets := range histograms {
+		acc.AddHistogram(name, buckets, tags, now)
+	}
+
+	return nil
+}
+
+func (c *Cassandra) gatherScylla(ctx context.Context, acc cua.Accumulator, u string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP status %s", u, resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return fmt.Errorf("parse metrics: %w", err)
+	}
+
+	now := time.Now()
+	fields := make(map[string]interface{})
+	histograms := make(map[string]map[string]interface{})
+	for name, family := range families {
+		if measurement, ok := scyllaHistograms[name]; ok && family.GetType() == dto.MetricType_HISTOGRAM {
+			if buckets := prometheusHistogram(family); len(buckets) > 0 {
+				histograms[measurement] = buckets
+			}
+			continue
+		}
+
+		field, ok := scyllaFields[name]
+		if !ok {
+			continue
+		}
+		var value float64
+		for _, m := range family.GetMetric() {
+			switch {
+			case m.Counter != nil:
+				value += m.GetCounter().GetValue()
+			case m.Gauge != nil:
+				value += m.GetGauge().GetValue()
+			case m.Untyped != nil:
+				value += m.GetUntyped().GetValue()
+			}
+		}
+		fields[field] = value
+	}
+
+	tags := c.tags(u, c.scyllaTopology(ctx, u))
+	if len(fields) > 0 {
+		acc.AddFields("cassandra", fields, tags, now)
+	}
+	for name, buckets := range histograms {
+		acc.AddCumulativeHistogram(name, buckets, tags, now)
+	}
+
+	return nil
+}
+
+// scyllaTopology returns the datacenter and rack of the node from the scylla
+// REST API, they are cached once read
+func (c *Cassandra) scyllaTopology(ctx context.Context, u string) topology {
+	topo := topology{datacenter: c.Datacenter, rack: c.Rack}
+	if topo.datacenter != "" && topo.rack != "" {
+		return topo
+	}
+
+	c.mu.Lock()
+	cached, ok := c.topology[u]
+	c.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return topo
+	}
+	api := fmt.Sprintf("%s://%s", parsed.Scheme, net.JoinHostPort(parsed.Hostname(), strconv.Itoa(c.ScyllaAPIPort)))
+
+	if topo.datacenter == "" {
+		if err := c.getJSON(ctx, api+"/snitch/datacenter", &topo.datacenter); err != nil {
+			c.Log.Debugf("%s: reading datacenter: %s", u, err)
+			return topo
+		}
+	}
+	if topo.rack == "" {
+		if err := c.getJSON(ctx, api+"/snitch/rack", &topo.rack); err != nil {
+			c.Log.Debugf("%s: reading rack: %s", u, err)
+			return topo
+		}
+	}
+
+	c.mu.Lock()
+	c.topology[u] = topo
+	c.mu.Unlock()
+
+	return topo
+}
+
+func (c *Cassandra) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP status %s", u, resp.Status)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+	return nil
+}
+
+func (c *Cassandra) tags(u string, topo topology) map[string]string {
+	node := u
+	if parsed, err := url.Parse(u); err == nil && parsed.Hostname() != "" {
+		node = parsed.Hostname()
+	}
+	tags := map[string]string{"node": node}
+	if topo.datacenter != "" {
+		tags["datacenter"] = topo.datacenter
+	}
+	if topo.rack != "" {
+		tags["rack"] = topo.rack
+	}
+	return tags
+}
+
+// estimatedHistogram converts the bucket counts of a cassandra
+// EstimatedHistogram to circonus histogram buckets, the bucket bounds are
+// not reported and are computed the same way cassandra does
+func estimatedHistogram(v interface{}) map[string]interface{} {
+	values, ok := v.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil
+	}
+
+	offsets := estimatedHistogramOffsets(len(values) - 1)
+	buckets := make(map[string]interface{})
+	for i, val := range values {
+		count, ok := val.(float64)
+		if !ok || count <= 0 {
+			continue
+		}
+		bound := overflowBucket
+		if i < len(offsets) {
+			bound = offsets[i]
+		}
+		buckets[fmt.Sprintf("%e", bound)] = int64(count)
+	}
+
+	return buckets
+}
+
+// estimatedHistogramOffsets returns the bucket bounds of a cassandra
+// EstimatedHistogram, each bound 1.2 times the previous one
+func estimatedHistogramOffsets(size int) []float64 {
+	offsets := make([]float64, size)
+	last := int64(1)
+	for i := range offsets {
+		if i > 0 {
+			next := int64(math.Round(float64(last) * 1.2))
+			if next == last {
+				next++
+			}
+			last = next
+		}
+		offsets[i] = float64(last)
+	}
+	return offsets
+}
+
+// prometheusHistogram converts a prometheus histogram, summed over the
+// metrics in the family, to circonus histogram buckets
+func prometheusHistogram(family *dto.MetricFamily) map[string]interface{} {
+	counts := make(map[float64]uint64)
+	for _, m := range family.GetMetric() {
+		for _, b := range m.GetHistogram().GetBucket() {
+			counts[b.GetUpperBound()] += b.GetCumulativeCount()
+		}
+	}
+
+	bounds := make([]float64, 0, len(counts))
+	for bound := range counts {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+
+	cumulative := make([]uint64, len(bounds))
+	for i, bound := range bounds {
+		cumulative[i] = counts[bound]
+	}
+
+	return histogram.FromCumulative(bounds, cumulative)
+}
+
+func addNumber(fields map[string]interface{}, name string, v interface{}) {
+	if f, ok := v.(float64); ok && !math.IsNaN(f) {
+		fields[name] = f
+	}
+}
+
+func init() {
+	inputs.Add("cassandra", func() cua.Input {
+		return &Cassandra{
+			ScyllaAPIPort: 10000,
+			Timeout:       internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
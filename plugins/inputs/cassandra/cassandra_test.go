@@ -0,0 +1,192 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const jolokiaResponse = `[
+  {"request": {"mbean": "org.apache.cassandra.metrics:type=Compaction,name=PendingTasks", "attribute": "Value", "type": "read"}, "value": 7, "status": 200},
+  {"request": {"mbean": "org.apache.cassandra.metrics:type=DroppedMessage,scope=MUTATION,name=Dropped", "attribute": "Count", "type": "read"}, "value": 12, "status": 200},
+  {"request": {"mbean": "org.apache.cassandra.metrics:type=ClientRequest,scope=Read,name=Latency", "attribute": ["Count", "Mean", "Max", "50thPercentile", "99thPercentile", "RecentValues"], "type": "read"},
+   "value": {"Count": 100, "Mean": 250.5, "Max": 1200.0, "50thPercentile": 215.0, "99thPercentile": 1109.0, "RecentValues": [0, 3, 0, 1, 0, 0]}, "status": 200},
+  {"request": {"mbean": "org.apache.cassandra.metrics:type=ClientRequest,scope=Write,name=Latency", "attribute": ["Count", "Mean", "Max", "50thPercentile", "99thPercentile", "RecentValues"], "type": "read"},
+   "error": "javax.management.AttributeNotFoundException : No such attribute: RecentValues", "status": 404},
+  {"request": {"mbean": "org.apache.cassandra.metrics:type=ClientRequest,scope=Read,name=Timeouts", "attribute": "Count", "type": "read"}, "value": 2, "status": 200},
+  {"request": {"mbean": "org.apache.cassandra.metrics:type=ClientRequest,scope=Write,name=Timeouts", "attribute": "Count", "type": "read"}, "value": 1, "status": 200},
+  {"request": {"mbean": "org.apache.cassandra.metrics:type=ClientRequest,scope=Read,name=Unavailables", "attribute": "Count", "type": "read"}, "value": 0, "status": 200},
+  {"request": {"mbean": "org.apache.cassandra.metrics:type=ClientRequest,scope=Write,name=Unavailables", "attribute": "Count", "type": "read"}, "value": 0, "status": 200},
+  {"request": {"mbean": "java.lang:type=Memory", "attribute": "HeapMemoryUsage", "type": "read"},
+   "value": {"init": 1000, "committed": 4000, "max": 8000, "used": 2000}, "status": 200},
+  {"request": {"mbean": "org.apache.cassandra.db:type=EndpointSnitchInfo", "attribute": ["Datacenter", "Rack"], "type": "read"},
+   "value": {"Datacenter": "dc1", "Rack": "rack1"}, "status": 200}
+]`
+
+const scyllaMetrics = `# HELP scylla_compaction_manager_pending_compactions Holds the number of compaction tasks waiting for an opportunity to run.
+# TYPE scylla_compaction_manager_pending_compactions gauge
+scylla_compaction_manager_pending_compactions{shard="0"} 2
+scylla_compaction_manager_pending_compactions{shard="1"} 1
+# HELP scylla_storage_proxy_coordinator_write_timeouts number of write request failed due to a timeout
+# TYPE scylla_storage_proxy_coordinator_write_timeouts counter
+scylla_storage_proxy_coordinator_write_timeouts{shard="0"} 4
+scylla_storage_proxy_coordinator_write_timeouts{shard="1"} 1
+# HELP scylla_storage_proxy_coordinator_read_latency The general read latency histogram
+# TYPE scylla_storage_proxy_coordinator_read_latency histogram
+scylla_storage_proxy_coordinator_read_latency_sum{shard="0"} 3000
+scylla_storage_proxy_coordinator_read_latency_count{shard="0"} 6
+scylla_storage_proxy_coordinator_read_latency_bucket{le="640.000000",shard="0"} 2
+scylla_storage_proxy_coordinator_read_latency_bucket{le="1280.000000",shard="0"} 5
+scylla_storage_proxy_coordinator_read_latency_bucket{le="+Inf",shard="0"} 6
+scylla_storage_proxy_coordinator_read_latency_sum{shard="1"} 500
+scylla_storage_proxy_coordinator_read_latency_count{shard="1"} 1
+scylla_storage_proxy_coordinator_read_latency_bucket{le="640.000000",shard="1"} 1
+scylla_storage_proxy_coordinator_read_latency_bucket{le="1280.000000",shard="1"} 1
+scylla_storage_proxy_coordinator_read_latency_bucket{le="+Inf",shard="1"} 1
+# HELP scylla_reactor_utilization CPU utilization
+# TYPE scylla_reactor_utilization gauge
+scylla_reactor_utilization{shard="0"} 4.5
+`
+
+func TestInit(t *testing.T) {
+	c := &Cassandra{}
+	require.Error(t, c.Init())
+
+	c = &Cassandra{URLs: []string{"http://localhost:8778/jolokia"}, Source: "jmx"}
+	require.Error(t, c.Init())
+
+	c = &Cassandra{URLs: []string{"http://localhost:8778/jolokia"}}
+	require.NoError(t, c.Init())
+	require.Equal(t, sourceJolokia, c.Source)
+	require.Len(t, c.jolokia, 1)
+}
+
+func TestEstimatedHistogramOffsets(t *testing.T) {
+	require.Equal(t,
+		[]float64{1, 2, 3, 4, 5, 6, 7, 8, 10, 12, 14, 17, 20, 24, 29, 35},
+		estimatedHistogramOffsets(16))
+}
+
+func TestGatherJolokia(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/jolokia/read", r.URL.Path)
+		require.Equal(t, http.MethodPost, r.Method)
+		fmt.Fprint(w, jolokiaResponse)
+	}))
+	defer ts.Close()
+
+	c := &Cassandra{
+		Log:  testutil.Logger{},
+		URLs: []string{ts.URL + "/jolokia"},
+	}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+
+	tags := map[string]string{"node": "127.0.0.1", "datacenter": "dc1", "rack": "rack1"}
+	acc.AssertContainsTaggedFields(t, "cassandra",
+		map[string]interface{}{
+			"pending_compactions": 7.0,
+			"dropped_mutations":   12.0,
+			"read_latency_count":  100.0,
+			"read_latency_mean":   250.5,
+			"read_latency_max":    1200.0,
+			"read_latency_p50":    215.0,
+			"read_latency_p99":    1109.0,
+			"read_timeouts":       2.0,
+			"write_timeouts":      1.0,
+			"read_unavailables":   0.0,
+			"write_unavailables":  0.0,
+			"heap_used":           2000.0,
+			"heap_committed":      4000.0,
+			"heap_max":            8000.0,
+		},
+		tags)
+
+	var found bool
+	for _, m := range acc.GetCUAMetrics() {
+		if m.Name() != "cassandra_read_latency" {
+			continue
+		}
+		found = true
+		require.Equal(t, cua.Histogram, m.Type())
+		require.Equal(t, tags, m.Tags())
+		// 5 offsets and the overflow bucket
+		require.Equal(t, map[string]interface{}{
+			"2.000000e+00": int64(3),
+			"4.000000e+00": int64(1),
+		}, m.Fields())
+	}
+	require.True(t, found)
+	require.False(t, acc.HasMeasurement("cassandra_write_latency"))
+}
+
+func TestGatherScylla(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metrics":
+			fmt.Fprint(w, scyllaMetrics)
+		case "/snitch/datacenter":
+			fmt.Fprint(w, `"us-east"`)
+		case "/snitch/rack":
+			fmt.Fprint(w, `"1a"`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	parsed, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(parsed.Host)
+	require.NoError(t, err)
+	apiPort, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	c := &Cassandra{
+		Log:           testutil.Logger{},
+		Source:        sourceScylla,
+		URLs:          []string{ts.URL + "/metrics"},
+		ScyllaAPIPort: apiPort,
+	}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+
+	tags := map[string]string{"node": "127.0.0.1", "datacenter": "us-east", "rack": "1a"}
+	acc.AssertContainsTaggedFields(t, "cassandra",
+		map[string]interface{}{
+			"pending_compactions": 3.0,
+			"write_timeouts":      5.0,
+		},
+		tags)
+
+	var found bool
+	for _, m := range acc.GetCUAMetrics() {
+		if m.Name() != "cassandra_read_latency" {
+			continue
+		}
+		found = true
+		require.Equal(t, cua.CumulativeHistogram, m.Type())
+		require.Equal(t, tags, m.Tags())
+		require.Equal(t, map[string]interface{}{
+			"6.400000e+02": int64(3),
+			"1.280000e+03": int64(4),
+		}, m.Fields())
+	}
+	require.True(t, found)
+	require.Equal(t, tags["rack"], c.topology[ts.URL+"/metrics"].rack)
+}
@@ -1,15 +1,24 @@
 package cgroup
 
 import (
+	"fmt"
+	"regexp"
+
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 )
 
 type CGroup struct {
-	Paths []string `toml:"paths"`
-	Files []string `toml:"files"`
+	Paths           []string `toml:"paths"`
+	Files           []string `toml:"files"`
+	PathTagPatterns []string `toml:"path_tag_patterns"`
+
+	pathTagPatterns []*regexp.Regexp
 }
 
+// the cgroup v2 resource accounting files read when none are configured
+var defaultFiles = []string{"cpu.stat", "memory.current", "memory.max", "io.stat", "pids.current"}
+
 var sampleConfig = `
   ## Directories in which to look for files, globs are supported.
   ## Consider restricting paths to the set of cgroups you really
@@ -19,10 +28,21 @@ var sampleConfig = `
   #   "/sys/fs/cgroup/memory",
   #   "/sys/fs/cgroup/memory/child1",
   #   "/sys/fs/cgroup/memory/child2/*",
+  #   "/sys/fs/cgroup/system.slice/*.service",
   # ]
   ## cgroup stat fields, as file names, globs are supported.
   ## these file names are appended to each path from above.
+  ## the default are the cgroup v2 cpu.stat, memory.current, memory.max,
+  ## io.stat and pids.current
   # files = ["memory.*usage*", "memory.limit_in_bytes"]
+
+  ## Regular expressions matched against each cgroup path, the named
+  ## groups become tags, e.g. the slice and the unit of a systemd service
+  ## or the id of a container.
+  # path_tag_patterns = [
+  #   '/(?P<slice>[^/]+\.slice)/(?P<unit>[^/]+\.service)$',
+  #   '/docker-(?P<container_id>[0-9a-f]{12})[0-9a-f]*\.scope$',
+  # ]
 `
 
 func (g *CGroup) SampleConfig() string {
@@ -33,6 +53,32 @@ func (g *CGroup) Description() string {
 	return "Read specific statistics per cgroup"
 }
 
+func (g *CGroup) Init() error {
+	if len(g.Files) == 0 {
+		g.Files = defaultFiles
+	}
+
+	g.pathTagPatterns = make([]*regexp.Regexp, 0, len(g.PathTagPatterns))
+	for _, p := range g.PathTagPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("path_tag_patterns (%s): %w", p, err)
+		}
+		named := false
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				named = true
+			}
+		}
+		if !named {
+			return fmt.Errorf("path_tag_patterns (%s): no named groups", p)
+		}
+		g.pathTagPatterns = append(g.pathTagPatterns, re)
+	}
+
+	return nil
+}
+
 func init() {
 	inputs.Add("cgroup", func() cua.Input { return &CGroup{} })
 }
@@ -6,11 +6,13 @@ package cgroup
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 )
@@ -59,13 +61,31 @@ func (g *CGroup) gatherDir(dir string, acc cua.Accumulator) error {
 		}
 	}
 
-	tags := map[string]string{"path": dir}
+	tags := g.pathTags(dir)
 
 	acc.AddFields(metricName, fields, tags)
 
 	return nil
 }
 
+// pathTags returns the tags of a cgroup, its path and the named groups of
+// the path tag patterns matching it
+func (g *CGroup) pathTags(dir string) map[string]string {
+	tags := map[string]string{"path": dir}
+	for _, re := range g.pathTagPatterns {
+		matches := re.FindStringSubmatch(dir)
+		if matches == nil {
+			continue
+		}
+		for i, name := range re.SubexpNames() {
+			if name != "" && matches[i] != "" {
+				tags[name] = matches[i]
+			}
+		}
+	}
+	return tags
+}
+
 // ======================================================================
 
 type pathInfo struct {
@@ -139,6 +159,9 @@ type fileData struct {
 
 func (fd *fileData) format() (*fileFormat, error) {
 	for _, ff := range fileFormats {
+		if ff.file != "" && ff.file != filepath.Base(fd.path) {
+			continue
+		}
 		ok, err := ff.match(fd.data)
 		if err != nil {
 			return nil, err
@@ -165,20 +188,47 @@ func (fd *fileData) parse(fields map[string]interface{}) error {
 
 type fileFormat struct {
 	name    string //nolint:structcheck,unused
+	file    string // the format only applies to the file of this name when set
 	pattern string
 	parser  func(measurement string, fields map[string]interface{}, b []byte)
 }
 
-const keyPattern = "[[:alpha:]_]+"
+const keyPattern = "[[:alnum:]_.]+"
 const valuePattern = "[\\d-]+"
 
+// cgroup v2 limits are "max" when unlimited
+const limitPattern = "[\\d-]+|max"
+
+// keys of the nested keyed files, e.g. the major:minor of a device
+const nestedKeyPattern = "[\\w:.-]+"
+const subKeyPattern = "[[:alnum:]_]+"
+const floatPattern = "[\\d.-]+"
+
 var fileFormats = [...]fileFormat{
+	// 	MAX PERIOD\n
+	// the cpu.max quota is "max" without a limit, reported as -1 like the
+	// cpu.cfs_quota_us of cgroup v1
+	{
+		name:    "Quota and period",
+		file:    "cpu.max",
+		pattern: "^(" + limitPattern + ") " + valuePattern + "\n$",
+		parser: func(measurement string, fields map[string]interface{}, b []byte) {
+			re := regexp.MustCompile("^(" + limitPattern + ") (" + valuePattern + ")\n$")
+			matches := re.FindStringSubmatch(string(b))
+			if matches[1] == "max" {
+				fields[measurement+".0"] = int64(-1)
+			} else {
+				fields[measurement+".0"] = numberOrString(matches[1])
+			}
+			fields[measurement+".1"] = numberOrString(matches[2])
+		},
+	},
 	// 	VAL\n
 	{
 		name:    "Single value",
-		pattern: "^" + valuePattern + "\n$",
+		pattern: "^(" + limitPattern + ")\n$",
 		parser: func(measurement string, fields map[string]interface{}, b []byte) {
-			re := regexp.MustCompile("^(" + valuePattern + ")\n$")
+			re := regexp.MustCompile("^(" + limitPattern + ")\n$")
 			matches := re.FindAllStringSubmatch(string(b), -1)
 			fields[measurement] = numberOrString(matches[0][1])
 		},
@@ -200,9 +250,9 @@ var fileFormats = [...]fileFormat{
 	// 	VAL0 VAL1 ...\n
 	{
 		name:    "Space separated values",
-		pattern: "^(" + valuePattern + " )+\n$",
+		pattern: "^(" + valuePattern + " )+(" + valuePattern + ")?\n$",
 		parser: func(measurement string, fields map[string]interface{}, b []byte) {
-			re := regexp.MustCompile("(" + valuePattern + ") ")
+			re := regexp.MustCompile("(" + valuePattern + ")[ \n]")
 			matches := re.FindAllStringSubmatch(string(b), -1)
 			for i, v := range matches {
 				fields[measurement+"."+strconv.Itoa(i)] = numberOrString(v[1])
@@ -223,6 +273,22 @@ var fileFormats = [...]fileFormat{
 			}
 		},
 	},
+	// 	KEY0 SUBKEY0=VAL00 SUBKEY1=VAL01 ...\n
+	// 	KEY1 SUBKEY0=VAL10 SUBKEY1=VAL11 ...\n
+	// 	...
+	{
+		name:    "Nested keyed",
+		pattern: "^(" + nestedKeyPattern + "( " + subKeyPattern + "=" + floatPattern + ")+\n)+$",
+		parser: func(measurement string, fields map[string]interface{}, b []byte) {
+			re := regexp.MustCompile("(" + subKeyPattern + ")=(" + floatPattern + ")")
+			for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+				key := strings.SplitN(line, " ", 2)[0]
+				for _, v := range re.FindAllStringSubmatch(line[len(key):], -1) {
+					fields[measurement+"."+key+"."+v[1]] = numberOrFloat(v[2])
+				}
+			}
+		},
+	},
 }
 
 func numberOrString(s string) interface{} {
@@ -230,6 +296,20 @@ func numberOrString(s string) interface{} {
 	if err == nil {
 		return i
 	}
+	if s == "max" {
+		return int64(math.MaxInt64)
+	}
+
+	return s
+}
+
+func numberOrFloat(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
 
 	return s
 }
@@ -4,6 +4,7 @@
 package cgroup
 
 import (
+	"math"
 	"testing"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
@@ -181,3 +182,113 @@ func TestCgroupStatistics_6(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "cgroup", fields, tags)
 }
+
+// ======================================================================
+
+func TestCgroupStatistics_V2(t *testing.T) {
+	cg := &CGroup{
+		Paths: []string{"testdata/v2/*/*"},
+		PathTagPatterns: []string{
+			`/(?P<slice>[^/]+\.slice)/(?P<unit>[^/]+\.service)$`,
+		},
+	}
+	require.NoError(t, cg.Init())
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(cg.Gather)
+	require.NoError(t, err)
+
+	tags := map[string]string{
+		"path":  "testdata/v2/system.slice/nginx.service",
+		"slice": "system.slice",
+		"unit":  "nginx.service",
+	}
+	fields := map[string]interface{}{
+		"cpu.stat.usage_usec":                 int64(4375196),
+		"cpu.stat.user_usec":                  int64(2637561),
+		"cpu.stat.system_usec":                int64(1737635),
+		"cpu.stat.nr_periods":                 int64(0),
+		"cpu.stat.nr_throttled":               int64(0),
+		"cpu.stat.throttled_usec":             int64(0),
+		"cpu.stat.core_sched.force_idle_usec": int64(0),
+		"memory.current":                      int64(14196736),
+		"memory.max":                          int64(math.MaxInt64),
+		"io.stat.8:0.rbytes":                  int64(1118208),
+		"io.stat.8:0.wbytes":                  int64(4096),
+		"io.stat.8:0.rios":                    int64(61),
+		"io.stat.8:0.wios":                    int64(1),
+		"io.stat.8:0.dbytes":                  int64(0),
+		"io.stat.8:0.dios":                    int64(0),
+		"io.stat.253:0.rbytes":                int64(1118208),
+		"io.stat.253:0.wbytes":                int64(4096),
+		"io.stat.253:0.rios":                  int64(61),
+		"io.stat.253:0.wios":                  int64(1),
+		"io.stat.253:0.dbytes":                int64(0),
+		"io.stat.253:0.dios":                  int64(0),
+		"pids.current":                        int64(3),
+	}
+	acc.AssertContainsTaggedFields(t, "cgroup", fields, tags)
+}
+
+func TestCgroupStatistics_V2PressureAndMax(t *testing.T) {
+	cg := &CGroup{
+		Paths: []string{"testdata/v2/system.slice/nginx.service"},
+		Files: []string{"memory.pressure", "cpu.max"},
+	}
+	require.NoError(t, cg.Init())
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(cg.Gather)
+	require.NoError(t, err)
+
+	tags := map[string]string{
+		"path": "testdata/v2/system.slice/nginx.service",
+	}
+	fields := map[string]interface{}{
+		"memory.pressure.some.avg10":  float64(0),
+		"memory.pressure.some.avg60":  float64(0.12),
+		"memory.pressure.some.avg300": float64(0.04),
+		"memory.pressure.some.total":  int64(152706),
+		"memory.pressure.full.avg10":  float64(0),
+		"memory.pressure.full.avg60":  float64(0.10),
+		"memory.pressure.full.avg300": float64(0.03),
+		"memory.pressure.full.total":  int64(136102),
+		"cpu.max.0":                   int64(50000),
+		"cpu.max.1":                   int64(100000),
+	}
+	acc.AssertContainsTaggedFields(t, "cgroup", fields, tags)
+}
+
+func TestCgroupStatistics_V2Unlimited(t *testing.T) {
+	cg := &CGroup{
+		Paths: []string{"testdata/v2/user.slice"},
+		Files: []string{"cpu.max", "pids.events"},
+	}
+	require.NoError(t, cg.Init())
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(cg.Gather)
+	require.NoError(t, err)
+
+	tags := map[string]string{
+		"path": "testdata/v2/user.slice",
+	}
+	fields := map[string]interface{}{
+		"cpu.max.0":       int64(-1),
+		"cpu.max.1":       int64(100000),
+		"pids.events.max": int64(0),
+	}
+	acc.AssertContainsTaggedFields(t, "cgroup", fields, tags)
+}
+
+func TestCgroupInit(t *testing.T) {
+	cg := &CGroup{PathTagPatterns: []string{"/(?P<unit>[^/]+"}}
+	require.Error(t, cg.Init())
+
+	cg = &CGroup{PathTagPatterns: []string{"/([^/]+)$"}}
+	require.Error(t, cg.Init())
+
+	cg = &CGroup{}
+	require.NoError(t, cg.Init())
+	require.Equal(t, defaultFiles, cg.Files)
+}
@@ -0,0 +1,143 @@
+package chef
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal/globpath"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const defaultReports = "/var/chef/reports/chef-run-report-*.json"
+
+// Chef reads the run reports written by the chef json_file report handler
+type Chef struct {
+	Reports string `toml:"reports"`
+
+	glob *globpath.GlobPath
+}
+
+var sampleConfig = `
+  ## Run reports written by the chef json_file report handler, the most
+  ## recently modified report is read, glob matching can be used
+  # reports = "/var/chef/reports/chef-run-report-*.json"
+`
+
+// report is the part of a json_file handler report used for the metrics
+type report struct {
+	Node struct {
+		Name string `json:"name"`
+	} `json:"node"`
+	Success          bool              `json:"success"`
+	StartTime        string            `json:"start_time"`
+	EndTime          string            `json:"end_time"`
+	ElapsedTime      float64           `json:"elapsed_time"`
+	AllResources     []json.RawMessage `json:"all_resources"`
+	UpdatedResources []json.RawMessage `json:"updated_resources"`
+}
+
+// SampleConfig returns sample configuration message
+func (c *Chef) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns description of Chef plugin
+func (c *Chef) Description() string {
+	return "Reads the last chef-client run report written by the json_file report handler"
+}
+
+// Init compiles the reports glob
+func (c *Chef) Init() error {
+	if c.Reports == "" {
+		c.Reports = defaultReports
+	}
+	glob, err := globpath.Compile(c.Reports)
+	if err != nil {
+		return fmt.Errorf("could not compile glob %q: %w", c.Reports, err)
+	}
+	c.glob = glob
+	return nil
+}
+
+// Gather reads the most recent run report
+func (c *Chef) Gather(ctx context.Context, acc cua.Accumulator) error {
+	path, err := c.lastReport()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("readfile (%s): %w", path, err)
+	}
+
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("json unmarshal (%s): %w", path, err)
+	}
+
+	fields := map[string]interface{}{
+		"success":           0,
+		"elapsed_time":      r.ElapsedTime,
+		"resources_total":   len(r.AllResources),
+		"resources_updated": len(r.UpdatedResources),
+	}
+	if r.Success {
+		fields["success"] = 1
+	}
+	if r.EndTime != "" {
+		end, err := parseTime(r.EndTime)
+		if err != nil {
+			return fmt.Errorf("end_time (%s): %w", path, err)
+		}
+		fields["last_run"] = end.Unix()
+		fields["last_run_age"] = int64(time.Since(end).Seconds())
+	}
+
+	tags := map[string]string{}
+	if r.Node.Name != "" {
+		tags["node"] = r.Node.Name
+	}
+	acc.AddFields("chef", fields, tags)
+
+	return nil
+}
+
+// lastReport returns the most recently modified report
+func (c *Chef) lastReport() (string, error) {
+	var last string
+	var lastMod time.Time
+	for _, path := range c.glob.Match() {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if last == "" || info.ModTime().After(lastMod) {
+			last, lastMod = path, info.ModTime()
+		}
+	}
+	if last == "" {
+		return "", fmt.Errorf("no run reports match %q", c.Reports)
+	}
+	return last, nil
+}
+
+// parseTime parses the report times, ruby formats them as
+// "2020-10-14 10:00:00 +0000"
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02 15:04:05 -0700", s)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s) //nolint:wrapcheck
+}
+
+func init() {
+	inputs.Add("chef", func() cua.Input {
+		return &Chef{}
+	})
+}
@@ -0,0 +1,59 @@
+package chef
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	c := &Chef{Reports: "testdata/chef-run-report-*.json"}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+
+	end := time.Date(2020, 10, 14, 10, 0, 42, 0, time.UTC)
+	require.True(t, acc.HasField("chef", "last_run_age"))
+	acc.AssertContainsTaggedFields(t, "chef",
+		map[string]interface{}{
+			"success":           1,
+			"elapsed_time":      42.318,
+			"resources_total":   3,
+			"resources_updated": 1,
+			"last_run":          end.Unix(),
+			"last_run_age":      acc.Metrics[0].Fields["last_run_age"],
+		},
+		map[string]string{"node": "web01.example.com"})
+}
+
+func TestGatherLastReport(t *testing.T) {
+	dir := t.TempDir()
+	failed := filepath.Join(dir, "chef-run-report-2.json")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "chef-run-report-1.json"), []byte(`{"success":true}`), 0600))
+	require.NoError(t, os.WriteFile(failed, []byte(`{"success":false,"end_time":"2020-10-14T11:00:00Z"}`), 0600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(failed, later, later))
+
+	c := &Chef{Reports: filepath.Join(dir, "chef-run-report-*.json")}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	success, ok := acc.IntField("chef", "success")
+	require.True(t, ok)
+	require.Equal(t, 0, success)
+}
+
+func TestGatherNoReports(t *testing.T) {
+	c := &Chef{Reports: filepath.Join(t.TempDir(), "*.json")}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, c.Gather(context.Background(), &acc))
+}
@@ -88,10 +88,11 @@ var sampleConfig = `
 `
 
 type connect struct {
-	Cluster  string `json:"cluster"`
-	ShardNum int    `json:"shard_num"`
-	Hostname string `json:"host_name"`
-	url      *url.URL
+	Cluster    string `json:"cluster"`
+	ShardNum   int    `json:"shard_num"`
+	ReplicaNum int    `json:"replica_num"`
+	Hostname   string `json:"host_name"`
+	url        *url.URL
 }
 
 func init() {
@@ -173,7 +174,7 @@ func (ch *ClickHouse) Gather(ctx context.Context, acc cua.Accumulator) (err erro
 		switch {
 		case ch.AutoDiscovery:
 			var conns []connect
-			if err := ch.execQuery(u, "SELECT cluster, shard_num, host_name FROM system.clusters "+ch.clusterIncludeExcludeFilter(), &conns); err != nil {
+			if err := ch.execQuery(u, "SELECT cluster, shard_num, replica_num, host_name FROM system.clusters "+ch.clusterIncludeExcludeFilter(), &conns); err != nil {
 				acc.AddError(err)
 				continue
 			}
@@ -200,6 +201,7 @@ func (ch *ClickHouse) Gather(ctx context.Context, acc cua.Accumulator) (err erro
 
 		metricsFuncs := []func(acc cua.Accumulator, conn *connect) error{
 			ch.tables,
+			ch.replicas,
 			ch.zookeeper,
 			ch.replicationQueue,
 			ch.detachedParts,
@@ -548,6 +550,51 @@ func (ch *ClickHouse) tables(acc cua.Accumulator, conn *connect) error {
 	return nil
 }
 
+func (ch *ClickHouse) replicas(acc cua.Accumulator, conn *connect) error {
+	var replicas []struct {
+		Database         string   `json:"database"`
+		Table            string   `json:"table"`
+		ReplicaName      string   `json:"replica_name"`
+		IsLeader         chUInt64 `json:"is_leader"`
+		IsReadonly       chUInt64 `json:"is_readonly"`
+		IsSessionExpired chUInt64 `json:"is_session_expired"`
+		QueueSize        chUInt64 `json:"queue_size"`
+		InsertsInQueue   chUInt64 `json:"inserts_in_queue"`
+		MergesInQueue    chUInt64 `json:"merges_in_queue"`
+		LogDelay         chUInt64 `json:"log_delay"`
+		AbsoluteDelay    chUInt64 `json:"absolute_delay"`
+		TotalReplicas    chUInt64 `json:"total_replicas"`
+		ActiveReplicas   chUInt64 `json:"active_replicas"`
+	}
+
+	if err := ch.execQuery(conn.url, systemReplicasSQL, &replicas); err != nil {
+		return err
+	}
+
+	for _, replica := range replicas {
+		tags := ch.makeDefaultTags(conn)
+		tags["database"] = replica.Database
+		tags["table"] = replica.Table
+		tags["replica_name"] = replica.ReplicaName
+		acc.AddFields("clickhouse_replicas",
+			map[string]interface{}{
+				"is_leader":          uint64(replica.IsLeader),
+				"is_readonly":        uint64(replica.IsReadonly),
+				"is_session_expired": uint64(replica.IsSessionExpired),
+				"queue_size":         uint64(replica.QueueSize),
+				"inserts_in_queue":   uint64(replica.InsertsInQueue),
+				"merges_in_queue":    uint64(replica.MergesInQueue),
+				"log_delay":          uint64(replica.LogDelay),
+				"absolute_delay":     uint64(replica.AbsoluteDelay),
+				"total_replicas":     uint64(replica.TotalReplicas),
+				"active_replicas":    uint64(replica.ActiveReplicas),
+			},
+			tags,
+		)
+	}
+	return nil
+}
+
 func (ch *ClickHouse) makeDefaultTags(conn *connect) map[string]string {
 	tags := map[string]string{
 		"source": conn.Hostname,
@@ -558,6 +605,9 @@ func (ch *ClickHouse) makeDefaultTags(conn *connect) map[string]string {
 	if conn.ShardNum != 0 {
 		tags["shard_num"] = strconv.Itoa(conn.ShardNum)
 	}
+	if conn.ReplicaNum != 0 {
+		tags["replica_num"] = strconv.Itoa(conn.ReplicaNum)
+	}
 	return tags
 }
 
@@ -641,6 +691,25 @@ const (
 		ORDER BY
 			database, table
 	`
+	systemReplicasSQL = `
+		SELECT
+			database,
+			table,
+			replica_name,
+			toUInt64(is_leader)          AS is_leader,
+			toUInt64(is_readonly)        AS is_readonly,
+			toUInt64(is_session_expired) AS is_session_expired,
+			toUInt64(queue_size)         AS queue_size,
+			toUInt64(inserts_in_queue)   AS inserts_in_queue,
+			toUInt64(merges_in_queue)    AS merges_in_queue,
+			toUInt64(if(log_max_index > log_pointer, log_max_index - log_pointer, 0)) AS log_delay,
+			toUInt64(absolute_delay)     AS absolute_delay,
+			toUInt64(total_replicas)     AS total_replicas,
+			toUInt64(active_replicas)    AS active_replicas
+		FROM system.replicas
+		ORDER BY
+			database, table
+	`
 	systemZookeeperExistsSQL    = "SELECT count() AS zk_exists FROM system.tables WHERE database='system' AND name='zookeeper'"
 	systemZookeeperRootNodesSQL = "SELECT count() AS zk_root_nodes FROM system.zookeeper WHERE path='/'"
 
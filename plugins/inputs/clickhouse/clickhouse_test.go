@@ -49,6 +49,18 @@ func TestChInt64(t *testing.T) {
 	}
 }
 
+func TestMakeDefaultTags(t *testing.T) {
+	ch := &ClickHouse{}
+	assert.Equal(t,
+		map[string]string{"source": "ch-1"},
+		ch.makeDefaultTags(&connect{Hostname: "ch-1"}),
+	)
+	assert.Equal(t,
+		map[string]string{"source": "ch-2", "cluster": "main", "shard_num": "1", "replica_num": "2"},
+		ch.makeDefaultTags(&connect{Hostname: "ch-2", Cluster: "main", ShardNum: 1, ReplicaNum: 2}),
+	)
+}
+
 func TestGather(t *testing.T) {
 	var (
 		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -75,6 +87,32 @@ func TestGather(t *testing.T) {
 						},
 					},
 				})
+			case strings.Contains(query, "system.replicas"):
+				_ = enc.Encode(result{
+					Data: []struct {
+						Database       string   `json:"database"`
+						Table          string   `json:"table"`
+						ReplicaName    string   `json:"replica_name"`
+						IsLeader       chUInt64 `json:"is_leader"`
+						QueueSize      chUInt64 `json:"queue_size"`
+						LogDelay       chUInt64 `json:"log_delay"`
+						AbsoluteDelay  chUInt64 `json:"absolute_delay"`
+						TotalReplicas  chUInt64 `json:"total_replicas"`
+						ActiveReplicas chUInt64 `json:"active_replicas"`
+					}{
+						{
+							Database:       "test_database",
+							Table:          "test_table",
+							ReplicaName:    "replica-1",
+							IsLeader:       1,
+							QueueSize:      5,
+							LogDelay:       2,
+							AbsoluteDelay:  30,
+							TotalReplicas:  2,
+							ActiveReplicas: 1,
+						},
+					},
+				})
 			case strings.Contains(query, "system.events"):
 				_ = enc.Encode(result{
 					Data: []struct {
@@ -309,6 +347,26 @@ func TestGather(t *testing.T) {
 			"database": "test_database",
 		},
 	)
+	acc.AssertContainsTaggedFields(t, "clickhouse_replicas",
+		map[string]interface{}{
+			"is_leader":          uint64(1),
+			"is_readonly":        uint64(0),
+			"is_session_expired": uint64(0),
+			"queue_size":         uint64(5),
+			"inserts_in_queue":   uint64(0),
+			"merges_in_queue":    uint64(0),
+			"log_delay":          uint64(2),
+			"absolute_delay":     uint64(30),
+			"total_replicas":     uint64(2),
+			"active_replicas":    uint64(1),
+		},
+		map[string]string{
+			"source":       "127.0.0.1",
+			"database":     "test_database",
+			"table":        "test_table",
+			"replica_name": "replica-1",
+		},
+	)
 	acc.AssertContainsFields(t, "clickhouse_events",
 		map[string]interface{}{
 			"test_system_event":  uint64(1000),
@@ -539,6 +597,7 @@ func TestOfflineServer(t *testing.T) {
 		"clickhouse_tables",
 		"clickhouse_zookeeper",
 		"clickhouse_replication_queue",
+		"clickhouse_replicas",
 		"clickhouse_detached_parts",
 		"clickhouse_dictionaries",
 		"clickhouse_mutations",
@@ -10,6 +10,7 @@ import (
 
 	"cloud.google.com/go/pubsub"
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
@@ -32,6 +33,7 @@ type PubSub struct {
 
 	// Subscription ReceiveSettings
 	MaxExtension           internal.Duration `toml:"max_extension"`
+	MaxExtensionPeriod     internal.Duration `toml:"max_extension_period"`
 	MaxOutstandingMessages int               `toml:"max_outstanding_messages"`
 	MaxOutstandingBytes    int               `toml:"max_outstanding_bytes"`
 	MaxReceiverGoRoutines  int               `toml:"max_receiver_go_routines"`
@@ -43,6 +45,8 @@ type PubSub struct {
 
 	Base64Data bool `toml:"base64_data"`
 
+	AttributesAsTags []string `toml:"attributes_as_tags"`
+
 	Log cua.Logger
 
 	sub     subscription
@@ -50,9 +54,10 @@ type PubSub struct {
 
 	cancel context.CancelFunc
 
-	parser parsers.Parser
-	wg     *sync.WaitGroup
-	acc    cua.TrackingAccumulator
+	parser     parsers.Parser
+	attrFilter filter.Filter
+	wg         *sync.WaitGroup
+	acc        cua.TrackingAccumulator
 
 	undelivered map[cua.TrackingID]message
 	sem         semaphore
@@ -88,6 +93,12 @@ func (ps *PubSub) Start(ctx context.Context, ac cua.Accumulator) error {
 		return fmt.Errorf(`"project" is required`)
 	}
 
+	attrFilter, err := filter.Compile(ps.AttributesAsTags)
+	if err != nil {
+		return fmt.Errorf("attributes_as_tags: %w", err)
+	}
+	ps.attrFilter = attrFilter
+
 	ps.sem = make(semaphore, ps.MaxUndeliveredMessages)
 	ps.acc = ac.WithTracking(ps.MaxUndeliveredMessages)
 
@@ -196,6 +207,17 @@ func (ps *PubSub) onMessage(ctx context.Context, msg message) error {
 		return nil
 	}
 
+	if ps.attrFilter != nil {
+		for name, value := range msg.Attributes() {
+			if !ps.attrFilter.Match(name) {
+				continue
+			}
+			for _, m := range metrics {
+				m.AddTag(name, value)
+			}
+		}
+	}
+
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -223,9 +245,15 @@ func (ps *PubSub) waitForDelivery(parentCtx context.Context) {
 		case info := <-ps.acc.Delivered():
 			<-ps.sem
 			msg := ps.removeDelivered(info.ID())
+			if msg == nil {
+				continue
+			}
 
-			if msg != nil {
+			// metrics the outputs failed to write are redelivered by PubSub
+			if info.Delivered() {
 				msg.Ack()
+			} else {
+				msg.Nack()
 			}
 		}
 	}
@@ -278,6 +306,7 @@ func (ps *PubSub) getGCPSubscription(subID string) (subscription, error) {
 	s.ReceiveSettings = pubsub.ReceiveSettings{
 		NumGoroutines:          ps.MaxReceiverGoRoutines,
 		MaxExtension:           ps.MaxExtension.Duration,
+		MaxExtensionPeriod:     ps.MaxExtensionPeriod.Duration,
 		MaxOutstandingMessages: ps.MaxOutstandingMessages,
 		MaxOutstandingBytes:    ps.MaxOutstandingBytes,
 	}
@@ -343,6 +372,11 @@ const sampleConfig = `
   ## 0, auto-extension is disabled.
   # max_extension = 0
 
+  ## Optional. Ack deadline requested by each extension of the PubSub ACK
+  ## deadline. If 0, the deadline follows the observed processing time of the
+  ## messages.
+  # max_extension_period = "0s"
+
   ## Optional. Maximum number of unprocessed messages in PubSub
   ## (unacknowledged but not yet expired in PubSub).
   ## A value of 0 is treated as the default PubSub value.
@@ -365,4 +399,8 @@ const sampleConfig = `
   ## Optional. If true, the agent will attempt to base64 decode the 
   ## PubSub message data before parsing
   # base64_data = false
+
+  ## Optional. Message attributes added as tags to the metrics of the
+  ## message, glob patterns are supported.
+  # attributes_as_tags = []
 `
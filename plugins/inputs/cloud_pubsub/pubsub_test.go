@@ -104,6 +104,54 @@ func TestRunBase64(t *testing.T) {
 	validateTestInfluxMetric(t, metric)
 }
 
+// Test adding message attributes as tags
+func TestRunAttributesAsTags(t *testing.T) {
+	subID := "sub-run-attributes"
+
+	testParser, _ := parsers.NewInfluxParser()
+
+	sub := &stubSub{
+		id:       subID,
+		messages: make(chan *testMsg, 100),
+	}
+	sub.receiver = testMessagesReceive(sub)
+
+	ps := &PubSub{
+		Log:                    testutil.Logger{},
+		parser:                 testParser,
+		stubSub:                func() subscription { return sub },
+		Project:                "projectIDontMatterForTests",
+		Subscription:           subID,
+		MaxUndeliveredMessages: defaultMaxUndeliveredMessages,
+		AttributesAsTags:       []string{"region", "cluster_*"},
+	}
+
+	acc := &testutil.Accumulator{}
+	if err := ps.Start(context.Background(), acc); err != nil {
+		t.Fatalf("test PubSub failed to start: %s", err)
+	}
+	defer ps.Stop()
+
+	testTracker := &testTracker{}
+	msg := &testMsg{
+		value: msgInflux,
+		attributes: map[string]string{
+			"region":       "us-east1",
+			"cluster_name": "prod",
+			"ignored":      "value",
+		},
+		tracker: testTracker,
+	}
+	sub.messages <- msg
+
+	acc.Wait(1)
+	assert.Equal(t, map[string]string{
+		"host":         "server01",
+		"region":       "us-east1",
+		"cluster_name": "prod",
+	}, acc.Metrics[0].Tags)
+}
+
 func TestRunInvalidMessages(t *testing.T) {
 	subID := "sub-invalid-messages"
 
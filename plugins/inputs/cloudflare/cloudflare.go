@@ -0,0 +1,321 @@
+// Package cloudflare reports zone request, bandwidth, threat and response
+// status statistics from the Cloudflare GraphQL Analytics API.
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const defaultURL = "https://api.cloudflare.com/client/v4"
+
+type Cloudflare struct {
+	Log       cua.Logger `toml:"-"`
+	client    *http.Client
+	zoneNames map[string]string
+	lastEnd   time.Time
+	URL       string            `toml:"url"`
+	APIToken  string            `toml:"api_token"`
+	Zones     []string          `toml:"zones"`
+	Delay     internal.Duration `toml:"delay"`
+	Timeout   internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+	mu sync.Mutex
+}
+
+const sampleConfig = `
+  ## Cloudflare API token with the Analytics:Read and Zone:Read permissions
+  api_token = ""
+
+  ## Zone IDs to report
+  zones = []
+
+  ## Cloudflare API url
+  # url = "https://api.cloudflare.com/client/v4"
+
+  ## Analytics are available after a short delay, each collection reports
+  ## the whole minutes, up to delay ago, since the previous collection.
+  # delay = "5m"
+
+  ## Timeout for API requests
+  # timeout = "10s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// zoneQuery sums the per minute request statistics of the zones over the
+// window, without dimensions each zone has a single group.
+const zoneQuery = `
+query ($zoneTags: [string!], $start: Time!, $end: Time!) {
+  viewer {
+    zones(filter: {zoneTag_in: $zoneTags}) {
+      zoneTag
+      httpRequests1mGroups(limit: 1, filter: {datetime_geq: $start, datetime_lt: $end}) {
+        sum {
+          requests
+          cachedRequests
+          bytes
+          cachedBytes
+          threats
+          pageViews
+          responseStatusMap {
+            edgeResponseStatus
+            requests
+          }
+        }
+      }
+    }
+  }
+}`
+
+type graphQLRequest struct {
+	Variables map[string]interface{} `json:"variables"`
+	Query     string                 `json:"query"`
+}
+
+type graphQLResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+	Data struct {
+		Viewer struct {
+			Zones []zone `json:"zones"`
+		} `json:"viewer"`
+	} `json:"data"`
+}
+
+type zone struct {
+	ZoneTag string `json:"zoneTag"`
+	Groups  []struct {
+		Sum struct {
+			ResponseStatusMap []struct {
+				Status   int    `json:"edgeResponseStatus"`
+				Requests uint64 `json:"requests"`
+			} `json:"responseStatusMap"`
+			Requests       uint64 `json:"requests"`
+			CachedRequests uint64 `json:"cachedRequests"`
+			Bytes          uint64 `json:"bytes"`
+			CachedBytes    uint64 `json:"cachedBytes"`
+			Threats        uint64 `json:"threats"`
+			PageViews      uint64 `json:"pageViews"`
+		} `json:"sum"`
+	} `json:"httpRequests1mGroups"`
+}
+
+func (c *Cloudflare) Description() string {
+	return "Read zone request, bandwidth, threat and status code statistics from the Cloudflare GraphQL Analytics API"
+}
+
+func (c *Cloudflare) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cloudflare) Init() error {
+	if c.APIToken == "" {
+		return errors.New("api_token is required")
+	}
+	if len(c.Zones) == 0 {
+		return errors.New("no zones configured")
+	}
+
+	tlsConfig, err := c.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	c.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+		Timeout: c.Timeout.Duration,
+	}
+	c.URL = strings.TrimSuffix(c.URL, "/")
+	c.zoneNames = make(map[string]string)
+
+	return nil
+}
+
+func (c *Cloudflare) Gather(ctx context.Context, acc cua.Accumulator) error {
+	return c.gather(ctx, acc, time.Now())
+}
+
+func (c *Cloudflare) gather(ctx context.Context, acc cua.Accumulator, now time.Time) error {
+	end := now.Add(-c.Delay.Duration).Truncate(time.Minute)
+	start := c.lastEnd
+	if start.IsZero() {
+		start = end.Add(-time.Minute)
+	}
+	if !end.After(start) {
+		return nil
+	}
+
+	zones, err := c.queryZones(ctx, start, end)
+	if err != nil {
+		return err
+	}
+	c.lastEnd = end
+
+	for _, z := range zones {
+		tags := map[string]string{"zone_id": z.ZoneTag}
+		if name := c.zoneName(ctx, z.ZoneTag); name != "" {
+			tags["zone"] = name
+		}
+
+		var requests, cachedRequests, bytesSent, cachedBytes, threats, pageViews uint64
+		statuses := make(map[int]uint64)
+		for _, g := range z.Groups {
+			s := g.Sum
+			requests += s.Requests
+			cachedRequests += s.CachedRequests
+			bytesSent += s.Bytes
+			cachedBytes += s.CachedBytes
+			threats += s.Threats
+			pageViews += s.PageViews
+			for _, r := range s.ResponseStatusMap {
+				statuses[r.Status] += r.Requests
+			}
+		}
+
+		fields := map[string]interface{}{
+			"requests":          requests,
+			"cached_requests":   cachedRequests,
+			"uncached_requests": requests - cachedRequests,
+			"bytes":             bytesSent,
+			"cached_bytes":      cachedBytes,
+			"uncached_bytes":    bytesSent - cachedBytes,
+			"threats":           threats,
+			"page_views":        pageViews,
+		}
+		if requests > 0 {
+			fields["cache_ratio"] = float64(cachedRequests) / float64(requests) * 100
+		}
+		for status, n := range statuses {
+			class := fmt.Sprintf("status_%dxx", status/100)
+			if v, ok := fields[class].(uint64); ok {
+				n += v
+			}
+			fields[class] = n
+		}
+		acc.AddFields("cloudflare", fields, tags, end)
+
+		for status, n := range statuses {
+			statusTags := make(map[string]string, len(tags)+1)
+			for k, v := range tags {
+				statusTags[k] = v
+			}
+			statusTags["status"] = strconv.Itoa(status)
+			acc.AddFields("cloudflare_status", map[string]interface{}{"requests": n}, statusTags, end)
+		}
+	}
+
+	return nil
+}
+
+// queryZones returns the request statistics of the configured zones for
+// the window [start, end)
+func (c *Cloudflare) queryZones(ctx context.Context, start, end time.Time) ([]zone, error) {
+	body, err := json.Marshal(graphQLRequest{
+		Query: zoneQuery,
+		Variables: map[string]interface{}{
+			"zoneTags": c.Zones,
+			"start":    start.UTC().Format(time.RFC3339),
+			"end":      end.UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("json marshal: %w", err)
+	}
+
+	var r graphQLResponse
+	if err := c.do(ctx, http.MethodPost, c.URL+"/graphql", body, &r); err != nil {
+		return nil, err
+	}
+	if len(r.Errors) > 0 {
+		msgs := make([]string, 0, len(r.Errors))
+		for _, e := range r.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return nil, fmt.Errorf("graphql query: %s", strings.Join(msgs, "; "))
+	}
+
+	return r.Data.Viewer.Zones, nil
+}
+
+// zoneName returns the name of a zone, names are looked up once and cached
+// and a failed lookup is logged and not retried.
+func (c *Cloudflare) zoneName(ctx context.Context, id string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if name, ok := c.zoneNames[id]; ok {
+		return name
+	}
+
+	var r struct {
+		Result struct {
+			Name string `json:"name"`
+		} `json:"result"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.URL+"/zones/"+id, nil, &r); err != nil {
+		c.Log.Warnf("looking up name of zone %s: %v", id, err)
+	}
+	c.zoneNames[id] = r.Result.Name
+
+	return r.Result.Name
+}
+
+func (c *Cloudflare) do(ctx context.Context, method, addr string, body []byte, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, addr, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, addr, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, addr, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+
+	return nil
+}
+
+func init() {
+	inputs.Add("cloudflare", func() cua.Input {
+		return &Cloudflare{
+			URL:     defaultURL,
+			Delay:   internal.Duration{Duration: 5 * time.Minute},
+			Timeout: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
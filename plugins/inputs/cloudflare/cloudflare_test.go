@@ -0,0 +1,145 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const zonesResponse = `
+{
+  "data": {
+    "viewer": {
+      "zones": [
+        {
+          "zoneTag": "023e105f4ecef8ad9ca31a8372d0c353",
+          "httpRequests1mGroups": [
+            {
+              "sum": {
+                "requests": 1000,
+                "cachedRequests": 800,
+                "bytes": 4096000,
+                "cachedBytes": 3072000,
+                "threats": 3,
+                "pageViews": 250,
+                "responseStatusMap": [
+                  {"edgeResponseStatus": 200, "requests": 900},
+                  {"edgeResponseStatus": 206, "requests": 20},
+                  {"edgeResponseStatus": 304, "requests": 50},
+                  {"edgeResponseStatus": 404, "requests": 25},
+                  {"edgeResponseStatus": 503, "requests": 5}
+                ]
+              }
+            }
+          ]
+        }
+      ]
+    }
+  },
+  "errors": null
+}`
+
+func newServer(t *testing.T, queries *[]graphQLRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/graphql":
+			var q graphQLRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&q))
+			*queries = append(*queries, q)
+			fmt.Fprint(w, zonesResponse)
+		case "/zones/023e105f4ecef8ad9ca31a8372d0c353":
+			fmt.Fprint(w, `{"success": true, "result": {"id": "023e105f4ecef8ad9ca31a8372d0c353", "name": "example.com"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGather(t *testing.T) {
+	var queries []graphQLRequest
+	ts := newServer(t, &queries)
+	defer ts.Close()
+
+	c := &Cloudflare{
+		Log:      testutil.Logger{},
+		URL:      ts.URL,
+		APIToken: "secret",
+		Zones:    []string{"023e105f4ecef8ad9ca31a8372d0c353"},
+		Delay:    internal.Duration{Duration: 5 * time.Minute},
+	}
+	require.NoError(t, c.Init())
+
+	now := time.Date(2021, 6, 1, 12, 10, 30, 0, time.UTC)
+	var acc testutil.Accumulator
+	require.NoError(t, c.gather(context.Background(), &acc, now))
+	require.Empty(t, acc.Errors)
+
+	require.Len(t, queries, 1)
+	require.Equal(t, "2021-06-01T12:04:00Z", queries[0].Variables["start"])
+	require.Equal(t, "2021-06-01T12:05:00Z", queries[0].Variables["end"])
+
+	tags := map[string]string{"zone_id": "023e105f4ecef8ad9ca31a8372d0c353", "zone": "example.com"}
+	acc.AssertContainsTaggedFields(t, "cloudflare",
+		map[string]interface{}{
+			"requests":          uint64(1000),
+			"cached_requests":   uint64(800),
+			"uncached_requests": uint64(200),
+			"bytes":             uint64(4096000),
+			"cached_bytes":      uint64(3072000),
+			"uncached_bytes":    uint64(1024000),
+			"threats":           uint64(3),
+			"page_views":        uint64(250),
+			"cache_ratio":       float64(80),
+			"status_2xx":        uint64(920),
+			"status_3xx":        uint64(50),
+			"status_4xx":        uint64(25),
+			"status_5xx":        uint64(5),
+		}, tags)
+
+	statusTags := map[string]string{"status": "404"}
+	for k, v := range tags {
+		statusTags[k] = v
+	}
+	acc.AssertContainsTaggedFields(t, "cloudflare_status",
+		map[string]interface{}{"requests": uint64(25)}, statusTags)
+
+	// the next window starts where the previous ended
+	require.NoError(t, c.gather(context.Background(), &acc, now.Add(3*time.Minute)))
+	require.Len(t, queries, 2)
+	require.Equal(t, "2021-06-01T12:05:00Z", queries[1].Variables["start"])
+	require.Equal(t, "2021-06-01T12:08:00Z", queries[1].Variables["end"])
+
+	// nothing new within the same minute
+	require.NoError(t, c.gather(context.Background(), &acc, now.Add(3*time.Minute+10*time.Second)))
+	require.Len(t, queries, 2)
+}
+
+func TestGatherGraphQLError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": null, "errors": [{"message": "zone '1' does not have access to the path"}]}`)
+	}))
+	defer ts.Close()
+
+	c := &Cloudflare{
+		Log:      testutil.Logger{},
+		URL:      ts.URL,
+		APIToken: "secret",
+		Zones:    []string{"1"},
+	}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	err := c.Gather(context.Background(), &acc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not have access")
+	require.Empty(t, acc.Metrics)
+}
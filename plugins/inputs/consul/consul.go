@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
@@ -23,6 +24,8 @@ type Consul struct {
 	tls.ClientConfig
 	TagDelimiter  string
 	MetricVersion int
+	GatherCatalog bool `toml:"gather_catalog"`
+	GatherRaft    bool `toml:"gather_raft"`
 	Log           cua.Logger
 
 	// client used to connect to Consul agnet
@@ -64,6 +67,14 @@ var sampleConfig = `
   # When tags are formatted like "key:value" with ":" as a delimiter then
   # they will be splitted and reported as proper key:value in circonus-unified-agent
   # tag_delimiter = ":"
+
+  ## Gather catalog metrics, the number of services and nodes and per
+  ## service instance counts by health status
+  # gather_catalog = false
+
+  ## Gather raft peer and autopilot server health metrics, requires a token
+  ## with operator:read
+  # gather_raft = false
 `
 
 func (c *Consul) Init() error {
@@ -75,7 +86,7 @@ func (c *Consul) Init() error {
 }
 
 func (c *Consul) Description() string {
-	return "Gather health check statuses from services registered in Consul"
+	return "Gather health check statuses, catalog and raft metrics from Consul"
 }
 
 func (c *Consul) SampleConfig() string {
@@ -184,9 +195,169 @@ func (c *Consul) Gather(ctx context.Context, acc cua.Accumulator) error {
 
 	c.GatherHealthCheck(acc, checks)
 
+	if c.GatherCatalog {
+		if err := c.gatherCatalog(acc, checks); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	if c.GatherRaft {
+		if err := c.gatherRaft(acc); err != nil {
+			acc.AddError(err)
+		}
+		if err := c.gatherAutopilot(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	return nil
+}
+
+// gatherCatalog reports the number of services and nodes in the catalog, and
+// for each service the number of instances and their health, the status of
+// an instance is the worst status of its checks (including node checks)
+func (c *Consul) gatherCatalog(acc cua.Accumulator, checks []*api.HealthCheck) error {
+	services, _, err := c.client.Catalog().Services(nil)
+	if err != nil {
+		return fmt.Errorf("catalog services: %w", err)
+	}
+	nodes, _, err := c.client.Catalog().Nodes(nil)
+	if err != nil {
+		return fmt.Errorf("catalog nodes: %w", err)
+	}
+
+	acc.AddFields("consul_catalog", map[string]interface{}{
+		"services": len(services),
+		"nodes":    len(nodes),
+	}, map[string]string{})
+
+	nodeStatus := make(map[string]string)
+	instanceStatus := make(map[string]string)
+	for _, check := range checks {
+		if check.ServiceID == "" {
+			nodeStatus[check.Node] = worseStatus(nodeStatus[check.Node], check.Status)
+			continue
+		}
+		key := check.Node + "/" + check.ServiceID
+		instanceStatus[key] = worseStatus(instanceStatus[key], check.Status)
+	}
+
+	for name := range services {
+		instances, _, err := c.client.Catalog().Service(name, "", nil)
+		if err != nil {
+			acc.AddError(fmt.Errorf("catalog service (%s): %w", name, err))
+			continue
+		}
+
+		fields := map[string]interface{}{
+			"instances":             len(instances),
+			"instances_passing":     0,
+			"instances_warning":     0,
+			"instances_critical":    0,
+			"instances_maintenance": 0,
+		}
+		for _, instance := range instances {
+			status := worseStatus(nodeStatus[instance.Node], instanceStatus[instance.Node+"/"+instance.ServiceID])
+			if status == "" {
+				status = api.HealthPassing
+			}
+			if _, ok := fields["instances_"+status]; ok {
+				fields["instances_"+status] = fields["instances_"+status].(int) + 1
+			}
+		}
+
+		acc.AddFields("consul_catalog_service", fields, map[string]string{"service_name": name})
+	}
+
+	return nil
+}
+
+// worseStatus returns the worse of two check statuses, an empty status is
+// no status
+func worseStatus(a, b string) string {
+	rank := map[string]int{
+		"":                 0,
+		api.HealthPassing:  1,
+		api.HealthWarning:  2,
+		api.HealthCritical: 3,
+		api.HealthMaint:    4,
+	}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// gatherRaft reports the raft peer set as seen by the agent
+func (c *Consul) gatherRaft(acc cua.Accumulator) error {
+	cfg, err := c.client.Operator().RaftGetConfiguration(nil)
+	if err != nil {
+		return fmt.Errorf("raft configuration: %w", err)
+	}
+
+	voters := 0
+	hasLeader := 0
+	for _, server := range cfg.Servers {
+		if server.Voter {
+			voters++
+		}
+		if server.Leader {
+			hasLeader = 1
+		}
+	}
+
+	acc.AddFields("consul_raft", map[string]interface{}{
+		"peers":      len(cfg.Servers),
+		"voters":     voters,
+		"has_leader": hasLeader,
+		"index":      cfg.Index,
+	}, map[string]string{})
+
 	return nil
 }
 
+// gatherAutopilot reports the health of the servers according to autopilot
+func (c *Consul) gatherAutopilot(acc cua.Accumulator) error {
+	health, err := c.client.Operator().AutopilotServerHealth(nil)
+	if err != nil {
+		return fmt.Errorf("autopilot health: %w", err)
+	}
+
+	healthy := 0
+	for _, server := range health.Servers {
+		if server.Healthy {
+			healthy++
+		}
+		acc.AddFields("consul_autopilot_server", map[string]interface{}{
+			"healthy":         boolInt(server.Healthy),
+			"leader":          boolInt(server.Leader),
+			"voter":           boolInt(server.Voter),
+			"last_contact_ms": float64(server.LastContact.Duration()) / float64(time.Millisecond),
+			"last_term":       server.LastTerm,
+			"last_index":      server.LastIndex,
+		}, map[string]string{
+			"server": server.Name,
+			"id":     server.ID,
+		})
+	}
+
+	acc.AddFields("consul_autopilot", map[string]interface{}{
+		"healthy":           boolInt(health.Healthy),
+		"failure_tolerance": health.FailureTolerance,
+		"servers":           len(health.Servers),
+		"healthy_servers":   healthy,
+	}, map[string]string{})
+
+	return nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func init() {
 	inputs.Add("consul", func() cua.Input {
 		return &Consul{}
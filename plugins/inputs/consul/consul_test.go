@@ -1,10 +1,15 @@
 package consul
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
 )
 
 var sampleChecks = []*api.HealthCheck{
@@ -135,3 +140,143 @@ func TestGatherHealthCheckWithDelimitedTagsV2(t *testing.T) {
 
 	acc.AssertContainsTaggedFields(t, "consul_health_checks", expectedFields, expectedTags)
 }
+
+func newTestServer() *httptest.Server {
+	responses := map[string]string{
+		"/v1/health/state/any": `[
+			{"Node": "node1", "CheckID": "serfHealth", "Name": "Serf Health Status", "Status": "passing"},
+			{"Node": "node2", "CheckID": "serfHealth", "Name": "Serf Health Status", "Status": "critical"},
+			{"Node": "node1", "CheckID": "service:web1", "Name": "web", "Status": "warning", "ServiceID": "web1", "ServiceName": "web"},
+			{"Node": "node1", "CheckID": "service:web2", "Name": "web", "Status": "passing", "ServiceID": "web2", "ServiceName": "web"},
+			{"Node": "node2", "CheckID": "service:web3", "Name": "web", "Status": "passing", "ServiceID": "web3", "ServiceName": "web"}
+		]`,
+		"/v1/catalog/services": `{"consul": [], "web": ["v1"]}`,
+		"/v1/catalog/nodes":    `[{"Node": "node1"}, {"Node": "node2"}]`,
+		"/v1/catalog/service/consul": `[
+			{"Node": "node1", "ServiceID": "consul", "ServiceName": "consul"}
+		]`,
+		"/v1/catalog/service/web": `[
+			{"Node": "node1", "ServiceID": "web1", "ServiceName": "web"},
+			{"Node": "node1", "ServiceID": "web2", "ServiceName": "web"},
+			{"Node": "node2", "ServiceID": "web3", "ServiceName": "web"},
+			{"Node": "node1", "ServiceID": "web4", "ServiceName": "web"}
+		]`,
+		"/v1/operator/raft/configuration": `{
+			"Servers": [
+				{"ID": "a", "Node": "node1", "Leader": true, "Voter": true},
+				{"ID": "b", "Node": "node2", "Leader": false, "Voter": true},
+				{"ID": "c", "Node": "node3", "Leader": false, "Voter": false}
+			],
+			"Index": 42
+		}`,
+		"/v1/operator/autopilot/health": `{
+			"Healthy": false,
+			"FailureTolerance": 0,
+			"Servers": [
+				{"ID": "a", "Name": "node1", "Leader": true, "Voter": true, "Healthy": true, "LastContact": "0s", "LastTerm": 3, "LastIndex": 100},
+				{"ID": "b", "Name": "node2", "Leader": false, "Voter": true, "Healthy": false, "LastContact": "1.5s", "LastTerm": 3, "LastIndex": 90}
+			]
+		}`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func TestGatherCatalogAndRaft(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	consul := &Consul{
+		Address:       strings.TrimPrefix(ts.URL, "http://"),
+		Token:         "secret",
+		MetricVersion: 2,
+		GatherCatalog: true,
+		GatherRaft:    true,
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, consul.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, consul.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "consul_catalog",
+		map[string]interface{}{
+			"services": 2,
+			"nodes":    2,
+		},
+		map[string]string{})
+
+	// web1 has a warning check, web3 is on a node with a critical check
+	// and web4 has no checks
+	acc.AssertContainsTaggedFields(t, "consul_catalog_service",
+		map[string]interface{}{
+			"instances":             4,
+			"instances_passing":     2,
+			"instances_warning":     1,
+			"instances_critical":    1,
+			"instances_maintenance": 0,
+		},
+		map[string]string{"service_name": "web"})
+
+	acc.AssertContainsTaggedFields(t, "consul_raft",
+		map[string]interface{}{
+			"peers":      3,
+			"voters":     2,
+			"has_leader": 1,
+			"index":      uint64(42),
+		},
+		map[string]string{})
+
+	acc.AssertContainsTaggedFields(t, "consul_autopilot",
+		map[string]interface{}{
+			"healthy":           0,
+			"failure_tolerance": 0,
+			"servers":           2,
+			"healthy_servers":   1,
+		},
+		map[string]string{})
+
+	acc.AssertContainsTaggedFields(t, "consul_autopilot_server",
+		map[string]interface{}{
+			"healthy":         0,
+			"leader":          0,
+			"voter":           1,
+			"last_contact_ms": 1500.0,
+			"last_term":       uint64(3),
+			"last_index":      uint64(90),
+		},
+		map[string]string{"server": "node2", "id": "b"})
+}
+
+func TestGatherToken(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	consul := &Consul{
+		Address:    strings.TrimPrefix(ts.URL, "http://"),
+		Token:      "secret",
+		GatherRaft: true,
+		Log:        testutil.Logger{},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, consul.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	consul.client = nil
+	consul.Token = "wrong"
+	require.Error(t, consul.Gather(context.Background(), &acc))
+}
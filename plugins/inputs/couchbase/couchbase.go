@@ -2,9 +2,13 @@ package couchbase
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"regexp"
 	"sync"
+	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
@@ -12,7 +16,48 @@ import (
 )
 
 type Couchbase struct {
-	Servers []string
+	Servers             []string
+	Username            string `toml:"username"`
+	Password            string `toml:"password"`
+	ExtendedBucketStats bool   `toml:"extended_bucket_stats"`
+
+	client *http.Client
+}
+
+// extendedBucketStats are the stats of the bucket stats endpoint reported
+// with extended_bucket_stats
+var extendedBucketStats = []string{
+	"cmd_get",
+	"cmd_set",
+	"couch_docs_actual_disk_size",
+	"couch_docs_fragmentation",
+	"curr_connections",
+	"curr_items",
+	"ep_bg_fetched",
+	"ep_cache_miss_rate",
+	"ep_diskqueue_drain",
+	"ep_diskqueue_fill",
+	"ep_flusher_todo",
+	"ep_mem_high_wat",
+	"ep_mem_low_wat",
+	"ep_num_value_ejects",
+	"ep_oom_errors",
+	"ep_queue_size",
+	"ep_tmp_oom_errors",
+	"get_hits",
+	"get_misses",
+	"ops",
+	"vb_active_num",
+	"vb_active_resident_items_ratio",
+	"vb_replica_resident_items_ratio",
+}
+
+// bucketStats is the response of /pools/default/buckets/<bucket>/stats,
+// samples holds the last minute of each stat
+type bucketStats struct {
+	Op struct {
+		Samples map[string][]float64 `json:"samples"`
+	} `json:"op"`
 }
 
 var sampleConfig = `
@@ -26,6 +71,14 @@ var sampleConfig = `
   ## If no protocol is specified, HTTP is used.
   ## If no port is specified, 8091 is used.
   servers = ["http://localhost:8091"]
+
+  ## HTTP basic auth credentials, used when the server url has none
+  # username = ""
+  # password = ""
+
+  ## Report the disk write queue, resident ratios, cache misses and other
+  ## stats of each bucket, one request per bucket
+  # extended_bucket_stats = false
 `
 
 var regexpURI = regexp.MustCompile(`(\S+://)?(\S+\:\S+@)`)
@@ -63,7 +116,13 @@ func (r *Couchbase) Gather(ctx context.Context, acc cua.Accumulator) error {
 
 func (r *Couchbase) gatherServer(addr string, acc cua.Accumulator, pool *couchbase.Pool) error {
 	if pool == nil {
-		client, err := couchbase.Connect(addr)
+		var client couchbase.Client
+		var err error
+		if r.Username != "" {
+			client, err = couchbase.ConnectWithAuthCreds(addr, r.Username, r.Password)
+		} else {
+			client, err = couchbase.Connect(addr)
+		}
 		if err != nil {
 			return fmt.Errorf("connect (%s): %w", addr, err)
 		}
@@ -98,11 +157,80 @@ func (r *Couchbase) gatherServer(addr string, acc cua.Accumulator, pool *couchba
 		fields["disk_used"] = bs["diskUsed"]
 		fields["data_used"] = bs["dataUsed"]
 		fields["mem_used"] = bs["memUsed"]
+		if r.ExtendedBucketStats {
+			if err := r.gatherBucketStats(addr, bucketName, fields); err != nil {
+				acc.AddError(fmt.Errorf("bucket stats (%s): %w", bucketName, err))
+			}
+		}
 		acc.AddFields("couchbase_bucket", fields, tags)
 	}
 	return nil
 }
 
+// gatherBucketStats adds the most recent sample of the extended bucket stats
+// to fields
+func (r *Couchbase) gatherBucketStats(addr, bucket string, fields map[string]interface{}) error {
+	base, err := couchbase.ParseURL(addr)
+	if err != nil {
+		return fmt.Errorf("parse url: %w", err)
+	}
+	u := *base
+	u.User = nil
+	u.Path = "/pools/default/buckets/" + url.PathEscape(bucket) + "/stats"
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	switch {
+	case base.User != nil:
+		password, _ := base.User.Password()
+		req.SetBasicAuth(base.User.Username(), password)
+	case r.Username != "":
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+
+	if r.client == nil {
+		r.client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP status %s", u.Path, resp.Status)
+	}
+
+	var stats bucketStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	last := func(name string) (float64, bool) {
+		samples := stats.Op.Samples[name]
+		if len(samples) == 0 {
+			return 0, false
+		}
+		return samples[len(samples)-1], true
+	}
+
+	for _, name := range extendedBucketStats {
+		if v, ok := last(name); ok {
+			fields[name] = v
+		}
+	}
+
+	// the disk write queue shown in the couchbase console
+	queue, ok := last("ep_queue_size")
+	todo, todoOK := last("ep_flusher_todo")
+	if ok && todoOK {
+		fields["disk_write_queue"] = queue + todo
+	}
+
+	return nil
+}
+
 func init() {
 	inputs.Add("couchbase", func() cua.Input {
 		return &Couchbase{}
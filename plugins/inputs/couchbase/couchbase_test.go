@@ -2,6 +2,9 @@ package couchbase
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
@@ -40,6 +43,59 @@ func TestGatherServer(t *testing.T) {
 		map[string]string{"cluster": "mycluster", "bucket": "blastro-df"})
 }
 
+func TestGatherServerExtendedBucketStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pools/default/buckets/blastro-df/stats" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"op":{"samples":{"ep_queue_size":[10,12],"ep_flusher_todo":[3,5],`+
+			`"vb_active_resident_items_ratio":[100,98.5],"ops":[5000,5686],"ep_cache_miss_rate":[0,0.25],`+
+			`"timestamp":[1602590534000,1602590535000]},"samplesCount":60,"isPersistent":true,"interval":1000}}`)
+	}))
+	defer ts.Close()
+
+	var pool couchbase.Pool
+	if err := json.Unmarshal([]byte(poolsDefaultResponse), &pool); err != nil {
+		t.Fatal("parse poolsDefaultResponse", err)
+	}
+	if err := json.Unmarshal([]byte(bucketResponse), &pool.BucketMap); err != nil {
+		t.Fatal("parse bucketResponse", err)
+	}
+
+	cb := Couchbase{Username: "admin", Password: "secret", ExtendedBucketStats: true}
+	var acc testutil.Accumulator
+	if err := cb.gatherServer(ts.URL, &acc, &pool); err != nil {
+		t.Fatal(err)
+	}
+	if len(acc.Errors) != 0 {
+		t.Fatal(acc.Errors)
+	}
+
+	acc.AssertContainsTaggedFields(t, "couchbase_bucket",
+		map[string]interface{}{
+			"quota_percent_used":             68.85424936294555,
+			"ops_per_sec":                    5686.789686789687,
+			"disk_fetches":                   0.0,
+			"item_count":                     943239752.0,
+			"disk_used":                      409178772321.0,
+			"data_used":                      212179309111.0,
+			"mem_used":                       202156957464.0,
+			"ep_queue_size":                  12.0,
+			"ep_flusher_todo":                5.0,
+			"disk_write_queue":               17.0,
+			"vb_active_resident_items_ratio": 98.5,
+			"ops":                            5686.0,
+			"ep_cache_miss_rate":             0.25,
+		},
+		map[string]string{"cluster": ts.URL, "bucket": "blastro-df"})
+}
+
 func TestSanitizeURI(t *testing.T) {
 
 	var sanitizeTest = []struct {
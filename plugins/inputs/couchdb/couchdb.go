@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 
@@ -80,15 +81,27 @@ type (
 		Httpd               httpd               `json:"httpd"`
 	}
 
+	activeTask struct {
+		Type             string   `json:"type"`
+		Progress         *float64 `json:"progress"`
+		ChangesPending   *float64 `json:"changes_pending"`
+		DocWriteFailures *float64 `json:"doc_write_failures"`
+	}
+
 	CouchDB struct {
-		Hosts         []string `toml:"hosts"`
-		BasicUsername string   `toml:"basic_username"`
-		BasicPassword string   `toml:"basic_password"`
+		Hosts             []string `toml:"hosts"`
+		BasicUsername     string   `toml:"basic_username"`
+		BasicPassword     string   `toml:"basic_password"`
+		GatherActiveTasks bool     `toml:"gather_active_tasks"`
 
 		client *http.Client
 	}
 )
 
+// activeTaskTypes are always reported so the task counts drop to zero when
+// the tasks finish
+var activeTaskTypes = []string{"database_compaction", "indexer", "replication", "view_compaction"}
+
 func (*CouchDB) Description() string {
 	return "Read CouchDB Stats from one or more servers"
 }
@@ -102,6 +115,10 @@ func (*CouchDB) SampleConfig() string {
   ## Use HTTP Basic Authentication.
   # basic_username = "circonus-unified-agent"
   # basic_password = "p@ssw0rd"
+
+  ## Report the running tasks by type from /_active_tasks on the same
+  ## server, requires a server admin user
+  # gather_active_tasks = false
 `
 }
 
@@ -114,6 +131,11 @@ func (c *CouchDB) Gather(ctx context.Context, accumulator cua.Accumulator) error
 			if err := c.fetchAndInsertData(accumulator, host); err != nil {
 				accumulator.AddError(fmt.Errorf("[host=%s]: %w", host, err))
 			}
+			if c.GatherActiveTasks {
+				if err := c.fetchActiveTasks(accumulator, host); err != nil {
+					accumulator.AddError(fmt.Errorf("[host=%s]: active tasks: %w", host, err))
+				}
+			}
 		}(u)
 	}
 
@@ -259,6 +281,89 @@ func (c *CouchDB) fetchAndInsertData(accumulator cua.Accumulator, host string) e
 	return nil
 }
 
+// fetchActiveTasks reports the number of running tasks by type, with the
+// mean progress of the tasks and the pending changes of replications
+func (c *CouchDB) fetchActiveTasks(accumulator cua.Accumulator, host string) error {
+	u, err := url.Parse(host)
+	if err != nil {
+		return fmt.Errorf("parse url: %w", err)
+	}
+	u.Path = "/_active_tasks"
+	u.RawQuery = ""
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("http new req (%s): %w", u, err)
+	}
+	if c.BasicUsername != "" || c.BasicPassword != "" {
+		req.SetBasicAuth(c.BasicUsername, c.BasicPassword)
+	}
+
+	response, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http req do: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return fmt.Errorf("Failed to get active tasks from couchdb: HTTP responded %d", response.StatusCode)
+	}
+
+	var tasks []activeTask
+	if err := json.NewDecoder(response.Body).Decode(&tasks); err != nil {
+		return fmt.Errorf("json decode: %w", err)
+	}
+
+	type summary struct {
+		tasks            int64
+		withProgress     int64
+		progress         float64
+		changesPending   float64
+		docWriteFailures float64
+	}
+	byType := make(map[string]*summary)
+	for _, taskType := range activeTaskTypes {
+		byType[taskType] = &summary{}
+	}
+	for _, task := range tasks {
+		s, ok := byType[task.Type]
+		if !ok {
+			s = &summary{}
+			byType[task.Type] = s
+		}
+		s.tasks++
+		if task.Progress != nil {
+			s.withProgress++
+			s.progress += *task.Progress
+		}
+		if task.ChangesPending != nil {
+			s.changesPending += *task.ChangesPending
+		}
+		if task.DocWriteFailures != nil {
+			s.docWriteFailures += *task.DocWriteFailures
+		}
+	}
+
+	for taskType, s := range byType {
+		fields := map[string]interface{}{
+			"tasks": s.tasks,
+		}
+		if s.withProgress > 0 {
+			fields["progress_mean"] = s.progress / float64(s.withProgress)
+		}
+		if taskType == "replication" {
+			fields["changes_pending"] = s.changesPending
+			fields["doc_write_failures"] = s.docWriteFailures
+		}
+		accumulator.AddFields("couchdb_active_tasks", fields, map[string]string{
+			"server": host,
+			"type":   taskType,
+		})
+	}
+
+	return nil
+}
+
 func (c *CouchDB) generateFields(fields map[string]interface{}, prefix string, obj metaData) {
 	if obj.Value != nil {
 		fields[prefix+"_value"] = *obj.Value
@@ -319,3 +319,63 @@ func TestBasic(t *testing.T) {
 	var acc testutil.Accumulator
 	require.NoError(t, acc.GatherError(plugin.Gather))
 }
+
+func TestActiveTasks(t *testing.T) {
+	tasks := `[
+  {"node": "couchdb@127.0.0.1", "pid": "<0.1.0>", "type": "replication", "changes_pending": 15, "doc_write_failures": 1, "docs_written": 100},
+  {"node": "couchdb@127.0.0.1", "pid": "<0.2.0>", "type": "replication", "changes_pending": 5, "doc_write_failures": 0, "docs_written": 10},
+  {"node": "couchdb@127.0.0.1", "pid": "<0.3.0>", "type": "indexer", "progress": 40, "design_document": "_design/a"},
+  {"node": "couchdb@127.0.0.1", "pid": "<0.4.0>", "type": "indexer", "progress": 60, "design_document": "_design/b"},
+  {"node": "couchdb@127.0.0.1", "pid": "<0.5.0>", "type": "search_indexer", "progress": 10}
+]`
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_node/_local/_stats":
+			_, _ = w.Write([]byte(`{"couchdb": {}}`))
+		case "/_active_tasks":
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "admin" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(tasks))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fakeServer.Close()
+
+	host := fakeServer.URL + "/_node/_local/_stats"
+	plugin := &couchdb.CouchDB{
+		Hosts:             []string{host},
+		BasicUsername:     "admin",
+		BasicPassword:     "secret",
+		GatherActiveTasks: true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	acc.AssertContainsTaggedFields(t, "couchdb_active_tasks",
+		map[string]interface{}{
+			"tasks":              int64(2),
+			"changes_pending":    20.0,
+			"doc_write_failures": 1.0,
+		},
+		map[string]string{"server": host, "type": "replication"})
+	acc.AssertContainsTaggedFields(t, "couchdb_active_tasks",
+		map[string]interface{}{
+			"tasks":         int64(2),
+			"progress_mean": 50.0,
+		},
+		map[string]string{"server": host, "type": "indexer"})
+	acc.AssertContainsTaggedFields(t, "couchdb_active_tasks",
+		map[string]interface{}{"tasks": int64(0)},
+		map[string]string{"server": host, "type": "database_compaction"})
+	acc.AssertContainsTaggedFields(t, "couchdb_active_tasks",
+		map[string]interface{}{
+			"tasks":         int64(1),
+			"progress_mean": 10.0,
+		},
+		map[string]string{"server": host, "type": "search_indexer"})
+}
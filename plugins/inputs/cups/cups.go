@@ -0,0 +1,355 @@
+// Package cups implements a plugin for collecting printer and print queue
+// status from CUPS servers over IPP.
+package cups
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const defaultServer = "http://localhost:631"
+
+// printerStopped is the printer-state of a stopped printer, idle and
+// processing are 3 and 4
+const printerStopped = 5
+
+// job-state values of the jobs which are not completed
+const (
+	jobPending           = 3
+	jobHeld              = 4
+	jobProcessing        = 5
+	jobProcessingStopped = 6
+)
+
+var printerAttrs = []string{
+	"printer-name",
+	"printer-state",
+	"printer-state-reasons",
+	"printer-state-change-time",
+	"printer-is-accepting-jobs",
+	"queued-job-count",
+}
+
+var jobAttrs = []string{
+	"job-id",
+	"job-printer-uri",
+	"job-state",
+	"time-at-creation",
+}
+
+// Cups reads the state and queues of the printers of one or more CUPS
+// servers
+type Cups struct {
+	Servers  []string          `toml:"servers"`
+	Printers []string          `toml:"printers"`
+	Username string            `toml:"username"`
+	Password string            `toml:"password"`
+	Timeout  internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client    *http.Client
+	requestID uint32
+}
+
+func (*Cups) Description() string {
+	return "Read printer state and print queue status from CUPS"
+}
+
+func (*Cups) SampleConfig() string {
+	return `
+  ## CUPS servers to query over IPP, ipp:// and ipps:// URLs use port 631
+  # servers = ["http://localhost:631"]
+
+  ## Printers and classes to report, all of them when empty
+  # printers = []
+
+  ## Credentials for basic HTTP authentication, CUPS lets anyone read the
+  ## printers and the jobs by default
+  # username = ""
+  # password = ""
+
+  ## Timeout for each IPP request
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+}
+
+func (c *Cups) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if c.client == nil {
+		tlsCfg, err := c.ClientConfig.TLSConfig()
+		if err != nil {
+			return fmt.Errorf("TLSConfig: %w", err)
+		}
+		c.client = &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsCfg},
+			Timeout:   c.Timeout.Duration,
+		}
+	}
+
+	for _, server := range c.Servers {
+		u, err := serverURL(server)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		if err := c.gatherServer(ctx, u, acc); err != nil {
+			acc.AddError(fmt.Errorf("%s: %w", u.Host, err))
+		}
+	}
+	return nil
+}
+
+// serverURL returns the http URL IPP requests are posted to
+func serverURL(server string) (*url.URL, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("url parse (%s): %w", server, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "ipp":
+		u.Scheme = "http"
+	case "ipps":
+		u.Scheme = "https"
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q of %s", u.Scheme, server)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("no host in %s", server)
+	}
+	if u.Port() == "" {
+		u.Host += ":631"
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u, nil
+}
+
+func (c *Cups) gatherServer(ctx context.Context, u *url.URL, acc cua.Accumulator) error {
+	req := c.newRequest(opCupsGetPrinters)
+	req.add(tagKeyword, "requested-attributes", printerAttrs...)
+	printers, err := c.do(ctx, u, req, tagPrinter)
+	if err != nil {
+		return fmt.Errorf("get printers: %w", err)
+	}
+
+	// the jobs of all the printers, lpstat -o
+	req = c.newRequest(opGetJobs)
+	req.add(tagURI, "printer-uri", "ipp://localhost/")
+	req.add(tagKeyword, "which-jobs", "not-completed")
+	req.add(tagKeyword, "requested-attributes", jobAttrs...)
+	jobs, err := c.do(ctx, u, req, tagJob)
+	if err != nil {
+		return fmt.Errorf("get jobs: %w", err)
+	}
+
+	c.gatherPrinters(u.Host, printers, jobs, time.Now(), acc)
+	return nil
+}
+
+func (c *Cups) newRequest(operation uint16) *ippRequest {
+	req := &ippRequest{
+		operation: operation,
+		requestID: atomic.AddUint32(&c.requestID, 1),
+	}
+	req.add(tagCharset, "attributes-charset", "utf-8")
+	req.add(tagLanguage, "attributes-natural-language", "en")
+	if c.Username != "" {
+		req.add(tagName, "requesting-user-name", c.Username)
+	}
+	return req
+}
+
+// do posts the request and returns the groups of the response with the
+// given delimiter tag
+func (c *Cups) do(ctx context.Context, u *url.URL, ipp *ippRequest, group byte) ([]ippObject, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(ipp.encode()))
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/ipp")
+	if c.Username != "" && c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP status %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	ippResp, err := decodeResponse(body)
+	if err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	// successful-ok-ignored-or-substituted-attributes and the like are
+	// successful as well, client errors start at 0x0400
+	switch {
+	case ippResp.status == 0x0406:
+		// client-error-not-found, CUPS has no printers
+		return nil, nil
+	case ippResp.status >= 0x0100:
+		return nil, fmt.Errorf("IPP status 0x%04x", ippResp.status)
+	}
+	return ippResp.groups[group], nil
+}
+
+// queue is the jobs of one printer which are not completed
+type queue struct {
+	pending    int
+	held       int
+	processing int
+	stopped    int
+	oldest     time.Time
+}
+
+func (c *Cups) gatherPrinters(server string, printers, jobs []ippObject, now time.Time, acc cua.Accumulator) {
+	queues := make(map[string]*queue)
+	for _, job := range jobs {
+		// ipp://host/printers/<name> or ipp://host/classes/<name>
+		name := path.Base(job.str("job-printer-uri"))
+		q, ok := queues[name]
+		if !ok {
+			q = &queue{}
+			queues[name] = q
+		}
+		state, _ := job.int("job-state")
+		switch state {
+		case jobPending:
+			q.pending++
+		case jobHeld:
+			q.held++
+		case jobProcessing:
+			q.processing++
+		case jobProcessingStopped:
+			q.stopped++
+		}
+		if created, ok := job.int("time-at-creation"); ok && created > 0 {
+			t := time.Unix(created, 0)
+			if q.oldest.IsZero() || t.Before(q.oldest) {
+				q.oldest = t
+			}
+		}
+	}
+
+	for _, printer := range printers {
+		name := printer.str("printer-name")
+		if name == "" || !c.reportPrinter(name) {
+			continue
+		}
+
+		fields := map[string]interface{}{}
+		if state, ok := printer.int("printer-state"); ok {
+			fields["state"] = state
+			fields["stopped"] = 0
+			if state == printerStopped {
+				fields["stopped"] = 1
+			}
+		}
+		if accepting, ok := printer.bool("printer-is-accepting-jobs"); ok {
+			fields["accepting_jobs"] = 0
+			if accepting {
+				fields["accepting_jobs"] = 1
+			}
+		}
+		if changed, ok := printer.int("printer-state-change-time"); ok && changed > 0 {
+			fields["state_age"] = age(now, time.Unix(changed, 0))
+		}
+
+		reasons := make([]string, 0)
+		stateErrors := 0
+		for _, reason := range printer.strs("printer-state-reasons") {
+			if reason == "none" {
+				continue
+			}
+			reasons = append(reasons, reason)
+			if strings.HasSuffix(reason, "-error") {
+				stateErrors++
+			}
+		}
+		fields["state_reasons"] = strings.Join(reasons, ",")
+		fields["state_errors"] = stateErrors
+
+		q := queues[name]
+		if q == nil {
+			q = &queue{}
+		}
+		if queued, ok := printer.int("queued-job-count"); ok {
+			fields["queued_jobs"] = queued
+		} else {
+			fields["queued_jobs"] = int64(q.pending + q.held + q.processing + q.stopped)
+		}
+		fields["jobs_pending"] = q.pending
+		fields["jobs_held"] = q.held
+		fields["jobs_processing"] = q.processing
+		fields["jobs_stopped"] = q.stopped
+		// age of the oldest job, 0 with an empty queue
+		fields["oldest_job_age"] = float64(0)
+		if !q.oldest.IsZero() {
+			fields["oldest_job_age"] = age(now, q.oldest)
+		}
+
+		acc.AddFields("cups_printer", fields, map[string]string{
+			"server":  server,
+			"printer": name,
+		})
+	}
+}
+
+func (c *Cups) reportPrinter(name string) bool {
+	if len(c.Printers) == 0 {
+		return true
+	}
+	for _, p := range c.Printers {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// age returns the seconds since t, clocks of the server and the agent may
+// differ slightly
+func age(now, t time.Time) float64 {
+	d := now.Sub(t)
+	if d < 0 {
+		d = 0
+	}
+	return d.Seconds()
+}
+
+func init() {
+	inputs.Add("cups", func() cua.Input {
+		return &Cups{
+			Servers: []string{defaultServer},
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
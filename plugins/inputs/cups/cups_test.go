@@ -0,0 +1,200 @@
+package cups
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// group is one attribute group of a test response
+type group struct {
+	tag   byte
+	attrs []attribute
+}
+
+func str(tag byte, name, value string) attribute {
+	return attribute{tag: tag, name: name, value: []byte(value)}
+}
+
+func integer(tag byte, name string, value int32) attribute {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(value))
+	return attribute{tag: tag, name: name, value: b}
+}
+
+func boolean(name string, value bool) attribute {
+	a := attribute{tag: tagBoolean, name: name, value: []byte{0}}
+	if value {
+		a.value[0] = 1
+	}
+	return a
+}
+
+func encodeResponse(status uint16, requestID uint32, groups ...group) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{1, 1})
+	_ = binary.Write(&buf, binary.BigEndian, status)
+	_ = binary.Write(&buf, binary.BigEndian, requestID)
+	groups = append([]group{{tag: tagOperation, attrs: []attribute{
+		str(tagCharset, "attributes-charset", "utf-8"),
+		str(tagLanguage, "attributes-natural-language", "en"),
+	}}}, groups...)
+	for _, g := range groups {
+		buf.WriteByte(g.tag)
+		for _, a := range g.attrs {
+			buf.WriteByte(a.tag)
+			_ = binary.Write(&buf, binary.BigEndian, uint16(len(a.name)))
+			buf.WriteString(a.name)
+			_ = binary.Write(&buf, binary.BigEndian, uint16(len(a.value)))
+			buf.Write(a.value)
+		}
+	}
+	buf.WriteByte(tagEnd)
+	return buf.Bytes()
+}
+
+func newServer(t *testing.T, now time.Time) *httptest.Server {
+	printers := []group{
+		{tag: tagPrinter, attrs: []attribute{
+			str(tagName, "printer-name", "front-desk"),
+			integer(tagEnum, "printer-state", 5),
+			str(tagKeyword, "printer-state-reasons", "paused"),
+			str(tagKeyword, "", "media-jam-error"),
+			integer(tagInteger, "printer-state-change-time", int32(now.Add(-time.Hour).Unix())),
+			boolean("printer-is-accepting-jobs", true),
+			integer(tagInteger, "queued-job-count", 3),
+		}},
+		{tag: tagPrinter, attrs: []attribute{
+			str(tagName, "printer-name", "back-office"),
+			integer(tagEnum, "printer-state", 3),
+			str(tagKeyword, "printer-state-reasons", "none"),
+			integer(tagInteger, "printer-state-change-time", int32(now.Add(-time.Minute).Unix())),
+			boolean("printer-is-accepting-jobs", false),
+			integer(tagInteger, "queued-job-count", 0),
+		}},
+	}
+	job := func(id int32, printer string, state int32, age time.Duration) group {
+		return group{tag: tagJob, attrs: []attribute{
+			integer(tagInteger, "job-id", id),
+			str(tagURI, "job-printer-uri", "ipp://localhost/printers/"+printer),
+			integer(tagEnum, "job-state", state),
+			integer(tagInteger, "time-at-creation", int32(now.Add(-age).Unix())),
+		}}
+	}
+	jobs := []group{
+		job(10, "front-desk", jobProcessing, 30*time.Minute),
+		job(11, "front-desk", jobPending, 20*time.Minute),
+		job(12, "front-desk", jobHeld, 10*time.Minute),
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/ipp", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.True(t, len(body) > 8)
+
+		requestID := binary.BigEndian.Uint32(body[4:8])
+		w.Header().Set("Content-Type", "application/ipp")
+		switch binary.BigEndian.Uint16(body[2:4]) {
+		case opCupsGetPrinters:
+			_, _ = w.Write(encodeResponse(0, requestID, printers...))
+		case opGetJobs:
+			_, _ = w.Write(encodeResponse(0, requestID, jobs...))
+		default:
+			_, _ = w.Write(encodeResponse(0x0501, requestID))
+		}
+	}))
+}
+
+func TestGather(t *testing.T) {
+	now := time.Now()
+	ts := newServer(t, now)
+	defer ts.Close()
+
+	c := &Cups{
+		Servers: []string{ts.URL},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 2)
+
+	for _, m := range acc.Metrics {
+		switch m.Tags["printer"] {
+		case "front-desk":
+			require.Equal(t, int64(5), m.Fields["state"])
+			require.Equal(t, 1, m.Fields["stopped"])
+			require.Equal(t, 1, m.Fields["accepting_jobs"])
+			require.Equal(t, "paused,media-jam-error", m.Fields["state_reasons"])
+			require.Equal(t, 1, m.Fields["state_errors"])
+			require.Equal(t, int64(3), m.Fields["queued_jobs"])
+			require.Equal(t, 1, m.Fields["jobs_pending"])
+			require.Equal(t, 1, m.Fields["jobs_held"])
+			require.Equal(t, 1, m.Fields["jobs_processing"])
+			require.Equal(t, 0, m.Fields["jobs_stopped"])
+			require.InDelta(t, 1800, m.Fields["oldest_job_age"], 5)
+			require.InDelta(t, 3600, m.Fields["state_age"], 5)
+		case "back-office":
+			require.Equal(t, int64(3), m.Fields["state"])
+			require.Equal(t, 0, m.Fields["stopped"])
+			require.Equal(t, 0, m.Fields["accepting_jobs"])
+			require.Equal(t, "", m.Fields["state_reasons"])
+			require.Equal(t, int64(0), m.Fields["queued_jobs"])
+			require.Equal(t, float64(0), m.Fields["oldest_job_age"])
+		default:
+			t.Fatalf("unexpected printer %q", m.Tags["printer"])
+		}
+	}
+}
+
+func TestGatherPrinterFilter(t *testing.T) {
+	ts := newServer(t, time.Now())
+	defer ts.Close()
+
+	c := &Cups{
+		Servers:  []string{ts.URL},
+		Printers: []string{"back-office"},
+		Timeout:  internal.Duration{Duration: 5 * time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "back-office", acc.Metrics[0].Tags["printer"])
+}
+
+func TestServerURL(t *testing.T) {
+	u, err := serverURL("ipp://cups.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "http://cups.example.com:631/", u.String())
+
+	u, err = serverURL("ipps://cups.example.com:8631")
+	require.NoError(t, err)
+	require.Equal(t, "https://cups.example.com:8631/", u.String())
+
+	_, err = serverURL("lpd://cups.example.com")
+	require.Error(t, err)
+}
+
+func TestEncodeRequest(t *testing.T) {
+	req := &ippRequest{operation: opGetJobs, requestID: 7}
+	req.add(tagKeyword, "requested-attributes", "job-id", "job-state")
+
+	resp, err := decodeResponse(req.encode())
+	require.NoError(t, err)
+	require.Equal(t, uint16(opGetJobs), resp.status)
+	require.Len(t, resp.groups[tagOperation], 1)
+	require.Equal(t, []string{"job-id", "job-state"}, resp.groups[tagOperation][0].strs("requested-attributes"))
+}
@@ -0,0 +1,185 @@
+package cups
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// operations, RFC 8011 and the CUPS extensions
+const (
+	opGetJobs         = 0x000a
+	opCupsGetPrinters = 0x4002
+)
+
+// delimiter tags of the attribute groups
+const (
+	tagOperation = 0x01
+	tagJob       = 0x02
+	tagEnd       = 0x03
+	tagPrinter   = 0x04
+)
+
+// value tags, RFC 8010 section 3.5.2
+const (
+	tagInteger  = 0x21
+	tagBoolean  = 0x22
+	tagEnum     = 0x23
+	tagText     = 0x41
+	tagName     = 0x42
+	tagKeyword  = 0x44
+	tagURI      = 0x45
+	tagCharset  = 0x47
+	tagLanguage = 0x48
+)
+
+// attribute is one value of an IPP attribute, additional values of a
+// multi-valued attribute have an empty name
+type attribute struct {
+	tag   byte
+	name  string
+	value []byte
+}
+
+type ippRequest struct {
+	operation uint16
+	requestID uint32
+	attrs     []attribute
+}
+
+func (r *ippRequest) add(tag byte, name string, values ...string) {
+	for i, v := range values {
+		a := attribute{tag: tag, value: []byte(v)}
+		if i == 0 {
+			a.name = name
+		}
+		r.attrs = append(r.attrs, a)
+	}
+}
+
+// encode returns the IPP/1.1 request with all the attributes in the
+// operation group
+func (r *ippRequest) encode() []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{1, 1})
+	_ = binary.Write(&buf, binary.BigEndian, r.operation)
+	_ = binary.Write(&buf, binary.BigEndian, r.requestID)
+	buf.WriteByte(tagOperation)
+	for _, a := range r.attrs {
+		buf.WriteByte(a.tag)
+		_ = binary.Write(&buf, binary.BigEndian, uint16(len(a.name)))
+		buf.WriteString(a.name)
+		_ = binary.Write(&buf, binary.BigEndian, uint16(len(a.value)))
+		buf.Write(a.value)
+	}
+	buf.WriteByte(tagEnd)
+	return buf.Bytes()
+}
+
+// ippObject is the attributes of one printer or job, by name
+type ippObject map[string][]attribute
+
+func (o ippObject) str(name string) string {
+	if v := o[name]; len(v) > 0 {
+		return string(v[0].value)
+	}
+	return ""
+}
+
+func (o ippObject) strs(name string) []string {
+	values := make([]string, 0, len(o[name]))
+	for _, a := range o[name] {
+		values = append(values, string(a.value))
+	}
+	return values
+}
+
+// int returns the value of an integer or enum attribute
+func (o ippObject) int(name string) (int64, bool) {
+	v := o[name]
+	if len(v) == 0 || (v[0].tag != tagInteger && v[0].tag != tagEnum) || len(v[0].value) != 4 {
+		return 0, false
+	}
+	return int64(int32(binary.BigEndian.Uint32(v[0].value))), true
+}
+
+func (o ippObject) bool(name string) (bool, bool) {
+	v := o[name]
+	if len(v) == 0 || v[0].tag != tagBoolean || len(v[0].value) != 1 {
+		return false, false
+	}
+	return v[0].value[0] != 0, true
+}
+
+type ippResponse struct {
+	status uint16
+	// groups of the response with the given delimiter tag, e.g. one
+	// printer group per printer
+	groups map[byte][]ippObject
+}
+
+// decodeResponse parses an IPP response, the values are kept as they are
+// encoded
+func decodeResponse(data []byte) (*ippResponse, error) {
+	if len(data) < 9 {
+		return nil, errors.New("short response")
+	}
+	resp := &ippResponse{
+		status: binary.BigEndian.Uint16(data[2:4]),
+		groups: make(map[byte][]ippObject),
+	}
+
+	r := bytes.NewReader(data[8:])
+	var (
+		group   ippObject
+		last    string
+		current byte
+	)
+	for {
+		tag, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("read tag: %w", err)
+		}
+		if tag == tagEnd {
+			return resp, nil
+		}
+		if tag < 0x10 {
+			// start of a new group
+			current = tag
+			group = make(ippObject)
+			resp.groups[current] = append(resp.groups[current], group)
+			last = ""
+			continue
+		}
+		if group == nil {
+			return nil, errors.New("attribute outside of a group")
+		}
+
+		name, err := readField(r)
+		if err != nil {
+			return nil, fmt.Errorf("read attribute name: %w", err)
+		}
+		value, err := readField(r)
+		if err != nil {
+			return nil, fmt.Errorf("read value of %s: %w", name, err)
+		}
+		if len(name) > 0 {
+			last = string(name)
+		}
+		group[last] = append(group[last], attribute{tag: tag, name: last, value: value})
+	}
+}
+
+func readField(r *bytes.Reader) ([]byte, error) {
+	var n uint16
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, fmt.Errorf("read length: %w", err)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("read %d bytes: %w", n, err)
+	}
+	return b, nil
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/targets"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/miekg/dns"
 )
@@ -40,6 +41,9 @@ type DNSQuery struct {
 
 	// Dns query timeout in seconds. 0 means no timeout
 	Timeout int
+
+	// Source of additional domains
+	targets.Source
 }
 
 var sampleConfig = `
@@ -52,6 +56,15 @@ var sampleConfig = `
   ## Domains or subdomains to query.
   # domains = ["."]
 
+  ## Optional source of additional domains, refreshed periodically, as a JSON
+  ## list of strings read from a file:// path, an http(s):// URL or the raw
+  ## value of a Consul KV key with consul://host:8500/path/of/key.
+  # targets_source = "consul://127.0.0.1:8500/probes/dns_query"
+  ## How often the domains are read again from the source.
+  # targets_refresh_interval = "5m"
+  ## Bearer token of http(s) sources, ACL token of Consul sources.
+  # targets_token = ""
+
   ## Query record type.
   ## Possible values: A, AAAA, CNAME, MX, NS, PTR, TXT, SOA, SPF, SRV.
   # record_type = "A"
@@ -74,7 +87,12 @@ func (d *DNSQuery) Gather(ctx context.Context, acc cua.Accumulator) error {
 	var wg sync.WaitGroup
 	d.setDefaultValues()
 
-	for _, domain := range d.Domains {
+	domains, err := d.Source.Targets(ctx, d.Domains)
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	for _, domain := range domains {
 		for _, server := range d.Servers {
 			wg.Add(1)
 			go func(domain, server string) {
@@ -123,7 +141,7 @@ func (d *DNSQuery) setDefaultValues() {
 		d.RecordType = "NS"
 	}
 
-	if len(d.Domains) == 0 {
+	if len(d.Domains) == 0 && !d.Source.Enabled() {
 		d.Domains = []string{"."}
 		d.RecordType = "NS"
 	}
@@ -0,0 +1,217 @@
+// Package domainexpiry reports domain registration expiry using RDAP or whois.
+package domainexpiry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## Domains to check the registration expiry of
+  domains = ["example.com"]
+
+  ## Lookup protocol, one of "rdap", "whois" or "auto".  When set to "auto"
+  ## rdap is tried first, falling back to whois if rdap fails.
+  # protocol = "auto"
+
+  ## RDAP domain lookup url, the domain name is appended.  The default
+  ## redirects to the authoritative RDAP server for the domain's TLD.
+  # rdap_url = "https://rdap.org/domain/"
+
+  ## Whois server used to find the authoritative whois server for a TLD
+  # whois_server = "whois.iana.org:43"
+
+  ## Registration data changes rarely and registries rate limit lookups,
+  ## lookups are cached for this long. Metrics are still reported every
+  ## interval with days_until_expiry computed from the cached expiry date.
+  # cache_ttl = "12h"
+
+  ## Timeout for each lookup
+  # timeout = "10s"
+`
+
+const description = "Reports domain registration expiry using RDAP or whois"
+
+const (
+	protocolAuto  = "auto"
+	protocolRDAP  = "rdap"
+	protocolWhois = "whois"
+
+	defaultRDAPURL     = "https://rdap.org/domain/"
+	defaultWhoisServer = "whois.iana.org:43"
+	defaultCacheTTL    = 12 * time.Hour
+	defaultTimeout     = 10 * time.Second
+)
+
+// registration is the result of a lookup
+type registration struct {
+	expires   time.Time
+	fetched   time.Time
+	registrar string
+	source    string
+}
+
+// DomainExpiry holds the configuration of the plugin.
+type DomainExpiry struct {
+	Log         cua.Logger `toml:"-"`
+	cache       map[string]*registration
+	lookups     map[string]lookupFunc
+	Protocol    string            `toml:"protocol"`
+	RDAPURL     string            `toml:"rdap_url"`
+	WhoisServer string            `toml:"whois_server"`
+	Domains     []string          `toml:"domains"`
+	CacheTTL    internal.Duration `toml:"cache_ttl"`
+	Timeout     internal.Duration `toml:"timeout"`
+	sync.Mutex
+}
+
+type lookupFunc func(ctx context.Context, domain string) (*registration, error)
+
+// Description returns description of the plugin.
+func (d *DomainExpiry) Description() string {
+	return description
+}
+
+// SampleConfig returns configuration sample for the plugin.
+func (d *DomainExpiry) SampleConfig() string {
+	return sampleConfig
+}
+
+// Init validates the configuration and applies defaults.
+func (d *DomainExpiry) Init() error {
+	if len(d.Domains) == 0 {
+		return errors.New("no domains configured")
+	}
+
+	if d.Protocol == "" {
+		d.Protocol = protocolAuto
+	}
+	if d.RDAPURL == "" {
+		d.RDAPURL = defaultRDAPURL
+	}
+	if d.WhoisServer == "" {
+		d.WhoisServer = defaultWhoisServer
+	}
+	if d.CacheTTL.Duration == 0 {
+		d.CacheTTL.Duration = defaultCacheTTL
+	}
+	if d.Timeout.Duration == 0 {
+		d.Timeout.Duration = defaultTimeout
+	}
+
+	d.lookups = make(map[string]lookupFunc)
+	switch d.Protocol {
+	case protocolAuto:
+		d.lookups[protocolRDAP] = d.lookupRDAP
+		d.lookups[protocolWhois] = d.lookupWhois
+	case protocolRDAP:
+		d.lookups[protocolRDAP] = d.lookupRDAP
+	case protocolWhois:
+		d.lookups[protocolWhois] = d.lookupWhois
+	default:
+		return fmt.Errorf("unknown protocol %q", d.Protocol)
+	}
+
+	d.cache = make(map[string]*registration)
+
+	return nil
+}
+
+// Gather reports the expiry of each domain, looking up any domains which are
+// not cached or whose cache entry has expired.
+func (d *DomainExpiry) Gather(ctx context.Context, acc cua.Accumulator) error {
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for _, domain := range d.Domains {
+		domain := strings.ToLower(strings.TrimSuffix(domain, "."))
+
+		d.Lock()
+		reg, ok := d.cache[domain]
+		d.Unlock()
+
+		if ok && now.Sub(reg.fetched) < d.CacheTTL.Duration {
+			acc.AddFields("domain_expiry", getFields(reg, now), getTags(reg, domain))
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			reg, err := d.lookup(ctx, domain)
+			if err != nil {
+				acc.AddError(fmt.Errorf("domain %s: %w", domain, err))
+				return
+			}
+			reg.fetched = now
+
+			d.Lock()
+			d.cache[domain] = reg
+			d.Unlock()
+
+			acc.AddFields("domain_expiry", getFields(reg, now), getTags(reg, domain))
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// lookup tries rdap and then whois, as enabled by the protocol setting
+func (d *DomainExpiry) lookup(ctx context.Context, domain string) (*registration, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout.Duration)
+	defer cancel()
+
+	var errs []string
+	for _, proto := range []string{protocolRDAP, protocolWhois} {
+		lookup, ok := d.lookups[proto]
+		if !ok {
+			continue
+		}
+		reg, err := lookup(ctx, domain)
+		if err == nil {
+			reg.source = proto
+			return reg, nil
+		}
+		d.Log.Debugf("%s lookup of %s failed: %s", proto, domain, err)
+		errs = append(errs, fmt.Sprintf("%s: %s", proto, err))
+	}
+
+	return nil, fmt.Errorf("lookup failed (%s)", strings.Join(errs, ", "))
+}
+
+func getFields(reg *registration, now time.Time) map[string]interface{} {
+	expiry := reg.expires.Sub(now)
+	return map[string]interface{}{
+		"expiry":            int64(expiry.Seconds()),
+		"days_until_expiry": int64(math.Floor(expiry.Hours() / 24)),
+		"expiration_date":   reg.expires.Unix(),
+	}
+}
+
+func getTags(reg *registration, domain string) map[string]string {
+	tags := map[string]string{
+		"domain": domain,
+		"source": reg.source,
+	}
+	if reg.registrar != "" {
+		tags["registrar"] = reg.registrar
+	}
+	return tags
+}
+
+func init() {
+	inputs.Add("domain_expiry", func() cua.Input {
+		return &DomainExpiry{}
+	})
+}
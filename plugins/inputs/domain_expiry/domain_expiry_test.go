@@ -0,0 +1,193 @@
+package domainexpiry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const rdapResponse = `{
+  "objectClassName": "domain",
+  "ldhName": "EXAMPLE.COM",
+  "events": [
+    {"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+    {"eventAction": "expiration", "eventDate": "%s"}
+  ],
+  "entities": [
+    {
+      "objectClassName": "entity",
+      "roles": ["registrar"],
+      "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Registrar, Inc."]]]
+    }
+  ]
+}`
+
+func TestRDAP(t *testing.T) {
+	expires := time.Now().Add(30*24*time.Hour + time.Hour).UTC().Truncate(time.Second)
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "/domain/example.com", r.URL.Path)
+		fmt.Fprintf(w, rdapResponse, expires.Format(time.RFC3339))
+	}))
+	defer ts.Close()
+
+	d := &DomainExpiry{
+		Log:      testutil.Logger{},
+		Domains:  []string{"Example.com."},
+		Protocol: "rdap",
+		RDAPURL:  ts.URL + "/domain/",
+	}
+	require.NoError(t, d.Init())
+
+	for i := 0; i < 2; i++ {
+		var acc testutil.Accumulator
+		require.NoError(t, d.Gather(context.Background(), &acc))
+		require.Empty(t, acc.Errors)
+
+		acc.AssertContainsTaggedFields(t, "domain_expiry",
+			map[string]interface{}{
+				"days_until_expiry": int64(30),
+				"expiry":            acc.Metrics[0].Fields["expiry"],
+				"expiration_date":   expires.Unix(),
+			},
+			map[string]string{
+				"domain":    "example.com",
+				"registrar": "Example Registrar, Inc.",
+				"source":    "rdap",
+			})
+	}
+
+	// second gather is served from the cache
+	require.Equal(t, 1, requests)
+}
+
+func TestRDAPNoExpiration(t *testing.T) {
+	_, err := parseRDAP(&rdapDomain{})
+	require.Error(t, err)
+}
+
+// whoisServer answers whois queries from a map of query to response
+func whoisServer(t *testing.T, responses map[string]string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 256)
+			n, _ := conn.Read(buf)
+			query := strings.TrimSpace(string(buf[:n]))
+			fmt.Fprint(conn, responses[query])
+			conn.Close()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestParseWhois(t *testing.T) {
+	values := parseWhois([]byte("% comment\n" +
+		"   Domain Name: EXAMPLE.COM\n" +
+		"   Registrar: Example Registrar, Inc.\n" +
+		"   Registry Expiry Date: 2024-08-13T04:00:00Z\n" +
+		"   Registry Expiry Date: 2025-08-13T04:00:00Z\n"))
+	require.Equal(t, "EXAMPLE.COM", values["domain name"])
+
+	reg, err := whoisRegistration(values)
+	require.NoError(t, err)
+	require.Equal(t, "Example Registrar, Inc.", reg.registrar)
+	require.Equal(t, time.Date(2024, 8, 13, 4, 0, 0, 0, time.UTC), reg.expires)
+}
+
+func TestWhoisReferral(t *testing.T) {
+	registry := whoisServer(t, map[string]string{
+		"example.com": "Domain Name: EXAMPLE.COM\n" +
+			"Registrar: Example Registrar, Inc.\n" +
+			"Registry Expiry Date: 2024-08-13T04:00:00Z\n",
+	})
+	iana := whoisServer(t, map[string]string{
+		"com": "% IANA WHOIS server\n\ndomain:       COM\n\nrefer:        " + registry + "\n",
+	})
+
+	d := &DomainExpiry{
+		Log:         testutil.Logger{},
+		Domains:     []string{"example.com"},
+		Protocol:    "whois",
+		WhoisServer: iana,
+	}
+	require.NoError(t, d.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Equal(t, "whois", acc.TagValue("domain_expiry", "source"))
+	require.Equal(t, "Example Registrar, Inc.", acc.TagValue("domain_expiry", "registrar"))
+	date, ok := acc.Int64Field("domain_expiry", "expiration_date")
+	require.True(t, ok)
+	require.Equal(t, int64(1723521600), date)
+}
+
+func TestWhoisDates(t *testing.T) {
+	tests := []struct {
+		values   map[string]string
+		expected time.Time
+	}{
+		{map[string]string{"expiry date": "2024-08-13"}, time.Date(2024, 8, 13, 0, 0, 0, 0, time.UTC)},
+		{map[string]string{"paid-till": "2024-08-13T04:00:00Z"}, time.Date(2024, 8, 13, 4, 0, 0, 0, time.UTC)},
+		{map[string]string{"expires": "13-Aug-2024"}, time.Date(2024, 8, 13, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		reg, err := whoisRegistration(tt.values)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, reg.expires)
+	}
+
+	_, err := whoisRegistration(map[string]string{"expires": "soon"})
+	require.Error(t, err)
+	_, err = whoisRegistration(map[string]string{})
+	require.Error(t, err)
+}
+
+func TestAutoFallback(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	registry := whoisServer(t, map[string]string{
+		"example.com": "Registry Expiry Date: 2024-08-13T04:00:00Z\n",
+	})
+	iana := whoisServer(t, map[string]string{
+		"com": "refer: " + registry + "\n",
+	})
+
+	d := &DomainExpiry{
+		Log:         testutil.Logger{},
+		Domains:     []string{"example.com"},
+		RDAPURL:     ts.URL + "/domain/",
+		WhoisServer: iana,
+	}
+	require.NoError(t, d.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Equal(t, "whois", acc.TagValue("domain_expiry", "source"))
+	require.False(t, acc.HasTag("domain_expiry", "registrar"))
+}
+
+func TestInit(t *testing.T) {
+	require.Error(t, (&DomainExpiry{}).Init())
+	require.Error(t, (&DomainExpiry{Domains: []string{"example.com"}, Protocol: "dns"}).Init())
+}
@@ -0,0 +1,101 @@
+package domainexpiry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// rdapDomain is the subset of an RDAP domain object (RFC 9083) used
+type rdapDomain struct {
+	Events []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles      []string      `json:"roles"`
+		VCardArray []interface{} `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+func (d *DomainExpiry) lookupRDAP(ctx context.Context, domain string) (*registration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.RDAPURL+domain, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP status %s", d.RDAPURL+domain, resp.Status)
+	}
+
+	var obj rdapDomain
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return parseRDAP(&obj)
+}
+
+func parseRDAP(obj *rdapDomain) (*registration, error) {
+	reg := &registration{}
+
+	for _, event := range obj.Events {
+		if event.Action != "expiration" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, event.Date)
+		if err != nil {
+			return nil, fmt.Errorf("parsing expiration date %q: %w", event.Date, err)
+		}
+		reg.expires = t
+	}
+	if reg.expires.IsZero() {
+		return nil, fmt.Errorf("no expiration event found")
+	}
+
+	for _, entity := range obj.Entities {
+		for _, role := range entity.Roles {
+			if role == "registrar" {
+				reg.registrar = vcardName(entity.VCardArray)
+			}
+		}
+	}
+
+	return reg, nil
+}
+
+// vcardName returns the formatted name (fn) property of a jCard (RFC 7095),
+// which has the form ["vcard", [["fn", {}, "text", "Name"], ...]]
+func vcardName(vcard []interface{}) string {
+	if len(vcard) != 2 {
+		return ""
+	}
+	props, ok := vcard[1].([]interface{})
+	if !ok {
+		return ""
+	}
+	for _, p := range props {
+		prop, ok := p.([]interface{})
+		if !ok || len(prop) < 4 {
+			continue
+		}
+		if name, ok := prop[0].(string); !ok || name != "fn" {
+			continue
+		}
+		if value, ok := prop[3].(string); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package domainexpiry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// whoisExpiryKeys are the keys used by registries and registrars for the
+// expiration date, in order of preference
+var whoisExpiryKeys = []string{
+	"registry expiry date",
+	"registrar registration expiration date",
+	"expiration date",
+	"expiry date",
+	"expiration time",
+	"expires on",
+	"expires",
+	"paid-till",
+}
+
+// whoisDateLayouts are the date formats seen in whois responses
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02",
+	"2006.01.02",
+	"2006/01/02",
+	"02-Jan-2006",
+	"02.01.2006",
+}
+
+func (d *DomainExpiry) lookupWhois(ctx context.Context, domain string) (*registration, error) {
+	tld := domain[strings.LastIndex(domain, ".")+1:]
+
+	resp, err := whoisQuery(ctx, d.WhoisServer, tld)
+	if err != nil {
+		return nil, err
+	}
+
+	values := parseWhois(resp)
+	server := values["refer"]
+	if server == "" {
+		server = values["whois"]
+	}
+	if server == "" {
+		return nil, fmt.Errorf("no whois server found for .%s", tld)
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "43")
+	}
+
+	resp, err = whoisQuery(ctx, server, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return whoisRegistration(parseWhois(resp))
+}
+
+// whoisQuery sends a query to a whois server (RFC 3912) and returns the response
+func whoisQuery(ctx context.Context, server, query string) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", server, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte(query + "\r\n")); err != nil {
+		return nil, fmt.Errorf("write %s: %w", server, err)
+	}
+
+	resp, err := io.ReadAll(io.LimitReader(conn, 1024*1024))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", server, err)
+	}
+
+	return resp, nil
+}
+
+// parseWhois returns the first value of each "key: value" line in a whois
+// response, keys are lower cased
+func parseWhois(resp []byte) map[string]string {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(resp))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 1 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+		if _, ok := values[key]; !ok && value != "" {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+func whoisRegistration(values map[string]string) (*registration, error) {
+	reg := &registration{registrar: values["registrar"]}
+
+	for _, key := range whoisExpiryKeys {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		t, err := parseWhoisDate(value)
+		if err != nil {
+			return nil, err
+		}
+		reg.expires = t
+		return reg, nil
+	}
+
+	return nil, fmt.Errorf("no expiration date found")
+}
+
+func parseWhoisDate(value string) (time.Time, error) {
+	for _, layout := range whoisDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized expiration date %q", value)
+}
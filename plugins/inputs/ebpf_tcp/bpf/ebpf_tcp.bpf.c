@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: GPL-2.0
+//
+// CO-RE programs of the ebpf_tcp input, built for the packages by
+// builds/ebpf/build.sh or with:
+//
+//	bpftool btf dump file /sys/kernel/btf/vmlinux format c > vmlinux.h
+//	clang -O2 -g -target bpf -D__TARGET_ARCH_x86 -c ebpf_tcp.bpf.c -o ebpf_tcp.bpf.o
+//
+// the maps are read by the agent, see the layout of their keys in maps.go
+
+#include "vmlinux.h"
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_tracing.h>
+
+#define MAX_ENTRIES 10240
+
+// latency_key is the bucket of a connect latency, mantissa * 10^exponent
+// microseconds, per destination port
+struct latency_key {
+	__u16 port;
+	__u8 mantissa;
+	__u8 exponent;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u64);
+	__type(value, __u64);
+} connect_start SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct latency_key);
+	__type(value, __u64);
+} connect_latency SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u16);
+	__type(value, __u64);
+} retransmits SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u16);
+	__type(value, __u64);
+} accept_drops SEC(".maps");
+
+static __always_inline void increment(void *map, const void *key)
+{
+	__u64 one = 1;
+	__u64 *count;
+
+	count = bpf_map_lookup_elem(map, key);
+	if (count) {
+		__sync_fetch_and_add(count, 1);
+		return;
+	}
+	if (bpf_map_update_elem(map, key, &one, BPF_NOEXIST) == 0)
+		return;
+	// created by another CPU in the meantime
+	count = bpf_map_lookup_elem(map, key);
+	if (count)
+		__sync_fetch_and_add(count, 1);
+}
+
+// bucket keeps the two most significant digits of a value, the log-linear
+// bins of the Circonus histograms
+static __always_inline void bucket(__u64 value, struct latency_key *key)
+{
+	__u8 exponent = 0;
+
+	for (int i = 0; i < 20 && value >= 100; i++) {
+		value /= 10;
+		exponent++;
+	}
+	key->mantissa = value;
+	key->exponent = exponent;
+}
+
+SEC("tracepoint/sock/inet_sock_set_state")
+int handle_set_state(struct trace_event_raw_inet_sock_set_state *ctx)
+{
+	struct latency_key key = {};
+	__u64 sk = (__u64)ctx->skaddr;
+	__u64 *start, ts;
+
+	if (ctx->protocol != IPPROTO_TCP)
+		return 0;
+
+	if (ctx->newstate == TCP_SYN_SENT) {
+		ts = bpf_ktime_get_ns();
+		bpf_map_update_elem(&connect_start, &sk, &ts, BPF_ANY);
+		return 0;
+	}
+	if (ctx->oldstate != TCP_SYN_SENT)
+		return 0;
+
+	start = bpf_map_lookup_elem(&connect_start, &sk);
+	if (!start)
+		return 0;
+	if (ctx->newstate == TCP_ESTABLISHED) {
+		key.port = ctx->dport;
+		bucket((bpf_ktime_get_ns() - *start) / 1000, &key);
+		increment(&connect_latency, &key);
+	}
+	bpf_map_delete_elem(&connect_start, &sk);
+	return 0;
+}
+
+SEC("tracepoint/tcp/tcp_retransmit_skb")
+int handle_retransmit(struct trace_event_raw_tcp_event_sk_skb *ctx)
+{
+	__u16 port = ctx->dport;
+
+	increment(&retransmits, &port);
+	return 0;
+}
+
+// accept_drop counts the connections dropped by a listener with a full
+// accept queue, see sk_acceptq_is_full
+static __always_inline int accept_drop(struct sock *sk)
+{
+	__u32 backlog = BPF_CORE_READ(sk, sk_ack_backlog);
+	__u32 max_backlog = BPF_CORE_READ(sk, sk_max_ack_backlog);
+	__u16 port;
+
+	if (backlog <= max_backlog)
+		return 0;
+	port = BPF_CORE_READ(sk, __sk_common.skc_num);
+	increment(&accept_drops, &port);
+	return 0;
+}
+
+SEC("kprobe/tcp_v4_syn_recv_sock")
+int BPF_KPROBE(handle_v4_syn_recv_sock, struct sock *sk)
+{
+	return accept_drop(sk);
+}
+
+SEC("kprobe/tcp_v6_syn_recv_sock")
+int BPF_KPROBE(handle_v6_syn_recv_sock, struct sock *sk)
+{
+	return accept_drop(sk);
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,279 @@
+//go:build linux
+// +build linux
+
+package ebpftcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/rlimit"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+// btfPath is the BTF of the running kernel, the CO-RE programs are relocated
+// against it when loaded
+const btfPath = "/sys/kernel/btf/vmlinux"
+
+// defaultObjectPath is where the packages install the compiled object of the
+// agent arch
+var defaultObjectPath = "/opt/circonus/unified-agent/etc/ebpf/ebpf_tcp_" + runtime.GOARCH + ".bpf.o"
+
+// EBPFTCP traces the TCP connects, retransmissions and accept queue drops
+// of the host with eBPF programs
+type EBPFTCP struct {
+	Log cua.Logger `toml:"-"`
+
+	ObjectPath       string `toml:"object_path"`
+	Ports            []int  `toml:"ports"`
+	ConnectLatency   bool   `toml:"connect_latency"`
+	Retransmits      bool   `toml:"retransmits"`
+	AcceptQueueDrops bool   `toml:"accept_queue_drops"`
+
+	ports    map[uint16]bool
+	coll     *ebpf.Collection
+	links    []link.Link
+	previous map[latencyKey]uint64
+}
+
+const sampleConfig = `
+  ## The compiled CO-RE object of bpf/ebpf_tcp.bpf.c, the kernel must be 5.x
+  ## or newer with BTF (/sys/kernel/btf/vmlinux). Loading the programs
+  ## requires root or the CAP_BPF and CAP_PERFMON capabilities. The default
+  ## is the object of the agent arch installed by the packages.
+  # object_path = "/opt/circonus/unified-agent/etc/ebpf/ebpf_tcp_amd64.bpf.o"
+
+  ## Destination ports reported individually, the others are summed in the
+  ## "other" port. All the ports are reported when empty, consider the
+  ## cardinality of the ephemeral ports of the clients of local servers.
+  # ports = [80, 443, 5432]
+
+  ## Histogram of the latency of the connects per destination port
+  # connect_latency = true
+  ## Retransmitted segments per destination port
+  # retransmits = true
+  ## Connections dropped per listening port because the accept queue is full
+  # accept_queue_drops = true
+`
+
+func (*EBPFTCP) Description() string {
+	return "Trace TCP connect latency, retransmissions and accept queue drops with eBPF"
+}
+
+func (*EBPFTCP) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *EBPFTCP) Init() error {
+	if e.ObjectPath == "" {
+		e.ObjectPath = defaultObjectPath
+	}
+	if !e.ConnectLatency && !e.Retransmits && !e.AcceptQueueDrops {
+		return fmt.Errorf("connect_latency, retransmits and accept_queue_drops are all disabled")
+	}
+
+	e.ports = make(map[uint16]bool, len(e.Ports))
+	for _, p := range e.Ports {
+		if p <= 0 || p > 65535 {
+			return fmt.Errorf("invalid port (%d)", p)
+		}
+		e.ports[uint16(p)] = true
+	}
+	e.previous = make(map[latencyKey]uint64)
+
+	return nil
+}
+
+// attachment is an eBPF program of the object and how to attach it
+type attachment struct {
+	program  string
+	optional bool
+	attach   func(*ebpf.Program) (link.Link, error)
+}
+
+func (e *EBPFTCP) attachments() []attachment {
+	var a []attachment
+	if e.ConnectLatency {
+		a = append(a, attachment{
+			program: "handle_set_state",
+			attach: func(p *ebpf.Program) (link.Link, error) {
+				return link.Tracepoint("sock", "inet_sock_set_state", p)
+			},
+		})
+	}
+	if e.Retransmits {
+		a = append(a, attachment{
+			program: "handle_retransmit",
+			attach: func(p *ebpf.Program) (link.Link, error) {
+				return link.Tracepoint("tcp", "tcp_retransmit_skb", p)
+			},
+		})
+	}
+	if e.AcceptQueueDrops {
+		a = append(a, attachment{
+			program: "handle_v4_syn_recv_sock",
+			attach: func(p *ebpf.Program) (link.Link, error) {
+				return link.Kprobe("tcp_v4_syn_recv_sock", p)
+			},
+		}, attachment{
+			// not available when ipv6 is not loaded
+			program:  "handle_v6_syn_recv_sock",
+			optional: true,
+			attach: func(p *ebpf.Program) (link.Link, error) {
+				return link.Kprobe("tcp_v6_syn_recv_sock", p)
+			},
+		})
+	}
+	return a
+}
+
+func (e *EBPFTCP) Start(ctx context.Context, _ cua.Accumulator) error {
+	if _, err := os.Stat(btfPath); err != nil {
+		return fmt.Errorf("kernel BTF required: %w", err)
+	}
+	if err := rlimit.RemoveMemlock(); err != nil {
+		return fmt.Errorf("removing memlock limit: %w", err)
+	}
+
+	spec, err := ebpf.LoadCollectionSpec(e.ObjectPath)
+	if err != nil {
+		return fmt.Errorf("object_path (%s): %w", e.ObjectPath, err)
+	}
+	coll, err := ebpf.NewCollection(spec)
+	if err != nil {
+		return fmt.Errorf("loading programs (%s): %w", e.ObjectPath, err)
+	}
+	e.coll = coll
+
+	for _, a := range e.attachments() {
+		prog, ok := coll.Programs[a.program]
+		if !ok {
+			e.Stop()
+			return fmt.Errorf("object_path (%s): program %s not found", e.ObjectPath, a.program)
+		}
+		l, err := a.attach(prog)
+		if err != nil {
+			if a.optional {
+				e.Log.Warnf("attaching %s: %s", a.program, err)
+				continue
+			}
+			e.Stop()
+			return fmt.Errorf("attaching %s: %w", a.program, err)
+		}
+		e.links = append(e.links, l)
+	}
+
+	return nil
+}
+
+func (e *EBPFTCP) Stop() {
+	for _, l := range e.links {
+		l.Close()
+	}
+	e.links = nil
+	if e.coll != nil {
+		e.coll.Close()
+		e.coll = nil
+	}
+}
+
+func (e *EBPFTCP) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if e.ConnectLatency {
+		counts, err := readLatencies(e.coll.Maps[connectLatencyMap])
+		if err != nil {
+			acc.AddError(fmt.Errorf("map %s: %w", connectLatencyMap, err))
+		} else {
+			for port, buckets := range histograms(counts, e.previous, e.portLabel) {
+				acc.AddHistogram("ebpf_tcp_connect_latency", buckets, map[string]string{"port": port})
+			}
+		}
+	}
+
+	fields := make(map[string]map[string]interface{})
+	if e.Retransmits {
+		counts, err := readPortCounts(e.coll.Maps[retransmitsMap])
+		if err != nil {
+			acc.AddError(fmt.Errorf("map %s: %w", retransmitsMap, err))
+		} else {
+			portCounters(fields, "retransmits", counts, e.portLabel)
+		}
+	}
+	if e.AcceptQueueDrops {
+		counts, err := readPortCounts(e.coll.Maps[acceptDropsMap])
+		if err != nil {
+			acc.AddError(fmt.Errorf("map %s: %w", acceptDropsMap, err))
+		} else {
+			portCounters(fields, "accept_queue_drops", counts, e.portLabel)
+		}
+	}
+	for port, f := range fields {
+		acc.AddFields("ebpf_tcp", f, map[string]string{"port": port})
+	}
+
+	return nil
+}
+
+// portLabel returns the port tag of a port, "other" for the ports not
+// configured
+func (e *EBPFTCP) portLabel(port uint16) string {
+	if len(e.ports) != 0 && !e.ports[port] {
+		return "other"
+	}
+	return strconv.Itoa(int(port))
+}
+
+// readLatencies reads the cumulative counts of the connect_latency map
+func readLatencies(m *ebpf.Map) (map[latencyKey]uint64, error) {
+	if m == nil {
+		return nil, fmt.Errorf("not found")
+	}
+	var (
+		key   latencyKey
+		count uint64
+	)
+	counts := make(map[latencyKey]uint64)
+	iter := m.Iterate()
+	for iter.Next(&key, &count) {
+		counts[key] = count
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("iterate: %w", err)
+	}
+	return counts, nil
+}
+
+// readPortCounts reads the cumulative counts of a map keyed by port
+func readPortCounts(m *ebpf.Map) (map[uint16]uint64, error) {
+	if m == nil {
+		return nil, fmt.Errorf("not found")
+	}
+	var (
+		key   uint16
+		count uint64
+	)
+	counts := make(map[uint16]uint64)
+	iter := m.Iterate()
+	for iter.Next(&key, &count) {
+		counts[key] = count
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("iterate: %w", err)
+	}
+	return counts, nil
+}
+
+func init() {
+	inputs.Add("ebpf_tcp", func() cua.Input {
+		return &EBPFTCP{
+			ConnectLatency:   true,
+			Retransmits:      true,
+			AcceptQueueDrops: true,
+		}
+	})
+}
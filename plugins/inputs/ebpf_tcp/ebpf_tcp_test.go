@@ -0,0 +1,94 @@
+//go:build linux
+// +build linux
+
+package ebpftcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyKeySeconds(t *testing.T) {
+	require.Equal(t, 42e-6, latencyKey{Mantissa: 42}.seconds())
+	require.InDelta(t, 0.012, latencyKey{Mantissa: 12, Exponent: 3}.seconds(), 1e-12)
+	require.InDelta(t, 1.5, latencyKey{Mantissa: 15, Exponent: 5}.seconds(), 1e-12)
+}
+
+func TestInit(t *testing.T) {
+	e := &EBPFTCP{ConnectLatency: true}
+	require.NoError(t, e.Init())
+	require.Equal(t, defaultObjectPath, e.ObjectPath)
+
+	e = &EBPFTCP{ObjectPath: "ebpf_tcp.bpf.o"}
+	require.Error(t, e.Init())
+
+	e = &EBPFTCP{ObjectPath: "ebpf_tcp.bpf.o", ConnectLatency: true, Ports: []int{70000}}
+	require.Error(t, e.Init())
+
+	e = &EBPFTCP{ObjectPath: "ebpf_tcp.bpf.o", ConnectLatency: true, Ports: []int{443}}
+	require.NoError(t, e.Init())
+	require.Equal(t, "443", e.portLabel(443))
+	require.Equal(t, "other", e.portLabel(80))
+
+	e = &EBPFTCP{ObjectPath: "ebpf_tcp.bpf.o", Retransmits: true}
+	require.NoError(t, e.Init())
+	require.Equal(t, "80", e.portLabel(80))
+}
+
+func TestHistograms(t *testing.T) {
+	e := &EBPFTCP{ObjectPath: "ebpf_tcp.bpf.o", ConnectLatency: true, Ports: []int{443}}
+	require.NoError(t, e.Init())
+
+	counts := map[latencyKey]uint64{
+		{Port: 443, Mantissa: 12, Exponent: 3}: 3,
+		{Port: 443, Mantissa: 15, Exponent: 5}: 1,
+		{Port: 80, Mantissa: 50}:               2,
+		{Port: 8080, Mantissa: 50}:             1,
+	}
+	hists := histograms(counts, e.previous, e.portLabel)
+	require.Equal(t, map[string]map[string]interface{}{
+		"443": {
+			"1.2e-02": int64(3),
+			"1.5e+00": int64(1),
+		},
+		"other": {
+			"5e-05": int64(3),
+		},
+	}, hists)
+
+	// only the counts of the interval, the reset counters are counted again
+	counts = map[latencyKey]uint64{
+		{Port: 443, Mantissa: 12, Exponent: 3}: 5,
+		{Port: 443, Mantissa: 15, Exponent: 5}: 1,
+		{Port: 80, Mantissa: 50}:               1,
+	}
+	hists = histograms(counts, e.previous, e.portLabel)
+	require.Equal(t, map[string]map[string]interface{}{
+		"443": {
+			"1.2e-02": int64(2),
+		},
+		"other": {
+			"5e-05": int64(1),
+		},
+	}, hists)
+	require.Len(t, e.previous, 3)
+}
+
+func TestPortCounters(t *testing.T) {
+	e := &EBPFTCP{ObjectPath: "ebpf_tcp.bpf.o", Retransmits: true, Ports: []int{443}}
+	require.NoError(t, e.Init())
+
+	fields := make(map[string]map[string]interface{})
+	portCounters(fields, "retransmits", map[uint16]uint64{443: 7, 80: 2, 22: 1}, e.portLabel)
+	portCounters(fields, "accept_queue_drops", map[uint16]uint64{443: 4}, e.portLabel)
+	require.Equal(t, map[string]map[string]interface{}{
+		"443": {
+			"retransmits":        uint64(7),
+			"accept_queue_drops": uint64(4),
+		},
+		"other": {
+			"retransmits": uint64(3),
+		},
+	}, fields)
+}
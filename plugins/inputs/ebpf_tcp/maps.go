@@ -0,0 +1,72 @@
+//go:build linux
+// +build linux
+
+package ebpftcp
+
+import (
+	"math"
+	"strconv"
+)
+
+// the maps of bpf/ebpf_tcp.bpf.c
+const (
+	connectLatencyMap = "connect_latency"
+	retransmitsMap    = "retransmits"
+	acceptDropsMap    = "accept_drops"
+)
+
+// latencyKey is the key of the connect_latency map, the bucket of a connect
+// latency of mantissa * 10^exponent microseconds to a destination port
+type latencyKey struct {
+	Port     uint16
+	Mantissa uint8
+	Exponent uint8
+}
+
+// seconds returns the value of the bucket in seconds
+func (k latencyKey) seconds() float64 {
+	return float64(k.Mantissa) * math.Pow10(int(k.Exponent)) / 1e6
+}
+
+// histograms turns the cumulative counts of the connect_latency map into the
+// counts of the interval per port, the previous counts are replaced
+func histograms(counts, previous map[latencyKey]uint64, label func(uint16) string) map[string]map[string]interface{} {
+	hists := make(map[string]map[string]interface{})
+	for key, count := range counts {
+		n := count
+		if prev, ok := previous[key]; ok && prev <= count {
+			n -= prev
+		}
+		previous[key] = count
+		if n == 0 {
+			continue
+		}
+
+		port := label(key.Port)
+		if hists[port] == nil {
+			hists[port] = make(map[string]interface{})
+		}
+		bucket := strconv.FormatFloat(key.seconds(), 'e', -1, 64)
+		v, _ := hists[port][bucket].(int64)
+		hists[port][bucket] = v + int64(n)
+	}
+	for key := range previous {
+		if _, ok := counts[key]; !ok {
+			delete(previous, key)
+		}
+	}
+	return hists
+}
+
+// portCounters sums the counts of a port map per port label into the field
+// of the metrics of the ports
+func portCounters(fields map[string]map[string]interface{}, field string, counts map[uint16]uint64, label func(uint16) string) {
+	for p, count := range counts {
+		port := label(p)
+		if fields[port] == nil {
+			fields[port] = make(map[string]interface{})
+		}
+		v, _ := fields[port][field].(uint64)
+		fields[port][field] = v + count
+	}
+}
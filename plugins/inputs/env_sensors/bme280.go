@@ -0,0 +1,173 @@
+//go:build linux
+// +build linux
+
+package envsensors
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const (
+	bme280Address = 0x76
+	bme280ChipID  = 0x60
+
+	bme280RegChipID   = 0xd0
+	bme280RegCalib1   = 0x88
+	bme280RegCalib2   = 0xe1
+	bme280RegCtrlHum  = 0xf2
+	bme280RegStatus   = 0xf3
+	bme280RegCtrlMeas = 0xf4
+	bme280RegData     = 0xf7
+)
+
+// bme280 reads a Bosch BME280 temperature, humidity and pressure sensor with
+// a forced mode measurement, without oversampling
+type bme280 struct {
+	calib *bme280Calibration
+}
+
+type bme280Calibration struct {
+	t1         uint16
+	t2, t3     int16
+	p1         uint16
+	p2, p3, p4 int16
+	p5, p6, p7 int16
+	p8, p9     int16
+	h1         uint8
+	h2         int16
+	h3         uint8
+	h4, h5     int16
+	h6         int8
+}
+
+func (s *bme280) read(dev i2cDevice) (map[string]interface{}, error) {
+	if s.calib == nil {
+		id, err := readRegisters(dev, bme280RegChipID, 1)
+		if err != nil {
+			return nil, err
+		}
+		if id[0] != bme280ChipID {
+			return nil, fmt.Errorf("unexpected chip id 0x%02x, not a bme280", id[0])
+		}
+		c1, err := readRegisters(dev, bme280RegCalib1, 26)
+		if err != nil {
+			return nil, err
+		}
+		c2, err := readRegisters(dev, bme280RegCalib2, 7)
+		if err != nil {
+			return nil, err
+		}
+		s.calib = parseBME280Calibration(c1, c2)
+	}
+
+	// humidity oversampling x1, applied by the write of ctrl_meas
+	if err := writeRegister(dev, bme280RegCtrlHum, 0x01); err != nil {
+		return nil, err
+	}
+	// temperature and pressure oversampling x1, forced mode
+	if err := writeRegister(dev, bme280RegCtrlMeas, 0x25); err != nil {
+		return nil, err
+	}
+
+	// a measurement without oversampling takes less than 10ms
+	for i := 0; ; i++ {
+		time.Sleep(10 * time.Millisecond)
+		status, err := readRegisters(dev, bme280RegStatus, 1)
+		if err != nil {
+			return nil, err
+		}
+		if status[0]&0x08 == 0 {
+			break
+		}
+		if i == 10 {
+			return nil, fmt.Errorf("measurement timeout")
+		}
+	}
+
+	data, err := readRegisters(dev, bme280RegData, 8)
+	if err != nil {
+		return nil, err
+	}
+	return s.calib.fields(data), nil
+}
+
+func parseBME280Calibration(c1, c2 []byte) *bme280Calibration {
+	le := binary.LittleEndian
+	return &bme280Calibration{
+		t1: le.Uint16(c1[0:]),
+		t2: int16(le.Uint16(c1[2:])),
+		t3: int16(le.Uint16(c1[4:])),
+		p1: le.Uint16(c1[6:]),
+		p2: int16(le.Uint16(c1[8:])),
+		p3: int16(le.Uint16(c1[10:])),
+		p4: int16(le.Uint16(c1[12:])),
+		p5: int16(le.Uint16(c1[14:])),
+		p6: int16(le.Uint16(c1[16:])),
+		p7: int16(le.Uint16(c1[18:])),
+		p8: int16(le.Uint16(c1[20:])),
+		p9: int16(le.Uint16(c1[22:])),
+		h1: c1[25],
+		h2: int16(le.Uint16(c2[0:])),
+		h3: c2[2],
+		// 12 bit signed values sharing the nibbles of 0xe5
+		h4: int16(int8(c2[3]))<<4 | int16(c2[4]&0x0f),
+		h5: int16(int8(c2[5]))<<4 | int16(c2[4]>>4),
+		h6: int8(c2[6]),
+	}
+}
+
+// fields compensates the raw measurement with the floating point formulas
+// of the datasheet, the pressure is reported in hPa
+func (c *bme280Calibration) fields(data []byte) map[string]interface{} {
+	adcP := float64(uint32(data[0])<<12 | uint32(data[1])<<4 | uint32(data[2])>>4)
+	adcT := float64(uint32(data[3])<<12 | uint32(data[4])<<4 | uint32(data[5])>>4)
+	adcH := float64(uint32(data[6])<<8 | uint32(data[7]))
+
+	tFine := c.temperatureFine(adcT)
+	return map[string]interface{}{
+		"temperature": tFine / 5120,
+		"pressure":    c.pressure(adcP, tFine) / 100,
+		"humidity":    c.humidity(adcH, tFine),
+	}
+}
+
+func (c *bme280Calibration) temperatureFine(adcT float64) float64 {
+	var1 := (adcT/16384 - float64(c.t1)/1024) * float64(c.t2)
+	var2 := (adcT/131072 - float64(c.t1)/8192) * (adcT/131072 - float64(c.t1)/8192) * float64(c.t3)
+	return var1 + var2
+}
+
+// pressure returns the pressure in Pa
+func (c *bme280Calibration) pressure(adcP, tFine float64) float64 {
+	var1 := tFine/2 - 64000
+	var2 := var1 * var1 * float64(c.p6) / 32768
+	var2 += var1 * float64(c.p5) * 2
+	var2 = var2/4 + float64(c.p4)*65536
+	var1 = (float64(c.p3)*var1*var1/524288 + float64(c.p2)*var1) / 524288
+	var1 = (1 + var1/32768) * float64(c.p1)
+	if var1 == 0 {
+		return 0
+	}
+	p := 1048576 - adcP
+	p = (p - var2/4096) * 6250 / var1
+	var1 = float64(c.p9) * p * p / 2147483648
+	var2 = p * float64(c.p8) / 32768
+	return p + (var1+var2+float64(c.p7))/16
+}
+
+// humidity returns the relative humidity in percent
+func (c *bme280Calibration) humidity(adcH, tFine float64) float64 {
+	h := tFine - 76800
+	h = (adcH - (float64(c.h4)*64 + float64(c.h5)/16384*h)) *
+		(float64(c.h2) / 65536 * (1 + float64(c.h6)/67108864*h*(1+float64(c.h3)/67108864*h)))
+	h *= 1 - float64(c.h1)*h/524288
+	switch {
+	case h > 100:
+		return 100
+	case h < 0:
+		return 0
+	}
+	return h
+}
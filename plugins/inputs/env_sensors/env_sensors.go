@@ -0,0 +1,223 @@
+//go:build linux
+// +build linux
+
+package envsensors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const defaultW1Path = "/sys/bus/w1/devices"
+
+// EnvSensors reads the temperature, humidity and pressure of 1-Wire and I2C
+// sensors
+type EnvSensors struct {
+	Log cua.Logger
+
+	W1        bool         `toml:"w1"`
+	W1Path    string       `toml:"w1_path"`
+	W1Sensors []string     `toml:"w1_sensors"`
+	I2C       []*I2CSensor `toml:"i2c"`
+
+	// openI2C opens a device on an I2C bus, replaced in tests
+	openI2C func(bus int, address uint16) (i2cDevice, error)
+}
+
+// I2CSensor is a sensor on an I2C bus
+type I2CSensor struct {
+	Type    string `toml:"type"`
+	Bus     int    `toml:"bus"`
+	Address string `toml:"address"`
+	Name    string `toml:"name"`
+
+	address uint16
+	sensor  i2cSensor
+}
+
+// i2cSensor reads the fields of a type of I2C sensor
+type i2cSensor interface {
+	read(dev i2cDevice) (map[string]interface{}, error)
+}
+
+const sampleConfig = `
+  ## Read the DS18B20 temperature sensors of the 1-Wire bus, the w1-gpio and
+  ## w1-therm kernel modules must be loaded
+  # w1 = true
+
+  ## sysfs directory of the 1-Wire devices
+  # w1_path = "/sys/bus/w1/devices"
+
+  ## IDs of the 1-Wire sensors to read, all the DS18B20 sensors by default
+  # w1_sensors = ["28-0316a2794bff"]
+
+  ## I2C sensors, the i2c-dev kernel module must be loaded and the agent
+  ## user needs access to /dev/i2c-<bus>, e.g. through the i2c group
+  # [[inputs.env_sensors.i2c]]
+  #   ## Sensor type, sht3x or bme280
+  #   type = "sht3x"
+  #   ## I2C bus number
+  #   bus = 1
+  #   ## Address of the sensor, 0x44 for sht3x and 0x76 for bme280 by default
+  #   # address = "0x44"
+  #   ## Optional name of the sensor, added as the name tag
+  #   # name = "closet"
+`
+
+func (*EnvSensors) Description() string {
+	return "Read temperature, humidity and pressure from 1-Wire DS18B20 and I2C SHT3x/BME280 sensors"
+}
+
+func (*EnvSensors) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *EnvSensors) Init() error {
+	if e.W1Path == "" {
+		e.W1Path = defaultW1Path
+	}
+	if e.openI2C == nil {
+		e.openI2C = openI2CDevice
+	}
+
+	for _, s := range e.I2C {
+		var def uint16
+		switch s.Type {
+		case "sht3x":
+			s.sensor = &sht3x{}
+			def = sht3xAddress
+		case "bme280":
+			s.sensor = &bme280{}
+			def = bme280Address
+		default:
+			return fmt.Errorf("invalid i2c sensor type (%s), must be sht3x or bme280", s.Type)
+		}
+		s.address = def
+		if s.Address != "" {
+			addr, err := strconv.ParseUint(s.Address, 0, 7)
+			if err != nil {
+				return fmt.Errorf("i2c sensor address (%s): %w", s.Address, err)
+			}
+			s.address = uint16(addr)
+		}
+	}
+
+	if !e.W1 && len(e.I2C) == 0 {
+		return fmt.Errorf("no sensors configured, enable w1 or add i2c sensors")
+	}
+
+	return nil
+}
+
+func (e *EnvSensors) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if e.W1 {
+		if err := e.gatherW1(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	for _, s := range e.I2C {
+		if err := e.gatherI2C(acc, s); err != nil {
+			acc.AddError(fmt.Errorf("%s on i2c-%d at 0x%02x: %w", s.Type, s.Bus, s.address, err))
+		}
+	}
+
+	return nil
+}
+
+func (e *EnvSensors) gatherW1(acc cua.Accumulator) error {
+	ids := e.W1Sensors
+	if len(ids) == 0 {
+		// DS18B20 family code
+		paths, err := filepath.Glob(filepath.Join(e.W1Path, "28-*"))
+		if err != nil {
+			return fmt.Errorf("w1 glob: %w", err)
+		}
+		for _, p := range paths {
+			ids = append(ids, filepath.Base(p))
+		}
+	}
+
+	for _, id := range ids {
+		data, err := os.ReadFile(filepath.Join(e.W1Path, id, "w1_slave"))
+		if err != nil {
+			acc.AddError(fmt.Errorf("w1 sensor %s: %w", id, err))
+			continue
+		}
+		temp, err := parseW1Slave(string(data))
+		if err != nil {
+			acc.AddError(fmt.Errorf("w1 sensor %s: %w", id, err))
+			continue
+		}
+		acc.AddFields("env_sensors",
+			map[string]interface{}{"temperature": temp},
+			map[string]string{"sensor": "ds18b20", "id": id},
+		)
+	}
+
+	return nil
+}
+
+// parseW1Slave returns the temperature in degrees Celsius of the w1_slave
+// file of a DS18B20 sensor:
+//
+//	72 01 4b 46 7f ff 0e 10 57 : crc=57 YES
+//	72 01 4b 46 7f ff 0e 10 57 t=23125
+func parseW1Slave(data string) (float64, error) {
+	lines := strings.Split(strings.TrimSpace(data), "\n")
+	if len(lines) != 2 {
+		return 0, fmt.Errorf("invalid w1_slave data: %q", data)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(lines[0]), "YES") {
+		return 0, fmt.Errorf("crc check failed")
+	}
+	i := strings.LastIndex(lines[1], "t=")
+	if i < 0 {
+		return 0, fmt.Errorf("no temperature in w1_slave data: %q", data)
+	}
+	milli, err := strconv.ParseInt(strings.TrimSpace(lines[1][i+2:]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("temperature: %w", err)
+	}
+	// 85000 is the power-on reset value, read before a conversion completed
+	if milli == 85000 {
+		return 0, fmt.Errorf("power-on reset value, conversion not completed")
+	}
+	return float64(milli) / 1000, nil
+}
+
+func (e *EnvSensors) gatherI2C(acc cua.Accumulator, s *I2CSensor) error {
+	dev, err := e.openI2C(s.Bus, s.address)
+	if err != nil {
+		return err
+	}
+	defer dev.Close()
+
+	fields, err := s.sensor.read(dev)
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]string{
+		"sensor": s.Type,
+		"id":     fmt.Sprintf("i2c-%d-0x%02x", s.Bus, s.address),
+	}
+	if s.Name != "" {
+		tags["name"] = s.Name
+	}
+	acc.AddFields("env_sensors", fields, tags)
+	return nil
+}
+
+func init() {
+	inputs.Add("env_sensors", func() cua.Input {
+		return &EnvSensors{W1: true}
+	})
+}
@@ -0,0 +1,156 @@
+//go:build linux
+// +build linux
+
+package envsensors
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDevice records the writes and returns the reads of a device
+type fakeDevice struct {
+	writes [][]byte
+	reads  [][]byte
+}
+
+func (d *fakeDevice) Write(b []byte) (int, error) {
+	d.writes = append(d.writes, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func (d *fakeDevice) Read(b []byte) (int, error) {
+	if len(d.reads) == 0 {
+		return 0, os.ErrClosed
+	}
+	n := copy(b, d.reads[0])
+	d.reads = d.reads[1:]
+	return n, nil
+}
+
+func (d *fakeDevice) Close() error {
+	return nil
+}
+
+func TestParseW1Slave(t *testing.T) {
+	temp, err := parseW1Slave("72 01 4b 46 7f ff 0e 10 57 : crc=57 YES\n72 01 4b 46 7f ff 0e 10 57 t=23125\n")
+	require.NoError(t, err)
+	require.Equal(t, 23.125, temp)
+
+	temp, err = parseW1Slave("5e ff 4b 46 7f ff 02 10 21 : crc=21 YES\n5e ff 4b 46 7f ff 02 10 21 t=-10125\n")
+	require.NoError(t, err)
+	require.Equal(t, -10.125, temp)
+
+	_, err = parseW1Slave("72 01 4b 46 7f ff 0e 10 57 : crc=00 NO\n72 01 4b 46 7f ff 0e 10 57 t=23125\n")
+	require.Error(t, err)
+
+	_, err = parseW1Slave("50 05 4b 46 7f ff 0c 10 1c : crc=1c YES\n50 05 4b 46 7f ff 0c 10 1c t=85000\n")
+	require.Error(t, err)
+}
+
+func TestCRC8(t *testing.T) {
+	// example of the SHT3x datasheet
+	require.Equal(t, byte(0x92), crc8([]byte{0xbe, 0xef}))
+}
+
+func TestSHT3xFields(t *testing.T) {
+	b := []byte{0x66, 0x66, 0, 0x80, 0x00, 0}
+	b[2] = crc8(b[0:2])
+	b[5] = crc8(b[3:5])
+
+	fields, err := sht3xFields(b)
+	require.NoError(t, err)
+	require.InDelta(t, 25.0, fields["temperature"], 0.01)
+	require.InDelta(t, 50.0, fields["humidity"], 0.01)
+
+	b[5]++
+	_, err = sht3xFields(b)
+	require.Error(t, err)
+}
+
+func TestBME280Fields(t *testing.T) {
+	// compensation example of the BME280 datasheet
+	c := &bme280Calibration{
+		t1: 27504, t2: 26435, t3: -1000,
+		p1: 36477, p2: -10685, p3: 3024, p4: 2855, p5: 140, p6: -7, p7: 15500, p8: -14600, p9: 6000,
+		h1: 75, h2: 362, h3: 0, h4: 313, h5: 50, h6: 30,
+	}
+	fields := c.fields([]byte{0x65, 0x5a, 0xc0, 0x7e, 0xed, 0x00, 0x6f, 0x00})
+	require.InDelta(t, 25.08, fields["temperature"], 0.01)
+	require.InDelta(t, 1006.53, fields["pressure"], 0.01)
+	require.GreaterOrEqual(t, fields["humidity"], 0.0)
+	require.LessOrEqual(t, fields["humidity"], 100.0)
+}
+
+func TestParseBME280Calibration(t *testing.T) {
+	c1 := make([]byte, 26)
+	c1[0], c1[1] = 0x70, 0x6b // t1 27504
+	c1[2], c1[3] = 0x43, 0x67 // t2 26435
+	c1[4], c1[5] = 0x18, 0xfc // t3 -1000
+	c1[25] = 75
+	c2 := []byte{0x6a, 0x01, 0x00, 0x13, 0x29, 0x03, 0x1e}
+
+	c := parseBME280Calibration(c1, c2)
+	require.Equal(t, uint16(27504), c.t1)
+	require.Equal(t, int16(26435), c.t2)
+	require.Equal(t, int16(-1000), c.t3)
+	require.Equal(t, uint8(75), c.h1)
+	require.Equal(t, int16(362), c.h2)
+	require.Equal(t, int16(0x139), c.h4)
+	require.Equal(t, int16(0x32), c.h5)
+	require.Equal(t, int8(30), c.h6)
+}
+
+func TestGather(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "28-0316a2794bff"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "28-0316a2794bff", "w1_slave"),
+		[]byte("72 01 4b 46 7f ff 0e 10 57 : crc=57 YES\n72 01 4b 46 7f ff 0e 10 57 t=23125\n"), 0600))
+
+	reading := []byte{0x66, 0x66, 0, 0x80, 0x00, 0}
+	reading[2] = crc8(reading[0:2])
+	reading[5] = crc8(reading[3:5])
+	dev := &fakeDevice{reads: [][]byte{reading}}
+
+	e := &EnvSensors{
+		W1:     true,
+		W1Path: dir,
+		I2C:    []*I2CSensor{{Type: "sht3x", Bus: 1, Address: "0x45", Name: "closet"}},
+		openI2C: func(bus int, address uint16) (i2cDevice, error) {
+			require.Equal(t, 1, bus)
+			require.Equal(t, uint16(0x45), address)
+			return dev, nil
+		},
+	}
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.True(t, bytes.Equal([]byte{0x24, 0x00}, dev.writes[0]))
+
+	acc.AssertContainsTaggedFields(t, "env_sensors",
+		map[string]interface{}{"temperature": 23.125},
+		map[string]string{"sensor": "ds18b20", "id": "28-0316a2794bff"},
+	)
+	acc.AssertContainsTaggedFields(t, "env_sensors",
+		map[string]interface{}{"temperature": 25.0, "humidity": 100 * float64(0x8000) / 65535},
+		map[string]string{"sensor": "sht3x", "id": "i2c-1-0x45", "name": "closet"},
+	)
+}
+
+func TestInit(t *testing.T) {
+	require.Error(t, (&EnvSensors{}).Init())
+	require.Error(t, (&EnvSensors{I2C: []*I2CSensor{{Type: "dht22"}}}).Init())
+	require.Error(t, (&EnvSensors{I2C: []*I2CSensor{{Type: "bme280", Address: "0x100"}}}).Init())
+
+	e := &EnvSensors{I2C: []*I2CSensor{{Type: "bme280"}}}
+	require.NoError(t, e.Init())
+	require.Equal(t, uint16(0x76), e.I2C[0].address)
+}
@@ -0,0 +1,54 @@
+//go:build linux
+// +build linux
+
+package envsensors
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// i2cSlave is the ioctl setting the address of the device of an i2c-dev file
+const i2cSlave = 0x0703
+
+// i2cDevice is a device on an I2C bus, writes and reads are plain I2C
+// transfers
+type i2cDevice interface {
+	Write(b []byte) (int, error)
+	Read(b []byte) (int, error)
+	Close() error
+}
+
+func openI2CDevice(bus int, address uint16) (i2cDevice, error) {
+	f, err := os.OpenFile(fmt.Sprintf("/dev/i2c-%d", bus), os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), i2cSlave, uintptr(address)); errno != 0 {
+		f.Close()
+		return nil, fmt.Errorf("set i2c address: %w", errno)
+	}
+	return f, nil
+}
+
+// readRegisters reads n bytes from the registers of a device starting at reg
+func readRegisters(dev i2cDevice, reg byte, n int) ([]byte, error) {
+	if _, err := dev.Write([]byte{reg}); err != nil {
+		return nil, fmt.Errorf("write register 0x%02x: %w", reg, err)
+	}
+	b := make([]byte, n)
+	if _, err := dev.Read(b); err != nil {
+		return nil, fmt.Errorf("read register 0x%02x: %w", reg, err)
+	}
+	return b, nil
+}
+
+// writeRegister writes a value to a register of a device
+func writeRegister(dev i2cDevice, reg, value byte) error {
+	if _, err := dev.Write([]byte{reg, value}); err != nil {
+		return fmt.Errorf("write register 0x%02x: %w", reg, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+//go:build linux
+// +build linux
+
+package envsensors
+
+import (
+	"fmt"
+	"time"
+)
+
+const sht3xAddress = 0x44
+
+// sht3x reads a Sensirion SHT3x temperature and humidity sensor with a single
+// shot, high repeatability measurement
+type sht3x struct{}
+
+func (*sht3x) read(dev i2cDevice) (map[string]interface{}, error) {
+	// single shot, high repeatability, clock stretching disabled
+	if _, err := dev.Write([]byte{0x24, 0x00}); err != nil {
+		return nil, fmt.Errorf("measure: %w", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	b := make([]byte, 6)
+	if _, err := dev.Read(b); err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	return sht3xFields(b)
+}
+
+// sht3xFields decodes a measurement, the temperature and the humidity words
+// are each followed by their CRC
+func sht3xFields(b []byte) (map[string]interface{}, error) {
+	if crc8(b[0:2]) != b[2] || crc8(b[3:5]) != b[5] {
+		return nil, fmt.Errorf("crc check failed")
+	}
+	rawT := uint16(b[0])<<8 | uint16(b[1])
+	rawRH := uint16(b[3])<<8 | uint16(b[4])
+	return map[string]interface{}{
+		"temperature": -45 + 175*float64(rawT)/65535,
+		"humidity":    100 * float64(rawRH) / 65535,
+	}, nil
+}
+
+// crc8 is the Sensirion CRC-8, polynomial 0x31 with an initial value of 0xff
+func crc8(data []byte) byte {
+	crc := byte(0xff)
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,428 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const sampleConfig = `
+  ## etcd client urls
+  endpoints = ["https://127.0.0.1:2379"]
+
+  ## Check the health of every member of the cluster, using the client urls
+  ## from the member list
+  # member_health = false
+
+  ## Timeout for each request
+  # timeout = "5s"
+
+  ## Optional TLS Config, the cert and key are used as the client
+  ## certificate when etcd requires client certificate auth
+  # tls_ca = "/etc/etcd/ca.crt"
+  # tls_cert = "/etc/etcd/client.crt"
+  # tls_key = "/etc/etcd/client.key"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// metricFields maps the etcd prometheus metrics reported to field names,
+// the db size metric was renamed in etcd 3.4
+var metricFields = map[string]string{
+	"etcd_server_has_leader":                          "has_leader",
+	"etcd_server_leader_changes_seen_total":           "leader_changes",
+	"etcd_server_proposals_committed_total":           "proposals_committed",
+	"etcd_server_proposals_applied_total":             "proposals_applied",
+	"etcd_server_proposals_pending":                   "proposals_pending",
+	"etcd_server_proposals_failed_total":              "proposals_failed",
+	"etcd_server_slow_apply_total":                    "slow_applies",
+	"etcd_server_heartbeat_send_failures_total":       "heartbeat_send_failures",
+	"etcd_mvcc_db_total_size_in_bytes":                "db_size",
+	"etcd_debugging_mvcc_db_total_size_in_bytes":      "db_size",
+	"etcd_mvcc_db_total_size_in_use_in_bytes":         "db_size_in_use",
+	"etcd_network_client_grpc_received_bytes_total":   "grpc_received_bytes",
+	"etcd_network_client_grpc_sent_bytes_total":       "grpc_sent_bytes",
+	"etcd_mvcc_db_compaction_keys_total":              "compaction_keys",
+	"etcd_debugging_mvcc_db_compaction_keys_total":    "compaction_keys",
+	"etcd_server_is_learner":                          "is_learner",
+	"etcd_server_snapshot_apply_in_progress_total":    "snapshot_apply_in_progress",
+	"etcd_server_read_indexes_failed_total":           "read_indexes_failed",
+	"etcd_network_peer_sent_failures_total":           "peer_sent_failures",
+	"etcd_network_peer_received_failures_total":       "peer_received_failures",
+	"etcd_server_quota_backend_bytes":                 "quota_backend_bytes",
+	"etcd_mvcc_delete_total":                          "deletes",
+	"etcd_mvcc_put_total":                             "puts",
+	"etcd_mvcc_range_total":                           "ranges",
+	"etcd_mvcc_txn_total":                             "txns",
+	"etcd_debugging_mvcc_keys_total":                  "keys",
+	"etcd_server_health_failures":                     "health_failures",
+	"etcd_server_health_success":                      "health_successes",
+	"etcd_network_active_peers":                       "active_peers",
+	"etcd_network_disconnected_peers_total":           "peer_disconnects",
+	"etcd_disk_backend_commit_duration_seconds_sum":   "backend_commit_seconds",
+	"etcd_disk_wal_fsync_duration_seconds_sum":        "wal_fsync_seconds",
+	"etcd_disk_backend_commit_duration_seconds_count": "backend_commits",
+	"etcd_disk_wal_fsync_duration_seconds_count":      "wal_fsyncs",
+}
+
+type Etcd struct {
+	Log          cua.Logger        `toml:"-"`
+	Endpoints    []string          `toml:"endpoints"`
+	Timeout      internal.Duration `toml:"timeout"`
+	MemberHealth bool              `toml:"member_health"`
+	tls.ClientConfig
+
+	client *http.Client
+}
+
+// uint64String is a uint64 encoded as a string by the etcd grpc gateway,
+// plain numbers are accepted as well
+type uint64String uint64
+
+func (u *uint64String) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parse uint64 (%s): %w", s, err)
+	}
+	*u = uint64String(v)
+	return nil
+}
+
+type responseHeader struct {
+	ClusterID uint64String `json:"cluster_id"`
+	MemberID  uint64String `json:"member_id"`
+	RaftTerm  uint64String `json:"raft_term"`
+}
+
+type statusResponse struct {
+	Header           responseHeader `json:"header"`
+	Version          string         `json:"version"`
+	Errors           []string       `json:"errors"`
+	DBSize           uint64String   `json:"dbSize"`
+	DBSizeInUse      uint64String   `json:"dbSizeInUse"`
+	Leader           uint64String   `json:"leader"`
+	RaftIndex        uint64String   `json:"raftIndex"`
+	RaftTerm         uint64String   `json:"raftTerm"`
+	RaftAppliedIndex uint64String   `json:"raftAppliedIndex"`
+	IsLearner        bool           `json:"isLearner"`
+}
+
+type member struct {
+	ID         uint64String `json:"ID"`
+	Name       string       `json:"name"`
+	ClientURLs []string     `json:"clientURLs"`
+	IsLearner  bool         `json:"isLearner"`
+}
+
+type memberListResponse struct {
+	Header  responseHeader `json:"header"`
+	Members []member       `json:"members"`
+}
+
+type healthResponse struct {
+	Health string `json:"health"`
+	Reason string `json:"reason"`
+}
+
+func (e *Etcd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Etcd) Description() string {
+	return "Read leader, proposal, db size and member health metrics from etcd"
+}
+
+func (e *Etcd) Init() error {
+	if len(e.Endpoints) == 0 {
+		return fmt.Errorf("no endpoints configured")
+	}
+	for i, endpoint := range e.Endpoints {
+		e.Endpoints[i] = strings.TrimSuffix(endpoint, "/")
+	}
+
+	tlsCfg, err := e.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+
+	e.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: e.Timeout.Duration,
+	}
+
+	return nil
+}
+
+func (e *Etcd) Gather(ctx context.Context, acc cua.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, endpoint := range e.Endpoints {
+		wg.Add(1)
+		go func(endpoint string) {
+			defer wg.Done()
+			e.gatherEndpoint(ctx, acc, endpoint)
+		}(endpoint)
+	}
+	wg.Wait()
+
+	for _, endpoint := range e.Endpoints {
+		var members memberListResponse
+		if err := e.post(ctx, endpoint+"/v3/cluster/member/list", &members); err != nil {
+			acc.AddError(fmt.Errorf("member list (%s): %w", endpoint, err))
+			continue
+		}
+		e.gatherMembers(ctx, acc, &members)
+		break
+	}
+
+	return nil
+}
+
+// gatherEndpoint reports the health, status and selected metrics of a member
+func (e *Etcd) gatherEndpoint(ctx context.Context, acc cua.Accumulator, endpoint string) {
+	tags := map[string]string{"endpoint": endpoint}
+	fields := make(map[string]interface{})
+
+	healthy, err := e.health(ctx, endpoint)
+	if err != nil {
+		acc.AddError(fmt.Errorf("health (%s): %w", endpoint, err))
+	}
+	fields["healthy"] = boolInt(healthy)
+
+	if err := e.gatherMetrics(ctx, endpoint, fields); err != nil {
+		acc.AddError(fmt.Errorf("metrics (%s): %w", endpoint, err))
+	}
+
+	var status statusResponse
+	if err := e.post(ctx, endpoint+"/v3/maintenance/status", &status); err != nil {
+		acc.AddError(fmt.Errorf("status (%s): %w", endpoint, err))
+	} else {
+		tags["cluster_id"] = fmt.Sprintf("%x", uint64(status.Header.ClusterID))
+		tags["member_id"] = fmt.Sprintf("%x", uint64(status.Header.MemberID))
+		fields["is_leader"] = boolInt(status.Leader != 0 && status.Leader == status.Header.MemberID)
+		fields["is_learner"] = boolInt(status.IsLearner)
+		fields["raft_term"] = uint64(status.RaftTerm)
+		fields["raft_index"] = uint64(status.RaftIndex)
+		fields["raft_applied_index"] = uint64(status.RaftAppliedIndex)
+		fields["db_size"] = uint64(status.DBSize)
+		if status.DBSizeInUse != 0 {
+			fields["db_size_in_use"] = uint64(status.DBSizeInUse)
+		}
+		fields["errors"] = len(status.Errors)
+	}
+
+	acc.AddFields("etcd", fields, tags)
+}
+
+// gatherMembers reports the cluster membership and, with member_health, the
+// health of each member
+func (e *Etcd) gatherMembers(ctx context.Context, acc cua.Accumulator, members *memberListResponse) {
+	clusterID := fmt.Sprintf("%x", uint64(members.Header.ClusterID))
+
+	learners := 0
+	healthy := 0
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, m := range members.Members {
+		if m.IsLearner {
+			learners++
+		}
+		if !e.MemberHealth {
+			continue
+		}
+
+		wg.Add(1)
+		go func(m member) {
+			defer wg.Done()
+
+			ok := false
+			for _, u := range m.ClientURLs {
+				var err error
+				if ok, err = e.health(ctx, strings.TrimSuffix(u, "/")); err == nil {
+					break
+				}
+				e.Log.Debugf("member %s health (%s): %s", m.Name, u, err)
+			}
+
+			mu.Lock()
+			if ok {
+				healthy++
+			}
+			mu.Unlock()
+
+			acc.AddFields("etcd_member",
+				map[string]interface{}{
+					"healthy":    boolInt(ok),
+					"is_learner": boolInt(m.IsLearner),
+				},
+				map[string]string{
+					"cluster_id": clusterID,
+					"member_id":  fmt.Sprintf("%x", uint64(m.ID)),
+					"member":     m.Name,
+				})
+		}(m)
+	}
+	wg.Wait()
+
+	fields := map[string]interface{}{
+		"members":  len(members.Members),
+		"learners": learners,
+	}
+	if e.MemberHealth {
+		fields["healthy_members"] = healthy
+	}
+	acc.AddFields("etcd_cluster", fields, map[string]string{"cluster_id": clusterID})
+}
+
+// gatherMetrics adds the fields in metricFields from the prometheus metrics
+// of an endpoint, values of metrics with labels are summed
+func (e *Etcd) gatherMetrics(ctx context.Context, endpoint string, fields map[string]interface{}) error {
+	body, err := e.get(ctx, endpoint+"/metrics")
+	if err != nil {
+		return err
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	for name, family := range families {
+		switch family.GetType() {
+		case dto.MetricType_HISTOGRAM:
+			sumField, sumOK := metricFields[name+"_sum"]
+			countField, countOK := metricFields[name+"_count"]
+			if !sumOK && !countOK {
+				continue
+			}
+			var sum float64
+			var count uint64
+			for _, m := range family.GetMetric() {
+				sum += m.GetHistogram().GetSampleSum()
+				count += m.GetHistogram().GetSampleCount()
+			}
+			if sumOK {
+				fields[sumField] = sum
+			}
+			if countOK {
+				fields[countField] = count
+			}
+		case dto.MetricType_COUNTER, dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			field, ok := metricFields[name]
+			if !ok {
+				continue
+			}
+			var value float64
+			for _, m := range family.GetMetric() {
+				switch {
+				case m.Counter != nil:
+					value += m.GetCounter().GetValue()
+				case m.Gauge != nil:
+					value += m.GetGauge().GetValue()
+				case m.Untyped != nil:
+					value += m.GetUntyped().GetValue()
+				}
+			}
+			fields[field] = value
+		}
+	}
+
+	return nil
+}
+
+func (e *Etcd) health(ctx context.Context, endpoint string) (bool, error) {
+	body, err := e.get(ctx, endpoint+"/health")
+	if err != nil {
+		return false, err
+	}
+	var health healthResponse
+	if err := json.Unmarshal(body, &health); err != nil {
+		return false, fmt.Errorf("parse: %w", err)
+	}
+	if health.Health != "true" && health.Reason != "" {
+		e.Log.Debugf("%s unhealthy: %s", endpoint, health.Reason)
+	}
+	return health.Health == "true", nil
+}
+
+func (e *Etcd) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	return e.do(req)
+}
+
+// post calls an etcd v3 grpc gateway endpoint
+func (e *Etcd) post(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader("{}"))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := e.do(req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+	return nil
+}
+
+func (e *Etcd) do(req *http.Request) ([]byte, error) {
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	// an unhealthy member answers /health with 503 and a json reason
+	if resp.StatusCode != http.StatusOK && !(resp.StatusCode == http.StatusServiceUnavailable && strings.HasSuffix(req.URL.Path, "/health")) {
+		return nil, fmt.Errorf("%s returned HTTP status %s", req.URL.Path, resp.Status)
+	}
+
+	return body, nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	inputs.Add("etcd", func() cua.Input {
+		return &Etcd{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleMetrics = `# HELP etcd_server_has_leader Whether or not a leader exists. 1 is existence, 0 is not.
+# TYPE etcd_server_has_leader gauge
+etcd_server_has_leader 1
+# HELP etcd_server_leader_changes_seen_total The number of leader changes seen.
+# TYPE etcd_server_leader_changes_seen_total counter
+etcd_server_leader_changes_seen_total 3
+# HELP etcd_server_proposals_failed_total The total number of failed proposals seen.
+# TYPE etcd_server_proposals_failed_total counter
+etcd_server_proposals_failed_total 2
+# HELP etcd_server_proposals_pending The current number of pending proposals to commit.
+# TYPE etcd_server_proposals_pending gauge
+etcd_server_proposals_pending 0
+# HELP etcd_mvcc_db_total_size_in_bytes Total size of the underlying database physically allocated in bytes.
+# TYPE etcd_mvcc_db_total_size_in_bytes gauge
+etcd_mvcc_db_total_size_in_bytes 65536
+# HELP etcd_network_client_grpc_sent_bytes_total The total number of bytes sent to grpc clients.
+# TYPE etcd_network_client_grpc_sent_bytes_total counter
+etcd_network_client_grpc_sent_bytes_total 100
+# HELP etcd_disk_wal_fsync_duration_seconds The latency distributions of fsync called by WAL.
+# TYPE etcd_disk_wal_fsync_duration_seconds histogram
+etcd_disk_wal_fsync_duration_seconds_bucket{le="0.001"} 8
+etcd_disk_wal_fsync_duration_seconds_bucket{le="+Inf"} 10
+etcd_disk_wal_fsync_duration_seconds_sum 0.5
+etcd_disk_wal_fsync_duration_seconds_count 10
+# HELP go_goroutines Number of goroutines that currently exist.
+# TYPE go_goroutines gauge
+go_goroutines 42
+`
+
+func newTestServer(t *testing.T, healthy bool) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			if !healthy {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprint(w, `{"health":"false","reason":"RAFT NO LEADER"}`)
+				return
+			}
+			fmt.Fprint(w, `{"health":"true","reason":""}`)
+		case "/metrics":
+			fmt.Fprint(w, sampleMetrics)
+		case "/v3/maintenance/status":
+			require.Equal(t, http.MethodPost, r.Method)
+			fmt.Fprint(w, `{"header":{"cluster_id":"14841639068965178418","member_id":"10276657743932975437","revision":"5","raft_term":"4"},`+
+				`"version":"3.4.13","dbSize":"20480","leader":"10276657743932975437","raftIndex":"42","raftTerm":"4",`+
+				`"raftAppliedIndex":"41","dbSizeInUse":"16384"}`)
+		case "/v3/cluster/member/list":
+			require.Equal(t, http.MethodPost, r.Method)
+			fmt.Fprintf(w, `{"header":{"cluster_id":"14841639068965178418","member_id":"10276657743932975437","raft_term":"4"},`+
+				`"members":[{"ID":"10276657743932975437","name":"etcd-0","clientURLs":[%q]},`+
+				`{"ID":"1","name":"etcd-1","clientURLs":["http://127.0.0.1:1"],"isLearner":true}]}`, ts.URL)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return ts
+}
+
+func TestInit(t *testing.T) {
+	e := &Etcd{}
+	require.Error(t, e.Init())
+
+	e = &Etcd{Endpoints: []string{"http://localhost:2379/"}}
+	require.NoError(t, e.Init())
+	require.Equal(t, []string{"http://localhost:2379"}, e.Endpoints)
+}
+
+func TestGather(t *testing.T) {
+	ts := newTestServer(t, true)
+	defer ts.Close()
+
+	e := &Etcd{
+		Log:          testutil.Logger{},
+		Endpoints:    []string{ts.URL},
+		MemberHealth: true,
+	}
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+
+	acc.AssertContainsTaggedFields(t, "etcd",
+		map[string]interface{}{
+			"healthy":            1,
+			"has_leader":         1.0,
+			"leader_changes":     3.0,
+			"proposals_failed":   2.0,
+			"proposals_pending":  0.0,
+			"grpc_sent_bytes":    100.0,
+			"wal_fsync_seconds":  0.5,
+			"wal_fsyncs":         uint64(10),
+			"is_leader":          1,
+			"is_learner":         0,
+			"raft_term":          uint64(4),
+			"raft_index":         uint64(42),
+			"raft_applied_index": uint64(41),
+			"db_size":            uint64(20480),
+			"db_size_in_use":     uint64(16384),
+			"errors":             0,
+		},
+		map[string]string{
+			"endpoint":   ts.URL,
+			"cluster_id": "cdf818194e3a8c32",
+			"member_id":  "8e9e05c52164694d",
+		})
+
+	acc.AssertContainsTaggedFields(t, "etcd_cluster",
+		map[string]interface{}{
+			"members":         2,
+			"learners":        1,
+			"healthy_members": 1,
+		},
+		map[string]string{"cluster_id": "cdf818194e3a8c32"})
+
+	acc.AssertContainsTaggedFields(t, "etcd_member",
+		map[string]interface{}{"healthy": 0, "is_learner": 1},
+		map[string]string{
+			"cluster_id": "cdf818194e3a8c32",
+			"member_id":  "1",
+			"member":     "etcd-1",
+		})
+}
+
+func TestGatherUnhealthy(t *testing.T) {
+	ts := newTestServer(t, false)
+	defer ts.Close()
+
+	e := &Etcd{
+		Log:       testutil.Logger{},
+		Endpoints: []string{ts.URL},
+	}
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+
+	healthy, ok := acc.IntField("etcd", "healthy")
+	require.True(t, ok)
+	require.Equal(t, 0, healthy)
+	require.False(t, acc.HasMeasurement("etcd_member"))
+}
+
+func TestGatherUnreachable(t *testing.T) {
+	ts := newTestServer(t, true)
+	ts.Close()
+
+	e := &Etcd{
+		Log:       testutil.Logger{},
+		Endpoints: []string{ts.URL},
+	}
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Gather(context.Background(), &acc))
+	require.NotEmpty(t, acc.Errors)
+	acc.AssertContainsTaggedFields(t, "etcd",
+		map[string]interface{}{"healthy": 0},
+		map[string]string{"endpoint": ts.URL})
+}
@@ -38,6 +38,14 @@ var metricsTargets = []struct {
 		target: "Currently banned:",
 		field:  "banned",
 	},
+	{
+		target: "Total failed:",
+		field:  "total_failed",
+	},
+	{
+		target: "Total banned:",
+		field:  "total_banned",
+	},
 }
 
 func (f *Fail2ban) Description() string {
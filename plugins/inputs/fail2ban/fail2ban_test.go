@@ -57,24 +57,30 @@ func TestGather(t *testing.T) {
 	}
 
 	fields1 := map[string]interface{}{
-		"banned": 2,
-		"failed": 0,
+		"banned":       2,
+		"failed":       0,
+		"total_failed": 5,
+		"total_banned": 50,
 	}
 	tags1 := map[string]string{
 		"jail": "sshd",
 	}
 
 	fields2 := map[string]interface{}{
-		"banned": 3,
-		"failed": 4,
+		"banned":       3,
+		"failed":       4,
+		"total_failed": 10,
+		"total_banned": 60,
 	}
 	tags2 := map[string]string{
 		"jail": "postfix",
 	}
 
 	fields3 := map[string]interface{}{
-		"banned": 0,
-		"failed": 11,
+		"banned":       0,
+		"failed":       11,
+		"total_failed": 22,
+		"total_banned": 100,
 	}
 	tags3 := map[string]string{
 		"jail": "dovecot",
@@ -0,0 +1,258 @@
+// Package fastly reports CDN edge statistics from the Fastly real-time
+// analytics API.
+package fastly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/internal/limiter"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const defaultURL = "https://rt.fastly.com"
+
+type Fastly struct {
+	Log       cua.Logger `toml:"-"`
+	cdn       cdn
+	URL       string            `toml:"url"`
+	APIToken  string            `toml:"api_token"`
+	Services  []string          `toml:"services"`
+	Timeout   internal.Duration `toml:"timeout"`
+	RateLimit int               `toml:"ratelimit"`
+	tls.ClientConfig
+}
+
+// cdn reads the per POP statistics of a service from a CDN real-time
+// analytics API. Other CDNs can be reported by implementing it and mapping
+// their statistics on to popStats.
+type cdn interface {
+	// stats returns the statistics of each POP serving the service since
+	// the previous call.
+	stats(ctx context.Context, service string) (map[string]*popStats, error)
+}
+
+// popStats are the request statistics of a POP, the json names are those
+// of the Fastly real-time API.
+type popStats struct {
+	Requests  uint64 `json:"requests"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"miss"`
+	Pass      uint64 `json:"pass"`
+	Errors    uint64 `json:"errors"`
+	Bandwidth uint64 `json:"bandwidth"`
+	Status2xx uint64 `json:"status_2xx"`
+	Status3xx uint64 `json:"status_3xx"`
+	Status4xx uint64 `json:"status_4xx"`
+	Status5xx uint64 `json:"status_5xx"`
+}
+
+func (p *popStats) add(o *popStats) {
+	p.Requests += o.Requests
+	p.Hits += o.Hits
+	p.Misses += o.Misses
+	p.Pass += o.Pass
+	p.Errors += o.Errors
+	p.Bandwidth += o.Bandwidth
+	p.Status2xx += o.Status2xx
+	p.Status3xx += o.Status3xx
+	p.Status4xx += o.Status4xx
+	p.Status5xx += o.Status5xx
+}
+
+func (p *popStats) fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"requests":   p.Requests,
+		"hits":       p.Hits,
+		"misses":     p.Misses,
+		"pass":       p.Pass,
+		"errors":     p.Errors,
+		"bandwidth":  p.Bandwidth,
+		"status_2xx": p.Status2xx,
+		"status_3xx": p.Status3xx,
+		"status_4xx": p.Status4xx,
+		"status_5xx": p.Status5xx,
+	}
+	if lookups := p.Hits + p.Misses; lookups > 0 {
+		fields["hit_ratio"] = float64(p.Hits) / float64(lookups) * 100
+	}
+	return fields
+}
+
+const sampleConfig = `
+  ## Fastly API token with the global:read scope
+  api_token = ""
+
+  ## Service IDs to report
+  services = []
+
+  ## Real-time analytics API url
+  # url = "https://rt.fastly.com"
+
+  ## Maximum number of API requests per second
+  # ratelimit = 10
+
+  ## Timeout for API requests, the real-time API holds a request open for
+  ## up to a second while it waits for new data.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (f *Fastly) Description() string {
+	return "Read per POP request, cache and bandwidth statistics from the Fastly real-time analytics API"
+}
+
+func (f *Fastly) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *Fastly) Init() error {
+	if f.APIToken == "" {
+		return errors.New("api_token is required")
+	}
+	if len(f.Services) == 0 {
+		return errors.New("no services configured")
+	}
+	if f.RateLimit < 1 {
+		return fmt.Errorf("invalid ratelimit %d", f.RateLimit)
+	}
+
+	tlsConfig, err := f.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	f.cdn = &fastlyAPI{
+		url:   strings.TrimSuffix(f.URL, "/"),
+		token: f.APIToken,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+			Timeout: f.Timeout.Duration,
+		},
+		timestamps: make(map[string]int64),
+	}
+
+	return nil
+}
+
+func (f *Fastly) Gather(ctx context.Context, acc cua.Accumulator) error {
+	lmtr := limiter.NewRateLimiter(f.RateLimit, time.Second)
+	defer lmtr.Stop()
+
+	var wg sync.WaitGroup
+	for _, service := range f.Services {
+		wg.Add(1)
+		<-lmtr.C
+		go func(service string) {
+			defer wg.Done()
+			stats, err := f.cdn.stats(ctx, service)
+			if err != nil {
+				acc.AddError(fmt.Errorf("service %s: %w", service, err))
+				return
+			}
+			now := time.Now()
+			for pop, s := range stats {
+				tags := map[string]string{"service": service, "pop": pop}
+				acc.AddFields("fastly", s.fields(), tags, now)
+			}
+		}(service)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// fastlyAPI reads the Fastly real-time analytics API, each response holds
+// one entry per second since the timestamp of the previous response.
+type fastlyAPI struct {
+	client     *http.Client
+	timestamps map[string]int64
+	url        string
+	token      string
+	mu         sync.Mutex
+}
+
+type fastlyResponse struct {
+	Error string `json:"Error"`
+	Data  []struct {
+		Datacenter map[string]*popStats `json:"datacenter"`
+	} `json:"Data"`
+	Timestamp int64 `json:"Timestamp"`
+}
+
+func (a *fastlyAPI) stats(ctx context.Context, service string) (map[string]*popStats, error) {
+	a.mu.Lock()
+	ts := a.timestamps[service]
+	a.mu.Unlock()
+
+	addr := a.url + "/v1/channel/" + service + "/ts/" + strconv.FormatInt(ts, 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Fastly-Key", a.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", addr, err)
+	}
+	defer resp.Body.Close()
+
+	var r fastlyResponse
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if json.Unmarshal(body, &r) == nil && r.Error != "" {
+			return nil, fmt.Errorf("get %s: %s: %s", addr, resp.Status, r.Error)
+		}
+		return nil, fmt.Errorf("get %s: %s", addr, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	a.mu.Lock()
+	a.timestamps[service] = r.Timestamp
+	a.mu.Unlock()
+
+	stats := make(map[string]*popStats)
+	for _, d := range r.Data {
+		for pop, s := range d.Datacenter {
+			if stats[pop] == nil {
+				stats[pop] = &popStats{}
+			}
+			stats[pop].add(s)
+		}
+	}
+
+	return stats, nil
+}
+
+func init() {
+	inputs.Add("fastly", func() cua.Input {
+		return &Fastly{
+			URL:       defaultURL,
+			RateLimit: 10,
+			Timeout:   internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package fastly
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const realtimeResponse = `
+{
+  "Timestamp": 1622470000,
+  "AggregateDelay": 5,
+  "Data": [
+    {
+      "datacenter": {
+        "SJC": {"requests": 100, "hits": 75, "miss": 20, "pass": 5, "errors": 1, "bandwidth": 204800, "status_2xx": 90, "status_3xx": 5, "status_4xx": 4, "status_5xx": 1},
+        "LHR": {"requests": 10, "hits": 0, "miss": 0, "pass": 10, "bandwidth": 1024, "status_2xx": 10}
+      },
+      "aggregated": {"requests": 110},
+      "recorded": 1622469998
+    },
+    {
+      "datacenter": {
+        "SJC": {"requests": 50, "hits": 45, "miss": 5, "bandwidth": 102400, "status_2xx": 50}
+      },
+      "aggregated": {"requests": 50},
+      "recorded": 1622469999
+    }
+  ]
+}`
+
+func TestGather(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("Fastly-Key"))
+		paths = append(paths, r.URL.Path)
+		fmt.Fprint(w, realtimeResponse)
+	}))
+	defer ts.Close()
+
+	f := &Fastly{
+		Log:       testutil.Logger{},
+		URL:       ts.URL,
+		APIToken:  "secret",
+		Services:  []string{"svc1"},
+		RateLimit: 10,
+	}
+	require.NoError(t, f.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "fastly",
+		map[string]interface{}{
+			"requests":   uint64(150),
+			"hits":       uint64(120),
+			"misses":     uint64(25),
+			"pass":       uint64(5),
+			"errors":     uint64(1),
+			"bandwidth":  uint64(307200),
+			"status_2xx": uint64(140),
+			"status_3xx": uint64(5),
+			"status_4xx": uint64(4),
+			"status_5xx": uint64(1),
+			"hit_ratio":  float64(120) / float64(145) * 100,
+		},
+		map[string]string{"service": "svc1", "pop": "SJC"})
+
+	// no cache lookups, no hit ratio
+	acc.AssertContainsTaggedFields(t, "fastly",
+		map[string]interface{}{
+			"requests":   uint64(10),
+			"hits":       uint64(0),
+			"misses":     uint64(0),
+			"pass":       uint64(10),
+			"errors":     uint64(0),
+			"bandwidth":  uint64(1024),
+			"status_2xx": uint64(10),
+			"status_3xx": uint64(0),
+			"status_4xx": uint64(0),
+			"status_5xx": uint64(0),
+		},
+		map[string]string{"service": "svc1", "pop": "LHR"})
+
+	// the next request continues from the timestamp of the previous response
+	require.NoError(t, f.Gather(context.Background(), &acc))
+	require.Equal(t, []string{"/v1/channel/svc1/ts/0", "/v1/channel/svc1/ts/1622470000"}, paths)
+}
+
+func TestGatherError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"Error": "Authentication failed"}`)
+	}))
+	defer ts.Close()
+
+	f := &Fastly{
+		Log:       testutil.Logger{},
+		URL:       ts.URL,
+		APIToken:  "bad",
+		Services:  []string{"svc1"},
+		RateLimit: 10,
+	}
+	require.NoError(t, f.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "Authentication failed")
+	require.Empty(t, acc.Metrics)
+}
+
+func TestInit(t *testing.T) {
+	f := &Fastly{Services: []string{"svc1"}, RateLimit: 10}
+	require.Error(t, f.Init())
+
+	f = &Fastly{APIToken: "secret", RateLimit: 10}
+	require.Error(t, f.Init())
+
+	f = &Fastly{APIToken: "secret", Services: []string{"svc1"}}
+	require.Error(t, f.Init())
+}
@@ -0,0 +1,439 @@
+// Package flowcollector implements a service input which receives sFlow v5,
+// NetFlow v9 and IPFIX datagrams and aggregates the flows per exporter and
+// interface.
+package flowcollector
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sflow"
+)
+
+const sampleConfig = `
+  ## Addresses to listen for flow datagrams, the protocol of a datagram is
+  ## detected from its version so sFlow v5, NetFlow v9 and IPFIX exporters
+  ## can share an address.
+  ##   example: service_addresses = ["udp://:2055", "udp://:4739", "udp://:6343"]
+  service_addresses = ["udp://:2055", "udp://:6343"]
+
+  ## Set the size of the operating system's receive buffer.
+  ##   example: read_buffer_size = "64KiB"
+  # read_buffer_size = ""
+
+  ## Sampling rate of the NetFlow v9 and IPFIX exporters which do not send
+  ## it in the flow or options records, the counters are multiplied by it.
+  # default_sampling_rate = 1
+
+  ## Number of top talkers, the source and destination addresses with the
+  ## most bytes, reported per exporter for each interval. 0 disables them.
+  # top_talkers = 0
+`
+
+const (
+	maxPacketSize = 64 * 1024
+
+	protocolSFlow     = "sflow"
+	protocolNetFlowV9 = "netflow_v9"
+	protocolIPFIX     = "ipfix"
+)
+
+// FlowCollector receives flow datagrams from the exporters
+type FlowCollector struct {
+	ServiceAddresses    []string      `toml:"service_addresses"`
+	ReadBufferSize      internal.Size `toml:"read_buffer_size"`
+	DefaultSamplingRate uint64        `toml:"default_sampling_rate"`
+	TopTalkers          int           `toml:"top_talkers"`
+
+	Log cua.Logger `toml:"-"`
+
+	sync.Mutex
+	sflow      *sflow.PacketDecoder
+	netflow    *netflowDecoder
+	interfaces map[interfaceKey]*counters
+	talkers    map[talkerKey]*counters
+	exporters  map[exporterKey]*exporterStats
+
+	conns []*net.UDPConn
+	wg    sync.WaitGroup
+}
+
+// flow is a decoded flow record or sample, the counters are corrected with
+// the sampling rate
+type flow struct {
+	inIf    uint32
+	outIf   uint32
+	src     net.IP
+	dst     net.IP
+	bytes   uint64
+	packets uint64
+}
+
+type counters struct {
+	bytes   uint64
+	packets uint64
+	flows   uint64
+}
+
+type interfaceKey struct {
+	exporter  string
+	ifIndex   uint32
+	direction string
+}
+
+type talkerKey struct {
+	exporter string
+	side     string
+	address  string
+}
+
+type exporterKey struct {
+	exporter string
+	protocol string
+}
+
+type exporterStats struct {
+	datagrams       uint64
+	decodeErrors    uint64
+	unknownTemplate uint64
+}
+
+func (*FlowCollector) Description() string {
+	return "Aggregate sFlow v5, NetFlow v9 and IPFIX flows per exporter and interface"
+}
+
+func (*FlowCollector) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *FlowCollector) Init() error {
+	if len(f.ServiceAddresses) == 0 {
+		return fmt.Errorf("no service_addresses configured")
+	}
+	if f.DefaultSamplingRate == 0 {
+		f.DefaultSamplingRate = 1
+	}
+	if f.TopTalkers < 0 {
+		return fmt.Errorf("invalid top_talkers %d", f.TopTalkers)
+	}
+
+	f.sflow = sflow.NewDecoder()
+	f.sflow.Log = f.Log
+	f.netflow = newNetflowDecoder(f.DefaultSamplingRate)
+	f.interfaces = make(map[interfaceKey]*counters)
+	f.talkers = make(map[talkerKey]*counters)
+	f.exporters = make(map[exporterKey]*exporterStats)
+	return nil
+}
+
+func (f *FlowCollector) Start(_ context.Context, acc cua.Accumulator) error {
+	for _, address := range f.ServiceAddresses {
+		u, err := url.Parse(address)
+		if err != nil {
+			f.Stop()
+			return fmt.Errorf("url parse (%s): %w", address, err)
+		}
+
+		conn, err := listenUDP(u.Scheme, u.Host)
+		if err != nil {
+			f.Stop()
+			return err
+		}
+		if f.ReadBufferSize.Size > 0 {
+			_ = conn.SetReadBuffer(int(f.ReadBufferSize.Size))
+		}
+		f.conns = append(f.conns, conn)
+
+		f.Log.Infof("Listening on %s://%s", conn.LocalAddr().Network(), conn.LocalAddr().String())
+
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.read(acc, conn)
+		}()
+	}
+	return nil
+}
+
+func (f *FlowCollector) Stop() {
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+	f.wg.Wait()
+	f.conns = nil
+}
+
+// Addresses returns the local addresses of the listeners
+func (f *FlowCollector) Addresses() []net.Addr {
+	addrs := make([]net.Addr, 0, len(f.conns))
+	for _, conn := range f.conns {
+		addrs = append(addrs, conn.LocalAddr())
+	}
+	return addrs
+}
+
+func (f *FlowCollector) read(acc cua.Accumulator, conn *net.UDPConn) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+				acc.AddError(err)
+			}
+			break
+		}
+		f.process(addr.IP, buf[:n])
+	}
+}
+
+// process decodes a datagram and adds its flows to the counters, the
+// decode errors are counted per exporter instead of being reported for
+// every datagram
+func (f *FlowCollector) process(source net.IP, buf []byte) {
+	f.Lock()
+	defer f.Unlock()
+
+	exporter := source.String()
+
+	var protocol string
+	var flows []flow
+	var unknown int
+	var err error
+	switch {
+	case len(buf) >= 4 && binary.BigEndian.Uint32(buf) == 5:
+		protocol = protocolSFlow
+		exporter, flows, err = f.decodeSFlow(exporter, buf)
+	case len(buf) >= 2 && binary.BigEndian.Uint16(buf) == 9:
+		protocol = protocolNetFlowV9
+		flows, unknown, err = f.netflow.decode(exporter, buf)
+	case len(buf) >= 2 && binary.BigEndian.Uint16(buf) == 10:
+		protocol = protocolIPFIX
+		flows, unknown, err = f.netflow.decode(exporter, buf)
+	default:
+		f.Log.Debugf("Unsupported datagram from %s", exporter)
+		return
+	}
+
+	key := exporterKey{exporter: exporter, protocol: protocol}
+	stats, ok := f.exporters[key]
+	if !ok {
+		stats = &exporterStats{}
+		f.exporters[key] = stats
+	}
+	stats.datagrams++
+	stats.unknownTemplate += uint64(unknown)
+	if err != nil {
+		stats.decodeErrors++
+		f.Log.Debugf("Decoding %s datagram from %s: %s", protocol, exporter, err)
+	}
+
+	for _, fl := range flows {
+		f.add(exporter, fl)
+	}
+}
+
+// decodeSFlow returns the flow samples of an sFlow datagram, the exporter is
+// the agent address of the datagram when it is set
+func (f *FlowCollector) decodeSFlow(exporter string, buf []byte) (string, []flow, error) {
+	p, err := f.sflow.DecodeOnePacket(bytes.NewBuffer(buf))
+	if p == nil {
+		return exporter, nil, err
+	}
+	if len(p.AgentAddress.IP) > 0 && !p.AgentAddress.IP.IsUnspecified() {
+		exporter = p.AgentAddress.IP.String()
+	}
+
+	var flows []flow
+	for _, s := range p.Samples {
+		sd := s.SampleData
+		for _, r := range sd.FlowRecords {
+			h, ok := r.FlowData.(sflow.RawPacketHeaderFlowData)
+			if !ok {
+				continue
+			}
+			fl := flow{
+				bytes:   uint64(h.FrameLength) * uint64(sd.SamplingRate),
+				packets: uint64(sd.SamplingRate),
+			}
+			// the formats other than 0 are discarded packets and multiple
+			// interfaces instead of an interface index
+			if sd.InputIfFormat == 0 {
+				fl.inIf = sd.InputIfIndex
+			}
+			if sd.OutputIfFormat == 0 {
+				fl.outIf = sd.OutputIfIndex
+			}
+			if eth, ok := h.Header.(sflow.EthHeader); ok {
+				switch ip := eth.IPHeader.(type) {
+				case sflow.IPV4Header:
+					fl.src, fl.dst = net.IP(ip.SourceIP[:]), net.IP(ip.DestIP[:])
+				case sflow.IPV6Header:
+					fl.src, fl.dst = net.IP(ip.SourceIP[:]), net.IP(ip.DestIP[:])
+				}
+			}
+			flows = append(flows, fl)
+		}
+	}
+	return exporter, flows, err
+}
+
+func (f *FlowCollector) add(exporter string, fl flow) {
+	if fl.inIf != 0 {
+		f.interfaceCounters(interfaceKey{exporter: exporter, ifIndex: fl.inIf, direction: "in"}).add(fl)
+	}
+	if fl.outIf != 0 {
+		f.interfaceCounters(interfaceKey{exporter: exporter, ifIndex: fl.outIf, direction: "out"}).add(fl)
+	}
+
+	if f.TopTalkers == 0 {
+		return
+	}
+	if fl.src != nil {
+		f.talkerCounters(talkerKey{exporter: exporter, side: "src", address: fl.src.String()}).add(fl)
+	}
+	if fl.dst != nil {
+		f.talkerCounters(talkerKey{exporter: exporter, side: "dst", address: fl.dst.String()}).add(fl)
+	}
+}
+
+func (f *FlowCollector) interfaceCounters(key interfaceKey) *counters {
+	c, ok := f.interfaces[key]
+	if !ok {
+		c = &counters{}
+		f.interfaces[key] = c
+	}
+	return c
+}
+
+func (f *FlowCollector) talkerCounters(key talkerKey) *counters {
+	c, ok := f.talkers[key]
+	if !ok {
+		c = &counters{}
+		f.talkers[key] = c
+	}
+	return c
+}
+
+func (c *counters) add(fl flow) {
+	c.bytes += fl.bytes
+	c.packets += fl.packets
+	c.flows++
+}
+
+// Gather reports the interface counters since the start and the top talkers
+// since the last gather
+func (f *FlowCollector) Gather(_ context.Context, acc cua.Accumulator) error {
+	f.Lock()
+	defer f.Unlock()
+
+	now := time.Now()
+	for key, stats := range f.exporters {
+		acc.AddCounter("flow_collector", map[string]interface{}{
+			"datagrams":                stats.datagrams,
+			"decode_errors":            stats.decodeErrors,
+			"unknown_template_records": stats.unknownTemplate,
+		}, map[string]string{
+			"exporter": key.exporter,
+			"protocol": key.protocol,
+		}, now)
+	}
+
+	for key, c := range f.interfaces {
+		acc.AddCounter("flow_interface", map[string]interface{}{
+			"bytes":   c.bytes,
+			"packets": c.packets,
+			"flows":   c.flows,
+		}, map[string]string{
+			"exporter":  key.exporter,
+			"ifindex":   strconv.FormatUint(uint64(key.ifIndex), 10),
+			"direction": key.direction,
+		}, now)
+	}
+
+	f.gatherTalkers(acc, now)
+	return nil
+}
+
+func (f *FlowCollector) gatherTalkers(acc cua.Accumulator, now time.Time) {
+	if f.TopTalkers == 0 {
+		return
+	}
+
+	type talker struct {
+		address string
+		*counters
+	}
+	type group struct {
+		exporter string
+		side     string
+	}
+	groups := make(map[group][]talker)
+	for key, c := range f.talkers {
+		g := group{exporter: key.exporter, side: key.side}
+		groups[g] = append(groups[g], talker{address: key.address, counters: c})
+	}
+
+	for g, talkers := range groups {
+		sort.Slice(talkers, func(i, j int) bool {
+			if talkers[i].bytes == talkers[j].bytes {
+				return talkers[i].address < talkers[j].address
+			}
+			return talkers[i].bytes > talkers[j].bytes
+		})
+		if len(talkers) > f.TopTalkers {
+			talkers = talkers[:f.TopTalkers]
+		}
+		for i, t := range talkers {
+			acc.AddGauge("flow_talker", map[string]interface{}{
+				"bytes":   t.bytes,
+				"packets": t.packets,
+				"flows":   t.flows,
+				"rank":    i + 1,
+			}, map[string]string{
+				"exporter": g.exporter,
+				"side":     g.side,
+				"address":  t.address,
+			}, now)
+		}
+	}
+
+	f.talkers = make(map[talkerKey]*counters)
+}
+
+func listenUDP(network string, address string) (*net.UDPConn, error) {
+	switch network {
+	case "udp", "udp4", "udp6":
+		addr, err := net.ResolveUDPAddr(network, address)
+		if err != nil {
+			return nil, fmt.Errorf("resolve udp addr (%s): %w", address, err)
+		}
+		conn, err := net.ListenUDP(network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("listen udp (%s): %w", address, err)
+		}
+		return conn, nil
+	default:
+		return nil, fmt.Errorf("unsupported network type: %s", network)
+	}
+}
+
+func init() {
+	inputs.Add("flow_collector", func() cua.Input {
+		return &FlowCollector{
+			ServiceAddresses:    []string{"udp://:2055", "udp://:6343"},
+			DefaultSamplingRate: 1,
+		}
+	})
+}
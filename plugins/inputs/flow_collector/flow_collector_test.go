@@ -0,0 +1,266 @@
+package flowcollector
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// sflowPacket is a datagram of agent 192.168.1.2 with two flow samples, the
+// sampling rates are 1024 and 16384
+const sflowPacket = "0000000500000001c0a80102000000100000f3d40bfa047f0000000200000001000000d00001210a000001fe000004000484240000000000000001fe00000200000000020000000100000090000000010000010b0000000400000080000c2936d3d694c691aa97600800450000f9f19040004011b4f5c0a80913c0a8090a00a1ba0500e5641f3081da02010104066d6f746f6770a281cc02047b46462e0201000201003081bd3012060d2b06010201190501010281dc710201003013060d2b06010201190501010281e66802025acc3012060d2b0601020119050101000003e9000000100000000900000000000000090000000000000001000000d00000e3cc000002100000400048eb740000000000000002100000020000000002000000010000009000000001000000970000000400000080000c2936d3d6fcecda44008f81000009080045000081186440003f119098c0a80815c0a8090a9a690202006d23083c33303e4170722031312030393a33333a3031206b6e6f64653120736e6d70645b313039385d3a20436f6e6e656374696f6e2066726f6d205544503a205b3139322e3136382e392e31305d3a34393233362d000003e90000001000000009000000000000000900000000"
+
+type builder struct {
+	b []byte
+}
+
+func (b *builder) u8(v uint8) *builder {
+	b.b = append(b.b, v)
+	return b
+}
+
+func (b *builder) u16(v uint16) *builder {
+	b.b = append(b.b, byte(v>>8), byte(v))
+	return b
+}
+
+func (b *builder) u32(v uint32) *builder {
+	return b.u16(uint16(v >> 16)).u16(uint16(v))
+}
+
+func (b *builder) ip(s string) *builder {
+	ip := net.ParseIP(s)
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	b.b = append(b.b, ip...)
+	return b
+}
+
+// set appends a set with its header, the length includes the header
+func (b *builder) set(id uint16, body *builder) *builder {
+	return b.u16(id).u16(uint16(len(body.b) + 4)).bytes(body.b)
+}
+
+func (b *builder) bytes(v []byte) *builder {
+	b.b = append(b.b, v...)
+	return b
+}
+
+// netflowV9 returns a datagram of source ID 1 with a template and two
+// records of template 256
+func netflowV9(withTemplate bool) []byte {
+	b := &builder{}
+	b.u16(9).u16(2).u32(1000).u32(1600000000).u32(1).u32(1)
+	if withTemplate {
+		t := &builder{}
+		t.u16(256).u16(6).
+			u16(ieSourceIPv4Address).u16(4).
+			u16(ieDestinationIPv4Addr).u16(4).
+			u16(ieIngressInterface).u16(2).
+			u16(ieEgressInterface).u16(2).
+			u16(ieOctetDeltaCount).u16(4).
+			u16(iePacketDeltaCount).u16(4)
+		b.set(netflowTemplateSet, t)
+	}
+	d := &builder{}
+	d.ip("10.0.0.1").ip("10.0.0.2").u16(3).u16(4).u32(1500).u32(10)
+	d.ip("10.0.0.3").ip("10.0.0.2").u16(3).u16(4).u32(500).u32(5)
+	d.u16(0) // padding
+	b.set(256, d)
+	return b.b
+}
+
+// ipfix returns a message of observation domain 7 with an options template
+// reporting a sampling interval of 100, a template with an enterprise and a
+// variable length element and a record
+func ipfix() []byte {
+	sets := &builder{}
+
+	ot := &builder{}
+	ot.u16(300).u16(2).u16(1).
+		u16(149).u16(4). // observationDomainId scope
+		u16(ieSamplingPktInterval).u16(4)
+	sets.set(ipfixOptionsTemplateSet, ot)
+	sets.set(300, (&builder{}).u32(7).u32(100))
+
+	t := &builder{}
+	t.u16(400).u16(5).
+		u16(ieSourceIPv6Address).u16(16).
+		u16(ieDestinationIPv6Addr).u16(16).
+		u16(0x8000 | 1000).u16(2).u32(9). // enterprise element
+		u16(82).u16(variableLength).      // interfaceName
+		u16(ieOctetDeltaCount).u16(8)
+	sets.set(ipfixTemplateSet, t)
+	d := &builder{}
+	d.ip("2001:db8::1").ip("2001:db8::2").u16(0xffff).u8(4).bytes([]byte("eth0"))
+	d.u32(0).u32(64)
+	sets.set(400, d)
+
+	b := &builder{}
+	b.u16(10).u16(uint16(16 + len(sets.b))).u32(1600000000).u32(1).u32(7)
+	return b.bytes(sets.b).b
+}
+
+func newCollector(t *testing.T, topTalkers int) *FlowCollector {
+	f := &FlowCollector{
+		ServiceAddresses: []string{"udp://127.0.0.1:0"},
+		TopTalkers:       topTalkers,
+		Log:              testutil.Logger{},
+	}
+	require.NoError(t, f.Init())
+	return f
+}
+
+func TestNetFlowV9(t *testing.T) {
+	f := newCollector(t, 0)
+	exporter := net.ParseIP("192.0.2.1")
+
+	// the records of a template which has not been received yet are dropped
+	f.process(exporter, netflowV9(false))
+	f.process(exporter, netflowV9(true))
+	f.process(exporter, netflowV9(false))
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(context.Background(), &acc))
+
+	expected := []cua.Metric{
+		testutil.MustMetric("flow_collector",
+			map[string]string{"exporter": "192.0.2.1", "protocol": "netflow_v9"},
+			map[string]interface{}{"datagrams": uint64(3), "decode_errors": uint64(0), "unknown_template_records": uint64(1)},
+			time.Unix(0, 0), cua.Counter),
+		testutil.MustMetric("flow_interface",
+			map[string]string{"exporter": "192.0.2.1", "ifindex": "3", "direction": "in"},
+			map[string]interface{}{"bytes": uint64(4000), "packets": uint64(30), "flows": uint64(4)},
+			time.Unix(0, 0), cua.Counter),
+		testutil.MustMetric("flow_interface",
+			map[string]string{"exporter": "192.0.2.1", "ifindex": "4", "direction": "out"},
+			map[string]interface{}{"bytes": uint64(4000), "packets": uint64(30), "flows": uint64(4)},
+			time.Unix(0, 0), cua.Counter),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+}
+
+func TestIPFIXSamplingRate(t *testing.T) {
+	f := newCollector(t, 1)
+	f.process(net.ParseIP("192.0.2.2"), ipfix())
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("flow_collector",
+			map[string]string{"exporter": "192.0.2.2", "protocol": "ipfix"},
+			map[string]interface{}{"datagrams": uint64(1), "decode_errors": uint64(0), "unknown_template_records": uint64(0)},
+			time.Unix(0, 0), cua.Counter),
+		testutil.MustMetric("flow_talker",
+			map[string]string{"exporter": "192.0.2.2", "side": "src", "address": "2001:db8::1"},
+			map[string]interface{}{"bytes": uint64(6400), "packets": uint64(0), "flows": uint64(1), "rank": 1},
+			time.Unix(0, 0), cua.Gauge),
+		testutil.MustMetric("flow_talker",
+			map[string]string{"exporter": "192.0.2.2", "side": "dst", "address": "2001:db8::2"},
+			map[string]interface{}{"bytes": uint64(6400), "packets": uint64(0), "flows": uint64(1), "rank": 1},
+			time.Unix(0, 0), cua.Gauge),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+}
+
+func TestTopTalkers(t *testing.T) {
+	f := newCollector(t, 1)
+	exporter := net.ParseIP("192.0.2.1")
+	f.process(exporter, netflowV9(true))
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(context.Background(), &acc))
+
+	var talkers []cua.Metric
+	for _, m := range acc.GetCUAMetrics() {
+		if m.Name() == "flow_talker" {
+			talkers = append(talkers, m)
+		}
+	}
+	expected := []cua.Metric{
+		testutil.MustMetric("flow_talker",
+			map[string]string{"exporter": "192.0.2.1", "side": "src", "address": "10.0.0.1"},
+			map[string]interface{}{"bytes": uint64(1500), "packets": uint64(10), "flows": uint64(1), "rank": 1},
+			time.Unix(0, 0), cua.Gauge),
+		testutil.MustMetric("flow_talker",
+			map[string]string{"exporter": "192.0.2.1", "side": "dst", "address": "10.0.0.2"},
+			map[string]interface{}{"bytes": uint64(2000), "packets": uint64(15), "flows": uint64(2), "rank": 1},
+			time.Unix(0, 0), cua.Gauge),
+	}
+	testutil.RequireMetricsEqual(t, expected, talkers, testutil.SortMetrics(), testutil.IgnoreTime())
+
+	// the talkers are reset after each gather
+	acc.ClearMetrics()
+	require.NoError(t, f.Gather(context.Background(), &acc))
+	for _, m := range acc.GetCUAMetrics() {
+		require.NotEqual(t, "flow_talker", m.Name())
+	}
+}
+
+func TestSFlow(t *testing.T) {
+	f := newCollector(t, 0)
+	require.NoError(t, f.Start(context.Background(), &testutil.Accumulator{}))
+	defer f.Stop()
+
+	addr := f.Addresses()[0]
+	client, err := net.Dial(addr.Network(), addr.String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	packet, err := hex.DecodeString(sflowPacket)
+	require.NoError(t, err)
+	_, err = client.Write(packet)
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	require.Eventually(t, func() bool {
+		acc.ClearMetrics()
+		require.NoError(t, f.Gather(context.Background(), &acc))
+		return len(acc.GetCUAMetrics()) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("flow_collector",
+			map[string]string{"exporter": "192.168.1.2", "protocol": "sflow"},
+			map[string]interface{}{"datagrams": uint64(1), "decode_errors": uint64(0), "unknown_template_records": uint64(0)},
+			time.Unix(0, 0), cua.Counter),
+		testutil.MustMetric("flow_interface",
+			map[string]string{"exporter": "192.168.1.2", "ifindex": "510", "direction": "in"},
+			map[string]interface{}{"bytes": uint64(273408), "packets": uint64(1024), "flows": uint64(1)},
+			time.Unix(0, 0), cua.Counter),
+		testutil.MustMetric("flow_interface",
+			map[string]string{"exporter": "192.168.1.2", "ifindex": "528", "direction": "in"},
+			map[string]interface{}{"bytes": uint64(2473984), "packets": uint64(16384), "flows": uint64(1)},
+			time.Unix(0, 0), cua.Counter),
+		testutil.MustMetric("flow_interface",
+			map[string]string{"exporter": "192.168.1.2", "ifindex": "512", "direction": "out"},
+			map[string]interface{}{"bytes": uint64(2747392), "packets": uint64(17408), "flows": uint64(2)},
+			time.Unix(0, 0), cua.Counter),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+}
+
+func TestInvalidDatagram(t *testing.T) {
+	f := newCollector(t, 0)
+	exporter := net.ParseIP("192.0.2.1")
+
+	b := netflowV9(true)
+	f.process(exporter, b[:len(b)-10])
+	f.process(exporter, []byte{0, 1, 2})
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(context.Background(), &acc))
+	stats, ok := acc.Get("flow_collector")
+	require.True(t, ok)
+	require.Equal(t, uint64(1), stats.Fields["datagrams"])
+	require.Equal(t, uint64(1), stats.Fields["decode_errors"])
+}
@@ -0,0 +1,314 @@
+package flowcollector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// set IDs of the templates, the IDs of the data sets are the template IDs
+// starting from 256
+const (
+	netflowTemplateSet        = 0
+	netflowOptionsTemplateSet = 1
+	ipfixTemplateSet          = 2
+	ipfixOptionsTemplateSet   = 3
+	minDataSet                = 256
+)
+
+// information elements of the flow records, the IDs of NetFlow v9 and IPFIX
+// are the same for these elements
+const (
+	ieOctetDeltaCount       = 1
+	iePacketDeltaCount      = 2
+	ieSourceIPv4Address     = 8
+	ieIngressInterface      = 10
+	ieDestinationIPv4Addr   = 12
+	ieEgressInterface       = 14
+	ieSourceIPv6Address     = 27
+	ieDestinationIPv6Addr   = 28
+	ieSamplingInterval      = 34
+	ieSamplerRandomInterval = 50
+	ieSamplingPktInterval   = 305
+)
+
+// variableLength is the field length of the IPFIX variable length elements
+const variableLength = 65535
+
+var errShort = errors.New("datagram too short")
+
+type templateField struct {
+	id uint16
+	// enterprise is non zero for the enterprise specific elements, these
+	// are skipped
+	enterprise uint32
+	length     uint16
+}
+
+type template struct {
+	fields []templateField
+	// scope is the number of scope fields of an options template
+	scope   int
+	options bool
+}
+
+// templateKey identifies a template, the template IDs are unique per
+// exporter and observation domain (source ID of NetFlow v9)
+type templateKey struct {
+	exporter string
+	domain   uint32
+	id       uint16
+}
+
+type domainKey struct {
+	exporter string
+	domain   uint32
+}
+
+// netflowDecoder decodes NetFlow v9 and IPFIX datagrams, the templates and
+// the sampling intervals of the options records are kept between the
+// datagrams
+type netflowDecoder struct {
+	templates     map[templateKey]template
+	samplingRates map[domainKey]uint64
+	defaultRate   uint64
+}
+
+func newNetflowDecoder(defaultRate uint64) *netflowDecoder {
+	return &netflowDecoder{
+		templates:     make(map[templateKey]template),
+		samplingRates: make(map[domainKey]uint64),
+		defaultRate:   defaultRate,
+	}
+}
+
+// decode returns the flows of a NetFlow v9 or IPFIX datagram and the number
+// of records which could not be decoded because their template is unknown
+func (d *netflowDecoder) decode(exporter string, buf []byte) ([]flow, int, error) {
+	if len(buf) < 2 {
+		return nil, 0, errShort
+	}
+
+	var domain uint32
+	var sets []byte
+	ipfix := false
+	switch version := binary.BigEndian.Uint16(buf); version {
+	case 9:
+		if len(buf) < 20 {
+			return nil, 0, errShort
+		}
+		domain = binary.BigEndian.Uint32(buf[16:])
+		sets = buf[20:]
+	case 10:
+		if len(buf) < 16 {
+			return nil, 0, errShort
+		}
+		length := int(binary.BigEndian.Uint16(buf[2:]))
+		if length < 16 || length > len(buf) {
+			return nil, 0, fmt.Errorf("invalid message length %d", length)
+		}
+		domain = binary.BigEndian.Uint32(buf[12:])
+		sets = buf[16:length]
+		ipfix = true
+	default:
+		return nil, 0, fmt.Errorf("version %d not supported", version)
+	}
+
+	var flows []flow
+	unknown := 0
+	for len(sets) > 0 {
+		if len(sets) < 4 {
+			return flows, unknown, errShort
+		}
+		id := binary.BigEndian.Uint16(sets)
+		length := int(binary.BigEndian.Uint16(sets[2:]))
+		if length < 4 || length > len(sets) {
+			return flows, unknown, fmt.Errorf("invalid length %d of set %d", length, id)
+		}
+		body := sets[4:length]
+		sets = sets[length:]
+
+		var err error
+		switch {
+		case id == netflowTemplateSet && !ipfix, id == ipfixTemplateSet && ipfix:
+			err = d.decodeTemplates(exporter, domain, body, ipfix, false)
+		case id == netflowOptionsTemplateSet && !ipfix, id == ipfixOptionsTemplateSet && ipfix:
+			err = d.decodeTemplates(exporter, domain, body, ipfix, true)
+		case id >= minDataSet:
+			t, ok := d.templates[templateKey{exporter: exporter, domain: domain, id: id}]
+			if !ok {
+				unknown++
+				continue
+			}
+			var f []flow
+			f, err = d.decodeRecords(exporter, domain, t, body)
+			flows = append(flows, f...)
+		}
+		if err != nil {
+			return flows, unknown, fmt.Errorf("set %d: %w", id, err)
+		}
+	}
+
+	return flows, unknown, nil
+}
+
+func (d *netflowDecoder) decodeTemplates(exporter string, domain uint32, b []byte, ipfix, options bool) error {
+	// the sets are padded to 4 bytes, a template header is at least 4 bytes
+	for len(b) >= 4 {
+		id := binary.BigEndian.Uint16(b)
+		var t template
+		var count int
+		switch {
+		case !options:
+			count = int(binary.BigEndian.Uint16(b[2:]))
+			b = b[4:]
+		case ipfix:
+			if len(b) < 6 {
+				return errShort
+			}
+			count = int(binary.BigEndian.Uint16(b[2:]))
+			t.scope = int(binary.BigEndian.Uint16(b[4:]))
+			b = b[6:]
+		default:
+			// the scope and option lengths of NetFlow v9 are in bytes
+			if len(b) < 6 {
+				return errShort
+			}
+			t.scope = int(binary.BigEndian.Uint16(b[2:])) / 4
+			count = t.scope + int(binary.BigEndian.Uint16(b[4:]))/4
+			b = b[6:]
+		}
+		t.options = options
+
+		if id < minDataSet {
+			// padding of NetFlow v9 template sets
+			return nil
+		}
+
+		for i := 0; i < count; i++ {
+			if len(b) < 4 {
+				return errShort
+			}
+			f := templateField{
+				id:     binary.BigEndian.Uint16(b),
+				length: binary.BigEndian.Uint16(b[2:]),
+			}
+			b = b[4:]
+			if ipfix && f.id&0x8000 != 0 {
+				if len(b) < 4 {
+					return errShort
+				}
+				f.id &= 0x7fff
+				f.enterprise = binary.BigEndian.Uint32(b)
+				b = b[4:]
+			}
+			t.fields = append(t.fields, f)
+		}
+
+		d.templates[templateKey{exporter: exporter, domain: domain, id: id}] = t
+	}
+	return nil
+}
+
+func (d *netflowDecoder) decodeRecords(exporter string, domain uint32, t template, b []byte) ([]flow, error) {
+	minLength := 0
+	for _, f := range t.fields {
+		if f.length == variableLength {
+			minLength++
+		} else {
+			minLength += int(f.length)
+		}
+	}
+	if minLength == 0 {
+		return nil, errors.New("empty template")
+	}
+
+	var flows []flow
+	// the remainder shorter than a record is padding
+	for len(b) >= minLength {
+		var fl flow
+		var rate uint64
+		for i, f := range t.fields {
+			length := int(f.length)
+			if f.length == variableLength {
+				if len(b) < 1 {
+					return flows, errShort
+				}
+				length = int(b[0])
+				b = b[1:]
+				if length == 255 {
+					if len(b) < 2 {
+						return flows, errShort
+					}
+					length = int(binary.BigEndian.Uint16(b))
+					b = b[2:]
+				}
+			}
+			if len(b) < length {
+				return flows, errShort
+			}
+			v := b[:length]
+			b = b[length:]
+
+			if f.enterprise != 0 || (t.options && i < t.scope) {
+				continue
+			}
+			switch f.id {
+			case ieOctetDeltaCount:
+				fl.bytes = uintValue(v)
+			case iePacketDeltaCount:
+				fl.packets = uintValue(v)
+			case ieIngressInterface:
+				fl.inIf = uint32(uintValue(v))
+			case ieEgressInterface:
+				fl.outIf = uint32(uintValue(v))
+			case ieSourceIPv4Address, ieSourceIPv6Address:
+				fl.src = ipValue(v)
+			case ieDestinationIPv4Addr, ieDestinationIPv6Addr:
+				fl.dst = ipValue(v)
+			case ieSamplingInterval, ieSamplerRandomInterval, ieSamplingPktInterval:
+				rate = uintValue(v)
+			}
+		}
+
+		if t.options {
+			if rate > 0 {
+				d.samplingRates[domainKey{exporter: exporter, domain: domain}] = rate
+			}
+			continue
+		}
+
+		if rate == 0 {
+			rate = d.samplingRate(exporter, domain)
+		}
+		fl.bytes *= rate
+		fl.packets *= rate
+		flows = append(flows, fl)
+	}
+	return flows, nil
+}
+
+// samplingRate returns the sampling interval of the options records of the
+// exporter, or the default when the exporter did not send one
+func (d *netflowDecoder) samplingRate(exporter string, domain uint32) uint64 {
+	if rate, ok := d.samplingRates[domainKey{exporter: exporter, domain: domain}]; ok {
+		return rate
+	}
+	return d.defaultRate
+}
+
+func uintValue(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func ipValue(b []byte) net.IP {
+	if len(b) != net.IPv4len && len(b) != net.IPv6len {
+		return nil
+	}
+	return net.IP(append([]byte(nil), b...))
+}
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// etagTransport makes conditional requests using the ETag of the previous
+// response for a url. GitHub does not count a 304 Not Modified response
+// against the rate limit, the cached response is returned in its place.
+type etagTransport struct {
+	base  http.RoundTripper
+	cache map[string]*cachedResponse
+	mu    sync.Mutex
+}
+
+type cachedResponse struct {
+	header http.Header
+	etag   string
+	body   []byte
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	t.mu.Lock()
+	cached := t.cache[key]
+	t.mu.Unlock()
+
+	if cached != nil {
+		// RoundTrip must not modify the request
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		// the 304 carries the current rate limit headers
+		header := cached.header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Header = header
+		resp.Body = ioutil.NopCloser(bytes.NewReader(cached.body))
+		resp.ContentLength = int64(len(cached.body))
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		t.mu.Lock()
+		t.cache[key] = &cachedResponse{
+			header: resp.Header.Clone(),
+			etag:   resp.Header.Get("ETag"),
+			body:   body,
+		}
+		t.mu.Unlock()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,48 @@
+package github
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagTransport(t *testing.T) {
+	var requests, conditional int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", 100-requests))
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"stargazers_count": 5}`)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &etagTransport{
+		base:  http.DefaultTransport,
+		cache: make(map[string]*cachedResponse),
+	}}
+
+	for i := 1; i <= 3; i++ {
+		resp, err := client.Get(ts.URL + "/repos/owner/repo")
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `{"stargazers_count": 5}`, string(body))
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		require.Equal(t, fmt.Sprintf("%d", 100-i), resp.Header.Get("X-RateLimit-Remaining"))
+	}
+	require.Equal(t, 3, requests)
+	require.Equal(t, 2, conditional)
+}
@@ -23,6 +23,7 @@ type GitHub struct {
 	AccessToken       string            `toml:"access_token"`
 	EnterpriseBaseURL string            `toml:"enterprise_base_url"`
 	HTTPTimeout       internal.Duration `toml:"http_timeout"`
+	AdditionalFields  []string          `toml:"additional_fields"`
 	githubClient      *github.Client
 
 	obfuscatedToken string
@@ -47,8 +48,22 @@ const sampleConfig = `
 
   ## Timeout for HTTP requests.
   # http_timeout = "5s"
+
+  ## Additional information to gather, each requires extra API requests:
+  ##   "pull-requests" - open and closed pull request counts
+  ##   "workflow-runs" - GitHub Actions run counts, success rate and duration
+  ##                     by workflow, from the most recent 100 runs
+  # additional_fields = []
 `
 
+const (
+	fieldPullRequests = "pull-requests"
+	fieldWorkflowRuns = "workflow-runs"
+
+	// workflowRunsPerPage is the number of recent workflow runs summarized
+	workflowRunsPerPage = 100
+)
+
 // SampleConfig returns sample configuration for this plugin.
 func (g *GitHub) SampleConfig() string {
 	return sampleConfig
@@ -59,11 +74,25 @@ func (g *GitHub) Description() string {
 	return "Gather repository information from GitHub hosted repositories."
 }
 
+func (g *GitHub) Init() error {
+	for _, field := range g.AdditionalFields {
+		switch field {
+		case fieldPullRequests, fieldWorkflowRuns:
+		default:
+			return fmt.Errorf("unknown additional field %q", field)
+		}
+	}
+	return nil
+}
+
 // Create GitHub Client
 func (g *GitHub) createGitHubClient(ctx context.Context) (*github.Client, error) {
 	httpClient := &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+		Transport: &etagTransport{
+			base: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+			},
+			cache: make(map[string]*cachedResponse),
 		},
 		Timeout: g.HTTPTimeout.Duration,
 	}
@@ -74,8 +103,8 @@ func (g *GitHub) createGitHubClient(ctx context.Context) (*github.Client, error)
 		tokenSource := oauth2.StaticTokenSource(
 			&oauth2.Token{AccessToken: g.AccessToken},
 		)
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
 		oauthClient := oauth2.NewClient(ctx, tokenSource)
-		_ = context.WithValue(ctx, oauth2.HTTPClient, oauthClient)
 
 		g.obfuscatedToken = g.AccessToken[0:4] + "..." + g.AccessToken[len(g.AccessToken)-3:]
 
@@ -127,24 +156,28 @@ func (g *GitHub) Gather(ctx context.Context, acc cua.Accumulator) error {
 			}
 
 			repositoryInfo, response, err := g.githubClient.Repositories.Get(ctx, owner, repository)
-
-			var rlerr *github.RateLimitError
-			if errors.As(err, &rlerr) {
-				g.RateLimitErrors.Incr(1)
-			}
-
-			if err != nil {
+			if err = g.handleResponse(response, err); err != nil {
 				acc.AddError(err)
 				return
 			}
 
-			g.RateLimit.Set(int64(response.Rate.Limit))
-			g.RateRemaining.Set(int64(response.Rate.Remaining))
-
 			now := time.Now()
 			tags := getTags(repositoryInfo)
 			fields := getFields(repositoryInfo)
 
+			for _, field := range g.AdditionalFields {
+				switch field {
+				case fieldPullRequests:
+					if err := g.addPullRequestCounts(ctx, owner, repository, fields); err != nil {
+						acc.AddError(err)
+					}
+				case fieldWorkflowRuns:
+					if err := g.gatherWorkflowRuns(ctx, owner, repository, acc, now); err != nil {
+						acc.AddError(err)
+					}
+				}
+			}
+
 			acc.AddFields("github_repository", fields, tags, now)
 		}(repository, acc)
 	}
@@ -153,6 +186,142 @@ func (g *GitHub) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return nil
 }
 
+// handleResponse records the rate limit state reported by an API response
+func (g *GitHub) handleResponse(response *github.Response, err error) error {
+	var rlerr *github.RateLimitError
+	if errors.As(err, &rlerr) {
+		g.RateLimitErrors.Incr(1)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	g.RateLimit.Set(int64(response.Rate.Limit))
+	g.RateRemaining.Set(int64(response.Rate.Remaining))
+
+	return nil
+}
+
+// addPullRequestCounts adds the number of open and closed pull requests to
+// fields. Pull requests are listed one per page, so the number of pages is
+// the number of pull requests.
+func (g *GitHub) addPullRequestCounts(ctx context.Context, owner, repository string, fields map[string]interface{}) error {
+	for _, state := range []string{"open", "closed"} {
+		opts := &github.PullRequestListOptions{
+			State:       state,
+			ListOptions: github.ListOptions{PerPage: 1},
+		}
+		prs, response, err := g.githubClient.PullRequests.List(ctx, owner, repository, opts)
+		if err = g.handleResponse(response, err); err != nil {
+			return err
+		}
+		fields[state+"_pull_requests"] = pageCount(response, len(prs))
+	}
+	return nil
+}
+
+// pageCount returns the number of pages of a list, a list that fits on a
+// single page has no last page.
+func pageCount(response *github.Response, items int) int {
+	if response.LastPage > 0 {
+		return response.LastPage
+	}
+	return items
+}
+
+// gatherWorkflowRuns reports a github_workflow metric for every workflow
+// with runs among the most recent workflow runs of the repository.
+func (g *GitHub) gatherWorkflowRuns(ctx context.Context, owner, repository string, acc cua.Accumulator, now time.Time) error {
+	workflows, response, err := g.githubClient.Actions.ListWorkflows(ctx, owner, repository, &github.ListOptions{PerPage: 100})
+	if err = g.handleResponse(response, err); err != nil {
+		return err
+	}
+
+	opts := &github.ListWorkflowRunsOptions{ListOptions: github.ListOptions{PerPage: workflowRunsPerPage}}
+	runs, response, err := g.githubClient.Actions.ListRepositoryWorkflowRuns(ctx, owner, repository, opts)
+	if err = g.handleResponse(response, err); err != nil {
+		return err
+	}
+
+	names := make(map[int64]string, len(workflows.Workflows))
+	for _, w := range workflows.Workflows {
+		names[w.GetID()] = w.GetName()
+	}
+
+	for id, fields := range getWorkflowFields(runs.WorkflowRuns) {
+		name, ok := names[id]
+		if !ok {
+			name = fmt.Sprintf("%d", id)
+		}
+		tags := map[string]string{
+			"owner":    owner,
+			"name":     repository,
+			"workflow": name,
+		}
+		acc.AddFields("github_workflow", fields, tags, now)
+	}
+
+	return nil
+}
+
+// getWorkflowFields summarizes workflow runs by workflow id. Cancelled and
+// skipped runs are counted but are not included in the success rate or
+// durations.
+func getWorkflowFields(runs []*github.WorkflowRun) map[int64]map[string]interface{} {
+	type summary struct {
+		total, max                            float64
+		success, failure, cancelled, inFlight int
+	}
+
+	summaries := make(map[int64]*summary)
+	for _, run := range runs {
+		s, ok := summaries[run.GetWorkflowID()]
+		if !ok {
+			s = &summary{}
+			summaries[run.GetWorkflowID()] = s
+		}
+
+		if run.GetStatus() != "completed" {
+			s.inFlight++
+			continue
+		}
+
+		switch run.GetConclusion() {
+		case "success":
+			s.success++
+		case "failure", "timed_out", "startup_failure":
+			s.failure++
+		default:
+			s.cancelled++
+			continue
+		}
+		duration := run.GetUpdatedAt().Time.Sub(run.GetCreatedAt().Time).Seconds()
+		s.total += duration
+		if duration > s.max {
+			s.max = duration
+		}
+	}
+
+	result := make(map[int64]map[string]interface{}, len(summaries))
+	for id, s := range summaries {
+		fields := map[string]interface{}{
+			"runs_success":     s.success,
+			"runs_failure":     s.failure,
+			"runs_cancelled":   s.cancelled,
+			"runs_in_progress": s.inFlight,
+		}
+		if finished := s.success + s.failure; finished > 0 {
+			fields["success_rate"] = float64(s.success) / float64(finished) * 100
+			fields["duration_mean"] = s.total / float64(finished)
+			fields["duration_max"] = s.max
+		}
+		result[id] = fields
+	}
+
+	return result
+}
+
 func splitRepositoryName(repositoryName string) (string, string, error) {
 	splits := strings.SplitN(repositoryName, "/", 2)
 
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	gh "github.com/google/go-github/v32/github"
 	"github.com/stretchr/testify/require"
@@ -140,3 +141,58 @@ func TestGetFields(t *testing.T) {
 
 	require.Equal(t, true, reflect.DeepEqual(getFieldsReturn, correctFieldReturn))
 }
+
+func TestInitAdditionalFields(t *testing.T) {
+	g := &GitHub{AdditionalFields: []string{"pull-requests", "workflow-runs"}}
+	require.NoError(t, g.Init())
+
+	g.AdditionalFields = []string{"stargazers"}
+	require.Error(t, g.Init())
+}
+
+func TestPageCount(t *testing.T) {
+	require.Equal(t, 42, pageCount(&gh.Response{LastPage: 42}, 1))
+	require.Equal(t, 1, pageCount(&gh.Response{}, 1))
+	require.Equal(t, 0, pageCount(&gh.Response{}, 0))
+}
+
+func TestGetWorkflowFields(t *testing.T) {
+	start := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	run := func(workflow int64, status, conclusion string, seconds int) *gh.WorkflowRun {
+		return &gh.WorkflowRun{
+			WorkflowID: &workflow,
+			Status:     &status,
+			Conclusion: &conclusion,
+			CreatedAt:  &gh.Timestamp{Time: start},
+			UpdatedAt:  &gh.Timestamp{Time: start.Add(time.Duration(seconds) * time.Second)},
+		}
+	}
+
+	fields := getWorkflowFields([]*gh.WorkflowRun{
+		run(1, "completed", "success", 60),
+		run(1, "completed", "success", 120),
+		run(1, "completed", "failure", 30),
+		run(1, "completed", "timed_out", 390),
+		run(1, "completed", "cancelled", 5),
+		run(1, "in_progress", "", 0),
+		run(2, "queued", "", 0),
+	})
+
+	require.Equal(t, map[int64]map[string]interface{}{
+		1: {
+			"runs_success":     2,
+			"runs_failure":     2,
+			"runs_cancelled":   1,
+			"runs_in_progress": 1,
+			"success_rate":     float64(50),
+			"duration_mean":    float64(150),
+			"duration_max":     float64(390),
+		},
+		2: {
+			"runs_success":     0,
+			"runs_failure":     0,
+			"runs_cancelled":   0,
+			"runs_in_progress": 1,
+		},
+	}, fields)
+}
@@ -512,6 +512,10 @@ const sampleConfig = `
  # prefix = ""
  # target = ""
 
+ ## Only receive updates after the initial sync, the current state is not
+ ## sent at the start of the subscription
+ # updates_only = false
+
  ## Define additional aliases to map telemetry encoding paths to simple measurement names
  #[inputs.gnmi.aliases]
  #  ifcounters = "openconfig:/interfaces/interface/state/counters"
@@ -97,8 +97,9 @@ func TestWaitError(t *testing.T) {
 	grpcServer.Stop()
 	wg.Wait()
 
-	require.Contains(t, acc.Errors,
-		errors.New("aborted gNMI subscription: rpc error: code = Unknown desc = testerror"))
+	require.Len(t, acc.Errors, 1)
+	require.EqualError(t, acc.Errors[0],
+		"aborted gNMI subscription: rpc error: code = Unknown desc = testerror")
 }
 
 func TestUsernamePassword(t *testing.T) {
@@ -155,8 +156,9 @@ func TestUsernamePassword(t *testing.T) {
 	grpcServer.Stop()
 	wg.Wait()
 
-	require.Contains(t, acc.Errors,
-		errors.New("aborted gNMI subscription: rpc error: code = Unknown desc = success"))
+	require.Len(t, acc.Errors, 1)
+	require.EqualError(t, acc.Errors[0],
+		"aborted gNMI subscription: rpc error: code = Unknown desc = success")
 }
 
 func mockGNMINotification() *gnmi.Notification {
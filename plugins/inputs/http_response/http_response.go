@@ -2,6 +2,8 @@ package httpresponse
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -12,16 +14,23 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/targets"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/circonus-labs/circonus-unified-agent/selfstat"
 )
 
 const (
+	// defaultRetryInterval is the default delay before the first retry of a
+	// failed request
+	defaultRetryInterval = time.Second
+
 	// defaultResponseBodyMaxSize is the default maximum response body size, in bytes.
 	// if the response body is over this size, we will raise a body_read_error.
 	defaultResponseBodyMaxSize = 32 * 1024 * 1024
@@ -32,6 +41,12 @@ type HTTPResponse struct {
 	Log                 cua.Logger
 	compiledStringMatch *regexp.Regexp
 	client              *http.Client
+	roundsSkipped       selfstat.Stat
+	urlsSkipped         selfstat.Stat
+	lastStart           time.Time
+	interval            time.Duration
+	failures            map[string]int
+	failuresMu          sync.Mutex
 	Headers             map[string]string
 	HTTPHeaderTags      map[string]string `toml:"http_header_tags"`
 	Interface           string
@@ -40,15 +55,25 @@ type HTTPResponse struct {
 	Password            string `toml:"password"`     // HTTP Basic Auth Credentials
 	BearerToken         string `toml:"bearer_token"` // Absolute path to file with Bearer token
 	HTTPProxy           string `toml:"http_proxy"`
+	Resolve             string `toml:"resolve"`
 	Username            string `toml:"username"` // HTTP Basic Auth Credentials
 	ResponseStringMatch string
 	ResponseBodyField   string `toml:"response_body_field"`
+	ExpectedBodyHash    string `toml:"expected_body_hash"`
 	tls.ClientConfig
+	targets.Source
 	URLs                []string      `toml:"urls"`
 	ResponseBodyMaxSize internal.Size `toml:"response_body_max_size"`
 	ResponseTimeout     internal.Duration
+	RetryInterval       internal.Duration `toml:"retry_interval"`
 	ResponseStatusCode  int
+	MaxConcurrency      int `toml:"max_concurrency"`
+	Retries             int `toml:"retries"`
+	ConsecutiveFailures int `toml:"consecutive_failures_before_report"`
 	FollowRedirects     bool
+	Histograms          bool `toml:"histograms"`
+	CacheHeaders        bool `toml:"cache_headers"`
+	BodyHash            bool `toml:"body_hash"`
 }
 
 // Description returns the plugin Description
@@ -64,12 +89,40 @@ var sampleConfig = `
   ## List of urls to query.
   # urls = ["http://localhost"]
 
+  ## Optional source of additional targets, refreshed periodically, as a JSON
+  ## list of strings read from a file:// path, an http(s):// URL or the raw
+  ## value of a Consul KV key with consul://host:8500/path/of/key.
+  # targets_source = "consul://127.0.0.1:8500/probes/http_response"
+  ## How often the targets are read again from the source.
+  # targets_refresh_interval = "5m"
+  ## Bearer token of http(s) sources, ACL token of Consul sources.
+  # targets_token = ""
+
   ## Set http_proxy (cua uses the system wide proxy settings if it's is not set)
   # http_proxy = "http://localhost:8888"
 
   ## Set response_timeout (default 5 seconds)
   # response_timeout = "5s"
 
+  ## Maximum number of urls to query at the same time (default 1, sequential).
+  ## Each url is timed individually regardless of how many run concurrently.
+  # max_concurrency = 1
+
+  ## Number of times a failed request is retried within a gather, each retry
+  ## waits retry_interval, doubled after every attempt. When retries is
+  ## set an http_response_attempt metric is added for every attempt.
+  # retries = 0
+  # retry_interval = "1s"
+
+  ## Number of gathers in a row which must fail before the "failing" field
+  ## is set to 1, so a transient failure is not reported. 0 disables the
+  ## consecutive_failures and failing fields.
+  # consecutive_failures_before_report = 0
+
+  ## Emit circonus histograms of response_time and content_length across all
+  ## urls queried by this plugin instance, in addition to the per url metrics.
+  # histograms = false
+
   ## HTTP Request Method
   # method = "GET"
 
@@ -103,6 +156,19 @@ var sampleConfig = `
   # response_string_match = "ok"
   # response_string_match = "\".*_status\".?:.?\"up\""
 
+  ## Add the cache_control_present, age_present and etag_present fields, and
+  ## the age field when the response has an Age header, to detect caching
+  ## misconfigurations.
+  # cache_headers = false
+
+  ## Add the SHA-256 of the response body as the body_sha256 field.
+  # body_hash = false
+
+  ## Expected SHA-256 of the response body, hex encoded. The body_hash_match
+  ## field is 1 if the body matches, otherwise 0 and the result is
+  ## body_hash_mismatch.
+  # expected_body_hash = ""
+
   ## Expected response status code.
   ## The status code of the response is compared to this value. If they match, the field
   ## "response_status_code_match" will be 1, otherwise it will be 0. If the
@@ -127,6 +193,17 @@ var sampleConfig = `
 
   ## Interface to use when dialing an address
   # interface = "eth0"
+
+  ## Override DNS resolution and connect to this address (host:port) for
+  ## every url of the instance, the url host is still used for the Host header
+  ## and TLS server name. Not applied to the connections through a proxy nor
+  ## to redirects to other hosts. Useful for checking a backend behind an SNI
+  ## routed load balancer.
+  # resolve = "10.0.0.5:443"
+
+  ## Services listening on unix sockets can be queried with a unix:// url,
+  ## the socket path is followed by the request path, e.g.
+  ##   urls = ["unix:///var/run/app.sock/health"]
 `
 
 // SampleConfig returns the plugin SampleConfig
@@ -173,7 +250,7 @@ func (h *HTTPResponse) createHTTPClient() (*http.Client, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			Proxy:             getProxyFunc(h.HTTPProxy),
-			DialContext:       dialer.DialContext,
+			DialContext:       h.dialContext(dialer),
 			DisableKeepAlives: true,
 			TLSClientConfig:   tlsCfg,
 		},
@@ -188,6 +265,66 @@ func (h *HTTPResponse) createHTTPClient() (*http.Client, error) {
 	return client, nil
 }
 
+// unixSocketKey is the request context key holding the unix socket to dial
+type unixSocketKey struct{}
+
+// resolveKey is the request context key holding the address of the url host
+// replaced by the resolve address
+type resolveKey struct{}
+
+// dialContext returns a DialContext function which connects to the unix
+// socket in the request context if there is one, otherwise to the resolve
+// address if configured and the url host is dialed directly, falling back to
+// the address from the url or of the proxy.
+func (h *HTTPResponse) dialContext(dialer *net.Dialer) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if sock, ok := ctx.Value(unixSocketKey{}).(string); ok {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "unix", sock)
+			if err != nil {
+				return nil, fmt.Errorf("dial unix (%s): %w", sock, err)
+			}
+			return conn, nil
+		}
+		if host, ok := ctx.Value(resolveKey{}).(string); ok && host == addr {
+			addr = h.Resolve
+		}
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("dial (%s): %w", addr, err)
+		}
+		return conn, nil
+	}
+}
+
+// hostPort returns the address dialed for the host of an url without proxy
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
+
+// splitUnixSocket splits the path of a unix:// url into the socket path and
+// the request path, by finding the longest leading part of the path which is
+// a socket.
+func splitUnixSocket(p string) (string, string, error) {
+	for i := len(p); i > 0; i = strings.LastIndex(p[:i], "/") {
+		if fi, err := os.Stat(p[:i]); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			reqPath := p[i:]
+			if reqPath == "" {
+				reqPath = "/"
+			}
+			return p[:i], reqPath, nil
+		}
+	}
+	return "", "", fmt.Errorf("no unix socket found in path %q", p)
+}
+
 func localAddress(interfaceName string) (net.Addr, error) {
 	i, err := net.InterfaceByName(interfaceName)
 	if err != nil {
@@ -218,6 +355,7 @@ func setResult(resultString string, fields map[string]interface{}, tags map[stri
 		"timeout":                       4,
 		"dns_error":                     5,
 		"response_status_code_mismatch": 6,
+		"body_hash_mismatch":            7,
 	}
 
 	tags["result"] = resultString
@@ -266,7 +404,20 @@ func (h *HTTPResponse) httpGather(u string) (map[string]interface{}, map[string]
 	if h.Body != "" {
 		body = strings.NewReader(h.Body)
 	}
-	request, err := http.NewRequest(h.Method, u, body)
+	target := u
+	ctx := context.Background()
+	if addr, err := url.Parse(u); err == nil && addr.Scheme == "unix" {
+		sock, reqPath, err := splitUnixSocket(addr.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		ctx = context.WithValue(ctx, unixSocketKey{}, sock)
+		target = (&url.URL{Scheme: "http", Host: "localhost", Path: reqPath, RawQuery: addr.RawQuery}).String()
+	} else if err == nil && h.Resolve != "" {
+		ctx = context.WithValue(ctx, resolveKey{}, hostPort(addr))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, h.Method, target, body)
 	if err != nil {
 		return nil, nil, fmt.Errorf("new request (%s): %w", u, err)
 	}
@@ -335,9 +486,6 @@ func (h *HTTPResponse) httpGather(u string) (map[string]interface{}, map[string]
 	tags["status_code"] = strconv.Itoa(resp.StatusCode)
 	fields["http_response_code"] = resp.StatusCode
 
-	if h.ResponseBodyMaxSize.Size == 0 {
-		h.ResponseBodyMaxSize.Size = defaultResponseBodyMaxSize
-	}
 	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, h.ResponseBodyMaxSize.Size+1))
 	// Check first if the response body size exceeds the limit.
 	if err == nil && int64(len(bodyBytes)) > h.ResponseBodyMaxSize.Size {
@@ -359,6 +507,10 @@ func (h *HTTPResponse) httpGather(u string) (map[string]interface{}, map[string]
 	}
 	fields["content_length"] = len(bodyBytes)
 
+	if h.CacheHeaders {
+		addCacheHeaders(resp.Header, fields)
+	}
+
 	var success = true
 
 	// Check the response for a regex
@@ -383,6 +535,24 @@ func (h *HTTPResponse) httpGather(u string) (map[string]interface{}, map[string]
 		}
 	}
 
+	// Check the hash of the response body
+	if h.BodyHash || h.ExpectedBodyHash != "" {
+		sum := sha256.Sum256(bodyBytes)
+		hash := hex.EncodeToString(sum[:])
+		if h.BodyHash {
+			fields["body_sha256"] = hash
+		}
+		if h.ExpectedBodyHash != "" {
+			if hash == h.ExpectedBodyHash {
+				fields["body_hash_match"] = 1
+			} else {
+				success = false
+				setResult("body_hash_mismatch", fields, tags)
+				fields["body_hash_match"] = 0
+			}
+		}
+	}
+
 	if success {
 		setResult("success", fields, tags)
 	}
@@ -390,6 +560,21 @@ func (h *HTTPResponse) httpGather(u string) (map[string]interface{}, map[string]
 	return fields, tags, nil
 }
 
+// addCacheHeaders adds the presence of the caching headers of a response and
+// its age in seconds
+func addCacheHeaders(header http.Header, fields map[string]interface{}) {
+	for _, name := range []string{"Cache-Control", "Age", "ETag"} {
+		present := 0
+		if header.Get(name) != "" {
+			present = 1
+		}
+		fields[strings.ToLower(strings.ReplaceAll(name, "-", "_"))+"_present"] = present
+	}
+	if age, err := strconv.Atoi(strings.TrimSpace(header.Get("Age"))); err == nil {
+		fields["age"] = age
+	}
+}
+
 // Set result in case of a body read error
 func (h *HTTPResponse) setBodyReadError(errorMsg string, bodyBytes []byte, fields map[string]interface{}, tags map[string]string) {
 	h.Log.Debugf(errorMsg)
@@ -411,6 +596,13 @@ func (h *HTTPResponse) Gather(ctx context.Context, acc cua.Accumulator) error {
 		}
 	}
 
+	h.ExpectedBodyHash = strings.ToLower(strings.TrimSpace(h.ExpectedBodyHash))
+	if h.ExpectedBodyHash != "" {
+		if b, err := hex.DecodeString(h.ExpectedBodyHash); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("expected_body_hash %q is not a hex encoded SHA-256", h.ExpectedBodyHash)
+		}
+	}
+
 	// Set default values
 	if h.ResponseTimeout.Duration < time.Second {
 		h.ResponseTimeout.Duration = time.Second * 5
@@ -420,10 +612,27 @@ func (h *HTTPResponse) Gather(ctx context.Context, acc cua.Accumulator) error {
 		h.Method = "GET"
 	}
 
-	if len(h.URLs) == 0 {
+	if len(h.URLs) == 0 && !h.Source.Enabled() {
 		h.URLs = []string{"http://localhost"}
 	}
 
+	urlList, err := h.Source.Targets(ctx, h.URLs)
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	if h.ResponseBodyMaxSize.Size == 0 {
+		h.ResponseBodyMaxSize.Size = defaultResponseBodyMaxSize
+	}
+
+	if h.MaxConcurrency < 1 {
+		h.MaxConcurrency = 1
+	}
+
+	if h.RetryInterval.Duration <= 0 {
+		h.RetryInterval.Duration = defaultRetryInterval
+	}
+
 	if h.client == nil {
 		client, err := h.createHTTPClient()
 		if err != nil {
@@ -432,34 +641,252 @@ func (h *HTTPResponse) Gather(ctx context.Context, acc cua.Accumulator) error {
 		h.client = client
 	}
 
-	for _, u := range h.URLs {
-		addr, err := url.Parse(u)
-		if err != nil {
-			acc.AddError(err)
-			continue
+	if h.roundsSkipped == nil {
+		tags := map[string]string{"urls": strings.Join(h.URLs, ",")}
+		if h.Source.Enabled() {
+			tags["targets_source"] = h.TargetsSource
 		}
+		h.roundsSkipped = selfstat.Register("http_response", "rounds_skipped", tags)
+		h.urlsSkipped = selfstat.Register("http_response", "urls_skipped", tags)
+	}
 
-		if addr.Scheme != "http" && addr.Scheme != "https" {
-			acc.AddError(errors.New("Only http and https are supported"))
-			continue
+	// The shortest time between the start of two rounds is taken as the
+	// collection interval, the agent skips the rounds due while a round is
+	// still running.
+	start := time.Now()
+	if !h.lastStart.IsZero() {
+		if gap := start.Sub(h.lastStart); h.interval == 0 || gap < h.interval {
+			h.interval = gap
 		}
+	}
+	h.lastStart = start
 
-		// Prepare data
-		var fields map[string]interface{}
-		var tags map[string]string
+	var histos *histograms
+	if h.Histograms {
+		histos = newHistograms()
+	}
 
-		// Gather data
+	urls := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < h.MaxConcurrency && i < len(urlList); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range urls {
+				h.gatherURL(ctx, acc, u, histos)
+			}
+		}()
+	}
+
+	// Stop handing out urls if the round is cancelled, e.g. on shutdown.
+	skipped := 0
+dispatch:
+	for i, u := range urlList {
+		if ctx.Err() != nil {
+			skipped = len(urlList) - i
+			break
+		}
+		select {
+		case urls <- u:
+		case <-ctx.Done():
+			skipped = len(urlList) - i
+			break dispatch
+		}
+	}
+	close(urls)
+	wg.Wait()
+
+	if skipped > 0 {
+		h.Log.Warnf("Round cancelled, %d of %d urls not queried", skipped, len(urlList))
+	}
+
+	if h.interval > 0 {
+		elapsed := time.Since(start)
+		if missed := int64(elapsed / h.interval); missed > 0 {
+			h.roundsSkipped.Incr(missed)
+			h.urlsSkipped.Incr(missed * int64(len(urlList)))
+			h.Log.Warnf("Round took %s, longer than the interval of %s, %d rounds skipped",
+				elapsed.Round(time.Millisecond), h.interval.Round(time.Millisecond), missed)
+		}
+	}
+
+	if histos != nil {
+		histos.emit(acc)
+	}
+
+	h.pruneFailures(urlList)
+
+	return nil
+}
+
+// gatherURL queries a single url, retrying a failed request, and adds the
+// metric of the last attempt
+func (h *HTTPResponse) gatherURL(ctx context.Context, acc cua.Accumulator, u string, histos *histograms) {
+	addr, err := url.Parse(u)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	if addr.Scheme != "http" && addr.Scheme != "https" && addr.Scheme != "unix" {
+		acc.AddError(errors.New("Only http, https and unix are supported"))
+		return
+	}
+
+	// Gather data
+	var fields map[string]interface{}
+	var tags map[string]string
+	attempt := 1
+	delay := h.RetryInterval.Duration
+retry:
+	for ; ; attempt++ {
 		fields, tags, err = h.httpGather(u)
 		if err != nil {
 			acc.AddError(err)
-			continue
+			return
+		}
+		if h.Retries > 0 {
+			addAttempt(acc, attempt, fields, tags)
 		}
+		if tags["result"] == "success" || attempt > h.Retries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break retry
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
 
-		// Add metrics
-		acc.AddFields("http_response", fields, tags)
+	if h.Retries > 0 {
+		fields["attempts"] = attempt
+	}
+	if h.ConsecutiveFailures > 0 {
+		failures := h.recordResult(u, tags["result"] == "success")
+		fields["consecutive_failures"] = failures
+		fields["failing"] = 0
+		if failures >= h.ConsecutiveFailures {
+			fields["failing"] = 1
+		}
 	}
 
-	return nil
+	// Add metrics
+	acc.AddFields("http_response", fields, tags)
+
+	if histos != nil {
+		histos.add(fields)
+	}
+}
+
+// attemptFieldNames are the fields of a request also added to the metric of
+// each attempt
+var attemptFieldNames = []string{"response_time", "http_response_code", "result_code"}
+
+// addAttempt adds the raw result of a single attempt at querying a url
+func addAttempt(acc cua.Accumulator, attempt int, fields map[string]interface{}, tags map[string]string) {
+	attemptTags := make(map[string]string, len(tags))
+	for k, v := range tags {
+		attemptTags[k] = v
+	}
+	attemptFields := map[string]interface{}{"attempt": attempt}
+	for _, field := range attemptFieldNames {
+		if v, ok := fields[field]; ok {
+			attemptFields[field] = v
+		}
+	}
+	acc.AddFields("http_response_attempt", attemptFields, attemptTags)
+}
+
+// recordResult records the result of a gather of a url and returns the
+// number of gathers in a row which failed
+func (h *HTTPResponse) recordResult(u string, success bool) int {
+	h.failuresMu.Lock()
+	defer h.failuresMu.Unlock()
+	if h.failures == nil {
+		h.failures = make(map[string]int)
+	}
+	if success {
+		delete(h.failures, u)
+		return 0
+	}
+	h.failures[u]++
+	return h.failures[u]
+}
+
+// pruneFailures forgets the failures of urls no longer queried, e.g. removed
+// from the targets source
+func (h *HTTPResponse) pruneFailures(urlList []string) {
+	h.failuresMu.Lock()
+	defer h.failuresMu.Unlock()
+	if len(h.failures) == 0 {
+		return
+	}
+	current := make(map[string]bool, len(urlList))
+	for _, u := range urlList {
+		current[u] = true
+	}
+	for u := range h.failures {
+		if !current[u] {
+			delete(h.failures, u)
+		}
+	}
+}
+
+// histogramFields are the per url fields which are also recorded in the
+// plugin instance histograms
+var histogramFields = []string{"response_time", "content_length"}
+
+// histograms collects the values of histogramFields across all urls queried
+// during a single gather, keyed by field and then by value
+type histograms struct {
+	values map[string]map[string]int64
+	sync.Mutex
+}
+
+func newHistograms() *histograms {
+	h := &histograms{values: make(map[string]map[string]int64)}
+	for _, field := range histogramFields {
+		h.values[field] = make(map[string]int64)
+	}
+	return h
+}
+
+// add records the histogram fields present in the fields of a url metric
+func (h *histograms) add(fields map[string]interface{}) {
+	h.Lock()
+	defer h.Unlock()
+	for _, field := range histogramFields {
+		var v float64
+		switch value := fields[field].(type) {
+		case float64:
+			v = value
+		case int:
+			v = float64(value)
+		default:
+			continue
+		}
+		h.values[field][strconv.FormatFloat(v, 'e', -1, 64)]++
+	}
+}
+
+// emit adds a histogram metric for each field with recorded values, the
+// histogram buckets are the fields of the metric like stackdriver_circonus
+func (h *histograms) emit(acc cua.Accumulator) {
+	h.Lock()
+	defer h.Unlock()
+	tags := map[string]string{"input_metric_group": "http_response"}
+	for _, field := range histogramFields {
+		if len(h.values[field]) == 0 {
+			continue
+		}
+		buckets := make(map[string]interface{}, len(h.values[field]))
+		for k, v := range h.values[field] {
+			buckets[k] = v
+		}
+		acc.AddHistogram(field, buckets, tags)
+	}
 }
 
 func init() {
@@ -11,11 +11,15 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/targets"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -129,6 +133,9 @@ func setUpTestMux() http.Handler {
 	mux.HandleFunc("/twosecondnap", func(w http.ResponseWriter, req *http.Request) {
 		time.Sleep(time.Second * 2)
 	})
+	mux.HandleFunc("/halfsecondnap", func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(time.Millisecond * 500)
+	})
 	mux.HandleFunc("/nocontent", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	})
@@ -194,6 +201,31 @@ func TestHeaders(t *testing.T) {
 	checkOutput(t, &acc, expectedFields, expectedTags, absentFields, nil)
 }
 
+func TestTargetsSource(t *testing.T) {
+	mux := setUpTestMux().(*http.ServeMux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	mux.HandleFunc("/targets", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, `["%s/good", "%s/nocontent"]`, ts.URL, ts.URL)
+	})
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 2},
+		Source:          targets.Source{TargetsSource: ts.URL + "/targets"},
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	servers := []string{}
+	for _, m := range acc.Metrics {
+		servers = append(servers, m.Tags["server"])
+	}
+	require.ElementsMatch(t, []string{ts.URL + "/good", ts.URL + "/nocontent"}, servers)
+}
+
 func TestFields(t *testing.T) {
 	mux := setUpTestMux()
 	ts := httptest.NewServer(mux)
@@ -1252,3 +1284,431 @@ func TestStatusCodeAndStringMatchFail(t *testing.T) {
 	}
 	checkOutput(t, &acc, expectedFields, expectedTags, nil, nil)
 }
+
+func TestMaxConcurrency(t *testing.T) {
+	mux := setUpTestMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log: testutil.Logger{},
+		URLs: []string{
+			ts.URL + "/halfsecondnap",
+			ts.URL + "/halfsecondnap",
+			ts.URL + "/halfsecondnap",
+			ts.URL + "/halfsecondnap",
+		},
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+		MaxConcurrency:  4,
+	}
+
+	var acc testutil.Accumulator
+	start := time.Now()
+	err := h.Gather(context.Background(), &acc)
+	require.NoError(t, err)
+	require.Less(t, time.Since(start).Seconds(), 1.5)
+
+	metrics := acc.GetCUAMetrics()
+	require.Len(t, metrics, 4)
+	for _, m := range metrics {
+		rt, ok := m.GetField("response_time")
+		require.True(t, ok)
+		require.GreaterOrEqual(t, rt.(float64), 0.5)
+		require.Less(t, rt.(float64), 1.5)
+	}
+}
+
+func TestCancelledRoundSkipsURLs(t *testing.T) {
+	mux := setUpTestMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		URLs:            []string{ts.URL + "/good", ts.URL + "/good"},
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+	require.Len(t, acc.GetCUAMetrics(), 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	acc.ClearMetrics()
+	require.NoError(t, h.Gather(ctx, &acc))
+	require.Len(t, acc.GetCUAMetrics(), 0)
+}
+
+func TestOverrunRoundsSkipped(t *testing.T) {
+	mux := setUpTestMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		URLs:            []string{ts.URL + "/halfsecondnap", ts.URL + "/good"},
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+	}
+
+	// the interval is not known during the first round
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+	require.Zero(t, h.roundsSkipped.Get())
+
+	// a round of over 500ms with an interval of 200ms skips two rounds
+	h.lastStart = time.Now().Add(-200 * time.Millisecond)
+	require.NoError(t, h.Gather(context.Background(), &acc))
+	require.InDelta(t, 200*time.Millisecond, h.interval, float64(50*time.Millisecond))
+	require.Equal(t, int64(2), h.roundsSkipped.Get())
+	require.Equal(t, int64(4), h.urlsSkipped.Get())
+
+	// the stats are per instance
+	other := &HTTPResponse{
+		Log:             testutil.Logger{},
+		URLs:            []string{ts.URL + "/good"},
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+	}
+	require.NoError(t, other.Gather(context.Background(), &acc))
+	require.Zero(t, other.roundsSkipped.Get())
+}
+
+func TestUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	listener, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(setUpTestMux())
+	ts.Listener = listener
+	ts.Start()
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		URLs:            []string{"unix://" + sock + "/good"},
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+
+	expectedFields := map[string]interface{}{
+		"http_response_code": http.StatusOK,
+		"result_type":        "success",
+		"result_code":        0,
+		"response_time":      nil,
+		"content_length":     nil,
+	}
+	expectedTags := map[string]interface{}{
+		"server":      "unix://" + sock + "/good",
+		"method":      "GET",
+		"status_code": "200",
+		"result":      "success",
+	}
+	checkOutput(t, &acc, expectedFields, expectedTags, nil, nil)
+}
+
+func TestUnixSocketNotFound(t *testing.T) {
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		URLs:            []string{"unix://" + filepath.Join(t.TempDir(), "missing.sock")},
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+	require.False(t, acc.HasMeasurement("http_response"))
+}
+
+func TestResolve(t *testing.T) {
+	ts := httptest.NewServer(setUpTestMux())
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		URLs:            []string{"http://service.invalid/good"},
+		Resolve:         ts.Listener.Addr().String(),
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+
+	expectedFields := map[string]interface{}{
+		"http_response_code": http.StatusOK,
+		"result_type":        "success",
+		"result_code":        0,
+	}
+	expectedTags := map[string]interface{}{
+		"server": "http://service.invalid/good",
+		"result": "success",
+	}
+	checkOutput(t, &acc, expectedFields, expectedTags, nil, nil)
+}
+
+func TestResolveNotAppliedToProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host != "service.invalid" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		URLs:            []string{"http://service.invalid/good"},
+		HTTPProxy:       proxy.URL,
+		Resolve:         "127.0.0.1:1",
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+
+	expectedFields := map[string]interface{}{
+		"http_response_code": http.StatusOK,
+		"result_type":        "success",
+		"result_code":        0,
+	}
+	expectedTags := map[string]interface{}{
+		"server": "http://service.invalid/good",
+		"result": "success",
+	}
+	checkOutput(t, &acc, expectedFields, expectedTags, nil, nil)
+}
+
+func TestHistograms(t *testing.T) {
+	ts := httptest.NewServer(setUpTestMux())
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log: testutil.Logger{},
+		URLs: []string{
+			ts.URL + "/good",
+			ts.URL + "/good",
+			ts.URL + "/jsonresponse",
+			"http://service.invalid",
+		},
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+		Histograms:      true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+
+	counts := make(map[string]int64)
+	for _, m := range acc.Metrics {
+		if m.Type != cua.Histogram {
+			continue
+		}
+		require.Equal(t, "http_response", m.Tags["input_metric_group"])
+		for _, v := range m.Fields {
+			counts[m.Measurement] += v.(int64)
+		}
+	}
+
+	// the url which fails to resolve is not included
+	require.Equal(t, map[string]int64{"response_time": 3, "content_length": 3}, counts)
+
+	m, ok := acc.Get("content_length")
+	require.True(t, ok)
+	require.Equal(t, int64(2), m.Fields["1.8e+01"])
+}
+
+func TestRetries(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "ok")
+	}))
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:                testutil.Logger{},
+		URLs:               []string{ts.URL},
+		ResponseStatusCode: http.StatusOK,
+		ResponseTimeout:    internal.Duration{Duration: time.Second * 20},
+		Retries:            3,
+		RetryInterval:      internal.Duration{Duration: time.Millisecond},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	var results []string
+	for _, m := range acc.Metrics {
+		if m.Measurement == "http_response_attempt" {
+			results = append(results, m.Tags["result"])
+			require.Equal(t, len(results), m.Fields["attempt"])
+		}
+	}
+	require.Equal(t, []string{"response_status_code_mismatch", "response_status_code_mismatch", "success"}, results)
+
+	checkOutput(t, &acc, map[string]interface{}{"attempts": 3, "result_code": 0}, map[string]interface{}{"result": "success"}, nil, nil)
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	ts := httptest.NewServer(setUpTestMux())
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:                testutil.Logger{},
+		URLs:               []string{ts.URL + "/nocontent"},
+		ResponseStatusCode: http.StatusOK,
+		ResponseTimeout:    internal.Duration{Duration: time.Second * 20},
+		Retries:            2,
+		RetryInterval:      internal.Duration{Duration: time.Millisecond},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+	require.Len(t, acc.GetCUAMetrics(), 4)
+	checkOutput(t, &acc, map[string]interface{}{"attempts": 3, "result_code": 6}, nil, nil, nil)
+}
+
+func TestConsecutiveFailuresBeforeReport(t *testing.T) {
+	var fail int32 = 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "ok")
+	}))
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:                 testutil.Logger{},
+		URLs:                []string{ts.URL},
+		ResponseStatusCode:  http.StatusOK,
+		ResponseTimeout:     internal.Duration{Duration: time.Second * 20},
+		ConsecutiveFailures: 2,
+	}
+
+	expected := []struct {
+		fail     int32
+		failures int
+		failing  int
+	}{
+		{fail: 1, failures: 1, failing: 0},
+		{fail: 1, failures: 2, failing: 1},
+		{fail: 1, failures: 3, failing: 1},
+		{fail: 0, failures: 0, failing: 0},
+		{fail: 1, failures: 1, failing: 0},
+	}
+	for _, e := range expected {
+		atomic.StoreInt32(&fail, e.fail)
+		var acc testutil.Accumulator
+		require.NoError(t, h.Gather(context.Background(), &acc))
+		checkOutput(t, &acc, map[string]interface{}{
+			"consecutive_failures": e.failures,
+			"failing":              e.failing,
+		}, nil, []string{"attempts"}, nil)
+	}
+}
+
+func TestCacheHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Age", "12")
+		fmt.Fprintf(w, "cached")
+	}))
+	defer ts.Close()
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		URLs:            []string{ts.URL},
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+		CacheHeaders:    true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(context.Background(), &acc))
+
+	expectedFields := map[string]interface{}{
+		"cache_control_present": 1,
+		"age_present":           1,
+		"etag_present":          0,
+		"age":                   12,
+	}
+	checkOutput(t, &acc, expectedFields, nil, []string{"body_sha256", "body_hash_match"}, nil)
+}
+
+func TestBodyHash(t *testing.T) {
+	ts := httptest.NewServer(setUpTestMux())
+	defer ts.Close()
+
+	// sha256 of "hit the good page!"
+	const goodHash = "918e4ad661fd2561a9be59f7cac48e14c5b45f1798a2e1b4c51d66c3bf6571f7"
+
+	tests := []struct {
+		name           string
+		expected       string
+		expectedFields map[string]interface{}
+		expectedTags   map[string]interface{}
+	}{
+		{
+			name:     "match",
+			expected: strings.ToUpper(goodHash),
+			expectedFields: map[string]interface{}{
+				"body_sha256":     goodHash,
+				"body_hash_match": 1,
+				"result_code":     0,
+			},
+			expectedTags: map[string]interface{}{"result": "success"},
+		},
+		{
+			name:     "mismatch",
+			expected: strings.Repeat("0", 64),
+			expectedFields: map[string]interface{}{
+				"body_sha256":     goodHash,
+				"body_hash_match": 0,
+				"result_code":     7,
+			},
+			expectedTags: map[string]interface{}{"result": "body_hash_mismatch"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &HTTPResponse{
+				Log:              testutil.Logger{},
+				URLs:             []string{ts.URL + "/good"},
+				ResponseTimeout:  internal.Duration{Duration: time.Second * 20},
+				BodyHash:         true,
+				ExpectedBodyHash: tt.expected,
+			}
+
+			var acc testutil.Accumulator
+			require.NoError(t, h.Gather(context.Background(), &acc))
+			checkOutput(t, &acc, tt.expectedFields, tt.expectedTags, nil, nil)
+		})
+	}
+}
+
+func TestInvalidExpectedBodyHash(t *testing.T) {
+	h := &HTTPResponse{
+		Log:              testutil.Logger{},
+		URLs:             []string{"http://localhost"},
+		ExpectedBodyHash: "not-a-hash",
+	}
+
+	var acc testutil.Accumulator
+	require.Error(t, h.Gather(context.Background(), &acc))
+}
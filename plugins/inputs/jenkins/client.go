@@ -154,3 +154,9 @@ func (c *client) getAllNodes(ctx context.Context) (nodeResp *nodeResponse, err e
 	err = c.doGet(ctx, nodePath, nodeResp)
 	return nodeResp, err
 }
+
+func (c *client) getQueue(ctx context.Context) (queueResp *queueResponse, err error) {
+	queueResp = new(queueResponse)
+	err = c.doGet(ctx, queuePath, queueResp)
+	return queueResp, err
+}
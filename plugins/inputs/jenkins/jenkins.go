@@ -38,6 +38,8 @@ type Jenkins struct {
 	MaxSubJobPerLayer int               `toml:"max_subjob_per_layer"`
 	JobExclude        []string          `toml:"job_exclude"`
 	jobFilter         filter.Filter
+	JobInclude        []string `toml:"job_include"`
+	jobIncludeFilter  filter.Filter
 
 	NodeExclude []string `toml:"node_exclude"`
 	nodeFilter  filter.Filter
@@ -80,6 +82,10 @@ const sampleConfig = `
   ## Jobs to exclude from gathering
   # job_exclude = [ "job1", "job2/subjob1/subjob2", "job3/*"]
 
+  ## Jobs to gather, by default all the jobs not excluded. Folders are still
+  ## searched for matching jobs.
+  # job_include = [ "deploy-*", "apps/*/main" ]
+
   ## Nodes to exclude from gathering
   # node_exclude = [ "node1", "node2" ]
 
@@ -93,6 +99,7 @@ const (
 	measurementJenkins = "jenkins"
 	measurementNode    = "jenkins_node"
 	measurementJob     = "jenkins_job"
+	measurementQueue   = "jenkins_queue"
 )
 
 // SampleConfig implements Input interface
@@ -118,6 +125,7 @@ func (j *Jenkins) Gather(ctx context.Context, acc cua.Accumulator) error {
 	}
 
 	j.gatherNodesData(acc)
+	j.gatherQueue(acc)
 	j.gatherJobs(acc)
 
 	return nil
@@ -163,6 +171,11 @@ func (j *Jenkins) initialize(client *http.Client) error {
 		return fmt.Errorf("error compile job filters[%s]: %w", j.URL, err)
 	}
 
+	j.jobIncludeFilter, err = filter.Compile(j.JobInclude)
+	if err != nil {
+		return fmt.Errorf("error compile job include filters[%s]: %w", j.URL, err)
+	}
+
 	// init node filter
 	j.nodeFilter, err = filter.Compile(j.NodeExclude)
 	if err != nil {
@@ -251,6 +264,9 @@ func (j *Jenkins) gatherNodesData(acc cua.Accumulator) {
 	fields := make(map[string]interface{})
 	fields["busy_executors"] = nodeResp.BusyExecutors
 	fields["total_executors"] = nodeResp.TotalExecutors
+	if nodeResp.TotalExecutors > 0 {
+		fields["executor_utilization"] = float64(nodeResp.BusyExecutors) / float64(nodeResp.TotalExecutors) * 100
+	}
 
 	acc.AddFields(measurementJenkins, fields, tags)
 
@@ -264,6 +280,43 @@ func (j *Jenkins) gatherNodesData(acc cua.Accumulator) {
 	}
 }
 
+func (j *Jenkins) gatherQueue(acc cua.Accumulator) {
+	queueResp, err := j.client.getQueue(context.Background())
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	tags := map[string]string{"source": j.Source, "port": j.Port}
+	fields := map[string]interface{}{
+		"size":      len(queueResp.Items),
+		"blocked":   0,
+		"buildable": 0,
+		"stuck":     0,
+	}
+
+	var oldest int64
+	for _, item := range queueResp.Items {
+		if item.Blocked {
+			fields["blocked"] = fields["blocked"].(int) + 1
+		}
+		if item.Buildable {
+			fields["buildable"] = fields["buildable"].(int) + 1
+		}
+		if item.Stuck {
+			fields["stuck"] = fields["stuck"].(int) + 1
+		}
+		if item.InQueueSince > 0 && (oldest == 0 || item.InQueueSince < oldest) {
+			oldest = item.InQueueSince
+		}
+	}
+	if oldest > 0 {
+		fields["oldest_wait"] = time.Since(time.Unix(0, oldest*int64(time.Millisecond))).Milliseconds()
+	}
+
+	acc.AddFields(measurementQueue, fields, tags)
+}
+
 func (j *Jenkins) gatherJobs(acc cua.Accumulator) {
 	js, err := j.client.getJobs(context.Background(), nil)
 	if err != nil {
@@ -333,6 +386,12 @@ func (j *Jenkins) getJobDetail(jr jobRequest, acc cua.Accumulator) error {
 	}
 	wg.Wait()
 
+	// folders are searched for included jobs but only the builds of those
+	// are gathered
+	if j.jobIncludeFilter != nil && !j.jobIncludeFilter.Match(jr.hierarchyName()) {
+		return nil
+	}
+
 	// collect build info
 	number := js.LastBuild.Number
 	if number < 1 {
@@ -362,6 +421,17 @@ func (j *Jenkins) getJobDetail(jr jobRequest, acc cua.Accumulator) error {
 	return nil
 }
 
+type queueResponse struct {
+	Items []queueItem `json:"items"`
+}
+
+type queueItem struct {
+	Blocked      bool  `json:"blocked"`
+	Buildable    bool  `json:"buildable"`
+	Stuck        bool  `json:"stuck"`
+	InQueueSince int64 `json:"inQueueSince"`
+}
+
 type nodeResponse struct {
 	Computers      []node `json:"computer"`
 	BusyExecutors  int    `json:"busyExecutors"`
@@ -428,8 +498,9 @@ func (b *buildResponse) GetTimestamp() time.Time {
 }
 
 const (
-	nodePath = "/computer/api/json"
-	jobPath  = "/api/json"
+	nodePath  = "/computer/api/json"
+	queuePath = "/queue/api/json"
+	jobPath   = "/api/json"
 )
 
 type jobRequest struct {
@@ -836,3 +836,141 @@ func TestGatherJobs(t *testing.T) {
 		})
 	}
 }
+
+func TestGatherQueue(t *testing.T) {
+	since := (time.Now().Unix() - int64(time.Minute.Seconds())) * 1000
+	mh := mockHandler{
+		responseMap: map[string]interface{}{
+			"/api/json": struct{}{},
+			"/queue/api/json": &queueResponse{
+				Items: []queueItem{
+					{Buildable: true, InQueueSince: since},
+					{Blocked: true, InQueueSince: since + 30000},
+					{Buildable: true, Stuck: true, InQueueSince: since + 50000},
+				},
+			},
+		},
+	}
+	ts := httptest.NewServer(mh)
+	defer ts.Close()
+
+	j := &Jenkins{
+		Log:             testutil.Logger{},
+		URL:             ts.URL,
+		ResponseTimeout: internal.Duration{Duration: time.Microsecond},
+	}
+	if err := j.initialize(&http.Client{Transport: &http.Transport{}}); err != nil {
+		t.Fatal(err)
+	}
+	acc := new(testutil.Accumulator)
+	j.gatherQueue(acc)
+	if err := acc.FirstError(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, ok := acc.Get(measurementQueue)
+	if !ok {
+		t.Fatal("no jenkins_queue metric")
+	}
+	for k, v := range map[string]interface{}{"size": 3, "blocked": 1, "buildable": 2, "stuck": 1} {
+		if m.Fields[k] != v {
+			t.Errorf("field %s: expected %v, got %v", k, v, m.Fields[k])
+		}
+	}
+	if wait, ok := m.Fields["oldest_wait"].(int64); !ok || wait < 60000 {
+		t.Errorf("field oldest_wait: expected at least 60000, got %v", m.Fields["oldest_wait"])
+	}
+}
+
+func TestGatherJobsInclude(t *testing.T) {
+	timestamp := (time.Now().Unix() - int64(time.Minute.Seconds())) * 1000
+	mh := mockHandler{
+		responseMap: map[string]interface{}{
+			"/api/json": &jobResponse{
+				Jobs: []innerJob{
+					{Name: "apps"},
+					{Name: "other"},
+				},
+			},
+			"/job/apps/api/json": &jobResponse{
+				Jobs: []innerJob{
+					{Name: "deploy"},
+					{Name: "test"},
+				},
+			},
+			"/job/other/api/json": &jobResponse{
+				LastBuild: jobBuild{Number: 1},
+			},
+			"/job/apps/job/deploy/api/json": &jobResponse{
+				LastBuild: jobBuild{Number: 1},
+			},
+			"/job/apps/job/test/api/json": &jobResponse{
+				LastBuild: jobBuild{Number: 1},
+			},
+			"/job/apps/job/deploy/1/api/json": &buildResponse{
+				Result:    "SUCCESS",
+				Duration:  1000,
+				Timestamp: timestamp,
+			},
+		},
+	}
+	ts := httptest.NewServer(mh)
+	defer ts.Close()
+
+	j := &Jenkins{
+		Log:             testutil.Logger{},
+		URL:             ts.URL,
+		MaxBuildAge:     internal.Duration{Duration: time.Hour},
+		ResponseTimeout: internal.Duration{Duration: time.Microsecond},
+		JobInclude:      []string{"apps/dep*"},
+	}
+	if err := j.initialize(&http.Client{Transport: &http.Transport{}}); err != nil {
+		t.Fatal(err)
+	}
+	acc := new(testutil.Accumulator)
+	j.gatherJobs(acc)
+	if err := acc.FirstError(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the builds of the jobs not included are never requested
+	if len(acc.Metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(acc.Metrics))
+	}
+	if acc.Metrics[0].Tags["name"] != "deploy" || acc.Metrics[0].Tags["parents"] != "apps" {
+		t.Errorf("unexpected job %v", acc.Metrics[0].Tags)
+	}
+}
+
+func TestExecutorUtilization(t *testing.T) {
+	mh := mockHandler{
+		responseMap: map[string]interface{}{
+			"/api/json": struct{}{},
+			"/computer/api/json": nodeResponse{
+				BusyExecutors:  3,
+				TotalExecutors: 4,
+			},
+		},
+	}
+	ts := httptest.NewServer(mh)
+	defer ts.Close()
+
+	j := &Jenkins{
+		Log:             testutil.Logger{},
+		URL:             ts.URL,
+		ResponseTimeout: internal.Duration{Duration: time.Microsecond},
+	}
+	if err := j.initialize(&http.Client{Transport: &http.Transport{}}); err != nil {
+		t.Fatal(err)
+	}
+	acc := new(testutil.Accumulator)
+	j.gatherNodesData(acc)
+
+	m, ok := acc.Get(measurementJenkins)
+	if !ok {
+		t.Fatal("no jenkins metric")
+	}
+	if m.Fields["executor_utilization"] != 75.0 {
+		t.Errorf("expected executor_utilization 75, got %v", m.Fields["executor_utilization"])
+	}
+}
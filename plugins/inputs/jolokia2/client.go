@@ -118,7 +118,8 @@ func NewClient(url string, config *ClientConfig) (*Client, error) {
 	}, nil
 }
 
-func (c *Client) read(requests []ReadRequest) ([]ReadResponse, error) {
+// Read sends the requests to the agent as a single bulk read request
+func (c *Client) Read(requests []ReadRequest) ([]ReadResponse, error) {
 	jrequests := makeJolokiaRequests(requests, c.config.ProxyConfig)
 	requestBody, err := json.Marshal(jrequests)
 	if err != nil {
@@ -34,7 +34,7 @@ func (g *Gatherer) Gather(client *Client, acc cua.Accumulator) error {
 	}
 
 	requests := makeReadRequests(g.metrics)
-	responses, err := client.read(requests)
+	responses, err := client.Read(requests)
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/plugins/common/kafka"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
+	"github.com/circonus-labs/circonus-unified-agent/selfstat"
 )
 
 const sampleConfig = `
@@ -231,6 +233,7 @@ func (k *KafkaConsumer) Start(ctx context.Context, acc cua.Accumulator) error {
 			handler := NewConsumerGroupHandler(acc, k.MaxUndeliveredMessages, k.parser)
 			handler.MaxMessageLen = k.MaxMessageLen
 			handler.TopicTag = k.TopicTag
+			handler.ConsumerGroup = k.ConsumerGroup
 			err := k.consumer.Consume(kctx, k.Topics, handler)
 			if err != nil {
 				acc.AddError(err)
@@ -284,6 +287,7 @@ func NewConsumerGroupHandler(acc cua.Accumulator, maxUndelivered int, parser par
 type ConsumerGroupHandler struct {
 	MaxMessageLen int
 	TopicTag      string
+	ConsumerGroup string
 
 	acc    cua.TrackingAccumulator
 	sem    semaphore
@@ -389,6 +393,15 @@ func (h *ConsumerGroupHandler) Handle(session sarama.ConsumerGroupSession, msg *
 func (h *ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	ctx := session.Context()
 
+	// messages of the partition not consumed yet, the stat can't be removed
+	// so it is zeroed when the partition is released, e.g. on a rebalance
+	lag := selfstat.Register("kafka_consumer", "lag", map[string]string{
+		"consumer_group": h.ConsumerGroup,
+		"topic":          claim.Topic(),
+		"partition":      strconv.Itoa(int(claim.Partition())),
+	})
+	defer lag.Set(0)
+
 	for {
 		err := h.Reserve(ctx)
 		if err != nil {
@@ -402,6 +415,7 @@ func (h *ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 			if !ok {
 				return nil
 			}
+			lag.Set(claim.HighWaterMarkOffset() - msg.Offset - 1)
 			err := h.Handle(session, msg)
 			if err != nil {
 				h.acc.AddError(err)
@@ -10,6 +10,7 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/plugins/common/kafka"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers/value"
+	"github.com/circonus-labs/circonus-unified-agent/selfstat"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -233,15 +234,16 @@ func (s *FakeConsumerGroupSession) Commit() {
 }
 
 type FakeConsumerGroupClaim struct {
-	messages chan *sarama.ConsumerMessage
+	messages      chan *sarama.ConsumerMessage
+	highWaterMark int64
 }
 
 func (c *FakeConsumerGroupClaim) Topic() string {
-	panic("not implemented")
+	return "circonus"
 }
 
 func (c *FakeConsumerGroupClaim) Partition() int32 {
-	panic("not implemented")
+	return 0
 }
 
 func (c *FakeConsumerGroupClaim) InitialOffset() int64 {
@@ -249,7 +251,7 @@ func (c *FakeConsumerGroupClaim) InitialOffset() int64 {
 }
 
 func (c *FakeConsumerGroupClaim) HighWaterMarkOffset() int64 {
-	panic("not implemented")
+	return c.highWaterMark
 }
 
 func (c *FakeConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage {
@@ -291,24 +293,41 @@ func TestConsumerGroupHandler_ConsumeClaim(t *testing.T) {
 
 	session := &FakeConsumerGroupSession{ctx: ctx}
 	claim := &FakeConsumerGroupClaim{
-		messages: make(chan *sarama.ConsumerMessage, 1),
+		messages:      make(chan *sarama.ConsumerMessage, 1),
+		highWaterMark: 10,
 	}
+	cg.ConsumerGroup = "circonus_metrics_consumers"
 
 	err := cg.Setup(session)
 	require.NoError(t, err)
 
 	claim.messages <- &sarama.ConsumerMessage{
-		Topic: "circonus",
-		Value: []byte("42"),
+		Topic:  "circonus",
+		Value:  []byte("42"),
+		Offset: 4,
 	}
 
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		err := cg.ConsumeClaim(session, claim)
 		require.NoError(t, err)
 	}()
 
 	acc.Wait(1)
+
+	lag := selfstat.Register("kafka_consumer", "lag", map[string]string{
+		"consumer_group": "circonus_metrics_consumers",
+		"topic":          "circonus",
+		"partition":      "0",
+	})
+	require.Equal(t, int64(5), lag.Get())
+
 	cancel()
+	<-done
+
+	// the partition is released
+	require.Equal(t, int64(0), lag.Get())
 
 	err = cg.Cleanup(session)
 	require.NoError(t, err)
@@ -0,0 +1,101 @@
+package kinesisconsumer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileCheckpoint stores the last processed sequence number of each shard in
+// a local json file, it is written at most every interval and on flush
+type fileCheckpoint struct {
+	path     string
+	interval time.Duration
+
+	mu        sync.Mutex
+	sequences map[string]string
+	dirty     bool
+	written   time.Time
+}
+
+func newFileCheckpoint(path string, interval time.Duration) (*fileCheckpoint, error) {
+	c := &fileCheckpoint{
+		path:      path,
+		interval:  interval,
+		sequences: make(map[string]string),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("read checkpoint (%s): %w", path, err)
+	}
+	if err := json.Unmarshal(data, &c.sequences); err != nil {
+		return nil, fmt.Errorf("parse checkpoint (%s): %w", path, err)
+	}
+	return c, nil
+}
+
+func checkpointKey(streamName, shardID string) string {
+	return streamName + "/" + shardID
+}
+
+func (c *fileCheckpoint) Get(streamName, shardID string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sequences[checkpointKey(streamName, shardID)], nil
+}
+
+func (c *fileCheckpoint) Set(streamName, shardID, sequenceNumber string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sequences[checkpointKey(streamName, shardID)] = sequenceNumber
+	c.dirty = true
+	if time.Since(c.written) < c.interval {
+		return nil
+	}
+	return c.write()
+}
+
+// flush writes the pending sequence numbers
+func (c *fileCheckpoint) flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	return c.write()
+}
+
+// write replaces the file, so that a crash never leaves a partial file
+func (c *fileCheckpoint) write() error {
+	data, err := json.Marshal(c.sequences)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("write checkpoint (%s): %w", c.path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write checkpoint (%s): %w", c.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write checkpoint (%s): %w", c.path, err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("write checkpoint (%s): %w", c.path, err)
+	}
+	c.dirty = false
+	c.written = time.Now()
+	return nil
+}
@@ -0,0 +1,41 @@
+package kinesisconsumer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	c, err := newFileCheckpoint(path, time.Hour)
+	require.NoError(t, err)
+
+	seq, err := c.Get("stream", "shard-0")
+	require.NoError(t, err)
+	require.Empty(t, seq)
+
+	// the first set is written, the next ones wait for the interval
+	require.NoError(t, c.Set("stream", "shard-0", "100"))
+	require.NoError(t, c.Set("stream", "shard-1", "200"))
+
+	c2, err := newFileCheckpoint(path, time.Hour)
+	require.NoError(t, err)
+	seq, err = c2.Get("stream", "shard-1")
+	require.NoError(t, err)
+	require.Empty(t, seq)
+
+	require.NoError(t, c.flush())
+
+	c2, err = newFileCheckpoint(path, time.Hour)
+	require.NoError(t, err)
+	seq, err = c2.Get("stream", "shard-0")
+	require.NoError(t, err)
+	require.Equal(t, "100", seq)
+	seq, err = c2.Get("stream", "shard-1")
+	require.NoError(t, err)
+	require.Equal(t, "200", seq)
+}
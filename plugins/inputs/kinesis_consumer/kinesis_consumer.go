@@ -37,6 +37,7 @@ type (
 		StreamName             string    `toml:"streamname"`
 		ShardIteratorType      string    `toml:"shard_iterator_type"`
 		DynamoDB               *DynamoDB `toml:"checkpoint_dynamodb"`
+		CheckpointFile         string    `toml:"checkpoint_file"`
 		MaxUndeliveredMessages int       `toml:"max_undelivered_messages"`
 
 		Log cua.Logger
@@ -49,6 +50,7 @@ type (
 		sem chan struct{}
 
 		checkpoint    consumer.Checkpoint
+		file          *fileCheckpoint
 		checkpoints   map[string]checkpoint
 		records       map[cua.TrackingID]string
 		checkpointTex sync.Mutex
@@ -118,6 +120,11 @@ var sampleConfig = `
   ## https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_INPUT.md
   data_format = "influx"
 
+  ## Optional
+  ## Local file keeping the last processed record of each shard, when no
+  ## dynamodb checkpoint is configured
+  # checkpoint_file = "/var/lib/circonus-unified-agent/kinesis_checkpoint.json"
+
   ## Optional
   ## Configuration for a dynamodb checkpoint
   [inputs.kinesis_consumer.checkpoint_dynamodb]
@@ -180,6 +187,14 @@ func (k *KinesisConsumer) connect(ctx context.Context, ac cua.Accumulator) error
 		if err != nil {
 			return fmt.Errorf("dynamo db: %w", err)
 		}
+	} else if k.CheckpointFile != "" {
+		if k.file == nil {
+			k.file, err = newFileCheckpoint(k.CheckpointFile, time.Second*10)
+			if err != nil {
+				return err
+			}
+		}
+		k.checkpoint = k.file
 	}
 
 	cons, err := consumer.New(
@@ -312,6 +327,11 @@ func strToBint(s string) *big.Int {
 func (k *KinesisConsumer) Stop() {
 	k.cancel()
 	k.wg.Wait()
+	if k.file != nil {
+		if err := k.file.flush(); err != nil {
+			k.Log.Error(err)
+		}
+	}
 }
 
 func (k *KinesisConsumer) Gather(ctx context.Context, acc cua.Accumulator) error {
@@ -2,10 +2,9 @@ package kubeinventory
 
 import (
 	"context"
-	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
-	v1 "github.com/ericchiang/k8s/apis/apps/v1"
+	v1 "k8s.io/api/apps/v1"
 )
 
 func collectDaemonSets(ctx context.Context, acc cua.Accumulator, ki *KubernetesInventory) {
@@ -15,8 +14,8 @@ func collectDaemonSets(ctx context.Context, acc cua.Accumulator, ki *KubernetesI
 		return
 	}
 	for _, d := range list.Items {
-		ki.gatherDaemonSet(*d, acc)
-		// if err = ki.gatherDaemonSet(*d, acc); err != nil {
+		ki.gatherDaemonSet(d, acc)
+		// if err = ki.gatherDaemonSet(d, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
@@ -25,27 +24,27 @@ func collectDaemonSets(ctx context.Context, acc cua.Accumulator, ki *KubernetesI
 
 func (ki *KubernetesInventory) gatherDaemonSet(d v1.DaemonSet, acc cua.Accumulator) {
 	fields := map[string]interface{}{
-		"generation":               d.Metadata.GetGeneration(),
-		"current_number_scheduled": d.Status.GetCurrentNumberScheduled(),
-		"desired_number_scheduled": d.Status.GetDesiredNumberScheduled(),
-		"number_available":         d.Status.GetNumberAvailable(),
-		"number_misscheduled":      d.Status.GetNumberMisscheduled(),
-		"number_ready":             d.Status.GetNumberReady(),
-		"number_unavailable":       d.Status.GetNumberUnavailable(),
-		"updated_number_scheduled": d.Status.GetUpdatedNumberScheduled(),
+		"generation":               d.Generation,
+		"current_number_scheduled": d.Status.CurrentNumberScheduled,
+		"desired_number_scheduled": d.Status.DesiredNumberScheduled,
+		"number_available":         d.Status.NumberAvailable,
+		"number_misscheduled":      d.Status.NumberMisscheduled,
+		"number_ready":             d.Status.NumberReady,
+		"number_unavailable":       d.Status.NumberUnavailable,
+		"updated_number_scheduled": d.Status.UpdatedNumberScheduled,
 	}
 	tags := map[string]string{
-		"daemonset_name": d.Metadata.GetName(),
-		"namespace":      d.Metadata.GetNamespace(),
+		"daemonset_name": d.Name,
+		"namespace":      d.Namespace,
 	}
-	for key, val := range d.GetSpec().GetSelector().GetMatchLabels() {
+	for key, val := range matchLabels(d.Spec.Selector) {
 		if ki.selectorFilter.Match(key) {
 			tags["selector_"+key] = val
 		}
 	}
 
-	if d.Metadata.CreationTimestamp.GetSeconds() != 0 {
-		fields["created"] = time.Unix(d.Metadata.CreationTimestamp.GetSeconds(), int64(d.Metadata.CreationTimestamp.GetNanos())).UnixNano()
+	if !d.CreationTimestamp.IsZero() {
+		fields["created"] = d.CreationTimestamp.UnixNano()
 	}
 
 	acc.AddFields(daemonSetMeasurement, fields, tags)
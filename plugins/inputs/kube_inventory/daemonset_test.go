@@ -7,8 +7,8 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
-	v1 "github.com/ericchiang/k8s/apis/apps/v1"
-	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestDaemonSet(t *testing.T) {
@@ -37,28 +37,28 @@ func TestDaemonSet(t *testing.T) {
 			handler: &mockHandler{
 				responseMap: map[string]interface{}{
 					"/daemonsets/": &v1.DaemonSetList{
-						Items: []*v1.DaemonSet{
+						Items: []v1.DaemonSet{
 							{
-								Status: &v1.DaemonSetStatus{
-									CurrentNumberScheduled: toInt32Ptr(3),
-									DesiredNumberScheduled: toInt32Ptr(5),
-									NumberAvailable:        toInt32Ptr(2),
-									NumberMisscheduled:     toInt32Ptr(2),
-									NumberReady:            toInt32Ptr(1),
-									NumberUnavailable:      toInt32Ptr(1),
-									UpdatedNumberScheduled: toInt32Ptr(2),
+								Status: v1.DaemonSetStatus{
+									CurrentNumberScheduled: 3,
+									DesiredNumberScheduled: 5,
+									NumberAvailable:        2,
+									NumberMisscheduled:     2,
+									NumberReady:            1,
+									NumberUnavailable:      1,
+									UpdatedNumberScheduled: 2,
 								},
-								Metadata: &metav1.ObjectMeta{
-									Generation: toInt64Ptr(11221),
-									Namespace:  toStrPtr("ns1"),
-									Name:       toStrPtr("daemon1"),
+								ObjectMeta: metav1.ObjectMeta{
+									Generation: 11221,
+									Namespace:  "ns1",
+									Name:       "daemon1",
 									Labels: map[string]string{
 										"lab1": "v1",
 										"lab2": "v2",
 									},
-									CreationTimestamp: &metav1.Time{Seconds: toInt64Ptr(now.Unix())},
+									CreationTimestamp: metav1.Time{Time: now},
 								},
-								Spec: &v1.DaemonSetSpec{
+								Spec: v1.DaemonSetSpec{
 									Selector: &metav1.LabelSelector{
 										MatchLabels: map[string]string{
 											"select1": "s1",
@@ -107,7 +107,7 @@ func TestDaemonSet(t *testing.T) {
 		_ = ks.createSelectorFilters()
 		acc := new(testutil.Accumulator)
 		for _, dset := range ((v.handler.responseMap["/daemonsets/"]).(*v1.DaemonSetList)).Items {
-			ks.gatherDaemonSet(*dset, acc)
+			ks.gatherDaemonSet(dset, acc)
 			// if err != nil {
 			// 	t.Errorf("Failed to gather daemonset - %s", err.Error())
 			// }
@@ -145,28 +145,28 @@ func TestDaemonSetSelectorFilter(t *testing.T) {
 
 	responseMap := map[string]interface{}{
 		"/daemonsets/": &v1.DaemonSetList{
-			Items: []*v1.DaemonSet{
+			Items: []v1.DaemonSet{
 				{
-					Status: &v1.DaemonSetStatus{
-						CurrentNumberScheduled: toInt32Ptr(3),
-						DesiredNumberScheduled: toInt32Ptr(5),
-						NumberAvailable:        toInt32Ptr(2),
-						NumberMisscheduled:     toInt32Ptr(2),
-						NumberReady:            toInt32Ptr(1),
-						NumberUnavailable:      toInt32Ptr(1),
-						UpdatedNumberScheduled: toInt32Ptr(2),
+					Status: v1.DaemonSetStatus{
+						CurrentNumberScheduled: 3,
+						DesiredNumberScheduled: 5,
+						NumberAvailable:        2,
+						NumberMisscheduled:     2,
+						NumberReady:            1,
+						NumberUnavailable:      1,
+						UpdatedNumberScheduled: 2,
 					},
-					Metadata: &metav1.ObjectMeta{
-						Generation: toInt64Ptr(11221),
-						Namespace:  toStrPtr("ns1"),
-						Name:       toStrPtr("daemon1"),
+					ObjectMeta: metav1.ObjectMeta{
+						Generation: 11221,
+						Namespace:  "ns1",
+						Name:       "daemon1",
 						Labels: map[string]string{
 							"lab1": "v1",
 							"lab2": "v2",
 						},
-						CreationTimestamp: &metav1.Time{Seconds: toInt64Ptr(now.Unix())},
+						CreationTimestamp: metav1.Time{Time: now},
 					},
-					Spec: &v1.DaemonSetSpec{
+					Spec: v1.DaemonSetSpec{
 						Selector: &metav1.LabelSelector{
 							MatchLabels: map[string]string{
 								"select1": "s1",
@@ -283,7 +283,7 @@ func TestDaemonSetSelectorFilter(t *testing.T) {
 		_ = ks.createSelectorFilters()
 		acc := new(testutil.Accumulator)
 		for _, dset := range ((v.handler.responseMap["/daemonsets/"]).(*v1.DaemonSetList)).Items {
-			ks.gatherDaemonSet(*dset, acc)
+			ks.gatherDaemonSet(dset, acc)
 			// if err != nil {
 			// 	t.Errorf("Failed to gather daemonset - %s", err.Error())
 			// }
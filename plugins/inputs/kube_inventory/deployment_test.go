@@ -7,9 +7,9 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
-	v1 "github.com/ericchiang/k8s/apis/apps/v1"
-	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
-	"github.com/ericchiang/k8s/util/intstr"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestDeployment(t *testing.T) {
@@ -52,23 +52,23 @@ func TestDeployment(t *testing.T) {
 			handler: &mockHandler{
 				responseMap: map[string]interface{}{
 					"/deployments/": &v1.DeploymentList{
-						Items: []*v1.Deployment{
+						Items: []v1.Deployment{
 							{
-								Status: &v1.DeploymentStatus{
-									Replicas:            toInt32Ptr(3),
-									AvailableReplicas:   toInt32Ptr(1),
-									UnavailableReplicas: toInt32Ptr(4),
-									UpdatedReplicas:     toInt32Ptr(2),
-									ObservedGeneration:  toInt64Ptr(9121),
+								Status: v1.DeploymentStatus{
+									Replicas:            3,
+									AvailableReplicas:   1,
+									UnavailableReplicas: 4,
+									UpdatedReplicas:     2,
+									ObservedGeneration:  9121,
 								},
-								Spec: &v1.DeploymentSpec{
-									Strategy: &v1.DeploymentStrategy{
+								Spec: v1.DeploymentSpec{
+									Strategy: v1.DeploymentStrategy{
 										RollingUpdate: &v1.RollingUpdateDeployment{
 											MaxUnavailable: &intstr.IntOrString{
-												IntVal: toInt32Ptr(30),
+												IntVal: 30,
 											},
 											MaxSurge: &intstr.IntOrString{
-												IntVal: toInt32Ptr(20),
+												IntVal: 20,
 											},
 										},
 									},
@@ -80,15 +80,15 @@ func TestDeployment(t *testing.T) {
 										},
 									},
 								},
-								Metadata: &metav1.ObjectMeta{
-									Generation: toInt64Ptr(11221),
-									Namespace:  toStrPtr("ns1"),
-									Name:       toStrPtr("deploy1"),
+								ObjectMeta: metav1.ObjectMeta{
+									Generation: 11221,
+									Namespace:  "ns1",
+									Name:       "deploy1",
 									Labels: map[string]string{
 										"lab1": "v1",
 										"lab2": "v2",
 									},
-									CreationTimestamp: &metav1.Time{Seconds: toInt64Ptr(now.Unix())},
+									CreationTimestamp: metav1.Time{Time: now},
 								},
 							},
 						},
@@ -113,7 +113,7 @@ func TestDeployment(t *testing.T) {
 		_ = ks.createSelectorFilters()
 		acc := new(testutil.Accumulator)
 		for _, deployment := range ((v.handler.responseMap["/deployments/"]).(*v1.DeploymentList)).Items {
-			ks.gatherDeployment(*deployment, acc)
+			ks.gatherDeployment(deployment, acc)
 			// if err != nil {
 			// 	t.Errorf("Failed to gather deployment - %s", err.Error())
 			// }
@@ -151,23 +151,23 @@ func TestDeploymentSelectorFilter(t *testing.T) {
 
 	responseMap := map[string]interface{}{
 		"/deployments/": &v1.DeploymentList{
-			Items: []*v1.Deployment{
+			Items: []v1.Deployment{
 				{
-					Status: &v1.DeploymentStatus{
-						Replicas:            toInt32Ptr(3),
-						AvailableReplicas:   toInt32Ptr(1),
-						UnavailableReplicas: toInt32Ptr(4),
-						UpdatedReplicas:     toInt32Ptr(2),
-						ObservedGeneration:  toInt64Ptr(9121),
+					Status: v1.DeploymentStatus{
+						Replicas:            3,
+						AvailableReplicas:   1,
+						UnavailableReplicas: 4,
+						UpdatedReplicas:     2,
+						ObservedGeneration:  9121,
 					},
-					Spec: &v1.DeploymentSpec{
-						Strategy: &v1.DeploymentStrategy{
+					Spec: v1.DeploymentSpec{
+						Strategy: v1.DeploymentStrategy{
 							RollingUpdate: &v1.RollingUpdateDeployment{
 								MaxUnavailable: &intstr.IntOrString{
-									IntVal: toInt32Ptr(30),
+									IntVal: 30,
 								},
 								MaxSurge: &intstr.IntOrString{
-									IntVal: toInt32Ptr(20),
+									IntVal: 20,
 								},
 							},
 						},
@@ -179,15 +179,15 @@ func TestDeploymentSelectorFilter(t *testing.T) {
 							},
 						},
 					},
-					Metadata: &metav1.ObjectMeta{
-						Generation: toInt64Ptr(11221),
-						Namespace:  toStrPtr("ns1"),
-						Name:       toStrPtr("deploy1"),
+					ObjectMeta: metav1.ObjectMeta{
+						Generation: 11221,
+						Namespace:  "ns1",
+						Name:       "deploy1",
 						Labels: map[string]string{
 							"lab1": "v1",
 							"lab2": "v2",
 						},
-						CreationTimestamp: &metav1.Time{Seconds: toInt64Ptr(now.Unix())},
+						CreationTimestamp: metav1.Time{Time: now},
 					},
 				},
 			},
@@ -298,7 +298,7 @@ func TestDeploymentSelectorFilter(t *testing.T) {
 		_ = ks.createSelectorFilters()
 		acc := new(testutil.Accumulator)
 		for _, deployment := range ((v.handler.responseMap["/deployments/"]).(*v1.DeploymentList)).Items {
-			ks.gatherDeployment(*deployment, acc)
+			ks.gatherDeployment(deployment, acc)
 			// if err != nil {
 			// 	t.Errorf("Failed to gather deployment - %s", err.Error())
 			// }
@@ -5,9 +5,10 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
-	v1 "github.com/ericchiang/k8s/apis/core/v1"
-	v1beta1EXT "github.com/ericchiang/k8s/apis/extensions/v1beta1"
-	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	v1 "k8s.io/api/core/v1"
+	v1beta1EXT "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestIngress(t *testing.T) {
@@ -36,30 +37,30 @@ func TestIngress(t *testing.T) {
 			handler: &mockHandler{
 				responseMap: map[string]interface{}{
 					"/ingress/": &v1beta1EXT.IngressList{
-						Items: []*v1beta1EXT.Ingress{
+						Items: []v1beta1EXT.Ingress{
 							{
-								Status: &v1beta1EXT.IngressStatus{
-									LoadBalancer: &v1.LoadBalancerStatus{
-										Ingress: []*v1.LoadBalancerIngress{
+								Status: v1beta1EXT.IngressStatus{
+									LoadBalancer: v1.LoadBalancerStatus{
+										Ingress: []v1.LoadBalancerIngress{
 											{
-												Hostname: toStrPtr("chron-1"),
-												Ip:       toStrPtr("1.0.0.127"),
+												Hostname: "chron-1",
+												IP:       "1.0.0.127",
 											},
 										},
 									},
 								},
-								Spec: &v1beta1EXT.IngressSpec{
-									Rules: []*v1beta1EXT.IngressRule{
+								Spec: v1beta1EXT.IngressSpec{
+									Rules: []v1beta1EXT.IngressRule{
 										{
-											Host: toStrPtr("ui.internal"),
-											IngressRuleValue: &v1beta1EXT.IngressRuleValue{
-												Http: &v1beta1EXT.HTTPIngressRuleValue{
-													Paths: []*v1beta1EXT.HTTPIngressPath{
+											Host: "ui.internal",
+											IngressRuleValue: v1beta1EXT.IngressRuleValue{
+												HTTP: &v1beta1EXT.HTTPIngressRuleValue{
+													Paths: []v1beta1EXT.HTTPIngressPath{
 														{
-															Path: toStrPtr("/"),
-															Backend: &v1beta1EXT.IngressBackend{
-																ServiceName: toStrPtr("chronografd"),
-																ServicePort: toIntStrPtrI(8080),
+															Path: "/",
+															Backend: v1beta1EXT.IngressBackend{
+																ServiceName: "chronografd",
+																ServicePort: intstr.FromInt(8080),
 															},
 														},
 													},
@@ -68,11 +69,11 @@ func TestIngress(t *testing.T) {
 										},
 									},
 								},
-								Metadata: &metav1.ObjectMeta{
-									Generation:        toInt64Ptr(12),
-									Namespace:         toStrPtr("ns1"),
-									Name:              toStrPtr("ui-lb"),
-									CreationTimestamp: &metav1.Time{Seconds: toInt64Ptr(now.Unix())},
+								ObjectMeta: metav1.ObjectMeta{
+									Generation:        12,
+									Namespace:         "ns1",
+									Name:              "ui-lb",
+									CreationTimestamp: metav1.Time{Time: now},
 								},
 							},
 						},
@@ -110,7 +111,7 @@ func TestIngress(t *testing.T) {
 		}
 		acc := new(testutil.Accumulator)
 		for _, ingress := range ((v.handler.responseMap["/ingress/"]).(*v1beta1EXT.IngressList)).Items {
-			ks.gatherIngress(*ingress, acc)
+			ks.gatherIngress(ingress, acc)
 			// if err != nil {
 			// 	t.Errorf("Failed to gather ingress - %s", err.Error())
 			// }
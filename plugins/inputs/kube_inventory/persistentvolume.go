@@ -5,7 +5,7 @@ import (
 	"strings"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
-	v1 "github.com/ericchiang/k8s/apis/core/v1"
+	v1 "k8s.io/api/core/v1"
 )
 
 func collectPersistentVolumes(ctx context.Context, acc cua.Accumulator, ki *KubernetesInventory) {
@@ -15,8 +15,8 @@ func collectPersistentVolumes(ctx context.Context, acc cua.Accumulator, ki *Kube
 		return
 	}
 	for _, pv := range list.Items {
-		ki.gatherPersistentVolume(*pv, acc)
-		// if err = ki.gatherPersistentVolume(*pv, acc); err != nil {
+		ki.gatherPersistentVolume(pv, acc)
+		// if err = ki.gatherPersistentVolume(pv, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
@@ -25,7 +25,7 @@ func collectPersistentVolumes(ctx context.Context, acc cua.Accumulator, ki *Kube
 
 func (ki *KubernetesInventory) gatherPersistentVolume(pv v1.PersistentVolume, acc cua.Accumulator) {
 	phaseType := 5
-	switch strings.ToLower(pv.Status.GetPhase()) {
+	switch strings.ToLower(string(pv.Status.Phase)) {
 	case "bound":
 		phaseType = 0
 	case "failed":
@@ -41,9 +41,9 @@ func (ki *KubernetesInventory) gatherPersistentVolume(pv v1.PersistentVolume, ac
 		"phase_type": phaseType,
 	}
 	tags := map[string]string{
-		"pv_name":      pv.Metadata.GetName(),
-		"phase":        pv.Status.GetPhase(),
-		"storageclass": pv.Spec.GetStorageClassName(),
+		"pv_name":      pv.Name,
+		"phase":        string(pv.Status.Phase),
+		"storageclass": pv.Spec.StorageClassName,
 	}
 
 	acc.AddFields(persistentVolumeMeasurement, fields, tags)
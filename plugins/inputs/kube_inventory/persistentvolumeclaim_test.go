@@ -7,8 +7,8 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
-	v1 "github.com/ericchiang/k8s/apis/core/v1"
-	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestPersistentVolumeClaim(t *testing.T) {
@@ -38,13 +38,13 @@ func TestPersistentVolumeClaim(t *testing.T) {
 			handler: &mockHandler{
 				responseMap: map[string]interface{}{
 					"/persistentvolumeclaims/": &v1.PersistentVolumeClaimList{
-						Items: []*v1.PersistentVolumeClaim{
+						Items: []v1.PersistentVolumeClaim{
 							{
-								Status: &v1.PersistentVolumeClaimStatus{
-									Phase: toStrPtr("bound"),
+								Status: v1.PersistentVolumeClaimStatus{
+									Phase: "bound",
 								},
-								Spec: &v1.PersistentVolumeClaimSpec{
-									VolumeName:       toStrPtr("pvc-dc870fd6-1e08-11e8-b226-02aa4bc06eb8"),
+								Spec: v1.PersistentVolumeClaimSpec{
+									VolumeName:       "pvc-dc870fd6-1e08-11e8-b226-02aa4bc06eb8",
 									StorageClassName: toStrPtr("ebs-1"),
 									Selector: &metav1.LabelSelector{
 										MatchLabels: map[string]string{
@@ -53,14 +53,14 @@ func TestPersistentVolumeClaim(t *testing.T) {
 										},
 									},
 								},
-								Metadata: &metav1.ObjectMeta{
-									Namespace: toStrPtr("ns1"),
-									Name:      toStrPtr("pc1"),
+								ObjectMeta: metav1.ObjectMeta{
+									Namespace: "ns1",
+									Name:      "pc1",
 									Labels: map[string]string{
 										"lab1": "v1",
 										"lab2": "v2",
 									},
-									CreationTimestamp: &metav1.Time{Seconds: toInt64Ptr(now.Unix())},
+									CreationTimestamp: metav1.Time{Time: now},
 								},
 							},
 						},
@@ -97,7 +97,7 @@ func TestPersistentVolumeClaim(t *testing.T) {
 		_ = ks.createSelectorFilters()
 		acc := new(testutil.Accumulator)
 		for _, pvc := range ((v.handler.responseMap["/persistentvolumeclaims/"]).(*v1.PersistentVolumeClaimList)).Items {
-			ks.gatherPersistentVolumeClaim(*pvc, acc)
+			ks.gatherPersistentVolumeClaim(pvc, acc)
 			// if err != nil {
 			// 	t.Errorf("Failed to gather pvc - %s", err.Error())
 			// }
@@ -135,13 +135,13 @@ func TestPersistentVolumeClaimSelectorFilter(t *testing.T) {
 
 	responseMap := map[string]interface{}{
 		"/persistentvolumeclaims/": &v1.PersistentVolumeClaimList{
-			Items: []*v1.PersistentVolumeClaim{
+			Items: []v1.PersistentVolumeClaim{
 				{
-					Status: &v1.PersistentVolumeClaimStatus{
-						Phase: toStrPtr("bound"),
+					Status: v1.PersistentVolumeClaimStatus{
+						Phase: "bound",
 					},
-					Spec: &v1.PersistentVolumeClaimSpec{
-						VolumeName:       toStrPtr("pvc-dc870fd6-1e08-11e8-b226-02aa4bc06eb8"),
+					Spec: v1.PersistentVolumeClaimSpec{
+						VolumeName:       "pvc-dc870fd6-1e08-11e8-b226-02aa4bc06eb8",
 						StorageClassName: toStrPtr("ebs-1"),
 						Selector: &metav1.LabelSelector{
 							MatchLabels: map[string]string{
@@ -150,14 +150,14 @@ func TestPersistentVolumeClaimSelectorFilter(t *testing.T) {
 							},
 						},
 					},
-					Metadata: &metav1.ObjectMeta{
-						Namespace: toStrPtr("ns1"),
-						Name:      toStrPtr("pc1"),
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ns1",
+						Name:      "pc1",
 						Labels: map[string]string{
 							"lab1": "v1",
 							"lab2": "v2",
 						},
-						CreationTimestamp: &metav1.Time{Seconds: toInt64Ptr(now.Unix())},
+						CreationTimestamp: metav1.Time{Time: now},
 					},
 				},
 			},
@@ -268,7 +268,7 @@ func TestPersistentVolumeClaimSelectorFilter(t *testing.T) {
 		_ = ks.createSelectorFilters()
 		acc := new(testutil.Accumulator)
 		for _, pvc := range ((v.handler.responseMap["/persistentvolumeclaims/"]).(*v1.PersistentVolumeClaimList)).Items {
-			ks.gatherPersistentVolumeClaim(*pvc, acc)
+			ks.gatherPersistentVolumeClaim(pvc, acc)
 			// if err != nil {
 			// 	t.Errorf("Failed to gather pvc - %s", err.Error())
 			// }
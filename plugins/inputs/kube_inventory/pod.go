@@ -4,7 +4,7 @@ import (
 	"context"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
-	v1 "github.com/ericchiang/k8s/apis/core/v1"
+	v1 "k8s.io/api/core/v1"
 )
 
 func collectPods(ctx context.Context, acc cua.Accumulator, ki *KubernetesInventory) {
@@ -14,8 +14,8 @@ func collectPods(ctx context.Context, acc cua.Accumulator, ki *KubernetesInvento
 		return
 	}
 	for _, p := range list.Items {
-		ki.gatherPod(*p, acc)
-		// if err = ki.gatherPod(*p, acc); err != nil {
+		ki.gatherPod(p, acc)
+		// if err = ki.gatherPod(p, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
@@ -23,13 +23,13 @@ func collectPods(ctx context.Context, acc cua.Accumulator, ki *KubernetesInvento
 }
 
 func (ki *KubernetesInventory) gatherPod(p v1.Pod, acc cua.Accumulator) {
-	if p.Metadata.CreationTimestamp.GetSeconds() == 0 && p.Metadata.CreationTimestamp.GetNanos() == 0 {
+	if p.CreationTimestamp.IsZero() {
 		return
 	}
 
 	for i, cs := range p.Status.ContainerStatuses {
 		c := p.Spec.Containers[i]
-		gatherPodContainer(ki, p, *cs, *c, acc)
+		gatherPodContainer(ki, p, cs, c, acc)
 	}
 }
 
@@ -37,6 +37,7 @@ func gatherPodContainer(ki *KubernetesInventory, p v1.Pod, cs v1.ContainerStatus
 	stateCode := 3
 	stateReason := ""
 	state := "unknown"
+	terminatedReason := ""
 
 	switch {
 	case cs.State.Running != nil:
@@ -45,22 +46,23 @@ func gatherPodContainer(ki *KubernetesInventory, p v1.Pod, cs v1.ContainerStatus
 	case cs.State.Terminated != nil:
 		stateCode = 1
 		state = "terminated"
-		stateReason = cs.State.Terminated.GetReason()
+		stateReason = cs.State.Terminated.Reason
+		terminatedReason = cs.State.Terminated.Reason
 	case cs.State.Waiting != nil:
 		stateCode = 2
 		state = "waiting"
-		stateReason = cs.State.Waiting.GetReason()
+		stateReason = cs.State.Waiting.Reason
 	}
 
 	readiness := "unready"
-	if cs.GetReady() {
+	if cs.Ready {
 		readiness = "ready"
 	}
 
 	fields := map[string]interface{}{
-		"restarts_total":    cs.GetRestartCount(),
+		"restarts_total":    cs.RestartCount,
 		"state_code":        stateCode,
-		"terminated_reason": cs.State.Terminated.GetReason(),
+		"terminated_reason": terminatedReason,
 	}
 
 	if stateReason != "" {
@@ -68,14 +70,14 @@ func gatherPodContainer(ki *KubernetesInventory, p v1.Pod, cs v1.ContainerStatus
 	}
 
 	tags := map[string]string{
-		"container_name": *c.Name,
-		"namespace":      *p.Metadata.Namespace,
-		"node_name":      *p.Spec.NodeName,
-		"pod_name":       *p.Metadata.Name,
+		"container_name": c.Name,
+		"namespace":      p.Namespace,
+		"node_name":      p.Spec.NodeName,
+		"pod_name":       p.Name,
 		"state":          state,
 		"readiness":      readiness,
 	}
-	for key, val := range p.GetSpec().GetNodeSelector() {
+	for key, val := range p.Spec.NodeSelector {
 		if ki.selectorFilter.Match(key) {
 			tags["node_selector_"+key] = val
 		}
@@ -87,17 +89,17 @@ func gatherPodContainer(ki *KubernetesInventory, p v1.Pod, cs v1.ContainerStatus
 	for resourceName, val := range req {
 		switch resourceName {
 		case "cpu":
-			fields["resource_requests_millicpu_units"] = convertQuantity(val.GetString_(), 1000)
+			fields["resource_requests_millicpu_units"] = convertQuantity(val.String(), 1000)
 		case "memory":
-			fields["resource_requests_memory_bytes"] = convertQuantity(val.GetString_(), 1)
+			fields["resource_requests_memory_bytes"] = convertQuantity(val.String(), 1)
 		}
 	}
 	for resourceName, val := range lim {
 		switch resourceName {
 		case "cpu":
-			fields["resource_limits_millicpu_units"] = convertQuantity(val.GetString_(), 1000)
+			fields["resource_limits_millicpu_units"] = convertQuantity(val.String(), 1000)
 		case "memory":
-			fields["resource_limits_memory_bytes"] = convertQuantity(val.GetString_(), 1)
+			fields["resource_limits_memory_bytes"] = convertQuantity(val.String(), 1)
 		}
 	}
 
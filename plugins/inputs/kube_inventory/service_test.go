@@ -7,8 +7,9 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
-	v1 "github.com/ericchiang/k8s/apis/core/v1"
-	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestService(t *testing.T) {
@@ -38,29 +39,29 @@ func TestService(t *testing.T) {
 			handler: &mockHandler{
 				responseMap: map[string]interface{}{
 					"/service/": &v1.ServiceList{
-						Items: []*v1.Service{
+						Items: []v1.Service{
 							{
-								Spec: &v1.ServiceSpec{
-									Ports: []*v1.ServicePort{
+								Spec: v1.ServiceSpec{
+									Ports: []v1.ServicePort{
 										{
-											Port:       toInt32Ptr(8080),
-											TargetPort: toIntStrPtrI(1234),
-											Name:       toStrPtr("diagnostic"),
-											Protocol:   toStrPtr("TCP"),
+											Port:       8080,
+											TargetPort: intstr.FromInt(1234),
+											Name:       "diagnostic",
+											Protocol:   "TCP",
 										},
 									},
 									ExternalIPs: []string{"1.0.0.127"},
-									ClusterIP:   toStrPtr("127.0.0.1"),
+									ClusterIP:   "127.0.0.1",
 									Selector: map[string]string{
 										"select1": "s1",
 										"select2": "s2",
 									},
 								},
-								Metadata: &metav1.ObjectMeta{
-									Generation:        toInt64Ptr(12),
-									Namespace:         toStrPtr("ns1"),
-									Name:              toStrPtr("checker"),
-									CreationTimestamp: &metav1.Time{Seconds: toInt64Ptr(now.Unix())},
+								ObjectMeta: metav1.ObjectMeta{
+									Generation:        12,
+									Namespace:         "ns1",
+									Name:              "checker",
+									CreationTimestamp: metav1.Time{Time: now},
 								},
 							},
 						},
@@ -103,7 +104,7 @@ func TestService(t *testing.T) {
 		_ = ks.createSelectorFilters()
 		acc := new(testutil.Accumulator)
 		for _, service := range ((v.handler.responseMap["/service/"]).(*v1.ServiceList)).Items {
-			ks.gatherService(*service, acc)
+			ks.gatherService(service, acc)
 			// if err != nil {
 			// 	t.Errorf("Failed to gather service - %s", err.Error())
 			// }
@@ -141,29 +142,29 @@ func TestServiceSelectorFilter(t *testing.T) {
 
 	responseMap := map[string]interface{}{
 		"/service/": &v1.ServiceList{
-			Items: []*v1.Service{
+			Items: []v1.Service{
 				{
-					Spec: &v1.ServiceSpec{
-						Ports: []*v1.ServicePort{
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{
 							{
-								Port:       toInt32Ptr(8080),
-								TargetPort: toIntStrPtrI(1234),
-								Name:       toStrPtr("diagnostic"),
-								Protocol:   toStrPtr("TCP"),
+								Port:       8080,
+								TargetPort: intstr.FromInt(1234),
+								Name:       "diagnostic",
+								Protocol:   "TCP",
 							},
 						},
 						ExternalIPs: []string{"1.0.0.127"},
-						ClusterIP:   toStrPtr("127.0.0.1"),
+						ClusterIP:   "127.0.0.1",
 						Selector: map[string]string{
 							"select1": "s1",
 							"select2": "s2",
 						},
 					},
-					Metadata: &metav1.ObjectMeta{
-						Generation:        toInt64Ptr(12),
-						Namespace:         toStrPtr("ns1"),
-						Name:              toStrPtr("checker"),
-						CreationTimestamp: &metav1.Time{Seconds: toInt64Ptr(now.Unix())},
+					ObjectMeta: metav1.ObjectMeta{
+						Generation:        12,
+						Namespace:         "ns1",
+						Name:              "checker",
+						CreationTimestamp: metav1.Time{Time: now},
 					},
 				},
 			},
@@ -274,7 +275,7 @@ func TestServiceSelectorFilter(t *testing.T) {
 		_ = ks.createSelectorFilters()
 		acc := new(testutil.Accumulator)
 		for _, service := range ((v.handler.responseMap["/service/"]).(*v1.ServiceList)).Items {
-			ks.gatherService(*service, acc)
+			ks.gatherService(service, acc)
 			// if err != nil {
 			// 	t.Errorf("Failed to gather service - %s", err.Error())
 			// }
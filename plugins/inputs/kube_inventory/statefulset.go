@@ -2,10 +2,9 @@ package kubeinventory
 
 import (
 	"context"
-	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
-	v1 "github.com/ericchiang/k8s/apis/apps/v1"
+	v1 "k8s.io/api/apps/v1"
 )
 
 func collectStatefulSets(ctx context.Context, acc cua.Accumulator, ki *KubernetesInventory) {
@@ -15,8 +14,8 @@ func collectStatefulSets(ctx context.Context, acc cua.Accumulator, ki *Kubernete
 		return
 	}
 	for _, s := range list.Items {
-		ki.gatherStatefulSet(*s, acc)
-		// if err = ki.gatherStatefulSet(*s, acc); err != nil {
+		ki.gatherStatefulSet(s, acc)
+		// if err = ki.gatherStatefulSet(s, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
@@ -26,20 +25,22 @@ func collectStatefulSets(ctx context.Context, acc cua.Accumulator, ki *Kubernete
 func (ki *KubernetesInventory) gatherStatefulSet(s v1.StatefulSet, acc cua.Accumulator) {
 	status := s.Status
 	fields := map[string]interface{}{
-		"created":             time.Unix(s.Metadata.CreationTimestamp.GetSeconds(), int64(s.Metadata.CreationTimestamp.GetNanos())).UnixNano(),
-		"generation":          *s.Metadata.Generation,
-		"replicas":            *status.Replicas,
-		"replicas_current":    *status.CurrentReplicas,
-		"replicas_ready":      *status.ReadyReplicas,
-		"replicas_updated":    *status.UpdatedReplicas,
-		"spec_replicas":       *s.Spec.Replicas,
-		"observed_generation": *s.Status.ObservedGeneration,
+		"created":             s.CreationTimestamp.UnixNano(),
+		"generation":          s.Generation,
+		"replicas":            status.Replicas,
+		"replicas_current":    status.CurrentReplicas,
+		"replicas_ready":      status.ReadyReplicas,
+		"replicas_updated":    status.UpdatedReplicas,
+		"observed_generation": status.ObservedGeneration,
+	}
+	if s.Spec.Replicas != nil {
+		fields["spec_replicas"] = *s.Spec.Replicas
 	}
 	tags := map[string]string{
-		"statefulset_name": *s.Metadata.Name,
-		"namespace":        *s.Metadata.Namespace,
+		"statefulset_name": s.Name,
+		"namespace":        s.Namespace,
 	}
-	for key, val := range s.GetSpec().GetSelector().GetMatchLabels() {
+	for key, val := range matchLabels(s.Spec.Selector) {
 		if ki.selectorFilter.Match(key) {
 			tags["selector_"+key] = val
 		}
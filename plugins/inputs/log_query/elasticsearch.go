@@ -0,0 +1,117 @@
+package logquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// elasticsearch runs search requests with the Elasticsearch search API
+type elasticsearch struct {
+	client *client
+}
+
+type searchResponse struct {
+	Hits struct {
+		Total json.RawMessage `json:"total"`
+	} `json:"hits"`
+	Aggregations map[string]interface{} `json:"aggregations"`
+}
+
+func (e *elasticsearch) query(ctx context.Context, q *Query) ([]result, error) {
+	path := "/" + url.PathEscape(q.Index) + "/_search?size=0"
+	var r searchResponse
+	if err := e.client.do(ctx, http.MethodPost, path, q.Query, &r); err != nil {
+		return nil, err
+	}
+
+	count, err := hitsTotal(r.Hits.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	root := result{tags: map[string]string{}, fields: map[string]interface{}{"count": count}}
+	return flattenAggregations(root, r.Aggregations), nil
+}
+
+// hitsTotal returns the hit count, an object since Elasticsearch 7 and a
+// number before
+func hitsTotal(raw json.RawMessage) (int64, error) {
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	var total struct {
+		Value int64 `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &total); err == nil {
+		return total.Value, nil
+	}
+	var n int64
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return 0, fmt.Errorf("parsing hits total: %w", err)
+	}
+	return n, nil
+}
+
+// flattenAggregations adds the metric aggregations as fields of parent and
+// returns parent followed by a result per bucket of the bucket aggregations,
+// tagged with the bucket key and with the bucket doc_count as the count field
+func flattenAggregations(parent result, aggs map[string]interface{}) []result {
+	var buckets []result
+	for name, v := range aggs {
+		agg, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, ok := agg["value"].(float64); ok {
+			parent.fields[name] = value
+			continue
+		}
+		list, ok := agg["buckets"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, b := range list {
+			bucket, ok := b.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			r := result{
+				tags:   make(map[string]string, len(parent.tags)+1),
+				fields: make(map[string]interface{}),
+			}
+			for k, v := range parent.tags {
+				r.tags[k] = v
+			}
+			r.tags[name] = bucketKey(bucket)
+			if count, ok := bucket["doc_count"].(float64); ok {
+				r.fields["count"] = int64(count)
+			}
+			sub := make(map[string]interface{}, len(bucket))
+			for k, v := range bucket {
+				if k != "key" && k != "key_as_string" && k != "doc_count" {
+					sub[k] = v
+				}
+			}
+			buckets = append(buckets, flattenAggregations(r, sub)...)
+		}
+	}
+	return append([]result{parent}, buckets...)
+}
+
+func bucketKey(bucket map[string]interface{}) string {
+	if s, ok := bucket["key_as_string"].(string); ok {
+		return s
+	}
+	switch key := bucket["key"].(type) {
+	case string:
+		return key
+	case float64:
+		return strconv.FormatFloat(key, 'f', -1, 64)
+	default:
+		return fmt.Sprint(key)
+	}
+}
@@ -0,0 +1,196 @@
+// Package logquery runs count and aggregation queries against log stores,
+// Grafana Loki or Elasticsearch, and reports the results as metrics.
+package logquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const defaultMeasurement = "log_query"
+
+type LogQuery struct {
+	Log         cua.Logger `toml:"-"`
+	store       store
+	Type        string            `toml:"type"`
+	URL         string            `toml:"url"`
+	Username    string            `toml:"username"`
+	Password    string            `toml:"password"`
+	BearerToken string            `toml:"bearer_token"`
+	Headers     map[string]string `toml:"headers"`
+	Queries     []*Query          `toml:"query"`
+	Timeout     internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+}
+
+// Query is a LogQL query or an Elasticsearch search request body
+type Query struct {
+	Measurement string            `toml:"measurement"`
+	Query       string            `toml:"query"`
+	Index       string            `toml:"index"`
+	Tags        map[string]string `toml:"tags"`
+}
+
+// result is a row of the result of a query
+type result struct {
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+// store runs the queries against a log store
+type store interface {
+	query(ctx context.Context, q *Query) ([]result, error)
+}
+
+const sampleConfig = `
+  ## Type of log store, "loki" or "elasticsearch"
+  type = "loki"
+
+  ## URL of the log store
+  url = "http://localhost:3100"
+
+  ## Optional HTTP basic auth credentials or bearer token
+  # username = ""
+  # password = ""
+  # bearer_token = ""
+
+  ## Optional HTTP headers, e.g. the Loki tenant
+  # headers = {"X-Scope-OrgID" = "tenant1"}
+
+  ## Timeout of each query
+  # timeout = "10s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Loki instant LogQL queries, each series of the result is a metric with
+  ## the series labels as tags and a "value" field.
+  [[inputs.log_query.query]]
+    measurement = "log_errors"
+    query = 'sum by (service) (count_over_time({env="prod"} |= "error" [5m]))'
+    ## Tags added to the metrics of the query
+    # tags = {}
+
+  ## Elasticsearch search request bodies run against an index pattern, the
+  ## hit count is the "count" field, metric aggregations are fields named
+  ## after the aggregation and bucket aggregations add a tag per bucket.
+  # [[inputs.log_query.query]]
+  #   measurement = "log_errors"
+  #   index = "logs-*"
+  #   query = '''
+  #   {
+  #     "query": {"bool": {"filter": [
+  #       {"match": {"level": "error"}},
+  #       {"range": {"@timestamp": {"gte": "now-5m"}}}
+  #     ]}},
+  #     "aggs": {"service": {"terms": {"field": "service.keyword"}}}
+  #   }
+  #   '''
+`
+
+func (l *LogQuery) Description() string {
+	return "Run Loki LogQL or Elasticsearch queries and report the results as metrics"
+}
+
+func (l *LogQuery) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *LogQuery) Init() error {
+	if l.URL == "" {
+		return errors.New("url is required")
+	}
+	if len(l.Queries) == 0 {
+		return errors.New("no queries configured")
+	}
+	for _, q := range l.Queries {
+		if q.Query == "" {
+			return errors.New("query is required")
+		}
+		if q.Measurement == "" {
+			q.Measurement = defaultMeasurement
+		}
+	}
+
+	tlsConfig, err := l.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	c := &client{
+		url: strings.TrimSuffix(l.URL, "/"),
+		http: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+			Timeout: l.Timeout.Duration,
+		},
+		username:    l.Username,
+		password:    l.Password,
+		bearerToken: l.BearerToken,
+		headers:     l.Headers,
+	}
+
+	switch l.Type {
+	case "loki":
+		l.store = &loki{client: c}
+	case "elasticsearch":
+		for _, q := range l.Queries {
+			if q.Index == "" {
+				return fmt.Errorf("index is required for elasticsearch query %q", q.Query)
+			}
+		}
+		l.store = &elasticsearch{client: c}
+	default:
+		return fmt.Errorf("invalid type %q, must be loki or elasticsearch", l.Type)
+	}
+
+	return nil
+}
+
+func (l *LogQuery) Gather(ctx context.Context, acc cua.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, q := range l.Queries {
+		wg.Add(1)
+		go func(q *Query) {
+			defer wg.Done()
+			results, err := l.store.query(ctx, q)
+			if err != nil {
+				acc.AddError(fmt.Errorf("query %q: %w", q.Query, err))
+				return
+			}
+			now := time.Now()
+			for _, r := range results {
+				for k, v := range q.Tags {
+					r.tags[k] = v
+				}
+				acc.AddFields(q.Measurement, r.fields, r.tags, now)
+			}
+		}(q)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func init() {
+	inputs.Add("log_query", func() cua.Input {
+		return &LogQuery{
+			Timeout: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
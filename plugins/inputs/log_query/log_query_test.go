@@ -0,0 +1,210 @@
+package logquery
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const lokiVectorResponse = `
+{
+  "status": "success",
+  "data": {
+    "resultType": "vector",
+    "result": [
+      {"metric": {"service": "api"}, "value": [1622470000.123, "12"]},
+      {"metric": {"service": "web"}, "value": [1622470000.123, "3"]}
+    ]
+  }
+}`
+
+const esResponse = `
+{
+  "took": 5,
+  "hits": {"total": {"value": 15, "relation": "eq"}, "hits": []},
+  "aggregations": {
+    "service": {
+      "buckets": [
+        {"key": "api", "doc_count": 12, "latency": {"value": 0.25}},
+        {"key": "web", "doc_count": 3, "latency": {"value": 0.5}}
+      ]
+    }
+  }
+}`
+
+func TestGatherLoki(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/loki/api/v1/query", r.URL.Path)
+		require.Equal(t, `sum by (service) (count_over_time({env="prod"} |= "error" [5m]))`, r.URL.Query().Get("query"))
+		require.Equal(t, "tenant1", r.Header.Get("X-Scope-OrgID"))
+		_, _ = w.Write([]byte(lokiVectorResponse))
+	}))
+	defer ts.Close()
+
+	l := &LogQuery{
+		Type:    "loki",
+		URL:     ts.URL,
+		Headers: map[string]string{"X-Scope-OrgID": "tenant1"},
+		Queries: []*Query{{
+			Measurement: "log_errors",
+			Query:       `sum by (service) (count_over_time({env="prod"} |= "error" [5m]))`,
+			Tags:        map[string]string{"env": "prod"},
+		}},
+	}
+	require.NoError(t, l.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, l.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("log_errors",
+			map[string]string{"service": "api", "env": "prod"},
+			map[string]interface{}{"value": 12.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("log_errors",
+			map[string]string{"service": "web", "env": "prod"},
+			map[string]interface{}{"value": 3.0},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherLokiLogQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "streams", "result": []}}`))
+	}))
+	defer ts.Close()
+
+	l := &LogQuery{
+		Type:    "loki",
+		URL:     ts.URL,
+		Queries: []*Query{{Query: `{env="prod"}`}},
+	}
+	require.NoError(t, l.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, l.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+}
+
+func TestGatherElasticsearch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/logs-*/_search", r.URL.Path)
+		require.Equal(t, "0", r.URL.Query().Get("size"))
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", user)
+		require.Equal(t, "pass", pass)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"aggs": {"service": {"terms": {"field": "service"}}}}`, string(body))
+		_, _ = w.Write([]byte(esResponse))
+	}))
+	defer ts.Close()
+
+	l := &LogQuery{
+		Type:     "elasticsearch",
+		URL:      ts.URL,
+		Username: "user",
+		Password: "pass",
+		Queries: []*Query{{
+			Index: "logs-*",
+			Query: `{"aggs": {"service": {"terms": {"field": "service"}}}}`,
+		}},
+	}
+	require.NoError(t, l.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, l.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("log_query",
+			map[string]string{},
+			map[string]interface{}{"count": int64(15)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("log_query",
+			map[string]string{"service": "api"},
+			map[string]interface{}{"count": int64(12), "latency": 0.25},
+			time.Unix(0, 0)),
+		testutil.MustMetric("log_query",
+			map[string]string{"service": "web"},
+			map[string]interface{}{"count": int64(3), "latency": 0.5},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestFlattenAggregationsMixed(t *testing.T) {
+	aggs := map[string]interface{}{
+		"latency": map[string]interface{}{"value": 0.3},
+		"service": map[string]interface{}{
+			"buckets": []interface{}{
+				map[string]interface{}{
+					"key":       "api",
+					"doc_count": 12.0,
+					"latency":   map[string]interface{}{"value": 0.25},
+					"status": map[string]interface{}{
+						"buckets": []interface{}{
+							map[string]interface{}{"key": 500.0, "doc_count": 2.0},
+						},
+					},
+				},
+			},
+		},
+	}
+	root := result{tags: map[string]string{}, fields: map[string]interface{}{"count": int64(15)}}
+
+	results := flattenAggregations(root, aggs)
+	require.Equal(t, []result{
+		{
+			tags:   map[string]string{},
+			fields: map[string]interface{}{"count": int64(15), "latency": 0.3},
+		},
+		{
+			tags:   map[string]string{"service": "api"},
+			fields: map[string]interface{}{"count": int64(12), "latency": 0.25},
+		},
+		{
+			tags:   map[string]string{"service": "api", "status": "500"},
+			fields: map[string]interface{}{"count": int64(2)},
+		},
+	}, results)
+}
+
+func TestHitsTotal(t *testing.T) {
+	n, err := hitsTotal([]byte(`{"value": 7, "relation": "eq"}`))
+	require.NoError(t, err)
+	require.Equal(t, int64(7), n)
+
+	// before elasticsearch 7
+	n, err = hitsTotal([]byte(`42`))
+	require.NoError(t, err)
+	require.Equal(t, int64(42), n)
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name string
+		l    *LogQuery
+	}{
+		{name: "no url", l: &LogQuery{Type: "loki", Queries: []*Query{{Query: "q"}}}},
+		{name: "no queries", l: &LogQuery{Type: "loki", URL: "http://localhost"}},
+		{name: "invalid type", l: &LogQuery{Type: "splunk", URL: "http://localhost", Queries: []*Query{{Query: "q"}}}},
+		{name: "no index", l: &LogQuery{Type: "elasticsearch", URL: "http://localhost", Queries: []*Query{{Query: "{}"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.l.Init())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package logquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// loki runs instant LogQL queries with the Loki HTTP API
+type loki struct {
+	client *client
+}
+
+type lokiResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type lokiSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]interface{}    `json:"value"`
+}
+
+func (l *loki) query(ctx context.Context, q *Query) ([]result, error) {
+	path := "/loki/api/v1/query?" + url.Values{"query": {q.Query}}.Encode()
+	var r lokiResponse
+	if err := l.client.do(ctx, http.MethodGet, path, "", &r); err != nil {
+		return nil, err
+	}
+
+	switch r.Data.ResultType {
+	case "vector":
+		var samples []lokiSample
+		if err := json.Unmarshal(r.Data.Result, &samples); err != nil {
+			return nil, fmt.Errorf("parsing vector: %w", err)
+		}
+		results := make([]result, 0, len(samples))
+		for _, s := range samples {
+			v, err := sampleValue(s.Value)
+			if err != nil {
+				return nil, err
+			}
+			tags := make(map[string]string, len(s.Metric))
+			for k, v := range s.Metric {
+				tags[k] = v
+			}
+			results = append(results, result{tags: tags, fields: map[string]interface{}{"value": v}})
+		}
+		return results, nil
+	case "scalar":
+		var s [2]interface{}
+		if err := json.Unmarshal(r.Data.Result, &s); err != nil {
+			return nil, fmt.Errorf("parsing scalar: %w", err)
+		}
+		v, err := sampleValue(s)
+		if err != nil {
+			return nil, err
+		}
+		return []result{{tags: map[string]string{}, fields: map[string]interface{}{"value": v}}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %q, the query must be a metric query", r.Data.ResultType)
+	}
+}
+
+// sampleValue returns the value of a [timestamp, "value"] sample
+func sampleValue(sample [2]interface{}) (float64, error) {
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid sample value %v", sample[1])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sample value %q: %w", s, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,160 @@
+//go:build darwin
+// +build darwin
+
+package macos
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"golang.org/x/sys/unix"
+)
+
+type MacOS struct {
+	Log     cua.Logger `toml:"-"`
+	run     runner
+	Timeout internal.Duration `toml:"timeout"`
+	CPU     bool              `toml:"cpu"`
+	Memory  bool              `toml:"memory"`
+	Thermal bool              `toml:"thermal"`
+	Battery bool              `toml:"battery"`
+}
+
+const sampleConfig = `
+  ## Report cpu usage from top, this adds about one second to each collection
+  # cpu = true
+
+  ## Report memory pressure and swap usage from sysctl
+  # memory = true
+
+  ## Report thermal and performance warning levels from pmset
+  # thermal = true
+
+  ## Report battery charge, health and cycle count from the IOKit registry
+  # battery = true
+
+  ## Timeout for running top, pmset and ioreg
+  # timeout = "5s"
+`
+
+type runner func(timeout time.Duration, name string, args ...string) ([]byte, error)
+
+func (m *MacOS) Description() string {
+	return "Read macOS cpu, memory pressure, thermal state and battery metrics"
+}
+
+func (m *MacOS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *MacOS) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if m.CPU {
+		if err := m.gatherCPU(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if m.Memory {
+		if err := m.gatherMemory(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if m.Thermal {
+		if err := m.gatherThermal(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if m.Battery {
+		if err := m.gatherBattery(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+func (m *MacOS) gatherCPU(acc cua.Accumulator) error {
+	out, err := m.run(m.Timeout.Duration, "top", "-l", "2", "-n", "0", "-s", "1")
+	if err != nil {
+		return err
+	}
+	if fields := parseTopCPU(out); len(fields) > 0 {
+		acc.AddGauge("macos_cpu", fields, nil)
+	}
+	return nil
+}
+
+func (m *MacOS) gatherMemory(acc cua.Accumulator) error {
+	fields := make(map[string]interface{})
+
+	// 1 normal, 2 warning, 4 critical
+	if level, err := unix.SysctlUint32("kern.memorystatus_vm_pressure_level"); err == nil {
+		fields["pressure_level"] = int64(level)
+	}
+	// percentage of memory available before the system is under pressure
+	if level, err := unix.SysctlUint32("kern.memorystatus_level"); err == nil {
+		fields["available_percent"] = int64(level)
+	}
+	if total, err := unix.SysctlUint64("hw.memsize"); err == nil {
+		fields["total"] = total
+	}
+
+	// struct xsw_usage { u_int64_t xsu_total, xsu_avail, xsu_used; ... }
+	if swap, err := unix.SysctlRaw("vm.swapusage"); err == nil && len(swap) >= 24 {
+		fields["swap_total"] = binary.LittleEndian.Uint64(swap[0:8])
+		fields["swap_free"] = binary.LittleEndian.Uint64(swap[8:16])
+		fields["swap_used"] = binary.LittleEndian.Uint64(swap[16:24])
+	}
+
+	if len(fields) == 0 {
+		return fmt.Errorf("no memory statistics available from sysctl")
+	}
+	acc.AddGauge("macos_memory", fields, nil)
+	return nil
+}
+
+func (m *MacOS) gatherThermal(acc cua.Accumulator) error {
+	out, err := m.run(m.Timeout.Duration, "pmset", "-g", "therm")
+	if err != nil {
+		return err
+	}
+	acc.AddGauge("macos_thermal", parsePmsetTherm(out), nil)
+	return nil
+}
+
+func (m *MacOS) gatherBattery(acc cua.Accumulator) error {
+	out, err := m.run(m.Timeout.Duration, "ioreg", "-r", "-n", "AppleSmartBattery")
+	if err != nil {
+		return err
+	}
+	// desktops have no battery
+	if fields := parseIoregBattery(out); fields != nil {
+		acc.AddGauge("macos_battery", fields, nil)
+	}
+	return nil
+}
+
+func run(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	out, err := internal.CombinedOutputTimeout(exec.Command(name, args...), timeout)
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %w", name, err)
+	}
+	return out, nil
+}
+
+func init() {
+	inputs.Add("macos", func() cua.Input {
+		return &MacOS{
+			run:     run,
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+			CPU:     true,
+			Memory:  true,
+			Thermal: true,
+			Battery: true,
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package macos
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTopCPU(t *testing.T) {
+	out, err := os.ReadFile("testdata/top.txt")
+	require.NoError(t, err)
+
+	// the second sample is used, the first is averaged since boot
+	require.Equal(t, map[string]interface{}{
+		"usage_user":   12.5,
+		"usage_system": 8.25,
+		"usage_idle":   79.25,
+		"usage_active": 100 - 79.25,
+	}, parseTopCPU(out))
+
+	require.Nil(t, parseTopCPU([]byte("Processes: 512 total\n")))
+}
+
+func TestParsePmsetTherm(t *testing.T) {
+	out, err := os.ReadFile("testdata/pmset_therm.txt")
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]interface{}{
+		"thermal_warning_level":     int64(0),
+		"performance_warning_level": int64(2),
+		"cpu_scheduler_limit":       int64(100),
+		"cpu_available_cpus":        int64(8),
+		"cpu_speed_limit":           int64(74),
+	}, parsePmsetTherm(out))
+}
+
+func TestParseIoregBattery(t *testing.T) {
+	out, err := os.ReadFile("testdata/ioreg_battery.txt")
+	require.NoError(t, err)
+
+	fields := parseIoregBattery(out)
+	require.Equal(t, map[string]interface{}{
+		"present":            int64(1),
+		"charging":           int64(0),
+		"external_connected": int64(0),
+		"fully_charged":      int64(0),
+		"cycle_count":        int64(87),
+		"percent":            float64(84),
+		"current_capacity":   int64(3986),
+		"max_capacity":       int64(4781),
+		"design_capacity":    int64(5103),
+		"health_percent":     float64(4781) / float64(5103) * 100,
+		"temperature":        30.12,
+		"voltage":            12.433,
+		"amperage":           -1.21,
+		"time_remaining":     int64(187 * 60),
+	}, fields)
+
+	// no battery, e.g. a Mac mini
+	require.Nil(t, parseIoregBattery([]byte("")))
+}
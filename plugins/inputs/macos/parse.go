@@ -0,0 +1,198 @@
+// Package macos reports macOS host metrics that are not available from the
+// portable inputs when built without cgo.
+package macos
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// parseTopCPU parses the cpu usage percentages from the last sample of
+// "top -l 2 -n 0", the first sample is averaged since boot.
+//
+//	CPU usage: 7.69% user, 15.38% sys, 76.92% idle
+func parseTopCPU(out []byte) map[string]interface{} {
+	var last string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "CPU usage:") {
+			last = strings.TrimPrefix(line, "CPU usage:")
+		}
+	}
+	if last == "" {
+		return nil
+	}
+
+	fields := make(map[string]interface{})
+	for _, part := range strings.Split(last, ",") {
+		col := strings.Fields(part)
+		if len(col) != 2 {
+			continue
+		}
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(col[0], "%"), 64)
+		if err != nil {
+			continue
+		}
+		switch col[1] {
+		case "user":
+			fields["usage_user"] = pct
+		case "sys":
+			fields["usage_system"] = pct
+		case "idle":
+			fields["usage_idle"] = pct
+			fields["usage_active"] = 100 - pct
+		}
+	}
+	return fields
+}
+
+// parsePmsetTherm parses the output of "pmset -g therm"
+//
+//	Note: No thermal warning level has been recorded
+//	Note: No performance warning level has been recorded
+//	2021-06-08 14:21:07 -0700 CPU Power notify
+//		CPU_Scheduler_Limit 	= 100
+//		CPU_Available_CPUs 	= 8
+//		CPU_Speed_Limit 	= 100
+func parsePmsetTherm(out []byte) map[string]interface{} {
+	fields := map[string]interface{}{
+		"thermal_warning_level":     int64(0),
+		"performance_warning_level": int64(0),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Thermal warning level set to 1.
+		for prefix, field := range map[string]string{
+			"Thermal warning level set to":     "thermal_warning_level",
+			"Performance warning level set to": "performance_warning_level",
+		} {
+			if i := strings.Index(line, prefix); i >= 0 {
+				v := strings.TrimSuffix(strings.TrimSpace(line[i+len(prefix):]), ".")
+				if level, err := strconv.ParseInt(v, 10, 64); err == nil {
+					fields[field] = level
+				}
+			}
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "CPU_Scheduler_Limit":
+			fields["cpu_scheduler_limit"] = v
+		case "CPU_Available_CPUs":
+			fields["cpu_available_cpus"] = v
+		case "CPU_Speed_Limit":
+			fields["cpu_speed_limit"] = v
+		}
+	}
+	return fields
+}
+
+// parseIoregBattery parses the AppleSmartBattery properties from
+// "ioreg -r -n AppleSmartBattery", nil is returned when there is no battery.
+//
+//	"CycleCount" = 87
+//	"IsCharging" = Yes
+func parseIoregBattery(out []byte) map[string]interface{} {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimLeft(scanner.Text(), " |"))
+		kv := strings.SplitN(line, " = ", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], `"`) {
+			continue
+		}
+		props[strings.Trim(kv[0], `"`)] = strings.TrimSpace(kv[1])
+	}
+	if len(props) == 0 {
+		return nil
+	}
+
+	boolean := func(v string) int64 {
+		if v == "Yes" {
+			return 1
+		}
+		return 0
+	}
+	integer := func(name string) (int64, bool) {
+		v, ok := props[name]
+		if !ok {
+			return 0, false
+		}
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			// negative values, e.g. Amperage, are reported as unsigned 64bit integers
+			u, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			i = int64(u)
+		}
+		return i, true
+	}
+
+	fields := map[string]interface{}{
+		"present":            boolean(props["BatteryInstalled"]),
+		"charging":           boolean(props["IsCharging"]),
+		"external_connected": boolean(props["ExternalConnected"]),
+		"fully_charged":      boolean(props["FullyCharged"]),
+	}
+
+	if v, ok := integer("CycleCount"); ok {
+		fields["cycle_count"] = v
+	}
+
+	// on Apple silicon CurrentCapacity and MaxCapacity are percentages and
+	// the capacity in mAh is reported as AppleRawCurrentCapacity and
+	// AppleRawMaxCapacity
+	current, curOK := integer("CurrentCapacity")
+	maxCap, maxOK := integer("MaxCapacity")
+	if curOK && maxOK && maxCap > 0 {
+		fields["percent"] = float64(current) / float64(maxCap) * 100
+	}
+	if v, ok := integer("AppleRawMaxCapacity"); ok {
+		maxCap, maxOK = v, true
+	}
+	if v, ok := integer("AppleRawCurrentCapacity"); ok {
+		current, curOK = v, true
+	}
+	if curOK {
+		fields["current_capacity"] = current
+	}
+	if maxOK {
+		fields["max_capacity"] = maxCap
+	}
+	if design, ok := integer("DesignCapacity"); ok {
+		fields["design_capacity"] = design
+		if maxOK && design > 0 {
+			fields["health_percent"] = float64(maxCap) / float64(design) * 100
+		}
+	}
+
+	if v, ok := integer("Temperature"); ok {
+		fields["temperature"] = float64(v) / 100
+	}
+	if v, ok := integer("Voltage"); ok {
+		fields["voltage"] = float64(v) / 1000
+	}
+	if v, ok := integer("Amperage"); ok {
+		fields["amperage"] = float64(v) / 1000
+	}
+	// 65535 is reported while the time remaining is being calculated
+	if v, ok := integer("TimeRemaining"); ok && v != 65535 {
+		fields["time_remaining"] = v * 60
+	}
+
+	return fields
+}
@@ -0,0 +1,316 @@
+//go:build linux
+// +build linux
+
+// Package mdraid reports the state of Linux software RAID arrays and LVM thin
+// pools.
+package mdraid
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+type MDRaid struct {
+	Log     cua.Logger `toml:"-"`
+	lvs     lvsReporter
+	procDir string
+	Timeout internal.Duration `toml:"timeout"`
+	LVM     bool              `toml:"lvm"`
+	UseSudo bool              `toml:"use_sudo"`
+}
+
+type lvsReporter func(timeout time.Duration, useSudo bool) ([]byte, error)
+
+const sampleConfig = `
+  ## Report LVM thin pool utilization using "lvs", this requires root
+  ## privileges or sudo access to lvs.
+  # lvm = false
+
+  ## Run lvs with sudo, adjust your sudo settings appropriately, e.g.
+  ## cua ALL=(root) NOPASSWD: /sbin/lvs
+  # use_sudo = false
+
+  ## Timeout for running lvs
+  # timeout = "5s"
+`
+
+func (m *MDRaid) Description() string {
+	return "Read software RAID array state from /proc/mdstat and LVM thin pool utilization"
+}
+
+func (m *MDRaid) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *MDRaid) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if err := m.gatherMDStat(acc); err != nil {
+		acc.AddError(err)
+	}
+
+	if m.LVM {
+		if err := m.gatherThinPools(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	return nil
+}
+
+var (
+	// [2/1] [U_]
+	disksRE = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	// sdb1[1](F)
+	memberRE = regexp.MustCompile(`^[^\[]+\[\d+\](\([A-Z]\))*$`)
+	// recovery =  8.5% (89152/1047552) finish=0.7min speed=22288K/sec
+	syncRE = regexp.MustCompile(`(recovery|resync|check|reshape|repair)\s*=\s*([\d.]+)%.*?finish=([\d.]+)min\s+speed=(\d+)K/sec`)
+	// resync=DELAYED
+	syncPendingRE = regexp.MustCompile(`(recovery|resync|check|reshape|repair)\s*=\s*(DELAYED|PENDING)`)
+)
+
+// gatherMDStat reports one mdraid metric for every array in /proc/mdstat, a
+// missing file means the md module is not loaded and is not an error.
+func (m *MDRaid) gatherMDStat(acc cua.Accumulator) error {
+	path := filepath.Join(m.procDir, "mdstat")
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var tags map[string]string
+	var fields map[string]interface{}
+
+	flush := func() {
+		if tags != nil {
+			acc.AddGauge("mdraid", fields, tags)
+		}
+		tags, fields = nil, nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// md1 : active raid1 sdb1[1] sda1[0]
+		if col := strings.Fields(line); len(col) >= 3 && col[1] == ":" && strings.HasPrefix(col[0], "md") {
+			flush()
+			tags, fields = parseArray(col[0], col[2:])
+			continue
+		}
+
+		if tags == nil {
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		parseArrayStatus(line, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	flush()
+
+	return nil
+}
+
+// parseArray parses the state, level and member devices of an array
+func parseArray(device string, col []string) (map[string]string, map[string]interface{}) {
+	tags := map[string]string{"device": device}
+	fields := map[string]interface{}{
+		"sync_action":            "idle",
+		"sync_percent":           float64(100),
+		"sync_speed":             uint64(0),
+		"sync_remaining_seconds": float64(0),
+	}
+
+	var active, members, failed, spare int64
+	if col[0] == "active" {
+		active = 1
+	}
+
+	for _, c := range col[1:] {
+		switch {
+		case strings.HasPrefix(c, "("):
+			// (read-only), (auto-read-only)
+			continue
+		case memberRE.MatchString(c):
+			members++
+			if strings.Contains(c, "(F)") {
+				failed++
+			}
+			if strings.Contains(c, "(S)") {
+				spare++
+			}
+		default:
+			tags["level"] = c
+		}
+	}
+	fields["active"] = active
+	fields["members"] = members
+	fields["disks_failed"] = failed
+	fields["disks_spare"] = spare
+
+	return tags, fields
+}
+
+// parseArrayStatus parses the block count, disk counts and sync progress
+// lines that follow an array
+func parseArrayStatus(line string, fields map[string]interface{}) {
+	col := strings.Fields(line)
+	if len(col) >= 2 && col[1] == "blocks" {
+		if blocks, err := strconv.ParseUint(col[0], 10, 64); err == nil {
+			fields["blocks"] = blocks
+		}
+		if match := disksRE.FindStringSubmatch(line); match != nil {
+			total, _ := strconv.ParseInt(match[1], 10, 64)
+			active, _ := strconv.ParseInt(match[2], 10, 64)
+			fields["disks_total"] = total
+			fields["disks_active"] = active
+			fields["disks_down"] = total - active
+			degraded := int64(0)
+			if active < total {
+				degraded = 1
+			}
+			fields["degraded"] = degraded
+		}
+		return
+	}
+
+	if match := syncRE.FindStringSubmatch(line); match != nil {
+		fields["sync_action"] = match[1]
+		if pct, err := strconv.ParseFloat(match[2], 64); err == nil {
+			fields["sync_percent"] = pct
+		}
+		if finish, err := strconv.ParseFloat(match[3], 64); err == nil {
+			fields["sync_remaining_seconds"] = finish * 60
+		}
+		if speed, err := strconv.ParseUint(match[4], 10, 64); err == nil {
+			fields["sync_speed"] = speed * 1024
+		}
+		return
+	}
+
+	if match := syncPendingRE.FindStringSubmatch(line); match != nil {
+		fields["sync_action"] = match[1]
+		fields["sync_percent"] = float64(0)
+	}
+}
+
+type lvsReport struct {
+	Report []struct {
+		LV []struct {
+			Name            string `json:"lv_name"`
+			VG              string `json:"vg_name"`
+			Attr            string `json:"lv_attr"`
+			Size            string `json:"lv_size"`
+			DataPercent     string `json:"data_percent"`
+			MetadataPercent string `json:"metadata_percent"`
+		} `json:"lv"`
+	} `json:"report"`
+}
+
+// gatherThinPools reports the size and data and metadata utilization of LVM
+// thin pools
+func (m *MDRaid) gatherThinPools(acc cua.Accumulator) error {
+	out, err := m.lvs(m.Timeout.Duration, m.UseSudo)
+	if err != nil {
+		return err
+	}
+
+	var report lvsReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return fmt.Errorf("parsing lvs report: %w", err)
+	}
+
+	for _, r := range report.Report {
+		for _, lv := range r.LV {
+			// the first lv_attr character is the volume type, t is a thin pool
+			if !strings.HasPrefix(lv.Attr, "t") {
+				continue
+			}
+
+			fields := make(map[string]interface{})
+			if size, err := strconv.ParseUint(lv.Size, 10, 64); err == nil {
+				fields["size"] = size
+			}
+			if pct, err := strconv.ParseFloat(lv.DataPercent, 64); err == nil {
+				fields["data_percent"] = pct
+			}
+			if pct, err := strconv.ParseFloat(lv.MetadataPercent, 64); err == nil {
+				fields["metadata_percent"] = pct
+			}
+			if len(fields) == 0 {
+				continue
+			}
+
+			tags := map[string]string{"vg": lv.VG, "lv": lv.Name}
+			acc.AddGauge("mdraid_lvm_thin_pool", fields, tags)
+		}
+	}
+
+	return nil
+}
+
+func lvs(timeout time.Duration, useSudo bool) ([]byte, error) {
+	lvsPath, err := exec.LookPath("lvs")
+	if err != nil {
+		return nil, fmt.Errorf("lookpath (lvs): %w", err)
+	}
+
+	args := []string{
+		"--reportformat", "json", "--units", "b", "--nosuffix",
+		"-o", "lv_name,vg_name,lv_attr,lv_size,data_percent,metadata_percent",
+	}
+	cmdName := lvsPath
+	if useSudo {
+		cmdName = "sudo"
+		args = append([]string{"-n", lvsPath}, args...)
+	}
+
+	cmd := exec.Command(cmdName, args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := internal.RunTimeout(cmd, timeout); err != nil {
+		return nil, fmt.Errorf("error running lvs: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+func getHostProc() string {
+	if procPath := os.Getenv("HOST_PROC"); procPath != "" {
+		return procPath
+	}
+	return "/proc"
+}
+
+func init() {
+	inputs.Add("mdraid", func() cua.Input {
+		return &MDRaid{
+			lvs:     lvs,
+			procDir: getHostProc(),
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
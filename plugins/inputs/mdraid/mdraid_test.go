@@ -0,0 +1,161 @@
+//go:build linux
+// +build linux
+
+package mdraid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const lvsOutput = `  {
+      "report": [
+          {
+              "lv": [
+                  {"lv_name":"root", "vg_name":"vg0", "lv_attr":"-wi-ao----", "lv_size":"21474836480", "data_percent":"", "metadata_percent":""},
+                  {"lv_name":"pool0", "vg_name":"vg0", "lv_attr":"twi-aotz--", "lv_size":"107374182400", "data_percent":"42.17", "metadata_percent":"3.05"},
+                  {"lv_name":"thin1", "vg_name":"vg0", "lv_attr":"Vwi-aotz--", "lv_size":"53687091200", "data_percent":"80.00", "metadata_percent":""}
+              ]
+          }
+      ]
+  }
+`
+
+func TestGatherMDStat(t *testing.T) {
+	m := &MDRaid{
+		Log:     testutil.Logger{},
+		procDir: "testdata/proc",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, m.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "mdraid",
+		map[string]interface{}{
+			"active":                 int64(1),
+			"members":                int64(2),
+			"disks_failed":           int64(0),
+			"disks_spare":            int64(0),
+			"blocks":                 uint64(1048512),
+			"disks_total":            int64(2),
+			"disks_active":           int64(2),
+			"disks_down":             int64(0),
+			"degraded":               int64(0),
+			"sync_action":            "idle",
+			"sync_percent":           float64(100),
+			"sync_speed":             uint64(0),
+			"sync_remaining_seconds": float64(0),
+		},
+		map[string]string{"device": "md1", "level": "raid1"})
+
+	acc.AssertContainsTaggedFields(t, "mdraid",
+		map[string]interface{}{
+			"active":                 int64(1),
+			"members":                int64(4),
+			"disks_failed":           int64(1),
+			"disks_spare":            int64(1),
+			"blocks":                 uint64(2095104),
+			"disks_total":            int64(3),
+			"disks_active":           int64(2),
+			"disks_down":             int64(1),
+			"degraded":               int64(1),
+			"sync_action":            "recovery",
+			"sync_percent":           float64(8.5),
+			"sync_speed":             uint64(22288 * 1024),
+			"sync_remaining_seconds": float64(0.7 * 60),
+		},
+		map[string]string{"device": "md2", "level": "raid5"})
+
+	acc.AssertContainsTaggedFields(t, "mdraid",
+		map[string]interface{}{
+			"active":                 int64(1),
+			"members":                int64(2),
+			"disks_failed":           int64(0),
+			"disks_spare":            int64(0),
+			"blocks":                 uint64(523264),
+			"disks_total":            int64(2),
+			"disks_active":           int64(2),
+			"disks_down":             int64(0),
+			"degraded":               int64(0),
+			"sync_action":            "resync",
+			"sync_percent":           float64(0),
+			"sync_speed":             uint64(0),
+			"sync_remaining_seconds": float64(0),
+		},
+		map[string]string{"device": "md3", "level": "raid1"})
+
+	acc.AssertContainsTaggedFields(t, "mdraid",
+		map[string]interface{}{
+			"active":                 int64(0),
+			"members":                int64(1),
+			"disks_failed":           int64(0),
+			"disks_spare":            int64(1),
+			"blocks":                 uint64(1048576),
+			"sync_action":            "idle",
+			"sync_percent":           float64(100),
+			"sync_speed":             uint64(0),
+			"sync_remaining_seconds": float64(0),
+		},
+		map[string]string{"device": "md127"})
+
+	require.False(t, acc.HasMeasurement("mdraid_lvm_thin_pool"))
+}
+
+func TestGatherMDStatMissing(t *testing.T) {
+	m := &MDRaid{
+		Log:     testutil.Logger{},
+		procDir: "testdata/missing",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, m.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestGatherThinPools(t *testing.T) {
+	m := &MDRaid{
+		Log:     testutil.Logger{},
+		procDir: "testdata/missing",
+		LVM:     true,
+		UseSudo: true,
+		lvs: func(timeout time.Duration, useSudo bool) ([]byte, error) {
+			require.True(t, useSudo)
+			return []byte(lvsOutput), nil
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, m.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "mdraid_lvm_thin_pool",
+		map[string]interface{}{
+			"size":             uint64(107374182400),
+			"data_percent":     float64(42.17),
+			"metadata_percent": float64(3.05),
+		},
+		map[string]string{"vg": "vg0", "lv": "pool0"})
+}
+
+func TestGatherThinPoolsError(t *testing.T) {
+	m := &MDRaid{
+		Log:     testutil.Logger{},
+		procDir: "testdata/missing",
+		LVM:     true,
+		lvs: func(timeout time.Duration, useSudo bool) ([]byte, error) {
+			return nil, errors.New("lvs: permission denied")
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, m.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+}
@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/url"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
@@ -716,8 +717,9 @@ func (m *Modbus) Gather(ctx context.Context, acc cua.Accumulator) error {
 	grouper := metric.NewSeriesGrouper()
 	for _, reg := range m.registers {
 		tags := map[string]string{
-			"name": m.Name,
-			"type": reg.Type,
+			"name":     m.Name,
+			"type":     reg.Type,
+			"slave_id": strconv.Itoa(m.SlaveID),
 		}
 
 		for _, field := range reg.Fields {
@@ -730,11 +732,11 @@ func (m *Modbus) Gather(ctx context.Context, acc cua.Accumulator) error {
 			// Group the data by series
 			_ = grouper.Add(measurement, tags, timestamp, field.Name, field.value)
 		}
+	}
 
-		// Add the metrics grouped by series to the accumulator
-		for _, metric := range grouper.Metrics() {
-			acc.AddMetric(metric)
-		}
+	// Add the metrics grouped by series to the accumulator
+	for _, metric := range grouper.Metrics() {
+		acc.AddMetric(metric)
 	}
 
 	return nil
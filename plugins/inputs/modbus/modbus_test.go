@@ -785,3 +785,56 @@ func TestRetryFail(t *testing.T) {
 		assert.Equal(t, counter, 1)
 	})
 }
+
+func TestGatherTags(t *testing.T) {
+	serv := mbserver.NewServer()
+	err := serv.ListenTCP("localhost:1502")
+	assert.NoError(t, err)
+	defer serv.Close()
+
+	handler := m.NewTCPClientHandler("localhost:1502")
+	err = handler.Connect()
+	assert.NoError(t, err)
+	defer handler.Close()
+	client := m.NewClient(handler)
+	_, err = client.WriteMultipleCoils(0, 1, []byte{0x01})
+	assert.NoError(t, err)
+	_, err = client.WriteMultipleRegisters(0, 1, []byte{0x00, 0x2a})
+	assert.NoError(t, err)
+
+	modbus := Modbus{
+		Name:       "TestTags",
+		Controller: "tcp://localhost:1502",
+		SlaveID:    3,
+		Coils: []fieldContainer{
+			{
+				Name:    "pump_run",
+				Address: []uint16{0},
+			},
+		},
+		HoldingRegisters: []fieldContainer{
+			{
+				Name:      "level",
+				ByteOrder: "AB",
+				DataType:  "UINT16",
+				Scale:     1.0,
+				Address:   []uint16{0},
+			},
+		},
+	}
+
+	err = modbus.Init()
+	assert.NoError(t, err)
+	var acc testutil.Accumulator
+	err = modbus.Gather(context.Background(), &acc)
+	assert.NoError(t, err)
+
+	// one metric per register type
+	assert.Len(t, acc.GetCUAMetrics(), 2)
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{"pump_run": uint64(1)},
+		map[string]string{"name": "TestTags", "type": cCoils, "slave_id": "3"})
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{"level": uint64(42)},
+		map[string]string{"name": "TestTags", "type": cHoldingRegisters, "slave_id": "3"})
+}
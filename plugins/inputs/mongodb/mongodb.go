@@ -160,6 +160,9 @@ func (m *MongoDB) gatherServer(server *Server, acc cua.Accumulator) error {
 			} else {
 				tlsConfig.InsecureSkipVerify = true
 			}
+			if err := tlsint.ApplyPolicy(tlsConfig); err != nil {
+				return fmt.Errorf("TLSConfig: %w", err)
+			}
 		} else {
 			tlsConfig, err = m.ClientConfig.TLSConfig()
 			if err != nil {
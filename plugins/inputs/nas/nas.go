@@ -0,0 +1,152 @@
+// Package nas implements a plugin for collecting volume, disk and RAID
+// metrics from Synology and QNAP NAS systems with the SNMP input.
+package nas
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	isnmp "github.com/circonus-labs/circonus-unified-agent/internal/snmp"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/snmp"
+)
+
+// NAS reads the vendor MIB objects of a NAS model
+type NAS struct {
+	Model        string   `toml:"model"`
+	Agents       []string `toml:"agents"`
+	AgentHostTag string   `toml:"agent_host_tag"`
+	isnmp.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	snmp *snmp.Snmp
+}
+
+var sampleConfig = `
+  ## NAS model, one of "synology" or "qnap"
+  model = "synology"
+
+  ## Agent addresses to retrieve values from.
+  ##   example: agents = ["udp://127.0.0.1:161"]
+  ##            agents = ["tcp://127.0.0.1:161"]
+  agents = ["udp://127.0.0.1:161"]
+
+  ## Timeout for each request.
+  # timeout = "5s"
+
+  ## SNMP version; can be 1, 2, or 3.
+  # version = 2
+
+  ## SNMP community string.
+  # community = "public"
+
+  ## Number of retries to attempt.
+  # retries = 3
+
+  ## The GETBULK max-repetitions parameter.
+  # max_repetitions = 10
+
+  ## SNMPv3 authentication and encryption options.
+  ##
+  ## Security Name.
+  # sec_name = "myuser"
+  ## Authentication protocol; one of "MD5", "SHA", or "".
+  # auth_protocol = "MD5"
+  ## Authentication password.
+  # auth_password = "pass"
+  ## Security Level; one of "noAuthNoPriv", "authNoPriv", or "authPriv".
+  # sec_level = "authNoPriv"
+  ## Context Name.
+  # context_name = ""
+  ## Privacy protocol used for encrypted messages; one of "DES", "AES" or "".
+  # priv_protocol = ""
+  ## Privacy password used for encrypted messages.
+  # priv_password = ""
+
+  ## The tag used to name the agent host
+  # agent_host_tag = "agent_host"
+`
+
+func (*NAS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (*NAS) Description() string {
+	return "Read volume, disk and RAID status from Synology and QNAP NAS systems with SNMP"
+}
+
+func (n *NAS) Init() error {
+	p, ok := presets[strings.ToLower(n.Model)]
+	if !ok {
+		return fmt.Errorf("unknown model %q, must be one of %s", n.Model, strings.Join(models(), ", "))
+	}
+
+	n.snmp = &snmp.Snmp{
+		Name:         "nas",
+		Agents:       n.Agents,
+		AgentHostTag: n.AgentHostTag,
+		ClientConfig: n.ClientConfig,
+		Log:          n.Log,
+	}
+	for _, o := range p.system {
+		n.snmp.Fields = append(n.snmp.Fields, o.field())
+	}
+	for _, t := range p.tables {
+		st := snmp.Table{
+			Name:        t.name,
+			InheritTags: []string{"model"},
+		}
+		for _, o := range t.objects {
+			st.Fields = append(st.Fields, o.field())
+		}
+		n.snmp.Tables = append(n.snmp.Tables, st)
+	}
+	return nil
+}
+
+func (n *NAS) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if err := n.snmp.Gather(ctx, acc); err != nil {
+		return fmt.Errorf("snmp gather: %w", err)
+	}
+	return nil
+}
+
+// field returns the SNMP field of the object, the translation of the OID is
+// forced so the SNMP input does not look it up with snmptranslate
+func (o object) field() snmp.Field {
+	snmp.TranslateForce(o.oid, o.mib, o.oid, o.text, "")
+	return snmp.Field{
+		Name:  o.name,
+		Oid:   o.oid,
+		IsTag: o.isTag,
+	}
+}
+
+func models() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	inputs.Add("nas", func() cua.Input {
+		return &NAS{
+			ClientConfig: isnmp.ClientConfig{
+				Retries:        3,
+				MaxRepetitions: 10,
+				Timeout:        internal.Duration{Duration: 5 * time.Second},
+				Version:        2,
+				Community:      "public",
+			},
+		}
+	})
+}
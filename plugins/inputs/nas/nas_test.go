@@ -0,0 +1,52 @@
+package nas
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/snmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitSynology(t *testing.T) {
+	n := &NAS{
+		Model:  "Synology",
+		Agents: []string{"udp://127.0.0.1:161"},
+	}
+	require.NoError(t, n.Init())
+
+	s := n.snmp
+	require.Equal(t, "nas", s.Name)
+	require.Equal(t, []string{"udp://127.0.0.1:161"}, s.Agents)
+	require.Len(t, s.Fields, len(presets["synology"].system))
+	require.Equal(t, snmp.Field{Name: "model", Oid: ".1.3.6.1.4.1.6574.1.5.1.0", IsTag: true}, s.Fields[0])
+
+	require.Len(t, s.Tables, 2)
+	require.Equal(t, "nas_disk", s.Tables[0].Name)
+	require.Equal(t, []string{"model"}, s.Tables[0].InheritTags)
+	require.Equal(t, "nas_raid", s.Tables[1].Name)
+	require.Equal(t, snmp.Field{Name: "raid", Oid: ".1.3.6.1.4.1.6574.3.1.1.2", IsTag: true}, s.Tables[1].Fields[0])
+}
+
+func TestInitUnknownModel(t *testing.T) {
+	n := &NAS{Model: "netgear"}
+	require.EqualError(t, n.Init(), `unknown model "netgear", must be one of qnap, synology`)
+}
+
+func TestPresets(t *testing.T) {
+	for model, p := range presets {
+		oids := map[string]bool{}
+		for _, o := range p.system {
+			require.True(t, strings.HasSuffix(o.oid, ".0"), "%s: %s is not a scalar", model, o.name)
+			require.False(t, oids[o.oid], "%s: duplicate OID %s", model, o.oid)
+			oids[o.oid] = true
+		}
+		for _, tbl := range p.tables {
+			require.True(t, tbl.objects[0].isTag, "%s: first object of %s is not a tag", model, tbl.name)
+			for _, o := range tbl.objects {
+				require.False(t, oids[o.oid], "%s: duplicate OID %s", model, o.oid)
+				oids[o.oid] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package nas
+
+// object is a MIB object of a preset, the OIDs are numeric so the vendor
+// MIBs do not have to be installed
+type object struct {
+	name  string
+	oid   string
+	mib   string
+	text  string
+	isTag bool
+}
+
+type table struct {
+	name    string
+	objects []object
+}
+
+type preset struct {
+	// system is read with GET requests, the objects are scalars
+	system []object
+	tables []table
+}
+
+const (
+	synologySystem = ".1.3.6.1.4.1.6574.1"
+	synologyDisk   = ".1.3.6.1.4.1.6574.2.1.1"
+	synologyRaid   = ".1.3.6.1.4.1.6574.3.1.1"
+
+	qnapNAS   = ".1.3.6.1.4.1.24681.1.2"
+	qnapNASEx = ".1.3.6.1.4.1.24681.1.3"
+)
+
+var presets = map[string]preset{
+	// SYNOLOGY-SYSTEM-MIB, SYNOLOGY-DISK-MIB and SYNOLOGY-RAID-MIB of DSM
+	// 6 and 7, the status values are 1 for normal
+	"synology": {
+		system: []object{
+			{name: "model", oid: synologySystem + ".5.1.0", mib: "SYNOLOGY-SYSTEM-MIB", text: "modelName", isTag: true},
+			{name: "system_status", oid: synologySystem + ".1.0", mib: "SYNOLOGY-SYSTEM-MIB", text: "systemStatus"},
+			{name: "temperature", oid: synologySystem + ".2.0", mib: "SYNOLOGY-SYSTEM-MIB", text: "temperature"},
+			{name: "power_status", oid: synologySystem + ".3.0", mib: "SYNOLOGY-SYSTEM-MIB", text: "powerStatus"},
+			{name: "system_fan_status", oid: synologySystem + ".4.1.0", mib: "SYNOLOGY-SYSTEM-MIB", text: "systemFanStatus"},
+			{name: "cpu_fan_status", oid: synologySystem + ".4.2.0", mib: "SYNOLOGY-SYSTEM-MIB", text: "cpuFanStatus"},
+			{name: "upgrade_available", oid: synologySystem + ".5.4.0", mib: "SYNOLOGY-SYSTEM-MIB", text: "upgradeAvailable"},
+		},
+		tables: []table{
+			{name: "nas_disk", objects: []object{
+				{name: "disk", oid: synologyDisk + ".2", mib: "SYNOLOGY-DISK-MIB", text: "diskID", isTag: true},
+				{name: "disk_model", oid: synologyDisk + ".3", mib: "SYNOLOGY-DISK-MIB", text: "diskModel", isTag: true},
+				{name: "status", oid: synologyDisk + ".5", mib: "SYNOLOGY-DISK-MIB", text: "diskStatus"},
+				{name: "temperature", oid: synologyDisk + ".6", mib: "SYNOLOGY-DISK-MIB", text: "diskTemperature"},
+				{name: "bad_sectors", oid: synologyDisk + ".9", mib: "SYNOLOGY-DISK-MIB", text: "diskBadSector"},
+				{name: "remaining_life", oid: synologyDisk + ".11", mib: "SYNOLOGY-DISK-MIB", text: "diskRemainLife"},
+				{name: "health_status", oid: synologyDisk + ".13", mib: "SYNOLOGY-DISK-MIB", text: "diskHealthStatus"},
+			}},
+			// the RAID groups are the volumes of DSM 6 and the storage
+			// pools of DSM 7
+			{name: "nas_raid", objects: []object{
+				{name: "raid", oid: synologyRaid + ".2", mib: "SYNOLOGY-RAID-MIB", text: "raidName", isTag: true},
+				{name: "status", oid: synologyRaid + ".3", mib: "SYNOLOGY-RAID-MIB", text: "raidStatus"},
+				{name: "free_size", oid: synologyRaid + ".4", mib: "SYNOLOGY-RAID-MIB", text: "raidFreeSize"},
+				{name: "total_size", oid: synologyRaid + ".5", mib: "SYNOLOGY-RAID-MIB", text: "raidTotalSize"},
+			}},
+		},
+	},
+	// NAS-MIB of QTS 4.3 and later, the EX objects are the numeric
+	// variants of the original objects which are strings with units
+	"qnap": {
+		system: []object{
+			{name: "model", oid: qnapNAS + ".12.0", mib: "NAS-MIB", text: "modelName", isTag: true},
+			{name: "cpu_usage", oid: qnapNASEx + ".1.0", mib: "NAS-MIB", text: "systemCPU-UsageEX"},
+			{name: "mem_total", oid: qnapNASEx + ".2.0", mib: "NAS-MIB", text: "systemTotalMemEX"},
+			{name: "mem_free", oid: qnapNASEx + ".3.0", mib: "NAS-MIB", text: "systemFreeMemEX"},
+			{name: "cpu_temperature", oid: qnapNASEx + ".5.0", mib: "NAS-MIB", text: "cpu-TemperatureEX"},
+			{name: "temperature", oid: qnapNASEx + ".6.0", mib: "NAS-MIB", text: "systemTemperatureEX"},
+		},
+		tables: []table{
+			{name: "nas_disk", objects: []object{
+				{name: "disk", oid: qnapNASEx + ".11.1.2", mib: "NAS-MIB", text: "hdDescrEX", isTag: true},
+				{name: "disk_model", oid: qnapNASEx + ".11.1.5", mib: "NAS-MIB", text: "hdModelEX", isTag: true},
+				{name: "temperature", oid: qnapNASEx + ".11.1.3", mib: "NAS-MIB", text: "hdTemperatureEX"},
+				{name: "status", oid: qnapNASEx + ".11.1.4", mib: "NAS-MIB", text: "hdStatusEX"},
+				{name: "capacity", oid: qnapNASEx + ".11.1.6", mib: "NAS-MIB", text: "hdCapacityEX"},
+				{name: "smart_info", oid: qnapNASEx + ".11.1.7", mib: "NAS-MIB", text: "hdSmartInfoEX"},
+			}},
+			// the status of a volume is the state of its RAID group, e.g.
+			// Ready, Degraded or Rebuilding
+			{name: "nas_volume", objects: []object{
+				{name: "volume", oid: qnapNASEx + ".17.1.2", mib: "NAS-MIB", text: "sysVolumeDescrEX", isTag: true},
+				{name: "filesystem", oid: qnapNASEx + ".17.1.3", mib: "NAS-MIB", text: "sysVolumeFSEX", isTag: true},
+				{name: "total_size", oid: qnapNASEx + ".17.1.4", mib: "NAS-MIB", text: "sysVolumeTotalSizeEX"},
+				{name: "free_size", oid: qnapNASEx + ".17.1.5", mib: "NAS-MIB", text: "sysVolumeFreeSizeEX"},
+				{name: "status", oid: qnapNASEx + ".17.1.6", mib: "NAS-MIB", text: "sysVolumeStatusEX"},
+			}},
+			{name: "nas_fan", objects: []object{
+				{name: "fan", oid: qnapNASEx + ".15.1.2", mib: "NAS-MIB", text: "sysFanDescrEX", isTag: true},
+				{name: "speed", oid: qnapNASEx + ".15.1.3", mib: "NAS-MIB", text: "sysFanSpeedEX"},
+			}},
+		},
+	},
+}
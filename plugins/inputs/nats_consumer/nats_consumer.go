@@ -39,6 +39,7 @@ type natsConsumer struct {
 	Username    string   `toml:"username"`
 	Password    string   `toml:"password"`
 	Credentials string   `toml:"credentials"`
+	JetStream   bool     `toml:"jetstream"`
 
 	tls.ClientConfig
 
@@ -57,10 +58,12 @@ type natsConsumer struct {
 	// channel for all incoming NATS messages
 	in chan *nats.Msg
 	// channel for all NATS read errors
-	errs   chan error
-	acc    cua.TrackingAccumulator
-	wg     sync.WaitGroup
-	cancel context.CancelFunc
+	errs chan error
+	acc  cua.TrackingAccumulator
+	// JetStream messages to acknowledge once delivered
+	undelivered map[cua.TrackingID]*nats.Msg
+	wg          sync.WaitGroup
+	cancel      context.CancelFunc
 }
 
 var sampleConfig = `
@@ -73,6 +76,12 @@ var sampleConfig = `
   ## name a queue group
   queue_group = "circonus_consumers"
 
+  ## Acknowledge the messages of JetStream push consumers once their metrics
+  ## are written by the outputs. The subjects are the deliver subjects of
+  ## durable consumers with the explicit ack policy, and the queue group their
+  ## deliver group.
+  # jetstream = false
+
   ## Optional credentials
   # username = ""
   # password = ""
@@ -135,6 +144,7 @@ func (n *natsConsumer) natsErrHandler(c *nats.Conn, s *nats.Subscription, e erro
 // Start the nats consumer. Caller must call *natsConsumer.Stop() to clean up.
 func (n *natsConsumer) Start(ctx context.Context, acc cua.Accumulator) error {
 	n.acc = acc.WithTracking(n.MaxUndeliveredMessages)
+	n.undelivered = make(map[cua.TrackingID]*nats.Msg)
 
 	var connectErr error
 
@@ -214,7 +224,8 @@ func (n *natsConsumer) receiver(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		case <-n.acc.Delivered():
+		case track := <-n.acc.Delivered():
+			n.onDelivery(track)
 			<-sem
 		case err := <-n.errs:
 			n.Log.Error(err)
@@ -225,23 +236,72 @@ func (n *natsConsumer) receiver(ctx context.Context) {
 			case err := <-n.errs:
 				<-sem
 				n.Log.Error(err)
-			case <-n.acc.Delivered():
+			case track := <-n.acc.Delivered():
+				n.onDelivery(track)
 				<-sem
 				<-sem
 			case msg := <-n.in:
-				metrics, err := n.parser.Parse(msg.Data)
-				if err != nil {
-					n.Log.Errorf("Subject: %s, error: %s", msg.Subject, err.Error())
+				if !n.handle(msg) {
 					<-sem
-					continue
 				}
-
-				n.acc.AddTrackingMetricGroup(metrics)
 			}
 		}
 	}
 }
 
+// handle parses a message into metrics, it returns false when no metric
+// is tracked for the message
+func (n *natsConsumer) handle(msg *nats.Msg) bool {
+	// JetStream flow control and heartbeats come without data, the
+	// headers are not read by this client
+	if n.JetStream && len(msg.Data) == 0 {
+		if msg.Reply != "" {
+			n.ack(msg, "")
+		}
+		return false
+	}
+
+	metrics, err := n.parser.Parse(msg.Data)
+	if err != nil {
+		n.Log.Errorf("Subject: %s, error: %s", msg.Subject, err.Error())
+		// a message that cannot be parsed is not redelivered
+		n.ack(msg, "+TERM")
+		return false
+	}
+
+	id := n.acc.AddTrackingMetricGroup(metrics)
+	if n.JetStream {
+		n.undelivered[id] = msg
+	}
+	return true
+}
+
+// onDelivery acknowledges the JetStream message of delivered metrics, the
+// message is redelivered when the outputs failed to write them
+func (n *natsConsumer) onDelivery(track cua.DeliveryInfo) {
+	msg, ok := n.undelivered[track.ID()]
+	if !ok {
+		return
+	}
+	delete(n.undelivered, track.ID())
+
+	if track.Delivered() {
+		n.ack(msg, "+ACK")
+	} else {
+		n.ack(msg, "-NAK")
+	}
+}
+
+// ack replies to a JetStream message
+func (n *natsConsumer) ack(msg *nats.Msg, reply string) {
+	if !n.JetStream || msg.Reply == "" {
+		return
+	}
+	if err := n.conn.Publish(msg.Reply, []byte(reply)); err != nil {
+		n.Log.Errorf("Subject: %s, ack error: %s", msg.Subject, err.Error())
+	}
+}
+
 func (n *natsConsumer) clean() {
 	for _, sub := range n.subs {
 		if err := sub.Unsubscribe(); err != nil {
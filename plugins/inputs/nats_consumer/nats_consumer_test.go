@@ -0,0 +1,103 @@
+package natsconsumer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers/value"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+type deliveryInfo struct {
+	id        cua.TrackingID
+	delivered bool
+}
+
+func (d deliveryInfo) ID() cua.TrackingID { return d.id }
+func (d deliveryInfo) Delivered() bool    { return d.delivered }
+
+func runServer(t *testing.T) *nats.Conn {
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+	go s.Start()
+	t.Cleanup(s.Shutdown)
+	require.True(t, s.ReadyForConnections(5*time.Second))
+
+	conn, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+	return conn
+}
+
+func TestJetStreamAck(t *testing.T) {
+	conn := runServer(t)
+
+	acks := make(chan *nats.Msg, 10)
+	_, err := conn.ChanSubscribe("$JS.ACK.>", acks)
+	require.NoError(t, err)
+	require.NoError(t, conn.Flush())
+
+	acc := &testutil.Accumulator{}
+	n := &natsConsumer{
+		JetStream:   true,
+		Log:         testutil.Logger{},
+		conn:        conn,
+		parser:      &value.Parser{MetricName: "cpu", DataType: "int"},
+		acc:         acc.WithTracking(10),
+		undelivered: make(map[cua.TrackingID]*nats.Msg),
+	}
+
+	delivered := &nats.Msg{Subject: "metrics", Reply: "$JS.ACK.METRICS.circonus.1.1.1", Data: []byte("42")}
+	failed := &nats.Msg{Subject: "metrics", Reply: "$JS.ACK.METRICS.circonus.1.2.2", Data: []byte("43")}
+	invalid := &nats.Msg{Subject: "metrics", Reply: "$JS.ACK.METRICS.circonus.1.3.3", Data: []byte("not a number")}
+	flowControl := &nats.Msg{Subject: "metrics", Reply: "$JS.ACK.flow"}
+
+	require.True(t, n.handle(delivered))
+	require.True(t, n.handle(failed))
+	require.False(t, n.handle(invalid))
+	require.False(t, n.handle(flowControl))
+	require.Len(t, n.undelivered, 2)
+	require.Equal(t, 2, len(acc.GetCUAMetrics()))
+
+	for id, msg := range n.undelivered {
+		n.onDelivery(deliveryInfo{id: id, delivered: msg == delivered})
+	}
+	require.Empty(t, n.undelivered)
+	require.NoError(t, conn.Flush())
+
+	replies := make(map[string]string)
+	for i := 0; i < 4; i++ {
+		select {
+		case msg := <-acks:
+			replies[msg.Subject] = string(msg.Data)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("missing acks, got %v", replies)
+		}
+	}
+	require.Equal(t, map[string]string{
+		delivered.Reply:   "+ACK",
+		failed.Reply:      "-NAK",
+		invalid.Reply:     "+TERM",
+		flowControl.Reply: "",
+	}, replies)
+}
+
+func TestCoreNoAck(t *testing.T) {
+	conn := runServer(t)
+
+	acc := &testutil.Accumulator{}
+	n := &natsConsumer{
+		Log:         testutil.Logger{},
+		conn:        conn,
+		parser:      &value.Parser{MetricName: "cpu", DataType: "int"},
+		acc:         acc.WithTracking(10),
+		undelivered: make(map[cua.TrackingID]*nats.Msg),
+	}
+
+	require.True(t, n.handle(&nats.Msg{Subject: "metrics", Reply: "inbox", Data: []byte("42")}))
+	require.Empty(t, n.undelivered)
+}
@@ -0,0 +1,387 @@
+//go:build linux
+// +build linux
+
+// Package nfsstat reports NFS client, per mount and server statistics.
+package nfsstat
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+type NFSStat struct {
+	Log           cua.Logger `toml:"-"`
+	mountFilter   filter.Filter
+	opFilter      filter.Filter
+	procDir       string
+	IncludeMounts []string `toml:"include_mounts"`
+	ExcludeMounts []string `toml:"exclude_mounts"`
+	IncludeOps    []string `toml:"include_operations"`
+	Client        bool     `toml:"client"`
+	Server        bool     `toml:"server"`
+}
+
+const sampleConfig = `
+  ## Collect client statistics from /proc/net/rpc/nfs and per mount
+  ## statistics from /proc/self/mountstats
+  # client = true
+
+  ## Collect server statistics from /proc/net/rpc/nfsd
+  # server = true
+
+  ## Mount points to report per mount statistics for, glob patterns are
+  ## supported. By default all nfs mounts are reported.
+  # include_mounts = []
+  # exclude_mounts = []
+
+  ## Per mount operations to report, e.g. ["READ", "WRITE", "GETATTR"].
+  ## By default all operations with a non-zero count are reported.
+  # include_operations = []
+`
+
+func (n *NFSStat) Description() string {
+	return "Read NFS client, per mount and server statistics from procfs"
+}
+
+func (n *NFSStat) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *NFSStat) Init() error {
+	var err error
+	n.mountFilter, err = filter.NewIncludeExcludeFilter(n.IncludeMounts, n.ExcludeMounts)
+	if err != nil {
+		return fmt.Errorf("compiling mount filter: %w", err)
+	}
+	n.opFilter, err = filter.Compile(n.IncludeOps)
+	if err != nil {
+		return fmt.Errorf("compiling operation filter: %w", err)
+	}
+	return nil
+}
+
+func (n *NFSStat) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if n.Client {
+		if err := n.gatherClient(acc); err != nil {
+			acc.AddError(err)
+		}
+		if err := n.gatherMounts(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	if n.Server {
+		if err := n.gatherServer(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	return nil
+}
+
+// readLines returns the fields of each line of a procfs file, a missing
+// file means the nfs module is not loaded and is not an error.
+func readLines(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var lines [][]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+// addValues parses values and adds them to fields using the names given,
+// values without a name are ignored.
+func addValues(fields map[string]interface{}, names []string, values []string) {
+	for i, v := range values {
+		if i >= len(names) || names[i] == "" {
+			break
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		fields[names[i]] = n
+	}
+}
+
+// nfs3Ops are the NFSv3 procedures in the order reported by procfs
+var nfs3Ops = []string{
+	"null", "getattr", "setattr", "lookup", "access", "readlink", "read",
+	"write", "create", "mkdir", "symlink", "mknod", "remove", "rmdir",
+	"rename", "link", "readdir", "readdirplus", "fsstat", "fsinfo",
+	"pathconf", "commit",
+}
+
+// nfs4ClientOps are the NFSv4 client procedures in the order reported by the
+// "proc4" line of /proc/net/rpc/nfs
+var nfs4ClientOps = []string{
+	"null", "read", "write", "commit", "open", "open_confirm", "open_noattr",
+	"open_downgrade", "close", "setattr", "fsinfo", "renew", "setclientid",
+	"setclientid_confirm", "lock", "lockt", "locku", "access", "getattr",
+	"lookup", "lookup_root", "remove", "rename", "link", "symlink", "create",
+	"pathconf", "statfs", "readlink", "readdir", "server_caps", "delegreturn",
+	"getacl", "setacl", "fs_locations", "release_lockowner", "secinfo",
+	"fsid_present", "exchange_id", "create_session", "destroy_session",
+	"sequence", "get_lease_time", "reclaim_complete", "layoutget",
+	"getdeviceinfo", "layoutcommit", "layoutreturn", "secinfo_no_name",
+	"test_stateid", "free_stateid", "getdevicelist", "bind_conn_to_session",
+	"destroy_clientid", "seek", "allocate", "deallocate", "layoutstats",
+	"clone", "copy", "offload_cancel", "lookupp", "layouterror", "copy_notify",
+	"getxattr", "setxattr", "listxattrs", "removexattr", "read_plus",
+}
+
+// nfs4ServerProcs are the NFSv4 server procedures of the "proc4" line of
+// /proc/net/rpc/nfsd
+var nfs4ServerProcs = []string{"null", "compound"}
+
+// nfs4ServerOps are the NFSv4 operations of the "proc4ops" line of
+// /proc/net/rpc/nfsd, indexed by operation number. Operations 0-2 are unused.
+var nfs4ServerOps = []string{
+	"", "", "", "access", "close", "commit", "create", "delegpurge",
+	"delegreturn", "getattr", "getfh", "link", "lock", "lockt", "locku",
+	"lookup", "lookupp", "nverify", "open", "openattr", "open_confirm",
+	"open_downgrade", "putfh", "putpubfh", "putrootfh", "read", "readdir",
+	"readlink", "remove", "rename", "renew", "restorefh", "savefh", "secinfo",
+	"setattr", "setclientid", "setclientid_confirm", "verify", "write",
+	"release_lockowner", "backchannel_ctl", "bind_conn_to_session",
+	"exchange_id", "create_session", "destroy_session", "free_stateid",
+	"get_dir_delegation", "getdeviceinfo", "getdevicelist", "layoutcommit",
+	"layoutget", "layoutreturn", "secinfo_no_name", "sequence", "set_ssv",
+	"test_stateid", "want_delegation", "destroy_clientid", "reclaim_complete",
+	"allocate", "copy", "copy_notify", "deallocate", "io_advise",
+	"layouterror", "layoutstats", "offload_cancel", "offload_status",
+	"read_plus", "seek", "write_same", "clone", "getxattr", "setxattr",
+	"listxattrs", "removexattr",
+}
+
+// gatherClient reports the client rpc totals from /proc/net/rpc/nfs
+func (n *NFSStat) gatherClient(acc cua.Accumulator) error {
+	lines, err := readLines(filepath.Join(n.procDir, "net/rpc/nfs"))
+	if err != nil || lines == nil {
+		return err
+	}
+
+	fields := make(map[string]interface{})
+	for _, line := range lines {
+		switch line[0] {
+		case "net":
+			addValues(fields, []string{"net_packets", "net_udp", "net_tcp", "net_tcp_connections"}, line[1:])
+		case "rpc":
+			addValues(fields, []string{"rpc_calls", "rpc_retransmissions", "rpc_auth_refresh"}, line[1:])
+		case "proc3":
+			n.addOps(acc, "nfsstat_client_ops", "3", nfs3Ops, line)
+		case "proc4":
+			n.addOps(acc, "nfsstat_client_ops", "4", nfs4ClientOps, line)
+		}
+	}
+
+	if len(fields) > 0 {
+		acc.AddCounter("nfsstat_client", fields, nil)
+	}
+	return nil
+}
+
+// gatherServer reports the server statistics from /proc/net/rpc/nfsd
+func (n *NFSStat) gatherServer(acc cua.Accumulator) error {
+	lines, err := readLines(filepath.Join(n.procDir, "net/rpc/nfsd"))
+	if err != nil || lines == nil {
+		return err
+	}
+
+	counters := make(map[string]interface{})
+	gauges := make(map[string]interface{})
+	for _, line := range lines {
+		switch line[0] {
+		case "rc":
+			addValues(counters, []string{"reply_cache_hits", "reply_cache_misses", "reply_cache_nocache"}, line[1:])
+		case "io":
+			addValues(counters, []string{"read_bytes", "write_bytes"}, line[1:])
+		case "th":
+			addValues(gauges, []string{"threads"}, line[1:])
+		case "net":
+			addValues(counters, []string{"net_packets", "net_udp", "net_tcp", "net_tcp_connections"}, line[1:])
+		case "rpc":
+			addValues(counters, []string{"rpc_calls", "rpc_bad_calls", "rpc_bad_format", "rpc_bad_auth", "rpc_bad_client"}, line[1:])
+		case "proc3":
+			n.addOps(acc, "nfsstat_server_ops", "3", nfs3Ops, line)
+		case "proc4":
+			n.addOps(acc, "nfsstat_server_ops", "4", nfs4ServerProcs, line)
+		case "proc4ops":
+			n.addOps(acc, "nfsstat_server_ops", "4", nfs4ServerOps, line)
+		}
+	}
+
+	if len(counters) > 0 {
+		acc.AddCounter("nfsstat_server", counters, nil)
+	}
+	if len(gauges) > 0 {
+		acc.AddGauge("nfsstat_server", gauges, nil)
+	}
+	return nil
+}
+
+// addOps reports the per operation counts of a "proc<v> <n> <count>..." line,
+// names maps the position of a count to its operation name
+func (n *NFSStat) addOps(acc cua.Accumulator, measurement, version string, names []string, line []string) {
+	if len(line) < 2 {
+		return
+	}
+	for i, v := range line[2:] {
+		if i >= len(names) {
+			break
+		}
+		if names[i] == "" || !n.includeOp(strings.ToUpper(names[i])) {
+			continue
+		}
+		count, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || count == 0 {
+			continue
+		}
+		tags := map[string]string{"version": version, "operation": names[i]}
+		acc.AddCounter(measurement, map[string]interface{}{"ops": count}, tags)
+	}
+}
+
+// mountOpFields are the per operation statistics of a mount, in order
+var mountOpFields = []string{
+	"ops", "transmissions", "major_timeouts", "bytes_sent", "bytes_recv",
+	"queue_time_ms", "rtt_ms", "execute_time_ms", "errors",
+}
+
+// mountBytesFields are the values of the "bytes:" line of a mount
+var mountBytesFields = []string{
+	"read_bytes", "write_bytes", "direct_read_bytes", "direct_write_bytes",
+	"server_read_bytes", "server_write_bytes", "read_pages", "write_pages",
+}
+
+// gatherMounts reports per mount and per operation statistics from
+// /proc/self/mountstats
+func (n *NFSStat) gatherMounts(acc cua.Accumulator) error {
+	lines, err := readLines(filepath.Join(n.procDir, "self/mountstats"))
+	if err != nil {
+		return err
+	}
+
+	var tags map[string]string
+	var fields map[string]interface{}
+	inOps := false
+
+	flush := func() {
+		if tags != nil && len(fields) > 0 {
+			acc.AddCounter("nfsstat_mount", fields, tags)
+		}
+		tags, fields, inOps = nil, nil, false
+	}
+
+	for _, line := range lines {
+		// device 10.0.0.1:/export mounted on /mnt/export with fstype nfs4 statvers=1.1
+		if line[0] == "device" {
+			flush()
+			if len(line) < 8 || !strings.HasPrefix(line[7], "nfs") {
+				continue
+			}
+			if !n.mountFilter.Match(line[4]) {
+				continue
+			}
+			server, export := line[1], ""
+			if i := strings.Index(server, ":"); i > 0 {
+				server, export = server[:i], server[i+1:]
+			}
+			tags = map[string]string{
+				"mountpoint": line[4],
+				"server":     server,
+				"export":     export,
+				"fstype":     line[7],
+			}
+			fields = make(map[string]interface{})
+			continue
+		}
+
+		if tags == nil {
+			continue
+		}
+
+		switch {
+		case line[0] == "bytes:":
+			addValues(fields, mountBytesFields, line[1:])
+		case line[0] == "age:":
+			addValues(fields, []string{"age"}, line[1:])
+		case line[0] == "per-op":
+			inOps = true
+		case inOps && strings.HasSuffix(line[0], ":"):
+			n.addMountOp(acc, tags, strings.TrimSuffix(line[0], ":"), line[1:])
+		}
+	}
+	flush()
+
+	return nil
+}
+
+func (n *NFSStat) addMountOp(acc cua.Accumulator, mountTags map[string]string, op string, values []string) {
+	if !n.includeOp(op) {
+		return
+	}
+
+	fields := make(map[string]interface{})
+	addValues(fields, mountOpFields, values)
+	if ops, ok := fields["ops"].(uint64); !ok || ops == 0 {
+		return
+	}
+	if trans, ok := fields["transmissions"].(uint64); ok && trans >= fields["ops"].(uint64) {
+		fields["retransmissions"] = trans - fields["ops"].(uint64)
+	}
+
+	tags := make(map[string]string, len(mountTags)+1)
+	for k, v := range mountTags {
+		tags[k] = v
+	}
+	tags["operation"] = op
+
+	acc.AddCounter("nfsstat_mount_ops", fields, tags)
+}
+
+// includeOp reports whether the operation passes include_operations
+func (n *NFSStat) includeOp(op string) bool {
+	return n.opFilter == nil || n.opFilter.Match(op)
+}
+
+func getHostProc() string {
+	if procPath := os.Getenv("HOST_PROC"); procPath != "" {
+		return procPath
+	}
+	return "/proc"
+}
+
+func init() {
+	inputs.Add("nfsstat", func() cua.Input {
+		return &NFSStat{
+			Client:  true,
+			Server:  true,
+			procDir: getHostProc(),
+		}
+	})
+}
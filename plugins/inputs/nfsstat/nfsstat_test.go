@@ -0,0 +1,207 @@
+//go:build linux
+// +build linux
+
+package nfsstat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newNFSStat(t *testing.T) *NFSStat {
+	n := &NFSStat{
+		Log:     testutil.Logger{},
+		Client:  true,
+		Server:  true,
+		procDir: "testdata/proc",
+	}
+	require.NoError(t, n.Init())
+	return n
+}
+
+func TestGatherClient(t *testing.T) {
+	n := newNFSStat(t)
+	n.Server = false
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsFields(t, "nfsstat_client", map[string]interface{}{
+		"net_packets":         uint64(0),
+		"net_udp":             uint64(0),
+		"net_tcp":             uint64(0),
+		"net_tcp_connections": uint64(0),
+		"rpc_calls":           uint64(2110),
+		"rpc_retransmissions": uint64(12),
+		"rpc_auth_refresh":    uint64(0),
+	})
+	acc.AssertContainsTaggedFields(t, "nfsstat_client_ops",
+		map[string]interface{}{"ops": uint64(85)},
+		map[string]string{"version": "3", "operation": "read"})
+	acc.AssertContainsTaggedFields(t, "nfsstat_client_ops",
+		map[string]interface{}{"ops": uint64(70)},
+		map[string]string{"version": "4", "operation": "read"})
+	acc.AssertContainsTaggedFields(t, "nfsstat_client_ops",
+		map[string]interface{}{"ops": uint64(900)},
+		map[string]string{"version": "4", "operation": "sequence"})
+	require.False(t, acc.HasMeasurement("nfsstat_server"))
+}
+
+func TestGatherMounts(t *testing.T) {
+	n := newNFSStat(t)
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	renders := map[string]string{
+		"mountpoint": "/mnt/renders",
+		"server":     "10.0.0.5",
+		"export":     "/export/renders",
+		"fstype":     "nfs4",
+	}
+	acc.AssertContainsTaggedFields(t, "nfsstat_mount",
+		map[string]interface{}{
+			"age":                uint64(86400),
+			"read_bytes":         uint64(1073741824),
+			"write_bytes":        uint64(536870912),
+			"direct_read_bytes":  uint64(0),
+			"direct_write_bytes": uint64(0),
+			"server_read_bytes":  uint64(1073741824),
+			"server_write_bytes": uint64(536870912),
+			"read_pages":         uint64(262144),
+			"write_pages":        uint64(131072),
+		}, renders)
+
+	readTags := map[string]string{"operation": "READ"}
+	for k, v := range renders {
+		readTags[k] = v
+	}
+	acc.AssertContainsTaggedFields(t, "nfsstat_mount_ops",
+		map[string]interface{}{
+			"ops":             uint64(1024),
+			"transmissions":   uint64(1030),
+			"retransmissions": uint64(6),
+			"major_timeouts":  uint64(2),
+			"bytes_sent":      uint64(184320),
+			"bytes_recv":      uint64(1074003968),
+			"queue_time_ms":   uint64(120),
+			"rtt_ms":          uint64(8192),
+			"execute_time_ms": uint64(8400),
+			"errors":          uint64(0),
+		}, readTags)
+
+	// operations with no calls are skipped
+	for _, m := range acc.Metrics {
+		if m.Measurement == "nfsstat_mount_ops" {
+			require.NotEqual(t, "COMMIT", m.Tags["operation"])
+		}
+	}
+
+	// older kernels do not report errors
+	home := map[string]string{
+		"mountpoint": "/home",
+		"server":     "10.0.0.6",
+		"export":     "/home",
+		"fstype":     "nfs",
+		"operation":  "READ",
+	}
+	acc.AssertContainsTaggedFields(t, "nfsstat_mount_ops",
+		map[string]interface{}{
+			"ops":             uint64(5),
+			"transmissions":   uint64(5),
+			"retransmissions": uint64(0),
+			"major_timeouts":  uint64(0),
+			"bytes_sent":      uint64(900),
+			"bytes_recv":      uint64(1000),
+			"queue_time_ms":   uint64(1),
+			"rtt_ms":          uint64(10),
+			"execute_time_ms": uint64(12),
+		}, home)
+}
+
+func TestGatherMountFilters(t *testing.T) {
+	n := &NFSStat{
+		Log:           testutil.Logger{},
+		Client:        true,
+		procDir:       "testdata/proc",
+		ExcludeMounts: []string{"/home"},
+		IncludeOps:    []string{"READ", "WRITE"},
+	}
+	require.NoError(t, n.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+
+	ops := make(map[string]bool)
+	for _, m := range acc.Metrics {
+		switch m.Measurement {
+		case "nfsstat_mount", "nfsstat_mount_ops":
+			require.Equal(t, "/mnt/renders", m.Tags["mountpoint"])
+			if op, ok := m.Tags["operation"]; ok {
+				ops[op] = true
+			}
+		}
+	}
+	require.Equal(t, map[string]bool{"READ": true, "WRITE": true}, ops)
+}
+
+func TestGatherServer(t *testing.T) {
+	n := newNFSStat(t)
+	n.Client = false
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsFields(t, "nfsstat_server", map[string]interface{}{
+		"reply_cache_hits":    uint64(0),
+		"reply_cache_misses":  uint64(5032),
+		"reply_cache_nocache": uint64(12),
+		"read_bytes":          uint64(1048576),
+		"write_bytes":         uint64(2097152),
+		"net_packets":         uint64(5044),
+		"net_udp":             uint64(0),
+		"net_tcp":             uint64(5044),
+		"net_tcp_connections": uint64(21),
+		"rpc_calls":           uint64(5044),
+		"rpc_bad_calls":       uint64(1),
+		"rpc_bad_format":      uint64(0),
+		"rpc_bad_auth":        uint64(1),
+		"rpc_bad_client":      uint64(0),
+	})
+	var threads interface{}
+	for _, m := range acc.Metrics {
+		if m.Measurement == "nfsstat_server" && m.Type == cua.Gauge {
+			threads = m.Fields["threads"]
+		}
+	}
+	require.Equal(t, uint64(8), threads)
+	acc.AssertContainsTaggedFields(t, "nfsstat_server_ops",
+		map[string]interface{}{"ops": uint64(600)},
+		map[string]string{"version": "3", "operation": "write"})
+	acc.AssertContainsTaggedFields(t, "nfsstat_server_ops",
+		map[string]interface{}{"ops": uint64(1500)},
+		map[string]string{"version": "4", "operation": "compound"})
+	acc.AssertContainsTaggedFields(t, "nfsstat_server_ops",
+		map[string]interface{}{"ops": uint64(1200)},
+		map[string]string{"version": "4", "operation": "putfh"})
+	acc.AssertContainsTaggedFields(t, "nfsstat_server_ops",
+		map[string]interface{}{"ops": uint64(1500)},
+		map[string]string{"version": "4", "operation": "sequence"})
+}
+
+func TestGatherMissingFiles(t *testing.T) {
+	n := newNFSStat(t)
+	n.procDir = t.TempDir()
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Empty(t, acc.Metrics)
+}
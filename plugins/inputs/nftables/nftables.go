@@ -0,0 +1,202 @@
+//go:build linux
+// +build linux
+
+package nftables
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+// Nftables is a plugin to gather packets and bytes counters of commented rules from nftables.
+type Nftables struct {
+	UseSudo bool     `toml:"use_sudo"`
+	Binary  string   `toml:"binary"`
+	Tables  []string `toml:"tables"`
+	Chains  []string `toml:"chains"`
+	lister  tableLister
+}
+
+// Description returns a short description of the plugin.
+func (nft *Nftables) Description() string {
+	return "Gather packets and bytes counters of commented rules from nftables"
+}
+
+// SampleConfig returns sample configuration options.
+func (nft *Nftables) SampleConfig() string {
+	return `
+  ## nft requires CAP_NET_ADMIN.
+  ## Setting 'use_sudo' to true will make use of sudo to run nft.
+  ## Users must configure sudo to allow cua user to run nft with no password.
+  ## nft can be restricted to only the list command "nft -j list table *".
+  use_sudo = false
+  ## Define an alternate executable. Default is "nft".
+  # binary = "/usr/sbin/nft"
+  ## defines the tables to monitor, as "family name":
+  tables = [ "inet filter" ]
+  ## defines the chains to monitor, all chains of the tables if empty.
+  ## NOTE: rules without a comment or a counter will not be monitored.
+  # chains = [ "input" ]
+`
+}
+
+// Gather gathers the rule counters of the configured tables and chains.
+func (nft *Nftables) Gather(ctx context.Context, acc cua.Accumulator) error {
+	// best effort : we continue through the tables even if an error is encountered
+	for _, table := range nft.Tables {
+		family, name, err := splitTable(table)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		data, err := nft.lister(family, name)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		if err := nft.parseAndGather(data, acc); err != nil {
+			acc.AddError(err)
+			continue
+		}
+	}
+	return nil
+}
+
+func splitTable(table string) (string, string, error) {
+	parts := strings.Fields(table)
+	switch len(parts) {
+	case 1:
+		// nft defaults to the ip family
+		return "ip", parts[0], nil
+	case 2:
+		return parts[0], parts[1], nil
+	}
+	return "", "", fmt.Errorf("invalid table %q, expected \"family name\"", table)
+}
+
+func (nft *Nftables) tableList(family, table string) ([]byte, error) {
+	binary := "nft"
+	if nft.Binary != "" {
+		binary = nft.Binary
+	}
+	nftPath, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, fmt.Errorf("nftables tablelist lookpath (%s): %w", binary, err)
+	}
+	var args []string
+	name := nftPath
+	if nft.UseSudo {
+		name = "sudo"
+		args = append(args, nftPath)
+	}
+	args = append(args, "-j", "list", "table", family, table)
+	c := exec.Command(name, args...)
+	out, err := c.Output()
+	if err != nil {
+		return out, fmt.Errorf("nft cmd output: %w", err)
+	}
+	return out, nil
+}
+
+const measurement = "nftables"
+
+// ruleset is the nft -j output, a list of objects with one key naming the
+// object type
+type ruleset struct {
+	Nftables []struct {
+		Rule *rule `json:"rule"`
+	} `json:"nftables"`
+}
+
+type rule struct {
+	Family  string                       `json:"family"`
+	Table   string                       `json:"table"`
+	Chain   string                       `json:"chain"`
+	Comment string                       `json:"comment"`
+	Expr    []map[string]json.RawMessage `json:"expr"`
+}
+
+type counter struct {
+	Packets uint64 `json:"packets"`
+	Bytes   uint64 `json:"bytes"`
+}
+
+// verdicts are the statements ending a rule, the target tag
+var verdicts = []string{"accept", "drop", "reject", "jump", "goto", "return", "queue", "continue"}
+
+func (nft *Nftables) parseAndGather(data []byte, acc cua.Accumulator) error {
+	var rs ruleset
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return fmt.Errorf("cannot parse nft list output: %w", err)
+	}
+
+	for _, obj := range rs.Nftables {
+		r := obj.Rule
+		if r == nil || r.Comment == "" || !nft.monitored(r.Chain) {
+			continue
+		}
+
+		var c *counter
+		target := ""
+		for _, expr := range r.Expr {
+			if raw, ok := expr["counter"]; ok && c == nil {
+				// a named counter is referenced by its name
+				var anon counter
+				if err := json.Unmarshal(raw, &anon); err == nil {
+					c = &anon
+				}
+			}
+			for _, v := range verdicts {
+				if _, ok := expr[v]; ok {
+					target = v
+				}
+			}
+		}
+		if c == nil {
+			continue
+		}
+
+		tags := map[string]string{
+			"family": r.Family,
+			"table":  r.Table,
+			"chain":  r.Chain,
+			"ruleid": r.Comment,
+		}
+		if target != "" {
+			tags["target"] = target
+		}
+		acc.AddFields(measurement, map[string]interface{}{
+			"pkts":  c.Packets,
+			"bytes": c.Bytes,
+		}, tags)
+	}
+	return nil
+}
+
+func (nft *Nftables) monitored(chain string) bool {
+	if len(nft.Chains) == 0 {
+		return true
+	}
+	for _, c := range nft.Chains {
+		if c == chain {
+			return true
+		}
+	}
+	return false
+}
+
+type tableLister func(family, table string) ([]byte, error)
+
+func init() {
+	inputs.Add("nftables", func() cua.Input {
+		nft := new(Nftables)
+		nft.lister = nft.tableList
+		return nft
+	})
+}
@@ -0,0 +1,76 @@
+//go:build linux
+// +build linux
+
+package nftables
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const filterTable = `{"nftables": [
+  {"metainfo": {"version": "0.9.3", "release_name": "Topsy", "json_schema_version": 1}},
+  {"table": {"family": "inet", "name": "filter", "handle": 1}},
+  {"chain": {"family": "inet", "table": "filter", "name": "input", "handle": 1, "type": "filter", "hook": "input", "prio": 0, "policy": "drop"}},
+  {"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 4, "comment": "ssh",
+    "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "tcp", "field": "dport"}}, "right": 22}},
+             {"counter": {"packets": 100, "bytes": 1024}}, {"accept": null}]}},
+  {"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 5, "comment": "blocklist",
+    "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "ip", "field": "saddr"}}, "right": "@blocklist"}},
+             {"counter": {"packets": 7, "bytes": 420}}, {"drop": null}]}},
+  {"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 6,
+    "expr": [{"counter": {"packets": 1, "bytes": 60}}, {"accept": null}]}},
+  {"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 7, "comment": "named counter",
+    "expr": [{"counter": "http"}, {"accept": null}]}},
+  {"rule": {"family": "inet", "table": "filter", "chain": "forward", "handle": 8, "comment": "docker",
+    "expr": [{"counter": {"packets": 42, "bytes": 2048}}, {"jump": {"target": "docker"}}]}}
+]}`
+
+func TestNftables_Gather(t *testing.T) {
+	nft := &Nftables{
+		Tables: []string{"inet filter"},
+		lister: func(family, table string) ([]byte, error) {
+			require.Equal(t, "inet", family)
+			require.Equal(t, "filter", table)
+			return []byte(filterTable), nil
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(nft.Gather))
+	require.Len(t, acc.Metrics, 3)
+	acc.AssertContainsTaggedFields(t, "nftables",
+		map[string]interface{}{"pkts": uint64(100), "bytes": uint64(1024)},
+		map[string]string{"family": "inet", "table": "filter", "chain": "input", "ruleid": "ssh", "target": "accept"})
+	acc.AssertContainsTaggedFields(t, "nftables",
+		map[string]interface{}{"pkts": uint64(7), "bytes": uint64(420)},
+		map[string]string{"family": "inet", "table": "filter", "chain": "input", "ruleid": "blocklist", "target": "drop"})
+	acc.AssertContainsTaggedFields(t, "nftables",
+		map[string]interface{}{"pkts": uint64(42), "bytes": uint64(2048)},
+		map[string]string{"family": "inet", "table": "filter", "chain": "forward", "ruleid": "docker", "target": "jump"})
+
+	nft.Chains = []string{"forward"}
+	acc.ClearMetrics()
+	require.NoError(t, acc.GatherError(nft.Gather))
+	require.Len(t, acc.Metrics, 1)
+}
+
+func TestNftables_Errors(t *testing.T) {
+	nft := &Nftables{
+		Tables: []string{"inet filter", "too many words", "ip nat"},
+		lister: func(family, table string) ([]byte, error) {
+			if table == "nat" {
+				return nil, errors.New("no such table")
+			}
+			return []byte("not json"), nil
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, nft.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 3)
+}
@@ -0,0 +1,179 @@
+//go:build linux
+// +build linux
+
+package numa
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+// NUMA reports the hugepage pools and the NUMA allocation counters of the
+// memory nodes
+type NUMA struct {
+	Log cua.Logger `toml:"-"`
+
+	Hugepages bool `toml:"hugepages"`
+	NodeStats bool `toml:"node_stats"`
+
+	sysDir string
+}
+
+const sampleConfig = `
+  ## Report the hugepage pools of each page size, system wide and per node
+  # hugepages = true
+
+  ## Report the NUMA allocation counters of each node, e.g. numa_hit,
+  ## numa_miss and numa_foreign
+  # node_stats = true
+`
+
+// hugepageFiles maps the files of a hugepage pool to their field
+var hugepageFiles = map[string]string{
+	"nr_hugepages":            "total",
+	"free_hugepages":          "free",
+	"surplus_hugepages":       "surplus",
+	"resv_hugepages":          "reserved",
+	"nr_overcommit_hugepages": "overcommit_max",
+}
+
+func (*NUMA) Description() string {
+	return "Read hugepage pool usage and NUMA node allocation counters from sysfs"
+}
+
+func (*NUMA) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *NUMA) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if n.Hugepages {
+		if err := gatherHugepages(acc, filepath.Join(n.sysDir, "kernel", "mm", "hugepages"), "numa_hugepages", nil); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	nodes, err := filepath.Glob(filepath.Join(n.sysDir, "devices", "system", "node", "node[0-9]*"))
+	if err != nil {
+		return fmt.Errorf("node glob: %w", err)
+	}
+	for _, dir := range nodes {
+		node := strings.TrimPrefix(filepath.Base(dir), "node")
+		if n.Hugepages {
+			tags := map[string]string{"node": node}
+			if err := gatherHugepages(acc, filepath.Join(dir, "hugepages"), "numa_node_hugepages", tags); err != nil {
+				acc.AddError(err)
+			}
+		}
+		if n.NodeStats {
+			if err := gatherNodeStats(acc, dir, node); err != nil {
+				acc.AddError(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// gatherHugepages reports the pools of a hugepages directory, one per page size
+func gatherHugepages(acc cua.Accumulator, dir, measurement string, tags map[string]string) error {
+	pools, err := filepath.Glob(filepath.Join(dir, "hugepages-*kB"))
+	if err != nil {
+		return fmt.Errorf("hugepages glob: %w", err)
+	}
+
+	for _, pool := range pools {
+		size := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(pool), "hugepages-"), "kB")
+		sizeKB, err := strconv.ParseUint(size, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		for file, field := range hugepageFiles {
+			b, err := os.ReadFile(filepath.Join(pool, file))
+			if err != nil {
+				// the per node pools only have the total, free and surplus pages
+				continue
+			}
+			v, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+			if err != nil {
+				return fmt.Errorf("parse %s: %w", filepath.Join(pool, file), err)
+			}
+			fields[field] = v
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if total, ok := fields["total"].(uint64); ok {
+			if free, ok := fields["free"].(uint64); ok && free <= total {
+				fields["used"] = total - free
+			}
+			fields["total_bytes"] = total * sizeKB * 1024
+		}
+
+		poolTags := map[string]string{"size_kb": size}
+		for k, v := range tags {
+			poolTags[k] = v
+		}
+		acc.AddFields(measurement, fields, poolTags)
+	}
+
+	return nil
+}
+
+// gatherNodeStats reports the numastat counters of a node:
+//
+//	numa_hit 2343478
+//	numa_miss 0
+//	numa_foreign 0
+func gatherNodeStats(acc cua.Accumulator, dir, node string) error {
+	b, err := os.ReadFile(filepath.Join(dir, "numastat"))
+	if err != nil {
+		return fmt.Errorf("node %s numastat: %w", node, err)
+	}
+
+	fields := make(map[string]interface{})
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("node %s numastat %s: %w", node, parts[0], err)
+		}
+		fields[parts[0]] = v
+	}
+	if len(fields) != 0 {
+		acc.AddFields("numa_node", fields, map[string]string{"node": node})
+	}
+
+	return nil
+}
+
+func getHostSys() string {
+	if sysPath := os.Getenv("HOST_SYS"); sysPath != "" {
+		return sysPath
+	}
+	return "/sys"
+}
+
+func init() {
+	inputs.Add("numa", func() cua.Input {
+		return &NUMA{
+			Hugepages: true,
+			NodeStats: true,
+			sysDir:    getHostSys(),
+		}
+	})
+}
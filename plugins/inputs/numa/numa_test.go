@@ -0,0 +1,90 @@
+//go:build linux
+// +build linux
+
+package numa
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0600))
+	}
+}
+
+func TestGather(t *testing.T) {
+	sys := t.TempDir()
+	writeFiles(t, filepath.Join(sys, "kernel", "mm", "hugepages", "hugepages-2048kB"), map[string]string{
+		"nr_hugepages":            "1024",
+		"free_hugepages":          "256",
+		"surplus_hugepages":       "0",
+		"resv_hugepages":          "12",
+		"nr_overcommit_hugepages": "0",
+	})
+	writeFiles(t, filepath.Join(sys, "kernel", "mm", "hugepages", "hugepages-1048576kB"), map[string]string{
+		"nr_hugepages":            "0",
+		"free_hugepages":          "0",
+		"surplus_hugepages":       "0",
+		"resv_hugepages":          "0",
+		"nr_overcommit_hugepages": "0",
+	})
+	node0 := filepath.Join(sys, "devices", "system", "node", "node0")
+	writeFiles(t, filepath.Join(node0, "hugepages", "hugepages-2048kB"), map[string]string{
+		"nr_hugepages":      "512",
+		"free_hugepages":    "128",
+		"surplus_hugepages": "0",
+	})
+	writeFiles(t, node0, map[string]string{
+		"numastat": "numa_hit 2343478\nnuma_miss 10\nnuma_foreign 20\ninterleave_hit 3356\nlocal_node 2343000\nother_node 478",
+	})
+	// not a node
+	writeFiles(t, filepath.Join(sys, "devices", "system", "node", "power"), map[string]string{})
+
+	n := &NUMA{Hugepages: true, NodeStats: true, sysDir: sys}
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 4)
+
+	acc.AssertContainsTaggedFields(t, "numa_hugepages",
+		map[string]interface{}{
+			"total":          uint64(1024),
+			"free":           uint64(256),
+			"used":           uint64(768),
+			"surplus":        uint64(0),
+			"reserved":       uint64(12),
+			"overcommit_max": uint64(0),
+			"total_bytes":    uint64(2 * 1024 * 1024 * 1024),
+		},
+		map[string]string{"size_kb": "2048"},
+	)
+	acc.AssertContainsTaggedFields(t, "numa_node_hugepages",
+		map[string]interface{}{
+			"total":       uint64(512),
+			"free":        uint64(128),
+			"used":        uint64(384),
+			"surplus":     uint64(0),
+			"total_bytes": uint64(1024 * 1024 * 1024),
+		},
+		map[string]string{"size_kb": "2048", "node": "0"},
+	)
+	acc.AssertContainsTaggedFields(t, "numa_node",
+		map[string]interface{}{
+			"numa_hit":       uint64(2343478),
+			"numa_miss":      uint64(10),
+			"numa_foreign":   uint64(20),
+			"interleave_hit": uint64(3356),
+			"local_node":     uint64(2343000),
+			"other_node":     uint64(478),
+		},
+		map[string]string{"node": "0"},
+	)
+}
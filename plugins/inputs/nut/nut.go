@@ -0,0 +1,252 @@
+// Package nut implements a plugin for collecting UPS status from the
+// Network UPS Tools upsd daemon.
+package nut
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const defaultAddress = "tcp://127.0.0.1:3493"
+
+// variables maps the upsd variables to the fields, named like the ones of
+// the apcupsd input
+var variables = map[string]string{
+	"battery.charge":          "battery_charge_percent",
+	"battery.voltage":         "battery_voltage",
+	"battery.voltage.nominal": "nominal_battery_voltage",
+	"input.voltage":           "input_voltage",
+	"input.voltage.nominal":   "nominal_input_voltage",
+	"input.frequency":         "input_frequency",
+	"output.voltage":          "output_voltage",
+	"ups.load":                "load_percent",
+	"ups.temperature":         "internal_temp",
+	"ups.realpower.nominal":   "nominal_power",
+}
+
+// Nut reads the variables of the UPSes of one or more upsd servers
+type Nut struct {
+	Servers  []string          `toml:"servers"`
+	Ups      []string          `toml:"ups"`
+	Username string            `toml:"username"`
+	Password string            `toml:"password"`
+	Timeout  internal.Duration `toml:"timeout"`
+}
+
+func (*Nut) Description() string {
+	return "Monitor UPSes through the Network UPS Tools upsd daemon"
+}
+
+func (*Nut) SampleConfig() string {
+	return `
+  ## A list of upsd servers to connect to.
+  # servers = ["tcp://127.0.0.1:3493"]
+
+  ## UPS names to report, all the UPSes of the servers when empty
+  # ups = []
+
+  ## Credentials of a upsd.users user, upsd lets any client read the
+  ## variables unless it is restricted
+  # username = ""
+  # password = ""
+
+  ## Timeout for connecting and reading the variables
+  # timeout = "5s"
+`
+}
+
+func (n *Nut) Gather(ctx context.Context, acc cua.Accumulator) error {
+	for _, addr := range n.Servers {
+		u, err := url.Parse(addr)
+		if err != nil {
+			acc.AddError(fmt.Errorf("url parse (%s): %w", addr, err))
+			continue
+		}
+		if err := n.gatherServer(acc, u.Host); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+func (n *Nut) gatherServer(acc cua.Accumulator, addr string) error {
+	conn, err := net.DialTimeout("tcp", addr, n.Timeout.Duration)
+	if err != nil {
+		return fmt.Errorf("dial (%s): %w", addr, err)
+	}
+	defer conn.Close()
+	if n.Timeout.Duration > 0 {
+		_ = conn.SetDeadline(time.Now().Add(n.Timeout.Duration))
+	}
+
+	c := &upsdConn{conn: conn, r: bufio.NewReader(conn)}
+	if n.Username != "" {
+		if _, err := c.command("USERNAME " + n.Username); err != nil {
+			return fmt.Errorf("username (%s): %w", addr, err)
+		}
+		if _, err := c.command("PASSWORD " + n.Password); err != nil {
+			return fmt.Errorf("password (%s): %w", addr, err)
+		}
+	}
+
+	upses := n.Ups
+	if len(upses) == 0 {
+		lines, err := c.list("UPS")
+		if err != nil {
+			return fmt.Errorf("list ups (%s): %w", addr, err)
+		}
+		// UPS <upsname> "<description>"
+		for _, line := range lines {
+			if cols := strings.Fields(line); len(cols) > 1 && cols[0] == "UPS" {
+				upses = append(upses, cols[1])
+			}
+		}
+	}
+
+	for _, ups := range upses {
+		lines, err := c.list("VAR " + ups)
+		if err != nil {
+			acc.AddError(fmt.Errorf("list var %s (%s): %w", ups, addr, err))
+			continue
+		}
+		vars := make(map[string]string)
+		// VAR <upsname> <varname> "<value>"
+		for _, line := range lines {
+			cols := strings.SplitN(line, " ", 4)
+			if len(cols) != 4 || cols[0] != "VAR" {
+				continue
+			}
+			vars[cols[2]] = unquote(cols[3])
+		}
+		addUPS(acc, addr, ups, vars)
+	}
+
+	_, _ = fmt.Fprint(conn, "LOGOUT\n")
+
+	return nil
+}
+
+// addUPS adds the metric of a UPS from its variables
+func addUPS(acc cua.Accumulator, server, ups string, vars map[string]string) {
+	tags := map[string]string{
+		"server":   server,
+		"ups_name": ups,
+		"status":   vars["ups.status"],
+		"model":    first(vars, "device.model", "ups.model"),
+		"serial":   first(vars, "device.serial", "ups.serial"),
+	}
+
+	fields := make(map[string]interface{})
+	for name, field := range variables {
+		if v, err := strconv.ParseFloat(vars[name], 64); err == nil {
+			fields[field] = v
+		}
+	}
+	if v, err := strconv.ParseFloat(vars["battery.runtime"], 64); err == nil {
+		fields["time_left_ns"] = int64(v * float64(time.Second))
+	}
+
+	// OL, OB, LB, RB, CHRG, ... separated by spaces
+	flags := make(map[string]bool)
+	for _, flag := range strings.Fields(vars["ups.status"]) {
+		flags[flag] = true
+	}
+	fields["on_battery"] = flag(flags["OB"])
+	fields["low_battery"] = flag(flags["LB"])
+	fields["replace_battery"] = flag(flags["RB"])
+	fields["overload"] = flag(flags["OVER"])
+
+	acc.AddFields("nut", fields, tags)
+}
+
+func flag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func first(vars map[string]string, names ...string) string {
+	for _, name := range names {
+		if v, ok := vars[name]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// unquote removes the quotes and the escaping of \ and " of a value
+func unquote(s string) string {
+	if v, err := strconv.Unquote(s); err == nil {
+		return v
+	}
+	return strings.Trim(s, `"`)
+}
+
+// upsdConn is a connection to upsd using its line based protocol
+type upsdConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *upsdConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("read: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "ERR ") {
+		return "", fmt.Errorf("%s", strings.TrimPrefix(line, "ERR "))
+	}
+	return line, nil
+}
+
+// command sends a command answered by a single line
+func (c *upsdConn) command(cmd string) (string, error) {
+	if _, err := fmt.Fprintf(c.conn, "%s\n", cmd); err != nil {
+		return "", fmt.Errorf("write: %w", err)
+	}
+	return c.readLine()
+}
+
+// list sends LIST <query> and returns the lines between BEGIN LIST and
+// END LIST
+func (c *upsdConn) list(query string) ([]string, error) {
+	line, err := c.command("LIST " + query)
+	if err != nil {
+		return nil, err
+	}
+	if line != "BEGIN LIST "+query {
+		return nil, fmt.Errorf("unexpected reply %q", line)
+	}
+	var lines []string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "END LIST "+query {
+			return lines, nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+func init() {
+	inputs.Add("nut", func() cua.Input {
+		return &Nut{
+			Servers: []string{defaultAddress},
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
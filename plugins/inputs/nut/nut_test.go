@@ -0,0 +1,163 @@
+package nut
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var upsVars = map[string][]string{
+	"myups": {
+		`VAR myups battery.charge "84"`,
+		`VAR myups battery.runtime "1740"`,
+		`VAR myups battery.voltage "26.2"`,
+		`VAR myups device.model "Back-UPS XS 1400U"`,
+		`VAR myups device.serial "4B1623P12345"`,
+		`VAR myups input.voltage "0.0"`,
+		`VAR myups input.voltage.nominal "230"`,
+		`VAR myups ups.load "21"`,
+		`VAR myups ups.realpower.nominal "700"`,
+		`VAR myups ups.status "OB DISCHRG"`,
+		`VAR myups ups.mfr.date "2016/06/07"`,
+	},
+	"rack2": {
+		`VAR rack2 battery.charge "100"`,
+		`VAR rack2 ups.model "Smart-UPS 1500 \"RM\""`,
+		`VAR rack2 ups.status "OL RB"`,
+	},
+}
+
+func fakeUpsd(t *testing.T, password string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.Fields(line)
+			switch {
+			case cmd[0] == "USERNAME":
+				fmt.Fprint(conn, "OK\n")
+			case cmd[0] == "PASSWORD":
+				if len(cmd) < 2 || cmd[1] != password {
+					fmt.Fprint(conn, "ERR ACCESS-DENIED\n")
+					continue
+				}
+				fmt.Fprint(conn, "OK\n")
+			case len(cmd) == 2 && cmd[1] == "UPS":
+				fmt.Fprint(conn, "BEGIN LIST UPS\nUPS myups \"APC Back-UPS\"\nUPS rack2 \"Rack UPS\"\nEND LIST UPS\n")
+			case len(cmd) == 3 && cmd[1] == "VAR":
+				vars, ok := upsVars[cmd[2]]
+				if !ok {
+					fmt.Fprint(conn, "ERR UNKNOWN-UPS\n")
+					continue
+				}
+				fmt.Fprintf(conn, "BEGIN LIST VAR %s\n%s\nEND LIST VAR %s\n", cmd[2], strings.Join(vars, "\n"), cmd[2])
+			case cmd[0] == "LOGOUT":
+				fmt.Fprint(conn, "OK Goodbye\n")
+				return
+			default:
+				fmt.Fprint(conn, "ERR UNKNOWN-COMMAND\n")
+			}
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestGather(t *testing.T) {
+	addr := fakeUpsd(t, "")
+	n := &Nut{
+		Servers: []string{"tcp://" + addr},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(n.Gather))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "nut",
+		map[string]interface{}{
+			"battery_charge_percent": float64(84),
+			"battery_voltage":        26.2,
+			"input_voltage":          float64(0),
+			"nominal_input_voltage":  float64(230),
+			"load_percent":           float64(21),
+			"nominal_power":          float64(700),
+			"time_left_ns":           int64(1740000000000),
+			"on_battery":             1,
+			"low_battery":            0,
+			"replace_battery":        0,
+			"overload":               0,
+		},
+		map[string]string{
+			"server":   addr,
+			"ups_name": "myups",
+			"status":   "OB DISCHRG",
+			"model":    "Back-UPS XS 1400U",
+			"serial":   "4B1623P12345",
+		})
+	acc.AssertContainsTaggedFields(t, "nut",
+		map[string]interface{}{
+			"battery_charge_percent": float64(100),
+			"on_battery":             0,
+			"low_battery":            0,
+			"replace_battery":        1,
+			"overload":               0,
+		},
+		map[string]string{
+			"server":   addr,
+			"ups_name": "rack2",
+			"status":   "OL RB",
+			"model":    `Smart-UPS 1500 "RM"`,
+			"serial":   "",
+		})
+}
+
+func TestGatherUnknownUps(t *testing.T) {
+	addr := fakeUpsd(t, "")
+	n := &Nut{
+		Servers: []string{"tcp://" + addr},
+		Ups:     []string{"nope", "rack2"},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "UNKNOWN-UPS")
+	require.Equal(t, 1, len(acc.GetCUAMetrics()))
+}
+
+func TestGatherBadPassword(t *testing.T) {
+	addr := fakeUpsd(t, "secret")
+	n := &Nut{
+		Servers:  []string{"tcp://" + addr},
+		Username: "monitor",
+		Password: "wrong",
+		Timeout:  internal.Duration{Duration: 5 * time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "ACCESS-DENIED")
+}
@@ -15,7 +15,10 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 )
 
-const measurement = "nvidia_smi"
+const (
+	measurement        = "nvidia_smi"
+	processMeasurement = "nvidia_smi_process"
+)
 
 // NvidiaSMI holds the methods for this plugin
 type NvidiaSMI struct {
@@ -88,7 +91,7 @@ func gatherNvidiaSMI(ret []byte, acc cua.Accumulator) error {
 	metrics := smi.genTagsFields()
 
 	for _, metric := range metrics {
-		acc.AddFields(measurement, metric.fields, metric.tags)
+		acc.AddFields(metric.name, metric.fields, metric.tags)
 	}
 
 	return nil
@@ -97,6 +100,7 @@ func gatherNvidiaSMI(ret []byte, acc cua.Accumulator) error {
 type metric struct {
 	tags   map[string]string
 	fields map[string]interface{}
+	name   string
 }
 
 func (s *SMI) genTagsFields() []metric {
@@ -137,11 +141,53 @@ func (s *SMI) genTagsFields() []metric {
 		setIfUsed("int", fields, "clocks_current_video", gpu.Clocks.Video)
 
 		setIfUsed("float", fields, "power_draw", gpu.Power.PowerDraw)
-		metrics = append(metrics, metric{tags, fields})
+
+		setECCIfUsed(fields, "ecc_errors_volatile_corrected", gpu.ECC.Volatile.corrected()...)
+		setECCIfUsed(fields, "ecc_errors_volatile_uncorrected", gpu.ECC.Volatile.uncorrected()...)
+		setECCIfUsed(fields, "ecc_errors_aggregate_corrected", gpu.ECC.Aggregate.corrected()...)
+		setECCIfUsed(fields, "ecc_errors_aggregate_uncorrected", gpu.ECC.Aggregate.uncorrected()...)
+
+		metrics = append(metrics, metric{tags, fields, measurement})
+
+		for _, proc := range gpu.Processes {
+			ptags := map[string]string{
+				"index": tags["index"],
+			}
+			setTagIfUsed(ptags, "uuid", gpu.UUID)
+			setTagIfUsed(ptags, "process_name", proc.ProcessName)
+			setTagIfUsed(ptags, "type", proc.Type)
+
+			// the pid is a field, tagging by it would create a new series
+			// for every process started
+			pfields := map[string]interface{}{}
+			setIfUsed("int", pfields, "used_memory", proc.UsedMemory)
+			if len(pfields) == 0 {
+				continue
+			}
+			setIfUsed("int", pfields, "pid", proc.PID)
+			metrics = append(metrics, metric{ptags, pfields, processMeasurement})
+		}
 	}
 	return metrics
 }
 
+// setECCIfUsed sets the sum of the error counts, counts that are not
+// available, e.g. N/A when ECC is disabled, are ignored.
+func setECCIfUsed(m map[string]interface{}, k string, counts ...string) {
+	total, used := 0, false
+	for _, count := range counts {
+		i, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil {
+			continue
+		}
+		total += i
+		used = true
+	}
+	if used {
+		m[k] = total
+	}
+}
+
 func setTagIfUsed(m map[string]string, k, v string) {
 	if v != "" {
 		m[k] = v
@@ -185,7 +231,7 @@ func setIfUsed(t string, m map[string]interface{}, k, v string) {
 type SMI struct {
 	GPU           GPU    `xml:"gpu"`
 	DriverVersion string `xml:"driver_version"`
-	CUDAVersion   string `xml:"cuda_versoion"`
+	CUDAVersion   string `xml:"cuda_version"`
 }
 
 // GPU defines the structure of the GPU portion of the smi output.
@@ -203,6 +249,8 @@ type GPU []struct {
 	Encoder     EncoderStats     `xml:"encoder_stats"`
 	FBC         FBCStats         `xml:"fbc_stats"`
 	Clocks      ClockStats       `xml:"clocks"`
+	ECC         ECCErrors        `xml:"ecc_errors"`
+	Processes   []ProcessInfo    `xml:"processes>process_info"`
 }
 
 // MemoryStats defines the structure of the memory portions in the smi output.
@@ -263,3 +311,41 @@ type ClockStats struct {
 	Memory   string `xml:"mem_clock"`      // int
 	Video    string `xml:"video_clock"`    // int
 }
+
+// ECCErrors defines the structure of the ecc_errors portion of the smi output.
+type ECCErrors struct {
+	Volatile  ECCCounts `xml:"volatile"`
+	Aggregate ECCCounts `xml:"aggregate"`
+}
+
+// ECCCounts defines the structure of the volatile and aggregate ecc error
+// counts, older drivers report single and double bit totals and newer
+// drivers report sram and dram correctable and uncorrectable counts.
+type ECCCounts struct {
+	SingleBit struct {
+		Total string `xml:"total"` // int
+	} `xml:"single_bit"`
+	DoubleBit struct {
+		Total string `xml:"total"` // int
+	} `xml:"double_bit"`
+	SRAMCorrectable   string `xml:"sram_correctable"`   // int
+	SRAMUncorrectable string `xml:"sram_uncorrectable"` // int
+	DRAMCorrectable   string `xml:"dram_correctable"`   // int
+	DRAMUncorrectable string `xml:"dram_uncorrectable"` // int
+}
+
+func (c ECCCounts) corrected() []string {
+	return []string{c.SingleBit.Total, c.SRAMCorrectable, c.DRAMCorrectable}
+}
+
+func (c ECCCounts) uncorrected() []string {
+	return []string{c.DoubleBit.Total, c.SRAMUncorrectable, c.DRAMUncorrectable}
+}
+
+// ProcessInfo defines the structure of the process_info portion of the smi output.
+type ProcessInfo struct {
+	PID         string `xml:"pid"`          // int
+	Type        string `xml:"type"`         // C, G or C+G
+	ProcessName string `xml:"process_name"` // string
+	UsedMemory  string `xml:"used_memory"`  // int
+}
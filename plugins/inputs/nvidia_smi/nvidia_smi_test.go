@@ -134,6 +134,71 @@ func TestGatherValidXML(t *testing.T) {
 					time.Unix(0, 0)),
 			},
 		},
+		{
+			name:     "Tesla V100",
+			filename: "tesla-v100.xml",
+			expected: []cua.Metric{
+				testutil.MustMetric(
+					"nvidia_smi",
+					map[string]string{
+						"compute_mode": "Default",
+						"index":        "0",
+						"name":         "Tesla V100-SXM2-16GB",
+						"pstate":       "P0",
+						"uuid":         "GPU-6a2c6b8e-7b3f-1d2e-9c1a-4b5d6e7f8091",
+					},
+					map[string]interface{}{
+						"clocks_current_graphics":          1530,
+						"clocks_current_memory":            877,
+						"clocks_current_sm":                1530,
+						"clocks_current_video":             1372,
+						"cuda_version":                     "11.2",
+						"driver_version":                   "460.73.01",
+						"ecc_errors_aggregate_corrected":   14,
+						"ecc_errors_aggregate_uncorrected": 1,
+						"ecc_errors_volatile_corrected":    2,
+						"ecc_errors_volatile_uncorrected":  0,
+						"memory_free":                      6284,
+						"memory_total":                     16160,
+						"memory_used":                      9876,
+						"pcie_link_gen_current":            3,
+						"pcie_link_width_current":          16,
+						"power_draw":                       243.51,
+						"temperature_gpu":                  61,
+						"utilization_gpu":                  87,
+						"utilization_memory":               41,
+						"utilization_encoder":              0,
+						"utilization_decoder":              0,
+					},
+					time.Unix(0, 0)),
+				testutil.MustMetric(
+					"nvidia_smi_process",
+					map[string]string{
+						"index":        "0",
+						"uuid":         "GPU-6a2c6b8e-7b3f-1d2e-9c1a-4b5d6e7f8091",
+						"process_name": "/usr/bin/python3",
+						"type":         "C",
+					},
+					map[string]interface{}{
+						"used_memory": 9361,
+						"pid":         31337,
+					},
+					time.Unix(0, 0)),
+				testutil.MustMetric(
+					"nvidia_smi_process",
+					map[string]string{
+						"index":        "0",
+						"uuid":         "GPU-6a2c6b8e-7b3f-1d2e-9c1a-4b5d6e7f8091",
+						"process_name": "/opt/dcgm/bin/nv-hostengine",
+						"type":         "C",
+					},
+					map[string]interface{}{
+						"used_memory": 512,
+						"pid":         4242,
+					},
+					time.Unix(0, 0)),
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
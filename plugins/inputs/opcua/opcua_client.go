@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/config"
@@ -18,6 +19,13 @@ import (
 const (
 	none = "None"
 	auto = "auto"
+
+	modeRead      = "read"
+	modeSubscribe = "subscribe"
+
+	timestampGather = "gather"
+	timestampServer = "server"
+	timestampSource = "source"
 )
 
 // OpcUA type
@@ -33,6 +41,9 @@ type OpcUA struct {
 	AuthMethod     string          `toml:"auth_method"`
 	ConnectTimeout config.Duration `toml:"connect_timeout"`
 	RequestTimeout config.Duration `toml:"request_timeout"`
+	Mode           string          `toml:"mode"`
+	SubInterval    config.Duration `toml:"subscription_interval"`
+	Timestamp      string          `toml:"timestamp"`
 	NodeList       []OPCTag        `toml:"nodes"`
 
 	Nodes       []string     `toml:"-"`
@@ -50,6 +61,11 @@ type OpcUA struct {
 	client *opcua.Client
 	req    *ua.ReadRequest
 	opts   []opcua.Option
+
+	// subscription, the notifications update NodeData
+	mu        sync.Mutex
+	subCancel context.CancelFunc
+	subErr    error
 }
 
 // OPCTag type
@@ -70,6 +86,9 @@ type OPCData struct {
 	TimeStamp string
 	Time      string
 	DataType  ua.TypeID
+
+	ServerTime time.Time
+	SourceTime time.Time
 }
 
 // ConnectionState used for constants
@@ -124,6 +143,17 @@ const sampleConfig = `
   ## Password. Required for auth_method = "UserName"
   # password = ""
   #
+  ## How the values are collected, "read" reads the nodes on every interval,
+  ## "subscribe" monitors the nodes and reports their latest values
+  # mode = "read"
+  #
+  ## Publishing interval of the subscription, with mode = "subscribe"
+  # subscription_interval = "1s"
+  #
+  ## Timestamp of the metrics, one of "gather" (collection time), "server"
+  ## (server timestamp of the value) or "source" (source timestamp of the value)
+  # timestamp = "gather"
+  #
   ## Node ID configuration
   ## name       			- the variable name
   ## namespace  			- integer value 0 thru 3
@@ -198,6 +228,20 @@ func (o *OpcUA) validateEndpoint() error {
 	default:
 		return fmt.Errorf("invalid security type '%s' in '%s'", o.SecurityMode, o.Name)
 	}
+	switch o.Mode {
+	case "":
+		o.Mode = modeRead
+	case modeRead, modeSubscribe:
+	default:
+		return fmt.Errorf("invalid mode '%s' in '%s'", o.Mode, o.Name)
+	}
+	switch o.Timestamp {
+	case "":
+		o.Timestamp = timestampGather
+	case timestampGather, timestampServer, timestampSource:
+	default:
+		return fmt.Errorf("invalid timestamp '%s' in '%s'", o.Timestamp, o.Name)
+	}
 	return nil
 }
 
@@ -302,6 +346,12 @@ func Connect(o *OpcUA) error {
 			return fmt.Errorf("Get Data Failed: %w", err)
 		}
 
+		if o.Mode == modeSubscribe {
+			if err := o.subscribe(regResp.RegisteredNodeIDs); err != nil {
+				return fmt.Errorf("Subscribe failed: %w", err)
+			}
+		}
+
 	default:
 		return fmt.Errorf("unsupported scheme %q in endpoint. Expected opc.tcp", u.Scheme)
 	}
@@ -332,22 +382,95 @@ func (o *OpcUA) getData() error {
 		return fmt.Errorf("RegisterNodes Read failed: %w", err)
 	}
 	o.ReadSuccess++
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	for i, d := range resp.Results {
-		if d.Status != ua.StatusOK {
-			return fmt.Errorf("Status not OK: %v", d.Status)
-		}
-		o.NodeData[i].TagName = o.NodeList[i].Name
-		if d.Value != nil {
-			o.NodeData[i].Value = d.Value.Value()
-			o.NodeData[i].DataType = d.Value.Type()
+		o.updateNode(i, d)
+	}
+	return nil
+}
+
+// updateNode stores a value read or notified for the node, the value of a
+// node with a bad status is reported as missing
+func (o *OpcUA) updateNode(i int, d *ua.DataValue) {
+	if i < 0 || i >= len(o.NodeData) || d == nil {
+		return
+	}
+	o.NodeData[i].TagName = o.NodeList[i].Name
+	o.NodeData[i].Value = nil
+	if d.Value != nil && d.Status == ua.StatusOK {
+		o.NodeData[i].Value = d.Value.Value()
+		o.NodeData[i].DataType = d.Value.Type()
+	}
+	o.NodeData[i].Quality = d.Status
+	o.NodeData[i].TimeStamp = d.ServerTimestamp.String()
+	o.NodeData[i].Time = d.SourceTimestamp.String()
+	o.NodeData[i].ServerTime = d.ServerTimestamp
+	o.NodeData[i].SourceTime = d.SourceTimestamp
+}
+
+// subscribe monitors the value of the nodes, the client handle of a
+// monitored item is the index of its node
+func (o *OpcUA) subscribe(ids []*ua.NodeID) error {
+	notifs := make(chan *opcua.PublishNotificationData)
+	sub, err := o.client.Subscribe(&opcua.SubscriptionParameters{
+		Interval: time.Duration(o.SubInterval),
+	}, notifs)
+	if err != nil {
+		return fmt.Errorf("create subscription: %w", err)
+	}
+
+	items := make([]*ua.MonitoredItemCreateRequest, len(ids))
+	for i, id := range ids {
+		items[i] = opcua.NewMonitoredItemCreateRequestWithDefaults(id, ua.AttributeIDValue, uint32(i))
+	}
+	res, err := sub.Monitor(ua.TimestampsToReturnBoth, items...)
+	if err != nil {
+		_ = sub.Cancel()
+		return fmt.Errorf("monitor: %w", err)
+	}
+	for i, r := range res.Results {
+		if r.StatusCode != ua.StatusOK {
+			log.Printf("W! [inputs.opcua] monitoring %s failed: %v", o.NodeList[i].Name, r.StatusCode)
 		}
-		o.NodeData[i].Quality = d.Status
-		o.NodeData[i].TimeStamp = d.ServerTimestamp.String()
-		o.NodeData[i].Time = d.SourceTimestamp.String()
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.mu.Lock()
+	o.subCancel = cancel
+	o.subErr = nil
+	o.mu.Unlock()
+
+	go sub.Run(ctx)
+	go o.notifications(ctx, notifs)
+
 	return nil
 }
 
+// notifications updates the node data until the subscription is cancelled
+// or fails, a failure is returned by the next gather
+func (o *OpcUA) notifications(ctx context.Context, notifs chan *opcua.PublishNotificationData) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-notifs:
+			o.mu.Lock()
+			if n.Error != nil {
+				o.subErr = n.Error
+				o.mu.Unlock()
+				return
+			}
+			if dc, ok := n.Value.(*ua.DataChangeNotification); ok {
+				for _, item := range dc.MonitoredItems {
+					o.updateNode(int(item.ClientHandle), item.Value)
+				}
+			}
+			o.mu.Unlock()
+		}
+	}
+}
+
 func readvalues(ids []*ua.NodeID) []*ua.ReadValueID {
 	rvids := make([]*ua.ReadValueID, len(ids))
 	for i, v := range ids {
@@ -365,6 +488,13 @@ func disconnect(o *OpcUA) error {
 	o.ReadError = 0
 	o.ReadSuccess = 0
 
+	o.mu.Lock()
+	if o.subCancel != nil {
+		o.subCancel()
+		o.subCancel = nil
+	}
+	o.mu.Unlock()
+
 	switch u.Scheme {
 	case "opc.tcp":
 		o.state = Disconnected
@@ -388,25 +518,51 @@ func (o *OpcUA) Gather(ctx context.Context, acc cua.Accumulator) error {
 
 	o.state = Connected
 
-	err := o.getData()
+	var err error
+	if o.Mode == modeSubscribe {
+		o.mu.Lock()
+		err = o.subErr
+		o.mu.Unlock()
+	} else {
+		err = o.getData()
+	}
 	if err != nil && o.state == Connected {
 		o.state = Disconnected
 		_ = disconnect(o)
 		return err
 	}
 
+	o.addFields(acc, time.Now())
+	return nil
+}
+
+func (o *OpcUA) addFields(acc cua.Accumulator, now time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
 	for i, n := range o.NodeList {
+		d := o.NodeData[i]
 		fields := make(map[string]interface{})
 		tags := map[string]string{
 			"name": n.Name,
 			"id":   BuildNodeID(n),
 		}
 
-		fields[o.NodeData[i].TagName] = o.NodeData[i].Value
-		fields["Quality"] = strings.TrimSpace(fmt.Sprint(o.NodeData[i].Quality))
-		acc.AddFields(o.Name, fields, tags)
+		if d.Value != nil {
+			fields[n.Name] = d.Value
+		}
+		fields["Quality"] = strings.TrimSpace(fmt.Sprint(d.Quality))
+		fields["StatusCode"] = uint32(d.Quality)
+
+		ts := now
+		switch {
+		case o.Timestamp == timestampServer && !d.ServerTime.IsZero():
+			ts = d.ServerTime
+		case o.Timestamp == timestampSource && !d.SourceTime.IsZero():
+			ts = d.SourceTime
+		}
+		acc.AddFields(o.Name, fields, tags, ts)
 	}
-	return nil
 }
 
 // Add this plugin
@@ -419,6 +575,9 @@ func init() {
 			SecurityMode:   auto,
 			RequestTimeout: config.Duration(5 * time.Second),
 			ConnectTimeout: config.Duration(10 * time.Second),
+			Mode:           modeRead,
+			SubInterval:    config.Duration(time.Second),
+			Timestamp:      timestampGather,
 			Certificate:    "/etc/circonus-unified-agent/cert.pem",
 			PrivateKey:     "/etc/circonus-unified-agent/key.pem",
 			AuthMethod:     "Anonymous",
@@ -3,10 +3,13 @@ package opcuaclient
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/gopcua/opcua/ua"
 	"github.com/stretchr/testify/require"
 )
 
@@ -108,3 +111,78 @@ nodes = [
 	require.Equal(t, o.NodeList[0].Name, "name")
 	require.Equal(t, o.NodeList[1].Name, "name2")
 }
+
+func TestAddFields(t *testing.T) {
+	now := time.Unix(1602669600, 0)
+	server := now.Add(-time.Second)
+	source := now.Add(-2 * time.Second)
+
+	newOpcUA := func(timestamp string) *OpcUA {
+		o := &OpcUA{
+			Name:      "plc",
+			Timestamp: timestamp,
+			NodeList: []OPCTag{
+				{Name: "temperature", Namespace: "3", IdentifierType: "s", Identifier: "Temperature", DataType: "float"},
+				{Name: "pressure", Namespace: "3", IdentifierType: "s", Identifier: "Pressure", DataType: "float"},
+			},
+			NodeData: make([]OPCData, 2),
+		}
+		o.updateNode(0, &ua.DataValue{
+			Value:           ua.MustVariant(79.0),
+			Status:          ua.StatusOK,
+			ServerTimestamp: server,
+			SourceTimestamp: source,
+		})
+		o.updateNode(1, &ua.DataValue{
+			Value:  ua.MustVariant(1.5),
+			Status: ua.StatusBadSensorFailure,
+		})
+		// out of range client handle of a notification
+		o.updateNode(5, &ua.DataValue{Status: ua.StatusOK})
+		return o
+	}
+
+	var acc testutil.Accumulator
+	newOpcUA(timestampGather).addFields(&acc, now)
+	acc.AssertContainsTaggedFields(t, "plc",
+		map[string]interface{}{
+			"temperature": 79.0,
+			"Quality":     "OK (0x0)",
+			"StatusCode":  uint32(0),
+		},
+		map[string]string{"name": "temperature", "id": "ns=3;s=Temperature"})
+	// the value of a node with a bad status is left out
+	acc.AssertContainsTaggedFields(t, "plc",
+		map[string]interface{}{
+			"Quality":    strings.TrimSpace(fmt.Sprint(ua.StatusBadSensorFailure)),
+			"StatusCode": uint32(ua.StatusBadSensorFailure),
+		},
+		map[string]string{"name": "pressure", "id": "ns=3;s=Pressure"})
+	for _, m := range acc.Metrics {
+		require.Equal(t, now, m.Time)
+	}
+
+	acc.ClearMetrics()
+	newOpcUA(timestampSource).addFields(&acc, now)
+	require.Equal(t, source, acc.Metrics[0].Time)
+	// no source timestamp, the gather time is used
+	require.Equal(t, now, acc.Metrics[1].Time)
+
+	acc.ClearMetrics()
+	newOpcUA(timestampServer).addFields(&acc, now)
+	require.Equal(t, server, acc.Metrics[0].Time)
+}
+
+func TestValidateModeAndTimestamp(t *testing.T) {
+	o := &OpcUA{Name: "plc", Endpoint: "opc.tcp://localhost:4840", SecurityPolicy: "None", SecurityMode: "None"}
+	require.NoError(t, o.validateEndpoint())
+	require.Equal(t, modeRead, o.Mode)
+	require.Equal(t, timestampGather, o.Timestamp)
+
+	o.Mode = "poll"
+	require.Error(t, o.validateEndpoint())
+
+	o.Mode = modeSubscribe
+	o.Timestamp = "device"
+	require.Error(t, o.validateEndpoint())
+}
@@ -0,0 +1,144 @@
+package openldap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"gopkg.in/ldap.v3"
+)
+
+var rootDSEAttrs = []string{
+	"defaultNamingContext",
+	"highestCommittedUSN",
+	"isSynchronized",
+	"isGlobalCatalogReady",
+}
+
+// replNeighbor is the XML form of a DS_REPL_NEIGHBOR, the values of the
+// msDS-ReplAllInboundNeighbors attribute
+type replNeighbor struct {
+	NamingContext             string `xml:"pszNamingContext"`
+	SourceDsaDN               string `xml:"pszSourceDsaDN"`
+	UsnLastObjChangeSynced    int64  `xml:"usnLastObjChangeSynced"`
+	LastSyncSuccess           string `xml:"ftimeLastSyncSuccess"`
+	LastSyncResult            int64  `xml:"dwLastSyncResult"`
+	NumConsecutiveSyncFailure int64  `xml:"cNumConsecutiveSyncFailures"`
+}
+
+// gatherActiveDirectory reads the state of a domain controller from its
+// root DSE and the inbound replication neighbors of its default naming
+// context, Active Directory has no cn=Monitor backend
+func (o *Openldap) gatherActiveDirectory(l *ldap.Conn, bindTime time.Duration, acc cua.Accumulator) {
+	sr, err := l.Search(ldap.NewSearchRequest(
+		"",
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		"(objectClass=*)",
+		rootDSEAttrs,
+		nil,
+	))
+	if err != nil {
+		acc.AddError(fmt.Errorf("search root DSE: %w", err))
+		return
+	}
+	if len(sr.Entries) == 0 {
+		acc.AddError(fmt.Errorf("no root DSE"))
+		return
+	}
+	rootDSE := sr.Entries[0]
+	gatherRootDSE(rootDSE, o, bindTime, acc)
+
+	namingContext := rootDSE.GetAttributeValue("defaultNamingContext")
+	if namingContext == "" {
+		return
+	}
+	sr, err = l.Search(ldap.NewSearchRequest(
+		namingContext,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		"(objectClass=*)",
+		[]string{"msDS-ReplAllInboundNeighbors"},
+		nil,
+	))
+	if err != nil {
+		acc.AddError(fmt.Errorf("search replication neighbors of %s: %w", namingContext, err))
+		return
+	}
+	if len(sr.Entries) == 0 {
+		return
+	}
+	gatherReplNeighbors(sr.Entries[0].GetAttributeValues("msDS-ReplAllInboundNeighbors"), o, time.Now(), acc)
+}
+
+func gatherRootDSE(entry *ldap.Entry, o *Openldap, bindTime time.Duration, acc cua.Accumulator) {
+	fields := map[string]interface{}{}
+	if bindTime > 0 {
+		fields["bind_time"] = bindTime.Seconds()
+	}
+	if v, err := strconv.ParseInt(entry.GetAttributeValue("highestCommittedUSN"), 10, 64); err == nil {
+		fields["highest_committed_usn"] = v
+	}
+	for attr, field := range map[string]string{
+		"isSynchronized":       "is_synchronized",
+		"isGlobalCatalogReady": "is_global_catalog_ready",
+	} {
+		switch strings.ToUpper(entry.GetAttributeValue(attr)) {
+		case "TRUE":
+			fields[field] = 1
+		case "FALSE":
+			fields[field] = 0
+		}
+	}
+	acc.AddFields("active_directory", fields, o.tags())
+}
+
+// gatherReplNeighbors adds the age of the last successful replication and
+// the failures per naming context and source domain controller
+func gatherReplNeighbors(values []string, o *Openldap, now time.Time, acc cua.Accumulator) {
+	for _, value := range values {
+		var n replNeighbor
+		if err := xml.Unmarshal([]byte(value), &n); err != nil {
+			acc.AddError(fmt.Errorf("parse replication neighbor: %w", err))
+			continue
+		}
+
+		tags := o.tags()
+		tags["naming_context"] = n.NamingContext
+		tags["source"] = sourceDSA(n.SourceDsaDN)
+
+		fields := map[string]interface{}{
+			"usn_last_obj_change_synced": n.UsnLastObjChangeSynced,
+			"last_sync_result":           n.LastSyncResult,
+			"consecutive_sync_failures":  n.NumConsecutiveSyncFailure,
+		}
+		// a neighbor that never replicated has a zero time
+		if t, err := time.Parse(time.RFC3339, n.LastSyncSuccess); err == nil && t.Year() > 1601 {
+			lag := now.Sub(t)
+			if lag < 0 {
+				lag = 0
+			}
+			fields["lag"] = lag.Seconds()
+		}
+		acc.AddFields("active_directory_replication", fields, tags)
+	}
+}
+
+// sourceDSA returns the name of the domain controller from the dn of its
+// NTDS settings, CN=NTDS Settings,CN=DC2,CN=Servers,...
+func sourceDSA(dn string) string {
+	parts := strings.Split(dn, ",")
+	if len(parts) > 1 && strings.EqualFold(parts[0], "CN=NTDS Settings") {
+		return strings.TrimPrefix(strings.TrimPrefix(parts[1], "CN="), "cn=")
+	}
+	return dn
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
@@ -13,18 +14,26 @@ import (
 )
 
 type Openldap struct {
-	Host               string
-	Port               int
-	SSL                string `toml:"ssl"` // Deprecated in 1.7; use TLS
-	TLS                string `toml:"tls"`
-	InsecureSkipVerify bool
-	SSLCA              string `toml:"ssl_ca"` // Deprecated in 1.7; use TLSCA
-	TLSCA              string `toml:"tls_ca"`
-	BindDn             string
-	BindPassword       string
-	ReverseMetricNames bool
+	Host                string
+	Port                int
+	SSL                 string `toml:"ssl"` // Deprecated in 1.7; use TLS
+	TLS                 string `toml:"tls"`
+	InsecureSkipVerify  bool
+	SSLCA               string `toml:"ssl_ca"` // Deprecated in 1.7; use TLSCA
+	TLSCA               string `toml:"tls_ca"`
+	BindDn              string
+	BindPassword        string
+	ReverseMetricNames  bool
+	ServerType          string `toml:"server_type"`
+	ReplicationBaseDN   string `toml:"replication_base_dn"`
+	ReplicationProvider string `toml:"replication_provider"`
 }
 
+const (
+	serverTypeOpenldap        = "openldap"
+	serverTypeActiveDirectory = "active_directory"
+)
+
 const sampleConfig string = `
   host = "localhost"
   port = 389
@@ -47,6 +56,17 @@ const sampleConfig string = `
   # Reverse metric names so they sort more naturally. Recommended.
   # This defaults to false if unset, but is set to true when generating a new config
   reverse_metric_names = true
+
+  # Type of the server, "openldap" reads the cn=Monitor backend and
+  # "active_directory" the root DSE and the inbound replication neighbors of
+  # the default naming context.
+  # server_type = "openldap"
+
+  # Syncrepl consumers only: the replication lag is measured by comparing
+  # the contextCSN of the base dn with the one of the provider, which is
+  # connected to with the same tls and bind settings.
+  # replication_base_dn = "dc=example,dc=com"
+  # replication_provider = "provider.example.com:389"
 `
 
 var searchBase = "cn=Monitor"
@@ -98,8 +118,55 @@ func (o *Openldap) Gather(ctx context.Context, acc cua.Accumulator) error {
 		o.TLSCA = o.SSLCA
 	}
 
-	var err error
+	l, bindTime, err := o.connect(fmt.Sprintf("%s:%d", o.Host, o.Port))
+	if err != nil {
+		acc.AddError(err)
+		return nil
+	}
+	defer l.Close()
+
+	switch o.ServerType {
+	case "", serverTypeOpenldap:
+	case serverTypeActiveDirectory:
+		o.gatherActiveDirectory(l, bindTime, acc)
+		return nil
+	default:
+		acc.AddError(fmt.Errorf("invalid server_type %q", o.ServerType))
+		return nil
+	}
+
+	searchRequest := ldap.NewSearchRequest(
+		searchBase,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		searchFilter,
+		searchAttrs,
+		nil,
+	)
+
+	sr, err := l.Search(searchRequest)
+	if err != nil {
+		acc.AddError(err)
+		return nil
+	}
+
+	gatherSearchResult(sr, o, bindTime, acc)
+
+	if o.ReplicationProvider != "" {
+		o.gatherReplication(l, acc)
+	}
+
+	return nil
+}
+
+// connect opens a connection to the server and binds with the credentials
+// when set, the time taken by the bind is returned along with the connection
+func (o *Openldap) connect(address string) (*ldap.Conn, time.Duration, error) {
 	var l *ldap.Conn
+	var err error
 	if o.TLS != "" {
 		// build tls config
 		clientTLSConfig := tls.ClientConfig{
@@ -108,79 +175,55 @@ func (o *Openldap) Gather(ctx context.Context, acc cua.Accumulator) error {
 		}
 		tlsConfig, err := clientTLSConfig.TLSConfig()
 		if err != nil {
-			acc.AddError(err)
-			return nil
+			return nil, 0, fmt.Errorf("TLSConfig: %w", err)
 		}
 		switch o.TLS {
 		case "ldaps":
-			l, err = ldap.DialTLS("tcp", fmt.Sprintf("%s:%d", o.Host, o.Port), tlsConfig)
+			l, err = ldap.DialTLS("tcp", address, tlsConfig)
 			if err != nil {
-				acc.AddError(err)
-				return nil
+				return nil, 0, fmt.Errorf("dial %s: %w", address, err)
 			}
 		case "starttls":
-			l, err = ldap.Dial("tcp", fmt.Sprintf("%s:%d", o.Host, o.Port))
+			l, err = ldap.Dial("tcp", address)
 			if err != nil {
-				acc.AddError(err)
-				return nil
+				return nil, 0, fmt.Errorf("dial %s: %w", address, err)
 			}
 			err = l.StartTLS(tlsConfig)
 			if err != nil {
-				acc.AddError(err)
-				return nil
+				l.Close()
+				return nil, 0, fmt.Errorf("starttls %s: %w", address, err)
 			}
 		default:
-			acc.AddError(fmt.Errorf("Invalid setting for ssl: %s", o.TLS))
-			return nil
+			return nil, 0, fmt.Errorf("Invalid setting for ssl: %s", o.TLS)
 		}
 	} else {
-		l, err = ldap.Dial("tcp", fmt.Sprintf("%s:%d", o.Host, o.Port))
-	}
-
-	if err != nil {
-		acc.AddError(err)
-		return nil
+		l, err = ldap.Dial("tcp", address)
+		if err != nil {
+			return nil, 0, fmt.Errorf("dial %s: %w", address, err)
+		}
 	}
-	defer l.Close()
 
 	// username/password bind
+	var bindTime time.Duration
 	if o.BindDn != "" && o.BindPassword != "" {
+		start := time.Now()
 		err = l.Bind(o.BindDn, o.BindPassword)
 		if err != nil {
-			acc.AddError(err)
-			return nil
+			l.Close()
+			return nil, 0, fmt.Errorf("bind %s: %w", address, err)
 		}
+		bindTime = time.Since(start)
 	}
 
-	searchRequest := ldap.NewSearchRequest(
-		searchBase,
-		ldap.ScopeWholeSubtree,
-		ldap.NeverDerefAliases,
-		0,
-		0,
-		false,
-		searchFilter,
-		searchAttrs,
-		nil,
-	)
-
-	sr, err := l.Search(searchRequest)
-	if err != nil {
-		acc.AddError(err)
-		return nil
-	}
-
-	gatherSearchResult(sr, o, acc)
-
-	return nil
+	return l, bindTime, nil
 }
 
-func gatherSearchResult(sr *ldap.SearchResult, o *Openldap, acc cua.Accumulator) {
+func gatherSearchResult(sr *ldap.SearchResult, o *Openldap, bindTime time.Duration, acc cua.Accumulator) {
 	fields := map[string]interface{}{}
-	tags := map[string]string{
-		"server": o.Host,
-		"port":   strconv.Itoa(o.Port),
+	if bindTime > 0 {
+		fields["bind_time"] = bindTime.Seconds()
 	}
+	tags := o.tags()
 	for _, entry := range sr.Entries {
 		metricName := dnToMetric(entry.DN, o)
 		for _, attr := range entry.Attributes {
@@ -194,6 +237,13 @@ func gatherSearchResult(sr *ldap.SearchResult, o *Openldap, acc cua.Accumulator)
 	acc.AddFields("openldap", fields, tags)
 }
 
+func (o *Openldap) tags() map[string]string {
+	return map[string]string{
+		"server": o.Host,
+		"port":   strconv.Itoa(o.Port),
+	}
+}
+
 // Convert a DN to metric name, eg cn=Read,cn=Waiters,cn=Monitor becomes waiters_read
 // Assumes the last part of the DN is cn=Monitor and we want to drop it
 func dnToMetric(dn string, o *Openldap) string {
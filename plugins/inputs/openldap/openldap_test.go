@@ -4,7 +4,9 @@ import (
 	"context"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,7 +32,7 @@ func TestOpenldapMockResult(t *testing.T) {
 		Port: 389,
 	}
 
-	gatherSearchResult(&mockSearchResult, o, &acc)
+	gatherSearchResult(&mockSearchResult, o, 0, &acc)
 	commonTests(t, o, &acc)
 }
 
@@ -170,3 +172,97 @@ func TestOpenldapReverseMetrics(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, acc.HasInt64Field("openldap", "connections_total"), "Has an integer field called connections_total")
 }
+
+func TestOpenldapBindTime(t *testing.T) {
+	var acc testutil.Accumulator
+	o := &Openldap{Host: "localhost", Port: 389}
+
+	gatherSearchResult(&ldap.SearchResult{}, o, 1500*time.Millisecond, &acc)
+	f, ok := acc.FloatField("openldap", "bind_time")
+	require.True(t, ok)
+	assert.Equal(t, 1.5, f)
+}
+
+func TestOpenldapReplicationLag(t *testing.T) {
+	var acc testutil.Accumulator
+	o := &Openldap{Host: "consumer", Port: 389, ReplicationProvider: "provider:389"}
+
+	consumer := []string{
+		"20200913122630.000000Z#000000#001#000000",
+		"20200913122640.000000Z#000000#002#000000",
+	}
+	provider := []string{
+		"20200913122640.500000Z#000000#001#000000",
+		"20200913122640.000000Z#000000#002#000000",
+		"20200913122000.000000Z#000000#003#000000",
+	}
+	gatherReplicationLag(consumer, provider, o, &acc)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("openldap_replication",
+			map[string]string{"server": "consumer", "port": "389", "provider": "provider:389", "sid": "001"},
+			map[string]interface{}{"lag": 10.5, "in_sync": 0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("openldap_replication",
+			map[string]string{"server": "consumer", "port": "389", "provider": "provider:389", "sid": "002"},
+			map[string]interface{}{"lag": 0.0, "in_sync": 1},
+			time.Unix(0, 0)),
+		testutil.MustMetric("openldap_replication",
+			map[string]string{"server": "consumer", "port": "389", "provider": "provider:389", "sid": "003"},
+			map[string]interface{}{"in_sync": 0},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+}
+
+func TestActiveDirectory(t *testing.T) {
+	var acc testutil.Accumulator
+	o := &Openldap{Host: "dc1", Port: 389, ServerType: serverTypeActiveDirectory}
+
+	rootDSE := ldap.NewEntry("", map[string][]string{
+		"defaultNamingContext": {"DC=corp,DC=example,DC=com"},
+		"highestCommittedUSN":  {"123456"},
+		"isSynchronized":       {"TRUE"},
+		"isGlobalCatalogReady": {"FALSE"},
+	})
+	gatherRootDSE(rootDSE, o, 0, &acc)
+
+	neighbors := []string{
+		`<DS_REPL_NEIGHBOR>
+	<pszNamingContext>DC=corp,DC=example,DC=com</pszNamingContext>
+	<pszSourceDsaDN>CN=NTDS Settings,CN=DC2,CN=Servers,CN=Default-First-Site-Name,CN=Sites,CN=Configuration,DC=corp,DC=example,DC=com</pszSourceDsaDN>
+	<dwReplicaFlags>1879113744</dwReplicaFlags>
+	<usnLastObjChangeSynced>98765</usnLastObjChangeSynced>
+	<ftimeLastSyncSuccess>2020-09-13T12:25:40Z</ftimeLastSyncSuccess>
+	<ftimeLastSyncAttempt>2020-09-13T12:25:40Z</ftimeLastSyncAttempt>
+	<dwLastSyncResult>0</dwLastSyncResult>
+	<cNumConsecutiveSyncFailures>0</cNumConsecutiveSyncFailures>
+</DS_REPL_NEIGHBOR>`,
+		`<DS_REPL_NEIGHBOR>
+	<pszNamingContext>DC=corp,DC=example,DC=com</pszNamingContext>
+	<pszSourceDsaDN>CN=NTDS Settings,CN=DC3,CN=Servers,CN=Default-First-Site-Name,CN=Sites,CN=Configuration,DC=corp,DC=example,DC=com</pszSourceDsaDN>
+	<usnLastObjChangeSynced>0</usnLastObjChangeSynced>
+	<ftimeLastSyncSuccess>1601-01-01T00:00:00Z</ftimeLastSyncSuccess>
+	<dwLastSyncResult>8453</dwLastSyncResult>
+	<cNumConsecutiveSyncFailures>12</cNumConsecutiveSyncFailures>
+</DS_REPL_NEIGHBOR>`,
+	}
+	gatherReplNeighbors(neighbors, o, time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC), &acc)
+
+	nc := "DC=corp,DC=example,DC=com"
+	expected := []cua.Metric{
+		testutil.MustMetric("active_directory",
+			map[string]string{"server": "dc1", "port": "389"},
+			map[string]interface{}{"highest_committed_usn": int64(123456), "is_synchronized": 1, "is_global_catalog_ready": 0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("active_directory_replication",
+			map[string]string{"server": "dc1", "port": "389", "naming_context": nc, "source": "DC2"},
+			map[string]interface{}{"usn_last_obj_change_synced": int64(98765), "last_sync_result": int64(0), "consecutive_sync_failures": int64(0), "lag": 60.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("active_directory_replication",
+			map[string]string{"server": "dc1", "port": "389", "naming_context": nc, "source": "DC3"},
+			map[string]interface{}{"usn_last_obj_change_synced": int64(0), "last_sync_result": int64(8453), "consecutive_sync_failures": int64(12)},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.SortMetrics(), testutil.IgnoreTime())
+}
@@ -0,0 +1,114 @@
+package openldap
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"gopkg.in/ldap.v3"
+)
+
+// csnTimeFormat is the time part of a change sequence number, e.g.
+// 20200913122640.123456Z#000000#001#000000
+const csnTimeFormat = "20060102150405.999999Z"
+
+// gatherReplication compares the contextCSN of the replicated base dn on
+// the consumer with the one on the provider, there is one CSN per server id
+// of the providers
+func (o *Openldap) gatherReplication(l *ldap.Conn, acc cua.Accumulator) {
+	if o.ReplicationBaseDN == "" {
+		acc.AddError(fmt.Errorf("replication_base_dn is required with replication_provider"))
+		return
+	}
+
+	consumer, err := contextCSN(l, o.ReplicationBaseDN)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	pl, _, err := o.connect(o.ReplicationProvider)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	defer pl.Close()
+
+	provider, err := contextCSN(pl, o.ReplicationBaseDN)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	gatherReplicationLag(consumer, provider, o, acc)
+}
+
+func contextCSN(l *ldap.Conn, baseDN string) ([]string, error) {
+	sr, err := l.Search(ldap.NewSearchRequest(
+		baseDN,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		"(objectClass=*)",
+		[]string{"contextCSN"},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("search contextCSN of %s: %w", baseDN, err)
+	}
+	if len(sr.Entries) == 0 {
+		return nil, fmt.Errorf("no entry %s", baseDN)
+	}
+	return sr.Entries[0].GetAttributeValues("contextCSN"), nil
+}
+
+// gatherReplicationLag adds the lag of the consumer per server id, the time
+// between the last change of the provider and the last change replicated
+func gatherReplicationLag(consumer, provider []string, o *Openldap, acc cua.Accumulator) {
+	consumerTimes := csnTimes(consumer)
+	for sid, providerTime := range csnTimes(provider) {
+		tags := o.tags()
+		tags["provider"] = o.ReplicationProvider
+		tags["sid"] = sid
+
+		fields := map[string]interface{}{}
+		consumerTime, ok := consumerTimes[sid]
+		if !ok {
+			// nothing replicated yet from this server id
+			fields["in_sync"] = 0
+			acc.AddFields("openldap_replication", fields, tags)
+			continue
+		}
+
+		lag := providerTime.Sub(consumerTime)
+		if lag < 0 {
+			lag = 0
+		}
+		fields["lag"] = lag.Seconds()
+		fields["in_sync"] = 0
+		if lag == 0 {
+			fields["in_sync"] = 1
+		}
+		acc.AddFields("openldap_replication", fields, tags)
+	}
+}
+
+// csnTimes returns the time of the CSNs by server id
+func csnTimes(csns []string) map[string]time.Time {
+	times := make(map[string]time.Time)
+	for _, csn := range csns {
+		parts := strings.Split(csn, "#")
+		if len(parts) != 4 {
+			continue
+		}
+		t, err := time.Parse(csnTimeFormat, parts[0])
+		if err != nil {
+			continue
+		}
+		times[parts[2]] = t
+	}
+	return times
+}
@@ -0,0 +1,221 @@
+package openstack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errUnauthorized = errors.New("unauthorized")
+
+// endpoint of a service of the Keystone catalog
+type endpoint struct {
+	Interface string `json:"interface"`
+	Region    string `json:"region"`
+	RegionID  string `json:"region_id"`
+	URL       string `json:"url"`
+}
+
+type catalogEntry struct {
+	Type      string     `json:"type"`
+	Endpoints []endpoint `json:"endpoints"`
+}
+
+// client keeps a project scoped Keystone token and the service endpoints of
+// its catalog
+type client struct {
+	http     *http.Client
+	authURL  string
+	domain   string
+	project  string
+	username string
+	password string
+	region   string
+	iface    string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	catalog []catalogEntry
+}
+
+// authenticate requests a new token when there is none or it is about to
+// expire
+func (c *client) authenticate(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Until(c.expires) > time.Minute {
+		return nil
+	}
+
+	domain := map[string]string{"name": c.domain}
+	var req struct {
+		Auth struct {
+			Identity struct {
+				Methods  []string `json:"methods"`
+				Password struct {
+					User struct {
+						Name     string            `json:"name"`
+						Domain   map[string]string `json:"domain"`
+						Password string            `json:"password"`
+					} `json:"user"`
+				} `json:"password"`
+			} `json:"identity"`
+			Scope struct {
+				Project struct {
+					Name   string            `json:"name"`
+					Domain map[string]string `json:"domain"`
+				} `json:"project"`
+			} `json:"scope"`
+		} `json:"auth"`
+	}
+	req.Auth.Identity.Methods = []string{"password"}
+	req.Auth.Identity.Password.User.Name = c.username
+	req.Auth.Identity.Password.User.Domain = domain
+	req.Auth.Identity.Password.User.Password = c.password
+	req.Auth.Scope.Project.Name = c.project
+	req.Auth.Scope.Project.Domain = domain
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal auth request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.authURL, "/")+"/auth/tokens", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("auth request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("auth request: HTTP status %s", resp.Status)
+	}
+
+	var token struct {
+		Token struct {
+			ExpiresAt time.Time      `json:"expires_at"`
+			Catalog   []catalogEntry `json:"catalog"`
+		} `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("decode auth response: %w", err)
+	}
+	c.token = resp.Header.Get("X-Subject-Token")
+	if c.token == "" {
+		return errors.New("auth response without a token")
+	}
+	c.expires = token.Token.ExpiresAt
+	c.catalog = token.Token.Catalog
+	return nil
+}
+
+// invalidate drops the token, e.g. after it was revoked
+func (c *client) invalidate() {
+	c.mu.Lock()
+	c.token = ""
+	c.mu.Unlock()
+}
+
+// endpoint returns the URL of the first of the service types in the
+// catalog, for the configured interface and region
+func (c *client) endpoint(types ...string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range types {
+		for _, entry := range c.catalog {
+			if entry.Type != t {
+				continue
+			}
+			for _, ep := range entry.Endpoints {
+				if ep.Interface != c.iface {
+					continue
+				}
+				if c.region != "" && ep.RegionID != c.region && ep.Region != c.region {
+					continue
+				}
+				return strings.TrimRight(ep.URL, "/"), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no %s endpoint of service %s in region %q", c.iface, strings.Join(types, " or "), c.region)
+}
+
+// get decodes the JSON response of a GET request
+func (c *client) get(ctx context.Context, u string, v interface{}) error {
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Auth-Token", token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return errUnauthorized
+	case resp.StatusCode != http.StatusOK:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s: HTTP status %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode %s: %w", u, err)
+	}
+	return nil
+}
+
+// list returns the items of a collection, following the next links of the
+// pages, the items are in the key of the response and the links in
+// <key>_links
+func (c *client) list(ctx context.Context, u, key string) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	for u != "" {
+		var page map[string]json.RawMessage
+		if err := c.get(ctx, u, &page); err != nil {
+			return nil, err
+		}
+		var pageItems []json.RawMessage
+		if raw, ok := page[key]; ok {
+			if err := json.Unmarshal(raw, &pageItems); err != nil {
+				return nil, fmt.Errorf("decode %s: %w", key, err)
+			}
+		}
+		items = append(items, pageItems...)
+
+		var links []struct {
+			Href string `json:"href"`
+			Rel  string `json:"rel"`
+		}
+		u = ""
+		if raw, ok := page[key+"_links"]; ok {
+			if err := json.Unmarshal(raw, &links); err != nil {
+				return nil, fmt.Errorf("decode %s_links: %w", key, err)
+			}
+		}
+		for _, l := range links {
+			// an empty page links to itself
+			if l.Rel == "next" && len(pageItems) > 0 {
+				u = l.Href
+			}
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,428 @@
+// Package openstack implements a plugin for collecting hypervisor capacity
+// and per project resource usage from the OpenStack APIs.
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/internal/choice"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+var defaultServices = []string{"hypervisors", "servers", "volumes", "floating_ips"}
+
+// OpenStack reads the state of an OpenStack cloud with the Nova, Neutron and
+// Cinder APIs
+type OpenStack struct {
+	AuthenticationEndpoint string            `toml:"authentication_endpoint"`
+	Domain                 string            `toml:"domain"`
+	Project                string            `toml:"project"`
+	Username               string            `toml:"username"`
+	Password               string            `toml:"password"`
+	Region                 string            `toml:"region"`
+	Interface              string            `toml:"interface"`
+	EnabledServices        []string          `toml:"enabled_services"`
+	Quotas                 bool              `toml:"quotas"`
+	Timeout                internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	client *client
+}
+
+var sampleConfig = `
+  ## Keystone v3 endpoint and credentials of a user with the admin role, the
+  ## servers, volumes and floating IPs of all the projects are read
+  authentication_endpoint = "https://keystone.example.com:5000/v3"
+  # domain = "default"
+  # project = "admin"
+  username = "admin"
+  password = "secret"
+
+  ## Region of the endpoints to use from the service catalog, the first
+  ## endpoint of each service when empty
+  # region = "RegionOne"
+
+  ## Interface of the endpoints, public, internal or admin
+  # interface = "public"
+
+  ## What to gather:
+  ##   hypervisors   vCPU, memory and disk capacity and use of each hypervisor
+  ##   servers       instances by project and status
+  ##   volumes       volumes and their size by project
+  ##   floating_ips  allocated and associated floating IPs by project
+  # enabled_services = ["hypervisors", "servers", "volumes", "floating_ips"]
+
+  ## Read the volume and floating IP quotas of each project for their
+  ## utilization, one request per project and service
+  # quotas = false
+
+  ## Timeout of each API request
+  # timeout = "10s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (*OpenStack) SampleConfig() string {
+	return sampleConfig
+}
+
+func (*OpenStack) Description() string {
+	return "Read hypervisor capacity and per project instances, volumes and floating IPs from OpenStack"
+}
+
+func (o *OpenStack) Init() error {
+	if o.AuthenticationEndpoint == "" {
+		return errors.New("authentication_endpoint is required")
+	}
+	if err := choice.CheckSlice(o.EnabledServices, defaultServices); err != nil {
+		return fmt.Errorf("enabled_services: %w", err)
+	}
+
+	tlsCfg, err := o.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	o.client = &client{
+		http: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsCfg},
+			Timeout:   o.Timeout.Duration,
+		},
+		authURL:  o.AuthenticationEndpoint,
+		domain:   o.Domain,
+		project:  o.Project,
+		username: o.Username,
+		password: o.Password,
+		region:   o.Region,
+		iface:    o.Interface,
+	}
+	return nil
+}
+
+// project is the resources of one project
+type project struct {
+	servers             map[string]int
+	volumes             int
+	volumesInUse        int
+	volumesError        int
+	volumeSizeGB        int64
+	floatingIPs         int
+	floatingIPsAssigned int
+}
+
+func (o *OpenStack) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if err := o.client.authenticate(ctx); err != nil {
+		return err
+	}
+
+	names := o.projectNames(ctx)
+	projects := make(map[string]*project)
+	getProject := func(id string) *project {
+		p, ok := projects[id]
+		if !ok {
+			p = &project{servers: make(map[string]int)}
+			projects[id] = p
+		}
+		return p
+	}
+
+	for _, service := range o.EnabledServices {
+		var err error
+		switch service {
+		case "hypervisors":
+			err = o.gatherHypervisors(ctx, acc)
+		case "servers":
+			err = o.gatherServers(ctx, getProject)
+		case "volumes":
+			err = o.gatherVolumes(ctx, getProject)
+		case "floating_ips":
+			err = o.gatherFloatingIPs(ctx, getProject)
+		}
+		if errors.Is(err, errUnauthorized) {
+			// the token was revoked, authenticate again with the next gather
+			o.client.invalidate()
+			return fmt.Errorf("%s: %w", service, err)
+		}
+		if err != nil {
+			acc.AddError(fmt.Errorf("%s: %w", service, err))
+		}
+	}
+
+	for id, p := range projects {
+		tags := map[string]string{
+			"project_id": id,
+			"project":    id,
+		}
+		if name, ok := names[id]; ok {
+			tags["project"] = name
+		}
+		o.addProject(ctx, id, p, tags, acc)
+	}
+	return nil
+}
+
+func (o *OpenStack) addProject(ctx context.Context, id string, p *project, tags map[string]string, acc cua.Accumulator) {
+	for status, count := range p.servers {
+		serverTags := map[string]string{"status": strings.ToLower(status)}
+		for k, v := range tags {
+			serverTags[k] = v
+		}
+		acc.AddFields("openstack_servers", map[string]interface{}{"servers": count}, serverTags)
+	}
+
+	if o.enabled("volumes") {
+		fields := map[string]interface{}{
+			"volumes":        p.volumes,
+			"volumes_in_use": p.volumesInUse,
+			"volumes_error":  p.volumesError,
+			"size_gb":        p.volumeSizeGB,
+		}
+		if o.Quotas {
+			if err := o.volumeQuotas(ctx, id, p, fields); err != nil {
+				acc.AddError(fmt.Errorf("volume quotas of %s: %w", id, err))
+			}
+		}
+		acc.AddFields("openstack_volumes", fields, tags)
+	}
+
+	if o.enabled("floating_ips") {
+		fields := map[string]interface{}{
+			"floating_ips":            p.floatingIPs,
+			"floating_ips_associated": p.floatingIPsAssigned,
+		}
+		if o.Quotas {
+			if err := o.floatingIPQuota(ctx, id, p, fields); err != nil {
+				acc.AddError(fmt.Errorf("floating IP quota of %s: %w", id, err))
+			}
+		}
+		acc.AddFields("openstack_floating_ips", fields, tags)
+	}
+}
+
+// projectNames returns the project names by id, without the names the
+// projects are tagged by id
+func (o *OpenStack) projectNames(ctx context.Context) map[string]string {
+	names := make(map[string]string)
+	items, err := o.client.list(ctx, strings.TrimRight(o.AuthenticationEndpoint, "/")+"/projects", "projects")
+	if err != nil {
+		o.Log.Debugf("list projects: %v", err)
+		return names
+	}
+	for _, item := range items {
+		var p struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(item, &p); err == nil {
+			names[p.ID] = p.Name
+		}
+	}
+	return names
+}
+
+func (o *OpenStack) gatherHypervisors(ctx context.Context, acc cua.Accumulator) error {
+	compute, err := o.client.endpoint("compute")
+	if err != nil {
+		return err
+	}
+	items, err := o.client.list(ctx, compute+"/os-hypervisors/detail", "hypervisors")
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var h struct {
+			Hostname     string `json:"hypervisor_hostname"`
+			Type         string `json:"hypervisor_type"`
+			State        string `json:"state"`
+			Status       string `json:"status"`
+			VCPUs        int64  `json:"vcpus"`
+			VCPUsUsed    int64  `json:"vcpus_used"`
+			MemoryMB     int64  `json:"memory_mb"`
+			MemoryMBUsed int64  `json:"memory_mb_used"`
+			LocalGB      int64  `json:"local_gb"`
+			LocalGBUsed  int64  `json:"local_gb_used"`
+			RunningVMs   int64  `json:"running_vms"`
+		}
+		if err := json.Unmarshal(item, &h); err != nil {
+			return fmt.Errorf("decode hypervisor: %w", err)
+		}
+		acc.AddFields("openstack_hypervisor", map[string]interface{}{
+			"vcpus":          h.VCPUs,
+			"vcpus_used":     h.VCPUsUsed,
+			"memory_mb":      h.MemoryMB,
+			"memory_mb_used": h.MemoryMBUsed,
+			"local_gb":       h.LocalGB,
+			"local_gb_used":  h.LocalGBUsed,
+			"running_vms":    h.RunningVMs,
+		}, map[string]string{
+			"hypervisor":      h.Hostname,
+			"hypervisor_type": h.Type,
+			"state":           h.State,
+			"status":          h.Status,
+		})
+	}
+	return nil
+}
+
+func (o *OpenStack) gatherServers(ctx context.Context, getProject func(string) *project) error {
+	compute, err := o.client.endpoint("compute")
+	if err != nil {
+		return err
+	}
+	items, err := o.client.list(ctx, compute+"/servers/detail?all_tenants=1", "servers")
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var s struct {
+			TenantID string `json:"tenant_id"`
+			Status   string `json:"status"`
+		}
+		if err := json.Unmarshal(item, &s); err != nil {
+			return fmt.Errorf("decode server: %w", err)
+		}
+		getProject(s.TenantID).servers[s.Status]++
+	}
+	return nil
+}
+
+func (o *OpenStack) volumeEndpoint() (string, error) {
+	return o.client.endpoint("volumev3", "block-storage", "volumev2")
+}
+
+func (o *OpenStack) gatherVolumes(ctx context.Context, getProject func(string) *project) error {
+	volume, err := o.volumeEndpoint()
+	if err != nil {
+		return err
+	}
+	items, err := o.client.list(ctx, volume+"/volumes/detail?all_tenants=1", "volumes")
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var v struct {
+			TenantID string `json:"os-vol-tenant-attr:tenant_id"`
+			Status   string `json:"status"`
+			Size     int64  `json:"size"`
+		}
+		if err := json.Unmarshal(item, &v); err != nil {
+			return fmt.Errorf("decode volume: %w", err)
+		}
+		p := getProject(v.TenantID)
+		p.volumes++
+		p.volumeSizeGB += v.Size
+		switch {
+		case v.Status == "in-use":
+			p.volumesInUse++
+		case strings.HasPrefix(v.Status, "error"):
+			p.volumesError++
+		}
+	}
+	return nil
+}
+
+func (o *OpenStack) gatherFloatingIPs(ctx context.Context, getProject func(string) *project) error {
+	network, err := o.client.endpoint("network")
+	if err != nil {
+		return err
+	}
+	items, err := o.client.list(ctx, network+"/v2.0/floatingips", "floatingips")
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var f struct {
+			ProjectID string  `json:"project_id"`
+			TenantID  string  `json:"tenant_id"`
+			PortID    *string `json:"port_id"`
+		}
+		if err := json.Unmarshal(item, &f); err != nil {
+			return fmt.Errorf("decode floating IP: %w", err)
+		}
+		id := f.ProjectID
+		if id == "" {
+			id = f.TenantID
+		}
+		p := getProject(id)
+		p.floatingIPs++
+		if f.PortID != nil && *f.PortID != "" {
+			p.floatingIPsAssigned++
+		}
+	}
+	return nil
+}
+
+// volumeQuotas adds the volume quotas of a project, -1 is unlimited
+func (o *OpenStack) volumeQuotas(ctx context.Context, id string, p *project, fields map[string]interface{}) error {
+	volume, err := o.volumeEndpoint()
+	if err != nil {
+		return err
+	}
+	var quotas struct {
+		QuotaSet struct {
+			Volumes   int64 `json:"volumes"`
+			Gigabytes int64 `json:"gigabytes"`
+		} `json:"quota_set"`
+	}
+	if err := o.client.get(ctx, volume+"/os-quota-sets/"+id, &quotas); err != nil {
+		return err
+	}
+	fields["volumes_quota"] = quotas.QuotaSet.Volumes
+	fields["size_gb_quota"] = quotas.QuotaSet.Gigabytes
+	if quotas.QuotaSet.Gigabytes > 0 {
+		fields["size_gb_used_percent"] = float64(p.volumeSizeGB) / float64(quotas.QuotaSet.Gigabytes) * 100
+	}
+	return nil
+}
+
+// floatingIPQuota adds the floating IP quota of a project, -1 is unlimited
+func (o *OpenStack) floatingIPQuota(ctx context.Context, id string, p *project, fields map[string]interface{}) error {
+	network, err := o.client.endpoint("network")
+	if err != nil {
+		return err
+	}
+	var quota struct {
+		Quota struct {
+			FloatingIP int64 `json:"floatingip"`
+		} `json:"quota"`
+	}
+	if err := o.client.get(ctx, network+"/v2.0/quotas/"+id, &quota); err != nil {
+		return err
+	}
+	fields["floating_ips_quota"] = quota.Quota.FloatingIP
+	if quota.Quota.FloatingIP > 0 {
+		fields["floating_ips_used_percent"] = float64(p.floatingIPs) / float64(quota.Quota.FloatingIP) * 100
+	}
+	return nil
+}
+
+func (o *OpenStack) enabled(service string) bool {
+	return choice.Contains(service, o.EnabledServices)
+}
+
+func init() {
+	inputs.Add("openstack", func() cua.Input {
+		return &OpenStack{
+			Domain:          "default",
+			Project:         "admin",
+			Interface:       "public",
+			EnabledServices: defaultServices,
+			Timeout:         internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
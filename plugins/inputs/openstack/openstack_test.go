@@ -0,0 +1,213 @@
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const token = "gAAAAABf-token"
+
+// newCloud serves Keystone, Nova, Cinder and Neutron with the endpoints of
+// two regions in the catalog
+func newCloud(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+
+	mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("X-Subject-Token", token)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": {
+			"expires_at": %q,
+			"catalog": [
+				{"type": "compute", "endpoints": [
+					{"interface": "public", "region_id": "RegionTwo", "url": "http://region-two.invalid/compute/v2.1"},
+					{"interface": "public", "region_id": "RegionOne", "url": "%[2]s/compute/v2.1"}
+				]},
+				{"type": "volumev3", "endpoints": [
+					{"interface": "public", "region_id": "RegionOne", "url": "%[2]s/volume/v3/admin-id"}
+				]},
+				{"type": "network", "endpoints": [
+					{"interface": "public", "region_id": "RegionOne", "url": "%[2]s/network/"}
+				]}
+			]
+		}}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339), ts.URL)
+	})
+
+	handle := func(path, body string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Auth-Token") != token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	handle("/v3/projects", `{"projects": [{"id": "p1", "name": "retail"}, {"id": "p2", "name": "warehouse"}]}`)
+	handle("/compute/v2.1/os-hypervisors/detail", `{"hypervisors": [{
+		"hypervisor_hostname": "compute1", "hypervisor_type": "QEMU", "state": "up", "status": "enabled",
+		"vcpus": 32, "vcpus_used": 10, "memory_mb": 131072, "memory_mb_used": 40960,
+		"local_gb": 1000, "local_gb_used": 200, "running_vms": 5
+	}]}`)
+	// the servers are returned in two pages
+	mux.HandleFunc("/compute/v2.1/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "1", r.URL.Query().Get("all_tenants"))
+		if r.URL.Query().Get("marker") == "" {
+			fmt.Fprintf(w, `{"servers": [
+				{"id": "s1", "tenant_id": "p1", "status": "ACTIVE"},
+				{"id": "s2", "tenant_id": "p1", "status": "ACTIVE"}
+			], "servers_links": [{"rel": "next", "href": "%s/compute/v2.1/servers/detail?all_tenants=1&marker=s2"}]}`, ts.URL)
+			return
+		}
+		_, _ = w.Write([]byte(`{"servers": [
+			{"id": "s3", "tenant_id": "p1", "status": "ERROR"},
+			{"id": "s4", "tenant_id": "p2", "status": "SHUTOFF"}
+		]}`))
+	})
+	handle("/volume/v3/admin-id/volumes/detail", `{"volumes": [
+		{"os-vol-tenant-attr:tenant_id": "p1", "status": "in-use", "size": 100},
+		{"os-vol-tenant-attr:tenant_id": "p1", "status": "available", "size": 50},
+		{"os-vol-tenant-attr:tenant_id": "p2", "status": "error_deleting", "size": 10}
+	]}`)
+	handle("/volume/v3/admin-id/os-quota-sets/p1", `{"quota_set": {"id": "p1", "volumes": 10, "gigabytes": 1000}}`)
+	handle("/volume/v3/admin-id/os-quota-sets/p2", `{"quota_set": {"id": "p2", "volumes": -1, "gigabytes": -1}}`)
+	handle("/network/v2.0/floatingips", `{"floatingips": [
+		{"project_id": "p1", "port_id": "port1", "floating_ip_address": "203.0.113.10"},
+		{"project_id": "p1", "port_id": null, "floating_ip_address": "203.0.113.11"}
+	]}`)
+	handle("/network/v2.0/quotas/p1", `{"quota": {"floatingip": 4}}`)
+	handle("/network/v2.0/quotas/p2", `{"quota": {"floatingip": 50}}`)
+
+	return ts
+}
+
+func newOpenStack(url string) *OpenStack {
+	return &OpenStack{
+		AuthenticationEndpoint: url + "/v3",
+		Domain:                 "default",
+		Project:                "admin",
+		Username:               "admin",
+		Password:               "secret",
+		Region:                 "RegionOne",
+		Interface:              "public",
+		EnabledServices:        defaultServices,
+		Timeout:                internal.Duration{Duration: 5 * time.Second},
+		Log:                    testutil.Logger{},
+	}
+}
+
+func TestGather(t *testing.T) {
+	ts := newCloud(t)
+	defer ts.Close()
+
+	o := newOpenStack(ts.URL)
+	o.Quotas = true
+	require.NoError(t, o.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, o.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "openstack_hypervisor", map[string]interface{}{
+		"vcpus":          int64(32),
+		"vcpus_used":     int64(10),
+		"memory_mb":      int64(131072),
+		"memory_mb_used": int64(40960),
+		"local_gb":       int64(1000),
+		"local_gb_used":  int64(200),
+		"running_vms":    int64(5),
+	}, map[string]string{"hypervisor": "compute1", "hypervisor_type": "QEMU", "state": "up", "status": "enabled"})
+
+	retail := map[string]string{"project": "retail", "project_id": "p1"}
+	warehouse := map[string]string{"project": "warehouse", "project_id": "p2"}
+	withStatus := func(tags map[string]string, status string) map[string]string {
+		t := map[string]string{"status": status}
+		for k, v := range tags {
+			t[k] = v
+		}
+		return t
+	}
+	acc.AssertContainsTaggedFields(t, "openstack_servers", map[string]interface{}{"servers": 2}, withStatus(retail, "active"))
+	acc.AssertContainsTaggedFields(t, "openstack_servers", map[string]interface{}{"servers": 1}, withStatus(retail, "error"))
+	acc.AssertContainsTaggedFields(t, "openstack_servers", map[string]interface{}{"servers": 1}, withStatus(warehouse, "shutoff"))
+
+	acc.AssertContainsTaggedFields(t, "openstack_volumes", map[string]interface{}{
+		"volumes":              2,
+		"volumes_in_use":       1,
+		"volumes_error":        0,
+		"size_gb":              int64(150),
+		"volumes_quota":        int64(10),
+		"size_gb_quota":        int64(1000),
+		"size_gb_used_percent": float64(15),
+	}, retail)
+	acc.AssertContainsTaggedFields(t, "openstack_volumes", map[string]interface{}{
+		"volumes":        1,
+		"volumes_in_use": 0,
+		"volumes_error":  1,
+		"size_gb":        int64(10),
+		"volumes_quota":  int64(-1),
+		"size_gb_quota":  int64(-1),
+	}, warehouse)
+
+	acc.AssertContainsTaggedFields(t, "openstack_floating_ips", map[string]interface{}{
+		"floating_ips":              2,
+		"floating_ips_associated":   1,
+		"floating_ips_quota":        int64(4),
+		"floating_ips_used_percent": float64(50),
+	}, retail)
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	ts := newCloud(t)
+	defer ts.Close()
+
+	o := newOpenStack(ts.URL)
+	o.EnabledServices = []string{"hypervisors"}
+	require.NoError(t, o.Init())
+	require.NoError(t, o.client.authenticate(context.Background()))
+	// a revoked token
+	o.client.token = "revoked"
+
+	var acc testutil.Accumulator
+	require.Error(t, o.Gather(context.Background(), &acc))
+	require.Empty(t, o.client.token)
+
+	// a new token is requested with the next gather
+	acc.ClearMetrics()
+	require.NoError(t, o.Gather(context.Background(), &acc))
+	require.True(t, acc.HasMeasurement("openstack_hypervisor"))
+}
+
+func TestEndpointRegion(t *testing.T) {
+	c := &client{
+		iface:  "public",
+		region: "RegionTwo",
+		catalog: []catalogEntry{{Type: "compute", Endpoints: []endpoint{
+			{Interface: "internal", RegionID: "RegionTwo", URL: "http://internal"},
+			{Interface: "public", RegionID: "RegionOne", URL: "http://one"},
+			{Interface: "public", Region: "RegionTwo", URL: "http://two/"},
+		}}},
+	}
+	u, err := c.endpoint("compute")
+	require.NoError(t, err)
+	require.Equal(t, "http://two", u)
+
+	_, err = c.endpoint("network")
+	require.Error(t, err)
+}
+
+func TestInitUnknownService(t *testing.T) {
+	o := newOpenStack("http://localhost:5000")
+	o.EnabledServices = []string{"images"}
+	require.Error(t, o.Init())
+}
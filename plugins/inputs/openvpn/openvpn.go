@@ -0,0 +1,258 @@
+// Package openvpn implements a plugin for collecting tunnel and client
+// statistics from the OpenVPN management interface.
+package openvpn
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+// OpenVPN reads the status of one or more OpenVPN management interfaces
+type OpenVPN struct {
+	Servers  []string          `toml:"servers"`
+	Password string            `toml:"password"`
+	Timeout  internal.Duration `toml:"timeout"`
+}
+
+func (o *OpenVPN) Description() string {
+	return "Read tunnel state and connected clients from the OpenVPN management interface"
+}
+
+func (o *OpenVPN) SampleConfig() string {
+	return `
+  ## Management interfaces to query, "management 127.0.0.1 7505" or
+  ## "management /run/openvpn/server.sock unix" in the OpenVPN configuration.
+  ## URL must match pattern: scheme://address[:port]
+  # servers = ["tcp://127.0.0.1:7505", "unix:///run/openvpn/server.sock"]
+  servers = ["tcp://127.0.0.1:7505"]
+
+  ## Password of the management interface, when it is set with
+  ## "management-client-auth"/"management ... pw-file"
+  # password = ""
+
+  ## Timeout for connecting and reading the status
+  # timeout = "5s"
+`
+}
+
+func (o *OpenVPN) Gather(ctx context.Context, acc cua.Accumulator) error {
+	var wg sync.WaitGroup
+
+	for _, s := range o.Servers {
+		wg.Add(1)
+		go func(s string) {
+			defer wg.Done()
+			u, err := url.Parse(s)
+			if err != nil {
+				acc.AddError(fmt.Errorf("could not parse management url '%s': %w", s, err))
+				return
+			}
+			if err := o.gatherServer(acc, u); err != nil {
+				acc.AddError(err)
+			}
+		}(s)
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (o *OpenVPN) gatherServer(acc cua.Accumulator, u *url.URL) error {
+	var addr string
+	switch u.Scheme {
+	case "tcp":
+		addr = u.Host
+	case "unix":
+		addr = u.Path
+	default:
+		return fmt.Errorf("'%s' is not a supported scheme", u.Scheme)
+	}
+
+	conn, err := net.DialTimeout(u.Scheme, addr, o.Timeout.Duration)
+	if err != nil {
+		return fmt.Errorf("dial (%s): %w", addr, err)
+	}
+	defer conn.Close()
+	if o.Timeout.Duration > 0 {
+		_ = conn.SetDeadline(time.Now().Add(o.Timeout.Duration))
+	}
+
+	m := &mgmt{conn: conn, r: bufio.NewReader(conn)}
+	if o.Password != "" {
+		if _, err := fmt.Fprintf(conn, "%s\n", o.Password); err != nil {
+			return fmt.Errorf("password (%s): %w", addr, err)
+		}
+	}
+
+	state, err := m.command("state")
+	if err != nil {
+		return fmt.Errorf("state (%s): %w", addr, err)
+	}
+	status, err := m.command("status 3")
+	if err != nil {
+		return fmt.Errorf("status (%s): %w", addr, err)
+	}
+	_, _ = fmt.Fprint(conn, "quit\n")
+
+	gatherStatus(acc, addr, state, status)
+
+	return nil
+}
+
+// mgmt is a connection to a management interface
+type mgmt struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// command sends a command and returns the lines of its reply up to END,
+// real-time notifications (">INFO:", ">CLIENT:", ...) are skipped
+func (m *mgmt) command(cmd string) ([]string, error) {
+	if _, err := fmt.Fprintf(m.conn, "%s\n", cmd); err != nil {
+		return nil, fmt.Errorf("write: %w", err)
+	}
+
+	var lines []string
+	for {
+		line, err := m.r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+		// the password prompt is not terminated by a newline
+		line = strings.TrimPrefix(strings.TrimRight(line, "\r\n"), "ENTER PASSWORD:")
+		switch {
+		case line == "END":
+			return lines, nil
+		case strings.HasPrefix(line, "ERROR:"):
+			return nil, fmt.Errorf("%s", strings.TrimSpace(strings.TrimPrefix(line, "ERROR:")))
+		case strings.HasPrefix(line, ">"), strings.HasPrefix(line, "SUCCESS:"), line == "":
+			continue
+		}
+		lines = append(lines, line)
+	}
+}
+
+// splitLine splits a status line, version 3 separates the columns with tabs
+// but older servers may still use commas
+func splitLine(line string) []string {
+	if strings.Contains(line, "\t") {
+		return strings.Split(line, "\t")
+	}
+	return strings.Split(line, ",")
+}
+
+type client struct {
+	connections   int
+	bytesReceived int64
+	bytesSent     int64
+	since         int64
+}
+
+// gatherStatus adds the metrics of a server ("CLIENT_LIST" rows) or of a
+// client ("TCP/UDP read bytes" rows) status
+func gatherStatus(acc cua.Accumulator, server string, state, status []string) {
+	tags := map[string]string{"server": server, "mode": "client"}
+	fields := map[string]interface{}{"up": 0}
+
+	// unixtime,CONNECTED,SUCCESS,local ip,remote ip,...
+	for _, line := range state {
+		cols := strings.Split(line, ",")
+		if len(cols) > 1 && cols[1] == "CONNECTED" {
+			fields["up"] = 1
+		}
+	}
+
+	var header map[string]int
+	clients := make(map[string]*client)
+	var received, sent int64
+	for _, line := range status {
+		cols := splitLine(line)
+		if len(cols) < 2 {
+			continue
+		}
+		switch cols[0] {
+		case "HEADER":
+			if cols[1] != "CLIENT_LIST" {
+				continue
+			}
+			// the columns differ between versions, the header names them
+			header = make(map[string]int)
+			for i, name := range cols[2:] {
+				header[name] = i + 1
+			}
+		case "TITLE":
+			tags["mode"] = "server"
+		case "CLIENT_LIST":
+			tags["mode"] = "server"
+			if header == nil {
+				continue
+			}
+			col := func(name string) string {
+				if i, ok := header[name]; ok && i < len(cols) {
+					return cols[i]
+				}
+				return ""
+			}
+			cn := col("Common Name")
+			c, ok := clients[cn]
+			if !ok {
+				c = &client{}
+				clients[cn] = c
+			}
+			c.connections++
+			r, _ := strconv.ParseInt(col("Bytes Received"), 10, 64)
+			s, _ := strconv.ParseInt(col("Bytes Sent"), 10, 64)
+			c.bytesReceived += r
+			c.bytesSent += s
+			received += r
+			sent += s
+			if since, err := strconv.ParseInt(col("Connected Since (time_t)"), 10, 64); err == nil {
+				if c.since == 0 || since < c.since {
+					c.since = since
+				}
+			}
+		case "TCP/UDP read bytes":
+			received, _ = strconv.ParseInt(cols[1], 10, 64)
+		case "TCP/UDP write bytes":
+			sent, _ = strconv.ParseInt(cols[1], 10, 64)
+		}
+	}
+
+	fields["bytes_received"] = received
+	fields["bytes_sent"] = sent
+	if tags["mode"] == "server" {
+		connections := 0
+		for cn, c := range clients {
+			connections += c.connections
+			acc.AddFields("openvpn_client", map[string]interface{}{
+				"connections":     c.connections,
+				"bytes_received":  c.bytesReceived,
+				"bytes_sent":      c.bytesSent,
+				"connected_since": c.since,
+			}, map[string]string{"server": server, "common_name": cn})
+		}
+		fields["clients"] = connections
+	}
+
+	acc.AddFields("openvpn", fields, tags)
+}
+
+func init() {
+	inputs.Add("openvpn", func() cua.Input {
+		return &OpenVPN{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package openvpn
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const serverState = `1602669600,CONNECTED,SUCCESS,10.8.0.1,,,,
+END
+`
+
+const serverStatus = "TITLE\tOpenVPN 2.4.9 x86_64-redhat-linux-gnu [SSL (OpenSSL)] [LZO] [LZ4] [EPOLL] [PKCS11] [MH/PKTINFO] [AEAD] built on Apr 24 2020\n" +
+	"TIME\tWed Oct 14 10:00:00 2020\t1602669600\n" +
+	"HEADER\tCLIENT_LIST\tCommon Name\tReal Address\tVirtual Address\tVirtual IPv6 Address\tBytes Received\tBytes Sent\tConnected Since\tConnected Since (time_t)\tUsername\tClient ID\tPeer ID\n" +
+	"CLIENT_LIST\talice\t192.0.2.10:51820\t10.8.0.2\t\t12345\t67890\tWed Oct 14 09:00:00 2020\t1602666000\tUNDEF\t0\t0\n" +
+	"CLIENT_LIST\tbob\t192.0.2.11:51821\t10.8.0.3\t\t100\t200\tWed Oct 14 09:30:00 2020\t1602667800\tUNDEF\t1\t1\n" +
+	"CLIENT_LIST\tbob\t192.0.2.12:51822\t10.8.0.4\t\t1\t2\tWed Oct 14 09:10:00 2020\t1602666600\tUNDEF\t2\t2\n" +
+	"HEADER\tROUTING_TABLE\tVirtual Address\tCommon Name\tReal Address\tLast Ref\tLast Ref (time_t)\n" +
+	"ROUTING_TABLE\t10.8.0.2\talice\t192.0.2.10:51820\tWed Oct 14 09:59:59 2020\t1602669599\n" +
+	"GLOBAL_STATS\tMax bcast/mcast queue length\t0\n" +
+	"END\n"
+
+const clientState = `>INFO:OpenVPN Management Interface Version 3 -- type 'help' for more info
+1602669600,RECONNECTING,SIGUSR1,,,,,
+END
+`
+
+const clientStatus = "OpenVPN STATISTICS\n" +
+	"Updated\tWed Oct 14 10:00:00 2020\n" +
+	"TUN/TAP read bytes\t1000\n" +
+	"TUN/TAP write bytes\t2000\n" +
+	"TCP/UDP read bytes\t3000\n" +
+	"TCP/UDP write bytes\t4000\n" +
+	"Auth read bytes\t2100\n" +
+	"END\n"
+
+// fakeManagement serves a single management session, checking the
+// password when one is given
+func fakeManagement(t *testing.T, password, state, status string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if password != "" {
+			fmt.Fprint(conn, "ENTER PASSWORD:")
+			line, _ := r.ReadString('\n')
+			if strings.TrimSpace(line) != password {
+				fmt.Fprint(conn, "ERROR: bad password\r\n")
+				return
+			}
+			fmt.Fprint(conn, "SUCCESS: password is correct\r\n")
+		}
+		fmt.Fprint(conn, ">INFO:OpenVPN Management Interface Version 3 -- type 'help' for more info\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch strings.TrimSpace(line) {
+			case "state":
+				fmt.Fprint(conn, state)
+			case "status 3":
+				fmt.Fprint(conn, status)
+			case "quit":
+				return
+			default:
+				fmt.Fprint(conn, "ERROR: unknown command, enter 'help' for more options\r\n")
+			}
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestGatherServer(t *testing.T) {
+	addr := fakeManagement(t, "secret", serverState, serverStatus)
+	o := &OpenVPN{
+		Servers:  []string{"tcp://" + addr},
+		Password: "secret",
+		Timeout:  internal.Duration{Duration: 5 * time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(o.Gather))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "openvpn",
+		map[string]interface{}{
+			"up":             1,
+			"clients":        3,
+			"bytes_received": int64(12446),
+			"bytes_sent":     int64(68092),
+		},
+		map[string]string{"server": addr, "mode": "server"})
+	acc.AssertContainsTaggedFields(t, "openvpn_client",
+		map[string]interface{}{
+			"connections":     1,
+			"bytes_received":  int64(12345),
+			"bytes_sent":      int64(67890),
+			"connected_since": int64(1602666000),
+		},
+		map[string]string{"server": addr, "common_name": "alice"})
+	acc.AssertContainsTaggedFields(t, "openvpn_client",
+		map[string]interface{}{
+			"connections":     2,
+			"bytes_received":  int64(101),
+			"bytes_sent":      int64(202),
+			"connected_since": int64(1602666600),
+		},
+		map[string]string{"server": addr, "common_name": "bob"})
+}
+
+func TestGatherClient(t *testing.T) {
+	addr := fakeManagement(t, "", clientState, clientStatus)
+	o := &OpenVPN{
+		Servers: []string{"tcp://" + addr},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(o.Gather))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "openvpn",
+		map[string]interface{}{
+			"up":             0,
+			"bytes_received": int64(3000),
+			"bytes_sent":     int64(4000),
+		},
+		map[string]string{"server": addr, "mode": "client"})
+	require.False(t, acc.HasMeasurement("openvpn_client"))
+}
+
+func TestBadPassword(t *testing.T) {
+	addr := fakeManagement(t, "secret", serverState, serverStatus)
+	o := &OpenVPN{
+		Servers:  []string{"tcp://" + addr},
+		Password: "wrong",
+		Timeout:  internal.Duration{Duration: 5 * time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, o.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "bad password")
+}
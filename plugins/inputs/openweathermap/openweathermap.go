@@ -33,6 +33,8 @@ const (
 type OpenWeatherMap struct {
 	client          *http.Client
 	baseURL         *url.URL
+	cities          map[string]*WeatherEntry
+	citiesMu        sync.Mutex
 	AppID           string            `toml:"app_id"`
 	BaseURL         string            `toml:"base_url"`
 	Units           string            `toml:"units"`
@@ -55,7 +57,8 @@ var sampleConfig = `
   ## "se", "sk", "sl", "es", "tr", "ua", "vi", "zh_cn", "zh_tw"
   # lang = "en"
 
-  ## APIs to fetch; can contain "weather" or "forecast".
+  ## APIs to fetch; can contain "weather", "forecast" or "alerts".
+  ## "alerts" reports government weather alerts from the One Call API.
   fetch = ["weather", "forecast"]
 
   ## OpenWeatherMap base URL
@@ -101,6 +104,17 @@ func (n *OpenWeatherMap) Gather(ctx context.Context, acc cua.Accumulator) error
 					gatherForecast(acc, status)
 				}()
 			}
+		} else if fetch == "alerts" {
+			for _, city := range n.CityID {
+				city := city
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := n.gatherAlerts(acc, city); err != nil {
+						acc.AddError(err)
+					}
+				}()
+			}
 		} else if fetch == "weather" {
 			j := 0
 			for j < len(n.CityID) {
@@ -146,26 +160,53 @@ func (n *OpenWeatherMap) createHTTPClient() *http.Client {
 }
 
 func (n *OpenWeatherMap) gatherURL(addr string) (*Status, error) {
+	body, err := n.get(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return gatherWeatherURL(body)
+}
+
+// get requests addr and returns the body of a successful json response
+func (n *OpenWeatherMap) get(addr string) (io.ReadCloser, error) {
 	resp, err := n.client.Get(addr)
 	if err != nil {
 		return nil, fmt.Errorf("error making HTTP request to %s: %w", addr, err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
 		return nil, fmt.Errorf("%s returned HTTP status %s", addr, resp.Status)
 	}
 
 	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
 	if err != nil {
+		resp.Body.Close()
 		return nil, fmt.Errorf("parse media type (%s): %w", resp.Header.Get("Content-Type"), err)
 	}
 
 	if mediaType != "application/json" {
+		resp.Body.Close()
 		return nil, fmt.Errorf("%s returned unexpected content type %s", addr, mediaType)
 	}
 
-	return gatherWeatherURL(resp.Body)
+	return resp.Body, nil
+}
+
+// getJSON requests addr and decodes the json response into v
+func (n *OpenWeatherMap) getJSON(addr string, v interface{}) error {
+	body, err := n.get(addr)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return fmt.Errorf("error while decoding JSON response: %w", err)
+	}
+	return nil
 }
 
 type WeatherEntry struct {
@@ -298,6 +339,124 @@ func gatherForecast(acc cua.Accumulator, status *Status) {
 	}
 }
 
+// Alert is a government weather alert from the One Call API
+type Alert struct {
+	SenderName  string   `json:"sender_name"`
+	Event       string   `json:"event"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Start       int64    `json:"start"`
+	End         int64    `json:"end"`
+}
+
+type OneCall struct {
+	Alerts []Alert `json:"alerts"`
+}
+
+// alertSeverities are the severities derived from the alert event name, in
+// order of precedence, e.g. "Winter Storm Warning" is a warning
+var alertSeverities = []string{"emergency", "warning", "watch", "advisory", "statement"}
+
+// alertSeverity derives the severity from the event name, the One Call API
+// does not report one
+func alertSeverity(event string) string {
+	event = strings.ToLower(event)
+	for _, severity := range alertSeverities {
+		if strings.Contains(event, severity) {
+			return severity
+		}
+	}
+	return "other"
+}
+
+// city returns the name, country and coordinates of a city, the One Call API
+// is queried by coordinates rather than city id. Cities are looked up once
+// and cached.
+func (n *OpenWeatherMap) city(id string) (*WeatherEntry, error) {
+	n.citiesMu.Lock()
+	defer n.citiesMu.Unlock()
+
+	if e, ok := n.cities[id]; ok {
+		return e, nil
+	}
+
+	e := &WeatherEntry{}
+	if err := n.getJSON(n.formatURL("/data/2.5/weather", id), e); err != nil {
+		return nil, err
+	}
+
+	if n.cities == nil {
+		n.cities = make(map[string]*WeatherEntry)
+	}
+	n.cities[id] = e
+	return e, nil
+}
+
+func (n *OpenWeatherMap) gatherAlerts(acc cua.Accumulator, cityID string) error {
+	city, err := n.city(cityID)
+	if err != nil {
+		return err
+	}
+
+	oneCall := &OneCall{}
+	if err := n.getJSON(n.formatOneCallURL(city.Coord.Lat, city.Coord.Lon), oneCall); err != nil {
+		return err
+	}
+
+	gatherAlerts(acc, city, oneCall, time.Now())
+	return nil
+}
+
+func gatherAlerts(acc cua.Accumulator, city *WeatherEntry, oneCall *OneCall, now time.Time) {
+	cityTags := map[string]string{
+		"city":    city.Name,
+		"city_id": strconv.FormatInt(city.ID, 10),
+		"country": city.Sys.Country,
+	}
+
+	counts := map[string]interface{}{
+		"count":        int64(len(oneCall.Alerts)),
+		"active_count": int64(0),
+		"count_other":  int64(0),
+	}
+	for _, severity := range alertSeverities {
+		counts["count_"+severity] = int64(0)
+	}
+
+	for _, a := range oneCall.Alerts {
+		severity := alertSeverity(a.Event)
+		counts["count_"+severity] = counts["count_"+severity].(int64) + 1
+
+		active := int64(0)
+		if !now.Before(time.Unix(a.Start, 0)) && now.Before(time.Unix(a.End, 0)) {
+			active = 1
+			counts["active_count"] = counts["active_count"].(int64) + 1
+		}
+
+		tags := map[string]string{
+			"event":    a.Event,
+			"sender":   a.SenderName,
+			"severity": severity,
+		}
+		if len(a.Tags) > 0 {
+			tags["category"] = strings.Join(a.Tags, ",")
+		}
+		for k, v := range cityTags {
+			tags[k] = v
+		}
+
+		fields := map[string]interface{}{
+			"description": a.Description,
+			"start":       time.Unix(a.Start, 0).UnixNano(),
+			"end":         time.Unix(a.End, 0).UnixNano(),
+			"active":      active,
+		}
+		acc.AddFields("weather_alert", fields, tags, now)
+	}
+
+	acc.AddFields("weather_alerts", counts, cityTags, now)
+}
+
 func init() {
 	inputs.Add("openweathermap", func() cua.Input {
 		tmout := internal.Duration{
@@ -343,6 +502,24 @@ func (n *OpenWeatherMap) Init() error {
 	return nil
 }
 
+func (n *OpenWeatherMap) formatOneCallURL(lat, lon float64) string {
+	v := url.Values{
+		"lat":     []string{strconv.FormatFloat(lat, 'f', -1, 64)},
+		"lon":     []string{strconv.FormatFloat(lon, 'f', -1, 64)},
+		"exclude": []string{"current,minutely,hourly,daily"},
+		"APPID":   []string{n.AppID},
+		"lang":    []string{n.Lang},
+		"units":   []string{n.Units},
+	}
+
+	relative := &url.URL{
+		Path:     "/data/2.5/onecall",
+		RawQuery: v.Encode(),
+	}
+
+	return n.baseURL.ResolveReference(relative).String()
+}
+
 func (n *OpenWeatherMap) formatURL(path string, city string) string {
 	v := url.Values{
 		"id":    []string{city},
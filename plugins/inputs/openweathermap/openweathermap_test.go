@@ -833,3 +833,165 @@ func TestDefaultLang(t *testing.T) {
 
 	require.Equal(t, "en", n.Lang)
 }
+
+const sampleCityResponse = `
+{
+	"coord": {"lon": -75.16, "lat": 39.95},
+	"weather": [{"id": 800, "main": "Clear", "description": "clear sky", "icon": "01d"}],
+	"main": {"temp": 21.3, "pressure": 1015, "humidity": 48},
+	"dt": 1684952747,
+	"sys": {"country": "US", "sunrise": 1684920000, "sunset": 1684972800},
+	"id": 4560349,
+	"name": "Philadelphia"
+}
+`
+
+const sampleOneCallResponse = `
+{
+	"lat": 39.95,
+	"lon": -75.16,
+	"timezone": "America/New_York",
+	"alerts": [
+		{
+			"sender_name": "NWS Philadelphia - Mount Holly",
+			"event": "Severe Thunderstorm Warning",
+			"start": 1684952747,
+			"end": 4102444800,
+			"description": "The National Weather Service has issued a Severe Thunderstorm Warning",
+			"tags": ["Thunderstorm", "Wind"]
+		},
+		{
+			"sender_name": "NWS Philadelphia - Mount Holly",
+			"event": "Heat Advisory",
+			"start": 1684900000,
+			"end": 1684950000,
+			"description": "Heat index values up to 105 expected",
+			"tags": ["Extreme high temperature"]
+		}
+	]
+}
+`
+
+func TestAlertsGeneratesMetrics(t *testing.T) {
+	cityLookups := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rsp string
+		switch r.URL.Path {
+		case "/data/2.5/weather":
+			cityLookups++
+			require.Equal(t, "4560349", r.URL.Query().Get("id"))
+			rsp = sampleCityResponse
+		case "/data/2.5/onecall":
+			require.Equal(t, "39.95", r.URL.Query().Get("lat"))
+			require.Equal(t, "-75.16", r.URL.Query().Get("lon"))
+			rsp = sampleOneCallResponse
+		default:
+			panic("Cannot handle request")
+		}
+		w.Header()["Content-Type"] = []string{"application/json"}
+		fmt.Fprintln(w, rsp)
+	}))
+	defer ts.Close()
+
+	n := &OpenWeatherMap{
+		BaseURL: ts.URL,
+		AppID:   "noappid",
+		CityID:  []string{"4560349"},
+		Fetch:   []string{"alerts"},
+	}
+	require.NoError(t, n.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []cua.Metric{
+		testutil.MustMetric(
+			"weather_alert",
+			map[string]string{
+				"city":     "Philadelphia",
+				"city_id":  "4560349",
+				"country":  "US",
+				"event":    "Severe Thunderstorm Warning",
+				"sender":   "NWS Philadelphia - Mount Holly",
+				"severity": "warning",
+				"category": "Thunderstorm,Wind",
+			},
+			map[string]interface{}{
+				"description": "The National Weather Service has issued a Severe Thunderstorm Warning",
+				"start":       time.Unix(1684952747, 0).UnixNano(),
+				"end":         time.Unix(4102444800, 0).UnixNano(),
+				"active":      int64(1),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"weather_alert",
+			map[string]string{
+				"city":     "Philadelphia",
+				"city_id":  "4560349",
+				"country":  "US",
+				"event":    "Heat Advisory",
+				"sender":   "NWS Philadelphia - Mount Holly",
+				"severity": "advisory",
+				"category": "Extreme high temperature",
+			},
+			map[string]interface{}{
+				"description": "Heat index values up to 105 expected",
+				"start":       time.Unix(1684900000, 0).UnixNano(),
+				"end":         time.Unix(1684950000, 0).UnixNano(),
+				"active":      int64(0),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"weather_alerts",
+			map[string]string{
+				"city":    "Philadelphia",
+				"city_id": "4560349",
+				"country": "US",
+			},
+			map[string]interface{}{
+				"count":           int64(2),
+				"active_count":    int64(1),
+				"count_emergency": int64(0),
+				"count_warning":   int64(1),
+				"count_watch":     int64(0),
+				"count_advisory":  int64(1),
+				"count_statement": int64(0),
+				"count_other":     int64(0),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t,
+		expected, acc.GetCUAMetrics(),
+		testutil.SortMetrics(), testutil.IgnoreTime())
+
+	// the city coordinates are cached
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Equal(t, 1, cityLookups)
+}
+
+func TestAlertSeverity(t *testing.T) {
+	require.Equal(t, "warning", alertSeverity("Winter Storm Warning"))
+	require.Equal(t, "watch", alertSeverity("Flood Watch"))
+	require.Equal(t, "advisory", alertSeverity("Wind Advisory"))
+	require.Equal(t, "statement", alertSeverity("Special Weather Statement"))
+	require.Equal(t, "emergency", alertSeverity("Tornado Emergency"))
+	require.Equal(t, "other", alertSeverity("Red Flag"))
+}
+
+func TestFormatOneCallURL(t *testing.T) {
+	n := &OpenWeatherMap{
+		AppID:   "appid",
+		Units:   "units",
+		Lang:    "lang",
+		BaseURL: "http://foo.com",
+	}
+	_ = n.Init()
+
+	require.Equal(t,
+		"http://foo.com/data/2.5/onecall?APPID=appid&exclude=current%2Cminutely%2Chourly%2Cdaily&lang=lang&lat=39.95&lon=-75.16&units=units",
+		n.formatOneCallURL(39.95, -75.16))
+}
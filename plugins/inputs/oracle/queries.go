@@ -0,0 +1,47 @@
+package oracle
+
+// the string columns of the queries are tags, the others fields; the column
+// names are lower cased
+
+const sessionsQuery = `
+SELECT
+	status,
+	type,
+	COUNT(*) AS sessions
+FROM v$session
+GROUP BY status, type
+`
+
+const sessionLimitQuery = `
+SELECT
+	(SELECT COUNT(*) FROM v$session) AS sessions,
+	TO_NUMBER(p.value) AS sessions_limit,
+	(SELECT COUNT(*) FROM v$process) AS processes,
+	(SELECT TO_NUMBER(value) FROM v$parameter WHERE name = 'processes') AS processes_limit
+FROM v$parameter p
+WHERE p.name = 'sessions'
+`
+
+const tablespacesQuery = `
+SELECT
+	m.tablespace_name,
+	t.contents,
+	t.status,
+	m.used_space * t.block_size AS used_bytes,
+	m.tablespace_size * t.block_size AS max_bytes,
+	m.used_percent
+FROM dba_tablespace_usage_metrics m
+JOIN dba_tablespaces t ON t.tablespace_name = m.tablespace_name
+`
+
+const rmanBackupsQuery = `
+SELECT
+	input_type,
+	MAX(status) KEEP (DENSE_RANK LAST ORDER BY start_time) AS last_status,
+	ROUND((SYSDATE - MAX(CASE WHEN status LIKE 'COMPLETED%' THEN end_time END)) * 86400) AS last_success_age,
+	MAX(elapsed_seconds) KEEP (DENSE_RANK LAST ORDER BY start_time) AS last_elapsed_seconds,
+	MAX(output_bytes) KEEP (DENSE_RANK LAST ORDER BY start_time) AS last_output_bytes
+FROM v$rman_backup_job_details
+WHERE start_time > SYSDATE - 31
+GROUP BY input_type
+`
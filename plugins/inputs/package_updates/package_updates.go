@@ -0,0 +1,332 @@
+package packageupdates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const (
+	managerApt    = "apt"
+	managerDnf    = "dnf"
+	managerYum    = "yum"
+	managerZypper = "zypper"
+)
+
+// commands for the package managers, in detection order
+var managerCommands = []struct {
+	manager string
+	command string
+}{
+	{managerApt, "apt-get"},
+	{managerDnf, "dnf"},
+	{managerYum, "yum"},
+	{managerZypper, "zypper"},
+}
+
+// files created by the package managers when a reboot is required
+var rebootFiles = []string{
+	"/var/run/reboot-required",
+	"/var/run/reboot-needed",
+}
+
+// runner runs a command and returns its stdout and exit status, a non zero
+// exit status is not an error
+type runner func(timeout time.Duration, useSudo bool, name string, args ...string) ([]byte, int, error)
+
+// PackageUpdates reports the pending package updates and whether a reboot is required
+type PackageUpdates struct {
+	Manager string            `toml:"manager"`
+	UseSudo bool              `toml:"use_sudo"`
+	Timeout internal.Duration `toml:"timeout"`
+
+	run         runner
+	lookPath    func(string) (string, error)
+	rebootFiles []string
+	command     string
+}
+
+var sampleConfig = `
+  ## Checking for updates is slow and loads the package mirrors, collect
+  ## it on a long interval
+  interval = "1h"
+
+  ## Package manager, one of "apt", "dnf", "yum" or "zypper", it is
+  ## detected from the commands in the PATH when not set
+  # manager = ""
+
+  ## Use sudo to run the package manager, yum and dnf need it to refresh
+  ## their metadata cache
+  # use_sudo = false
+
+  ## Timeout for each package manager command
+  # timeout = "5m"
+`
+
+// SampleConfig returns sample configuration message
+func (p *PackageUpdates) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns description of PackageUpdates plugin
+func (p *PackageUpdates) Description() string {
+	return "Report pending OS package updates and whether a reboot is required"
+}
+
+// Init finds the package manager
+func (p *PackageUpdates) Init() error {
+	if p.run == nil {
+		p.run = runCommand
+	}
+	if p.lookPath == nil {
+		p.lookPath = exec.LookPath
+	}
+	if p.rebootFiles == nil {
+		p.rebootFiles = rebootFiles
+	}
+	if p.Timeout.Duration == 0 {
+		p.Timeout.Duration = 5 * time.Minute
+	}
+
+	for _, mc := range managerCommands {
+		if p.Manager != "" && p.Manager != mc.manager {
+			continue
+		}
+		if path, err := p.lookPath(mc.command); err == nil {
+			p.Manager = mc.manager
+			p.command = path
+			return nil
+		}
+		if p.Manager != "" {
+			return fmt.Errorf("%s not found: verify that it is in your PATH", mc.command)
+		}
+	}
+	if p.Manager != "" {
+		return fmt.Errorf("unknown manager (%s)", p.Manager)
+	}
+	return errors.New("no supported package manager (apt-get, dnf, yum, zypper) found in your PATH")
+}
+
+// Gather counts the pending updates
+func (p *PackageUpdates) Gather(ctx context.Context, acc cua.Accumulator) error {
+	var updates, security int
+	var err error
+	switch p.Manager {
+	case managerApt:
+		updates, security, err = p.aptUpdates()
+	case managerDnf, managerYum:
+		updates, security, err = p.yumUpdates()
+	case managerZypper:
+		updates, security, err = p.zypperUpdates()
+	}
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"updates":          updates,
+		"security_updates": security,
+	}
+	if reboot, ok := p.rebootRequired(); ok {
+		fields["reboot_required"] = reboot
+	}
+	acc.AddFields("package_updates", fields, map[string]string{"manager": p.Manager})
+
+	return nil
+}
+
+// aptUpdates simulates an upgrade, the package lists are refreshed by the
+// apt daily timer
+func (p *PackageUpdates) aptUpdates() (int, int, error) {
+	out, status, err := p.run(p.Timeout.Duration, p.UseSudo, p.command,
+		"-s", "-o", "Debug::NoLocking=true", "dist-upgrade")
+	if err != nil {
+		return 0, 0, fmt.Errorf("apt-get: %w", err)
+	}
+	if status != 0 {
+		return 0, 0, fmt.Errorf("apt-get exited with status %d", status)
+	}
+	updates, security := parseApt(string(out))
+	return updates, security, nil
+}
+
+// yumUpdates lists the updates with check-update and the security updates
+// with updateinfo
+func (p *PackageUpdates) yumUpdates() (int, int, error) {
+	out, status, err := p.run(p.Timeout.Duration, p.UseSudo, p.command, "-q", "check-update")
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s check-update: %w", p.Manager, err)
+	}
+	// check-update exits with 100 when updates are available
+	if status != 0 && status != 100 {
+		return 0, 0, fmt.Errorf("%s check-update exited with status %d", p.Manager, status)
+	}
+	updates := parseYumCheckUpdate(string(out))
+
+	args := []string{"-q", "updateinfo", "list", "security"}
+	if p.Manager == managerDnf {
+		args = []string{"-q", "updateinfo", "list", "--security"}
+	}
+	out, status, err = p.run(p.Timeout.Duration, p.UseSudo, p.command, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s updateinfo: %w", p.Manager, err)
+	}
+	if status != 0 {
+		return 0, 0, fmt.Errorf("%s updateinfo exited with status %d", p.Manager, status)
+	}
+	return updates, parseYumUpdateinfo(string(out)), nil
+}
+
+func (p *PackageUpdates) zypperUpdates() (int, int, error) {
+	out, status, err := p.run(p.Timeout.Duration, p.UseSudo, p.command,
+		"--non-interactive", "--quiet", "list-updates")
+	if err != nil {
+		return 0, 0, fmt.Errorf("zypper list-updates: %w", err)
+	}
+	if status != 0 {
+		return 0, 0, fmt.Errorf("zypper list-updates exited with status %d", status)
+	}
+	updates := parseZypperTable(string(out))
+
+	out, status, err = p.run(p.Timeout.Duration, p.UseSudo, p.command,
+		"--non-interactive", "--quiet", "list-patches", "--category", "security")
+	if err != nil {
+		return 0, 0, fmt.Errorf("zypper list-patches: %w", err)
+	}
+	// 100 and 101 report needed patches and needed security patches
+	if status != 0 && status != 100 && status != 101 {
+		return 0, 0, fmt.Errorf("zypper list-patches exited with status %d", status)
+	}
+	return updates, parseZypperTable(string(out)), nil
+}
+
+// rebootRequired checks the reboot flag files, and needs-restarting on yum
+// and dnf systems, it returns false when there is no way to tell
+func (p *PackageUpdates) rebootRequired() (int, bool) {
+	for _, file := range p.rebootFiles {
+		if _, err := os.Stat(file); err == nil {
+			return 1, true
+		}
+	}
+
+	switch p.Manager {
+	case managerDnf, managerYum:
+		path, err := p.lookPath("needs-restarting")
+		if err != nil {
+			return 0, false
+		}
+		// needs-restarting -r exits with 1 when a reboot is required
+		_, status, err := p.run(p.Timeout.Duration, p.UseSudo, path, "-r")
+		if err != nil || status > 1 {
+			return 0, false
+		}
+		return status, true
+	case managerApt, managerZypper:
+		return 0, true
+	}
+	return 0, false
+}
+
+// parseApt counts the Inst lines of a simulated upgrade, ie:
+//
+//	Inst libssl1.1 [1.1.1d-0+deb10u3] (1.1.1d-0+deb10u4 Debian-Security:10/stable [amd64])
+func parseApt(out string) (int, int) {
+	var updates, security int
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(line, "Inst ") {
+			continue
+		}
+		updates++
+		if strings.Contains(strings.ToLower(line), "security") {
+			security++
+		}
+	}
+	return updates, security
+}
+
+// parseYumCheckUpdate counts the name.arch lines of check-update, a long
+// name wraps the version and repository to the next, indented, line
+func parseYumCheckUpdate(out string) int {
+	var updates int
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "Obsoleting Packages") {
+			break
+		}
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if strings.Contains(fields[0], ".") && (len(fields) == 1 || len(fields) == 3) {
+			updates++
+		}
+	}
+	return updates
+}
+
+// parseYumUpdateinfo counts the packages in the advisory list, a package
+// fixed by more than one advisory is listed once per advisory, ie:
+//
+//	RHSA-2020:4076 Important/Sec. nss-3.53.1-3.el7_9.x86_64
+func parseYumUpdateinfo(out string) int {
+	pkgs := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		pkgs[fields[2]] = true
+	}
+	return len(pkgs)
+}
+
+// parseZypperTable counts the rows of a zypper table, ie:
+//
+//	S | Repository | Name    | Current Version | Available Version | Arch
+//	--+------------+---------+-----------------+-------------------+-------
+//	v | Updates    | openssl | 1.1.1d-2.20     | 1.1.1d-2.24       | x86_64
+func parseZypperTable(out string) int {
+	var rows int
+	var header bool
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "-") && strings.Contains(line, "+") {
+			// the separator follows the header
+			header = true
+			continue
+		}
+		if header && strings.Contains(line, "|") {
+			rows++
+		}
+	}
+	return rows
+}
+
+func runCommand(timeout time.Duration, useSudo bool, name string, args ...string) ([]byte, int, error) {
+	if useSudo {
+		args = append([]string{"-n", name}, args...)
+		name = "sudo"
+	}
+	cmd := exec.Command(name, args...)
+	out, err := internal.StdOutputTimeout(cmd, timeout)
+	if err != nil {
+		// a command killed by the timeout has no exit status
+		if status, ok := internal.ExitStatus(err); ok && status >= 0 {
+			return out, status, nil
+		}
+		return nil, 0, fmt.Errorf("run %s: %w", strings.Join(cmd.Args, " "), err)
+	}
+	return out, 0, nil
+}
+
+func init() {
+	inputs.Add("package_updates", func() cua.Input {
+		return &PackageUpdates{}
+	})
+}
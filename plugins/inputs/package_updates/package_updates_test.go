@@ -0,0 +1,186 @@
+package packageupdates
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const aptOutput = `NOTE: This is only a simulation!
+      apt-get needs root privileges for real execution.
+Reading package lists...
+Building dependency tree...
+Calculating upgrade...
+The following packages will be upgraded:
+  libssl1.1 openssl tzdata
+3 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.
+Inst libssl1.1 [1.1.1d-0+deb10u3] (1.1.1d-0+deb10u4 Debian-Security:10/stable [amd64])
+Inst openssl [1.1.1d-0+deb10u3] (1.1.1d-0+deb10u4 Debian-Security:10/stable [amd64])
+Inst tzdata [2020a-0+deb10u1] (2020d-0+deb10u1 Debian:10.6/stable [all])
+Conf libssl1.1 (1.1.1d-0+deb10u4 Debian-Security:10/stable [amd64])
+Conf openssl (1.1.1d-0+deb10u4 Debian-Security:10/stable [amd64])
+Conf tzdata (2020d-0+deb10u1 Debian:10.6/stable [all])
+`
+
+const yumCheckUpdateOutput = `
+kernel.x86_64                          3.10.0-1160.2.1.el7           updates
+nss.x86_64                             3.53.1-3.el7_9                updates
+python-perf-debuginfo-common-extra-long-name.x86_64
+                                       3.10.0-1160.2.1.el7           updates
+Obsoleting Packages
+grub2.x86_64                           1:2.02-0.86.el7               updates
+    grub2.x86_64                       1:2.02-0.81.el7               @base
+`
+
+const yumUpdateinfoOutput = `RHSA-2020:4076 Important/Sec. nss-3.53.1-3.el7_9.x86_64
+RHSA-2020:4276 Important/Sec. kernel-3.10.0-1160.2.1.el7.x86_64
+RHSA-2020:4350 Moderate/Sec.  kernel-3.10.0-1160.2.1.el7.x86_64
+`
+
+const zypperUpdatesOutput = `S | Repository | Name    | Current Version | Available Version | Arch
+--+------------+---------+-----------------+-------------------+-------
+v | Updates    | openssl | 1.1.1d-2.20     | 1.1.1d-2.24       | x86_64
+v | Updates    | vim     | 8.0.1568-5.3.1  | 8.0.1568-5.6.1    | x86_64
+`
+
+const zypperPatchesOutput = `Repository | Name                 | Category | Severity  | Interactive | Status | Summary
+-----------+----------------------+----------+-----------+-------------+--------+---------------------
+Updates    | SUSE-SLE-2020-2944   | security | important | ---         | needed | Security update for openssl
+`
+
+type fakeCommand struct {
+	out    string
+	status int
+}
+
+func fakeRunner(t *testing.T, commands map[string]fakeCommand) runner {
+	return func(timeout time.Duration, useSudo bool, name string, args ...string) ([]byte, int, error) {
+		cmd := strings.Join(append([]string{name}, args...), " ")
+		fc, ok := commands[cmd]
+		if !ok {
+			t.Fatalf("unexpected command %q", cmd)
+		}
+		return []byte(fc.out), fc.status, nil
+	}
+}
+
+func fakeLookPath(commands ...string) func(string) (string, error) {
+	return func(file string) (string, error) {
+		for _, c := range commands {
+			if c == file {
+				return file, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestInit(t *testing.T) {
+	p := &PackageUpdates{lookPath: fakeLookPath("yum", "dnf")}
+	require.NoError(t, p.Init())
+	require.Equal(t, managerDnf, p.Manager)
+
+	p = &PackageUpdates{Manager: "yum", lookPath: fakeLookPath("yum", "dnf")}
+	require.NoError(t, p.Init())
+	require.Equal(t, "yum", p.command)
+
+	p = &PackageUpdates{Manager: "zypper", lookPath: fakeLookPath("yum")}
+	require.Error(t, p.Init())
+
+	p = &PackageUpdates{Manager: "pacman", lookPath: fakeLookPath("pacman")}
+	require.Error(t, p.Init())
+
+	p = &PackageUpdates{lookPath: fakeLookPath()}
+	require.Error(t, p.Init())
+}
+
+func TestGatherApt(t *testing.T) {
+	reboot := filepath.Join(t.TempDir(), "reboot-required")
+	require.NoError(t, os.WriteFile(reboot, nil, 0600))
+
+	p := &PackageUpdates{
+		lookPath:    fakeLookPath("apt-get"),
+		rebootFiles: []string{reboot},
+		run: fakeRunner(t, map[string]fakeCommand{
+			"apt-get -s -o Debug::NoLocking=true dist-upgrade": {out: aptOutput},
+		}),
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	acc.AssertContainsTaggedFields(t, "package_updates",
+		map[string]interface{}{
+			"updates":          3,
+			"security_updates": 2,
+			"reboot_required":  1,
+		},
+		map[string]string{"manager": "apt"})
+}
+
+func TestGatherYum(t *testing.T) {
+	p := &PackageUpdates{
+		lookPath:    fakeLookPath("yum", "needs-restarting"),
+		rebootFiles: []string{},
+		run: fakeRunner(t, map[string]fakeCommand{
+			"yum -q check-update":             {out: yumCheckUpdateOutput, status: 100},
+			"yum -q updateinfo list security": {out: yumUpdateinfoOutput},
+			"needs-restarting -r":             {status: 0},
+		}),
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	acc.AssertContainsTaggedFields(t, "package_updates",
+		map[string]interface{}{
+			"updates":          3,
+			"security_updates": 2,
+			"reboot_required":  0,
+		},
+		map[string]string{"manager": "yum"})
+}
+
+func TestGatherDnfError(t *testing.T) {
+	p := &PackageUpdates{
+		lookPath:    fakeLookPath("dnf"),
+		rebootFiles: []string{},
+		run: fakeRunner(t, map[string]fakeCommand{
+			"dnf -q check-update": {status: 1},
+		}),
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, p.Gather(context.Background(), &acc))
+	require.False(t, acc.HasMeasurement("package_updates"))
+}
+
+func TestGatherZypper(t *testing.T) {
+	p := &PackageUpdates{
+		lookPath:    fakeLookPath("zypper"),
+		rebootFiles: []string{},
+		run: fakeRunner(t, map[string]fakeCommand{
+			"zypper --non-interactive --quiet list-updates":                     {out: zypperUpdatesOutput},
+			"zypper --non-interactive --quiet list-patches --category security": {out: zypperPatchesOutput, status: 101},
+		}),
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	acc.AssertContainsTaggedFields(t, "package_updates",
+		map[string]interface{}{
+			"updates":          2,
+			"security_updates": 1,
+			"reboot_required":  0,
+		},
+		map[string]string{"manager": "zypper"})
+}
@@ -7,10 +7,11 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// Create an fcgi client
-func newFcgiClient(h string, args ...interface{}) (*conn, error) {
+// Create an fcgi client, the timeout bounds both the dial and the request
+func newFcgiClient(timeout time.Duration, h string, args ...interface{}) (*conn, error) {
 	var con net.Conn
 	if len(args) != 1 {
 		return nil, fmt.Errorf("fcgi: not enough params")
@@ -20,18 +21,23 @@ func newFcgiClient(h string, args ...interface{}) (*conn, error) {
 	switch arg := args[0].(type) {
 	case int:
 		addr := h + ":" + strconv.FormatInt(int64(arg), 10)
-		con, err = net.Dial("tcp", addr)
+		con, err = net.DialTimeout("tcp", addr, timeout)
 	case string:
-		laddr := net.UnixAddr{Name: arg, Net: h}
-		con, err = net.DialUnix(h, nil, &laddr)
+		con, err = net.DialTimeout(h, arg, timeout)
 	default:
 		err = fmt.Errorf("fcgi: we only accept int (port) or string (socket) params")
 	}
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if timeout > 0 {
+		_ = con.SetDeadline(time.Now().Add(timeout))
+	}
 	fcgi := &conn{
 		rwc: con,
 	}
 
-	return fcgi, err
+	return fcgi, nil
 }
 
 func (client *conn) Request(
@@ -64,8 +70,8 @@ func (client *conn) Request(
 READ_LOOP:
 	for {
 		err1 = rec.read(client.rwc)
-		if err1 != nil && strings.Contains(err1.Error(), "use of closed network connection") {
-			if !errors.Is(err1, io.EOF) {
+		if err1 != nil {
+			if !errors.Is(err1, io.EOF) && !strings.Contains(err1.Error(), "use of closed network connection") {
 				err = err1
 			}
 			break
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
@@ -35,6 +36,8 @@ const (
 	pfSlowRequests       = "slow requests"
 )
 
+const defaultTimeout = 5 * time.Second
+
 type metric map[string]int64
 type poolStat map[string]metric
 
@@ -67,7 +70,7 @@ var sampleConfig = `
   ## urls = ["http://192.168.1.20/status", "/tmp/fpm.sock"]
   urls = ["http://localhost/status"]
 
-  ## Duration allowed to complete HTTP requests.
+  ## Duration allowed to complete HTTP and FastCGI requests.
   # timeout = "5s"
 
   ## Optional TLS Config
@@ -87,6 +90,10 @@ func (p *phpfpm) Description() string {
 }
 
 func (p *phpfpm) Init() error {
+	if p.Timeout.Duration == 0 {
+		p.Timeout.Duration = defaultTimeout
+	}
+
 	tlsCfg, err := p.ClientConfig.TLSConfig()
 	if err != nil {
 		return fmt.Errorf("TLSConfig: %w", err)
@@ -105,7 +112,7 @@ func (p *phpfpm) Init() error {
 // Returns one of the errors encountered while gather stats (if any).
 func (p *phpfpm) Gather(ctx context.Context, acc cua.Accumulator) error {
 	if len(p.Urls) == 0 {
-		return p.gatherServer("http://127.0.0.1/status", acc)
+		return p.gatherServer(ctx, "http://127.0.0.1/status", acc)
 	}
 
 	var wg sync.WaitGroup
@@ -119,7 +126,7 @@ func (p *phpfpm) Gather(ctx context.Context, acc cua.Accumulator) error {
 		wg.Add(1)
 		go func(serv string) {
 			defer wg.Done()
-			acc.AddError(p.gatherServer(serv, acc))
+			acc.AddError(p.gatherServer(ctx, serv, acc))
 		}(serv)
 	}
 
@@ -129,9 +136,9 @@ func (p *phpfpm) Gather(ctx context.Context, acc cua.Accumulator) error {
 }
 
 // Request status page to get stat raw data and import it
-func (p *phpfpm) gatherServer(addr string, acc cua.Accumulator) error {
+func (p *phpfpm) gatherServer(ctx context.Context, addr string, acc cua.Accumulator) error {
 	if strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://") {
-		return p.gatherHTTP(addr, acc)
+		return p.gatherHTTP(ctx, addr, acc)
 	}
 
 	var (
@@ -149,7 +156,7 @@ func (p *phpfpm) gatherServer(addr string, acc cua.Accumulator) error {
 		socketAddr := strings.Split(u.Host, ":")
 		fcgiIP := socketAddr[0]
 		fcgiPort, _ := strconv.Atoi(socketAddr[1])
-		fcgi, err = newFcgiClient(fcgiIP, fcgiPort)
+		fcgi, err = newFcgiClient(p.Timeout.Duration, fcgiIP, fcgiPort)
 		if err != nil {
 			return err
 		}
@@ -163,7 +170,7 @@ func (p *phpfpm) gatherServer(addr string, acc cua.Accumulator) error {
 		if statusPath == "" {
 			statusPath = "status"
 		}
-		fcgi, err = newFcgiClient("unix", socketPath)
+		fcgi, err = newFcgiClient(p.Timeout.Duration, "unix", socketPath)
 	}
 
 	if err != nil {
@@ -193,13 +200,13 @@ func (p *phpfpm) gatherFcgi(fcgi *conn, statusPath string, acc cua.Accumulator,
 }
 
 // Gather stat using http protocol
-func (p *phpfpm) gatherHTTP(addr string, acc cua.Accumulator) error {
+func (p *phpfpm) gatherHTTP(ctx context.Context, addr string, acc cua.Accumulator) error {
 	u, err := url.Parse(addr)
 	if err != nil {
 		return fmt.Errorf("unable parse server address '%s': %w", addr, err)
 	}
 
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
 		return fmt.Errorf("unable to create new request '%s': %w", addr, err)
 	}
@@ -211,7 +218,7 @@ func (p *phpfpm) gatherHTTP(addr string, acc cua.Accumulator) error {
 	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
-		return fmt.Errorf("unable to get valid stat result from '%s': %w", addr, err)
+		return fmt.Errorf("unable to get valid stat result from '%s': %s", addr, res.Status)
 	}
 
 	importMetric(res.Body, acc, addr)
@@ -9,12 +9,15 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/fcgi"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/internal"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -315,6 +318,36 @@ func TestPhpFpmGeneratesMetrics_Throw_Error_When_Fpm_Status_Is_Not_Responding(t
 	assert.Contains(t, err.Error(), `lookup aninvalidone`)
 }
 
+func TestPhpFpmGeneratesMetrics_Throw_Error_When_Fcgi_Is_Not_Responding(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer tcp.Close()
+
+	// accept the connection and never answer
+	go func() {
+		conn, err := tcp.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	r := &phpfpm{
+		Urls:    []string{"fcgi://" + tcp.Addr().String() + "/status"},
+		Timeout: internal.Duration{Duration: 100 * time.Millisecond},
+	}
+
+	err = r.Init()
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+
+	err = acc.GatherError(r.Gather)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "i/o timeout")
+}
+
 func TestPhpFpmGeneratesMetrics_Throw_Error_When_Socket_Path_Is_Invalid(t *testing.T) {
 	r := &phpfpm{
 		Urls: []string{"/tmp/invalid.sock"},
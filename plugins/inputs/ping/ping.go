@@ -16,6 +16,7 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
 	circmgr "github.com/circonus-labs/circonus-unified-agent/internal/circonus"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/targets"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/go-trapmetrics"
 	"github.com/go-ping/ping"
@@ -56,6 +57,7 @@ type Ping struct {
 	calcInterval      time.Duration     // Pre-calculated interval
 	DirectMetrics     bool              `toml:"direct_metrics"` // enable direct metrics
 	IPv6              bool              // Whether to resolve addresses using ipv6 or not.
+	targets.Source
 }
 
 func (*Ping) Description() string {
@@ -66,6 +68,15 @@ const sampleConfig = `
   ## Hosts to send ping packets to.
   urls = ["example.org"]
 
+  ## Optional source of additional hosts, refreshed periodically, as a JSON
+  ## list of strings read from a file:// path, an http(s):// URL or the raw
+  ## value of a Consul KV key with consul://host:8500/path/of/key.
+  # targets_source = "consul://127.0.0.1:8500/probes/ping"
+  ## How often the hosts are read again from the source.
+  # targets_refresh_interval = "5m"
+  ## Bearer token of http(s) sources, ACL token of Consul sources.
+  # targets_token = ""
+
   ## Method used for sending pings, can be either "exec" or "native".  When set
   ## to "exec" the systems ping command will be executed.  When set to "native"
   ## the plugin will send pings directly.
@@ -116,7 +127,12 @@ func (*Ping) SampleConfig() string {
 }
 
 func (p *Ping) Gather(ctx context.Context, acc cua.Accumulator) error {
-	for _, host := range p.Urls {
+	hosts, err := p.Source.Targets(ctx, p.Urls)
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	for _, host := range hosts {
 		p.wg.Add(1)
 		go func(host string) {
 			defer p.wg.Done()
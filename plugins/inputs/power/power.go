@@ -0,0 +1,239 @@
+//go:build linux
+// +build linux
+
+package power
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+// Power reports the batteries and AC adapters of the power supply class and
+// the RAPL energy counters of the powercap class
+type Power struct {
+	Log cua.Logger `toml:"-"`
+
+	PowerSupply bool `toml:"power_supply"`
+	RAPL        bool `toml:"rapl"`
+
+	sysDir string
+	zones  map[string]*raplZone
+}
+
+// raplZone is the energy accumulated by a RAPL zone across the wraparounds
+// of its counter
+type raplZone struct {
+	lastUJ   uint64
+	lastTime time.Time
+	totalUJ  uint64
+}
+
+const sampleConfig = `
+  ## Report the batteries and AC adapters of /sys/class/power_supply
+  # power_supply = true
+
+  ## Report the RAPL energy counters of /sys/class/powercap, reading the
+  ## counters requires root on recent kernels
+  # rapl = true
+`
+
+func (*Power) Description() string {
+	return "Read battery, AC adapter and RAPL energy metrics from sysfs"
+}
+
+func (*Power) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Power) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if p.PowerSupply {
+		if err := p.gatherPowerSupplies(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if p.RAPL {
+		if err := p.gatherRAPL(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+func (p *Power) gatherPowerSupplies(acc cua.Accumulator) error {
+	dirs, err := filepath.Glob(filepath.Join(p.sysDir, "class", "power_supply", "*"))
+	if err != nil {
+		return fmt.Errorf("power_supply glob: %w", err)
+	}
+
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+		switch readString(dir, "type") {
+		case "Battery":
+			// peripherals, e.g. a wireless mouse, report their battery too
+			if readString(dir, "scope") == "Device" {
+				continue
+			}
+			fields := batteryFields(dir)
+			if len(fields) == 0 {
+				continue
+			}
+			// a field rather than a tag, the status changes on every
+			// charge and discharge
+			if status := readString(dir, "status"); status != "" {
+				fields["status"] = status
+			}
+			acc.AddFields("power_battery", fields, map[string]string{"name": name})
+		case "Mains":
+			if online, ok := readUint(dir, "online"); ok {
+				acc.AddFields("power_ac",
+					map[string]interface{}{"online": online == 1},
+					map[string]string{"name": name},
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// batteryFields returns the fields of a battery, the drivers report either
+// the energy (µWh) or the charge (µAh) of the battery
+func batteryFields(dir string) map[string]interface{} {
+	fields := make(map[string]interface{})
+
+	if capacity, ok := readUint(dir, "capacity"); ok {
+		fields["capacity_percent"] = capacity
+	}
+	if cycles, ok := readUint(dir, "cycle_count"); ok && cycles > 0 {
+		fields["cycle_count"] = cycles
+	}
+
+	full, okFull := readUint(dir, "energy_full")
+	design, okDesign := readUint(dir, "energy_full_design")
+	if !okFull || !okDesign {
+		full, okFull = readUint(dir, "charge_full")
+		design, okDesign = readUint(dir, "charge_full_design")
+	}
+	if okFull && okDesign && design > 0 {
+		fields["health_percent"] = float64(full) / float64(design) * 100
+	}
+
+	if now, ok := readUint(dir, "energy_now"); ok {
+		fields["energy_wh"] = float64(now) / 1e6
+	}
+	if power, ok := readUint(dir, "power_now"); ok {
+		fields["power_w"] = float64(power) / 1e6
+	} else if current, ok := readUint(dir, "current_now"); ok {
+		if voltage, ok := readUint(dir, "voltage_now"); ok {
+			fields["power_w"] = float64(current) / 1e6 * float64(voltage) / 1e6
+		}
+	}
+
+	return fields
+}
+
+func (p *Power) gatherRAPL(acc cua.Accumulator) error {
+	// top level zones and their subzones, e.g. intel-rapl:0 and intel-rapl:0:0
+	dirs, err := filepath.Glob(filepath.Join(p.sysDir, "class", "powercap", "intel-rapl:*"))
+	if err != nil {
+		return fmt.Errorf("powercap glob: %w", err)
+	}
+	if p.zones == nil {
+		p.zones = make(map[string]*raplZone)
+	}
+
+	now := time.Now()
+	for _, dir := range dirs {
+		id := filepath.Base(dir)
+		energy, err := readUintErr(dir, "energy_uj")
+		if err != nil {
+			acc.AddError(fmt.Errorf("rapl zone %s: %w", id, err))
+			continue
+		}
+		maxRange, _ := readUint(dir, "max_energy_range_uj")
+
+		fields := make(map[string]interface{})
+		zone, ok := p.zones[id]
+		if !ok {
+			zone = &raplZone{}
+			p.zones[id] = zone
+		} else {
+			delta := energy - zone.lastUJ
+			if energy < zone.lastUJ {
+				// the counter wrapped around, the energy of the interval
+				// is unknown without the range of the counter
+				if maxRange < zone.lastUJ {
+					zone.lastUJ = energy
+					zone.lastTime = now
+					continue
+				}
+				delta = maxRange - zone.lastUJ + energy
+			}
+			zone.totalUJ += delta
+			if elapsed := now.Sub(zone.lastTime).Seconds(); elapsed > 0 {
+				fields["power_w"] = float64(delta) / 1e6 / elapsed
+			}
+		}
+		zone.lastUJ = energy
+		zone.lastTime = now
+		fields["energy_j"] = float64(zone.totalUJ) / 1e6
+
+		tags := map[string]string{"zone": id}
+		if name := readString(dir, "name"); name != "" {
+			tags["name"] = name
+		}
+		acc.AddFields("power_rapl", fields, tags, now)
+	}
+
+	return nil
+}
+
+func readString(dir, file string) string {
+	b, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func readUint(dir, file string) (uint64, bool) {
+	v, err := readUintErr(dir, file)
+	return v, err == nil
+}
+
+func readUintErr(dir, file string) (uint64, error) {
+	b, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return 0, fmt.Errorf("read: %w", err)
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", file, err)
+	}
+	return v, nil
+}
+
+func getHostSys() string {
+	if sysPath := os.Getenv("HOST_SYS"); sysPath != "" {
+		return sysPath
+	}
+	return "/sys"
+}
+
+func init() {
+	inputs.Add("power", func() cua.Input {
+		return &Power{
+			PowerSupply: true,
+			RAPL:        true,
+			sysDir:      getHostSys(),
+		}
+	})
+}
@@ -0,0 +1,136 @@
+//go:build linux
+// +build linux
+
+package power
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0600))
+	}
+}
+
+func TestGatherPowerSupply(t *testing.T) {
+	sys := t.TempDir()
+	writeFiles(t, filepath.Join(sys, "class", "power_supply", "BAT0"), map[string]string{
+		"type":               "Battery",
+		"status":             "Discharging",
+		"capacity":           "87",
+		"cycle_count":        "312",
+		"energy_full":        "45000000",
+		"energy_full_design": "50000000",
+		"energy_now":         "39150000",
+		"power_now":          "7500000",
+	})
+	writeFiles(t, filepath.Join(sys, "class", "power_supply", "BAT1"), map[string]string{
+		"type":               "Battery",
+		"status":             "Full",
+		"capacity":           "100",
+		"cycle_count":        "0",
+		"charge_full":        "2000000",
+		"charge_full_design": "2500000",
+	})
+	writeFiles(t, filepath.Join(sys, "class", "power_supply", "hidpp_battery_0"), map[string]string{
+		"type":     "Battery",
+		"scope":    "Device",
+		"capacity": "50",
+	})
+	writeFiles(t, filepath.Join(sys, "class", "power_supply", "AC"), map[string]string{
+		"type":   "Mains",
+		"online": "0",
+	})
+
+	p := &Power{PowerSupply: true, sysDir: sys}
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 3)
+
+	acc.AssertContainsTaggedFields(t, "power_battery",
+		map[string]interface{}{
+			"capacity_percent": uint64(87),
+			"cycle_count":      uint64(312),
+			"health_percent":   90.0,
+			"energy_wh":        39.15,
+			"power_w":          7.5,
+			"status":           "Discharging",
+		},
+		map[string]string{"name": "BAT0"},
+	)
+	acc.AssertContainsTaggedFields(t, "power_battery",
+		map[string]interface{}{
+			"capacity_percent": uint64(100),
+			"health_percent":   80.0,
+			"status":           "Full",
+		},
+		map[string]string{"name": "BAT1"},
+	)
+	acc.AssertContainsTaggedFields(t, "power_ac",
+		map[string]interface{}{"online": false},
+		map[string]string{"name": "AC"},
+	)
+}
+
+func TestGatherRAPL(t *testing.T) {
+	sys := t.TempDir()
+	pkg := filepath.Join(sys, "class", "powercap", "intel-rapl:0")
+	writeFiles(t, pkg, map[string]string{
+		"name":                "package-0",
+		"energy_uj":           "262142000000",
+		"max_energy_range_uj": "262143328850",
+	})
+
+	p := &Power{RAPL: true, sysDir: sys}
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	acc.AssertContainsTaggedFields(t, "power_rapl",
+		map[string]interface{}{"energy_j": 0.0},
+		map[string]string{"zone": "intel-rapl:0", "name": "package-0"},
+	)
+
+	// wraparound of the counter
+	writeFiles(t, pkg, map[string]string{"energy_uj": "671150"})
+	acc.ClearMetrics()
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	m, ok := acc.Get("power_rapl")
+	require.True(t, ok)
+	require.InDelta(t, 2.0, m.Fields["energy_j"], 1e-6)
+	require.Contains(t, m.Fields, "power_w")
+}
+
+func TestGatherRAPLUnknownRange(t *testing.T) {
+	sys := t.TempDir()
+	pkg := filepath.Join(sys, "class", "powercap", "intel-rapl:0")
+	writeFiles(t, pkg, map[string]string{"energy_uj": "262142000000"})
+
+	p := &Power{RAPL: true, sysDir: sys}
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(context.Background(), &acc))
+
+	// the wraparound is skipped without max_energy_range_uj
+	writeFiles(t, pkg, map[string]string{"energy_uj": "671150"})
+	acc.ClearMetrics()
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Empty(t, acc.Metrics)
+
+	writeFiles(t, pkg, map[string]string{"energy_uj": "1671150"})
+	acc.ClearMetrics()
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	m, ok := acc.Get("power_rapl")
+	require.True(t, ok)
+	require.InDelta(t, 1.0, m.Fields["energy_j"], 1e-6)
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/metric"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/histogram"
 	"github.com/matttproud/golang_protobuf_extensions/pbutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
@@ -24,6 +25,12 @@ import (
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
 func ParseV2(buf []byte, header http.Header) ([]cua.Metric, error) {
+	return parseV2(buf, header, false)
+}
+
+// parseV2 is ParseV2, histograms are converted to Circonus histograms when
+// circHistograms is set
+func parseV2(buf []byte, header http.Header, circHistograms bool) ([]cua.Metric, error) {
 	var metrics []cua.Metric
 	var parser expfmt.TextParser
 	// parse even if the buffer begins with a newline
@@ -71,6 +78,18 @@ func ParseV2(buf []byte, header http.Header) ([]cua.Metric, error) {
 				metrics = append(metrics, agentMetrics...)
 			case dto.MetricType_HISTOGRAM:
 				// histogram metric
+				if circHistograms {
+					fields := map[string]interface{}{
+						metricName + "_count": float64(m.GetHistogram().GetSampleCount()),
+						metricName + "_sum":   m.GetHistogram().GetSampleSum(),
+					}
+					histTags := map[string]string{"input_metric_group": "prometheus"}
+					for k, v := range tags {
+						histTags[k] = v
+					}
+					metrics = append(metrics, makeCircHistogram(m, "prometheus", fields, metricName, histTags, now)...)
+					continue
+				}
 				agentMetrics := makeBucketsV2(m, tags, metricName, mf.GetType(), now)
 				metrics = append(metrics, agentMetrics...)
 			default:
@@ -94,7 +113,7 @@ func ParseV2(buf []byte, header http.Header) ([]cua.Metric, error) {
 		}
 	}
 
-	return metrics, fmt.Errorf("metric new: %w", err)
+	return metrics, nil
 }
 
 // Get Quantiles for summary metric & Buckets for histogram
@@ -164,6 +183,12 @@ func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, met
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
 func Parse(buf []byte, header http.Header) ([]cua.Metric, error) {
+	return parse(buf, header, false)
+}
+
+// parse is Parse, histograms are converted to Circonus histograms when
+// circHistograms is set
+func parse(buf []byte, header http.Header, circHistograms bool) ([]cua.Metric, error) {
 	var metrics []cua.Metric
 	var parser expfmt.TextParser
 	// parse even if the buffer begins with a newline
@@ -213,6 +238,14 @@ func Parse(buf []byte, header http.Header) ([]cua.Metric, error) {
 				fields["sum"] = m.GetSummary().GetSampleSum()
 			case dto.MetricType_HISTOGRAM:
 				// histogram metric
+				if circHistograms {
+					fields = map[string]interface{}{
+						"count": float64(m.GetHistogram().GetSampleCount()),
+						"sum":   m.GetHistogram().GetSampleSum(),
+					}
+					metrics = append(metrics, makeCircHistogram(m, metricName, fields, metricName, tags, now)...)
+					continue
+				}
 				fields = makeBuckets(m)
 				fields["count"] = float64(m.GetHistogram().GetSampleCount())
 				fields["sum"] = m.GetHistogram().GetSampleSum()
@@ -236,7 +269,7 @@ func Parse(buf []byte, header http.Header) ([]cua.Metric, error) {
 		}
 	}
 
-	return metrics, fmt.Errorf("metric new: %w", err)
+	return metrics, nil
 }
 
 func valueType(mt dto.MetricType) cua.ValueType {
@@ -274,6 +307,38 @@ func makeBuckets(m *dto.Metric) map[string]interface{} {
 	return fields
 }
 
+// makeCircHistogram returns the count and sum of a histogram metric as a
+// counter and its buckets as a Circonus cumulative histogram, the buckets of
+// Prometheus histograms grow over time like counters
+func makeCircHistogram(m *dto.Metric, name string, fields map[string]interface{}, histName string, histTags map[string]string, now time.Time) []cua.Metric {
+	var metrics []cua.Metric
+	t := now
+	if m.TimestampMs != nil && *m.TimestampMs > 0 {
+		t = time.Unix(0, *m.TimestampMs*1000000)
+	}
+
+	met, err := metric.New(name, makeLabels(m), fields, t, cua.Counter)
+	if err == nil {
+		metrics = append(metrics, met)
+	}
+
+	buckets := m.GetHistogram().GetBucket()
+	bounds := make([]float64, len(buckets))
+	counts := make([]uint64, len(buckets))
+	for i, b := range buckets {
+		bounds[i] = b.GetUpperBound()
+		counts[i] = b.GetCumulativeCount()
+	}
+	histFields := histogram.FromCumulative(bounds, counts)
+	if len(histFields) > 0 {
+		histMetric, err := metric.New(histName, histTags, histFields, t, cua.CumulativeHistogram)
+		if err == nil {
+			metrics = append(metrics, histMetric)
+		}
+	}
+	return metrics
+}
+
 // Get labels from metric
 func makeLabels(m *dto.Metric) map[string]string {
 	result := map[string]string{}
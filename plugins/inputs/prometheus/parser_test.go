@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -164,3 +165,37 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func TestParseCircHistograms(t *testing.T) {
+	expected := map[string]interface{}{
+		"1.250000e+05": int64(1994),
+		"2.500000e+05": int64(3),
+		"5.000000e+05": int64(3),
+		"1.000000e+06": int64(5),
+		"2.000000e+06": int64(7),
+		"4.000000e+06": int64(5),
+		"8.000000e+06": int64(8),
+	}
+
+	metrics, err := parse([]byte(validUniqueHistogram), http.Header{}, true)
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, cua.Counter, metrics[0].Type())
+	assert.Equal(t, map[string]interface{}{"count": 2025.0, "sum": 1.02726334e+08}, metrics[0].Fields())
+	assert.Equal(t, cua.CumulativeHistogram, metrics[1].Type())
+	assert.Equal(t, "apiserver_request_latencies", metrics[1].Name())
+	assert.Equal(t, expected, metrics[1].Fields())
+	assert.Equal(t, map[string]string{"verb": "POST", "resource": "bindings"}, metrics[1].Tags())
+
+	metrics, err = parseV2([]byte(validUniqueHistogram), http.Header{}, true)
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, "prometheus", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"apiserver_request_latencies_count": 2025.0,
+		"apiserver_request_latencies_sum":   1.02726334e+08,
+	}, metrics[0].Fields())
+	assert.Equal(t, "apiserver_request_latencies", metrics[1].Name())
+	assert.Equal(t, expected, metrics[1].Fields())
+	assert.Equal(t, "prometheus", metrics[1].Tags()["input_metric_group"])
+}
@@ -48,6 +48,9 @@ type Prometheus struct {
 
 	MetricVersion int `toml:"metric_version"`
 
+	// Convert histograms to Circonus histograms
+	CirconusHistograms bool `toml:"circonus_histograms"`
+
 	URLTag string `toml:"url_tag"`
 
 	tls.ClientConfig
@@ -78,6 +81,11 @@ var sampleConfig = `
   ##            metric_version = 2; recommended version
   # metric_version = 1
 
+  ## Convert the buckets of histograms to Circonus histograms instead of one
+  ## field or metric per bucket, which keeps the distribution when sent to
+  ## Circonus.  The count and sum are still gathered as counters.
+  # circonus_histograms = false
+
   ## Url tag name (tag containing scrapped url. optional, default is "url")
   # url_tag = "scrapeUrl"
 
@@ -331,9 +339,9 @@ func (p *Prometheus) gatherURL(u URLAndAddress, acc cua.Accumulator) error {
 	}
 
 	if p.MetricVersion == 2 {
-		metrics, err = ParseV2(body, resp.Header)
+		metrics, err = parseV2(body, resp.Header, p.CirconusHistograms)
 	} else {
-		metrics, err = Parse(body, resp.Header)
+		metrics, err = parse(body, resp.Header, p.CirconusHistograms)
 	}
 
 	if err != nil {
@@ -363,6 +371,8 @@ func (p *Prometheus) gatherURL(u URLAndAddress, acc cua.Accumulator) error {
 			acc.AddSummary(metric.Name(), metric.Fields(), tags, metric.Time())
 		case cua.Histogram:
 			acc.AddHistogram(metric.Name(), metric.Fields(), tags, metric.Time())
+		case cua.CumulativeHistogram:
+			acc.AddCumulativeHistogram(metric.Name(), metric.Fields(), tags, metric.Time())
 		default:
 			acc.AddFields(metric.Name(), metric.Fields(), tags, metric.Time())
 		}
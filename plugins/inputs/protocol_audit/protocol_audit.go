@@ -0,0 +1,448 @@
+package protocolaudit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/targets"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+type ResultType uint64
+
+const (
+	Success          ResultType = 0
+	Timeout          ResultType = 1
+	ConnectionFailed ResultType = 2
+	ReadFailed       ResultType = 3
+	HandshakeFailed  ResultType = 4
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+
+	// lines an SSH server may send before its version, RFC4253 section 4.2
+	maxSSHPreambleLines = 20
+)
+
+var defaultPorts = map[string]string{
+	"ssh":  "22",
+	"smtp": "25",
+	"tls":  "443",
+}
+
+// tlsVersions are probed oldest first, TLS 1.0 and 1.1 are deprecated by
+// RFC8996
+var tlsVersions = []struct {
+	field string
+	name  string
+	id    uint16
+	weak  bool
+}{
+	{field: "tls_1_0", name: "1.0", id: tls.VersionTLS10, weak: true},
+	{field: "tls_1_1", name: "1.1", id: tls.VersionTLS11, weak: true},
+	{field: "tls_1_2", name: "1.2", id: tls.VersionTLS12},
+	{field: "tls_1_3", name: "1.3", id: tls.VersionTLS13},
+}
+
+// ProtocolAudit records the banners and supported TLS versions and cipher
+// suites of services
+type ProtocolAudit struct {
+	Targets          []string          `toml:"targets"`
+	Timeout          internal.Duration `toml:"timeout"`
+	EnumerateCiphers bool              `toml:"enumerate_ciphers"`
+	targets.Source
+
+	Log cua.Logger `toml:"-"`
+}
+
+var sampleConfig = `
+  ## Services to audit, the scheme selects the protocol:
+  ##   ssh://host:22   SSH version banner
+  ##   smtp://host:25  SMTP greeting and STARTTLS, with the TLS audit when
+  ##                   STARTTLS is offered
+  ##   tls://host:443  supported TLS versions and cipher suites
+  targets = ["ssh://localhost:22"]
+
+  ## Optional source of additional targets, read periodically as a JSON
+  ## list of strings from a file:// path, an http(s):// URL or the raw
+  ## value of a Consul KV key with consul://host:8500/path/of/key.
+  # targets_source = "consul://127.0.0.1:8500/probes/protocol_audit"
+  ## How often the targets are read again from the source.
+  # targets_refresh_interval = "5m"
+  ## Bearer token of http(s) sources, ACL token of Consul sources.
+  # targets_token = ""
+
+  ## Timeout of each connection, including the reads and the handshakes
+  # timeout = "5s"
+
+  ## Test every TLS 1.0-1.2 cipher suite known to the agent with a handshake
+  ## of its own, up to about 30 connections per target and gather.
+  # enumerate_ciphers = false
+`
+
+func (*ProtocolAudit) SampleConfig() string {
+	return sampleConfig
+}
+
+func (*ProtocolAudit) Description() string {
+	return "Record the protocol banners, TLS versions and cipher suites of services"
+}
+
+func (p *ProtocolAudit) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if p.Timeout.Duration == 0 {
+		p.Timeout.Duration = defaultTimeout
+	}
+
+	list, err := p.Source.Targets(ctx, p.Targets)
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range list {
+		u, err := parseTarget(target)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		wg.Add(1)
+		go func(u *url.URL) {
+			defer wg.Done()
+			p.audit(u, acc)
+		}(u)
+	}
+	wg.Wait()
+	return nil
+}
+
+func parseTarget(target string) (*url.URL, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("parse target (%s): %w", target, err)
+	}
+	port, ok := defaultPorts[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported scheme %q in target %s", u.Scheme, target)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("missing host in target %s", target)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, nil
+}
+
+func (p *ProtocolAudit) audit(u *url.URL, acc cua.Accumulator) {
+	tags := map[string]string{
+		"server":   u.Hostname(),
+		"port":     u.Port(),
+		"protocol": u.Scheme,
+	}
+	fields := make(map[string]interface{})
+
+	var result ResultType
+	switch u.Scheme {
+	case "ssh":
+		result = p.auditSSH(u.Host, fields)
+	case "smtp":
+		result = p.auditSMTP(u.Host, u.Hostname(), fields)
+	case "tls":
+		result = p.auditTLS(u.Host, u.Hostname(), nil, fields)
+	}
+
+	setResult(result, fields, tags)
+	acc.AddFields("protocol_audit", fields, tags)
+}
+
+func (p *ProtocolAudit) dial(address string) (net.Conn, ResultType) {
+	conn, err := net.DialTimeout("tcp", address, p.Timeout.Duration)
+	if err != nil {
+		var nerr net.Error
+		if errors.As(err, &nerr) && nerr.Timeout() {
+			return nil, Timeout
+		}
+		return nil, ConnectionFailed
+	}
+	_ = conn.SetDeadline(time.Now().Add(p.Timeout.Duration))
+	return conn, Success
+}
+
+// auditSSH reads the version of the server, SSH-protoversion-softwareversion
+// followed by optional comments, protocol versions other than 2.0 allow
+// SSH 1 clients
+func (p *ProtocolAudit) auditSSH(address string, fields map[string]interface{}) ResultType {
+	start := time.Now()
+	conn, result := p.dial(address)
+	if result != Success {
+		return result
+	}
+	defer conn.Close()
+
+	tp := textproto.NewReader(bufio.NewReader(conn))
+	for i := 0; i < maxSSHPreambleLines; i++ {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return ReadFailed
+		}
+		if !strings.HasPrefix(line, "SSH-") {
+			continue
+		}
+		fields["response_time"] = time.Since(start).Seconds()
+		fields["banner"] = line
+
+		version := strings.SplitN(strings.SplitN(line, " ", 2)[0], "-", 3)
+		if len(version) < 3 {
+			return ReadFailed
+		}
+		if v, err := strconv.ParseFloat(version[1], 64); err == nil {
+			fields["ssh_protocol_version"] = v
+		}
+		fields["ssh_software"] = version[2]
+		fields["weak_protocol"] = boolToInt(version[1] != "2.0")
+		return Success
+	}
+	return ReadFailed
+}
+
+// auditSMTP reads the greeting of the server and checks whether STARTTLS is
+// offered, servers without STARTTLS only accept plain text mails
+func (p *ProtocolAudit) auditSMTP(address, host string, fields map[string]interface{}) ResultType {
+	start := time.Now()
+	conn, result := p.dial(address)
+	if result != Success {
+		return result
+	}
+	tp := textproto.NewConn(conn)
+	defer tp.Close()
+
+	code, msg, err := tp.ReadResponse(220)
+	if err != nil {
+		return ReadFailed
+	}
+	fields["response_time"] = time.Since(start).Seconds()
+	fields["banner"] = strings.SplitN(msg, "\n", 2)[0]
+	fields["smtp_code"] = code
+
+	id, err := tp.Cmd("EHLO %s", ehloName())
+	if err != nil {
+		return ReadFailed
+	}
+	tp.StartResponse(id)
+	_, msg, err = tp.ReadResponse(250)
+	tp.EndResponse(id)
+	if err != nil {
+		return ReadFailed
+	}
+	starttls := false
+	for _, ext := range strings.Split(msg, "\n") {
+		if strings.EqualFold(strings.TrimSpace(ext), "STARTTLS") {
+			starttls = true
+		}
+	}
+	_, _ = tp.Cmd("QUIT")
+
+	fields["smtp_starttls"] = boolToInt(starttls)
+	if !starttls {
+		fields["weak_protocol"] = 1
+		return Success
+	}
+
+	return p.auditTLS(address, host, starttlsSMTP, fields)
+}
+
+// starttlsSMTP upgrades a new SMTP connection to TLS
+func starttlsSMTP(conn net.Conn) error {
+	tp := textproto.NewConn(conn)
+	if _, _, err := tp.ReadResponse(220); err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if _, err := tp.Cmd("EHLO %s", ehloName()); err != nil {
+		return fmt.Errorf("EHLO: %w", err)
+	}
+	if _, _, err := tp.ReadResponse(250); err != nil {
+		return fmt.Errorf("EHLO: %w", err)
+	}
+	if _, err := tp.Cmd("STARTTLS"); err != nil {
+		return fmt.Errorf("STARTTLS: %w", err)
+	}
+	if _, _, err := tp.ReadResponse(220); err != nil {
+		return fmt.Errorf("STARTTLS: %w", err)
+	}
+	return nil
+}
+
+// auditTLS tries a handshake with each TLS version and optionally each
+// cipher suite, prepare upgrades plain text protocols to TLS
+func (p *ProtocolAudit) auditTLS(address, host string, prepare func(net.Conn) error, fields map[string]interface{}) ResultType {
+	start := time.Now()
+	var supported *tls.ConnectionState
+	weakProtocol := false
+	for _, version := range tlsVersions {
+		state, result := p.handshake(address, prepare, &tls.Config{
+			ServerName:         host,
+			MinVersion:         version.id,
+			MaxVersion:         version.id,
+			InsecureSkipVerify: true, //nolint:gosec // the protocol is audited, not the certificate
+		})
+		if result == Timeout || result == ConnectionFailed {
+			return result
+		}
+		if _, ok := fields["response_time"]; !ok {
+			fields["response_time"] = time.Since(start).Seconds()
+		}
+		fields[version.field] = boolToInt(state != nil)
+		if state != nil {
+			supported = state
+			fields["tls_version"] = version.name
+			weakProtocol = weakProtocol || version.weak
+		}
+	}
+	if supported == nil {
+		return HandshakeFailed
+	}
+
+	fields["tls_cipher"] = tls.CipherSuiteName(supported.CipherSuite)
+	weakCipher := insecureCipherSuites[supported.CipherSuite]
+
+	if p.EnumerateCiphers {
+		count, weak := p.enumerateCiphers(address, host, prepare)
+		fields["cipher_suites"] = count
+		fields["weak_cipher_suites"] = weak
+		weakCipher = weakCipher || weak > 0
+	}
+
+	if weakProtocol {
+		fields["weak_protocol"] = 1
+	} else if _, ok := fields["weak_protocol"]; !ok {
+		fields["weak_protocol"] = 0
+	}
+	fields["weak_cipher"] = boolToInt(weakCipher)
+	return Success
+}
+
+// enumerateCiphers returns the number of cipher suites accepted by the
+// server and how many of them are insecure, TLS 1.3 suites can not be
+// selected and are not counted
+func (p *ProtocolAudit) enumerateCiphers(address, host string, prepare func(net.Conn) error) (int, int) {
+	count, weak := 0, 0
+	for _, suite := range cipherSuites {
+		if !supportsTLS12(suite) {
+			continue
+		}
+		state, _ := p.handshake(address, prepare, &tls.Config{
+			ServerName:         host,
+			MinVersion:         tls.VersionTLS10,
+			MaxVersion:         tls.VersionTLS12,
+			CipherSuites:       []uint16{suite.ID},
+			InsecureSkipVerify: true, //nolint:gosec // the protocol is audited, not the certificate
+		})
+		if state == nil {
+			continue
+		}
+		count++
+		if insecureCipherSuites[suite.ID] {
+			weak++
+		}
+	}
+	return count, weak
+}
+
+// handshake returns the state of the connection, or nil when the server
+// refused the handshake
+func (p *ProtocolAudit) handshake(address string, prepare func(net.Conn) error, cfg *tls.Config) (*tls.ConnectionState, ResultType) {
+	conn, result := p.dial(address)
+	if result != Success {
+		return nil, result
+	}
+	defer conn.Close()
+
+	if prepare != nil {
+		if err := prepare(conn); err != nil {
+			p.Log.Debugf("Preparing TLS on %s: %v", address, err)
+			return nil, ReadFailed
+		}
+	}
+
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, HandshakeFailed
+	}
+	state := tlsConn.ConnectionState()
+	return &state, Success
+}
+
+var (
+	cipherSuites         = append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	insecureCipherSuites = func() map[uint16]bool {
+		m := make(map[uint16]bool)
+		for _, suite := range tls.InsecureCipherSuites() {
+			m[suite.ID] = true
+		}
+		return m
+	}()
+)
+
+func supportsTLS12(suite *tls.CipherSuite) bool {
+	for _, v := range suite.SupportedVersions {
+		if v <= tls.VersionTLS12 {
+			return true
+		}
+	}
+	return false
+}
+
+func ehloName() string {
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "localhost"
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func setResult(result ResultType, fields map[string]interface{}, tags map[string]string) {
+	var tag string
+	switch result {
+	case Success:
+		tag = "success"
+	case Timeout:
+		tag = "timeout"
+	case ConnectionFailed:
+		tag = "connection_failed"
+	case ReadFailed:
+		tag = "read_failed"
+	case HandshakeFailed:
+		tag = "handshake_failed"
+	}
+
+	tags["result"] = tag
+	fields["result_code"] = uint64(result)
+}
+
+func init() {
+	inputs.Add("protocol_audit", func() cua.Input {
+		return &ProtocolAudit{
+			Timeout: internal.Duration{Duration: defaultTimeout},
+		}
+	})
+}
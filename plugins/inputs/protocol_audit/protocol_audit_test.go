@@ -0,0 +1,147 @@
+package protocolaudit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// serve runs handle for each connection accepted on a local port
+func serve(t *testing.T, handle func(net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func newProtocolAudit(targets ...string) *ProtocolAudit {
+	return &ProtocolAudit{
+		Targets: targets,
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		Log:     testutil.Logger{},
+	}
+}
+
+func TestSSH(t *testing.T) {
+	addr := serve(t, func(conn net.Conn) {
+		_, _ = conn.Write([]byte("welcome\r\nSSH-1.99-OpenSSH_3.9p1 Debian\r\n"))
+	})
+
+	var acc testutil.Accumulator
+	require.NoError(t, newProtocolAudit("ssh://"+addr).Gather(context.Background(), &acc))
+	require.Len(t, acc.Metrics, 1)
+
+	m := acc.Metrics[0]
+	require.Equal(t, "success", m.Tags["result"])
+	require.Equal(t, "ssh", m.Tags["protocol"])
+	require.Equal(t, "SSH-1.99-OpenSSH_3.9p1 Debian", m.Fields["banner"])
+	require.Equal(t, 1.99, m.Fields["ssh_protocol_version"])
+	require.Equal(t, "OpenSSH_3.9p1", m.Fields["ssh_software"])
+	require.Equal(t, 1, m.Fields["weak_protocol"])
+}
+
+func TestSMTPWithoutStarttls(t *testing.T) {
+	addr := serve(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		_, _ = conn.Write([]byte("220 mail.example.com ESMTP Postfix\r\n"))
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				_, _ = conn.Write([]byte("250-mail.example.com\r\n250-PIPELINING\r\n250 8BITMIME\r\n"))
+			case strings.HasPrefix(line, "QUIT"):
+				_, _ = conn.Write([]byte("221 2.0.0 Bye\r\n"))
+				return
+			}
+		}
+	})
+
+	var acc testutil.Accumulator
+	require.NoError(t, newProtocolAudit("smtp://"+addr).Gather(context.Background(), &acc))
+	require.Len(t, acc.Metrics, 1)
+
+	m := acc.Metrics[0]
+	require.Equal(t, "success", m.Tags["result"])
+	require.Equal(t, "mail.example.com ESMTP Postfix", m.Fields["banner"])
+	require.Equal(t, 220, m.Fields["smtp_code"])
+	require.Equal(t, 0, m.Fields["smtp_starttls"])
+	require.Equal(t, 1, m.Fields["weak_protocol"])
+}
+
+func TestTLS(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	ts.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	ts.StartTLS()
+	defer ts.Close()
+
+	p := newProtocolAudit("tls://" + ts.Listener.Addr().String())
+	p.EnumerateCiphers = true
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	require.Len(t, acc.Metrics, 1)
+
+	m := acc.Metrics[0]
+	require.Equal(t, "success", m.Tags["result"])
+	require.Equal(t, 0, m.Fields["tls_1_0"])
+	require.Equal(t, 0, m.Fields["tls_1_1"])
+	require.Equal(t, 1, m.Fields["tls_1_2"])
+	require.Equal(t, 1, m.Fields["tls_1_3"])
+	require.Equal(t, "1.3", m.Fields["tls_version"])
+	require.Equal(t, 0, m.Fields["weak_protocol"])
+	require.Equal(t, 0, m.Fields["weak_cipher"])
+	require.Greater(t, m.Fields["cipher_suites"], 0)
+}
+
+func TestConnectionFailed(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	var acc testutil.Accumulator
+	require.NoError(t, newProtocolAudit("tls://"+addr).Gather(context.Background(), &acc))
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "connection_failed", acc.Metrics[0].Tags["result"])
+	require.Equal(t, uint64(ConnectionFailed), acc.Metrics[0].Fields["result_code"])
+}
+
+func TestParseTarget(t *testing.T) {
+	u, err := parseTarget("smtp://mail.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "mail.example.com:25", u.Host)
+
+	_, err = parseTarget("http://example.com")
+	require.Error(t, err)
+	_, err = parseTarget("ssh://:22")
+	require.Error(t, err)
+}
@@ -46,6 +46,9 @@ func (px *Proxmox) Gather(ctx context.Context, acc cua.Accumulator) error {
 		return err
 	}
 
+	gatherNodeData(px, acc)
+	gatherStorageData(px, acc)
+	gatherClusterData(px, acc)
 	gatherLxcData(px, acc)
 	gatherQemuData(px, acc)
 
@@ -121,6 +124,135 @@ func performRequest(px *Proxmox, apiURL string, method string, data url.Values)
 	return responseBody, nil
 }
 
+func gatherNodeData(px *Proxmox, acc cua.Accumulator) {
+	apiURL := "/nodes/" + px.NodeName + "/status"
+	jsonData, err := px.requestFunction(px, apiURL, http.MethodGet, nil)
+	if err != nil {
+		px.Log.Errorf("error getting node status: %v", err)
+		return
+	}
+
+	var nodeStatus NodeStatus
+	if err := json.Unmarshal(jsonData, &nodeStatus); err != nil {
+		px.Log.Errorf("error getting node status: json unmarshal: %v", err)
+		return
+	}
+
+	status := nodeStatus.Data
+	memTotal, memUsed, memFree, memUsedPercentage := getByteMetrics(status.Memory.Total, status.Memory.Used)
+	swapTotal, swapUsed, swapFree, swapUsedPercentage := getByteMetrics(status.Swap.Total, status.Swap.Used)
+	diskTotal, diskUsed, diskFree, diskUsedPercentage := getByteMetrics(status.RootFS.Total, status.RootFS.Used)
+
+	fields := map[string]interface{}{
+		"uptime":               jsonNumberToInt64(status.Uptime),
+		"cpuload":              jsonNumberToFloat64(status.CPULoad),
+		"cpus":                 jsonNumberToInt64(status.CPUInfo.CPUs),
+		"mem_used":             memUsed,
+		"mem_total":            memTotal,
+		"mem_free":             memFree,
+		"mem_used_percentage":  memUsedPercentage,
+		"swap_used":            swapUsed,
+		"swap_total":           swapTotal,
+		"swap_free":            swapFree,
+		"swap_used_percentage": swapUsedPercentage,
+		"disk_used":            diskUsed,
+		"disk_total":           diskTotal,
+		"disk_free":            diskFree,
+		"disk_used_percentage": diskUsedPercentage,
+	}
+	for i, name := range []string{"load1", "load5", "load15"} {
+		if i < len(status.LoadAvg) {
+			fields[name] = jsonNumberToFloat64(status.LoadAvg[i])
+		}
+	}
+
+	acc.AddFields("proxmox_node", fields, getNodeTags(px))
+}
+
+func gatherStorageData(px *Proxmox, acc cua.Accumulator) {
+	apiURL := "/nodes/" + px.NodeName + "/storage"
+	jsonData, err := px.requestFunction(px, apiURL, http.MethodGet, nil)
+	if err != nil {
+		px.Log.Errorf("error getting storage status: %v", err)
+		return
+	}
+
+	var storages NodeStorages
+	if err := json.Unmarshal(jsonData, &storages); err != nil {
+		px.Log.Errorf("error getting storage status: json unmarshal: %v", err)
+		return
+	}
+
+	for _, storage := range storages.Data {
+		tags := getNodeTags(px)
+		tags["storage"] = storage.Storage
+		tags["storage_type"] = storage.Type
+		tags["shared"] = storage.Shared.String()
+		if tags["shared"] == "" {
+			tags["shared"] = "0"
+		}
+
+		fields := map[string]interface{}{
+			"active":  jsonNumberToInt64(storage.Active),
+			"enabled": jsonNumberToInt64(storage.Enabled),
+		}
+		// inactive storages, e.g. an unreachable NFS share, have no usage
+		if fields["active"] == int64(1) {
+			total, used, free, usedPercentage := getByteMetrics(storage.Total, storage.Used)
+			fields["total"] = total
+			fields["used"] = used
+			fields["free"] = free
+			fields["used_percentage"] = usedPercentage
+		}
+		acc.AddFields("proxmox_storage", fields, tags)
+	}
+}
+
+func gatherClusterData(px *Proxmox, acc cua.Accumulator) {
+	jsonData, err := px.requestFunction(px, "/cluster/status", http.MethodGet, nil)
+	if err != nil {
+		px.Log.Errorf("error getting cluster status: %v", err)
+		return
+	}
+
+	var clusterStatus ClusterStatus
+	if err := json.Unmarshal(jsonData, &clusterStatus); err != nil {
+		px.Log.Errorf("error getting cluster status: json unmarshal: %v", err)
+		return
+	}
+
+	var clusterName string
+	fields := map[string]interface{}{}
+	var nodesOnline int64
+	for _, entry := range clusterStatus.Data {
+		switch entry.Type {
+		case "cluster":
+			clusterName = entry.Name
+			fields["quorate"] = jsonNumberToInt64(entry.Quorate)
+			fields["nodes"] = jsonNumberToInt64(entry.Nodes)
+		case "node":
+			nodesOnline += jsonNumberToInt64(entry.Online)
+		}
+	}
+	// a standalone node is not part of a cluster
+	if clusterName == "" {
+		return
+	}
+	fields["nodes_online"] = nodesOnline
+
+	// the quorum is as seen by this node, a node separated from the
+	// majority reports the cluster as not quorate
+	tags := getNodeTags(px)
+	tags["cluster_name"] = clusterName
+	acc.AddFields("proxmox_cluster", fields, tags)
+}
+
+func getNodeTags(px *Proxmox) map[string]string {
+	return map[string]string{
+		"node_fqdn": px.NodeName + "." + px.nodeSearchDomain,
+	}
+}
+
 func gatherLxcData(px *Proxmox, acc cua.Accumulator) {
 	gatherVMData(px, acc, LXC)
 }
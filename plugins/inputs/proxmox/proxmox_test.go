@@ -18,11 +18,20 @@ var lxcTestData = `{"data":[{"vmid":"111","type":"lxc","uptime":2078164,"swap":9
 var lxcConfigTestData = `{"data":{"hostname":"container1","searchdomain":"test.example.com"}}`
 var lxcCurrentStatusTestData = `{"data":{"vmid":"111","type":"lxc","uptime":2078164,"swap":9412608,"disk":"744189952","maxmem":536870912,"mem":98500608,"maxswap":536870912,"cpu":0.00371567669193613,"status":"running","maxdisk":"5217320960","name":"container1"}}`
 var qemuCurrentStatusTestData = `{"data":{"name":"qemu1","status":"running","maxdisk":10737418240,"cpu":0.029336643550795,"vmid":"113","uptime":2159739,"disk":0,"maxmem":2147483648,"mem":1722451796}}`
+var nodeStatusTestData = `{"data":{"uptime":2159800,"cpu":0.0512,"cpuinfo":{"cpus":8,"sockets":1,"model":"Intel(R) Xeon(R) E-2234"},"loadavg":["0.52","0.61","0.58"],"memory":{"total":33554432000,"used":8388608000,"free":25165824000},"swap":{"total":8589934592,"used":0,"free":8589934592},"rootfs":{"total":100000000000,"used":25000000000,"free":75000000000,"avail":70000000000}}}`
+var storageTestData = `{"data":[{"storage":"local-lvm","type":"lvmthin","content":"images,rootdir","active":1,"enabled":1,"shared":0,"total":500000000000,"used":125000000000,"avail":375000000000,"used_fraction":0.25},{"storage":"backup-nfs","type":"nfs","content":"backup","active":0,"enabled":1,"shared":1}]}`
+var clusterStatusTestData = `{"data":[{"type":"cluster","id":"cluster","name":"retail","nodes":3,"quorate":1,"version":5},{"type":"node","id":"node/testnode","name":"testnode","online":1,"local":1,"nodeid":1},{"type":"node","id":"node/pve2","name":"pve2","online":1,"local":0,"nodeid":2},{"type":"node","id":"node/pve3","name":"pve3","online":0,"local":0,"nodeid":3}]}`
 
 func performTestRequest(px *Proxmox, apiURL string, method string, data url.Values) ([]byte, error) {
 	var bytedata = []byte("")
 
 	switch {
+	case apiURL == "/cluster/status":
+		bytedata = []byte(clusterStatusTestData)
+	case strings.HasSuffix(apiURL, "testnode/status"):
+		bytedata = []byte(nodeStatusTestData)
+	case strings.HasSuffix(apiURL, "storage"):
+		bytedata = []byte(storageTestData)
 	case strings.HasSuffix(apiURL, "dns"):
 		bytedata = []byte(nodeSearchDomainTestData)
 	case strings.HasSuffix(apiURL, "qemu"):
@@ -141,6 +150,96 @@ func TestGather(t *testing.T) {
 	err := px.Gather(context.Background(), acc)
 	require.NoError(t, err)
 
-	// Results from both tests above
-	assert.Equal(t, acc.NFields(), 30)
+	// Results from the VM tests above and the node, storage and cluster
+	// tests below
+	assert.Equal(t, acc.NFields(), 30+18+8+3)
+}
+
+func TestGatherNodeData(t *testing.T) {
+	px := setUp(t)
+	px.nodeSearchDomain = "test.example.com"
+
+	acc := &testutil.Accumulator{}
+	gatherNodeData(px, acc)
+
+	acc.AssertContainsTaggedFields(t, "proxmox_node", map[string]interface{}{
+		"uptime":               int64(2159800),
+		"cpuload":              float64(0.0512),
+		"cpus":                 int64(8),
+		"load1":                float64(0.52),
+		"load5":                float64(0.61),
+		"load15":               float64(0.58),
+		"mem_used":             int64(8388608000),
+		"mem_total":            int64(33554432000),
+		"mem_free":             int64(25165824000),
+		"mem_used_percentage":  float64(25),
+		"swap_used":            int64(0),
+		"swap_total":           int64(8589934592),
+		"swap_free":            int64(8589934592),
+		"swap_used_percentage": float64(0),
+		"disk_used":            int64(25000000000),
+		"disk_total":           int64(100000000000),
+		"disk_free":            int64(75000000000),
+		"disk_used_percentage": float64(25),
+	}, map[string]string{"node_fqdn": "testnode.test.example.com"})
+}
+
+func TestGatherStorageData(t *testing.T) {
+	px := setUp(t)
+	px.nodeSearchDomain = "test.example.com"
+
+	acc := &testutil.Accumulator{}
+	gatherStorageData(px, acc)
+
+	acc.AssertContainsTaggedFields(t, "proxmox_storage", map[string]interface{}{
+		"active":          int64(1),
+		"enabled":         int64(1),
+		"total":           int64(500000000000),
+		"used":            int64(125000000000),
+		"free":            int64(375000000000),
+		"used_percentage": float64(25),
+	}, map[string]string{
+		"node_fqdn":    "testnode.test.example.com",
+		"storage":      "local-lvm",
+		"storage_type": "lvmthin",
+		"shared":       "0",
+	})
+	acc.AssertContainsTaggedFields(t, "proxmox_storage", map[string]interface{}{
+		"active":  int64(0),
+		"enabled": int64(1),
+	}, map[string]string{
+		"node_fqdn":    "testnode.test.example.com",
+		"storage":      "backup-nfs",
+		"storage_type": "nfs",
+		"shared":       "1",
+	})
+}
+
+func TestGatherClusterData(t *testing.T) {
+	px := setUp(t)
+	px.nodeSearchDomain = "test.example.com"
+
+	acc := &testutil.Accumulator{}
+	gatherClusterData(px, acc)
+
+	acc.AssertContainsTaggedFields(t, "proxmox_cluster", map[string]interface{}{
+		"quorate":      int64(1),
+		"nodes":        int64(3),
+		"nodes_online": int64(2),
+	}, map[string]string{
+		"node_fqdn":    "testnode.test.example.com",
+		"cluster_name": "retail",
+	})
+}
+
+func TestGatherClusterDataStandalone(t *testing.T) {
+	px := setUp(t)
+	px.nodeSearchDomain = "test.example.com"
+	px.requestFunction = func(px *Proxmox, apiURL string, method string, data url.Values) ([]byte, error) {
+		return []byte(`{"data":[{"type":"node","id":"node/testnode","name":"testnode","online":1,"local":1,"nodeid":0}]}`), nil
+	}
+
+	acc := &testutil.Accumulator{}
+	gatherClusterData(px, acc)
+	require.False(t, acc.HasMeasurement("proxmox_cluster"))
 }
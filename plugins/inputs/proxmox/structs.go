@@ -67,3 +67,47 @@ type NodeDNS struct {
 		Searchdomain string `json:"search"`
 	} `json:"data"`
 }
+
+type NodeStatus struct {
+	Data struct {
+		Uptime  json.Number `json:"uptime"`
+		CPULoad json.Number `json:"cpu"`
+		CPUInfo struct {
+			CPUs json.Number `json:"cpus"`
+		} `json:"cpuinfo"`
+		LoadAvg []json.Number `json:"loadavg"`
+		Memory  NodeUsage     `json:"memory"`
+		Swap    NodeUsage     `json:"swap"`
+		RootFS  NodeUsage     `json:"rootfs"`
+	} `json:"data"`
+}
+
+type NodeUsage struct {
+	Total json.Number `json:"total"`
+	Used  json.Number `json:"used"`
+}
+
+type NodeStorages struct {
+	Data []NodeStorage `json:"data"`
+}
+
+type NodeStorage struct {
+	Storage string      `json:"storage"`
+	Type    string      `json:"type"`
+	Content string      `json:"content"`
+	Active  json.Number `json:"active"`
+	Enabled json.Number `json:"enabled"`
+	Shared  json.Number `json:"shared"`
+	Total   json.Number `json:"total"`
+	Used    json.Number `json:"used"`
+}
+
+type ClusterStatus struct {
+	Data []struct {
+		Type    string      `json:"type"`
+		Name    string      `json:"name"`
+		Nodes   json.Number `json:"nodes"`
+		Quorate json.Number `json:"quorate"`
+		Online  json.Number `json:"online"`
+	} `json:"data"`
+}
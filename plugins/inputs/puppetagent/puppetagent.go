@@ -45,6 +45,8 @@ type resource struct {
 	FailedToRestart int64 `yaml:"failed_to_restart"`
 	Restarted       int64 `yaml:"restarted"`
 	OutOfSync       int64 `yaml:"out_of_sync"`
+	// resources changed back to the catalog state, puppet 5+
+	CorrectiveChange int64 `yaml:"corrective_change"`
 }
 
 type change struct {
@@ -0,0 +1,338 @@
+package scriptsuite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const (
+	resultPass       = "pass"
+	resultFail       = "fail"
+	resultTimeout    = "timeout"
+	resultOverBudget = "over_budget"
+	resultError      = "error"
+
+	maxStderrBytes = 512
+)
+
+const sampleConfig = `
+  ## Directory containing the scripts, every executable file in the directory
+  ## is run on each interval (files starting with "." are skipped)
+  directory = "/opt/circonus/unified-agent/etc/script_suite.d"
+
+  ## Maximum number of scripts run concurrently
+  # parallelism = 4
+
+  ## Defaults for the scripts
+  ## The script is stopped, and fails, if it runs longer than timeout
+  # timeout = "30s"
+  ## The script fails if it runs longer than budget, 0s disables the budget
+  # budget = "0s"
+  ## The script fails if it exits with a code not in expected_exit_codes
+  # expected_exit_codes = [0]
+
+  ## Per script settings, overriding the defaults, name is the file name
+  # [[inputs.script_suite.script]]
+  #   name = "login.sh"
+  #   args = ["--user", "synthetic"]
+  #   timeout = "10s"
+  #   budget = "2s"
+  #   expected_exit_codes = [0]
+`
+
+type ScriptSuite struct {
+	Log               cua.Logger        `toml:"-"`
+	Directory         string            `toml:"directory"`
+	Scripts           []*scriptConfig   `toml:"script"`
+	ExpectedExitCodes []int             `toml:"expected_exit_codes"`
+	Timeout           internal.Duration `toml:"timeout"`
+	Budget            internal.Duration `toml:"budget"`
+	Parallelism       int               `toml:"parallelism"`
+
+	scripts map[string]*scriptConfig
+}
+
+type scriptConfig struct {
+	Name              string            `toml:"name"`
+	Args              []string          `toml:"args"`
+	ExpectedExitCodes []int             `toml:"expected_exit_codes"`
+	Timeout           internal.Duration `toml:"timeout"`
+	Budget            internal.Duration `toml:"budget"`
+}
+
+type scriptResult struct {
+	result   string
+	exitCode int
+	duration time.Duration
+}
+
+func (s *ScriptSuite) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *ScriptSuite) Description() string {
+	return "Run a directory of test scripts and report pass/fail, duration and timeouts"
+}
+
+func (s *ScriptSuite) Init() error {
+	if s.Directory == "" {
+		return fmt.Errorf("directory is required")
+	}
+	if s.Parallelism < 1 {
+		return fmt.Errorf("invalid parallelism (%d), must be at least 1", s.Parallelism)
+	}
+	if s.Timeout.Duration <= 0 {
+		return fmt.Errorf("invalid timeout (%s), must be positive", s.Timeout.Duration)
+	}
+	if len(s.ExpectedExitCodes) == 0 {
+		s.ExpectedExitCodes = []int{0}
+	}
+
+	s.scripts = make(map[string]*scriptConfig, len(s.Scripts))
+	for _, sc := range s.Scripts {
+		if sc.Name == "" {
+			return fmt.Errorf("script name is required")
+		}
+		if _, ok := s.scripts[sc.Name]; ok {
+			return fmt.Errorf("duplicate script (%s)", sc.Name)
+		}
+		if sc.Timeout.Duration == 0 {
+			sc.Timeout = s.Timeout
+		}
+		if sc.Budget.Duration == 0 {
+			sc.Budget = s.Budget
+		}
+		if len(sc.ExpectedExitCodes) == 0 {
+			sc.ExpectedExitCodes = s.ExpectedExitCodes
+		}
+		s.scripts[sc.Name] = sc
+	}
+
+	return nil
+}
+
+func (s *ScriptSuite) Gather(ctx context.Context, acc cua.Accumulator) error {
+	names, err := s.findScripts()
+	if err != nil {
+		return err
+	}
+
+	for name := range s.scripts {
+		if !contains(names, name) {
+			s.Log.Warnf("configured script %s not found in %s", name, s.Directory)
+		}
+	}
+
+	results := make([]scriptResult, len(names))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.Parallelism)
+	for i, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = s.run(ctx, s.config(name))
+		}(i, name)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	passed := 0
+	for i, name := range names {
+		r := results[i]
+		if r.result == resultPass {
+			passed++
+		}
+		acc.AddFields("script_suite",
+			map[string]interface{}{
+				"result":      r.result,
+				"passed":      boolInt(r.result == resultPass),
+				"timed_out":   boolInt(r.result == resultTimeout),
+				"over_budget": boolInt(r.result == resultOverBudget),
+				"exit_code":   r.exitCode,
+				"duration":    r.duration.Seconds(),
+			},
+			map[string]string{
+				"directory": s.Directory,
+				"script":    name,
+			},
+			now)
+	}
+
+	acc.AddFields("script_suite_summary",
+		map[string]interface{}{
+			"scripts": len(names),
+			"passed":  passed,
+			"failed":  len(names) - passed,
+		},
+		map[string]string{"directory": s.Directory},
+		now)
+
+	return nil
+}
+
+// config returns the settings of a script, the defaults when the script has
+// no settings of its own
+func (s *ScriptSuite) config(name string) *scriptConfig {
+	if sc, ok := s.scripts[name]; ok {
+		return sc
+	}
+	return &scriptConfig{
+		Name:              name,
+		ExpectedExitCodes: s.ExpectedExitCodes,
+		Timeout:           s.Timeout,
+		Budget:            s.Budget,
+	}
+}
+
+// findScripts returns the names of the executable files in the directory
+func (s *ScriptSuite) findScripts() ([]string, error) {
+	entries, err := os.ReadDir(s.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("read directory (%s): %w", s.Directory, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			s.Log.Warnf("stat %s: %s", entry.Name(), err)
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+			s.Log.Debugf("skipping %s, not executable", entry.Name())
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (s *ScriptSuite) run(ctx context.Context, sc *scriptConfig) scriptResult {
+	cmd := exec.Command(filepath.Join(s.Directory, sc.Name), sc.Args...) //nolint:gosec // G204
+	cmd.Dir = s.Directory
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := s.runTimeout(ctx, cmd, sc.Timeout.Duration)
+	r := scriptResult{duration: time.Since(start)}
+
+	switch {
+	case errors.Is(err, internal.ErrTimeout):
+		r.result = resultTimeout
+		r.exitCode = -1
+		s.Log.Debugf("%s timed out after %s", sc.Name, sc.Timeout.Duration)
+		return r
+	case err != nil:
+		code, ok := internal.ExitStatus(err)
+		if !ok {
+			r.result = resultError
+			r.exitCode = -1
+			s.Log.Errorf("running %s: %s", sc.Name, err)
+			return r
+		}
+		r.exitCode = code
+	}
+
+	switch {
+	case !containsInt(sc.ExpectedExitCodes, r.exitCode):
+		r.result = resultFail
+		s.Log.Debugf("%s exited with %d: %s", sc.Name, r.exitCode, firstLine(stderr.Bytes()))
+	case sc.Budget.Duration > 0 && r.duration > sc.Budget.Duration:
+		r.result = resultOverBudget
+	default:
+		r.result = resultPass
+	}
+
+	return r
+}
+
+// runTimeout runs the command, stopping it when the timeout expires or the
+// agent is shutting down
+func (s *ScriptSuite) runTimeout(ctx context.Context, cmd *exec.Cmd, timeout time.Duration) error {
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+
+	return internal.WaitTimeout(cmd, timeout) //nolint:wrapcheck
+}
+
+func firstLine(b []byte) string {
+	if len(b) > maxStderrBytes {
+		b = b[:maxStderrBytes]
+	}
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	inputs.Add("script_suite", func() cua.Input {
+		return &ScriptSuite{
+			Timeout:           internal.Duration{Duration: 30 * time.Second},
+			Parallelism:       4,
+			ExpectedExitCodes: []int{0},
+		}
+	})
+}
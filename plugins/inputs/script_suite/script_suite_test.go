@@ -0,0 +1,136 @@
+//go:build !windows
+// +build !windows
+
+package scriptsuite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeScript(t *testing.T, dir, name, body string, mode os.FileMode) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body+"\n"), mode))
+}
+
+func newSuite(t *testing.T, dir string, scripts ...*scriptConfig) *ScriptSuite {
+	s := &ScriptSuite{
+		Log:         testutil.Logger{},
+		Directory:   dir,
+		Scripts:     scripts,
+		Timeout:     internal.Duration{Duration: 5 * time.Second},
+		Parallelism: 2,
+	}
+	require.NoError(t, s.Init())
+	return s
+}
+
+func TestInit(t *testing.T) {
+	s := &ScriptSuite{Timeout: internal.Duration{Duration: time.Second}, Parallelism: 1}
+	require.Error(t, s.Init())
+
+	s = &ScriptSuite{Directory: "/tmp", Timeout: internal.Duration{Duration: time.Second}}
+	require.Error(t, s.Init())
+
+	s = &ScriptSuite{
+		Directory:   "/tmp",
+		Timeout:     internal.Duration{Duration: time.Second},
+		Parallelism: 1,
+		Scripts:     []*scriptConfig{{Name: "a"}, {Name: "a"}},
+	}
+	require.Error(t, s.Init())
+
+	s = &ScriptSuite{
+		Directory:   "/tmp",
+		Timeout:     internal.Duration{Duration: time.Second},
+		Budget:      internal.Duration{Duration: time.Millisecond},
+		Parallelism: 1,
+		Scripts:     []*scriptConfig{{Name: "a", ExpectedExitCodes: []int{2}}},
+	}
+	require.NoError(t, s.Init())
+	require.Equal(t, []int{0}, s.ExpectedExitCodes)
+	require.Equal(t, time.Second, s.scripts["a"].Timeout.Duration)
+	require.Equal(t, time.Millisecond, s.scripts["a"].Budget.Duration)
+	require.Equal(t, []int{2}, s.scripts["a"].ExpectedExitCodes)
+}
+
+func TestGather(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "pass.sh", "exit 0", 0755)
+	writeScript(t, dir, "fail.sh", "echo broken >&2; exit 1", 0755)
+	writeScript(t, dir, "expected.sh", "exit 3", 0755)
+	writeScript(t, dir, "slow.sh", "exec sleep 2", 0755)
+	writeScript(t, dir, "budget.sh", "sleep 0.2", 0755)
+	writeScript(t, dir, "args.sh", `[ "$1" = "ok" ]`, 0755)
+	writeScript(t, dir, "notexec.sh", "exit 0", 0644)
+	writeScript(t, dir, ".hidden.sh", "exit 0", 0755)
+
+	s := newSuite(t, dir,
+		&scriptConfig{Name: "expected.sh", ExpectedExitCodes: []int{0, 3}},
+		&scriptConfig{Name: "slow.sh", Timeout: internal.Duration{Duration: 100 * time.Millisecond}},
+		&scriptConfig{Name: "budget.sh", Budget: internal.Duration{Duration: 10 * time.Millisecond}},
+		&scriptConfig{Name: "args.sh", Args: []string{"ok"}},
+	)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+
+	expected := map[string]struct {
+		result   string
+		exitCode int
+	}{
+		"args.sh":     {resultPass, 0},
+		"budget.sh":   {resultOverBudget, 0},
+		"expected.sh": {resultPass, 3},
+		"fail.sh":     {resultFail, 1},
+		"pass.sh":     {resultPass, 0},
+		"slow.sh":     {resultTimeout, -1},
+	}
+
+	scripts := 0
+	for _, m := range acc.GetCUAMetrics() {
+		if m.Name() != "script_suite" {
+			continue
+		}
+		scripts++
+		name, _ := m.GetTag("script")
+		want, ok := expected[name]
+		require.True(t, ok, name)
+
+		result, _ := m.GetField("result")
+		require.Equal(t, want.result, result, name)
+		exitCode, _ := m.GetField("exit_code")
+		require.Equal(t, int64(want.exitCode), exitCode, name)
+		passed, _ := m.GetField("passed")
+		require.Equal(t, int64(boolInt(want.result == resultPass)), passed, name)
+		timedOut, _ := m.GetField("timed_out")
+		require.Equal(t, int64(boolInt(want.result == resultTimeout)), timedOut, name)
+		overBudget, _ := m.GetField("over_budget")
+		require.Equal(t, int64(boolInt(want.result == resultOverBudget)), overBudget, name)
+		dir, _ := m.GetTag("directory")
+		require.Equal(t, s.Directory, dir)
+	}
+	require.Equal(t, len(expected), scripts)
+
+	acc.AssertContainsTaggedFields(t, "script_suite_summary",
+		map[string]interface{}{
+			"scripts": 6,
+			"passed":  3,
+			"failed":  3,
+		},
+		map[string]string{"directory": dir})
+}
+
+func TestGatherMissingDirectory(t *testing.T) {
+	s := newSuite(t, filepath.Join(t.TempDir(), "missing"))
+
+	var acc testutil.Accumulator
+	require.Error(t, s.Gather(context.Background(), &acc))
+}
@@ -0,0 +1,197 @@
+package sql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// conversion is the type a field column is converted to
+type conversion int
+
+const (
+	convNone conversion = iota
+	convFloat
+	convInt
+	convUint
+	convBool
+	convString
+)
+
+// convert converts a column value to the type of a field, without a
+// conversion the driver type is kept when it is a valid field type
+func convert(value interface{}, conv conversion) (interface{}, error) {
+	if b, ok := value.([]byte); ok {
+		// text protocols, e.g. mysql, return numbers as bytes
+		value = string(b)
+	}
+
+	switch conv {
+	case convFloat:
+		return toFloat(value)
+	case convInt:
+		return toInt(value)
+	case convUint:
+		return toUint(value)
+	case convBool:
+		return toBool(value)
+	case convString:
+		return toString(value), nil
+	}
+
+	switch v := value.(type) {
+	case int64, uint64, float64, string, bool:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint:
+		return uint64(v), nil
+	case uint8:
+		return uint64(v), nil
+	case uint16:
+		return uint64(v), nil
+	case uint32:
+		return uint64(v), nil
+	case float32:
+		return float64(v), nil
+	default:
+		return toString(v), nil
+	}
+}
+
+func toFloat(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case bool:
+		if v {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("float: %w", err)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to float", value)
+}
+
+func toInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, fmt.Errorf("int: %d out of range", v)
+		}
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case float32:
+		return int64(v), nil
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case time.Time:
+		return v.UnixNano(), nil
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("int: %w", err)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to int", value)
+}
+
+func toUint(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case uint64:
+		return v, nil
+	case uint32:
+		return uint64(v), nil
+	case int64:
+		if v < 0 {
+			return nil, fmt.Errorf("uint: %d out of range", v)
+		}
+		return uint64(v), nil
+	case int:
+		if v < 0 {
+			return nil, fmt.Errorf("uint: %d out of range", v)
+		}
+		return uint64(v), nil
+	case int32:
+		if v < 0 {
+			return nil, fmt.Errorf("uint: %d out of range", v)
+		}
+		return uint64(v), nil
+	case float64:
+		if v < 0 {
+			return nil, fmt.Errorf("uint: %g out of range", v)
+		}
+		return uint64(v), nil
+	case bool:
+		if v {
+			return uint64(1), nil
+		}
+		return uint64(0), nil
+	case string:
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("uint: %w", err)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to uint", value)
+}
+
+func toBool(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case int64:
+		return v != 0, nil
+	case uint64:
+		return v != 0, nil
+	case int:
+		return v != 0, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("bool: %w", err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to bool", value)
+}
@@ -0,0 +1,225 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+)
+
+// Query is a query and the mapping of its result columns
+type Query struct {
+	Query             string            `toml:"query"`
+	Script            string            `toml:"query_script"`
+	Measurement       string            `toml:"measurement"`
+	MeasurementColumn string            `toml:"measurement_column"`
+	TimeColumn        string            `toml:"time_column"`
+	TimeFormat        string            `toml:"time_format"`
+	Timeout           internal.Duration `toml:"timeout"`
+
+	TagColumnsInclude   []string `toml:"tag_columns_include"`
+	TagColumnsExclude   []string `toml:"tag_columns_exclude"`
+	FieldColumnsInclude []string `toml:"field_columns_include"`
+	FieldColumnsExclude []string `toml:"field_columns_exclude"`
+	FieldColumnsFloat   []string `toml:"field_columns_float"`
+	FieldColumnsInt     []string `toml:"field_columns_int"`
+	FieldColumnsUint    []string `toml:"field_columns_uint"`
+	FieldColumnsBool    []string `toml:"field_columns_bool"`
+	FieldColumnsString  []string `toml:"field_columns_string"`
+
+	tagFilter   filter.Filter
+	fieldFilter filter.Filter
+	conversions []columnConversion
+}
+
+type columnConversion struct {
+	filter filter.Filter
+	conv   conversion
+}
+
+type columnRole int
+
+const (
+	roleIgnore columnRole = iota
+	roleMeasurement
+	roleTime
+	roleTag
+	roleField
+)
+
+type columnKind struct {
+	role columnRole
+	conv conversion
+}
+
+type metric struct {
+	measurement string
+	tags        map[string]string
+	fields      map[string]interface{}
+	time        time.Time
+}
+
+func (q *Query) init() error {
+	if q.Measurement == "" {
+		q.Measurement = "sql"
+	}
+	if q.TimeFormat == "" {
+		q.TimeFormat = "unix"
+	}
+
+	var err error
+	// tags are opt-in, without an include list no column is a tag
+	if len(q.TagColumnsInclude) != 0 {
+		if q.tagFilter, err = filter.NewIncludeExcludeFilter(q.TagColumnsInclude, q.TagColumnsExclude); err != nil {
+			return fmt.Errorf("tag columns: %w", err)
+		}
+	}
+	if q.fieldFilter, err = filter.NewIncludeExcludeFilter(q.FieldColumnsInclude, q.FieldColumnsExclude); err != nil {
+		return fmt.Errorf("field columns: %w", err)
+	}
+
+	for _, c := range []struct {
+		columns []string
+		conv    conversion
+	}{
+		{q.FieldColumnsFloat, convFloat},
+		{q.FieldColumnsInt, convInt},
+		{q.FieldColumnsUint, convUint},
+		{q.FieldColumnsBool, convBool},
+		{q.FieldColumnsString, convString},
+	} {
+		if len(c.columns) == 0 {
+			continue
+		}
+		f, err := filter.Compile(c.columns)
+		if err != nil {
+			return fmt.Errorf("field column conversion: %w", err)
+		}
+		q.conversions = append(q.conversions, columnConversion{filter: f, conv: c.conv})
+	}
+
+	return nil
+}
+
+// columnKinds returns the role and conversion of each column of the result
+func (q *Query) columnKinds(columns []string) []columnKind {
+	kinds := make([]columnKind, len(columns))
+	for i, name := range columns {
+		switch {
+		case q.MeasurementColumn != "" && name == q.MeasurementColumn:
+			kinds[i].role = roleMeasurement
+		case q.TimeColumn != "" && name == q.TimeColumn:
+			kinds[i].role = roleTime
+		case q.tagFilter != nil && q.tagFilter.Match(name):
+			kinds[i].role = roleTag
+		case q.fieldFilter.Match(name):
+			kinds[i].role = roleField
+			for _, c := range q.conversions {
+				if c.filter.Match(name) {
+					kinds[i].conv = c.conv
+					break
+				}
+			}
+		}
+	}
+	return kinds
+}
+
+// metric maps a row of the result to a metric, NULL columns are omitted
+func (q *Query) metric(columns []string, kinds []columnKind, values []interface{}, now time.Time) (*metric, error) {
+	m := &metric{
+		measurement: q.Measurement,
+		tags:        make(map[string]string),
+		fields:      make(map[string]interface{}),
+		time:        now,
+	}
+
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		switch kinds[i].role {
+		case roleMeasurement:
+			if s := toString(value); s != "" {
+				m.measurement = s
+			}
+		case roleTime:
+			t, err := parseTime(value, q.TimeFormat)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", columns[i], err)
+			}
+			m.time = t
+		case roleTag:
+			m.tags[columns[i]] = toString(value)
+		case roleField:
+			v, err := convert(value, kinds[i].conv)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", columns[i], err)
+			}
+			m.fields[columns[i]] = v
+		}
+	}
+
+	if len(m.fields) == 0 {
+		return nil, fmt.Errorf("no field columns in row")
+	}
+	return m, nil
+}
+
+func parseTime(value interface{}, format string) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+
+	switch format {
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+		v, err := convert(value, convInt)
+		if err != nil {
+			f, ferr := convert(value, convFloat)
+			if ferr != nil {
+				return time.Time{}, err
+			}
+			// fractional seconds
+			if format == "unix" {
+				sec := f.(float64)
+				return time.Unix(0, int64(sec*float64(time.Second))), nil
+			}
+			v = int64(f.(float64))
+		}
+		n := v.(int64)
+		switch format {
+		case "unix_ms":
+			return time.Unix(0, n*int64(time.Millisecond)), nil
+		case "unix_us":
+			return time.Unix(0, n*int64(time.Microsecond)), nil
+		case "unix_ns":
+			return time.Unix(0, n), nil
+		default:
+			return time.Unix(n, 0), nil
+		}
+	default:
+		t, err := time.Parse(format, toString(value))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("time parse: %w", err)
+		}
+		return t, nil
+	}
+}
+
+// toString returns the string form of a column value
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
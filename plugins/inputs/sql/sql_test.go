@@ -0,0 +1,130 @@
+package sql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name string
+		s    *SQL
+	}{
+		{name: "no dsn", s: &SQL{Driver: "postgres", Queries: []*Query{{Query: "SELECT 1"}}}},
+		{name: "invalid driver", s: &SQL{Driver: "oracle", DSN: "x", Queries: []*Query{{Query: "SELECT 1"}}}},
+		{name: "no queries", s: &SQL{Driver: "mysql", DSN: "x"}},
+		{name: "empty query", s: &SQL{Driver: "mysql", DSN: "x", Queries: []*Query{{}}}},
+		{name: "missing script", s: &SQL{Driver: "mysql", DSN: "x", Queries: []*Query{{Script: "testdata/missing.sql"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.s.Init())
+		})
+	}
+
+	s := &SQL{Driver: "clickhouse", DSN: "tcp://localhost:9000", Queries: []*Query{{Query: "SELECT 1"}}}
+	require.NoError(t, s.Init())
+	require.Equal(t, defaultTimeout, s.Queries[0].Timeout.Duration)
+	require.Equal(t, "sql", s.Queries[0].Measurement)
+}
+
+func TestQueryMetric(t *testing.T) {
+	q := &Query{
+		MeasurementColumn:   "name",
+		TimeColumn:          "ts",
+		TimeFormat:          "unix_ms",
+		TagColumnsInclude:   []string{"service", "status"},
+		FieldColumnsExclude: []string{"ignored"},
+		FieldColumnsFloat:   []string{"duration"},
+		FieldColumnsInt:     []string{"req*"},
+		FieldColumnsBool:    []string{"healthy"},
+	}
+	require.NoError(t, q.init())
+
+	columns := []string{"name", "ts", "service", "status", "requests", "duration", "healthy", "ignored", "note", "missing"}
+	kinds := q.columnKinds(columns)
+
+	values := []interface{}{
+		[]byte("http"),
+		int64(1622470000123),
+		"api",
+		int64(200),
+		[]byte("42"),
+		"0.25",
+		int64(1),
+		"x",
+		[]byte("text"),
+		nil,
+	}
+	m, err := q.metric(columns, kinds, values, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "http", m.measurement)
+	require.Equal(t, time.Unix(0, 1622470000123*int64(time.Millisecond)), m.time)
+	require.Equal(t, map[string]string{"service": "api", "status": "200"}, m.tags)
+	require.Equal(t, map[string]interface{}{
+		"requests": int64(42),
+		"duration": 0.25,
+		"healthy":  true,
+		"note":     "text",
+	}, m.fields)
+
+	_, err = q.metric(columns, kinds, []interface{}{nil, nil, "api", nil, nil, nil, nil, nil, nil, nil}, time.Now())
+	require.Error(t, err)
+
+	values[4] = "many"
+	_, err = q.metric(columns, kinds, values, time.Now())
+	require.Error(t, err)
+}
+
+func TestParseTime(t *testing.T) {
+	ts := time.Date(2021, 5, 31, 14, 6, 40, 0, time.UTC)
+
+	tests := []struct {
+		format string
+		value  interface{}
+		exp    time.Time
+	}{
+		{format: "unix", value: ts, exp: ts},
+		{format: "unix", value: ts.Unix(), exp: ts},
+		{format: "unix", value: []byte("1622470000.5"), exp: time.Unix(1622470000, 5e8)},
+		{format: "unix_us", value: ts.UnixNano() / 1e3, exp: ts},
+		{format: "unix_ns", value: uint64(ts.UnixNano()), exp: ts},
+		{format: "2006-01-02 15:04:05", value: "2021-05-31 14:06:40", exp: ts},
+	}
+	for _, tt := range tests {
+		tm, err := parseTime(tt.value, tt.format)
+		require.NoError(t, err)
+		require.True(t, tt.exp.Equal(tm), "%s %v: %s", tt.format, tt.value, tm)
+	}
+
+	_, err := parseTime("yesterday", "unix")
+	require.Error(t, err)
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		conv  conversion
+		exp   interface{}
+	}{
+		{value: int32(5), conv: convNone, exp: int64(5)},
+		{value: uint8(5), conv: convNone, exp: uint64(5)},
+		{value: float32(0.5), conv: convNone, exp: 0.5},
+		{value: []byte("5"), conv: convNone, exp: "5"},
+		{value: []byte("5"), conv: convUint, exp: uint64(5)},
+		{value: int64(5), conv: convFloat, exp: 5.0},
+		{value: 5.9, conv: convInt, exp: int64(5)},
+		{value: "true", conv: convBool, exp: true},
+		{value: int64(5), conv: convString, exp: "5"},
+	}
+	for _, tt := range tests {
+		v, err := convert(tt.value, tt.conv)
+		require.NoError(t, err)
+		require.Equal(t, tt.exp, v)
+	}
+
+	_, err := convert(int64(-1), convUint)
+	require.Error(t, err)
+}
@@ -42,6 +42,16 @@ func TestSqlServer_QueriesInclusionExclusion(t *testing.T) {
 	}
 }
 
+func TestSqlServer_DatabaseTypeSQLServerQueries(t *testing.T) {
+	s := SQLServer{
+		DatabaseType: "SQLServer",
+		ExcludeQuery: []string{"SQLServerCpu"},
+	}
+	require.NoError(t, initQueries(&s))
+	assert.Contains(t, s.queries, "SQLServerAvailabilityReplicaStates")
+	assert.NotContains(t, s.queries, "SQLServerCpu")
+}
+
 func TestSqlServer_ParseMetrics(t *testing.T) {
 
 	var acc testutil.Accumulator
@@ -1150,3 +1150,48 @@ FROM (
 	ORDER BY [record_id] DESC
 ) AS z
 `
+
+const sqlServerAvailabilityReplicaStates string = `
+IF SERVERPROPERTY('EngineEdition') NOT IN (2,3,4) BEGIN /*NOT IN Standard,Enterpris,Express*/
+	DECLARE @ErrorMessage AS nvarchar(500) = 'Circonus Unified Agent - Connection string Server:'+ @@ServerName + ',Database:' + DB_NAME() +' is not a SQL Server Standard,Enterprise or Express. Check the database_type parameter in the configuration.';
+	RAISERROR (@ErrorMessage,11,1)
+	RETURN
+END
+
+IF SERVERPROPERTY('IsHadrEnabled') = 1 BEGIN
+	SELECT
+		 'sqlserver_hadr_replica_states' AS [measurement]
+		,REPLACE(@@SERVERNAME,'\',':') AS [sql_instance]
+		,ag.[name] AS [availability_group]
+		,ar.[replica_server_name]
+		,ISNULL(ars.[role_desc],'UNKNOWN') AS [role_desc]
+		,ISNULL(ars.[operational_state_desc],'UNKNOWN') AS [operational_state_desc]
+		,ISNULL(ars.[connected_state_desc],'UNKNOWN') AS [connected_state_desc]
+		,ISNULL(ars.[synchronization_health_desc],'UNKNOWN') AS [synchronization_health_desc]
+		,ar.[availability_mode_desc]
+		,ar.[failover_mode_desc]
+		,CAST(ISNULL(ars.[role],0) AS int) AS [role]
+		,CAST(ISNULL(ars.[connected_state],0) AS int) AS [connected_state]
+		/*0 not healthy, 1 partially healthy, 2 healthy*/
+		,CAST(ISNULL(ars.[synchronization_health],0) AS int) AS [synchronization_health]
+		,CAST(ars.[is_local] AS int) AS [is_local]
+		,ISNULL(drs.[log_send_queue_kb],0) AS [log_send_queue_kb]
+		,ISNULL(drs.[redo_queue_kb],0) AS [redo_queue_kb]
+		,ISNULL(drs.[suspended_databases],0) AS [suspended_databases]
+	FROM sys.availability_groups AS ag
+	INNER JOIN sys.availability_replicas AS ar
+		ON ar.[group_id] = ag.[group_id]
+	INNER JOIN sys.dm_hadr_availability_replica_states AS ars
+		ON ars.[replica_id] = ar.[replica_id]
+	LEFT OUTER JOIN (
+		SELECT
+			 [replica_id]
+			,SUM(CAST([log_send_queue_size] AS bigint)) AS [log_send_queue_kb]
+			,SUM(CAST([redo_queue_size] AS bigint)) AS [redo_queue_kb]
+			,SUM(CAST([is_suspended] AS int)) AS [suspended_databases]
+		FROM sys.dm_hadr_database_replica_states
+		GROUP BY [replica_id]
+	) AS drs
+		ON drs.[replica_id] = ar.[replica_id]
+END
+`
@@ -0,0 +1,319 @@
+package sqsconsumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	internalaws "github.com/circonus-labs/circonus-unified-agent/config/aws"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
+)
+
+const (
+	defaultMaxUndeliveredMessages = 1000
+	defaultMaxMessages            = 10
+	defaultWaitTime               = 20 * time.Second
+)
+
+type SQSConsumer struct {
+	Region                 string            `toml:"region"`
+	AccessKey              string            `toml:"access_key"`
+	SecretKey              string            `toml:"secret_key"`
+	RoleARN                string            `toml:"role_arn"`
+	Profile                string            `toml:"profile"`
+	Filename               string            `toml:"shared_credential_file"`
+	Token                  string            `toml:"token"`
+	EndpointURL            string            `toml:"endpoint_url"`
+	QueueURL               string            `toml:"queue_url"`
+	MaxMessages            int64             `toml:"max_messages"`
+	WaitTime               internal.Duration `toml:"wait_time"`
+	VisibilityTimeout      internal.Duration `toml:"visibility_timeout"`
+	MaxUndeliveredMessages int               `toml:"max_undelivered_messages"`
+
+	Log cua.Logger
+
+	client sqsiface.SQSAPI
+	parser parsers.Parser
+	acc    cua.TrackingAccumulator
+	sem    chan struct{}
+
+	// receipt handles of the messages waiting for their metrics to be written
+	undelivered map[cua.TrackingID]*string
+	mu          sync.Mutex
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var sampleConfig = `
+  ## Amazon REGION of the queue.
+  region = "us-east-1"
+
+  ## Amazon Credentials
+  ## Credentials are loaded in the following order
+  ## 1) Assumed credentials via STS if role_arn is specified
+  ## 2) explicit credentials from 'access_key' and 'secret_key'
+  ## 3) shared profile from 'profile'
+  ## 4) environment variables
+  ## 5) shared credentials file
+  ## 6) EC2 Instance Profile
+  # access_key = ""
+  # secret_key = ""
+  # token = ""
+  # role_arn = ""
+  # profile = ""
+  # shared_credential_file = ""
+
+  ## Endpoint to make request against, the correct endpoint is automatically
+  ## determined and this option should only be set if you wish to override the
+  ## default.
+  ##   ex: endpoint_url = "http://localhost:9324"
+  # endpoint_url = ""
+
+  ## URL of the queue, it must exist prior to starting circonus-unified-agent.
+  queue_url = "https://sqs.us-east-1.amazonaws.com/123456789012/metrics"
+
+  ## Maximum number of messages returned by a receive request (1 to 10).
+  # max_messages = 10
+
+  ## Long polling duration of a receive request (at most 20s).
+  # wait_time = "20s"
+
+  ## Visibility timeout of the received messages, defaults to the timeout of
+  ## the queue. A message is deleted once its metrics are written by the
+  ## outputs, and made visible again when they failed to be written.
+  # visibility_timeout = "30s"
+
+  ## Maximum messages to read from the queue that have not been written by an
+  ## output.  For best throughput set based on the number of metrics within
+  ## each message and the size of the output's metric_batch_size.
+  ##
+  ## For example, if each message from the queue contains 10 metrics and the
+  ## output metric_batch_size is 1000, setting this to 100 will ensure that a
+  ## full batch is collected and the write is triggered immediately without
+  ## waiting until the next flush_interval.
+  # max_undelivered_messages = 1000
+
+  ## Data format to consume.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+func (s *SQSConsumer) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SQSConsumer) Description() string {
+	return "Read metrics from an AWS SQS queue"
+}
+
+func (s *SQSConsumer) SetParser(parser parsers.Parser) {
+	s.parser = parser
+}
+
+func (s *SQSConsumer) Init() error {
+	if s.QueueURL == "" {
+		return fmt.Errorf("queue_url is required")
+	}
+	if s.MaxMessages < 1 || s.MaxMessages > 10 {
+		return fmt.Errorf("max_messages must be between 1 and 10")
+	}
+	if s.WaitTime.Duration < 0 || s.WaitTime.Duration > 20*time.Second {
+		return fmt.Errorf("wait_time must be at most 20s")
+	}
+	if s.MaxUndeliveredMessages < int(s.MaxMessages) {
+		return fmt.Errorf("max_undelivered_messages must be at least max_messages")
+	}
+	return nil
+}
+
+func (s *SQSConsumer) Start(ctx context.Context, acc cua.Accumulator) error {
+	if s.client == nil {
+		credentialConfig := &internalaws.CredentialConfig{
+			Region:      s.Region,
+			AccessKey:   s.AccessKey,
+			SecretKey:   s.SecretKey,
+			RoleARN:     s.RoleARN,
+			Profile:     s.Profile,
+			Filename:    s.Filename,
+			Token:       s.Token,
+			EndpointURL: s.EndpointURL,
+		}
+		configProvider, err := credentialConfig.Credentials()
+		if err != nil {
+			return fmt.Errorf("credentials: %w", err)
+		}
+		s.client = sqs.New(configProvider)
+	}
+
+	s.acc = acc.WithTracking(s.MaxUndeliveredMessages)
+	s.sem = make(chan struct{}, s.MaxUndeliveredMessages)
+	s.undelivered = make(map[cua.TrackingID]*string)
+
+	sctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.onDelivery(sctx)
+	}()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.receiver(sctx)
+	}()
+
+	return nil
+}
+
+// receiver polls the queue while fewer than max_undelivered_messages are
+// waiting for their metrics to be written
+func (s *SQSConsumer) receiver(ctx context.Context) {
+	for {
+		// reserve a slot for every message a request can return
+		for i := int64(0); i < s.MaxMessages; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case s.sem <- struct{}{}:
+			}
+		}
+
+		received, err := s.receive(ctx)
+		for i := received; i < int(s.MaxMessages); i++ {
+			<-s.sem
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.Log.Errorf("Receiving from %s: %s", s.QueueURL, err.Error())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+// receive reads a batch of messages, it returns the number of messages
+// holding a slot until they are delivered
+func (s *SQSConsumer) receive(ctx context.Context) (int, error) {
+	input := &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(s.QueueURL),
+		MaxNumberOfMessages: aws.Int64(s.MaxMessages),
+		WaitTimeSeconds:     aws.Int64(int64(s.WaitTime.Duration.Seconds())),
+	}
+	if s.VisibilityTimeout.Duration > 0 {
+		input.VisibilityTimeout = aws.Int64(int64(s.VisibilityTimeout.Duration.Seconds()))
+	}
+
+	out, err := s.client.ReceiveMessageWithContext(ctx, input)
+	if err != nil {
+		return 0, fmt.Errorf("receive message: %w", err)
+	}
+
+	tracked := 0
+	for _, msg := range out.Messages {
+		metrics, err := s.parser.Parse([]byte(aws.StringValue(msg.Body)))
+		if err != nil {
+			s.Log.Errorf("Message %s: %s", aws.StringValue(msg.MessageId), err.Error())
+			// a message that cannot be parsed is not received again
+			s.delete(ctx, msg.ReceiptHandle)
+			continue
+		}
+		if len(metrics) == 0 {
+			s.delete(ctx, msg.ReceiptHandle)
+			continue
+		}
+
+		s.mu.Lock()
+		id := s.acc.AddTrackingMetricGroup(metrics)
+		s.undelivered[id] = msg.ReceiptHandle
+		s.mu.Unlock()
+		tracked++
+	}
+	return tracked, nil
+}
+
+// onDelivery deletes the messages of delivered metrics, the messages of
+// metrics the outputs failed to write are made visible again
+func (s *SQSConsumer) onDelivery(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case info := <-s.acc.Delivered():
+			s.delivered(ctx, info)
+		}
+	}
+}
+
+func (s *SQSConsumer) delivered(ctx context.Context, info cua.DeliveryInfo) {
+	s.mu.Lock()
+	handle, ok := s.undelivered[info.ID()]
+	delete(s.undelivered, info.ID())
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if info.Delivered() {
+		s.delete(ctx, handle)
+	} else {
+		s.release(ctx, handle)
+	}
+	<-s.sem
+}
+
+func (s *SQSConsumer) delete(ctx context.Context, handle *string) {
+	_, err := s.client.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(s.QueueURL),
+		ReceiptHandle: handle,
+	})
+	if err != nil && ctx.Err() == nil {
+		s.Log.Errorf("Deleting message from %s: %s", s.QueueURL, err.Error())
+	}
+}
+
+func (s *SQSConsumer) release(ctx context.Context, handle *string) {
+	_, err := s.client.ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(s.QueueURL),
+		ReceiptHandle:     handle,
+		VisibilityTimeout: aws.Int64(0),
+	})
+	if err != nil && ctx.Err() == nil {
+		s.Log.Errorf("Releasing message of %s: %s", s.QueueURL, err.Error())
+	}
+}
+
+func (s *SQSConsumer) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *SQSConsumer) Gather(_ context.Context, _ cua.Accumulator) error {
+	return nil
+}
+
+func init() {
+	inputs.Add("sqs_consumer", func() cua.Input {
+		return &SQSConsumer{
+			MaxMessages:            defaultMaxMessages,
+			WaitTime:               internal.Duration{Duration: defaultWaitTime},
+			MaxUndeliveredMessages: defaultMaxUndeliveredMessages,
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package sqsconsumer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers/value"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSQS struct {
+	sqsiface.SQSAPI
+
+	mu       sync.Mutex
+	messages []*sqs.Message
+	deleted  []string
+	released []string
+}
+
+func (f *fakeSQS) ReceiveMessageWithContext(ctx aws.Context, in *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	n := int(aws.Int64Value(in.MaxNumberOfMessages))
+	if n > len(f.messages) {
+		n = len(f.messages)
+	}
+	out := &sqs.ReceiveMessageOutput{Messages: f.messages[:n]}
+	f.messages = f.messages[n:]
+	f.mu.Unlock()
+
+	if n == 0 {
+		// long polling on an empty queue
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSQS) DeleteMessageWithContext(_ aws.Context, in *sqs.DeleteMessageInput, _ ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, aws.StringValue(in.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibilityWithContext(_ aws.Context, in *sqs.ChangeMessageVisibilityInput, _ ...request.Option) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.released = append(f.released, aws.StringValue(in.ReceiptHandle))
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *fakeSQS) handles() ([]string, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.deleted...), append([]string{}, f.released...)
+}
+
+type deliveryInfo struct {
+	id        cua.TrackingID
+	delivered bool
+}
+
+func (d deliveryInfo) ID() cua.TrackingID { return d.id }
+func (d deliveryInfo) Delivered() bool    { return d.delivered }
+
+func message(id, body string) *sqs.Message {
+	return &sqs.Message{MessageId: aws.String(id), ReceiptHandle: aws.String(id), Body: aws.String(body)}
+}
+
+func TestInit(t *testing.T) {
+	s := &SQSConsumer{
+		MaxMessages:            defaultMaxMessages,
+		WaitTime:               internal.Duration{Duration: defaultWaitTime},
+		MaxUndeliveredMessages: defaultMaxUndeliveredMessages,
+	}
+	require.Error(t, s.Init())
+
+	s.QueueURL = "https://sqs.us-east-1.amazonaws.com/123456789012/metrics"
+	require.NoError(t, s.Init())
+
+	s.MaxMessages = 11
+	require.Error(t, s.Init())
+
+	s.MaxMessages = defaultMaxMessages
+	s.WaitTime.Duration = time.Minute
+	require.Error(t, s.Init())
+}
+
+func TestConsume(t *testing.T) {
+	fake := &fakeSQS{
+		messages: []*sqs.Message{
+			message("1", "42"),
+			message("2", "not a number"),
+			message("3", "43"),
+		},
+	}
+	s := &SQSConsumer{
+		QueueURL:               "https://sqs.us-east-1.amazonaws.com/123456789012/metrics",
+		MaxMessages:            2,
+		MaxUndeliveredMessages: 10,
+		Log:                    testutil.Logger{},
+		client:                 fake,
+		parser:                 &value.Parser{MetricName: "cpu", DataType: "integer"},
+	}
+	require.NoError(t, s.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(context.Background(), acc))
+	defer s.Stop()
+
+	acc.Wait(2)
+	require.Equal(t, []int64{42, 43}, []int64{
+		acc.GetCUAMetrics()[0].Fields()["value"].(int64),
+		acc.GetCUAMetrics()[1].Fields()["value"].(int64),
+	})
+
+	// the unparsable message is deleted
+	deleted, released := fake.handles()
+	require.Equal(t, []string{"2"}, deleted)
+	require.Empty(t, released)
+
+	s.mu.Lock()
+	undelivered := make(map[cua.TrackingID]string)
+	for id, handle := range s.undelivered {
+		undelivered[id] = aws.StringValue(handle)
+	}
+	s.mu.Unlock()
+	require.Len(t, undelivered, 2)
+
+	for id, handle := range undelivered {
+		s.delivered(context.Background(), deliveryInfo{id: id, delivered: handle == "1"})
+	}
+
+	deleted, released = fake.handles()
+	require.Equal(t, []string{"2", "1"}, deleted)
+	require.Equal(t, []string{"3"}, released)
+	require.Empty(t, s.undelivered)
+}
@@ -0,0 +1,110 @@
+package stackdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	deltaModeRaw     = "raw"
+	deltaModeCounter = "counter"
+	deltaModeRate    = "rate"
+
+	// deltaExpiryWindows is the number of gather windows, of at least the
+	// default window, a series may go without new points before its running
+	// total is dropped
+	deltaExpiryWindows = 5
+)
+
+// deltaTotal is the running total of the DELTA points of a series, end is
+// the end time of the last point added so that points read again by
+// overlapping windows are not counted twice
+type deltaTotal struct {
+	Value float64 `json:"value"`
+	End   int64   `json:"end"`
+}
+
+// deltaCounters accumulates the DELTA points of each series into counters
+type deltaCounters struct {
+	sync.Mutex
+	totals map[string]*deltaTotal
+}
+
+func newDeltaCounters() *deltaCounters {
+	return &deltaCounters{totals: make(map[string]*deltaTotal)}
+}
+
+// add adds the point ending at end to the counter of the series and returns
+// the total, ok is false when the point was already added
+func (c *deltaCounters) add(series string, end int64, value float64) (float64, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	total, found := c.totals[series]
+	if !found {
+		total = &deltaTotal{}
+		c.totals[series] = total
+	}
+	if found && end <= total.End {
+		return total.Value, false
+	}
+	total.Value += value
+	total.End = end
+	return total.Value, true
+}
+
+// expire drops the totals of the series without a point ending after before
+func (c *deltaCounters) expire(before int64) {
+	c.Lock()
+	defer c.Unlock()
+	for series, total := range c.totals {
+		if total.End < before {
+			delete(c.totals, series)
+		}
+	}
+}
+
+func (c *deltaCounters) marshal() ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+	data, err := json.Marshal(c.totals)
+	if err != nil {
+		return nil, fmt.Errorf("marshal delta counters: %w", err)
+	}
+	return data, nil
+}
+
+func (c *deltaCounters) unmarshal(data []byte) error {
+	totals := make(map[string]*deltaTotal)
+	if err := json.Unmarshal(data, &totals); err != nil {
+		return fmt.Errorf("unmarshal delta counters: %w", err)
+	}
+	c.Lock()
+	c.totals = totals
+	c.Unlock()
+	return nil
+}
+
+// seriesKey identifies the series of a field by the measurement and tags
+func seriesKey(measurement, field string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(measurement)
+	b.WriteByte(0)
+	b.WriteString(field)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package stackdriver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	circmgr "github.com/circonus-labs/circonus-unified-agent/internal/circonus"
+)
+
+// preset is a curated bundle of the options commonly used to collect the
+// metrics of one GCP service
+type preset struct {
+	metricTypePrefixes []string
+	aligners           []string
+	tagExclude         []string
+}
+
+var presets = map[string]preset{
+	"gke": {
+		metricTypePrefixes: []string{
+			"kubernetes.io/container/",
+			"kubernetes.io/pod/",
+			"kubernetes.io/node/",
+		},
+	},
+	"cloudsql": {
+		metricTypePrefixes: []string{
+			"cloudsql.googleapis.com/database/",
+		},
+	},
+	"gce": {
+		metricTypePrefixes: []string{
+			"compute.googleapis.com/instance/",
+		},
+		// instance_name identifies the instance
+		tagExclude: []string{"instance_id"},
+	},
+	"lb": {
+		metricTypePrefixes: []string{
+			"loadbalancing.googleapis.com/https/",
+			"loadbalancing.googleapis.com/tcp_ssl_proxy/",
+			"loadbalancing.googleapis.com/l3/",
+		},
+		aligners: []string{
+			"ALIGN_PERCENTILE_99",
+			"ALIGN_PERCENTILE_95",
+			"ALIGN_PERCENTILE_50",
+		},
+		// one series per country and response code explodes the cardinality
+		tagExclude: []string{"client_country", "response_code"},
+	},
+}
+
+// presetNames returns the sorted names of the known presets
+func presetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyPresets adds the metric type prefixes, aligners and label excludes of
+// the configured presets to the options set by the user, the metric type
+// prefixes of the presets replace the default prefixes of all GCP services
+func (s *Stackdriver) applyPresets() error {
+	if len(s.Preset) != 0 && sameStrings(s.MetricTypePrefixInclude, circmgr.GCPMetricTypePrefixInclude()) {
+		s.MetricTypePrefixInclude = nil
+	}
+	for _, name := range s.Preset {
+		p, ok := presets[name]
+		if !ok {
+			return fmt.Errorf("unknown preset %q, must be one of %s", name, strings.Join(presetNames(), ", "))
+		}
+		s.MetricTypePrefixInclude = appendUnique(s.MetricTypePrefixInclude, p.metricTypePrefixes...)
+		s.DistributionAggregationAligners = appendUnique(s.DistributionAggregationAligners, p.aligners...)
+		s.TagExclude = appendUnique(s.TagExclude, p.tagExclude...)
+	}
+	return nil
+}
+
+// sameStrings reports whether both lists hold the same strings in any order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, v := range a {
+		set[v] = true
+	}
+	for _, v := range b {
+		if !set[v] {
+			return false
+		}
+	}
+	return true
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3"
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
 	circmgr "github.com/circonus-labs/circonus-unified-agent/internal/circonus"
 	"github.com/circonus-labs/circonus-unified-agent/internal/limiter"
@@ -39,6 +41,13 @@ const (
   ## the agent level interval with a value of 1m or greater.
   interval = "1m"
 
+  ## Presets collect the metrics of common services with vetted metric
+  ## types, distribution aligners and label excludes, they are added to the
+  ## options below.  Without metric_type_prefix_include the metric types of
+  ## the presets replace the default of all GCP services.  Available
+  ## presets: gke, cloudsql, gce, lb
+  # preset = ["gke"]
+
   ## Maximum number of API calls to make per second.  The quota for accounts
   ## varies, it can be viewed on the API dashboard:
   ##   https://cloud.google.com/monitoring/quotas#quotas_and_limits
@@ -77,6 +86,31 @@ const (
   # 	"ALIGN_PERCENTILE_50",
   # ]
 
+  ## How the points of DELTA metrics are emitted:
+  ##   raw:     the value of each window (default)
+  ##   counter: running counters per series, saved across restarts when the
+  ##            agent state_directory is set
+  ##   rate:    the value of each window divided by its length in seconds
+  # delta_metrics = "raw"
+
+  ## Resource and metric labels added as tags, glob patterns matching the
+  ## label names.  Labels not included or excluded are dropped, which helps
+  ## with resources carrying many labels such as dataflow jobs.
+  # tag_include = []
+  # tag_exclude = []
+
+  ## Metric types starting with the prefix can be given another measurement
+  ## name, renamed fields and additional static tags, e.g. to line up with the
+  ## naming of other clouds.  The fields are named after the last component of
+  ## the metric type, the mapping with the longest matching prefix is used.
+  # [[inputs.stackdriver_circonus.metric_type_mapping]]
+  #   metric_type_prefix = "compute.googleapis.com/instance/cpu/"
+  #   measurement = "cpu"
+  #   [inputs.stackdriver_circonus.metric_type_mapping.fields]
+  #     utilization = "usage_percent"
+  #   [inputs.stackdriver_circonus.metric_type_mapping.tags]
+  #     cloud = "gcp"
+
   ## Filters can be added to reduce the number of time series matched.  All
   ## functions are supported: starts_with, ends_with, has_substring, and
   ## one_of.  Only the '=' operator is supported.
@@ -115,10 +149,17 @@ type Stackdriver struct {
 	client                          metricClient
 	Log                             cua.Logger
 	timeSeriesConfCache             *timeSeriesConfCache
+	labelFilter                     filter.Filter
+	deltas                          *deltaCounters
 	Filter                          *ListTimeSeriesFilter `toml:"filter"`
+	MetricTypeMappings              []*MetricTypeMapping  `toml:"metric_type_mapping"`
 	Project                         string                `toml:"project"`
+	Preset                          []string              `toml:"preset"`
 	MetricTypePrefixExclude         []string
 	MetricTypePrefixInclude         []string
+	TagInclude                      []string          `toml:"tag_include"`
+	TagExclude                      []string          `toml:"tag_exclude"`
+	DeltaMetrics                    string            `toml:"delta_metrics"`
 	DistributionAggregationAligners []string          `toml:"distribution_aggregation_aligners"`
 	CacheTTL                        internal.Duration `toml:"cache_ttl"`
 	Delay                           internal.Duration `toml:"delay"`
@@ -133,6 +174,15 @@ type ListTimeSeriesFilter struct {
 	MetricLabels   []*Label `json:"metric_labels"`
 }
 
+// MetricTypeMapping renames the measurement and fields and adds static
+// tags to the metrics of the metric types starting with the prefix
+type MetricTypeMapping struct {
+	MetricTypePrefix string            `toml:"metric_type_prefix"`
+	Measurement      string            `toml:"measurement"`
+	Fields           map[string]string `toml:"fields"`
+	Tags             map[string]string `toml:"tags"`
+}
+
 // Label contains key and value
 type Label struct {
 	Key   string `toml:"key"`
@@ -156,6 +206,11 @@ type timeSeriesConf struct {
 	// this time series. (Or, if we only decide to write one field name, this
 	// field just holds the value of the field name.)
 	fieldKey string
+	// The metric_category tag, the last component of the measurement before
+	// any rename
+	category string
+	// Static tags added to the metrics of this time series
+	tags map[string]string
 }
 
 // stackdriverMetricClient is a metric client for stackdriver
@@ -279,6 +334,11 @@ func (s *Stackdriver) SampleConfig() string {
 	return sampleConfig
 }
 
+// Init implements cua.Initializer interface
+func (s *Stackdriver) Init() error {
+	return s.applyPresets()
+}
+
 // Gather implements cua.Input interface
 func (s *Stackdriver) Gather(ctx context.Context, acc cua.Accumulator) error {
 
@@ -286,6 +346,25 @@ func (s *Stackdriver) Gather(ctx context.Context, acc cua.Accumulator) error {
 		s.RateLimit = defaultRateLimit
 	}
 
+	switch s.DeltaMetrics {
+	case "":
+		s.DeltaMetrics = deltaModeRaw
+	case deltaModeRaw, deltaModeCounter, deltaModeRate:
+	default:
+		return fmt.Errorf("invalid delta_metrics %q, must be raw, counter or rate", s.DeltaMetrics)
+	}
+	if s.deltas == nil {
+		s.deltas = newDeltaCounters()
+	}
+
+	if s.labelFilter == nil {
+		labelFilter, err := filter.NewIncludeExcludeFilter(s.TagInclude, s.TagExclude)
+		if err != nil {
+			return fmt.Errorf("tag_include/tag_exclude: %w", err)
+		}
+		s.labelFilter = labelFilter
+	}
+
 	err := s.initializeStackdriverClient(ctx)
 	if err != nil {
 		return err
@@ -321,9 +400,35 @@ func (s *Stackdriver) Gather(ctx context.Context, acc cua.Accumulator) error {
 		acc.AddMetric(metric)
 	}
 
+	window := end.Sub(start)
+	if window < defaultWindow.Duration {
+		window = defaultWindow.Duration
+	}
+	s.deltas.expire(end.Add(-deltaExpiryWindows * window).Unix())
+
 	return nil
 }
 
+// GetState implements cua.PersistentState interface, the state holds the
+// counters of the DELTA metrics
+func (s *Stackdriver) GetState() ([]byte, error) {
+	if s.deltas == nil {
+		return nil, nil
+	}
+	return s.deltas.marshal()
+}
+
+// SetState implements cua.PersistentState interface
+func (s *Stackdriver) SetState(state []byte) error {
+	if len(state) == 0 {
+		return nil
+	}
+	if s.deltas == nil {
+		s.deltas = newDeltaCounters()
+	}
+	return s.deltas.unmarshal(state)
+}
+
 // Returns the start and end time for the next collection.
 func (s *Stackdriver) updateWindow(prevEnd time.Time) (time.Time, time.Time) {
 	var start time.Time
@@ -420,9 +525,40 @@ func (s *Stackdriver) newTimeSeriesConf(
 		cfg.fieldKey = metricType[slashIdx+1:]
 	}
 
+	// add metric category to prevent collisions
+	cfg.category = cfg.measurement
+	if slashIdx = strings.LastIndex(cfg.measurement, "/"); slashIdx > 0 {
+		cfg.category = cfg.measurement[slashIdx+1:]
+	}
+
+	if mapping := s.metricTypeMapping(metricType); mapping != nil {
+		if mapping.Measurement != "" {
+			cfg.measurement = mapping.Measurement
+		}
+		if field, ok := mapping.Fields[cfg.fieldKey]; ok {
+			cfg.fieldKey = field
+		}
+		cfg.tags = mapping.Tags
+	}
+
 	return cfg
 }
 
+// Returns the mapping with the longest prefix of the metric type, nil when
+// none matches
+func (s *Stackdriver) metricTypeMapping(metricType string) *MetricTypeMapping {
+	var match *MetricTypeMapping
+	for _, mapping := range s.MetricTypeMappings {
+		if !strings.HasPrefix(metricType, mapping.MetricTypePrefix) {
+			continue
+		}
+		if match == nil || len(mapping.MetricTypePrefix) > len(match.MetricTypePrefix) {
+			match = mapping
+		}
+	}
+	return match
+}
+
 // Change this configuration to query an aggregate by specifying an "aligner".
 // In GCP monitoring, "aligning" is aggregation performed *within* a time
 // series, to distill a pile of data points down to a single data point for
@@ -615,21 +751,22 @@ func (s *Stackdriver) gatherTimeSeries(
 			"project_id":    s.Project,
 		}
 		for k, v := range tsDesc.Resource.Labels {
-			tags[k] = v
+			if s.labelFilter == nil || s.labelFilter.Match(k) {
+				tags[k] = v
+			}
 		}
 		for k, v := range tsDesc.Metric.Labels {
-			tags[k] = v
+			if s.labelFilter == nil || s.labelFilter.Match(k) {
+				tags[k] = v
+			}
 		}
 
-		// add metric category to prevent collisions
-		slashIdx := strings.LastIndex(tsConf.measurement, "/")
-		if slashIdx > 0 {
-			cat := tsConf.measurement[slashIdx+1:]
-			tags["metric_category"] = cat
-		} else {
-			tags["metric_category"] = tsConf.measurement
+		for k, v := range tsConf.tags {
+			tags[k] = v
 		}
 
+		tags["metric_category"] = tsConf.category
+
 		// add stackdriver metrickind as a tag so proper math can applied ex-post facto
 		switch tsDesc.MetricKind {
 		case metricpb.MetricDescriptor_METRIC_KIND_UNSPECIFIED:
@@ -642,9 +779,32 @@ func (s *Stackdriver) gatherTimeSeries(
 			tags["metric_kind"] = "cumulative"
 		}
 
+		// numeric DELTA points are emitted as running counters or rates when
+		// configured, the metric kind tag reflects the emitted values
+		deltaMode := deltaModeRaw
+		if tsDesc.MetricKind == metricpb.MetricDescriptor_DELTA &&
+			(tsDesc.ValueType == metricpb.MetricDescriptor_INT64 || tsDesc.ValueType == metricpb.MetricDescriptor_DOUBLE) {
+			deltaMode = s.DeltaMetrics
+		}
+		points := tsDesc.Points
+		var series string
+		switch deltaMode {
+		case deltaModeCounter:
+			tags["metric_kind"] = "cumulative"
+			series = seriesKey(tsConf.measurement, tsConf.fieldKey, tags)
+			// points are listed newest first, they are added oldest first
+			points = make([]*monitoringpb.Point, len(tsDesc.Points))
+			copy(points, tsDesc.Points)
+			sort.Slice(points, func(i, j int) bool {
+				return points[i].Interval.EndTime.Seconds < points[j].Interval.EndTime.Seconds
+			})
+		case deltaModeRate:
+			tags["metric_kind"] = "gauge"
+		}
+
 		// s.Log.Debugf("%s %v %v\n", tsConf.fieldKey, tags, tsDesc.ValueType)
 
-		for _, p := range tsDesc.Points {
+		for _, p := range points {
 			ts := time.Unix(p.Interval.EndTime.Seconds, 0)
 
 			if tsDesc.ValueType == metricpb.MetricDescriptor_DISTRIBUTION {
@@ -672,6 +832,24 @@ func (s *Stackdriver) gatherTimeSeries(
 					value = p.Value.GetStringValue()
 				}
 
+				switch deltaMode {
+				case deltaModeCounter:
+					total, added := s.deltas.add(series, p.Interval.EndTime.Seconds, toFloat(value))
+					if !added {
+						continue
+					}
+					value = total
+					if tsDesc.ValueType == metricpb.MetricDescriptor_INT64 {
+						value = int64(total)
+					}
+				case deltaModeRate:
+					seconds := p.Interval.EndTime.Seconds - p.Interval.GetStartTime().GetSeconds()
+					if p.Interval.StartTime == nil || seconds <= 0 {
+						continue
+					}
+					value = toFloat(value) / float64(seconds)
+				}
+
 				_ = grouper.Add(tsConf.measurement, tags, ts, tsConf.fieldKey, value)
 			}
 			if s.done(ctx) {
@@ -686,6 +864,16 @@ func (s *Stackdriver) gatherTimeSeries(
 	return nil
 }
 
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
 func distributionToCircHisto(s *Stackdriver, //nolint:unparam
 	metric *distributionpb.Distribution,
 	options *distributionpb.Distribution_BucketOptions) map[string]int64 {
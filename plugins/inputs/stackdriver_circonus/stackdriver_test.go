@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	circmgr "github.com/circonus-labs/circonus-unified-agent/internal/circonus"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/golang/protobuf/ptypes/timestamp"
@@ -1134,3 +1135,344 @@ func TestNewListTimeSeriesFilter(t *testing.T) {
 
 func TestTimeSeriesConfCacheIsValid(t *testing.T) {
 }
+
+func TestGatherMetricTypeMapping(t *testing.T) {
+	now := time.Now().Round(time.Second)
+	descriptors := []*metricpb.MetricDescriptor{
+		{Type: "compute.googleapis.com/instance/cpu/utilization", ValueType: metricpb.MetricDescriptor_DOUBLE},
+		{Type: "compute.googleapis.com/instance/cpu/reserved_cores", ValueType: metricpb.MetricDescriptor_DOUBLE},
+		{Type: "compute.googleapis.com/instance/uptime", ValueType: metricpb.MetricDescriptor_DOUBLE},
+	}
+
+	var acc testutil.Accumulator
+	s := &Stackdriver{
+		Log:       testutil.Logger{},
+		Project:   "test",
+		RateLimit: 10,
+		MetricTypeMappings: []*MetricTypeMapping{
+			{
+				MetricTypePrefix: "compute.googleapis.com/",
+				Tags:             map[string]string{"cloud": "gcp"},
+			},
+			{
+				MetricTypePrefix: "compute.googleapis.com/instance/cpu/",
+				Measurement:      "cpu",
+				Fields:           map[string]string{"utilization": "usage_percent"},
+				Tags:             map[string]string{"cloud": "gcp", "service": "compute"},
+			},
+		},
+		client: &MockStackdriverClient{
+			ListMetricDescriptorsF: func(ctx context.Context, req *monitoringpb.ListMetricDescriptorsRequest) (<-chan *metricpb.MetricDescriptor, error) {
+				ch := make(chan *metricpb.MetricDescriptor, len(descriptors))
+				for _, d := range descriptors {
+					ch <- d
+				}
+				close(ch)
+				return ch, nil
+			},
+			ListTimeSeriesF: func(ctx context.Context, req *monitoringpb.ListTimeSeriesRequest) (<-chan *monitoringpb.TimeSeries, error) {
+				ch := make(chan *monitoringpb.TimeSeries, 1)
+				ch <- createTimeSeries(
+					&monitoringpb.Point{
+						Interval: &monitoringpb.TimeInterval{
+							EndTime: &timestamp.Timestamp{Seconds: now.Unix()},
+						},
+						Value: &monitoringpb.TypedValue{
+							Value: &monitoringpb.TypedValue_DoubleValue{DoubleValue: 42.0},
+						},
+					},
+					metricpb.MetricDescriptor_DOUBLE,
+				)
+				close(ch)
+				return ch, nil
+			},
+			CloseF: func() error {
+				return nil
+			},
+		},
+	}
+
+	require.NoError(t, s.Gather(context.Background(), &acc))
+
+	tags := func(category string, extra map[string]string) map[string]string {
+		tags := map[string]string{
+			"resource_type":   "global",
+			"project_id":      "test",
+			"metric_category": category,
+			"metric_kind":     "unspecified",
+		}
+		for k, v := range extra {
+			tags[k] = v
+		}
+		return tags
+	}
+	expected := []cua.Metric{
+		testutil.MustMetric("cpu",
+			tags("cpu", map[string]string{"cloud": "gcp", "service": "compute"}),
+			map[string]interface{}{"usage_percent": 42.0, "reserved_cores": 42.0},
+			now),
+		testutil.MustMetric("compute.googleapis.com/instance",
+			tags("instance", map[string]string{"cloud": "gcp"}),
+			map[string]interface{}{"uptime": 42.0},
+			now),
+	}
+
+	actual := []cua.Metric{}
+	for _, m := range acc.Metrics {
+		actual = append(actual, testutil.FromTestMetric(m))
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+}
+
+func TestGatherTagIncludeExclude(t *testing.T) {
+	now := time.Now().Round(time.Second)
+	ts := createTimeSeries(
+		&monitoringpb.Point{
+			Interval: &monitoringpb.TimeInterval{
+				EndTime: &timestamp.Timestamp{Seconds: now.Unix()},
+			},
+			Value: &monitoringpb.TypedValue{
+				Value: &monitoringpb.TypedValue_DoubleValue{DoubleValue: 42.0},
+			},
+		},
+		metricpb.MetricDescriptor_DOUBLE,
+	)
+	ts.Resource.Labels["job_id"] = "2020-09-13_12_00_00-123"
+	ts.Resource.Labels["job_name"] = "ingest"
+	ts.Metric.Labels["label_team"] = "data"
+	ts.Metric.Labels["label_owner"] = "someone"
+
+	var acc testutil.Accumulator
+	s := &Stackdriver{
+		Log:        testutil.Logger{},
+		Project:    "test",
+		RateLimit:  10,
+		TagInclude: []string{"job_*", "label_*"},
+		TagExclude: []string{"job_id", "label_owner"},
+		client: &MockStackdriverClient{
+			ListMetricDescriptorsF: func(ctx context.Context, req *monitoringpb.ListMetricDescriptorsRequest) (<-chan *metricpb.MetricDescriptor, error) {
+				ch := make(chan *metricpb.MetricDescriptor, 1)
+				ch <- &metricpb.MetricDescriptor{
+					Type:      "dataflow.googleapis.com/job/elapsed_time",
+					ValueType: metricpb.MetricDescriptor_DOUBLE,
+				}
+				close(ch)
+				return ch, nil
+			},
+			ListTimeSeriesF: func(ctx context.Context, req *monitoringpb.ListTimeSeriesRequest) (<-chan *monitoringpb.TimeSeries, error) {
+				ch := make(chan *monitoringpb.TimeSeries, 1)
+				ch <- ts
+				close(ch)
+				return ch, nil
+			},
+			CloseF: func() error {
+				return nil
+			},
+		},
+	}
+
+	require.NoError(t, s.Gather(context.Background(), &acc))
+
+	expected := []cua.Metric{
+		testutil.MustMetric("dataflow.googleapis.com/job",
+			map[string]string{
+				"resource_type":   "global",
+				"project_id":      "test",
+				"metric_category": "job",
+				"metric_kind":     "unspecified",
+				"job_name":        "ingest",
+				"label_team":      "data",
+			},
+			map[string]interface{}{"elapsed_time": 42.0},
+			now),
+	}
+
+	actual := []cua.Metric{}
+	for _, m := range acc.Metrics {
+		actual = append(actual, testutil.FromTestMetric(m))
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func deltaTimeSeries(points ...*monitoringpb.Point) *monitoringpb.TimeSeries {
+	ts := &monitoringpb.TimeSeries{
+		Metric: &metricpb.Metric{Labels: map[string]string{}},
+		Resource: &monitoredres.MonitoredResource{
+			Type:   "global",
+			Labels: map[string]string{},
+		},
+		MetricKind: metricpb.MetricDescriptor_DELTA,
+		ValueType:  metricpb.MetricDescriptor_INT64,
+		Points:     points,
+	}
+	return ts
+}
+
+func deltaPoint(start, end time.Time, value int64) *monitoringpb.Point {
+	return &monitoringpb.Point{
+		Interval: &monitoringpb.TimeInterval{
+			StartTime: &timestamp.Timestamp{Seconds: start.Unix()},
+			EndTime:   &timestamp.Timestamp{Seconds: end.Unix()},
+		},
+		Value: &monitoringpb.TypedValue{
+			Value: &monitoringpb.TypedValue_Int64Value{Int64Value: value},
+		},
+	}
+}
+
+func newDeltaStackdriver(mode string, series *[]*monitoringpb.TimeSeries) *Stackdriver {
+	return &Stackdriver{
+		Log:          testutil.Logger{},
+		Project:      "test",
+		RateLimit:    10,
+		DeltaMetrics: mode,
+		client: &MockStackdriverClient{
+			ListMetricDescriptorsF: func(ctx context.Context, req *monitoringpb.ListMetricDescriptorsRequest) (<-chan *metricpb.MetricDescriptor, error) {
+				ch := make(chan *metricpb.MetricDescriptor, 1)
+				ch <- &metricpb.MetricDescriptor{
+					Type:       "pubsub.googleapis.com/topic/send_request_count",
+					MetricKind: metricpb.MetricDescriptor_DELTA,
+					ValueType:  metricpb.MetricDescriptor_INT64,
+				}
+				close(ch)
+				return ch, nil
+			},
+			ListTimeSeriesF: func(ctx context.Context, req *monitoringpb.ListTimeSeriesRequest) (<-chan *monitoringpb.TimeSeries, error) {
+				ch := make(chan *monitoringpb.TimeSeries, len(*series))
+				for _, ts := range *series {
+					ch <- ts
+				}
+				close(ch)
+				return ch, nil
+			},
+			CloseF: func() error {
+				return nil
+			},
+		},
+	}
+}
+
+func deltaValues(t *testing.T, acc *testutil.Accumulator, kind string) []interface{} {
+	values := []interface{}{}
+	for _, m := range acc.Metrics {
+		require.Equal(t, kind, m.Tags["metric_kind"])
+		values = append(values, m.Fields["send_request_count"])
+	}
+	return values
+}
+
+func TestGatherDeltaCounter(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	series := []*monitoringpb.TimeSeries{
+		// newest point first, like the API
+		deltaTimeSeries(
+			deltaPoint(now.Add(-time.Minute), now, 5),
+			deltaPoint(now.Add(-2*time.Minute), now.Add(-time.Minute), 3),
+		),
+	}
+	s := newDeltaStackdriver("counter", &series)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Equal(t, []interface{}{int64(3), int64(8)}, deltaValues(t, &acc, "cumulative"))
+
+	// the overlapping point is not counted again
+	series = []*monitoringpb.TimeSeries{
+		deltaTimeSeries(
+			deltaPoint(now, now.Add(time.Minute), 2),
+			deltaPoint(now.Add(-time.Minute), now, 5),
+		),
+	}
+	acc.ClearMetrics()
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Equal(t, []interface{}{int64(10)}, deltaValues(t, &acc, "cumulative"))
+
+	// the counters continue from the saved state
+	state, err := s.GetState()
+	require.NoError(t, err)
+
+	series = []*monitoringpb.TimeSeries{
+		deltaTimeSeries(deltaPoint(now.Add(time.Minute), now.Add(2*time.Minute), 1)),
+	}
+	restarted := newDeltaStackdriver("counter", &series)
+	require.NoError(t, restarted.SetState(state))
+	acc.ClearMetrics()
+	require.NoError(t, restarted.Gather(context.Background(), &acc))
+	require.Equal(t, []interface{}{int64(11)}, deltaValues(t, &acc, "cumulative"))
+}
+
+func TestGatherDeltaCounterExpire(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	series := []*monitoringpb.TimeSeries{
+		deltaTimeSeries(deltaPoint(now.Add(-11*time.Minute), now.Add(-10*time.Minute), 5)),
+	}
+	s := newDeltaStackdriver("counter", &series)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Equal(t, []interface{}{int64(5)}, deltaValues(t, &acc, "cumulative"))
+
+	// the stale counter was dropped and starts again
+	series = []*monitoringpb.TimeSeries{
+		deltaTimeSeries(deltaPoint(now.Add(-time.Minute), now, 2)),
+	}
+	acc.ClearMetrics()
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Equal(t, []interface{}{int64(2)}, deltaValues(t, &acc, "cumulative"))
+}
+
+func TestGatherDeltaRate(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	series := []*monitoringpb.TimeSeries{
+		deltaTimeSeries(deltaPoint(now.Add(-time.Minute), now, 30)),
+	}
+	s := newDeltaStackdriver("rate", &series)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Equal(t, []interface{}{0.5}, deltaValues(t, &acc, "gauge"))
+}
+
+func TestGatherDeltaInvalid(t *testing.T) {
+	s := newDeltaStackdriver("sum", &[]*monitoringpb.TimeSeries{})
+	var acc testutil.Accumulator
+	require.Error(t, s.Gather(context.Background(), &acc))
+}
+
+func TestApplyPresets(t *testing.T) {
+	s := &Stackdriver{
+		Preset:                  []string{"gce", "lb"},
+		MetricTypePrefixInclude: []string{"compute.googleapis.com/instance/"},
+		TagExclude:              []string{"zone"},
+	}
+	require.NoError(t, s.applyPresets())
+	require.Equal(t, []string{
+		"compute.googleapis.com/instance/",
+		"loadbalancing.googleapis.com/https/",
+		"loadbalancing.googleapis.com/tcp_ssl_proxy/",
+		"loadbalancing.googleapis.com/l3/",
+	}, s.MetricTypePrefixInclude)
+	require.Equal(t, []string{"ALIGN_PERCENTILE_99", "ALIGN_PERCENTILE_95", "ALIGN_PERCENTILE_50"}, s.DistributionAggregationAligners)
+	require.Equal(t, []string{"zone", "instance_id", "client_country", "response_code"}, s.TagExclude)
+
+	s = &Stackdriver{Preset: []string{"gke", "bigtable"}}
+	require.Error(t, s.applyPresets())
+}
+
+func TestInitDefaultMetricTypes(t *testing.T) {
+	s := inputs.Inputs["stackdriver_circonus"]().(*Stackdriver)
+	require.NoError(t, s.Init())
+	require.ElementsMatch(t, circmgr.GCPMetricTypePrefixInclude(), s.MetricTypePrefixInclude)
+
+	// the presets replace the default metric types
+	s = inputs.Inputs["stackdriver_circonus"]().(*Stackdriver)
+	s.Preset = []string{"cloudsql"}
+	require.NoError(t, s.Init())
+	require.Equal(t, []string{"cloudsql.googleapis.com/database/"}, s.MetricTypePrefixInclude)
+
+	// an empty list set by the user is kept
+	s = inputs.Inputs["stackdriver_circonus"]().(*Stackdriver)
+	s.MetricTypePrefixInclude = []string{}
+	require.NoError(t, s.Init())
+	require.Empty(t, s.MetricTypePrefixInclude)
+}
@@ -0,0 +1,436 @@
+//go:build linux
+// +build linux
+
+// Package storagesessions reports SMB client and server sessions, per share
+// statistics and iSCSI session state and error counters.
+package storagesessions
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+// runner runs a command and returns its standard output
+type runner func(timeout time.Duration, useSudo bool, name string, args ...string) ([]byte, error)
+
+type StorageSessions struct {
+	CIFS          bool              `toml:"cifs"`
+	Smbstatus     bool              `toml:"smbstatus"`
+	SmbstatusPath string            `toml:"smbstatus_path"`
+	ISCSI         bool              `toml:"iscsi"`
+	IscsiadmPath  string            `toml:"iscsiadm_path"`
+	UseSudo       bool              `toml:"use_sudo"`
+	Timeout       internal.Duration `toml:"timeout"`
+	Log           cua.Logger        `toml:"-"`
+	procDir       string
+	sysDir        string
+	run           runner
+}
+
+const sampleConfig = `
+  ## Collect SMB client sessions and per share statistics from
+  ## /proc/fs/cifs/Stats
+  # cifs = true
+
+  ## Collect Samba server sessions and share connections with smbstatus
+  # smbstatus = false
+  # smbstatus_path = "smbstatus"
+
+  ## Collect iSCSI session state from sysfs and the session error counters
+  ## with iscsiadm
+  # iscsi = true
+  # iscsiadm_path = "iscsiadm"
+
+  ## smbstatus and iscsiadm usually require root, adjust your sudo settings
+  ## appropriately if using this option ("sudo smbstatus", "sudo iscsiadm")
+  # use_sudo = false
+
+  ## Timeout of the smbstatus and iscsiadm commands
+  # timeout = "5s"
+`
+
+func (s *StorageSessions) Description() string {
+	return "Read SMB/CIFS and iSCSI session statistics"
+}
+
+func (s *StorageSessions) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *StorageSessions) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if s.CIFS {
+		if err := s.gatherCIFS(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if s.Smbstatus {
+		if err := s.gatherSmbstatus(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if s.ISCSI {
+		if err := s.gatherISCSI(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+var (
+	// 1) \\server\share
+	cifsShareRe = regexp.MustCompile(`^\d+\) (\\\\([^\\]+)\\\S*)`)
+	// Reads: 16 total 0 failed, OplockBreaks: 0 sent 0 failed
+	cifsOpRe = regexp.MustCompile(`^(\w+): (\d+) \w+ (\d+) failed`)
+	// Bytes read: 1048576  Bytes written: 2097152
+	cifsBytesRe = regexp.MustCompile(`^Bytes read: (\d+)\s+Bytes written: (\d+)`)
+	// Open files: 2 total (local), 2 open on server
+	cifsOpenRe = regexp.MustCompile(`^Open files: (\d+) total \(local\), (\d+) open on server`)
+	// 0 session 0 share reconnects
+	cifsReconnectsRe = regexp.MustCompile(`^(\d+) session (\d+) share reconnects`)
+	// Total vfs operations: 100 maximum at one time: 3
+	cifsVfsRe = regexp.MustCompile(`^Total vfs operations: (\d+) maximum at one time: (\d+)`)
+
+	camelRe = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// gatherCIFS reports the SMB client statistics, a missing file means the
+// cifs module is not loaded and is not an error
+func (s *StorageSessions) gatherCIFS(acc cua.Accumulator) error {
+	path := filepath.Join(s.procDir, "fs/cifs/Stats")
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	gauges := make(map[string]interface{})
+	counters := make(map[string]interface{})
+	var shareTags map[string]string
+	var shareFields map[string]interface{}
+	flush := func() {
+		if shareTags != nil && len(shareFields) > 0 {
+			acc.AddCounter("cifs_share", shareFields, shareTags)
+		}
+		shareTags, shareFields = nil, nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if m := cifsShareRe.FindStringSubmatch(line); m != nil {
+			flush()
+			shareTags = map[string]string{"share": m[1], "server": m[2]}
+			shareFields = make(map[string]interface{})
+			continue
+		}
+
+		if shareTags == nil {
+			// global statistics before the first share
+			switch {
+			case strings.HasPrefix(line, "CIFS Session:"):
+				addUint(gauges, "sessions", strings.TrimPrefix(line, "CIFS Session:"))
+			case strings.HasPrefix(line, "Share (unique mount targets):"):
+				addUint(gauges, "shares", strings.TrimPrefix(line, "Share (unique mount targets):"))
+			case strings.HasPrefix(line, "Max requests in flight:"):
+				addUint(gauges, "max_requests_in_flight", strings.TrimPrefix(line, "Max requests in flight:"))
+			default:
+				if m := cifsReconnectsRe.FindStringSubmatch(line); m != nil {
+					addUint(counters, "session_reconnects", m[1])
+					addUint(counters, "share_reconnects", m[2])
+				} else if m := cifsVfsRe.FindStringSubmatch(line); m != nil {
+					addUint(counters, "vfs_operations", m[1])
+					addUint(gauges, "vfs_operations_max", m[2])
+				}
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "SMBs:"):
+			addUint(shareFields, "smbs", strings.TrimPrefix(line, "SMBs:"))
+		default:
+			if m := cifsBytesRe.FindStringSubmatch(line); m != nil {
+				addUint(shareFields, "read_bytes", m[1])
+				addUint(shareFields, "write_bytes", m[2])
+			} else if m := cifsOpenRe.FindStringSubmatch(line); m != nil {
+				addUint(shareFields, "open_files", m[1])
+				addUint(shareFields, "open_files_server", m[2])
+			} else if m := cifsOpRe.FindStringSubmatch(line); m != nil {
+				name := snakeCase(m[1])
+				addUint(shareFields, name, m[2])
+				addUint(shareFields, name+"_failed", m[3])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	flush()
+
+	if len(gauges) > 0 {
+		acc.AddGauge("cifs", gauges, nil)
+	}
+	if len(counters) > 0 {
+		acc.AddCounter("cifs", counters, nil)
+	}
+	return nil
+}
+
+// smbProtocolRe matches the protocol version column of smbstatus
+var smbProtocolRe = regexp.MustCompile(`^(SMB\d|NT1|LANMAN|CORE)`)
+
+// gatherSmbstatus reports the Samba sessions by protocol version and the
+// connections per share
+func (s *StorageSessions) gatherSmbstatus(acc cua.Accumulator) error {
+	out, err := s.run(s.Timeout.Duration, s.UseSudo, s.SmbstatusPath, "-b")
+	if err != nil {
+		return fmt.Errorf("smbstatus -b: %w", err)
+	}
+	parseSmbSessions(out, acc)
+
+	out, err = s.run(s.Timeout.Duration, s.UseSudo, s.SmbstatusPath, "-S")
+	if err != nil {
+		return fmt.Errorf("smbstatus -S: %w", err)
+	}
+	parseSmbShares(out, acc)
+	return nil
+}
+
+// smbRows returns the fields of the rows following the dashed line under
+// the column headers
+func smbRows(out []byte) [][]string {
+	var rows [][]string
+	inTable := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "---") {
+			inTable = true
+			continue
+		}
+		if !inTable {
+			continue
+		}
+		if line == "" {
+			break
+		}
+		rows = append(rows, strings.Fields(line))
+	}
+	return rows
+}
+
+// parseSmbSessions parses the rows of "smbstatus -b":
+// PID Username Group Machine Protocol-Version Encryption Signing
+func parseSmbSessions(out []byte, acc cua.Accumulator) {
+	type sessionCounts struct {
+		sessions, encrypted, signed uint64
+	}
+	byProtocol := make(map[string]*sessionCounts)
+	for _, row := range smbRows(out) {
+		// the machine column holds spaces, find the protocol after it
+		for i := 3; i < len(row); i++ {
+			if !smbProtocolRe.MatchString(row[i]) {
+				continue
+			}
+			c, ok := byProtocol[row[i]]
+			if !ok {
+				c = &sessionCounts{}
+				byProtocol[row[i]] = c
+			}
+			c.sessions++
+			if i+1 < len(row) && row[i+1] != "-" {
+				c.encrypted++
+			}
+			if i+2 < len(row) && row[i+2] != "-" {
+				c.signed++
+			}
+			break
+		}
+	}
+	for protocol, c := range byProtocol {
+		acc.AddGauge("samba_sessions", map[string]interface{}{
+			"sessions":           c.sessions,
+			"encrypted_sessions": c.encrypted,
+			"signed_sessions":    c.signed,
+		}, map[string]string{"protocol": protocol})
+	}
+}
+
+// parseSmbShares parses the rows of "smbstatus -S":
+// Service pid Machine Connected-at Encryption Signing
+func parseSmbShares(out []byte, acc cua.Accumulator) {
+	connections := make(map[string]uint64)
+	machines := make(map[string]map[string]bool)
+	for _, row := range smbRows(out) {
+		if len(row) < 3 {
+			continue
+		}
+		share := row[0]
+		connections[share]++
+		if machines[share] == nil {
+			machines[share] = make(map[string]bool)
+		}
+		machines[share][row[2]] = true
+	}
+	for share, n := range connections {
+		acc.AddGauge("samba_share", map[string]interface{}{
+			"connections": n,
+			"machines":    uint64(len(machines[share])),
+		}, map[string]string{"share": share})
+	}
+}
+
+// gatherISCSI reports the state of the sessions in sysfs and their
+// counters from "iscsiadm -m session -s"
+func (s *StorageSessions) gatherISCSI(acc cua.Accumulator) error {
+	dirs, err := filepath.Glob(filepath.Join(s.sysDir, "class/iscsi_session/session*"))
+	if err != nil {
+		return fmt.Errorf("list iscsi sessions: %w", err)
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	stats := make(map[string]map[string]interface{})
+	out, err := s.run(s.Timeout.Duration, s.UseSudo, s.IscsiadmPath, "-m", "session", "-s")
+	if err != nil {
+		acc.AddError(fmt.Errorf("iscsiadm -m session -s: %w", err))
+	} else {
+		stats = parseIscsiStats(out)
+	}
+
+	for _, dir := range dirs {
+		sid := strings.TrimPrefix(filepath.Base(dir), "session")
+		state := readSysfs(dir, "state")
+		tags := map[string]string{
+			"sid":    sid,
+			"target": readSysfs(dir, "targetname"),
+			"state":  strings.ToLower(state),
+		}
+
+		loggedIn := 0
+		if state == "LOGGED_IN" {
+			loggedIn = 1
+		}
+		fields := map[string]interface{}{"logged_in": loggedIn}
+		acc.AddGauge("iscsi_session", fields, tags)
+
+		if counters, ok := stats[sid]; ok && len(counters) > 0 {
+			acc.AddCounter("iscsi_session", counters, tags)
+		}
+	}
+	return nil
+}
+
+// iscsiSessionRe matches the header of the statistics of a session:
+// Stats for session [sid: 1, target: iqn..., portal: 10.0.0.5,3260]
+var iscsiSessionRe = regexp.MustCompile(`^Stats for session \[sid: (\d+),`)
+
+// parseIscsiStats returns the numeric counters by session id
+func parseIscsiStats(out []byte) map[string]map[string]interface{} {
+	stats := make(map[string]map[string]interface{})
+	var fields map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := iscsiSessionRe.FindStringSubmatch(line); m != nil {
+			fields = make(map[string]interface{})
+			stats[m[1]] = fields
+			continue
+		}
+		if fields == nil {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			continue
+		}
+		addUint(fields, strings.TrimSpace(parts[0]), parts[1])
+	}
+	return stats
+}
+
+func readSysfs(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func addUint(fields map[string]interface{}, name, value string) {
+	if v, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64); err == nil {
+		fields[name] = v
+	}
+}
+
+// snakeCase converts the CamelCase operation names of cifs, TreeConnects
+// becomes tree_connects
+func snakeCase(name string) string {
+	return strings.ToLower(camelRe.ReplaceAllString(name, "${1}_${2}"))
+}
+
+func runCommand(timeout time.Duration, useSudo bool, name string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found: %w", name, err)
+	}
+	if useSudo {
+		args = append([]string{path}, args...)
+		path = "sudo"
+	}
+
+	cmd := exec.Command(path, args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := internal.RunTimeout(cmd, timeout); err != nil {
+		return nil, fmt.Errorf("run %s: %w", name, err)
+	}
+	return out.Bytes(), nil
+}
+
+func getHostProc() string {
+	if procPath := os.Getenv("HOST_PROC"); procPath != "" {
+		return procPath
+	}
+	return "/proc"
+}
+
+func getHostSys() string {
+	if sysPath := os.Getenv("HOST_SYS"); sysPath != "" {
+		return sysPath
+	}
+	return "/sys"
+}
+
+func init() {
+	inputs.Add("storage_sessions", func() cua.Input {
+		return &StorageSessions{
+			CIFS:          true,
+			ISCSI:         true,
+			SmbstatusPath: "smbstatus",
+			IscsiadmPath:  "iscsiadm",
+			Timeout:       internal.Duration{Duration: 5 * time.Second},
+			procDir:       getHostProc(),
+			sysDir:        getHostSys(),
+			run:           runCommand,
+		}
+	})
+}
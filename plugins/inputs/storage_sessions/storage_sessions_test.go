@@ -0,0 +1,213 @@
+//go:build linux
+// +build linux
+
+package storagesessions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const smbstatusSessions = `
+Samba version 4.11.6-Ubuntu
+PID     Username     Group        Machine                                   Protocol Version  Encryption           Signing
+----------------------------------------------------------------------------------------------------------------------------------------
+12345   alice        alice        192.168.1.20 (ipv4:192.168.1.20:52000)    SMB3_11           -                    partial(AES-128-CMAC)
+12346   bob          bob          192.168.1.21 (ipv4:192.168.1.21:52010)    SMB3_11           AES-128-CCM          AES-128-CMAC
+12347   carol        carol        192.168.1.22 (ipv4:192.168.1.22:52020)    NT1               -                    -
+`
+
+const smbstatusShares = `
+Service      pid     Machine       Connected at                     Encryption   Signing
+---------------------------------------------------------------------------------------------
+projects     12345   192.168.1.20  Mon Sep 14 10:00:00 AM 2020 UTC  -            -
+projects     12346   192.168.1.21  Mon Sep 14 10:05:00 AM 2020 UTC  AES-128-CCM  AES-128-CMAC
+IPC$         12345   192.168.1.20  Mon Sep 14 10:00:00 AM 2020 UTC  -            -
+`
+
+const iscsiadmStats = `Stats for session [sid: 1, target: iqn.2003-01.org.linux-iscsi.gw1:vol1, portal: 10.0.0.5,3260]
+iSCSI SNMP:
+	txdata_octets: 123456
+	rxdata_octets: 654321
+	noptx_pdus: 0
+	scsicmd_pdus: 10
+	digest_err: 0
+	timeout_err: 3
+iSCSI Extended:
+	tx_sendpage_failures: 0
+	eh_abort_cnt: 1
+`
+
+func newStorageSessions(outputs map[string]string) *StorageSessions {
+	return &StorageSessions{
+		CIFS:          true,
+		Smbstatus:     true,
+		SmbstatusPath: "smbstatus",
+		ISCSI:         true,
+		IscsiadmPath:  "iscsiadm",
+		Timeout:       internal.Duration{Duration: time.Second},
+		Log:           testutil.Logger{},
+		procDir:       "testdata/proc",
+		sysDir:        "testdata/sys",
+		run: func(timeout time.Duration, useSudo bool, name string, args ...string) ([]byte, error) {
+			out, ok := outputs[name+" "+strings.Join(args, " ")]
+			if !ok {
+				return nil, errors.New("unexpected command")
+			}
+			return []byte(out), nil
+		},
+	}
+}
+
+func TestGatherCIFS(t *testing.T) {
+	s := newStorageSessions(nil)
+	s.Smbstatus = false
+	s.ISCSI = false
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	cifs := make(map[cua.ValueType]map[string]interface{})
+	for _, m := range acc.Metrics {
+		if m.Measurement == "cifs" {
+			cifs[m.Type] = m.Fields
+		}
+	}
+	require.Equal(t, map[string]interface{}{
+		"sessions":               uint64(2),
+		"shares":                 uint64(3),
+		"max_requests_in_flight": uint64(8),
+		"vfs_operations_max":     uint64(4),
+	}, cifs[cua.Gauge])
+	require.Equal(t, map[string]interface{}{
+		"session_reconnects": uint64(1),
+		"share_reconnects":   uint64(2),
+		"vfs_operations":     uint64(1200),
+	}, cifs[cua.Counter])
+	acc.AssertContainsTaggedFields(t, "cifs_share", map[string]interface{}{
+		"smbs":                     uint64(42),
+		"read_bytes":               uint64(1048576),
+		"write_bytes":              uint64(2097152),
+		"open_files":               uint64(2),
+		"open_files_server":        uint64(2),
+		"tree_connects":            uint64(1),
+		"tree_connects_failed":     uint64(0),
+		"tree_disconnects":         uint64(0),
+		"tree_disconnects_failed":  uint64(0),
+		"creates":                  uint64(12),
+		"creates_failed":           uint64(1),
+		"closes":                   uint64(10),
+		"closes_failed":            uint64(0),
+		"flushes":                  uint64(0),
+		"flushes_failed":           uint64(0),
+		"reads":                    uint64(16),
+		"reads_failed":             uint64(0),
+		"writes":                   uint64(32),
+		"writes_failed":            uint64(2),
+		"locks":                    uint64(0),
+		"locks_failed":             uint64(0),
+		"ioctls":                   uint64(2),
+		"ioctls_failed":            uint64(0),
+		"query_directories":        uint64(3),
+		"query_directories_failed": uint64(0),
+		"change_notifies":          uint64(0),
+		"change_notifies_failed":   uint64(0),
+		"query_infos":              uint64(20),
+		"query_infos_failed":       uint64(0),
+		"set_infos":                uint64(1),
+		"set_infos_failed":         uint64(0),
+		"oplock_breaks":            uint64(0),
+		"oplock_breaks_failed":     uint64(0),
+	}, map[string]string{"share": `\\fileserver\projects`, "server": "fileserver"})
+	acc.AssertContainsTaggedFields(t, "cifs_share", map[string]interface{}{
+		"smbs":                 uint64(7),
+		"read_bytes":           uint64(0),
+		"write_bytes":          uint64(4096),
+		"open_files":           uint64(0),
+		"open_files_server":    uint64(0),
+		"tree_connects":        uint64(1),
+		"tree_connects_failed": uint64(0),
+		"writes":               uint64(1),
+		"writes_failed":        uint64(0),
+	}, map[string]string{"share": `\\backup.example.com\archive`, "server": "backup.example.com"})
+}
+
+func TestGatherSmbstatus(t *testing.T) {
+	s := newStorageSessions(map[string]string{
+		"smbstatus -b": smbstatusSessions,
+		"smbstatus -S": smbstatusShares,
+	})
+	s.CIFS = false
+	s.ISCSI = false
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "samba_sessions", map[string]interface{}{
+		"sessions":           uint64(2),
+		"encrypted_sessions": uint64(1),
+		"signed_sessions":    uint64(2),
+	}, map[string]string{"protocol": "SMB3_11"})
+	acc.AssertContainsTaggedFields(t, "samba_sessions", map[string]interface{}{
+		"sessions":           uint64(1),
+		"encrypted_sessions": uint64(0),
+		"signed_sessions":    uint64(0),
+	}, map[string]string{"protocol": "NT1"})
+	acc.AssertContainsTaggedFields(t, "samba_share", map[string]interface{}{
+		"connections": uint64(2),
+		"machines":    uint64(2),
+	}, map[string]string{"share": "projects"})
+	acc.AssertContainsTaggedFields(t, "samba_share", map[string]interface{}{
+		"connections": uint64(1),
+		"machines":    uint64(1),
+	}, map[string]string{"share": "IPC$"})
+}
+
+func TestGatherISCSI(t *testing.T) {
+	s := newStorageSessions(map[string]string{
+		"iscsiadm -m session -s": iscsiadmStats,
+	})
+	s.CIFS = false
+	s.Smbstatus = false
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	vol1 := map[string]string{"sid": "1", "target": "iqn.2003-01.org.linux-iscsi.gw1:vol1", "state": "logged_in"}
+	acc.AssertContainsTaggedFields(t, "iscsi_session", map[string]interface{}{"logged_in": 1}, vol1)
+	acc.AssertContainsTaggedFields(t, "iscsi_session", map[string]interface{}{
+		"txdata_octets":        uint64(123456),
+		"rxdata_octets":        uint64(654321),
+		"noptx_pdus":           uint64(0),
+		"scsicmd_pdus":         uint64(10),
+		"digest_err":           uint64(0),
+		"timeout_err":          uint64(3),
+		"tx_sendpage_failures": uint64(0),
+		"eh_abort_cnt":         uint64(1),
+	}, vol1)
+	acc.AssertContainsTaggedFields(t, "iscsi_session", map[string]interface{}{"logged_in": 0},
+		map[string]string{"sid": "2", "target": "iqn.2003-01.org.linux-iscsi.gw1:vol2", "state": "failed"})
+}
+
+func TestGatherISCSICommandFails(t *testing.T) {
+	s := newStorageSessions(nil)
+	s.CIFS = false
+	s.Smbstatus = false
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	// the state of the sessions is still reported
+	require.Len(t, acc.Errors, 1)
+	require.True(t, acc.HasMeasurement("iscsi_session"))
+}
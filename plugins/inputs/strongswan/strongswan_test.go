@@ -0,0 +1,181 @@
+package strongswan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(s section) []byte {
+	var b []byte
+	str := func(v string) {
+		b = append(b, byte(len(v)>>8), byte(len(v)))
+		b = append(b, v...)
+	}
+	for k, v := range s {
+		switch v := v.(type) {
+		case string:
+			b = append(b, keyValue, byte(len(k)))
+			b = append(b, k...)
+			str(v)
+		case []string:
+			b = append(b, listStart, byte(len(k)))
+			b = append(b, k...)
+			for _, i := range v {
+				b = append(b, listItem)
+				str(i)
+			}
+			b = append(b, listEnd)
+		case section:
+			b = append(b, sectionStart, byte(len(k)))
+			b = append(b, k...)
+			b = append(b, encode(v)...)
+			b = append(b, sectionEnd)
+		}
+	}
+	return b
+}
+
+func packet(ptype byte, name string, msg []byte) []byte {
+	payload := []byte{ptype}
+	if name != "" {
+		payload = append(payload, byte(len(name)))
+		payload = append(payload, name...)
+	}
+	payload = append(payload, msg...)
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	return append(buf, payload...)
+}
+
+var testConns = []section{
+	{"site-a": section{
+		"local_addrs":  []string{"%any"},
+		"remote_addrs": []string{"198.51.100.1"},
+		"children":     section{"net-a": section{"mode": "TUNNEL"}, "net-b": section{"mode": "TUNNEL"}},
+	}},
+	{"site-down": section{
+		"remote_addrs": []string{"198.51.100.2"},
+		"children":     section{"net-c": section{"mode": "TUNNEL"}},
+	}},
+}
+
+var testSAs = []section{
+	{"site-a": section{
+		"uniqueid":    "1",
+		"state":       "ESTABLISHED",
+		"remote-host": "198.51.100.1",
+		"child-sas": section{
+			"net-a-1": section{"name": "net-a", "state": "INSTALLED", "bytes-in": "100", "bytes-out": "200", "packets-in": "1", "packets-out": "2"},
+			"net-a-2": section{"name": "net-a", "state": "REKEYING", "bytes-in": "10", "bytes-out": "20", "packets-in": "3", "packets-out": "4"},
+		},
+	}},
+	{"roadwarrior": section{
+		"state":     "ESTABLISHED",
+		"remote-id": "CN=alice",
+		"child-sas": section{
+			"rw-3": section{"name": "rw", "state": "INSTALLED", "bytes-in": "5", "bytes-out": "6", "packets-in": "7", "packets-out": "8"},
+		},
+	}},
+	{"roadwarrior": section{
+		"state":     "CONNECTING",
+		"remote-id": "CN=bob",
+	}},
+}
+
+func fakeVici(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "charon.vici")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			var hdr [4]byte
+			if _, err := io.ReadFull(r, hdr[:]); err != nil {
+				return
+			}
+			payload := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			name := string(payload[2 : 2+int(payload[1])])
+			switch payload[0] {
+			case eventRegister, eventUnregister:
+				_, _ = conn.Write(packet(eventConfirm, "", nil))
+			case cmdRequest:
+				msgs, ev := testSAs, "list-sa"
+				if name == "list-conns" {
+					msgs, ev = testConns, "list-conn"
+				}
+				for _, m := range msgs {
+					_, _ = conn.Write(packet(event, ev, encode(m)))
+				}
+				_, _ = conn.Write(packet(cmdResponse, "", nil))
+			}
+		}
+	}()
+
+	return path
+}
+
+func TestGather(t *testing.T) {
+	s := &Strongswan{
+		Socket:  fakeVici(t),
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, s.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(s.Gather))
+
+	acc.AssertContainsTaggedFields(t, "strongswan_conn",
+		map[string]interface{}{"up": 1, "ike_sas": 1, "child_sas": 2},
+		map[string]string{"connection": "site-a"})
+	acc.AssertContainsTaggedFields(t, "strongswan_conn",
+		map[string]interface{}{"up": 0, "ike_sas": 0, "child_sas": 0},
+		map[string]string{"connection": "site-down"})
+	acc.AssertContainsTaggedFields(t, "strongswan_conn",
+		map[string]interface{}{"up": 1, "ike_sas": 2, "child_sas": 1},
+		map[string]string{"connection": "roadwarrior"})
+
+	acc.AssertContainsTaggedFields(t, "strongswan_child",
+		map[string]interface{}{
+			"up":          1,
+			"child_sas":   2,
+			"bytes_in":    int64(110),
+			"bytes_out":   int64(220),
+			"packets_in":  int64(4),
+			"packets_out": int64(6),
+		},
+		map[string]string{"connection": "site-a", "child": "net-a"})
+	acc.AssertContainsTaggedFields(t, "strongswan_child",
+		map[string]interface{}{
+			"up":          0,
+			"child_sas":   0,
+			"bytes_in":    int64(0),
+			"bytes_out":   int64(0),
+			"packets_in":  int64(0),
+			"packets_out": int64(0),
+		},
+		map[string]string{"connection": "site-down", "child": "net-c"})
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	_, err := decode([]byte{keyValue, 4, 'n', 'a'})
+	require.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package strongswan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// VICI packet types
+const (
+	cmdRequest      = 0
+	cmdResponse     = 1
+	cmdUnknown      = 2
+	eventRegister   = 3
+	eventUnregister = 4
+	eventConfirm    = 5
+	eventUnknown    = 6
+	event           = 7
+)
+
+// VICI message element types
+const (
+	sectionStart = 1
+	sectionEnd   = 2
+	keyValue     = 3
+	listStart    = 4
+	listItem     = 5
+	listEnd      = 6
+)
+
+// section is a decoded VICI message, values are strings, []string for
+// lists or section for sub sections
+type section map[string]interface{}
+
+// viciConn is a minimal client of the charon VICI protocol, only what is
+// needed to issue the streamed list commands
+type viciConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (v *viciConn) writePacket(ptype byte, name string) error {
+	payload := []byte{ptype}
+	if name != "" {
+		payload = append(payload, byte(len(name)))
+		payload = append(payload, name...)
+	}
+	buf := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	if _, err := v.conn.Write(append(buf, payload...)); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+func (v *viciConn) readPacket() (byte, []byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(v.r, hdr[:]); err != nil {
+		return 0, nil, fmt.Errorf("read: %w", err)
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+	if _, err := io.ReadFull(v.r, payload); err != nil {
+		return 0, nil, fmt.Errorf("read: %w", err)
+	}
+	if len(payload) == 0 {
+		return 0, nil, errors.New("empty packet")
+	}
+	return payload[0], payload[1:], nil
+}
+
+// stream registers for the event, issues the command and returns the
+// messages of the events streamed before the command response
+func (v *viciConn) stream(cmd, eventName string) ([]section, error) {
+	if err := v.writePacket(eventRegister, eventName); err != nil {
+		return nil, err
+	}
+	ptype, _, err := v.readPacket()
+	if err != nil {
+		return nil, err
+	}
+	if ptype != eventConfirm {
+		return nil, fmt.Errorf("registering for %s failed", eventName)
+	}
+
+	if err := v.writePacket(cmdRequest, cmd); err != nil {
+		return nil, err
+	}
+	var msgs []section
+	for done := false; !done; {
+		ptype, payload, err := v.readPacket()
+		if err != nil {
+			return nil, err
+		}
+		switch ptype {
+		case event:
+			if len(payload) < 1 || len(payload) < 1+int(payload[0]) {
+				return nil, errors.New("truncated event")
+			}
+			msg, err := decode(payload[1+int(payload[0]):])
+			if err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, msg)
+		case cmdResponse:
+			done = true
+		case cmdUnknown:
+			return nil, fmt.Errorf("unknown command %s", cmd)
+		default:
+			return nil, fmt.Errorf("unexpected packet type %d", ptype)
+		}
+	}
+
+	if err := v.writePacket(eventUnregister, eventName); err != nil {
+		return nil, err
+	}
+	if _, _, err := v.readPacket(); err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
+
+// decode decodes the elements of a VICI message
+func decode(b []byte) (section, error) {
+	root := section{}
+	stack := []section{root}
+	var list []string
+	var listName string
+	inList := false
+
+	name := func() (string, error) {
+		if len(b) < 1 || len(b) < 1+int(b[0]) {
+			return "", errors.New("truncated name")
+		}
+		n := string(b[1 : 1+int(b[0])])
+		b = b[1+int(b[0]):]
+		return n, nil
+	}
+	value := func() (string, error) {
+		if len(b) < 2 {
+			return "", errors.New("truncated value")
+		}
+		l := int(binary.BigEndian.Uint16(b))
+		if len(b) < 2+l {
+			return "", errors.New("truncated value")
+		}
+		v := string(b[2 : 2+l])
+		b = b[2+l:]
+		return v, nil
+	}
+
+	for len(b) > 0 {
+		etype := b[0]
+		b = b[1:]
+		cur := stack[len(stack)-1]
+		switch etype {
+		case sectionStart:
+			n, err := name()
+			if err != nil {
+				return nil, err
+			}
+			s := section{}
+			cur[n] = s
+			stack = append(stack, s)
+		case sectionEnd:
+			if len(stack) == 1 {
+				return nil, errors.New("unbalanced section end")
+			}
+			stack = stack[:len(stack)-1]
+		case keyValue:
+			n, err := name()
+			if err != nil {
+				return nil, err
+			}
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			cur[n] = v
+		case listStart:
+			n, err := name()
+			if err != nil {
+				return nil, err
+			}
+			listName, list, inList = n, nil, true
+		case listItem:
+			if !inList {
+				return nil, errors.New("list item outside of a list")
+			}
+			v, err := value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		case listEnd:
+			if !inList {
+				return nil, errors.New("unbalanced list end")
+			}
+			cur[listName] = list
+			inList = false
+		default:
+			return nil, fmt.Errorf("unknown element type %d", etype)
+		}
+	}
+
+	return root, nil
+}
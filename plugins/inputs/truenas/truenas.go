@@ -0,0 +1,325 @@
+// Package truenas implements a plugin for collecting pool, dataset,
+// replication and alert metrics from the TrueNAS REST API.
+package truenas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+// alertLevels are the levels of the alerts, from the least severe
+var alertLevels = []string{"info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// TrueNAS reads the state of a TrueNAS CORE or SCALE system
+type TrueNAS struct {
+	URL             string            `toml:"url"`
+	APIKey          string            `toml:"api_key"`
+	Datasets        []string          `toml:"datasets"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	client        *http.Client
+	datasetFilter filter.Filter
+}
+
+var sampleConfig = `
+  ## URL of the v2.0 REST API
+  url = "https://localhost/api/v2.0"
+
+  ## API key, created in the web interface under the user settings
+  api_key = ""
+
+  ## Datasets to report, globs are supported, all the datasets when empty
+  # datasets = ["tank", "tank/shares/*"]
+
+  ## HTTP response timeout
+  # response_timeout = "10s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification, TrueNAS uses a self-signed
+  ## certificate by default
+  # insecure_skip_verify = false
+`
+
+func (*TrueNAS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (*TrueNAS) Description() string {
+	return "Read pool health, dataset usage, replication task state and alerts from TrueNAS"
+}
+
+func (t *TrueNAS) Init() error {
+	if t.APIKey == "" {
+		return errors.New("api_key is required")
+	}
+
+	var err error
+	if t.datasetFilter, err = filter.Compile(t.Datasets); err != nil {
+		return fmt.Errorf("datasets: %w", err)
+	}
+
+	tlsCfg, err := t.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	t.client = &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsCfg},
+		Timeout:   t.ResponseTimeout.Duration,
+	}
+	return nil
+}
+
+func (t *TrueNAS) Gather(ctx context.Context, acc cua.Accumulator) error {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return fmt.Errorf("url parse (%s): %w", t.URL, err)
+	}
+	tags := map[string]string{"server": u.Host}
+
+	var pools []pool
+	if err := t.get(ctx, "/pool", &pools); err != nil {
+		acc.AddError(err)
+	}
+	gatherPools(pools, tags, acc)
+
+	var datasets []dataset
+	if err := t.get(ctx, "/pool/dataset", &datasets); err != nil {
+		acc.AddError(err)
+	}
+	t.gatherDatasets(datasets, tags, acc)
+
+	var tasks []replicationTask
+	if err := t.get(ctx, "/replication", &tasks); err != nil {
+		acc.AddError(err)
+	}
+	gatherReplication(tasks, tags, time.Now(), acc)
+
+	var alerts []alert
+	if err := t.get(ctx, "/alert/list", &alerts); err != nil {
+		acc.AddError(err)
+	} else {
+		gatherAlerts(alerts, tags, acc)
+	}
+	return nil
+}
+
+func (t *TrueNAS) get(ctx context.Context, path string, v interface{}) error {
+	u := strings.TrimRight(t.URL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("http new req (%s): %w", u, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http do (%s): %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s returned HTTP status %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("json decode (%s): %w", u, err)
+	}
+	return nil
+}
+
+type pool struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Healthy bool   `json:"healthy"`
+	// the sizes are only reported by TrueNAS SCALE
+	Size      *int64 `json:"size"`
+	Allocated *int64 `json:"allocated"`
+	Free      *int64 `json:"free"`
+	Scan      struct {
+		Function string `json:"function"`
+		State    string `json:"state"`
+		Errors   int64  `json:"errors"`
+	} `json:"scan"`
+}
+
+func gatherPools(pools []pool, serverTags map[string]string, acc cua.Accumulator) {
+	for _, p := range pools {
+		tags := copyTags(serverTags)
+		tags["pool"] = p.Name
+
+		fields := map[string]interface{}{
+			"status":  p.Status,
+			"healthy": boolToInt(p.Healthy),
+			// errors of the last scrub or resilver
+			"scan_errors": p.Scan.Errors,
+			"scrubbing":   boolToInt(p.Scan.State == "SCANNING"),
+		}
+		if p.Size != nil && p.Allocated != nil && p.Free != nil {
+			fields["size"] = *p.Size
+			fields["allocated"] = *p.Allocated
+			fields["free"] = *p.Free
+			if *p.Size > 0 {
+				fields["used_percentage"] = float64(*p.Allocated) * 100 / float64(*p.Size)
+			}
+		}
+		acc.AddFields("truenas_pool", fields, tags)
+	}
+}
+
+// property is a ZFS property of a dataset, e.g.
+// {"parsed": 1073741824, "rawvalue": "1073741824", "value": "1G"}
+type property struct {
+	RawValue string `json:"rawvalue"`
+}
+
+func (p property) int64() int64 {
+	v, _ := strconv.ParseInt(p.RawValue, 10, 64)
+	return v
+}
+
+type dataset struct {
+	ID            string    `json:"id"`
+	Pool          string    `json:"pool"`
+	Type          string    `json:"type"`
+	Used          property  `json:"used"`
+	Available     property  `json:"available"`
+	Quota         property  `json:"quota"`
+	CompressRatio property  `json:"compressratio"`
+	Children      []dataset `json:"children"`
+}
+
+func (t *TrueNAS) gatherDatasets(datasets []dataset, serverTags map[string]string, acc cua.Accumulator) {
+	for _, d := range datasets {
+		if t.datasetFilter == nil || t.datasetFilter.Match(d.ID) {
+			tags := copyTags(serverTags)
+			tags["dataset"] = d.ID
+			tags["pool"] = d.Pool
+			tags["type"] = strings.ToLower(d.Type)
+
+			used, available := d.Used.int64(), d.Available.int64()
+			fields := map[string]interface{}{
+				"used":      used,
+				"available": available,
+				"quota":     d.Quota.int64(),
+			}
+			if used+available > 0 {
+				fields["used_percentage"] = float64(used) * 100 / float64(used+available)
+			}
+			if ratio, err := strconv.ParseFloat(strings.TrimSuffix(d.CompressRatio.RawValue, "x"), 64); err == nil {
+				fields["compress_ratio"] = ratio
+			}
+			acc.AddFields("truenas_dataset", fields, tags)
+		}
+
+		// the child datasets are nested in the list of the datasets of
+		// the pools
+		t.gatherDatasets(d.Children, serverTags, acc)
+	}
+}
+
+type replicationTask struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	State   struct {
+		State    string `json:"state"`
+		DateTime *struct {
+			Date int64 `json:"$date"`
+		} `json:"datetime"`
+	} `json:"state"`
+}
+
+func gatherReplication(tasks []replicationTask, serverTags map[string]string, now time.Time, acc cua.Accumulator) {
+	for _, task := range tasks {
+		tags := copyTags(serverTags)
+		tags["task"] = task.Name
+
+		state := task.State.State
+		fields := map[string]interface{}{
+			"enabled": boolToInt(task.Enabled),
+			"state":   state,
+			"failed":  boolToInt(state == "ERROR"),
+			"running": boolToInt(state == "RUNNING"),
+		}
+		// the time of the last state change, milliseconds since the epoch
+		if dt := task.State.DateTime; dt != nil && dt.Date > 0 {
+			age := now.Sub(time.Unix(0, dt.Date*int64(time.Millisecond)))
+			if age < 0 {
+				age = 0
+			}
+			fields["last_run_age"] = age.Seconds()
+		}
+		acc.AddFields("truenas_replication", fields, tags)
+	}
+}
+
+type alert struct {
+	Level     string `json:"level"`
+	Dismissed bool   `json:"dismissed"`
+}
+
+func gatherAlerts(alerts []alert, tags map[string]string, acc cua.Accumulator) {
+	fields := make(map[string]interface{}, len(alertLevels)+2)
+	for _, level := range alertLevels {
+		fields[level] = 0
+	}
+	var active, dismissed int
+	for _, a := range alerts {
+		if a.Dismissed {
+			dismissed++
+			continue
+		}
+		active++
+		level := strings.ToLower(a.Level)
+		if n, ok := fields[level].(int); ok {
+			fields[level] = n + 1
+		}
+	}
+	fields["active"] = active
+	fields["dismissed"] = dismissed
+	acc.AddFields("truenas_alerts", fields, copyTags(tags))
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	c := make(map[string]string, len(tags)+3)
+	for k, v := range tags {
+		c[k] = v
+	}
+	return c
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	inputs.Add("truenas", func() cua.Input {
+		return &TrueNAS{
+			URL:             "https://localhost/api/v2.0",
+			ResponseTimeout: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package truenas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var responses = map[string]string{
+	"/api/v2.0/pool": `[
+		{"id": 1, "name": "tank", "status": "DEGRADED", "healthy": false,
+		 "size": 4000000000000, "allocated": 1000000000000, "free": 3000000000000,
+		 "scan": {"function": "SCRUB", "state": "FINISHED", "errors": 2}},
+		{"id": 2, "name": "ssd", "status": "ONLINE", "healthy": true,
+		 "scan": {"function": "SCRUB", "state": "SCANNING", "errors": 0}}
+	]`,
+	"/api/v2.0/pool/dataset": `[
+		{"id": "tank", "pool": "tank", "type": "FILESYSTEM",
+		 "used": {"parsed": 1000, "rawvalue": "1000", "value": "1000B"},
+		 "available": {"parsed": 3000, "rawvalue": "3000", "value": "2.9K"},
+		 "quota": {"parsed": null, "rawvalue": "0", "value": null},
+		 "compressratio": {"parsed": "1.50", "rawvalue": "1.50", "value": "1.50x"},
+		 "children": [
+			{"id": "tank/shares", "pool": "tank", "type": "FILESYSTEM",
+			 "used": {"rawvalue": "750"}, "available": {"rawvalue": "250"},
+			 "quota": {"rawvalue": "1000"}, "compressratio": {"rawvalue": "2.00"},
+			 "children": []}
+		 ]}
+	]`,
+	"/api/v2.0/replication": `[
+		{"id": 1, "name": "tank/shares - backup", "enabled": true,
+		 "state": {"state": "ERROR", "datetime": {"$date": 1600000000000}, "error": "connection refused"}},
+		{"id": 2, "name": "never run", "enabled": false, "state": {"state": "PENDING"}}
+	]`,
+	"/api/v2.0/alert/list": `[
+		{"uuid": "a", "klass": "ZpoolCapacityWarning", "level": "WARNING", "dismissed": false},
+		{"uuid": "b", "klass": "VolumeStatus", "level": "CRITICAL", "dismissed": false},
+		{"uuid": "c", "klass": "SMART", "level": "CRITICAL", "dismissed": true}
+	]`,
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer 1-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func newTrueNAS(t *testing.T, u string) *TrueNAS {
+	tn := &TrueNAS{
+		URL:             u + "/api/v2.0",
+		APIKey:          "1-secret",
+		ResponseTimeout: internal.Duration{Duration: 5 * time.Second},
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, tn.Init())
+	return tn
+}
+
+func TestGather(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	server := u.Host
+
+	tn := newTrueNAS(t, ts.URL)
+	var acc testutil.Accumulator
+	require.NoError(t, tn.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "truenas_pool", map[string]interface{}{
+		"status":          "DEGRADED",
+		"healthy":         0,
+		"scan_errors":     int64(2),
+		"scrubbing":       0,
+		"size":            int64(4000000000000),
+		"allocated":       int64(1000000000000),
+		"free":            int64(3000000000000),
+		"used_percentage": float64(25),
+	}, map[string]string{"server": server, "pool": "tank"})
+	acc.AssertContainsTaggedFields(t, "truenas_pool", map[string]interface{}{
+		"status":      "ONLINE",
+		"healthy":     1,
+		"scan_errors": int64(0),
+		"scrubbing":   1,
+	}, map[string]string{"server": server, "pool": "ssd"})
+
+	acc.AssertContainsTaggedFields(t, "truenas_dataset", map[string]interface{}{
+		"used":            int64(1000),
+		"available":       int64(3000),
+		"quota":           int64(0),
+		"used_percentage": float64(25),
+		"compress_ratio":  1.5,
+	}, map[string]string{"server": server, "dataset": "tank", "pool": "tank", "type": "filesystem"})
+	acc.AssertContainsTaggedFields(t, "truenas_dataset", map[string]interface{}{
+		"used":            int64(750),
+		"available":       int64(250),
+		"quota":           int64(1000),
+		"used_percentage": float64(75),
+		"compress_ratio":  2.0,
+	}, map[string]string{"server": server, "dataset": "tank/shares", "pool": "tank", "type": "filesystem"})
+
+	acc.AssertContainsTaggedFields(t, "truenas_alerts", map[string]interface{}{
+		"info":      0,
+		"notice":    0,
+		"warning":   1,
+		"error":     0,
+		"critical":  1,
+		"alert":     0,
+		"emergency": 0,
+		"active":    2,
+		"dismissed": 1,
+	}, map[string]string{"server": server})
+
+	acc.AssertContainsTaggedFields(t, "truenas_replication", map[string]interface{}{
+		"enabled": 0,
+		"state":   "PENDING",
+		"failed":  0,
+		"running": 0,
+	}, map[string]string{"server": server, "task": "never run"})
+}
+
+func TestGatherReplication(t *testing.T) {
+	tasks := []replicationTask{{Name: "backup", Enabled: true}}
+	tasks[0].State.State = "ERROR"
+	tasks[0].State.DateTime = &struct {
+		Date int64 `json:"$date"`
+	}{Date: 1600000000000}
+
+	var acc testutil.Accumulator
+	gatherReplication(tasks, map[string]string{}, time.Unix(1600003600, 0), &acc)
+	acc.AssertContainsTaggedFields(t, "truenas_replication", map[string]interface{}{
+		"enabled":      1,
+		"state":        "ERROR",
+		"failed":       1,
+		"running":      0,
+		"last_run_age": float64(3600),
+	}, map[string]string{"task": "backup"})
+}
+
+func TestGatherDatasetFilter(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	tn := newTrueNAS(t, ts.URL)
+	tn.Datasets = []string{"tank/*"}
+	require.NoError(t, tn.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, tn.Gather(context.Background(), &acc))
+
+	var datasets []string
+	for _, m := range acc.Metrics {
+		if m.Measurement == "truenas_dataset" {
+			datasets = append(datasets, m.Tags["dataset"])
+		}
+	}
+	require.Equal(t, []string{"tank/shares"}, datasets)
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	tn := newTrueNAS(t, ts.URL)
+	tn.APIKey = "wrong"
+
+	var acc testutil.Accumulator
+	require.NoError(t, tn.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 4)
+	require.Empty(t, acc.Metrics)
+}
@@ -27,9 +27,15 @@ type Unbound struct {
 	Server      string
 	ThreadAsTag bool
 	ConfigFile  string
+	Histogram   bool `toml:"histogram"`
 
 	// filter filter.Filter
 	run runner
+
+	// cache hits and misses of the previous gather, for the hit ratio
+	lastHits   float64
+	lastMisses float64
+	haveLast   bool
 }
 
 var defaultBinary = "/usr/sbin/unbound-control"
@@ -58,6 +64,10 @@ var sampleConfig = `
   ## true in a future version.  It is recommended to set to true on new
   ## deployments.
   thread_as_tag = false
+
+  ## Report the recursion time histogram (extended-statistics) as a circonus
+  ## histogram in the unbound_recursion_time measurement
+  # histogram = false
 `
 
 // Description displays what this plugin is about
@@ -124,10 +134,11 @@ func unboundRunner(cmdName string, timeout internal.Duration, useSudo bool, serv
 
 // Gather collects stats from unbound-control and adds them to the Accumulator
 //
-// All the dots in stat name will replaced by underscores. Histogram statistics will not be collected.
+// All the dots in stat name will replaced by underscores. Histogram statistics are reported in a
+// separate measurement when enabled.
 func (s *Unbound) Gather(ctx context.Context, acc cua.Accumulator) error {
 
-	// Always exclude histogram statistics
+	// Histogram statistics are never fields
 	statExcluded := []string{"histogram.*"}
 	filterExcluded, err := filter.Compile(statExcluded)
 	if err != nil {
@@ -142,6 +153,7 @@ func (s *Unbound) Gather(ctx context.Context, acc cua.Accumulator) error {
 	// Process values
 	fields := make(map[string]interface{})
 	fieldsThreads := make(map[string]map[string]interface{})
+	buckets := make(map[string]interface{})
 
 	scanner := bufio.NewScanner(out)
 	for scanner.Scan() {
@@ -158,6 +170,14 @@ func (s *Unbound) Gather(ctx context.Context, acc cua.Accumulator) error {
 
 		// Filter value
 		if filterExcluded.Match(stat) {
+			if s.Histogram {
+				if bound, ok := histogramBound(stat); ok {
+					count, err := strconv.ParseInt(value, 10, 64)
+					if err == nil {
+						buckets[fmt.Sprintf("%e", bound)] = count
+					}
+				}
+			}
 			continue
 		}
 
@@ -195,8 +215,13 @@ func (s *Unbound) Gather(ctx context.Context, acc cua.Accumulator) error {
 
 	}
 
+	s.addCacheHitRatio(fields)
 	acc.AddFields("unbound", fields, nil)
 
+	if len(buckets) > 0 {
+		acc.AddCumulativeHistogram("unbound_recursion_time", buckets, nil)
+	}
+
 	if s.ThreadAsTag && len(fieldsThreads) > 0 {
 		for thisThreadID, thisThreadFields := range fieldsThreads {
 			thisThreadTag := map[string]string{"thread": thisThreadID}
@@ -207,6 +232,37 @@ func (s *Unbound) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return nil
 }
 
+// addCacheHitRatio adds the percent of the queries answered from the cache
+// since the previous gather
+func (s *Unbound) addCacheHitRatio(fields map[string]interface{}) {
+	hits, hitsOK := fields["total_num_cachehits"].(float64)
+	misses, missesOK := fields["total_num_cachemiss"].(float64)
+	if !hitsOK || !missesOK {
+		return
+	}
+	// the counters restart with unbound
+	if s.haveLast && hits >= s.lastHits && misses >= s.lastMisses {
+		if queries := hits - s.lastHits + misses - s.lastMisses; queries > 0 {
+			fields["cache_hit_ratio"] = 100 * (hits - s.lastHits) / queries
+		}
+	}
+	s.lastHits, s.lastMisses, s.haveLast = hits, misses, true
+}
+
+// histogramBound returns the upper bound, in seconds, of a recursion time
+// histogram bucket, ie histogram.000000.000128.to.000000.000256
+func histogramBound(stat string) (float64, bool) {
+	parts := strings.Split(stat, ".")
+	if len(parts) != 6 || parts[0] != "histogram" || parts[3] != "to" {
+		return 0, false
+	}
+	bound, err := strconv.ParseFloat(parts[4]+"."+parts[5], 64)
+	if err != nil {
+		return 0, false
+	}
+	return bound, true
+}
+
 func init() {
 	inputs.Add("unbound", func() cua.Input {
 		return &Unbound{
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
@@ -56,6 +57,53 @@ func TestParseFullOutputThreadAsTag(t *testing.T) {
 	acc.AssertContainsFields(t, "unbound_threads", parsedFullOutputThreadAsTagMeasurementUnboundThreads)
 }
 
+func TestParseHistogram(t *testing.T) {
+	output := `total.num.cachehits=30
+total.num.cachemiss=10
+histogram.000000.000000.to.000000.000001=0
+histogram.000000.000128.to.000000.000256=12
+histogram.000000.262144.to.000000.524288=3
+histogram.000002.000000.to.000004.000000=1
+`
+	acc := &testutil.Accumulator{}
+	v := &Unbound{
+		run:       UnboundControl(output, TestTimeout, false, "", false, ""),
+		Histogram: true,
+	}
+	assert.NoError(t, v.Gather(context.Background(), acc))
+	assert.False(t, acc.HasField("unbound", "histogram_000000_000128_to_000000_000256"))
+
+	var found bool
+	for _, m := range acc.GetCUAMetrics() {
+		if m.Name() != "unbound_recursion_time" {
+			continue
+		}
+		found = true
+		assert.Equal(t, cua.CumulativeHistogram, m.Type())
+		assert.Equal(t, map[string]interface{}{
+			"1.000000e-06": int64(0),
+			"2.560000e-04": int64(12),
+			"5.242880e-01": int64(3),
+			"4.000000e+00": int64(1),
+		}, m.Fields())
+	}
+	assert.True(t, found)
+}
+
+func TestCacheHitRatio(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	v := &Unbound{run: UnboundControl("total.num.cachehits=30\ntotal.num.cachemiss=10\n", TestTimeout, false, "", false, "")}
+	assert.NoError(t, v.Gather(context.Background(), acc))
+	assert.False(t, acc.HasField("unbound", "cache_hit_ratio"))
+
+	v.run = UnboundControl("total.num.cachehits=60\ntotal.num.cachemiss=20\n", TestTimeout, false, "", false, "")
+	acc.ClearMetrics()
+	assert.NoError(t, v.Gather(context.Background(), acc))
+	ratio, ok := acc.FloatField("unbound", "cache_hit_ratio")
+	assert.True(t, ok)
+	assert.Equal(t, 75.0, ratio)
+}
+
 var parsedFullOutput = map[string]interface{}{
 	"thread0_num_queries":              float64(11907596),
 	"thread0_num_cachehits":            float64(11489288),
@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -67,7 +68,7 @@ func (u *Uwsgi) Gather(ctx context.Context, acc cua.Accumulator) error {
 				return
 			}
 
-			if err := u.gatherServer(acc, n); err != nil {
+			if err := u.gatherServer(ctx, acc, n); err != nil {
 				acc.AddError(err)
 				return
 			}
@@ -79,29 +80,36 @@ func (u *Uwsgi) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return nil
 }
 
-func (u *Uwsgi) gatherServer(acc cua.Accumulator, surl *url.URL) error {
-	var err error
+func (u *Uwsgi) gatherServer(ctx context.Context, acc cua.Accumulator, surl *url.URL) error {
 	var r io.ReadCloser
 	var s StatsServer
 
 	switch surl.Scheme {
 	case "tcp":
-		r, err = net.DialTimeout(surl.Scheme, surl.Host, u.Timeout.Duration)
+		conn, err := net.DialTimeout(surl.Scheme, surl.Host, u.Timeout.Duration)
 		if err != nil {
 			return fmt.Errorf("dial (%s): %w", surl.Host, err)
 		}
+		u.setDeadline(conn)
+		r = conn
 		s.source = surl.Host
 	case "unix":
-		r, err = net.DialTimeout(surl.Scheme, surl.Path, u.Timeout.Duration)
+		conn, err := net.DialTimeout(surl.Scheme, surl.Path, u.Timeout.Duration)
 		if err != nil {
 			return fmt.Errorf("dial (%s): %w", surl.Path, err)
 		}
+		u.setDeadline(conn)
+		r = conn
 		s.source, err = os.Hostname()
 		if err != nil {
 			s.source = ""
 		}
 	case "http":
-		resp, err := u.client.Get(surl.String())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, surl.String(), nil)
+		if err != nil {
+			return fmt.Errorf("http request (%s): %w", surl.String(), err)
+		}
+		resp, err := u.client.Do(req)
 		if err != nil {
 			return fmt.Errorf("http get (%s): %w", surl.String(), err)
 		}
@@ -122,6 +130,14 @@ func (u *Uwsgi) gatherServer(acc cua.Accumulator, surl *url.URL) error {
 	return nil
 }
 
+// setDeadline bounds reading the stats from a socket, the stats server
+// writes the json and closes the connection
+func (u *Uwsgi) setDeadline(conn net.Conn) {
+	if u.Timeout.Duration > 0 {
+		_ = conn.SetDeadline(time.Now().Add(u.Timeout.Duration))
+	}
+}
+
 func (u *Uwsgi) gatherStatServer(acc cua.Accumulator, s *StatsServer) {
 	fields := map[string]interface{}{
 		"listen_queue":        s.ListenQueue,
@@ -129,6 +145,10 @@ func (u *Uwsgi) gatherStatServer(acc cua.Accumulator, s *StatsServer) {
 		"signal_queue":        s.SignalQueue,
 		"load":                s.Load,
 		"pid":                 s.PID,
+		"workers":             len(s.Workers),
+	}
+	for status, count := range workerStatuses(s.Workers) {
+		fields["workers_"+status] = count
 	}
 
 	tags := map[string]string{
@@ -144,6 +164,27 @@ func (u *Uwsgi) gatherStatServer(acc cua.Accumulator, s *StatsServer) {
 	u.gatherCores(acc, s)
 }
 
+// workerStatuses counts the workers by status, a worker handling a signal
+// has a sig status and is counted as busy
+func workerStatuses(workers []*Worker) map[string]int {
+	counts := map[string]int{
+		"idle":  0,
+		"busy":  0,
+		"cheap": 0,
+		"pause": 0,
+	}
+	for _, w := range workers {
+		status := w.Status
+		if strings.HasPrefix(status, "sig") {
+			status = "busy"
+		}
+		if _, ok := counts[status]; ok {
+			counts[status]++
+		}
+	}
+	return counts
+}
+
 func (u *Uwsgi) gatherWorkers(acc cua.Accumulator, s *StatsServer) {
 	for _, w := range s.Workers {
 		fields := map[string]interface{}{
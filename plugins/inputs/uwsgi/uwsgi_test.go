@@ -2,10 +2,15 @@ package uwsgi_test
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/internal"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/uwsgi"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/require"
@@ -184,3 +189,67 @@ func TestUnixSocketError(t *testing.T) {
 	_ = plugin.Gather(context.Background(), &acc)
 	require.Equal(t, 1, len(acc.Errors))
 }
+
+func TestWorkerStatuses(t *testing.T) {
+	js := `{"version":"2.0.19","listen_queue":3,"listen_queue_errors":0,"signal_queue":0,"load":2,"pid":1,"uid":0,"gid":0,
+"workers":[{"id":1,"status":"busy","avg_rt":1200},{"id":2,"status":"sig12"},{"id":3,"status":"idle"},{"id":4,"status":"cheap"}]}`
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		fmt.Fprint(conn, js)
+		conn.Close()
+	}()
+
+	plugin := &uwsgi.Uwsgi{
+		Servers: []string{"tcp://" + l.Addr().String()},
+		Timeout: internal.Duration{Duration: time.Second},
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+
+	acc.AssertContainsTaggedFields(t, "uwsgi_overview",
+		map[string]interface{}{
+			"listen_queue":        3,
+			"listen_queue_errors": 0,
+			"signal_queue":        0,
+			"load":                2,
+			"pid":                 1,
+			"workers":             4,
+			"workers_idle":        1,
+			"workers_busy":        2,
+			"workers_cheap":       1,
+			"workers_pause":       0,
+		},
+		map[string]string{"source": l.Addr().String(), "uid": "0", "gid": "0", "version": "2.0.19"})
+}
+
+func TestTcpTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	// accept the connection and never write the stats
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	plugin := &uwsgi.Uwsgi{
+		Servers: []string{"tcp://" + l.Addr().String()},
+		Timeout: internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	var acc testutil.Accumulator
+	_ = plugin.Gather(context.Background(), &acc)
+	require.Len(t, acc.Errors, 1)
+}
@@ -7,7 +7,9 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -19,17 +21,40 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 )
 
-type runner func(cmdName string, UseSudo bool, InstanceName string, Timeout internal.Duration) (*bytes.Buffer, error)
+type runner func(cmdName string, UseSudo bool, InstanceName string, UseJSON bool, Timeout internal.Duration) (*bytes.Buffer, error)
 
 // Varnish is used to store configuration values
 type Varnish struct {
 	filter       filter.Filter
+	exclude      filter.Filter
 	run          runner
 	Binary       string
 	InstanceName string
 	Stats        []string
+	StatsExclude []string `toml:"stats_exclude"`
 	Timeout      internal.Duration
 	UseSudo      bool
+	UseJSON      bool `toml:"use_json"`
+	Summary      bool `toml:"summary"`
+
+	// cache hits and misses of the previous gather, for the hit ratio
+	lastHit  uint64
+	lastMiss uint64
+	haveLast bool
+}
+
+// summaryStats are the MAIN stats reported in varnish_summary
+var summaryStats = []string{
+	"backend_fail",
+	"backend_unhealthy",
+	"backend_busy",
+	"threads",
+	"threads_limited",
+	"threads_failed",
+	"thread_queue_len",
+	"sess_dropped",
+	"n_object",
+	"n_lru_nuked",
 }
 
 var defaultStats = []string{"MAIN.cache_hit", "MAIN.cache_miss", "MAIN.uptime"}
@@ -49,6 +74,17 @@ var sampleConfig = `
   ## stats may also be set to ["*"], which will collect all stats
   stats = ["MAIN.cache_hit", "MAIN.cache_miss", "MAIN.uptime"]
 
+  ## Stats to leave out, glob matching can be used, ie, stats_exclude = ["LCK.*"]
+  # stats_exclude = []
+
+  ## Read the stats with varnishstat -j instead of -1, the backend names of
+  ## the VBE stats may contain spaces which -1 does not handle
+  # use_json = false
+
+  ## Report the interval cache hit ratio, backend failures, thread usage and
+  ## object counts in the varnish_summary measurement, independently of stats
+  # summary = false
+
   ## Optional name for the varnish instance (or working directory) to query
   ## Usually append after -n in varnish cli
   # instance_name = instanceName
@@ -67,8 +103,11 @@ func (s *Varnish) SampleConfig() string {
 }
 
 // Shell out to varnish_stat and return the output
-func varnishRunner(cmdName string, useSudo bool, instanceName string, timeout internal.Duration) (*bytes.Buffer, error) {
+func varnishRunner(cmdName string, useSudo bool, instanceName string, useJSON bool, timeout internal.Duration) (*bytes.Buffer, error) {
 	cmdArgs := []string{"-1"}
+	if useJSON {
+		cmdArgs = []string{"-j"}
+	}
 
 	if instanceName != "" {
 		cmdArgs = append(cmdArgs, []string{"-n", instanceName}...)
@@ -114,28 +153,33 @@ func (s *Varnish) Gather(ctx context.Context, acc cua.Accumulator) error {
 		if err != nil {
 			return fmt.Errorf("filter compile: %w", err)
 		}
+		s.exclude, err = filter.Compile(s.StatsExclude)
+		if err != nil {
+			return fmt.Errorf("exclude filter compile: %w", err)
+		}
 	}
 
-	out, err := s.run(s.Binary, s.UseSudo, s.InstanceName, s.Timeout)
+	out, err := s.run(s.Binary, s.UseSudo, s.InstanceName, s.UseJSON, s.Timeout)
 	if err != nil {
 		return fmt.Errorf("error gathering metrics: %w", err)
 	}
 
+	var stats map[string]uint64
+	if s.UseJSON {
+		stats, err = parseJSON(out, acc)
+	} else {
+		stats = parseText(out, acc)
+	}
+	if err != nil {
+		return err
+	}
+
 	sectionMap := make(map[string]map[string]interface{})
-	scanner := bufio.NewScanner(out)
-	for scanner.Scan() {
-		cols := strings.Fields(scanner.Text())
-		if len(cols) < 2 {
-			continue
-		}
-		if !strings.Contains(cols[0], ".") {
+	for stat, value := range stats {
+		if s.filter != nil && !s.filter.Match(stat) {
 			continue
 		}
-
-		stat := cols[0]
-		value := cols[1]
-
-		if s.filter != nil && !s.filter.Match(stat) {
+		if s.exclude != nil && s.exclude.Match(stat) {
 			continue
 		}
 
@@ -148,10 +192,7 @@ func (s *Varnish) Gather(ctx context.Context, acc cua.Accumulator) error {
 			sectionMap[section] = make(map[string]interface{})
 		}
 
-		sectionMap[section][field], err = strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			acc.AddError(fmt.Errorf("expected a numeric value for %s = %v", stat, value))
-		}
+		sectionMap[section][field] = value
 	}
 
 	for section, fields := range sectionMap {
@@ -165,9 +206,105 @@ func (s *Varnish) Gather(ctx context.Context, acc cua.Accumulator) error {
 		acc.AddFields("varnish", fields, tags)
 	}
 
+	if s.Summary {
+		s.gatherSummary(stats, acc)
+	}
+
 	return nil
 }
 
+// gatherSummary reports the cache hit ratio since the previous gather and
+// selected MAIN stats
+func (s *Varnish) gatherSummary(stats map[string]uint64, acc cua.Accumulator) {
+	fields := make(map[string]interface{})
+
+	hit, hitOK := stats["MAIN.cache_hit"]
+	miss, missOK := stats["MAIN.cache_miss"]
+	if hitOK && missOK {
+		// the counters restart with the varnish child process
+		if s.haveLast && hit >= s.lastHit && miss >= s.lastMiss {
+			hits := hit - s.lastHit
+			lookups := hits + miss - s.lastMiss
+			if lookups > 0 {
+				fields["cache_hit_ratio"] = 100 * float64(hits) / float64(lookups)
+			}
+		}
+		s.lastHit, s.lastMiss, s.haveLast = hit, miss, true
+	}
+
+	for _, stat := range summaryStats {
+		if v, ok := stats["MAIN."+stat]; ok {
+			fields[stat] = v
+		}
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields("varnish_summary", fields, nil)
+	}
+}
+
+// parseText parses the varnishstat -1 output
+func parseText(out io.Reader, acc cua.Accumulator) map[string]uint64 {
+	stats := make(map[string]uint64)
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		cols := strings.Fields(scanner.Text())
+		if len(cols) < 2 {
+			continue
+		}
+		if !strings.Contains(cols[0], ".") {
+			continue
+		}
+
+		stat := cols[0]
+		value := cols[1]
+
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			acc.AddError(fmt.Errorf("expected a numeric value for %s = %v", stat, value))
+		}
+		stats[stat] = v
+	}
+	return stats
+}
+
+type jsonCounter struct {
+	Value json.Number `json:"value"`
+}
+
+// parseJSON parses the varnishstat -j output, the counters are in a
+// "counters" object since varnish 6.5 and top level keys before
+func parseJSON(out io.Reader, acc cua.Accumulator) (map[string]uint64, error) {
+	var doc map[string]json.RawMessage
+	if err := json.NewDecoder(out).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse varnishstat json: %w", err)
+	}
+	if counters, ok := doc["counters"]; ok {
+		doc = nil
+		if err := json.Unmarshal(counters, &doc); err != nil {
+			return nil, fmt.Errorf("parse varnishstat json counters: %w", err)
+		}
+	}
+
+	stats := make(map[string]uint64, len(doc))
+	for stat, raw := range doc {
+		if !strings.Contains(stat, ".") {
+			continue
+		}
+		var counter jsonCounter
+		if err := json.Unmarshal(raw, &counter); err != nil {
+			continue
+		}
+		v, err := strconv.ParseUint(counter.Value.String(), 10, 64)
+		if err != nil {
+			acc.AddError(fmt.Errorf("expected a numeric value for %s = %v", stat, counter.Value))
+			continue
+		}
+		stats[stat] = v
+	}
+	return stats, nil
+}
+
 func init() {
 	inputs.Add("varnish", func() cua.Input {
 		return &Varnish{
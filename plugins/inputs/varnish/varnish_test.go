@@ -16,8 +16,8 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func fakeVarnishStat(output string, useSudo bool, instanceName string, timeout internal.Duration) func(string, bool, string, internal.Duration) (*bytes.Buffer, error) { //nolint:unparam
-	return func(string, bool, string, internal.Duration) (*bytes.Buffer, error) {
+func fakeVarnishStat(output string, useSudo bool, instanceName string, timeout internal.Duration) func(string, bool, string, bool, internal.Duration) (*bytes.Buffer, error) { //nolint:unparam
+	return func(string, bool, string, bool, internal.Duration) (*bytes.Buffer, error) {
 		return bytes.NewBuffer([]byte(output)), nil
 	}
 }
@@ -428,3 +428,73 @@ LCK.pipestat.creat                                       1         0.00 Created
 LCK.pipestat.destroy                                     0         0.00 Destroyed locks
 LCK.pipestat.locks                                       0         0.00 Lock Operations
 `
+
+const jsonOutput = `{
+  "version": 1,
+  "timestamp": "2020-10-14T10:00:00",
+  "counters": {
+    "MAIN.uptime": {"description": "Child process uptime", "flag": "c", "format": "d", "value": 326570},
+    "MAIN.cache_hit": {"description": "Cache hits", "flag": "c", "format": "i", "value": 90},
+    "MAIN.cache_miss": {"description": "Cache misses", "flag": "c", "format": "i", "value": 10},
+    "MAIN.threads": {"description": "Total number of threads", "flag": "g", "format": "i", "value": 200},
+    "MAIN.n_object": {"description": "object structs made", "flag": "g", "format": "i", "value": 42},
+    "VBE.boot.default.happy": {"description": "Happy health probes", "flag": "b", "format": "b", "value": 18446744073709551615},
+    "LCK.sma.creat": {"description": "Created locks", "flag": "c", "format": "i", "value": 2}
+  }
+}`
+
+const legacyJSONOutput = `{
+  "timestamp": "2019-10-14T10:00:00",
+  "MAIN.uptime": {"description": "Child process uptime", "flag": "c", "format": "d", "value": 326570},
+  "MAIN.cache_hit": {"description": "Cache hits", "flag": "c", "format": "i", "value": 90}
+}`
+
+func TestParseJSON(t *testing.T) {
+	expect := map[string]map[string]interface{}{
+		jsonOutput: {
+			"uptime":     uint64(326570),
+			"cache_hit":  uint64(90),
+			"cache_miss": uint64(10),
+			"threads":    uint64(200),
+			"n_object":   uint64(42),
+		},
+		legacyJSONOutput: {"uptime": uint64(326570), "cache_hit": uint64(90)},
+	}
+
+	for output, fields := range expect {
+		acc := &testutil.Accumulator{}
+		v := &Varnish{
+			run:          fakeVarnishStat(output, false, "", internal.Duration{Duration: time.Second}),
+			Stats:        []string{"*"},
+			StatsExclude: []string{"LCK.*"},
+			UseJSON:      true,
+		}
+		assert.NoError(t, v.Gather(context.Background(), acc))
+		assert.Len(t, acc.Errors, 0)
+
+		acc.AssertContainsTaggedFields(t, "varnish", fields, map[string]string{"section": "MAIN"})
+		assert.False(t, acc.HasField("varnish", "creat"))
+	}
+}
+
+func TestSummary(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	v := &Varnish{
+		run:     fakeVarnishStat(jsonOutput, false, "", internal.Duration{Duration: time.Second}),
+		UseJSON: true,
+		Summary: true,
+	}
+	assert.NoError(t, v.Gather(context.Background(), acc))
+	acc.AssertContainsFields(t, "varnish_summary",
+		map[string]interface{}{"threads": uint64(200), "n_object": uint64(42)})
+	assert.False(t, acc.HasField("varnish_summary", "cache_hit_ratio"))
+
+	// 15 more hits and 5 more misses since the previous gather
+	v.run = fakeVarnishStat(strings.NewReplacer(`"value": 90`, `"value": 105`, `"value": 10}`, `"value": 15}`).Replace(jsonOutput),
+		false, "", internal.Duration{Duration: time.Second})
+	acc.ClearMetrics()
+	assert.NoError(t, v.Gather(context.Background(), acc))
+	ratio, ok := acc.FloatField("varnish_summary", "cache_hit_ratio")
+	assert.True(t, ok)
+	assert.Equal(t, 75.0, ratio)
+}
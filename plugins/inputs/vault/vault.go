@@ -0,0 +1,438 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const (
+	authToken = "token"
+	authCert  = "cert"
+)
+
+const sampleConfig = `
+  ## Vault server address
+  address = "https://127.0.0.1:8200"
+
+  ## Authentication, one of "token" or "cert"
+  ##   token - use token or the contents of token_file
+  ##   cert  - login with the tls_cert/tls_key client certificate
+  ## Without a token only the health, seal and leader status are gathered.
+  # auth_method = "token"
+  # token = ""
+  # token_file = "/etc/circonus-unified-agent/vault-token"
+
+  ## Mount path and role for the cert auth method
+  # cert_auth_mount = "cert"
+  # cert_role = ""
+
+  ## Telemetry from the sys/metrics endpoint to report, glob patterns matched
+  ## against the vault metric name, set to [] to disable
+  # metrics = ["vault.core.*", "vault.expire.num_leases", "vault.token.*", "vault.runtime.*"]
+
+  ## Timeout for each request
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/vault/ca.pem"
+  # tls_cert = "/etc/vault/client.pem"
+  # tls_key = "/etc/vault/client-key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type Vault struct {
+	Log           cua.Logger        `toml:"-"`
+	Address       string            `toml:"address"`
+	AuthMethod    string            `toml:"auth_method"`
+	Token         string            `toml:"token"`
+	TokenFile     string            `toml:"token_file"`
+	CertAuthMount string            `toml:"cert_auth_mount"`
+	CertRole      string            `toml:"cert_role"`
+	Metrics       []string          `toml:"metrics"`
+	Timeout       internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client        *http.Client
+	metricsFilter filter.Filter
+
+	mu          sync.Mutex
+	loginToken  string
+	loginExpire time.Time
+}
+
+type healthResponse struct {
+	Initialized                bool   `json:"initialized"`
+	Sealed                     bool   `json:"sealed"`
+	Standby                    bool   `json:"standby"`
+	PerformanceStandby         bool   `json:"performance_standby"`
+	ReplicationPerformanceMode string `json:"replication_performance_mode"`
+	ReplicationDRMode          string `json:"replication_dr_mode"`
+	Version                    string `json:"version"`
+	ClusterName                string `json:"cluster_name"`
+}
+
+type sealStatusResponse struct {
+	Type        string `json:"type"`
+	Threshold   int    `json:"t"`
+	Shares      int    `json:"n"`
+	Progress    int    `json:"progress"`
+	Initialized bool   `json:"initialized"`
+	Sealed      bool   `json:"sealed"`
+}
+
+type leaderResponse struct {
+	HAEnabled bool `json:"ha_enabled"`
+	IsSelf    bool `json:"is_self"`
+}
+
+type tokenLookupResponse struct {
+	Data struct {
+		TTL int64 `json:"ttl"`
+	} `json:"data"`
+}
+
+type loginResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+// metricsResponse is the json format of sys/metrics, the go-metrics
+// in-memory sink summary
+type metricsResponse struct {
+	Gauges   []gaugeValue   `json:"Gauges"`
+	Counters []sampledValue `json:"Counters"`
+	Samples  []sampledValue `json:"Samples"`
+}
+
+type gaugeValue struct {
+	Name   string            `json:"Name"`
+	Value  float64           `json:"Value"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type sampledValue struct {
+	Name   string            `json:"Name"`
+	Count  int64             `json:"Count"`
+	Sum    float64           `json:"Sum"`
+	Min    float64           `json:"Min"`
+	Max    float64           `json:"Max"`
+	Mean   float64           `json:"Mean"`
+	Labels map[string]string `json:"Labels"`
+}
+
+func (v *Vault) SampleConfig() string {
+	return sampleConfig
+}
+
+func (v *Vault) Description() string {
+	return "Read seal status, HA mode, token and telemetry metrics from HashiCorp Vault"
+}
+
+func (v *Vault) Init() error {
+	if v.Address == "" {
+		return fmt.Errorf("address is required")
+	}
+	v.Address = strings.TrimSuffix(v.Address, "/")
+
+	switch v.AuthMethod {
+	case "", authToken:
+		v.AuthMethod = authToken
+		if v.Token != "" && v.TokenFile != "" {
+			return fmt.Errorf("only one of token and token_file can be set")
+		}
+	case authCert:
+		if v.TLSCert == "" || v.TLSKey == "" {
+			return fmt.Errorf("tls_cert and tls_key are required for cert auth")
+		}
+		if v.CertAuthMount == "" {
+			v.CertAuthMount = "cert"
+		}
+	default:
+		return fmt.Errorf("unknown auth_method (%s)", v.AuthMethod)
+	}
+
+	f, err := filter.Compile(v.Metrics)
+	if err != nil {
+		return fmt.Errorf("compile metrics filter: %w", err)
+	}
+	v.metricsFilter = f
+
+	tlsCfg, err := v.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+
+	v.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: v.Timeout.Duration,
+	}
+
+	return nil
+}
+
+func (v *Vault) Gather(ctx context.Context, acc cua.Accumulator) error {
+	tags := map[string]string{"address": v.Address}
+	fields := make(map[string]interface{})
+
+	// standby and sealed nodes answer sys/health with status codes other
+	// than 200, ask for 200 so the body is always read the same way
+	var health healthResponse
+	if err := v.get(ctx, "/v1/sys/health?standbyok=true&perfstandbyok=true&sealedcode=200&uninitcode=200&drsecondarycode=200", "", &health); err != nil {
+		return fmt.Errorf("health: %w", err)
+	}
+	if health.ClusterName != "" {
+		tags["cluster_name"] = health.ClusterName
+	}
+	fields["initialized"] = boolInt(health.Initialized)
+	fields["sealed"] = boolInt(health.Sealed)
+	fields["standby"] = boolInt(health.Standby)
+	fields["performance_standby"] = boolInt(health.PerformanceStandby)
+	fields["version"] = health.Version
+	if health.ReplicationPerformanceMode != "" {
+		fields["replication_performance_mode"] = health.ReplicationPerformanceMode
+	}
+	if health.ReplicationDRMode != "" {
+		fields["replication_dr_mode"] = health.ReplicationDRMode
+	}
+
+	var seal sealStatusResponse
+	if err := v.get(ctx, "/v1/sys/seal-status", "", &seal); err != nil {
+		acc.AddError(fmt.Errorf("seal status: %w", err))
+	} else {
+		fields["seal_threshold"] = seal.Threshold
+		fields["seal_shares"] = seal.Shares
+		fields["unseal_progress"] = seal.Progress
+	}
+
+	if !health.Sealed && health.Initialized {
+		var leader leaderResponse
+		if err := v.get(ctx, "/v1/sys/leader", "", &leader); err != nil {
+			acc.AddError(fmt.Errorf("leader: %w", err))
+		} else {
+			fields["ha_enabled"] = boolInt(leader.HAEnabled)
+			fields["active"] = boolInt(leader.IsSelf || !leader.HAEnabled)
+		}
+	}
+
+	// the authenticated endpoints, and cert login, are unavailable while sealed
+	var token string
+	if !health.Sealed && health.Initialized {
+		var err error
+		if token, err = v.token(ctx); err != nil {
+			acc.AddError(fmt.Errorf("auth: %w", err))
+		}
+	}
+	if token != "" {
+		var lookup tokenLookupResponse
+		if err := v.get(ctx, "/v1/auth/token/lookup-self", token, &lookup); err != nil {
+			acc.AddError(fmt.Errorf("token lookup: %w", err))
+		} else {
+			fields["token_ttl"] = lookup.Data.TTL
+		}
+
+		if err := v.gatherMetrics(ctx, acc, token, fields); err != nil {
+			acc.AddError(fmt.Errorf("metrics: %w", err))
+		}
+	}
+
+	acc.AddFields("vault", fields, tags)
+
+	return nil
+}
+
+// gatherMetrics reports the sys/metrics telemetry matching the metrics
+// filter, it adds the token counts to fields
+func (v *Vault) gatherMetrics(ctx context.Context, acc cua.Accumulator, token string, fields map[string]interface{}) error {
+	var resp metricsResponse
+	if err := v.get(ctx, "/v1/sys/metrics", token, &resp); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	grouper := metric.NewSeriesGrouper()
+	add := func(name string, labels map[string]string, field string, value interface{}) {
+		tags := map[string]string{"address": v.Address}
+		for k, val := range labels {
+			tags[k] = val
+		}
+		_ = grouper.Add("vault_metrics", tags, now, strings.ReplaceAll(name, ".", "_")+field, value)
+	}
+
+	tokens := 0.0
+	haveTokens := false
+	for _, g := range resp.Gauges {
+		if g.Name == "vault.token.count" {
+			tokens += g.Value
+			haveTokens = true
+		}
+		if v.metricsFilter == nil || !v.metricsFilter.Match(g.Name) {
+			continue
+		}
+		add(g.Name, g.Labels, "", g.Value)
+	}
+	if haveTokens {
+		fields["token_count"] = int64(tokens)
+	}
+
+	for _, list := range [][]sampledValue{resp.Counters, resp.Samples} {
+		for _, s := range list {
+			if v.metricsFilter == nil || !v.metricsFilter.Match(s.Name) {
+				continue
+			}
+			add(s.Name, s.Labels, "_count", s.Count)
+			add(s.Name, s.Labels, "_sum", s.Sum)
+			add(s.Name, s.Labels, "_min", s.Min)
+			add(s.Name, s.Labels, "_max", s.Max)
+			add(s.Name, s.Labels, "_mean", s.Mean)
+		}
+	}
+
+	for _, m := range grouper.Metrics() {
+		acc.AddMetric(m)
+	}
+
+	return nil
+}
+
+// token returns the token used for the authenticated endpoints, logging in
+// with the client certificate again when the previous token has expired
+func (v *Vault) token(ctx context.Context) (string, error) {
+	switch {
+	case v.AuthMethod == authToken && v.TokenFile != "":
+		b, err := ioutil.ReadFile(v.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("read token file: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	case v.AuthMethod == authToken:
+		return v.Token, nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.loginToken != "" && (v.loginExpire.IsZero() || time.Now().Before(v.loginExpire)) {
+		return v.loginToken, nil
+	}
+
+	body := "{}"
+	if v.CertRole != "" {
+		b, err := json.Marshal(map[string]string{"name": v.CertRole})
+		if err != nil {
+			return "", fmt.Errorf("marshal login: %w", err)
+		}
+		body = string(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.Address+"/v1/auth/"+v.CertAuthMount+"/login", strings.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var login loginResponse
+	if err := v.do(req, &login); err != nil {
+		return "", fmt.Errorf("cert login: %w", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("cert login: no client token returned")
+	}
+
+	v.loginToken = login.Auth.ClientToken
+	v.loginExpire = time.Time{}
+	if login.Auth.LeaseDuration > 0 {
+		// log in again a little before the token expires
+		lease := time.Duration(login.Auth.LeaseDuration) * time.Second
+		v.loginExpire = time.Now().Add(lease - lease/10)
+	}
+
+	return v.loginToken, nil
+}
+
+func (v *Vault) get(ctx context.Context, path, token string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.Address+path, nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	err = v.do(req, out)
+	var se *statusError
+	if errors.As(err, &se) && se.code == http.StatusForbidden && v.AuthMethod == authCert {
+		// the token was revoked, log in again on the next gather
+		v.mu.Lock()
+		v.loginToken = ""
+		v.mu.Unlock()
+	}
+	return err
+}
+
+func (v *Vault) do(req *http.Request, out interface{}) error {
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{path: req.URL.Path, code: resp.StatusCode, status: resp.Status}
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("parse %s: %w", req.URL.Path, err)
+	}
+
+	return nil
+}
+
+type statusError struct {
+	path   string
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned HTTP status %s", e.path, e.status)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	inputs.Add("vault", func() cua.Input {
+		return &Vault{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+			Metrics: []string{"vault.core.*", "vault.expire.num_leases", "vault.token.*", "vault.runtime.*"},
+		}
+	})
+}
@@ -0,0 +1,217 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleMetrics = `{
+  "Timestamp": "2020-10-13 12:00:00 +0000 UTC",
+  "Gauges": [
+    {"Name": "vault.token.count", "Value": 12, "Labels": {"namespace": "root"}},
+    {"Name": "vault.token.count", "Value": 3, "Labels": {"namespace": "ops"}},
+    {"Name": "vault.expire.num_leases", "Value": 40, "Labels": {}},
+    {"Name": "vault.audit.log_request_failure", "Value": 0, "Labels": {}}
+  ],
+  "Points": [],
+  "Counters": [
+    {"Name": "vault.core.handle_login_request", "Count": 2, "Rate": 0.2, "Sum": 2, "Min": 1, "Max": 1, "Mean": 1, "Stddev": 0, "Labels": {}}
+  ],
+  "Samples": [
+    {"Name": "vault.core.handle_request", "Count": 4, "Rate": 0.1, "Sum": 10, "Min": 1, "Max": 4, "Mean": 2.5, "Stddev": 1.2, "Labels": {}}
+  ]
+}`
+
+type testVault struct {
+	sealed  bool
+	logins  int
+	revoked bool
+}
+
+func (tv *testVault) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authed := func() bool {
+			token := r.Header.Get("X-Vault-Token")
+			if token == "" || (tv.revoked && token == "login-1") {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"errors":["permission denied"]}`)
+				return false
+			}
+			return true
+		}
+
+		switch r.URL.Path {
+		case "/v1/sys/health":
+			require.Equal(t, "200", r.URL.Query().Get("sealedcode"))
+			fmt.Fprintf(w, `{"initialized":true,"sealed":%t,"standby":false,"performance_standby":false,`+
+				`"replication_performance_mode":"disabled","replication_dr_mode":"disabled",`+
+				`"server_time_utc":1602590535,"version":"1.5.4","cluster_name":"vault-cluster-a","cluster_id":"abc"}`, tv.sealed)
+		case "/v1/sys/seal-status":
+			fmt.Fprintf(w, `{"type":"shamir","initialized":true,"sealed":%t,"t":3,"n":5,"progress":1,"version":"1.5.4"}`, tv.sealed)
+		case "/v1/sys/leader":
+			fmt.Fprint(w, `{"ha_enabled":true,"is_self":true,"leader_address":"https://10.0.0.1:8200"}`)
+		case "/v1/auth/cert/login":
+			require.Equal(t, http.MethodPost, r.Method)
+			tv.logins++
+			fmt.Fprintf(w, `{"auth":{"client_token":"login-%d","lease_duration":3600}}`, tv.logins)
+		case "/v1/auth/token/lookup-self":
+			if authed() {
+				fmt.Fprint(w, `{"data":{"ttl":1800}}`)
+			}
+		case "/v1/sys/metrics":
+			if authed() {
+				fmt.Fprint(w, sampleMetrics)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestInit(t *testing.T) {
+	v := &Vault{}
+	require.Error(t, v.Init())
+
+	v = &Vault{Address: "http://localhost:8200", Token: "a", TokenFile: "b"}
+	require.Error(t, v.Init())
+
+	v = &Vault{Address: "http://localhost:8200", AuthMethod: "cert"}
+	require.Error(t, v.Init())
+
+	v = &Vault{Address: "http://localhost:8200", AuthMethod: "approle"}
+	require.Error(t, v.Init())
+
+	v = &Vault{Address: "http://localhost:8200/"}
+	require.NoError(t, v.Init())
+	require.Equal(t, "http://localhost:8200", v.Address)
+	require.Equal(t, authToken, v.AuthMethod)
+}
+
+func TestGatherToken(t *testing.T) {
+	tv := &testVault{}
+	ts := httptest.NewServer(tv.handler(t))
+	defer ts.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s.token\n"), 0600))
+
+	v := &Vault{
+		Log:       testutil.Logger{},
+		Address:   ts.URL,
+		TokenFile: tokenFile,
+		Metrics:   []string{"vault.core.*", "vault.expire.num_leases", "vault.token.*"},
+	}
+	require.NoError(t, v.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, v.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+
+	tags := map[string]string{"address": ts.URL, "cluster_name": "vault-cluster-a"}
+	acc.AssertContainsTaggedFields(t, "vault",
+		map[string]interface{}{
+			"initialized":                  1,
+			"sealed":                       0,
+			"standby":                      0,
+			"performance_standby":          0,
+			"version":                      "1.5.4",
+			"replication_performance_mode": "disabled",
+			"replication_dr_mode":          "disabled",
+			"seal_threshold":               3,
+			"seal_shares":                  5,
+			"unseal_progress":              1,
+			"ha_enabled":                   1,
+			"active":                       1,
+			"token_ttl":                    int64(1800),
+			"token_count":                  int64(15),
+		},
+		tags)
+
+	acc.AssertContainsTaggedFields(t, "vault_metrics",
+		map[string]interface{}{
+			"vault_expire_num_leases":               40.0,
+			"vault_core_handle_login_request_count": int64(2),
+			"vault_core_handle_login_request_sum":   2.0,
+			"vault_core_handle_login_request_min":   1.0,
+			"vault_core_handle_login_request_max":   1.0,
+			"vault_core_handle_login_request_mean":  1.0,
+			"vault_core_handle_request_count":       int64(4),
+			"vault_core_handle_request_sum":         10.0,
+			"vault_core_handle_request_min":         1.0,
+			"vault_core_handle_request_max":         4.0,
+			"vault_core_handle_request_mean":        2.5,
+		},
+		map[string]string{"address": ts.URL})
+	acc.AssertContainsTaggedFields(t, "vault_metrics",
+		map[string]interface{}{"vault_token_count": 12.0},
+		map[string]string{"address": ts.URL, "namespace": "root"})
+	acc.AssertDoesNotContainsTaggedFields(t, "vault_metrics",
+		map[string]interface{}{"vault_audit_log_request_failure": 0.0},
+		map[string]string{"address": ts.URL})
+}
+
+func TestGatherNoToken(t *testing.T) {
+	tv := &testVault{}
+	ts := httptest.NewServer(tv.handler(t))
+	defer ts.Close()
+
+	v := &Vault{Log: testutil.Logger{}, Address: ts.URL}
+	require.NoError(t, v.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, v.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+	require.False(t, acc.HasMeasurement("vault_metrics"))
+	require.False(t, acc.HasField("vault", "token_ttl"))
+	require.True(t, acc.HasField("vault", "sealed"))
+}
+
+func TestGatherSealed(t *testing.T) {
+	tv := &testVault{sealed: true}
+	ts := httptest.NewServer(tv.handler(t))
+	defer ts.Close()
+
+	v := &Vault{Log: testutil.Logger{}, Address: ts.URL, Token: "s.token"}
+	require.NoError(t, v.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, v.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+
+	sealed, ok := acc.IntField("vault", "sealed")
+	require.True(t, ok)
+	require.Equal(t, 1, sealed)
+	require.False(t, acc.HasField("vault", "active"))
+	require.False(t, acc.HasMeasurement("vault_metrics"))
+}
+
+func TestGatherCertLogin(t *testing.T) {
+	tv := &testVault{}
+	ts := httptest.NewServer(tv.handler(t))
+	defer ts.Close()
+
+	v := &Vault{Log: testutil.Logger{}, Address: ts.URL, AuthMethod: authCert, CertAuthMount: "cert"}
+	v.client = ts.Client()
+
+	var acc testutil.Accumulator
+	require.NoError(t, v.Gather(context.Background(), &acc))
+	require.NoError(t, v.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 0)
+	require.Equal(t, 1, tv.logins)
+	require.True(t, acc.HasField("vault", "token_ttl"))
+
+	// a revoked token is dropped and the next gather logs in again
+	tv.revoked = true
+	require.NoError(t, v.Gather(context.Background(), &acc))
+	require.NotEmpty(t, acc.Errors)
+	require.NoError(t, v.Gather(context.Background(), &acc))
+	require.Equal(t, 2, tv.logins)
+}
@@ -0,0 +1,326 @@
+package voip
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// rtpQoS is the RTP statistics of the audio stream of a channel,
+// https://wiki.asterisk.org/wiki/display/AST/Asterisk+18+Function_CHANNEL
+const rtpQoS = "CHANNEL(rtpqos,audio,all)"
+
+// amiMessage is a response or an event of the Asterisk Manager Interface
+type amiMessage map[string]string
+
+// amiConn is a logged in connection to the Asterisk Manager Interface
+type amiConn struct {
+	conn     net.Conn
+	r        *bufio.Reader
+	actionID int
+}
+
+func (c *amiConn) readMessage() (amiMessage, error) {
+	msg := amiMessage{}
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if len(msg) == 0 {
+				continue
+			}
+			return msg, nil
+		}
+		if i := strings.Index(line, ":"); i > 0 {
+			msg[line[:i]] = strings.TrimSpace(line[i+1:])
+		}
+	}
+}
+
+// action sends an action with the key value pairs of args and returns its
+// response, events of other actions are skipped
+func (c *amiConn) action(name string, args ...string) (amiMessage, string, error) {
+	c.actionID++
+	id := strconv.Itoa(c.actionID)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Action: %s\r\nActionID: %s\r\n", name, id)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, "%s: %s\r\n", args[i], args[i+1])
+	}
+	b.WriteString("\r\n")
+	if _, err := c.conn.Write([]byte(b.String())); err != nil {
+		return nil, id, fmt.Errorf("write %s: %w", name, err)
+	}
+
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			return nil, id, fmt.Errorf("%s: %w", name, err)
+		}
+		if msg["ActionID"] != id || msg["Response"] == "" {
+			continue
+		}
+		if strings.EqualFold(msg["Response"], "Error") {
+			return nil, id, fmt.Errorf("%s: %s", name, msg["Message"])
+		}
+		return msg, id, nil
+	}
+}
+
+// list sends an action which responds with a list of events and returns the
+// events up to the one completing the list
+func (c *amiConn) list(name, complete string, args ...string) ([]amiMessage, error) {
+	_, id, err := c.action(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	var events []amiMessage
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if msg["ActionID"] != id {
+			continue
+		}
+		if msg["Event"] == complete {
+			return events, nil
+		}
+		events = append(events, msg)
+	}
+}
+
+func (v *VoIP) gatherAsterisk(ctx context.Context, u *url.URL, acc cua.Accumulator) error {
+	conn, err := v.dial(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c := &amiConn{conn: conn, r: bufio.NewReader(conn)}
+	banner, err := c.r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("read banner: %w", err)
+	}
+	if !strings.HasPrefix(banner, "Asterisk Call Manager") {
+		return fmt.Errorf("unexpected banner %q", strings.TrimSpace(banner))
+	}
+
+	secret, _ := u.User.Password()
+	if _, _, err := c.action("Login", "Username", u.User.Username(), "Secret", secret, "Events", "off"); err != nil {
+		return err
+	}
+	defer func() { _, _, _ = c.action("Logoff") }()
+
+	tags := map[string]string{
+		"server":   u.Host,
+		"platform": "asterisk",
+	}
+	fields := map[string]interface{}{}
+
+	status, _, err := c.action("CoreStatus")
+	if err != nil {
+		return err
+	}
+	if calls, err := strconv.ParseInt(status["CoreCurrentCalls"], 10, 64); err == nil {
+		fields["active_calls"] = calls
+	}
+
+	channels, err := c.list("CoreShowChannels", "CoreShowChannelsComplete")
+	if err != nil {
+		return err
+	}
+	fields["active_channels"] = len(channels)
+
+	if v.CallQuality {
+		var q quality
+		for _, ch := range channels {
+			resp, _, err := c.action("Getvar", "Channel", ch["Channel"], "Variable", rtpQoS)
+			if err != nil {
+				// e.g. Local channels have no RTP, or the channel hung up
+				continue
+			}
+			q.add(resp["Value"])
+		}
+		q.fields(fields)
+	}
+
+	if v.Peers {
+		addPeers(asteriskPeers(c), tags, fields, acc)
+	}
+
+	acc.AddFields("voip", fields, tags)
+	return nil
+}
+
+// asteriskPeers returns the peers of chan_sip and chan_pjsip, the actions of
+// a channel driver which is not loaded fail and are skipped
+func asteriskPeers(c *amiConn) []*peer {
+	var peers []*peer
+
+	if events, err := c.list("SIPpeers", "PeerlistComplete"); err == nil {
+		for _, e := range events {
+			peers = append(peers, sipPeer(e))
+		}
+	}
+
+	endpoints, err := c.list("PJSIPShowEndpoints", "EndpointListComplete")
+	if err != nil {
+		return peers
+	}
+	// the contacts list is available since Asterisk 16, before that the
+	// reachability of the endpoints is unknown
+	contacts, _ := c.list("PJSIPShowContacts", "ContactListComplete")
+	for _, e := range endpoints {
+		peers = append(peers, pjsipEndpoint(e, contacts))
+	}
+	return peers
+}
+
+// sipPeer reads a PeerEntry event, the status is e.g. "OK (5 ms)",
+// "LAGGED (2100 ms)", "UNREACHABLE" or "Unmonitored" without qualify
+func sipPeer(e amiMessage) *peer {
+	p := &peer{name: e["ObjectName"], technology: "sip"}
+	registered := e["IPaddress"] != "" && e["IPaddress"] != "-none-"
+	p.registered = &registered
+
+	status := e["Status"]
+	var reachable bool
+	switch {
+	case strings.HasPrefix(status, "OK"), strings.HasPrefix(status, "LAGGED"):
+		reachable = true
+		if i := strings.Index(status, "("); i > 0 {
+			ms, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(status[i+1:]), " ms)"), 64)
+			if err == nil {
+				p.rtt = time.Duration(ms * float64(time.Millisecond))
+			}
+		}
+		p.reachable = &reachable
+	case strings.HasPrefix(status, "UNREACHABLE"):
+		p.reachable = &reachable
+	}
+	return p
+}
+
+// pjsipEndpoint reads an EndpointList event, an endpoint is registered when
+// it has a contact, static or from a registration
+func pjsipEndpoint(e amiMessage, contacts []amiMessage) *peer {
+	name := e["ObjectName"]
+	p := &peer{name: name, technology: "pjsip"}
+	registered := strings.Trim(e["Contacts"], ",") != ""
+	p.registered = &registered
+
+	for _, c := range contacts {
+		if c["Endpoint"] != name {
+			continue
+		}
+		var reachable bool
+		switch c["Status"] {
+		case "Reachable", "Avail":
+			reachable = true
+		case "Unreachable", "Unavail":
+		default:
+			// Unknown or NonQualified
+			continue
+		}
+		if p.reachable == nil || reachable {
+			p.reachable = &reachable
+		}
+		usec, err := strconv.ParseFloat(c["RoundtripUsec"], 64)
+		if err == nil && usec > 0 {
+			rtt := time.Duration(usec * float64(time.Microsecond))
+			if p.rtt == 0 || rtt < p.rtt {
+				p.rtt = rtt
+			}
+		}
+	}
+	return p
+}
+
+// quality sums up the RTP statistics of the channels, jitter and round trip
+// time are in seconds
+type quality struct {
+	streams     int
+	rxJitterSum float64
+	rxJitterMax float64
+	txJitterSum float64
+	txJitterMax float64
+	rttSum      float64
+	rttMax      float64
+	rttStreams  int
+	lost        int64
+	remoteLost  int64
+}
+
+// add adds the value of CHANNEL(rtpqos,audio,all), e.g.
+// ssrc=1;themssrc=2;lp=0;rxjitter=0.000125;rxcount=240;txjitter=0.0;txcount=241;rlp=0;rtt=0.012
+func (q *quality) add(value string) {
+	stats := map[string]string{}
+	for _, kv := range strings.Split(value, ";") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			stats[parts[0]] = parts[1]
+		}
+	}
+	if _, ok := stats["rxjitter"]; !ok {
+		return
+	}
+
+	float := func(key string) float64 {
+		f, _ := strconv.ParseFloat(stats[key], 64)
+		return f
+	}
+	integer := func(key string) int64 {
+		i, _ := strconv.ParseInt(stats[key], 10, 64)
+		return i
+	}
+
+	q.streams++
+	rx, tx, rtt := float("rxjitter"), float("txjitter"), float("rtt")
+	q.rxJitterSum += rx
+	q.txJitterSum += tx
+	if rx > q.rxJitterMax {
+		q.rxJitterMax = rx
+	}
+	if tx > q.txJitterMax {
+		q.txJitterMax = tx
+	}
+	// no RTCP report received yet
+	if rtt > 0 {
+		q.rttStreams++
+		q.rttSum += rtt
+		if rtt > q.rttMax {
+			q.rttMax = rtt
+		}
+	}
+	q.lost += integer("lp")
+	q.remoteLost += integer("rlp")
+}
+
+func (q *quality) fields(fields map[string]interface{}) {
+	fields["rtp_streams"] = q.streams
+	if q.streams == 0 {
+		return
+	}
+	fields["rx_jitter_avg"] = q.rxJitterSum / float64(q.streams)
+	fields["rx_jitter_max"] = q.rxJitterMax
+	fields["tx_jitter_avg"] = q.txJitterSum / float64(q.streams)
+	fields["tx_jitter_max"] = q.txJitterMax
+	if q.rttStreams > 0 {
+		fields["rtt_avg"] = q.rttSum / float64(q.rttStreams)
+		fields["rtt_max"] = q.rttMax
+	}
+	fields["packets_lost"] = q.lost
+	fields["remote_packets_lost"] = q.remoteLost
+}
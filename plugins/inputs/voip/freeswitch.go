@@ -0,0 +1,196 @@
+package voip
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// eslConn is an authenticated connection to the FreeSWITCH event socket
+type eslConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// readMessage reads the headers of a message and its body, if any
+func (c *eslConn) readMessage() (map[string]string, string, error) {
+	headers := map[string]string{}
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, "", fmt.Errorf("read: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if len(headers) == 0 {
+				continue
+			}
+			break
+		}
+		if i := strings.Index(line, ":"); i > 0 {
+			headers[line[:i]] = strings.TrimSpace(line[i+1:])
+		}
+	}
+
+	n, err := strconv.Atoi(headers["Content-Length"])
+	if err != nil || n <= 0 {
+		return headers, "", nil
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return nil, "", fmt.Errorf("read body: %w", err)
+	}
+	return headers, string(body), nil
+}
+
+// api runs an API command, e.g. "show calls count", and returns its output
+func (c *eslConn) api(command string) (string, error) {
+	if _, err := c.conn.Write([]byte("api " + command + "\n\n")); err != nil {
+		return "", fmt.Errorf("write %s: %w", command, err)
+	}
+	for {
+		headers, body, err := c.readMessage()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", command, err)
+		}
+		switch headers["Content-Type"] {
+		case "api/response":
+			if strings.HasPrefix(body, "-ERR") {
+				return "", fmt.Errorf("%s: %s", command, strings.TrimSpace(body))
+			}
+			return body, nil
+		case "text/disconnect-notice":
+			return "", fmt.Errorf("%s: disconnected", command)
+		}
+	}
+}
+
+// sofiaGateways is the output of sofia xmlstatus gateway
+type sofiaGateways struct {
+	Gateways []struct {
+		Name     string `xml:"name"`
+		Profile  string `xml:"profile"`
+		State    string `xml:"state"`
+		Status   string `xml:"status"`
+		PingTime string `xml:"pingtime"`
+	} `xml:"gateway"`
+}
+
+func (v *VoIP) gatherFreeSWITCH(ctx context.Context, u *url.URL, acc cua.Accumulator) error {
+	conn, err := v.dial(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c := &eslConn{conn: conn, r: bufio.NewReader(conn)}
+	headers, _, err := c.readMessage()
+	if err != nil {
+		return err
+	}
+	if headers["Content-Type"] != "auth/request" {
+		return fmt.Errorf("unexpected greeting %q", headers["Content-Type"])
+	}
+	password, _ := u.User.Password()
+	if _, err := c.conn.Write([]byte("auth " + password + "\n\n")); err != nil {
+		return fmt.Errorf("write auth: %w", err)
+	}
+	headers, _, err = c.readMessage()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(headers["Reply-Text"], "+OK") {
+		return fmt.Errorf("auth: %s", headers["Reply-Text"])
+	}
+	defer func() { _, _ = c.conn.Write([]byte("exit\n\n")) }()
+
+	tags := map[string]string{
+		"server":   u.Host,
+		"platform": "freeswitch",
+	}
+	fields := map[string]interface{}{}
+
+	for field, command := range map[string]string{
+		"active_calls":    "show calls count",
+		"active_channels": "show channels count",
+		"registrations":   "show registrations count",
+	} {
+		out, err := c.api(command)
+		if err != nil {
+			return err
+		}
+		count, err := parseCount(out)
+		if err != nil {
+			return fmt.Errorf("%s: %w", command, err)
+		}
+		fields[field] = count
+	}
+
+	if v.Peers {
+		// mod_sofia may not be loaded
+		if out, err := c.api("sofia xmlstatus gateway"); err == nil {
+			peers, err := sofiaPeers(out)
+			if err != nil {
+				return err
+			}
+			addPeers(peers, tags, fields, acc)
+		}
+	}
+
+	acc.AddFields("voip", fields, tags)
+	return nil
+}
+
+// parseCount reads the output of a show count command, "\n3 total.\n"
+func parseCount(out string) (int64, error) {
+	f := strings.Fields(out)
+	if len(f) != 2 || f[1] != "total." {
+		return 0, fmt.Errorf("unexpected output %q", strings.TrimSpace(out))
+	}
+	n, err := strconv.ParseInt(f[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse count: %w", err)
+	}
+	return n, nil
+}
+
+// sofiaPeers returns the gateways of the sofia profiles, the state of a
+// gateway is REGED once registered and NOREG when it does not register, its
+// status is UP or DOWN by the options pings
+func sofiaPeers(out string) ([]*peer, error) {
+	var gateways sofiaGateways
+	d := xml.NewDecoder(strings.NewReader(out))
+	// the output is declared ISO-8859-1, the names are plain ASCII
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	if err := d.Decode(&gateways); err != nil {
+		return nil, fmt.Errorf("parse sofia gateways: %w", err)
+	}
+
+	peers := make([]*peer, 0, len(gateways.Gateways))
+	for _, gw := range gateways.Gateways {
+		p := &peer{name: gw.Profile + "/" + gw.Name, technology: "sofia"}
+		if gw.State != "NOREG" {
+			registered := gw.State == "REGED"
+			p.registered = &registered
+		}
+		switch gw.Status {
+		case "UP", "DOWN":
+			reachable := gw.Status == "UP"
+			p.reachable = &reachable
+		}
+		if ms, err := strconv.ParseFloat(gw.PingTime, 64); err == nil && ms > 0 {
+			p.rtt = time.Duration(ms * float64(time.Millisecond))
+		}
+		peers = append(peers, p)
+	}
+	return peers, nil
+}
@@ -0,0 +1,114 @@
+package generic
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers/json"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultMetricName      = "webhooks"
+	defaultSignatureHeader = "X-Signature"
+)
+
+// Webhook receives JSON events of any service, the events are parsed with
+// the json data format
+type Webhook struct {
+	Path             string
+	MetricName       string   `toml:"metric_name"`
+	TagKeys          []string `toml:"tag_keys"`
+	JSONStringFields []string `toml:"json_string_fields"`
+	JSONQuery        string   `toml:"json_query"`
+	JSONTimeKey      string   `toml:"json_time_key"`
+	JSONTimeFormat   string   `toml:"json_time_format"`
+	// Secret is the key of the HMAC-SHA256 signature of the body, hex encoded
+	// in the SignatureHeader header with an optional sha256= prefix
+	Secret          string
+	SignatureHeader string `toml:"signature_header"`
+
+	parser *json.Parser
+	acc    cua.Accumulator
+}
+
+func (g *Webhook) Register(router *mux.Router, acc cua.Accumulator) {
+	if err := g.init(); err != nil {
+		log.Printf("E! Failed to start the webhooks_generic on %s: %s\n", g.Path, err)
+		return
+	}
+	router.HandleFunc(g.Path, g.eventHandler).Methods("POST")
+	log.Printf("I! Started the webhooks_generic on %s\n", g.Path)
+	g.acc = acc
+}
+
+func (g *Webhook) init() error {
+	if g.MetricName == "" {
+		g.MetricName = defaultMetricName
+	}
+	if g.SignatureHeader == "" {
+		g.SignatureHeader = defaultSignatureHeader
+	}
+
+	parser, err := json.New(&json.Config{
+		MetricName:   g.MetricName,
+		TagKeys:      g.TagKeys,
+		StringFields: g.JSONStringFields,
+		Query:        g.JSONQuery,
+		TimeKey:      g.JSONTimeKey,
+		TimeFormat:   g.JSONTimeFormat,
+	})
+	if err != nil {
+		return fmt.Errorf("json parser: %w", err)
+	}
+	g.parser = parser
+	return nil
+}
+
+func (g *Webhook) eventHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if g.Secret != "" && !checkSignature(g.Secret, data, r.Header.Get(g.SignatureHeader)) {
+		log.Printf("E! Fail to check the generic webhook signature\n")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	metrics, err := g.parser.Parse(data)
+	if err != nil {
+		g.acc.AddError(fmt.Errorf("parse event: %w", err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for _, m := range metrics {
+		if !m.HasField("count") {
+			m.AddField("count", uint64(1))
+		}
+		g.acc.AddMetric(m)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func checkSignature(secret string, data []byte, signature string) bool {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	return hmac.Equal([]byte(strings.ToLower(signature)), []byte(generateSignature(secret, data)))
+}
+
+func generateSignature(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,92 @@
+package generic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func postWebhooks(g *Webhook, body, signature string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/generic", strings.NewReader(body))
+	if signature != "" {
+		req.Header.Set("X-Signature", signature)
+	}
+	w := httptest.NewRecorder()
+	g.eventHandler(w, req)
+	return w
+}
+
+func TestEvent(t *testing.T) {
+	var acc testutil.Accumulator
+	g := &Webhook{
+		Path:             "/generic",
+		MetricName:       "deploys",
+		TagKeys:          []string{"service"},
+		JSONStringFields: []string{"version"},
+		JSONTimeKey:      "time",
+		JSONTimeFormat:   "unix",
+		acc:              &acc,
+	}
+	require.NoError(t, g.init())
+
+	resp := postWebhooks(g, `{"service": "api", "version": "1.2.3", "duration": 42, "time": 1600000000}`, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	expected := []cua.Metric{
+		testutil.MustMetric(
+			"deploys",
+			map[string]string{"service": "api"},
+			map[string]interface{}{
+				"version":  "1.2.3",
+				"duration": 42.0,
+				"count":    uint64(1),
+			},
+			time.Unix(1600000000, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics())
+}
+
+func TestEventArray(t *testing.T) {
+	var acc testutil.Accumulator
+	g := &Webhook{Path: "/generic", acc: &acc}
+	require.NoError(t, g.init())
+
+	resp := postWebhooks(g, `[{"value": 1, "count": 3}, {"value": 2}]`, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	metrics := acc.GetCUAMetrics()
+	require.Len(t, metrics, 2)
+	for i, count := range []float64{3, 1} {
+		require.Equal(t, "webhooks", metrics[i].Name())
+		v, ok := metrics[i].GetField("count")
+		require.True(t, ok)
+		require.EqualValues(t, count, v)
+	}
+}
+
+func TestInvalidJSON(t *testing.T) {
+	var acc testutil.Accumulator
+	g := &Webhook{Path: "/generic", acc: &acc}
+	require.NoError(t, g.init())
+	require.Equal(t, http.StatusBadRequest, postWebhooks(g, "{", "").Code)
+}
+
+func TestSignature(t *testing.T) {
+	var acc testutil.Accumulator
+	g := &Webhook{Path: "/generic", Secret: "secret", acc: &acc}
+	require.NoError(t, g.init())
+	body := `{"value": 1}`
+
+	require.Equal(t, http.StatusUnauthorized, postWebhooks(g, body, "").Code)
+	require.Equal(t, http.StatusUnauthorized, postWebhooks(g, body, generateSignature("other", []byte(body))).Code)
+	require.Equal(t, http.StatusOK, postWebhooks(g, body, generateSignature("secret", []byte(body))).Code)
+	require.Equal(t, http.StatusOK, postWebhooks(g, body, "sha256="+generateSignature("secret", []byte(body))).Code)
+	require.Len(t, acc.Metrics, 2)
+}
@@ -0,0 +1,54 @@
+package gitlab
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/gorilla/mux"
+)
+
+type Webhook struct {
+	Path  string
+	Token string
+	acc   cua.Accumulator
+}
+
+func (gl *Webhook) Register(router *mux.Router, acc cua.Accumulator) {
+	router.HandleFunc(gl.Path, gl.eventHandler).Methods("POST")
+	log.Printf("I! Started the webhooks_gitlab on %s\n", gl.Path)
+	gl.acc = acc
+}
+
+func (gl *Webhook) eventHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	if gl.Token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(gl.Token)) != 1 {
+		log.Printf("E! Fail to check the gitlab webhook token\n")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var e Event
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if e.ObjectKind == "" {
+		e.ObjectKind = eventKind(r.Header.Get("X-Gitlab-Event"))
+	}
+	log.Printf("D! New %v event received", e.ObjectKind)
+
+	gl.acc.AddFields("gitlab_webhooks", e.Fields(), e.Tags())
+	w.WriteHeader(http.StatusOK)
+}
+
+// eventKind returns the object kind of an X-Gitlab-Event header, e.g.
+// "merge_request" for "Merge Request Hook"
+func eventKind(header string) string {
+	kind := strings.TrimSuffix(strings.TrimSpace(header), " Hook")
+	return strings.ReplaceAll(strings.ToLower(kind), " ", "_")
+}
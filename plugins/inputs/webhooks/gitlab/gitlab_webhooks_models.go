@@ -0,0 +1,125 @@
+package gitlab
+
+import "strconv"
+
+// Event is the part of the GitLab webhook payloads common to the push, tag
+// push, issue, merge request, pipeline, job and deployment events
+type Event struct {
+	ObjectKind string `json:"object_kind"`
+	// push events
+	Ref          string `json:"ref"`
+	UserUsername string `json:"user_username"`
+	TotalCommits int    `json:"total_commits_count"`
+	// job and deployment events
+	ProjectName   string  `json:"project_name"`
+	BuildStatus   string  `json:"build_status"`
+	BuildName     string  `json:"build_name"`
+	BuildDuration float64 `json:"build_duration"`
+	Status        string  `json:"status"`
+	Environment   string  `json:"environment"`
+
+	User             User             `json:"user"`
+	Project          Project          `json:"project"`
+	ObjectAttributes ObjectAttributes `json:"object_attributes"`
+}
+
+type User struct {
+	Username string `json:"username"`
+}
+
+type Project struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+// ObjectAttributes are the attributes of the issue, merge request or
+// pipeline of an event
+type ObjectAttributes struct {
+	ID       int64   `json:"id"`
+	IID      int64   `json:"iid"`
+	Title    string  `json:"title"`
+	Action   string  `json:"action"`
+	State    string  `json:"state"`
+	Status   string  `json:"status"`
+	Ref      string  `json:"ref"`
+	URL      string  `json:"url"`
+	Duration float64 `json:"duration"`
+}
+
+func (e *Event) Tags() map[string]string {
+	tags := map[string]string{
+		"event":      e.ObjectKind,
+		"repository": e.Project.PathWithNamespace,
+	}
+	if tags["repository"] == "" {
+		tags["repository"] = e.ProjectName
+	}
+	if e.ObjectAttributes.Action != "" {
+		tags["action"] = e.ObjectAttributes.Action
+	}
+
+	status := e.ObjectAttributes.Status
+	if status == "" {
+		status = e.ObjectAttributes.State
+	}
+	if status == "" {
+		status = e.BuildStatus
+	}
+	if status == "" {
+		status = e.Status
+	}
+	if status != "" {
+		tags["status"] = status
+	}
+	if e.Environment != "" {
+		tags["environment"] = e.Environment
+	}
+	return tags
+}
+
+func (e *Event) Fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"count": uint64(1),
+	}
+
+	user := e.User.Username
+	if user == "" {
+		user = e.UserUsername
+	}
+	if user != "" {
+		fields["user"] = user
+	}
+
+	ref := e.ObjectAttributes.Ref
+	if ref == "" {
+		ref = e.Ref
+	}
+	if ref != "" {
+		fields["ref"] = ref
+	}
+
+	if e.ObjectAttributes.Title != "" {
+		fields["title"] = e.ObjectAttributes.Title
+	}
+	if e.ObjectAttributes.URL != "" {
+		fields["url"] = e.ObjectAttributes.URL
+	}
+	if id := e.ObjectAttributes.IID; id != 0 {
+		fields["id"] = strconv.FormatInt(id, 10)
+	} else if id := e.ObjectAttributes.ID; id != 0 {
+		fields["id"] = strconv.FormatInt(id, 10)
+	}
+
+	switch e.ObjectKind {
+	case "push", "tag_push":
+		fields["commits"] = e.TotalCommits
+	case "pipeline":
+		fields["duration"] = e.ObjectAttributes.Duration
+	case "build":
+		fields["duration"] = e.BuildDuration
+		if e.BuildName != "" {
+			fields["job"] = e.BuildName
+		}
+	}
+	return fields
+}
@@ -0,0 +1,193 @@
+package gitlab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+)
+
+func postWebhooks(gl *Webhook, event, token, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/gitlab", strings.NewReader(body))
+	req.Header.Set("X-Gitlab-Event", event)
+	if token != "" {
+		req.Header.Set("X-Gitlab-Token", token)
+	}
+	w := httptest.NewRecorder()
+	gl.eventHandler(w, req)
+	return w
+}
+
+func TestPushEvent(t *testing.T) {
+	var acc testutil.Accumulator
+	gl := &Webhook{Path: "/gitlab", acc: &acc}
+	resp := postWebhooks(gl, "Push Hook", "", PushEventJSON())
+	if resp.Code != http.StatusOK {
+		t.Errorf("POST push returned HTTP status code %v.\nExpected %v", resp.Code, http.StatusOK)
+	}
+
+	fields := map[string]interface{}{
+		"count":   uint64(1),
+		"user":    "jsmith",
+		"ref":     "refs/heads/master",
+		"commits": 4,
+	}
+	tags := map[string]string{
+		"event":      "push",
+		"repository": "mike/diaspora",
+	}
+	acc.AssertContainsTaggedFields(t, "gitlab_webhooks", fields, tags)
+}
+
+func TestPipelineEvent(t *testing.T) {
+	var acc testutil.Accumulator
+	gl := &Webhook{Path: "/gitlab", acc: &acc}
+	resp := postWebhooks(gl, "Pipeline Hook", "", PipelineEventJSON())
+	if resp.Code != http.StatusOK {
+		t.Errorf("POST pipeline returned HTTP status code %v.\nExpected %v", resp.Code, http.StatusOK)
+	}
+
+	fields := map[string]interface{}{
+		"count":    uint64(1),
+		"user":     "root",
+		"ref":      "master",
+		"id":       "31",
+		"duration": 63.0,
+	}
+	tags := map[string]string{
+		"event":      "pipeline",
+		"repository": "gitlab-org/gitlab-test",
+		"status":     "success",
+	}
+	acc.AssertContainsTaggedFields(t, "gitlab_webhooks", fields, tags)
+}
+
+func TestMergeRequestEvent(t *testing.T) {
+	var acc testutil.Accumulator
+	gl := &Webhook{Path: "/gitlab", acc: &acc}
+	resp := postWebhooks(gl, "Merge Request Hook", "", MergeRequestEventJSON())
+	if resp.Code != http.StatusOK {
+		t.Errorf("POST merge request returned HTTP status code %v.\nExpected %v", resp.Code, http.StatusOK)
+	}
+
+	fields := map[string]interface{}{
+		"count": uint64(1),
+		"user":  "root",
+		"title": "MS-Viewport",
+		"url":   "http://example.com/diaspora/merge_requests/1",
+		"id":    "1",
+	}
+	tags := map[string]string{
+		"event":      "merge_request",
+		"repository": "gitlabhq/gitlab-test",
+		"action":     "open",
+		"status":     "opened",
+	}
+	acc.AssertContainsTaggedFields(t, "gitlab_webhooks", fields, tags)
+}
+
+func TestToken(t *testing.T) {
+	var acc testutil.Accumulator
+	gl := &Webhook{Path: "/gitlab", Token: "secret", acc: &acc}
+
+	if resp := postWebhooks(gl, "Push Hook", "wrong", PushEventJSON()); resp.Code != http.StatusUnauthorized {
+		t.Errorf("POST with a wrong token returned HTTP status code %v.\nExpected %v", resp.Code, http.StatusUnauthorized)
+	}
+	if resp := postWebhooks(gl, "Push Hook", "secret", PushEventJSON()); resp.Code != http.StatusOK {
+		t.Errorf("POST with the token returned HTTP status code %v.\nExpected %v", resp.Code, http.StatusOK)
+	}
+	if len(acc.Metrics) != 1 {
+		t.Errorf("expected 1 metric, got %d", len(acc.Metrics))
+	}
+}
+
+func TestEventKind(t *testing.T) {
+	for header, kind := range map[string]string{
+		"Push Hook":          "push",
+		"Tag Push Hook":      "tag_push",
+		"Merge Request Hook": "merge_request",
+		"Job Hook":           "job",
+	} {
+		if got := eventKind(header); got != kind {
+			t.Errorf("eventKind(%q) = %q, expected %q", header, got, kind)
+		}
+	}
+}
+
+func PushEventJSON() string {
+	return `
+{
+  "object_kind": "push",
+  "before": "95790bf891e76fee5e1747ab589903a6a1f80f22",
+  "after": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+  "ref": "refs/heads/master",
+  "user_id": 4,
+  "user_name": "John Smith",
+  "user_username": "jsmith",
+  "project_id": 15,
+  "project": {
+    "id": 15,
+    "name": "Diaspora",
+    "web_url": "http://example.com/mike/diaspora",
+    "path_with_namespace": "mike/diaspora"
+  },
+  "commits": [],
+  "total_commits_count": 4
+}`
+}
+
+func PipelineEventJSON() string {
+	return `
+{
+  "object_kind": "pipeline",
+  "object_attributes": {
+    "id": 31,
+    "ref": "master",
+    "tag": false,
+    "status": "success",
+    "stages": ["build", "test", "deploy"],
+    "created_at": "2016-08-12 15:23:28 UTC",
+    "finished_at": "2016-08-12 15:26:29 UTC",
+    "duration": 63
+  },
+  "user": {
+    "name": "Administrator",
+    "username": "root"
+  },
+  "project": {
+    "id": 1,
+    "name": "Gitlab Test",
+    "web_url": "http://192.168.64.1:3005/gitlab-org/gitlab-test",
+    "path_with_namespace": "gitlab-org/gitlab-test"
+  }
+}`
+}
+
+func MergeRequestEventJSON() string {
+	return `
+{
+  "object_kind": "merge_request",
+  "user": {
+    "name": "Administrator",
+    "username": "root"
+  },
+  "project": {
+    "id": 1,
+    "name": "Gitlab Test",
+    "web_url": "http://example.com/gitlabhq/gitlab-test",
+    "path_with_namespace": "gitlabhq/gitlab-test"
+  },
+  "object_attributes": {
+    "id": 99,
+    "iid": 1,
+    "target_branch": "master",
+    "source_branch": "ms-viewport",
+    "title": "MS-Viewport",
+    "state": "opened",
+    "url": "http://example.com/diaspora/merge_requests/1",
+    "action": "open"
+  }
+}`
+}
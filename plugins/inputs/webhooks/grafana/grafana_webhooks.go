@@ -0,0 +1,93 @@
+package grafana
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/gorilla/mux"
+)
+
+type Webhook struct {
+	Path     string
+	Username string
+	Password string
+	acc      cua.Accumulator
+}
+
+func (gf *Webhook) Register(router *mux.Router, acc cua.Accumulator) {
+	router.HandleFunc(gf.Path, gf.eventHandler).Methods("POST")
+	log.Printf("I! Started the webhooks_grafana on %s\n", gf.Path)
+	gf.acc = acc
+}
+
+func (gf *Webhook) eventHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	if gf.Username != "" || gf.Password != "" {
+		username, password, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(username), []byte(gf.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(gf.Password)) != 1 {
+			log.Printf("E! Fail to check the grafana webhook credentials\n")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var n Notification
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// grafana alerting sends the alerts of the notification, legacy
+	// dashboard alerts a single rule state
+	if len(n.Alerts) == 0 {
+		fields := map[string]interface{}{
+			"count":   uint64(1),
+			"title":   n.Title,
+			"message": n.Message,
+		}
+		tags := map[string]string{
+			"alertname": n.RuleName,
+			"status":    n.State,
+		}
+		gf.acc.AddFields("grafana_webhooks", fields, tags)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	for _, a := range n.Alerts {
+		fields := map[string]interface{}{
+			"count":       uint64(1),
+			"fingerprint": a.Fingerprint,
+		}
+		for _, annotation := range []string{"summary", "description"} {
+			if v, ok := a.Annotations[annotation]; ok {
+				fields[annotation] = v
+			}
+		}
+		tags := map[string]string{
+			"alertname": a.Labels["alertname"],
+			"status":    a.Status,
+			"receiver":  n.Receiver,
+		}
+		if severity, ok := a.Labels["severity"]; ok {
+			tags["severity"] = severity
+		}
+
+		t := a.StartsAt
+		if a.Status == "resolved" && !a.EndsAt.IsZero() {
+			t = a.EndsAt
+		}
+		if t.IsZero() {
+			t = time.Now()
+		}
+		gf.acc.AddFields("grafana_webhooks", fields, tags, t)
+	}
+	w.WriteHeader(http.StatusOK)
+}
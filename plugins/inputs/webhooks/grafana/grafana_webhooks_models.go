@@ -0,0 +1,24 @@
+package grafana
+
+import "time"
+
+// Notification is the payload of a grafana webhook contact point, Alerts is
+// set by grafana alerting and RuleName and State by legacy dashboard alerts
+type Notification struct {
+	Receiver string  `json:"receiver"`
+	Status   string  `json:"status"`
+	Alerts   []Alert `json:"alerts"`
+	Title    string  `json:"title"`
+	Message  string  `json:"message"`
+	RuleName string  `json:"ruleName"`
+	State    string  `json:"state"`
+}
+
+type Alert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+	Fingerprint string            `json:"fingerprint"`
+}
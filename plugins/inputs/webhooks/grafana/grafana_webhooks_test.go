@@ -0,0 +1,148 @@
+package grafana
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func postWebhooks(gf *Webhook, body string, auth bool) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/grafana", strings.NewReader(body))
+	if auth {
+		req.SetBasicAuth("grafana", "secret")
+	}
+	w := httptest.NewRecorder()
+	gf.eventHandler(w, req)
+	return w
+}
+
+func TestAlertingNotification(t *testing.T) {
+	var acc testutil.Accumulator
+	gf := &Webhook{Path: "/grafana", acc: &acc}
+	resp := postWebhooks(gf, AlertingNotificationJSON(), false)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	expected := []struct {
+		tags   map[string]string
+		fields map[string]interface{}
+		time   time.Time
+	}{
+		{
+			tags: map[string]string{
+				"alertname": "HighCPU",
+				"status":    "firing",
+				"receiver":  "ops",
+				"severity":  "critical",
+			},
+			fields: map[string]interface{}{
+				"count":       uint64(1),
+				"fingerprint": "a1b2c3",
+				"summary":     "cpu above 90%",
+			},
+			time: time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			tags: map[string]string{
+				"alertname": "DiskFull",
+				"status":    "resolved",
+				"receiver":  "ops",
+			},
+			fields: map[string]interface{}{
+				"count":       uint64(1),
+				"fingerprint": "d4e5f6",
+			},
+			time: time.Date(2022, 3, 1, 11, 30, 0, 0, time.UTC),
+		},
+	}
+	require.Len(t, acc.Metrics, len(expected))
+	for i, e := range expected {
+		m := acc.Metrics[i]
+		require.Equal(t, "grafana_webhooks", m.Measurement)
+		require.Equal(t, e.tags, m.Tags)
+		require.Equal(t, e.fields, m.Fields)
+		require.True(t, e.time.Equal(m.Time))
+	}
+}
+
+func TestLegacyNotification(t *testing.T) {
+	var acc testutil.Accumulator
+	gf := &Webhook{Path: "/grafana", acc: &acc}
+	resp := postWebhooks(gf, LegacyNotificationJSON(), false)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	fields := map[string]interface{}{
+		"count":   uint64(1),
+		"title":   "[Alerting] Panel Title alert",
+		"message": "Notification Message",
+	}
+	tags := map[string]string{
+		"alertname": "Panel Title alert",
+		"status":    "alerting",
+	}
+	acc.AssertContainsTaggedFields(t, "grafana_webhooks", fields, tags)
+}
+
+func TestBasicAuth(t *testing.T) {
+	var acc testutil.Accumulator
+	gf := &Webhook{Path: "/grafana", Username: "grafana", Password: "secret", acc: &acc}
+
+	require.Equal(t, http.StatusUnauthorized, postWebhooks(gf, LegacyNotificationJSON(), false).Code)
+	require.Equal(t, http.StatusOK, postWebhooks(gf, LegacyNotificationJSON(), true).Code)
+	require.Len(t, acc.Metrics, 1)
+}
+
+func TestInvalidJSON(t *testing.T) {
+	var acc testutil.Accumulator
+	gf := &Webhook{Path: "/grafana", acc: &acc}
+	require.Equal(t, http.StatusBadRequest, postWebhooks(gf, "{", false).Code)
+}
+
+func AlertingNotificationJSON() string {
+	return `
+{
+  "receiver": "ops",
+  "status": "firing",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighCPU", "severity": "critical"},
+      "annotations": {"summary": "cpu above 90%"},
+      "startsAt": "2022-03-01T10:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "fingerprint": "a1b2c3"
+    },
+    {
+      "status": "resolved",
+      "labels": {"alertname": "DiskFull"},
+      "annotations": {},
+      "startsAt": "2022-03-01T09:00:00Z",
+      "endsAt": "2022-03-01T11:30:00Z",
+      "fingerprint": "d4e5f6"
+    }
+  ],
+  "title": "[FIRING:1] HighCPU",
+  "message": "cpu above 90%"
+}`
+}
+
+func LegacyNotificationJSON() string {
+	return `
+{
+  "dashboardId": 1,
+  "evalMatches": [{"value": 1, "metric": "Count", "tags": {}}],
+  "message": "Notification Message",
+  "orgId": 1,
+  "panelId": 2,
+  "ruleId": 1,
+  "ruleName": "Panel Title alert",
+  "ruleUrl": "http://localhost:3000/d/hZ7BuVbWz/test-dashboard",
+  "state": "alerting",
+  "tags": {},
+  "title": "[Alerting] Panel Title alert"
+}`
+}
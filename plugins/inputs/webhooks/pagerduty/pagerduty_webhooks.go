@@ -0,0 +1,80 @@
+package pagerduty
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/gorilla/mux"
+)
+
+type Webhook struct {
+	Path   string
+	Secret string
+	acc    cua.Accumulator
+}
+
+func (pd *Webhook) Register(router *mux.Router, acc cua.Accumulator) {
+	router.HandleFunc(pd.Path, pd.eventHandler).Methods("POST")
+	log.Printf("I! Started the webhooks_pagerduty on %s\n", pd.Path)
+	pd.acc = acc
+}
+
+func (pd *Webhook) eventHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if pd.Secret != "" && !checkSignature(pd.Secret, data, r.Header.Get("X-PagerDuty-Signature")) {
+		log.Printf("E! Fail to check the pagerduty webhook signature\n")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var p Payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	log.Printf("D! New %v event received", p.Event.EventType)
+
+	if p.Event.EventType == "pagey.ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	t := p.Event.OccurredAt
+	if t.IsZero() {
+		t = time.Now()
+	}
+	pd.acc.AddFields("pagerduty_webhooks", p.Event.Fields(), p.Event.Tags(), t)
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkSignature checks the v1 signatures of the X-PagerDuty-Signature
+// header, one per secret while a secret is rotated
+func checkSignature(secret string, data []byte, header string) bool {
+	expected := generateSignature(secret, data)
+	for _, signature := range strings.Split(header, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(expected)) {
+			return true
+		}
+	}
+	return false
+}
+
+func generateSignature(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(data)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,82 @@
+package pagerduty
+
+import "time"
+
+// Payload is a PagerDuty V3 webhook payload
+type Payload struct {
+	Event Event `json:"event"`
+}
+
+type Event struct {
+	ID           string    `json:"id"`
+	EventType    string    `json:"event_type"`
+	ResourceType string    `json:"resource_type"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	Agent        Reference `json:"agent"`
+	Data         Data      `json:"data"`
+}
+
+// Data is the incident, or the incident of the note or the responder
+// request, of an event
+type Data struct {
+	ID       string     `json:"id"`
+	Type     string     `json:"type"`
+	Title    string     `json:"title"`
+	Status   string     `json:"status"`
+	Urgency  string     `json:"urgency"`
+	HTMLURL  string     `json:"html_url"`
+	Number   int        `json:"number"`
+	Service  Reference  `json:"service"`
+	Priority *Reference `json:"priority"`
+	Incident *Reference `json:"incident"`
+}
+
+type Reference struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary"`
+	HTMLURL string `json:"html_url"`
+}
+
+func (e *Event) Tags() map[string]string {
+	tags := map[string]string{
+		"event":         e.EventType,
+		"resource_type": e.ResourceType,
+	}
+	if e.Data.Service.Summary != "" {
+		tags["service"] = e.Data.Service.Summary
+	}
+	if e.Data.Status != "" {
+		tags["status"] = e.Data.Status
+	}
+	if e.Data.Urgency != "" {
+		tags["urgency"] = e.Data.Urgency
+	}
+	if e.Data.Priority != nil && e.Data.Priority.Summary != "" {
+		tags["priority"] = e.Data.Priority.Summary
+	}
+	return tags
+}
+
+func (e *Event) Fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"count": uint64(1),
+	}
+
+	incident := e.Data.ID
+	if e.Data.Incident != nil {
+		incident = e.Data.Incident.ID
+	}
+	if incident != "" {
+		fields["incident_id"] = incident
+	}
+	if e.Data.Title != "" {
+		fields["title"] = e.Data.Title
+	}
+	if e.Data.HTMLURL != "" {
+		fields["url"] = e.Data.HTMLURL
+	}
+	if e.Agent.Summary != "" {
+		fields["agent"] = e.Agent.Summary
+	}
+	return fields
+}
@@ -0,0 +1,113 @@
+package pagerduty
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func postWebhooks(pd *Webhook, body, signature string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/pagerduty", strings.NewReader(body))
+	if signature != "" {
+		req.Header.Set("X-PagerDuty-Signature", signature)
+	}
+	w := httptest.NewRecorder()
+	pd.eventHandler(w, req)
+	return w
+}
+
+func TestIncidentTriggered(t *testing.T) {
+	var acc testutil.Accumulator
+	pd := &Webhook{Path: "/pagerduty", acc: &acc}
+	resp := postWebhooks(pd, IncidentTriggeredJSON(), "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	fields := map[string]interface{}{
+		"count":       uint64(1),
+		"incident_id": "PGR0VU2",
+		"title":       "A little bump in the road",
+		"url":         "https://acme.pagerduty.com/incidents/PGR0VU2",
+		"agent":       "Tenex Engineer",
+	}
+	tags := map[string]string{
+		"event":         "incident.triggered",
+		"resource_type": "incident",
+		"service":       "API Service",
+		"status":        "triggered",
+		"urgency":       "high",
+		"priority":      "P1",
+	}
+	acc.AssertContainsTaggedFields(t, "pagerduty_webhooks", fields, tags)
+
+	m, ok := acc.Get("pagerduty_webhooks")
+	require.True(t, ok)
+	require.True(t, time.Date(2020, 10, 2, 18, 45, 22, 169000000, time.UTC).Equal(m.Time))
+}
+
+func TestPing(t *testing.T) {
+	var acc testutil.Accumulator
+	pd := &Webhook{Path: "/pagerduty", acc: &acc}
+	resp := postWebhooks(pd, `{"event": {"event_type": "pagey.ping"}}`, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, acc.Metrics, 0)
+}
+
+func TestSignature(t *testing.T) {
+	var acc testutil.Accumulator
+	pd := &Webhook{Path: "/pagerduty", Secret: "secret", acc: &acc}
+	body := IncidentTriggeredJSON()
+
+	require.Equal(t, http.StatusUnauthorized, postWebhooks(pd, body, "").Code)
+	require.Equal(t, http.StatusUnauthorized, postWebhooks(pd, body, generateSignature("other", []byte(body))).Code)
+
+	// the header has a signature per secret while a secret is rotated
+	signatures := generateSignature("other", []byte(body)) + "," + generateSignature("secret", []byte(body))
+	require.Equal(t, http.StatusOK, postWebhooks(pd, body, signatures).Code)
+	require.Len(t, acc.Metrics, 1)
+}
+
+func IncidentTriggeredJSON() string {
+	return `
+{
+  "event": {
+    "id": "5ac64822-4adc-4fda-ade0-410becf0de4f",
+    "event_type": "incident.triggered",
+    "resource_type": "incident",
+    "occurred_at": "2020-10-02T18:45:22.169Z",
+    "agent": {
+      "html_url": "https://acme.pagerduty.com/users/PLH1HKV",
+      "id": "PLH1HKV",
+      "self": "https://api.pagerduty.com/users/PLH1HKV",
+      "summary": "Tenex Engineer",
+      "type": "user_reference"
+    },
+    "data": {
+      "id": "PGR0VU2",
+      "type": "incident",
+      "self": "https://api.pagerduty.com/incidents/PGR0VU2",
+      "html_url": "https://acme.pagerduty.com/incidents/PGR0VU2",
+      "number": 2,
+      "status": "triggered",
+      "title": "A little bump in the road",
+      "service": {
+        "html_url": "https://acme.pagerduty.com/services/PF9KMXH",
+        "id": "PF9KMXH",
+        "summary": "API Service",
+        "type": "service_reference"
+      },
+      "urgency": "high",
+      "priority": {
+        "html_url": "https://acme.pagerduty.com/account/incident_priorities",
+        "id": "PSO75BM",
+        "summary": "P1",
+        "type": "priority_reference"
+      }
+    }
+  }
+}`
+}
@@ -12,8 +12,12 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/filestack"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/generic"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/github"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/gitlab"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/grafana"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/mandrill"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/pagerduty"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/papertrail"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/particle"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks/rollbar"
@@ -37,6 +41,10 @@ type Webhooks struct {
 	Rollbar    *rollbar.Webhook
 	Papertrail *papertrail.Webhook
 	Particle   *particle.Webhook
+	Gitlab     *gitlab.Webhook
+	Grafana    *grafana.Webhook
+	PagerDuty  *pagerduty.Webhook `toml:"pagerduty"`
+	Generic    *generic.Webhook
 
 	srv *http.Server
 }
@@ -68,6 +76,36 @@ func (*Webhooks) SampleConfig() string {
 
   [inputs.webhooks.particle]
     path = "/particle"
+
+  [inputs.webhooks.gitlab]
+    path = "/gitlab"
+    ## Secret token of the webhook, checked against X-Gitlab-Token
+    # token = ""
+
+  [inputs.webhooks.grafana]
+    path = "/grafana"
+    ## HTTP basic auth credentials of the webhook contact point
+    # username = ""
+    # password = ""
+
+  [inputs.webhooks.pagerduty]
+    path = "/pagerduty"
+    ## Signing secret of the V3 webhook subscription
+    # secret = ""
+
+  ## Events of any service as JSON, parsed like the json data format
+  [inputs.webhooks.generic]
+    path = "/generic"
+    # metric_name = "webhooks"
+    # tag_keys = []
+    # json_string_fields = []
+    # json_query = ""
+    # json_time_key = ""
+    # json_time_format = ""
+    ## Secret of the HMAC-SHA256 signature of the body, hex encoded in the
+    ## signature_header header with an optional "sha256=" prefix
+    # secret = ""
+    # signature_header = "X-Signature"
 `
 }
 
@@ -14,6 +14,8 @@ import (
 	"fmt"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -99,6 +101,15 @@ var sampleConfig = `
 
   ## Skip those tags or fields if their value is empty or equals to zero. Globbing supported
   exclude_empty = ["*ActivityID", "UserID"]
+
+  ## Unrolled UserData/EventData fields to add as tags instead of fields. Globbing supported
+  ## Example: ["Data_TargetUserName", "Data_LogonType"]
+  # event_data_tags = []
+
+  ## Instead of a metric for every event, report a win_eventlog_count counter
+  ## of the matching events per Channel, Source, Level and EventID, along with
+  ## any event_data_tags
+  # event_counts = false
 `
 
 // WinEventLog config
@@ -115,11 +126,20 @@ type WinEventLog struct {
 	EventFields            []string `toml:"event_fields"`
 	ExcludeFields          []string `toml:"exclude_fields"`
 	ExcludeEmpty           []string `toml:"exclude_empty"`
+	EventDataTags          []string `toml:"event_data_tags"`
+	EventCounts            bool     `toml:"event_counts"`
 	subscription           EvtHandle
 	buf                    []byte
+	counts                 map[string]*eventCount
 	Log                    cua.Logger
 }
 
+// eventCount is the number of events seen with a set of tags
+type eventCount struct {
+	tags  map[string]string
+	count uint64
+}
+
 var bufferSize = 1 << 14
 
 var description = "Input plugin to collect Windows Event Log messages"
@@ -269,20 +289,92 @@ loop:
 				fieldsUsage = xmlFieldsUsage
 			}
 			uniqueXMLFields := UniqueFieldNames(xmlFields, fieldsUsage, w.Separator)
+			dataTags := map[string]string{}
 			for _, xmlField := range uniqueXMLFields {
+				if w.isDataTag(xmlField.Name) {
+					dataTags[xmlField.Name] = xmlField.Value
+					continue
+				}
 				if !w.shouldExclude(xmlField.Name) {
 					fields[xmlField.Name] = xmlField.Value
 				}
 			}
 
+			if w.EventCounts {
+				w.countEvent(event, dataTags)
+				continue
+			}
+
+			for k, v := range dataTags {
+				tags[k] = v
+			}
+
 			// Pass collected metrics
 			acc.AddFields("win_eventlog", fields, tags, timeStamp)
 		}
 	}
 
+	if w.EventCounts {
+		w.addCounts(acc)
+	}
+
 	return nil
 }
 
+func (w *WinEventLog) isDataTag(field string) bool {
+	for _, pattern := range w.EventDataTags {
+		if matched, _ := filepath.Match(pattern, field); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// countEvent increments the count of events matching the event's Channel,
+// Source, Level and EventID and the data tags
+func (w *WinEventLog) countEvent(event Event, dataTags map[string]string) {
+	tags := map[string]string{
+		"Channel": event.Channel,
+		"Source":  event.Source.Name,
+		"Level":   strconv.Itoa(event.Level),
+		"EventID": strconv.Itoa(event.EventID),
+	}
+	for k, v := range dataTags {
+		tags[k] = v
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var key strings.Builder
+	for _, k := range keys {
+		key.WriteString(k)
+		key.WriteByte('=')
+		key.WriteString(tags[k])
+		key.WriteByte(0)
+	}
+
+	if w.counts == nil {
+		w.counts = make(map[string]*eventCount)
+	}
+	c, ok := w.counts[key.String()]
+	if !ok {
+		c = &eventCount{tags: tags}
+		w.counts[key.String()] = c
+	}
+	c.count++
+}
+
+// addCounts reports the running count of every combination of tags seen
+// since the agent started
+func (w *WinEventLog) addCounts(acc cua.Accumulator) {
+	for _, c := range w.counts {
+		acc.AddCounter("win_eventlog_count", map[string]interface{}{"count": c.count}, c.tags)
+	}
+}
+
 func (w *WinEventLog) shouldExclude(field string) (should bool) {
 	for _, excludePattern := range w.ExcludeFields {
 		// Check if field name matches excluded list
@@ -7,6 +7,9 @@ package wineventlog
 
 import (
 	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWinEventLog_shouldExcludeEmptyField(t *testing.T) {
@@ -137,3 +140,65 @@ func TestWinEventLog_shouldProcessField(t *testing.T) {
 		})
 	}
 }
+
+func TestWinEventLog_eventCounts(t *testing.T) {
+	w := &WinEventLog{EventDataTags: []string{"Data_Target*"}}
+
+	logon := Event{Channel: "Security", Source: Provider{Name: "Microsoft-Windows-Security-Auditing"}, EventID: 4625}
+	w.countEvent(logon, map[string]string{"Data_TargetUserName": "alice"})
+	w.countEvent(logon, map[string]string{"Data_TargetUserName": "alice"})
+	w.countEvent(logon, map[string]string{"Data_TargetUserName": "bob"})
+	w.countEvent(Event{Channel: "System", Source: Provider{Name: "disk"}, EventID: 7, Level: 2}, nil)
+
+	var acc testutil.Accumulator
+	w.addCounts(&acc)
+
+	acc.AssertContainsTaggedFields(t, "win_eventlog_count",
+		map[string]interface{}{"count": uint64(2)},
+		map[string]string{
+			"Channel":             "Security",
+			"Source":              "Microsoft-Windows-Security-Auditing",
+			"Level":               "0",
+			"EventID":             "4625",
+			"Data_TargetUserName": "alice",
+		})
+	acc.AssertContainsTaggedFields(t, "win_eventlog_count",
+		map[string]interface{}{"count": uint64(1)},
+		map[string]string{
+			"Channel":             "Security",
+			"Source":              "Microsoft-Windows-Security-Auditing",
+			"Level":               "0",
+			"EventID":             "4625",
+			"Data_TargetUserName": "bob",
+		})
+	acc.AssertContainsTaggedFields(t, "win_eventlog_count",
+		map[string]interface{}{"count": uint64(1)},
+		map[string]string{
+			"Channel": "System",
+			"Source":  "disk",
+			"Level":   "2",
+			"EventID": "7",
+		})
+
+	// counts are cumulative across gathers
+	w.countEvent(logon, map[string]string{"Data_TargetUserName": "bob"})
+	acc.ClearMetrics()
+	w.addCounts(&acc)
+	require.Len(t, acc.Metrics, 3)
+	acc.AssertContainsTaggedFields(t, "win_eventlog_count",
+		map[string]interface{}{"count": uint64(2)},
+		map[string]string{
+			"Channel":             "Security",
+			"Source":              "Microsoft-Windows-Security-Auditing",
+			"Level":               "0",
+			"EventID":             "4625",
+			"Data_TargetUserName": "bob",
+		})
+}
+
+func TestWinEventLog_isDataTag(t *testing.T) {
+	w := &WinEventLog{EventDataTags: []string{"Data_Target*", "Data_LogonType"}}
+	require.True(t, w.isDataTag("Data_TargetUserName"))
+	require.True(t, w.isDataTag("Data_LogonType"))
+	require.False(t, w.isDataTag("Data_IpAddress"))
+}
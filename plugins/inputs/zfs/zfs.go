@@ -1,22 +1,29 @@
 package zfs
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 )
 
 type Sysctl func(metric string) ([]string, error)
 type Zpool func() ([]string, error)
+type ZpoolStatus func() ([]string, error)
 type Zdataset func(properties []string) ([]string, error)
 
 type Zfs struct {
-	Log            cua.Logger `toml:"-"`
-	zdataset       Zdataset   //nolint:structcheck,unused
-	sysctl         Sysctl     //nolint:structcheck,unused
-	zpool          Zpool      //nolint:structcheck,unused
+	Log            cua.Logger  `toml:"-"`
+	zdataset       Zdataset    //nolint:structcheck,unused
+	sysctl         Sysctl      //nolint:structcheck,unused
+	zpool          Zpool       //nolint:structcheck,unused
+	zpoolStatus    ZpoolStatus //nolint:structcheck,unused
 	KstatPath      string
 	KstatMetrics   []string
 	PoolMetrics    bool
 	DatasetMetrics bool
+	VdevMetrics    bool
 }
 
 var sampleConfig = `
@@ -45,6 +52,10 @@ var sampleConfig = `
   #   "dmu_tx", "fm", "vdev_mirror_stats", "zfetchstats", "zil"]
   ## By default, don't gather dataset metrics
   # datasetMetrics = false
+
+  ## By default, don't gather per-vdev error counters, enabling this runs
+  ## "zpool status -p" on every collection
+  # vdevMetrics = false
 `
 
 func (z *Zfs) SampleConfig() string {
@@ -54,3 +65,142 @@ func (z *Zfs) SampleConfig() string {
 func (z *Zfs) Description() string {
 	return "Read metrics of ZFS from arcstats, zfetchstats, vdev_cache_stats, pools, and datasets"
 }
+
+// healthStates maps the zpool health status to the value reported in the
+// health_state field, anything unrecognized is reported as -1.
+var healthStates = map[string]int64{
+	"ONLINE":    0,
+	"DEGRADED":  1,
+	"FAULTED":   2,
+	"OFFLINE":   3,
+	"UNAVAIL":   4,
+	"REMOVED":   5,
+	"SUSPENDED": 6,
+}
+
+func healthState(health string) int64 {
+	if v, ok := healthStates[strings.ToUpper(health)]; ok {
+		return v
+	}
+	return -1
+}
+
+// addHitRatio adds arcstats_hit_ratio, the percentage of ARC lookups served
+// from the cache since the module was loaded, when hits and misses are present.
+func addHitRatio(fields map[string]interface{}) {
+	hits, ok := fields["arcstats_hits"].(int64)
+	if !ok {
+		return
+	}
+	misses, ok := fields["arcstats_misses"].(int64)
+	if !ok {
+		return
+	}
+	if hits+misses == 0 {
+		return
+	}
+	fields["arcstats_hit_ratio"] = float64(hits) / float64(hits+misses) * 100
+}
+
+// parsePoolList parses a line of output from
+// zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio
+func parsePoolList(line string) (string, string, map[string]interface{}, error) {
+	col := strings.Split(line, "\t")
+	if len(col) != 8 {
+		return "", "", nil, fmt.Errorf("invalid number of columns for line: %s", line)
+	}
+
+	name, health := col[0], col[1]
+	fields := map[string]interface{}{"health_state": healthState(health)}
+
+	if health == "UNAVAIL" {
+		fields["size"] = int64(0)
+		return name, health, fields, nil
+	}
+
+	size, err := strconv.ParseInt(col[2], 10, 64)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("Error parsing size: %w", err)
+	}
+	fields["size"] = size
+
+	alloc, err := strconv.ParseInt(col[3], 10, 64)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("Error parsing allocation: %w", err)
+	}
+	fields["allocated"] = alloc
+
+	free, err := strconv.ParseInt(col[4], 10, 64)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("Error parsing free: %w", err)
+	}
+	fields["free"] = free
+
+	frag, err := strconv.ParseInt(strings.TrimSuffix(col[5], "%"), 10, 0)
+	if err != nil { // This might be - for RO devs
+		frag = 0
+	}
+	fields["fragmentation"] = frag
+
+	capval, err := strconv.ParseInt(col[6], 10, 0)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("Error parsing capacity: %w", err)
+	}
+	fields["capacity"] = capval
+
+	dedup, err := strconv.ParseFloat(strings.TrimSuffix(col[7], "x"), 32)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("Error parsing dedupratio: %w", err)
+	}
+	fields["dedupratio"] = dedup
+
+	return name, health, fields, nil
+}
+
+// gatherVdevStats parses the config section of "zpool status -p" and adds a
+// zfs_vdev metric with the error counters of every vdev in each pool.
+func gatherVdevStats(lines []string, acc cua.Accumulator) {
+	var pool string
+	inConfig := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "pool:"):
+			pool = strings.TrimSpace(strings.TrimPrefix(trimmed, "pool:"))
+			inConfig = false
+			continue
+		case strings.HasPrefix(trimmed, "config:"):
+			inConfig = true
+			continue
+		case strings.HasPrefix(trimmed, "errors:"):
+			inConfig = false
+			continue
+		}
+		if !inConfig || pool == "" {
+			continue
+		}
+
+		col := strings.Fields(trimmed)
+		if len(col) < 5 || col[0] == "NAME" {
+			// section headers (logs, cache, spares) and spares have no counters
+			continue
+		}
+
+		fields := map[string]interface{}{"state": healthState(col[1])}
+		valid := true
+		for i, key := range []string{"read_errors", "write_errors", "checksum_errors"} {
+			value, err := strconv.ParseInt(col[i+2], 10, 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			fields[key] = value
+		}
+		if !valid {
+			continue
+		}
+
+		tags := map[string]string{"pool": pool, "vdev": col[0]}
+		acc.AddFields("zfs_vdev", fields, tags)
+	}
+}
@@ -4,11 +4,8 @@
 package zfs
 
 import (
-	"bytes"
 	"context"
-	"errors"
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
 
@@ -32,57 +29,16 @@ func (z *Zfs) gatherPoolStats(acc cua.Accumulator) (string, error) {
 
 	if z.PoolMetrics {
 		for _, line := range lines {
-			col := strings.Split(line, "\t")
-			if len(col) != 8 {
+			if len(strings.Split(line, "\t")) != 8 {
 				continue
 			}
 
-			tags := map[string]string{"pool": col[0], "health": col[1]}
-			fields := map[string]interface{}{}
-
-			if tags["health"] == "UNAVAIL" {
-
-				fields["size"] = int64(0)
-
-			} else {
-
-				size, err := strconv.ParseInt(col[2], 10, 64)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing size: %w", err)
-				}
-				fields["size"] = size
-
-				alloc, err := strconv.ParseInt(col[3], 10, 64)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing allocation: %w", err)
-				}
-				fields["allocated"] = alloc
-
-				free, err := strconv.ParseInt(col[4], 10, 64)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing free: %w", err)
-				}
-				fields["free"] = free
-
-				frag, err := strconv.ParseInt(strings.TrimSuffix(col[5], "%"), 10, 0)
-				if err != nil { // This might be - for RO devs
-					frag = 0
-				}
-				fields["fragmentation"] = frag
-
-				capval, err := strconv.ParseInt(col[6], 10, 0)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing capacity: %w", err)
-				}
-				fields["capacity"] = capval
-
-				dedup, err := strconv.ParseFloat(strings.TrimSuffix(col[7], "x"), 32)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing dedupratio: %w", err)
-				}
-				fields["dedupratio"] = dedup
+			name, health, fields, err := parsePoolList(line)
+			if err != nil {
+				return "", err
 			}
 
+			tags := map[string]string{"pool": name, "health": health}
 			acc.AddFields("zfs_pool", fields, tags)
 		}
 	}
@@ -144,6 +100,14 @@ func (z *Zfs) Gather(ctx context.Context, acc cua.Accumulator) error {
 	}
 	tags["pools"] = poolNames
 
+	if z.VdevMetrics {
+		lines, err := z.zpoolStatus()
+		if err != nil {
+			return err
+		}
+		gatherVdevStats(lines, acc)
+	}
+
 	datasetNames, err := z.gatherDatasetStats(acc)
 	if err != nil {
 		return err
@@ -163,34 +127,11 @@ func (z *Zfs) Gather(ctx context.Context, acc cua.Accumulator) error {
 			fields[key] = value
 		}
 	}
+	addHitRatio(fields)
 	acc.AddFields("zfs", fields, tags)
 	return nil
 }
 
-func run(command string, args ...string) ([]string, error) {
-	cmd := exec.Command(command, args...)
-	var outbuf, errbuf bytes.Buffer
-	cmd.Stdout = &outbuf
-	cmd.Stderr = &errbuf
-	err := cmd.Run()
-
-	stdout := strings.TrimSpace(outbuf.String())
-	stderr := strings.TrimSpace(errbuf.String())
-
-	var exitErr *exec.ExitError
-	if errors.As(err, &exitErr) {
-		return nil, fmt.Errorf("%s error: %s", command, stderr)
-	}
-	// if _, ok := err.(*exec.ExitError); ok {
-	// 	return nil, fmt.Errorf("%s error: %s", command, stderr)
-	// }
-	return strings.Split(stdout, "\n"), nil
-}
-
-func zpool() ([]string, error) {
-	return run("zpool", []string{"list", "-Hp", "-o", "name,health,size,alloc,free,fragmentation,capacity,dedupratio"}...)
-}
-
 func zdataset(properties []string) ([]string, error) {
 	return run("zfs", []string{"list", "-Hp", "-o", strings.Join(properties, ",")}...)
 }
@@ -202,9 +143,10 @@ func sysctl(metric string) ([]string, error) {
 func init() {
 	inputs.Add("zfs", func() cua.Input {
 		return &Zfs{
-			sysctl:   sysctl,
-			zpool:    zpool,
-			zdataset: zdataset,
+			sysctl:      sysctl,
+			zpool:       zpool,
+			zpoolStatus: zpoolStatus,
+			zdataset:    zdataset,
 		}
 	})
 }
@@ -216,12 +216,14 @@ func getFreeNasBootPoolMetrics() map[string]interface{} {
 		"free":          int64(28579464704),
 		"size":          int64(30601641984),
 		"fragmentation": int64(0),
+		"health_state":  int64(0),
 	}
 }
 
 func getTemp2PoolMetrics() map[string]interface{} {
 	return map[string]interface{}{
-		"size": int64(0),
+		"size":         int64(0),
+		"health_state": int64(4),
 	}
 }
 
@@ -6,6 +6,7 @@ package zfs
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -16,8 +17,9 @@ import (
 )
 
 type poolInfo struct {
-	name       string
-	ioFilename string
+	name          string
+	ioFilename    string
+	stateFilename string
 }
 
 func getPools(kstatPath string) []poolInfo {
@@ -27,7 +29,11 @@ func getPools(kstatPath string) []poolInfo {
 	for _, poolDir := range poolsDirs {
 		poolDirSplit := strings.Split(poolDir, "/")
 		pool := poolDirSplit[len(poolDirSplit)-2]
-		pools = append(pools, poolInfo{name: pool, ioFilename: poolDir})
+		pools = append(pools, poolInfo{
+			name:          pool,
+			ioFilename:    poolDir,
+			stateFilename: filepath.Join(filepath.Dir(poolDir), "state"),
+		})
 	}
 
 	return pools
@@ -46,7 +52,36 @@ func getTags(pools []poolInfo) map[string]string {
 	return map[string]string{"pools": poolNames}
 }
 
-func gatherPoolStats(pool poolInfo, acc cua.Accumulator) error {
+// poolList returns the capacity fields from "zpool list" keyed by pool name,
+// the kstat pool stats are still reported if zpool is unavailable.
+func (z *Zfs) poolList() map[string]map[string]interface{} {
+	listed := make(map[string]map[string]interface{})
+	if z.zpool == nil {
+		return listed
+	}
+
+	lines, err := z.zpool()
+	if err != nil {
+		z.Log.Debugf("unable to list pools: %v", err)
+		return listed
+	}
+
+	for _, line := range lines {
+		if len(strings.Split(line, "\t")) != 8 {
+			continue
+		}
+		name, _, fields, err := parsePoolList(line)
+		if err != nil {
+			z.Log.Warn(err)
+			continue
+		}
+		listed[name] = fields
+	}
+
+	return listed
+}
+
+func gatherPoolStats(pool poolInfo, listed map[string]interface{}, acc cua.Accumulator) error {
 	lines, err := internal.ReadLines(pool.ioFilename)
 	if err != nil {
 		return fmt.Errorf("zfs pool stats (%s): %w", pool.ioFilename, err)
@@ -74,6 +109,16 @@ func gatherPoolStats(pool poolInfo, acc cua.Accumulator) error {
 		}
 		fields[keys[i]] = value
 	}
+
+	for k, v := range listed {
+		fields[k] = v
+	}
+
+	// the state kstat is available in ZFS on Linux 0.8.0 and later
+	if state, err := os.ReadFile(pool.stateFilename); err == nil {
+		fields["health_state"] = healthState(strings.TrimSpace(string(state)))
+	}
+
 	acc.AddFields("zfs_pool", fields, tag)
 
 	return nil
@@ -98,14 +143,23 @@ func (z *Zfs) Gather(ctx context.Context, acc cua.Accumulator) error {
 	tags := getTags(pools)
 
 	if z.PoolMetrics {
+		listed := z.poolList()
 		for _, pool := range pools {
-			err := gatherPoolStats(pool, acc)
+			err := gatherPoolStats(pool, listed[pool.name], acc)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
+	if z.VdevMetrics && z.zpoolStatus != nil {
+		lines, err := z.zpoolStatus()
+		if err != nil {
+			return err
+		}
+		gatherVdevStats(lines, acc)
+	}
+
 	fields := make(map[string]interface{})
 	for _, metric := range kstatMetrics {
 		lines, err := internal.ReadLines(kstatPath + "/" + metric)
@@ -129,12 +183,16 @@ func (z *Zfs) Gather(ctx context.Context, acc cua.Accumulator) error {
 			fields[key] = value
 		}
 	}
+	addHitRatio(fields)
 	acc.AddFields("zfs", fields, tags)
 	return nil
 }
 
 func init() {
 	inputs.Add("zfs", func() cua.Input {
-		return &Zfs{}
+		return &Zfs{
+			zpool:       zpool,
+			zpoolStatus: zpoolStatus,
+		}
 	})
 }
@@ -285,6 +285,122 @@ func TestZfsPoolMetrics(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// $ zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio
+var zpoolListOutput = []string{
+	"HOME	DEGRADED	30601641984	2022177280	28579464704	12%	6	1.00x",
+}
+
+func mockZpool() ([]string, error) {
+	return zpoolListOutput, nil
+}
+
+// $ zpool status -p
+var zpoolStatusOutput = []string{
+	"  pool: HOME",
+	" state: DEGRADED",
+	"status: One or more devices has been removed by the administrator.",
+	"config:",
+	"",
+	"	NAME        STATE     READ WRITE CKSUM",
+	"	HOME        DEGRADED     0     0     0",
+	"	  mirror-0  DEGRADED     0     0     0",
+	"	    sda     ONLINE       0     0     0",
+	"	    sdb     REMOVED      3     1    17",
+	"	logs",
+	"	  sdc       ONLINE       0     0     0",
+	"	spares",
+	"	  sdd       AVAIL",
+	"",
+	"errors: No known data errors",
+}
+
+func mockZpoolStatus() ([]string, error) {
+	return zpoolStatusOutput, nil
+}
+
+func TestZfsPoolHealthAndCapacity(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/cua")
+
+	err = os.WriteFile(testKstatPath+"/HOME/io", []byte(poolIOContents), 0600)
+	require.NoError(t, err)
+
+	err = os.WriteFile(testKstatPath+"/HOME/state", []byte("DEGRADED\n"), 0600)
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+
+	z := &Zfs{
+		Log:          testutil.Logger{},
+		KstatPath:    testKstatPath,
+		KstatMetrics: []string{"arcstats"},
+		PoolMetrics:  true,
+		zpool:        mockZpool,
+	}
+	err = z.Gather(context.Background(), &acc)
+	require.NoError(t, err)
+
+	poolMetrics := getPoolMetrics()
+	poolMetrics["allocated"] = int64(2022177280)
+	poolMetrics["capacity"] = int64(6)
+	poolMetrics["dedupratio"] = float64(1)
+	poolMetrics["free"] = int64(28579464704)
+	poolMetrics["size"] = int64(30601641984)
+	poolMetrics["fragmentation"] = int64(12)
+	poolMetrics["health_state"] = int64(1)
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", poolMetrics, map[string]string{"pool": "HOME"})
+}
+
+func TestZfsVdevMetrics(t *testing.T) {
+	err := os.MkdirAll(testKstatPath, 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/cua")
+
+	var acc testutil.Accumulator
+
+	z := &Zfs{
+		KstatPath:   testKstatPath,
+		zpoolStatus: mockZpoolStatus,
+	}
+	err = z.Gather(context.Background(), &acc)
+	require.NoError(t, err)
+	require.False(t, acc.HasMeasurement("zfs_vdev"))
+
+	z.VdevMetrics = true
+	err = z.Gather(context.Background(), &acc)
+	require.NoError(t, err)
+
+	vdevs := []string{}
+	for _, m := range acc.Metrics {
+		if m.Measurement == "zfs_vdev" {
+			vdevs = append(vdevs, m.Tags["vdev"])
+		}
+	}
+	// spares have no error counters
+	require.Equal(t, []string{"HOME", "mirror-0", "sda", "sdb", "sdc"}, vdevs)
+
+	acc.AssertContainsTaggedFields(t, "zfs_vdev",
+		map[string]interface{}{
+			"state":           int64(5),
+			"read_errors":     int64(3),
+			"write_errors":    int64(1),
+			"checksum_errors": int64(17),
+		},
+		map[string]string{"pool": "HOME", "vdev": "sdb"},
+	)
+	acc.AssertContainsTaggedFields(t, "zfs_vdev",
+		map[string]interface{}{
+			"state":           int64(1),
+			"read_errors":     int64(0),
+			"write_errors":    int64(0),
+			"checksum_errors": int64(0),
+		},
+		map[string]string{"pool": "HOME", "vdev": "HOME"},
+	)
+}
+
 func TestZfsGeneratesMetrics(t *testing.T) {
 	err := os.MkdirAll(testKstatPath, 0755)
 	require.NoError(t, err)
@@ -365,6 +481,7 @@ func TestZfsGeneratesMetrics(t *testing.T) {
 func getKstatMetricsArcOnly() map[string]interface{} {
 	return map[string]interface{}{
 		"arcstats_hits":                     int64(5968846374),
+		"arcstats_hit_ratio":                float64(5968846374) / float64(5968846374+1659178751) * 100,
 		"arcstats_misses":                   int64(1659178751),
 		"arcstats_demand_data_hits":         int64(4860247322),
 		"arcstats_demand_data_misses":       int64(501499535),
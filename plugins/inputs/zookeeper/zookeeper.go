@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
@@ -19,10 +21,16 @@ import (
 
 var zookeeperFormatRE = regexp.MustCompile(`^zk_(\w+)\s+([\w\.\-]+)`)
 
+const (
+	modeMntr  = "mntr"
+	modeAdmin = "admin"
+)
+
 // Zookeeper is a zookeeper plugin
 type Zookeeper struct {
 	Servers []string
 	Timeout internal.Duration
+	Mode    string `toml:"mode"`
 
 	EnableTLS bool `toml:"enable_tls"`
 	EnableSSL bool `toml:"enable_ssl"` // deprecated in 1.7; use enable_tls
@@ -30,6 +38,7 @@ type Zookeeper struct {
 
 	initialized bool
 	tlsConfig   *tls.Config
+	client      *http.Client
 }
 
 var sampleConfig = `
@@ -40,6 +49,13 @@ var sampleConfig = `
   ## If no port is specified, 2181 is used
   servers = [":2181"]
 
+  ## How the stats are read, one of "mntr" or "admin"
+  ##   mntr  - the 'mntr' four letter word command on the client port, it must
+  ##           be in 4lw.commands.whitelist on zookeeper 3.5.3+
+  ##   admin - the AdminServer 'monitor' command, servers are the AdminServer
+  ##           addresses and the default port is 8080
+  # mode = "mntr"
+
   ## Timeout for metric collections from all servers.  Minimum timeout is "1s".
   # timeout = "5s"
 
@@ -85,6 +101,19 @@ func (z *Zookeeper) Gather(ctx context.Context, acc cua.Accumulator) error {
 			return fmt.Errorf("TLSConfig: %w", err)
 		}
 		z.tlsConfig = tlsConfig
+		z.client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		}
+		switch z.Mode {
+		case "":
+			z.Mode = modeMntr
+		case modeMntr, modeAdmin:
+		default:
+			return fmt.Errorf("unknown mode (%s)", z.Mode)
+		}
 		z.initialized = true
 	}
 
@@ -96,7 +125,7 @@ func (z *Zookeeper) Gather(ctx context.Context, acc cua.Accumulator) error {
 	defer cancel()
 
 	if len(z.Servers) == 0 {
-		z.Servers = []string{":2181"}
+		z.Servers = []string{":" + z.defaultPort()}
 	}
 
 	for _, serverAddress := range z.Servers {
@@ -105,17 +134,77 @@ func (z *Zookeeper) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return nil
 }
 
+func (z *Zookeeper) defaultPort() string {
+	if z.Mode == modeAdmin {
+		return "8080"
+	}
+	return "2181"
+}
+
 func (z *Zookeeper) gatherServer(ctx context.Context, address string, acc cua.Accumulator) error {
-	var zookeeperState string
 	_, _, err := net.SplitHostPort(address)
 	if err != nil {
-		address += ":2181"
+		address += ":" + z.defaultPort()
 	}
 
-	c, err := z.dial(ctx, address)
+	service := strings.Split(address, ":")
+	if len(service) != 2 {
+		return fmt.Errorf("Invalid service address: %s", address)
+	}
+
+	var fields map[string]interface{}
+	var zookeeperState string
+	if z.Mode == modeAdmin {
+		fields, zookeeperState, err = z.gatherAdmin(ctx, address)
+	} else {
+		fields, zookeeperState, err = z.gatherMntr(ctx, address)
+	}
 	if err != nil {
 		return err
 	}
+
+	if zookeeperState != "" {
+		fields["leader"] = 0
+		if zookeeperState == "leader" {
+			fields["leader"] = 1
+			// followers is learners (followers and observers) on 3.6+
+			followers, ok := fields["followers"].(int64)
+			if !ok {
+				followers, ok = fields["learners"].(int64)
+			}
+			synced, syncedOK := fields["synced_followers"].(int64)
+			if ok && syncedOK {
+				if observers, ok := fields["synced_observers"].(int64); ok {
+					synced += observers
+				}
+				fields["unsynced_followers"] = followers - synced
+			}
+		}
+	}
+
+	srv := "localhost"
+	if service[0] != "" {
+		srv = service[0]
+	}
+
+	tags := map[string]string{
+		"server": srv,
+		"port":   service[1],
+		"state":  zookeeperState,
+	}
+	acc.AddFields("zookeeper", fields, tags)
+
+	return nil
+}
+
+// gatherMntr reads the stats with the mntr four letter word command
+func (z *Zookeeper) gatherMntr(ctx context.Context, address string) (map[string]interface{}, string, error) {
+	var zookeeperState string
+
+	c, err := z.dial(ctx, address)
+	if err != nil {
+		return nil, "", err
+	}
 	defer c.Close()
 
 	// Apply deadline to connection
@@ -128,48 +217,98 @@ func (z *Zookeeper) gatherServer(ctx context.Context, address string, acc cua.Ac
 	rdr := bufio.NewReader(c)
 	scanner := bufio.NewScanner(rdr)
 
-	service := strings.Split(address, ":")
-	if len(service) != 2 {
-		return fmt.Errorf("Invalid service address: %s", address)
-	}
-
 	fields := make(map[string]interface{})
 	for scanner.Scan() {
 		line := scanner.Text()
 		parts := zookeeperFormatRE.FindStringSubmatch(line)
 
 		if len(parts) != 3 {
-			return fmt.Errorf("unexpected line in mntr response: %q", line)
+			if strings.Contains(line, "not in the whitelist") {
+				return nil, "", fmt.Errorf("mntr is not in 4lw.commands.whitelist on %s, whitelist it or use mode = \"admin\"", address)
+			}
+			return nil, "", fmt.Errorf("unexpected line in mntr response: %q", line)
 		}
 
 		measurement := strings.TrimPrefix(parts[1], "zk_")
 		if measurement == "server_state" {
 			zookeeperState = parts[2]
 		} else {
-			sValue := parts[2]
+			fields[measurement] = parseValue(parts[2])
+		}
+	}
+
+	return fields, zookeeperState, nil
+}
+
+// gatherAdmin reads the stats from the AdminServer monitor command
+func (z *Zookeeper) gatherAdmin(ctx context.Context, address string) (map[string]interface{}, string, error) {
+	scheme := "http"
+	if z.EnableTLS || z.EnableSSL {
+		scheme = "https"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+address+"/commands/monitor", nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("new request: %w", err)
+	}
+	resp, err := z.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%s returned HTTP status %s", req.URL, resp.Status)
+	}
 
-			iVal, err := strconv.ParseInt(sValue, 10, 64)
-			if err == nil {
-				fields[measurement] = iVal
+	var stats map[string]interface{}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&stats); err != nil {
+		return nil, "", fmt.Errorf("parse monitor response: %w", err)
+	}
+	if msg, ok := stats["error"].(string); ok && msg != "" {
+		return nil, "", fmt.Errorf("monitor command: %s", msg)
+	}
+
+	var zookeeperState string
+	fields := make(map[string]interface{})
+	for k, v := range stats {
+		switch k {
+		case "command", "error":
+			continue
+		case "server_state":
+			zookeeperState, _ = v.(string)
+			continue
+		}
+
+		switch val := v.(type) {
+		case json.Number:
+			fields[k] = parseValue(val.String())
+		case string:
+			fields[k] = val
+		case bool:
+			if val {
+				fields[k] = int64(1)
 			} else {
-				fields[measurement] = sValue
+				fields[k] = int64(0)
 			}
 		}
 	}
 
-	srv := "localhost"
-	if service[0] != "" {
-		srv = service[0]
-	}
+	return fields, zookeeperState, nil
+}
 
-	tags := map[string]string{
-		"server": srv,
-		"port":   service[1],
-		"state":  zookeeperState,
+// parseValue returns the value as an integer or float when it is numeric,
+// zookeeper 3.5+ reports the average latency with a fraction
+func parseValue(s string) interface{} {
+	if iVal, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return iVal
 	}
-	acc.AddFields("zookeeper", fields, tags)
-
-	return nil
+	if fVal, err := strconv.ParseFloat(s, 64); err == nil {
+		return fVal
+	}
+	return s
 }
 
 func init() {
@@ -1,6 +1,13 @@
 package zookeeper
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
@@ -40,3 +47,124 @@ func TestZookeeperGeneratesMetrics(t *testing.T) {
 		assert.True(t, acc.HasInt64Field("zookeeper", metric), metric)
 	}
 }
+
+const leaderMntr = `zk_version	3.6.2--803c7f1a12f85978cb049af5e4ef23bd8b688715, built on 09/04/2020 12:44 GMT
+zk_server_state	leader
+zk_avg_latency	0.4238
+zk_max_latency	12
+zk_min_latency	0
+zk_outstanding_requests	0
+zk_znode_count	129
+zk_learners	3
+zk_synced_followers	1
+zk_synced_observers	1
+zk_pending_syncs	0
+`
+
+// mntrServer answers a single mntr command with response
+func mntrServer(t *testing.T, response string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		cmd, _ := bufio.NewReader(c).ReadString('\n')
+		if strings.TrimSpace(cmd) == "mntr" {
+			fmt.Fprint(c, response)
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestGatherMntr(t *testing.T) {
+	addr := mntrServer(t, leaderMntr)
+	_, port, _ := net.SplitHostPort(addr)
+
+	z := &Zookeeper{Servers: []string{addr}}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(z.Gather))
+
+	acc.AssertContainsTaggedFields(t, "zookeeper",
+		map[string]interface{}{
+			"version":              "3.6.2--803c7f1a12f85978cb049af5e4ef23bd8b688715",
+			"avg_latency":          0.4238,
+			"max_latency":          int64(12),
+			"min_latency":          int64(0),
+			"outstanding_requests": int64(0),
+			"znode_count":          int64(129),
+			"learners":             int64(3),
+			"synced_followers":     int64(1),
+			"synced_observers":     int64(1),
+			"pending_syncs":        int64(0),
+			"leader":               1,
+			"unsynced_followers":   int64(1),
+		},
+		map[string]string{"server": "127.0.0.1", "port": port, "state": "leader"})
+}
+
+func TestGatherMntrNotWhitelisted(t *testing.T) {
+	addr := mntrServer(t, "mntr is not executed because it is not in the whitelist.\n")
+
+	z := &Zookeeper{Servers: []string{addr}}
+
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "whitelist")
+}
+
+func TestGatherAdmin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/commands/monitor", r.URL.Path)
+		fmt.Fprint(w, `{
+  "version" : "3.6.2--803c7f1a12f85978cb049af5e4ef23bd8b688715, built on 09/04/2020 12:44 GMT",
+  "avg_latency" : 0.5,
+  "max_latency" : 3,
+  "min_latency" : 0,
+  "outstanding_requests" : 2,
+  "server_state" : "follower",
+  "znode_count" : 5,
+  "uptime" : 86400000,
+  "read_only" : false,
+  "command" : "monitor",
+  "error" : null
+}`)
+	}))
+	defer ts.Close()
+
+	addr := strings.TrimPrefix(ts.URL, "http://")
+	_, port, _ := net.SplitHostPort(addr)
+
+	z := &Zookeeper{Servers: []string{addr}, Mode: "admin"}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(z.Gather))
+
+	acc.AssertContainsTaggedFields(t, "zookeeper",
+		map[string]interface{}{
+			"version":              "3.6.2--803c7f1a12f85978cb049af5e4ef23bd8b688715, built on 09/04/2020 12:44 GMT",
+			"avg_latency":          0.5,
+			"max_latency":          int64(3),
+			"min_latency":          int64(0),
+			"outstanding_requests": int64(2),
+			"znode_count":          int64(5),
+			"uptime":               int64(86400000),
+			"read_only":            int64(0),
+			"leader":               0,
+		},
+		map[string]string{"server": "127.0.0.1", "port": port, "state": "follower"})
+}
+
+func TestGatherUnknownMode(t *testing.T) {
+	z := &Zookeeper{Mode: "stat"}
+
+	var acc testutil.Accumulator
+	require.Error(t, z.Gather(context.Background(), &acc))
+}
@@ -3,7 +3,16 @@ package all
 //nolint:golint
 import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/circonus"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/cloud_pubsub"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/datadog"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/discard"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/exec"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/execd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/file"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/graphite"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/health"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/influxdb_v2"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/postgresql"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/splunk_hec"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/syslog"
 )
@@ -13,6 +13,7 @@ import (
 type metricDestination struct {
 	metrics       *trapmetrics.TrapMetrics
 	id            string
+	key           string
 	queuedMetrics int64
 }
 
@@ -109,6 +110,7 @@ func (c *Circonus) initMetricDestination(metricMeta circmgr.MetricMeta) error {
 	c.metricDestinations[destKey] = &metricDestination{
 		metrics: dest,
 		id:      metricMeta.PluginID,
+		key:     destKey,
 	}
 
 	return nil
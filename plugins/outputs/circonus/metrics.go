@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	circmgr "github.com/circonus-labs/circonus-unified-agent/internal/circonus"
 	"github.com/circonus-labs/go-trapmetrics"
 )
 
@@ -118,6 +119,9 @@ func (c *Circonus) handleGeneric(m cua.Metric) int64 {
 
 	for _, field := range m.FieldList() {
 		mn := strings.TrimSuffix(field.Key, "__value")
+		if !circmgr.MetricAllowed(dest.key, mn) {
+			continue
+		}
 		if c.DebugMetrics {
 			c.Log.Infof("%s %v %v %T\n", mn, tags.String(), field.Value, field.Value)
 		}
@@ -159,6 +163,9 @@ func (c *Circonus) buildHistogram(m cua.Metric) int64 {
 
 	numMetrics := int64(0)
 	mn := strings.TrimSuffix(m.Name(), "__value")
+	if !circmgr.MetricAllowed(dest.key, mn) {
+		return 0
+	}
 	tags := c.convertTags(m)
 
 	for _, field := range m.FieldList() {
@@ -192,6 +199,9 @@ func (c *Circonus) buildCumulativeHistogram(m cua.Metric) int64 {
 
 	numMetrics := int64(0)
 	mn := strings.TrimSuffix(m.Name(), "__value")
+	if !circmgr.MetricAllowed(dest.key, mn) {
+		return 0
+	}
 	tags := c.convertTags(m)
 
 	for _, field := range m.FieldList() {
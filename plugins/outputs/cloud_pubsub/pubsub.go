@@ -0,0 +1,243 @@
+package cloudpubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	pubsub "cloud.google.com/go/pubsub/apiv1"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/option"
+	pb "google.golang.org/genproto/googleapis/pubsub/v1"
+)
+
+const (
+	// limits of a publish request
+	maxRequestMessages = 1000
+	maxRequestBytes    = 10 * 1000 * 1000
+
+	defaultPublishTimeout = 30 * time.Second
+)
+
+type publisher interface {
+	Publish(ctx context.Context, req *pb.PublishRequest, opts ...gax.CallOption) (*pb.PublishResponse, error)
+	Close() error
+}
+
+type PubSub struct {
+	CredentialsFile string            `toml:"credentials_file"`
+	Project         string            `toml:"project"`
+	Topic           string            `toml:"topic"`
+	EndpointURL     string            `toml:"endpoint_url"`
+	OrderingKeyTags []string          `toml:"ordering_key_tags"`
+	Attributes      map[string]string `toml:"attributes"`
+	UseBatchFormat  bool              `toml:"use_batch_format"`
+	Base64Data      bool              `toml:"base64_data"`
+	PublishTimeout  internal.Duration `toml:"publish_timeout"`
+
+	Log cua.Logger `toml:"-"`
+
+	client     publisher
+	serializer serializers.Serializer
+}
+
+var sampleConfig = `
+  ## Required. Name of Google Cloud Platform (GCP) Project that owns
+  ## the given PubSub topic.
+  project = "my-project"
+
+  ## Required. Name of PubSub topic to publish metrics to.
+  topic = "my-topic"
+
+  ## Required. Data format to output.
+  ## Each data format has its own unique set of configuration options.
+  ## Read more about them here:
+  ## https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+
+  ## Optional. Filepath for GCP credentials JSON file to authorize calls to
+  ## PubSub APIs. If not set explicitly, the agent will attempt to use
+  ## Application Default Credentials, which is preferred.
+  # credentials_file = "path/to/my/creds.json"
+
+  ## Optional. PubSub endpoint, message ordering requires a regional
+  ## endpoint, ie. "us-east1-pubsub.googleapis.com:443".
+  # endpoint_url = ""
+
+  ## Optional. Tags whose values form the ordering key of the messages,
+  ## messages with the same ordering key are received in the order they were
+  ## published by subscriptions with message ordering enabled.
+  # ordering_key_tags = []
+
+  ## Optional. If true, the metrics of a message with the same ordering key
+  ## are serialized together with the batch format, otherwise a message is
+  ## published per metric.
+  # use_batch_format = false
+
+  ## Optional. If true, the message data is base64 encoded.
+  # base64_data = false
+
+  ## Optional. Maximum time to wait for the metrics to be published.
+  # publish_timeout = "30s"
+
+  ## Optional. Attributes added to every message.
+  # [outputs.cloud_pubsub.attributes]
+  #   source = "circonus-unified-agent"
+`
+
+func (ps *PubSub) SampleConfig() string {
+	return sampleConfig
+}
+
+func (ps *PubSub) Description() string {
+	return "Publish metrics to a Google Cloud PubSub topic"
+}
+
+func (ps *PubSub) SetSerializer(serializer serializers.Serializer) {
+	ps.serializer = serializer
+}
+
+func (ps *PubSub) Connect() error {
+	if ps.Project == "" {
+		return fmt.Errorf(`"project" is required`)
+	}
+	if ps.Topic == "" {
+		return fmt.Errorf(`"topic" is required`)
+	}
+	if ps.PublishTimeout.Duration == 0 {
+		ps.PublishTimeout.Duration = defaultPublishTimeout
+	}
+	if ps.client != nil {
+		return nil
+	}
+
+	opts := []option.ClientOption{option.WithUserAgent(internal.ProductToken())}
+	if ps.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(ps.CredentialsFile))
+	}
+	if ps.EndpointURL != "" {
+		opts = append(opts, option.WithEndpoint(ps.EndpointURL))
+	}
+	client, err := pubsub.NewPublisherClient(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("unable to generate PubSub client: %w", err)
+	}
+	ps.client = client
+	return nil
+}
+
+func (ps *PubSub) Close() error {
+	if ps.client == nil {
+		return nil
+	}
+	err := ps.client.Close()
+	ps.client = nil
+	if err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
+
+func (ps *PubSub) Write(metrics []cua.Metric) (int, error) {
+	messages, err := ps.messages(metrics)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ps.PublishTimeout.Duration)
+	defer cancel()
+
+	topic := fmt.Sprintf("projects/%s/topics/%s", ps.Project, ps.Topic)
+	for len(messages) > 0 {
+		n, size := 0, 0
+		for n < len(messages) && n < maxRequestMessages {
+			size += len(messages[n].Data)
+			if n > 0 && size > maxRequestBytes {
+				break
+			}
+			n++
+		}
+
+		_, err := ps.client.Publish(ctx, &pb.PublishRequest{Topic: topic, Messages: messages[:n]})
+		if err != nil {
+			return 0, fmt.Errorf("publish (%s): %w", topic, err)
+		}
+		messages = messages[n:]
+	}
+
+	return len(metrics), nil
+}
+
+// messages serializes the metrics, keeping the order of the metrics of each
+// ordering key
+func (ps *PubSub) messages(metrics []cua.Metric) ([]*pb.PubsubMessage, error) {
+	keys := []string{}
+	groups := make(map[string][]cua.Metric)
+	for _, m := range metrics {
+		key := ps.orderingKey(m)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], m)
+	}
+
+	messages := make([]*pb.PubsubMessage, 0, len(metrics))
+	for _, key := range keys {
+		if ps.UseBatchFormat {
+			data, err := ps.serializer.SerializeBatch(groups[key])
+			if err != nil {
+				return nil, fmt.Errorf("serialize batch: %w", err)
+			}
+			messages = append(messages, ps.message(key, data))
+			continue
+		}
+
+		for _, m := range groups[key] {
+			data, err := ps.serializer.Serialize(m)
+			if err != nil {
+				ps.Log.Debugf("Could not serialize metric: %v", err)
+				continue
+			}
+			messages = append(messages, ps.message(key, data))
+		}
+	}
+	return messages, nil
+}
+
+func (ps *PubSub) orderingKey(m cua.Metric) string {
+	if len(ps.OrderingKeyTags) == 0 {
+		return ""
+	}
+	values := make([]string, len(ps.OrderingKeyTags))
+	for i, tag := range ps.OrderingKeyTags {
+		values[i], _ = m.GetTag(tag)
+	}
+	return strings.Join(values, "/")
+}
+
+func (ps *PubSub) message(key string, data []byte) *pb.PubsubMessage {
+	if ps.Base64Data {
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(len(data)))
+		base64.StdEncoding.Encode(encoded, data)
+		data = encoded
+	}
+	return &pb.PubsubMessage{
+		Data:        data,
+		Attributes:  ps.Attributes,
+		OrderingKey: key,
+	}
+}
+
+func init() {
+	outputs.Add("cloud_pubsub", func() cua.Output {
+		return &PubSub{
+			PublishTimeout: internal.Duration{Duration: defaultPublishTimeout},
+		}
+	})
+}
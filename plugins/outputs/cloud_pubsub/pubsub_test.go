@@ -0,0 +1,139 @@
+package cloudpubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/carbon2"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	gax "github.com/googleapis/gax-go/v2"
+	"github.com/stretchr/testify/require"
+	pb "google.golang.org/genproto/googleapis/pubsub/v1"
+)
+
+type stubPublisher struct {
+	requests []*pb.PublishRequest
+	err      error
+}
+
+func (s *stubPublisher) Publish(_ context.Context, req *pb.PublishRequest, _ ...gax.CallOption) (*pb.PublishResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.requests = append(s.requests, req)
+	return &pb.PublishResponse{}, nil
+}
+
+func (s *stubPublisher) Close() error {
+	return nil
+}
+
+func testMetrics() []cua.Metric {
+	return []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+}
+
+func newTestPubSub(stub *stubPublisher) *PubSub {
+	ps := &PubSub{
+		Project:    "my-project",
+		Topic:      "my-topic",
+		Attributes: map[string]string{"source": "agent"},
+		Log:        testutil.Logger{},
+		client:     stub,
+	}
+	serializer, err := carbon2.NewSerializer("field_separate")
+	if err != nil {
+		panic(err)
+	}
+	ps.SetSerializer(serializer)
+	return ps
+}
+
+func TestWrite(t *testing.T) {
+	stub := &stubPublisher{}
+	ps := newTestPubSub(stub)
+	ps.OrderingKeyTags = []string{"host"}
+	require.NoError(t, ps.Connect())
+
+	n, err := ps.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	require.Len(t, stub.requests, 1)
+	require.Equal(t, "projects/my-project/topics/my-topic", stub.requests[0].Topic)
+
+	messages := stub.requests[0].Messages
+	require.Len(t, messages, 3)
+	require.Equal(t, "metric=cpu field=value host=a  1 0\n", string(messages[0].Data))
+	require.Equal(t, "a", messages[0].OrderingKey)
+	require.Equal(t, "metric=cpu field=value host=a  3 0\n", string(messages[1].Data))
+	require.Equal(t, "a", messages[1].OrderingKey)
+	require.Equal(t, "metric=cpu field=value host=b  2 0\n", string(messages[2].Data))
+	require.Equal(t, "b", messages[2].OrderingKey)
+	for _, msg := range messages {
+		require.Equal(t, map[string]string{"source": "agent"}, msg.Attributes)
+	}
+}
+
+func TestWriteBatchFormat(t *testing.T) {
+	stub := &stubPublisher{}
+	ps := newTestPubSub(stub)
+	ps.OrderingKeyTags = []string{"host"}
+	ps.UseBatchFormat = true
+	ps.Base64Data = true
+	require.NoError(t, ps.Connect())
+
+	_, err := ps.Write(testMetrics())
+	require.NoError(t, err)
+
+	messages := stub.requests[0].Messages
+	require.Len(t, messages, 2)
+
+	data, err := base64.StdEncoding.DecodeString(string(messages[0].Data))
+	require.NoError(t, err)
+	require.Equal(t, "metric=cpu field=value host=a  1 0\nmetric=cpu field=value host=a  3 0\n", string(data))
+	require.Equal(t, "a", messages[0].OrderingKey)
+	require.Equal(t, "b", messages[1].OrderingKey)
+}
+
+func TestWriteSplitsRequests(t *testing.T) {
+	stub := &stubPublisher{}
+	ps := newTestPubSub(stub)
+	require.NoError(t, ps.Connect())
+
+	metrics := make([]cua.Metric, 0, maxRequestMessages+1)
+	for i := 0; i <= maxRequestMessages; i++ {
+		metrics = append(metrics, testMetrics()[0])
+	}
+	_, err := ps.Write(metrics)
+	require.NoError(t, err)
+
+	require.Len(t, stub.requests, 2)
+	require.Len(t, stub.requests[0].Messages, maxRequestMessages)
+	require.Len(t, stub.requests[1].Messages, 1)
+	require.Empty(t, stub.requests[0].Messages[0].OrderingKey)
+}
+
+func TestWriteError(t *testing.T) {
+	stub := &stubPublisher{err: errors.New("unavailable")}
+	ps := newTestPubSub(stub)
+	require.NoError(t, ps.Connect())
+
+	_, err := ps.Write(testMetrics())
+	require.Error(t, err)
+}
+
+func TestConnectRequired(t *testing.T) {
+	ps := &PubSub{Topic: "my-topic"}
+	require.Error(t, ps.Connect())
+
+	ps = &PubSub{Project: "my-project"}
+	require.Error(t, ps.Connect())
+}
@@ -0,0 +1,289 @@
+package datadog
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+)
+
+const (
+	defaultURL         = "https://api.datadoghq.com"
+	seriesPath         = "/api/v1/series"
+	distributionsPath  = "/api/v1/distribution_points"
+	defaultCompression = "zlib"
+)
+
+type Datadog struct {
+	Apikey              string            `toml:"apikey"`
+	URL                 string            `toml:"url"`
+	HostTag             string            `toml:"host_tag"`
+	DistributionMetrics []string          `toml:"distribution_metrics"`
+	Compression         string            `toml:"compression"`
+	Timeout             internal.Duration `toml:"timeout"`
+	HTTPProxy           string            `toml:"http_proxy"`
+
+	Log cua.Logger `toml:"-"`
+
+	client        *http.Client
+	distributions filter.Filter
+	base          *url.URL
+}
+
+var sampleConfig = `
+  ## Datadog API key
+  apikey = "my-secret-key"
+
+  ## Datadog site of the organization, ie. "https://api.datadoghq.eu"
+  # url = "https://api.datadoghq.com"
+
+  ## Tag used as the host of the series, the tag is left out of the tags.
+  # host_tag = "host"
+
+  ## Metrics (<measurement>.<field>) sent as distribution points instead of
+  ## gauges, glob patterns are supported.
+  # distribution_metrics = []
+
+  ## Compression of the request bodies, "zlib" or "none".
+  # compression = "zlib"
+
+  ## Connection timeout.
+  # timeout = "5s"
+
+  ## Set http_proxy
+  # http_proxy = "http://localhost:8888"
+`
+
+func (d *Datadog) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Datadog) Description() string {
+	return "Configuration for DataDog API to send metrics to."
+}
+
+func (d *Datadog) Connect() error {
+	if d.Apikey == "" {
+		return fmt.Errorf("apikey is a required field for datadog output")
+	}
+
+	base, err := url.Parse(d.URL)
+	if err != nil {
+		return fmt.Errorf("error parsing url (%s): %w", d.URL, err)
+	}
+	d.base = base
+
+	switch d.Compression {
+	case "", "none", "zlib":
+	default:
+		return fmt.Errorf("unsupported compression %q", d.Compression)
+	}
+
+	d.distributions, err = filter.Compile(d.DistributionMetrics)
+	if err != nil {
+		return fmt.Errorf("distribution_metrics: %w", err)
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if d.HTTPProxy != "" {
+		proxyURL, err := url.Parse(d.HTTPProxy)
+		if err != nil {
+			return fmt.Errorf("error parsing http_proxy (%s): %w", d.HTTPProxy, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	d.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy: proxy,
+		},
+		Timeout: d.Timeout.Duration,
+	}
+	return nil
+}
+
+func (d *Datadog) Close() error {
+	if d.client != nil {
+		d.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+type point [2]interface{}
+
+type series struct {
+	Metric string   `json:"metric"`
+	Points []point  `json:"points"`
+	Type   string   `json:"type,omitempty"`
+	Host   string   `json:"host,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+type payload struct {
+	Series []*series `json:"series"`
+}
+
+func (d *Datadog) Write(metrics []cua.Metric) (int, error) {
+	gauges := payload{Series: []*series{}}
+	distributions := payload{Series: []*series{}}
+	// distribution points of a series and timestamp are sent together
+	points := make(map[string]*series)
+
+	for _, m := range metrics {
+		host, tags := d.tags(m)
+		ts := m.Time().Unix()
+
+		for _, field := range m.FieldList() {
+			value, ok := fieldValue(field.Value)
+			if !ok {
+				continue
+			}
+			name := m.Name() + "." + field.Key
+
+			if d.distributions != nil && d.distributions.Match(name) {
+				key := fmt.Sprintf("%s %s %s %d", name, host, strings.Join(tags, ","), ts)
+				s, ok := points[key]
+				if !ok {
+					s = &series{
+						Metric: name,
+						Points: []point{{ts, []float64{}}},
+						Host:   host,
+						Tags:   tags,
+					}
+					points[key] = s
+					distributions.Series = append(distributions.Series, s)
+				}
+				s.Points[0][1] = append(s.Points[0][1].([]float64), value)
+				continue
+			}
+
+			gauges.Series = append(gauges.Series, &series{
+				Metric: name,
+				Points: []point{{ts, value}},
+				Type:   "gauge",
+				Host:   host,
+				Tags:   tags,
+			})
+		}
+	}
+
+	if len(gauges.Series) > 0 {
+		if err := d.post(seriesPath, gauges); err != nil {
+			return 0, err
+		}
+	}
+	if len(distributions.Series) > 0 {
+		if err := d.post(distributionsPath, distributions); err != nil {
+			return 0, err
+		}
+	}
+	return len(metrics), nil
+}
+
+// tags converts the metric tags into the host and key:value tags
+func (d *Datadog) tags(m cua.Metric) (string, []string) {
+	host := ""
+	tags := make([]string, 0, len(m.TagList()))
+	for _, tag := range m.TagList() {
+		if d.HostTag != "" && tag.Key == d.HostTag {
+			host = tag.Value
+			continue
+		}
+		tags = append(tags, tag.Key+":"+tag.Value)
+	}
+	sort.Strings(tags)
+	return host, tags
+}
+
+// fieldValue is the numeric value of a field, strings are not sent
+func fieldValue(v interface{}) (float64, bool) {
+	var f float64
+	switch v := v.(type) {
+	case int64:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	case float64:
+		f = v
+	case bool:
+		if v {
+			f = 1
+		}
+	default:
+		return 0, false
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+func (d *Datadog) post(p string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("unable to marshal series: %w", err)
+	}
+
+	var reqBody io.Reader = bytes.NewReader(data)
+	if d.Compression == "zlib" {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return fmt.Errorf("compress: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("compress: %w", err)
+		}
+		reqBody = &buf
+	}
+
+	u := *d.base
+	u.Path = path.Join(u.Path, p)
+	req, err := http.NewRequest(http.MethodPost, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("unable to create http.Request (%s): %w", p, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", d.Apikey)
+	req.Header.Set("User-Agent", internal.ProductToken())
+	if d.Compression == "zlib" {
+		req.Header.Set("Content-Encoding", "deflate")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error POSTing metrics (%s): %w", p, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("received bad status code (%s), %d: %s", p, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func init() {
+	outputs.Add("datadog", func() cua.Output {
+		return &Datadog{
+			URL:         defaultURL,
+			HostTag:     "host",
+			Compression: defaultCompression,
+			Timeout:     internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
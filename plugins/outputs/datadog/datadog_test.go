@@ -0,0 +1,148 @@
+package datadog
+
+import (
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T, requests map[string]interface{}) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["Forbidden"]}`))
+			return
+		}
+
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "deflate" {
+			zr, err := zlib.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+		var payload interface{}
+		require.NoError(t, json.NewDecoder(body).Decode(&payload))
+		requests[r.URL.Path] = payload
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newDatadog(url string) *Datadog {
+	return &Datadog{
+		Apikey:      "secret",
+		URL:         url,
+		HostTag:     "host",
+		Compression: defaultCompression,
+		Timeout:     internal.Duration{Duration: time.Second},
+		Log:         testutil.Logger{},
+	}
+}
+
+func testMetrics() []cua.Metric {
+	return []cua.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "web01", "cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 91.5, "state": "ok"},
+			time.Unix(1600000000, 0)),
+		testutil.MustMetric("http",
+			map[string]string{"host": "web01"},
+			map[string]interface{}{"latency": int64(12), "up": true},
+			time.Unix(1600000000, 0)),
+		testutil.MustMetric("http",
+			map[string]string{"host": "web01"},
+			map[string]interface{}{"latency": int64(15)},
+			time.Unix(1600000000, 0)),
+	}
+}
+
+func TestWriteSeries(t *testing.T) {
+	requests := map[string]interface{}{}
+	ts := newServer(t, requests)
+
+	d := newDatadog(ts.URL)
+	require.NoError(t, d.Connect())
+
+	_, err := d.Write(testMetrics()[:2])
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"/api/v1/series": map[string]interface{}{
+			"series": []interface{}{
+				map[string]interface{}{
+					"metric": "cpu.usage_idle",
+					"points": []interface{}{[]interface{}{1600000000.0, 91.5}},
+					"type":   "gauge",
+					"host":   "web01",
+					"tags":   []interface{}{"cpu:cpu0"},
+				},
+				map[string]interface{}{
+					"metric": "http.latency",
+					"points": []interface{}{[]interface{}{1600000000.0, 12.0}},
+					"type":   "gauge",
+					"host":   "web01",
+				},
+				map[string]interface{}{
+					"metric": "http.up",
+					"points": []interface{}{[]interface{}{1600000000.0, 1.0}},
+					"type":   "gauge",
+					"host":   "web01",
+				},
+			},
+		},
+	}, requests)
+}
+
+func TestWriteDistributions(t *testing.T) {
+	requests := map[string]interface{}{}
+	ts := newServer(t, requests)
+
+	d := newDatadog(ts.URL)
+	d.DistributionMetrics = []string{"http.lat*"}
+	d.Compression = "none"
+	require.NoError(t, d.Connect())
+
+	_, err := d.Write(testMetrics())
+	require.NoError(t, err)
+	require.Len(t, requests["/api/v1/series"].(map[string]interface{})["series"], 2)
+	require.Equal(t, map[string]interface{}{
+		"series": []interface{}{
+			map[string]interface{}{
+				"metric": "http.latency",
+				"points": []interface{}{[]interface{}{1600000000.0, []interface{}{12.0, 15.0}}},
+				"host":   "web01",
+			},
+		},
+	}, requests["/api/v1/distribution_points"])
+}
+
+func TestWriteError(t *testing.T) {
+	ts := newServer(t, map[string]interface{}{})
+
+	d := newDatadog(ts.URL)
+	d.Apikey = "invalid"
+	require.NoError(t, d.Connect())
+
+	_, err := d.Write(testMetrics())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "403")
+}
+
+func TestConnect(t *testing.T) {
+	d := newDatadog(defaultURL)
+	d.Apikey = ""
+	require.Error(t, d.Connect())
+
+	d = newDatadog(defaultURL)
+	d.Compression = "lz4"
+	require.Error(t, d.Connect())
+}
@@ -0,0 +1,118 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+)
+
+const maxStderrBytes = 512
+
+const sampleConfig = `
+  ## Command to ingest metrics via stdin.
+  command = ["tee", "-a", "/dev/null"]
+
+  ## Timeout for command to complete.
+  # timeout = "5s"
+
+  ## Use batch serialization format instead of line based delimiting.  The
+  ## batch format allows for the production of non line based output formats and
+  ## may more efficiently encode metric groups.
+  # use_batch_format = true
+
+  ## Data format to output.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  # data_format = "influx"
+`
+
+type Exec struct {
+	Command        []string          `toml:"command"`
+	Timeout        internal.Duration `toml:"timeout"`
+	UseBatchFormat bool              `toml:"use_batch_format"`
+
+	Log cua.Logger `toml:"-"`
+
+	serializer serializers.Serializer
+}
+
+func (e *Exec) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Exec) Description() string {
+	return "Send metrics to command as input over stdin"
+}
+
+func (e *Exec) SetSerializer(serializer serializers.Serializer) {
+	e.serializer = serializer
+}
+
+func (e *Exec) Connect() error {
+	if len(e.Command) == 0 {
+		return fmt.Errorf("no command")
+	}
+	return nil
+}
+
+func (e *Exec) Close() error {
+	return nil
+}
+
+// Write runs the command once per batch with the serialized metrics on stdin
+func (e *Exec) Write(metrics []cua.Metric) (int, error) {
+	var buf bytes.Buffer
+	if e.UseBatchFormat {
+		b, err := e.serializer.SerializeBatch(metrics)
+		if err != nil {
+			return 0, fmt.Errorf("serialize batch: %w", err)
+		}
+		buf.Write(b)
+	} else {
+		for _, m := range metrics {
+			b, err := e.serializer.Serialize(m)
+			if err != nil {
+				e.Log.Debugf("Could not serialize metric: %v", err)
+				continue
+			}
+			buf.Write(b)
+		}
+	}
+	if buf.Len() == 0 {
+		return 0, nil
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(e.Command[0], e.Command[1:]...) //nolint:gosec // G204
+	cmd.Stdin = &buf
+	cmd.Stderr = &stderr
+
+	if err := internal.RunTimeout(cmd, e.Timeout.Duration); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > maxStderrBytes {
+			msg = msg[:maxStderrBytes] + "..."
+		}
+		if msg != "" {
+			return 0, fmt.Errorf("%s: %w: %s", e.Command[0], err, msg)
+		}
+		return 0, fmt.Errorf("%s: %w", e.Command[0], err)
+	}
+	return len(metrics), nil
+}
+
+func init() {
+	outputs.Add("exec", func() cua.Output {
+		return &Exec{
+			Timeout:        internal.Duration{Duration: 5 * time.Second},
+			UseBatchFormat: true,
+		}
+	})
+}
@@ -0,0 +1,78 @@
+//go:build !windows
+// +build !windows
+
+package exec
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/influx"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetrics() []cua.Metric {
+	return []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+	}
+}
+
+func TestWrite(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+
+	for _, batch := range []bool{true, false} {
+		e := &Exec{
+			Command:        []string{"sh", "-c", "cat >> " + out},
+			Timeout:        internal.Duration{Duration: 5 * time.Second},
+			UseBatchFormat: batch,
+			Log:            testutil.Logger{},
+		}
+		e.SetSerializer(influx.NewSerializer())
+		require.NoError(t, e.Connect())
+
+		n, err := e.Write(testMetrics())
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+	}
+
+	data, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "cpu,host=a value=1 0\ncpu,host=b value=2 0\ncpu,host=a value=1 0\ncpu,host=b value=2 0\n", string(data))
+}
+
+func TestWriteError(t *testing.T) {
+	e := &Exec{
+		Command: []string{"sh", "-c", "echo 'server unavailable' >&2; exit 1"},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		Log:     testutil.Logger{},
+	}
+	e.SetSerializer(influx.NewSerializer())
+	require.NoError(t, e.Connect())
+
+	_, err := e.Write(testMetrics())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "server unavailable")
+}
+
+func TestWriteTimeout(t *testing.T) {
+	e := &Exec{
+		Command: []string{"sleep", "10"},
+		Timeout: internal.Duration{Duration: 100 * time.Millisecond},
+		Log:     testutil.Logger{},
+	}
+	e.SetSerializer(influx.NewSerializer())
+
+	_, err := e.Write(testMetrics())
+	require.Error(t, err)
+}
+
+func TestConnectNoCommand(t *testing.T) {
+	e := &Exec{}
+	require.Error(t, e.Connect())
+}
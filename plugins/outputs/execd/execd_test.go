@@ -0,0 +1,81 @@
+package execd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/influx"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var copyTo = flag.String("copy", "",
+	"if set, copy the lines of stdin to the file instead of running the tests")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if *copyTo != "" {
+		runCopyProgram(*copyTo)
+		os.Exit(0)
+	}
+	code := m.Run()
+	os.Exit(code)
+}
+
+func runCopyProgram(path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERR %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Fprintln(f, scanner.Text())
+	}
+}
+
+func TestExternalOutputWorks(t *testing.T) {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	out := filepath.Join(t.TempDir(), "out")
+
+	e := &Execd{
+		Command:      []string{exe, "-copy", out},
+		RestartDelay: config.Duration(5 * time.Second),
+		Log:          testutil.Logger{},
+	}
+	e.SetSerializer(influx.NewSerializer())
+	require.NoError(t, e.Connect())
+
+	metrics := []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+	}
+	n, err := e.Write(metrics)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	expected := "cpu,host=a value=1 0\ncpu,host=b value=2 0\n"
+	require.Eventually(t, func() bool {
+		data, _ := ioutil.ReadFile(out)
+		return string(data) == expected
+	}, 10*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, e.Close())
+}
+
+func TestConnectNoCommand(t *testing.T) {
+	e := &Execd{Log: testutil.Logger{}}
+	require.Error(t, e.Connect())
+	require.NoError(t, e.Close())
+}
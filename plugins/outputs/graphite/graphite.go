@@ -0,0 +1,216 @@
+package graphite
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	tlsint "github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+)
+
+const defaultTimeout = 2 * time.Second
+
+type Graphite struct {
+	Servers            []string          `toml:"servers"`
+	Prefix             string            `toml:"prefix"`
+	Template           string            `toml:"template"`
+	Templates          []string          `toml:"templates"`
+	GraphiteTagSupport bool              `toml:"graphite_tag_support"`
+	GraphiteSeparator  string            `toml:"graphite_separator"`
+	Timeout            internal.Duration `toml:"timeout"`
+	tlsint.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	serializer serializers.Serializer
+	tlsConfig  *tls.Config
+	// connections of the servers, nil while a server is not connected
+	conns []net.Conn
+}
+
+var sampleConfig = `
+  ## TCP endpoint for your graphite instance.
+  ## If multiple endpoints are configured, output will be load balanced.
+  ## Only one of the endpoints will be written to with each iteration.
+  servers = ["localhost:2003"]
+
+  ## Prefix metrics name
+  prefix = ""
+
+  ## Graphite output template, tags are used in the metric name in the order
+  ## of the template.
+  ## see https://github.com/circonus-labs/circonus-unified-agent/tree/master/plugins/serializers/graphite
+  template = "host.tags.measurement.field"
+
+  ## Graphite templates patterns, the template of the first pattern matching
+  ## the measurement is used. A pattern-less template is the default template.
+  # templates = [
+  #   "cpu tags.measurement.host.field",
+  #   "host.measurement.tags.field"
+  # ]
+
+  ## Enable Graphite tags support
+  # graphite_tag_support = false
+
+  ## Character for separating metric name and field for Graphite tags
+  # graphite_separator = "."
+
+  ## timeout in seconds for the write connection to graphite
+  # timeout = "2s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (g *Graphite) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *Graphite) Description() string {
+	return "Configuration for Graphite server to send metrics to"
+}
+
+func (g *Graphite) Connect() error {
+	if len(g.Servers) == 0 {
+		g.Servers = append(g.Servers, "localhost:2003")
+	}
+	if g.Timeout.Duration == 0 {
+		g.Timeout.Duration = defaultTimeout
+	}
+
+	serializer, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport, g.GraphiteSeparator, g.Templates)
+	if err != nil {
+		return fmt.Errorf("graphite serializer: %w", err)
+	}
+	g.serializer = serializer
+
+	tlsConfig, err := g.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	g.tlsConfig = tlsConfig
+
+	// servers not reachable yet are connected on the next writes
+	g.conns = make([]net.Conn, len(g.Servers))
+	for i := range g.Servers {
+		if err := g.connect(i); err != nil {
+			g.Log.Warnf("Connecting to %s: %v", g.Servers[i], err)
+		}
+	}
+	return nil
+}
+
+func (g *Graphite) connect(i int) error {
+	dialer := &net.Dialer{Timeout: g.Timeout.Duration}
+
+	var conn net.Conn
+	var err error
+	if g.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", g.Servers[i], g.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", g.Servers[i])
+	}
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	g.conns[i] = conn
+	return nil
+}
+
+func (g *Graphite) disconnect(i int) {
+	if g.conns[i] != nil {
+		g.conns[i].Close()
+		g.conns[i] = nil
+	}
+}
+
+func (g *Graphite) Close() error {
+	for i := range g.conns {
+		g.disconnect(i)
+	}
+	return nil
+}
+
+// Write sends the metrics to one of the servers, the servers are tried in
+// random order and disconnected servers are connected again
+func (g *Graphite) Write(metrics []cua.Metric) (int, error) {
+	batch := make([]byte, 0, len(metrics)*64)
+	for _, m := range metrics {
+		buf, err := g.serializer.Serialize(m)
+		if err != nil {
+			g.Log.Errorf("Error serializing some metrics to graphite: %s", err.Error())
+			continue
+		}
+		batch = append(batch, buf...)
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	for _, i := range rand.Perm(len(g.conns)) {
+		if err := g.send(i, batch); err != nil {
+			g.Log.Errorf("Writing to %s: %v", g.Servers[i], err)
+			g.disconnect(i)
+			continue
+		}
+		return len(metrics), nil
+	}
+
+	return 0, fmt.Errorf("could not write to any graphite server")
+}
+
+func (g *Graphite) send(i int, batch []byte) error {
+	if g.conns[i] != nil && closed(g.conns[i]) {
+		g.Log.Debugf("Connection to %s closed by the server, reconnecting", g.Servers[i])
+		g.disconnect(i)
+	}
+	if g.conns[i] == nil {
+		if err := g.connect(i); err != nil {
+			return err
+		}
+	}
+
+	conn := g.conns[i]
+	if err := conn.SetWriteDeadline(time.Now().Add(g.Timeout.Duration)); err != nil {
+		return fmt.Errorf("set write deadline: %w", err)
+	}
+	if _, err := conn.Write(batch); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+// closed reports whether the server closed the connection, graphite servers
+// never write to the connection so a read only returns a timeout while the
+// connection is open
+func closed(conn net.Conn) bool {
+	if err := conn.SetReadDeadline(time.Now().Add(time.Millisecond)); err != nil {
+		return true
+	}
+	var b [1]byte
+	_, err := conn.Read(b[:])
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	return err != nil
+}
+
+func init() {
+	outputs.Add("graphite", func() cua.Output {
+		return &Graphite{
+			Timeout: internal.Duration{Duration: defaultTimeout},
+		}
+	})
+}
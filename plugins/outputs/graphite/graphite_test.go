@@ -0,0 +1,153 @@
+package graphite
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// listen accepts connections and sends the lines read to the channel
+func listen(t *testing.T) (net.Listener, chan string, chan net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	lines := make(chan string, 100)
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+			go func() {
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+	return l, lines, conns
+}
+
+func readLines(t *testing.T, lines chan string, n int) []string {
+	read := []string{}
+	for len(read) < n {
+		select {
+		case line := <-lines:
+			read = append(read, line)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("missing lines, got %v", read)
+		}
+	}
+	return read
+}
+
+func testMetrics() []cua.Metric {
+	return []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "web01", "cpu": "cpu0"}, map[string]interface{}{"usage_idle": 91.5}, time.Unix(1600000000, 0)),
+		testutil.MustMetric("mem", map[string]string{"host": "web01"}, map[string]interface{}{"used": 42}, time.Unix(1600000000, 0)),
+	}
+}
+
+func TestWriteTemplates(t *testing.T) {
+	l, lines, _ := listen(t)
+
+	g := &Graphite{
+		Servers:  []string{l.Addr().String()},
+		Prefix:   "agent",
+		Template: "host.measurement.tags.field",
+		Templates: []string{
+			"cpu tags.measurement.host.field",
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, g.Connect())
+	defer g.Close()
+
+	_, err := g.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"agent.cpu0.cpu.web01.usage_idle 91.5 1600000000",
+		"agent.web01.mem.used 42 1600000000",
+	}, readLines(t, lines, 2))
+}
+
+func TestWriteTagSupport(t *testing.T) {
+	l, lines, _ := listen(t)
+
+	g := &Graphite{
+		Servers:            []string{l.Addr().String()},
+		GraphiteTagSupport: true,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, g.Connect())
+	defer g.Close()
+
+	_, err := g.Write(testMetrics()[1:])
+	require.NoError(t, err)
+	require.Equal(t, []string{"mem.used;host=web01 42 1600000000"}, readLines(t, lines, 1))
+}
+
+func TestWriteReconnect(t *testing.T) {
+	l, lines, conns := listen(t)
+
+	g := &Graphite{
+		Servers:  []string{l.Addr().String()},
+		Template: "measurement.field",
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, g.Connect())
+	defer g.Close()
+
+	// the server closes the connection
+	conn := <-conns
+	conn.Close()
+
+	_, err := g.Write(testMetrics()[1:])
+	require.NoError(t, err)
+	require.Equal(t, []string{"mem.used 42 1600000000"}, readLines(t, lines, 1))
+}
+
+func TestWriteUnreachable(t *testing.T) {
+	l, lines, _ := listen(t)
+	addr := l.Addr().String()
+	l.Close()
+
+	g := &Graphite{
+		Servers:  []string{addr},
+		Template: "measurement.field",
+		Log:      testutil.Logger{},
+	}
+	// a server not reachable at start is connected on the next writes
+	require.NoError(t, g.Connect())
+	defer g.Close()
+
+	_, err := g.Write(testMetrics()[1:])
+	require.Error(t, err)
+
+	l2, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	defer l2.Close()
+	go func() {
+		conn, err := l2.Accept()
+		if err != nil {
+			return
+		}
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	_, err = g.Write(testMetrics()[1:])
+	require.NoError(t, err)
+	require.Equal(t, []string{"mem.used 42 1600000000"}, readLines(t, lines, 1))
+}
@@ -0,0 +1,260 @@
+package influxdbv2
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/influx"
+)
+
+const (
+	defaultMaxRetryWait = 60 * time.Second
+	defaultRetryWait    = 5 * time.Second
+)
+
+// APIError is an error reported by the write api
+type APIError struct {
+	StatusCode  int
+	Title       string
+	Description string
+}
+
+func (e APIError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Title, e.Description)
+	}
+	return e.Title
+}
+
+type httpConfig struct {
+	URL              *url.URL
+	Token            string
+	Organization     string
+	Bucket           string
+	BucketTag        string
+	ExcludeBucketTag bool
+	Timeout          time.Duration
+	Headers          map[string]string
+	Proxy            *url.URL
+	UserAgent        string
+	ContentEncoding  string
+	TLSConfig        *tls.Config
+	Serializer       *influx.Serializer
+	Log              cua.Logger
+}
+
+type httpClient struct {
+	*httpConfig
+
+	client   *http.Client
+	encoder  internal.ContentEncoder
+	retryAt  time.Time
+	writeURL *url.URL
+}
+
+func newHTTPClient(config *httpConfig) (*httpClient, error) {
+	if config.URL == nil {
+		return nil, fmt.Errorf("config URL is required")
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = internal.ProductToken()
+	}
+	if config.ContentEncoding == "" {
+		config.ContentEncoding = "identity"
+	}
+
+	encoder, err := internal.NewContentEncoder(config.ContentEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("content encoding (%s): %w", config.ContentEncoding, err)
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != nil {
+		proxy = http.ProxyURL(config.Proxy)
+	}
+
+	writeURL := *config.URL
+	writeURL.Path = path.Join(writeURL.Path, "/api/v2/write")
+
+	return &httpClient{
+		httpConfig: config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				Proxy:           proxy,
+				TLSClientConfig: config.TLSConfig,
+			},
+		},
+		encoder:  encoder,
+		writeURL: &writeURL,
+	}, nil
+}
+
+// Write sends the metrics grouped by bucket
+func (c *httpClient) Write(ctx context.Context, metrics []cua.Metric) error {
+	if c.retryAt.After(time.Now()) {
+		return fmt.Errorf("retrying after %s", c.retryAt.Format(time.RFC3339))
+	}
+
+	if c.BucketTag == "" {
+		return c.writeBatch(ctx, c.Bucket, metrics)
+	}
+
+	buckets := []string{}
+	batches := make(map[string][]cua.Metric)
+	for _, m := range metrics {
+		bucket, ok := m.GetTag(c.BucketTag)
+		if !ok {
+			bucket = c.Bucket
+		}
+		if c.ExcludeBucketTag {
+			// the metric may be shared with other outputs
+			m = m.Copy()
+			m.RemoveTag(c.BucketTag)
+		}
+		if _, ok := batches[bucket]; !ok {
+			buckets = append(buckets, bucket)
+		}
+		batches[bucket] = append(batches[bucket], m)
+	}
+
+	for _, bucket := range buckets {
+		if err := c.writeBatch(ctx, bucket, batches[bucket]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBatch sends the metrics of a bucket, a batch too large for the
+// server is split in halves
+func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []cua.Metric) error {
+	err := c.send(ctx, bucket, metrics)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestEntityTooLarge && len(metrics) > 1 {
+		c.Log.Debugf("Request too large for %d metrics, splitting the batch", len(metrics))
+		half := len(metrics) / 2
+		if err := c.writeBatch(ctx, bucket, metrics[:half]); err != nil {
+			return err
+		}
+		return c.writeBatch(ctx, bucket, metrics[half:])
+	}
+	return err
+}
+
+func (c *httpClient) send(ctx context.Context, bucket string, metrics []cua.Metric) error {
+	body, err := c.Serializer.SerializeBatch(metrics)
+	if err != nil {
+		return fmt.Errorf("serialize: %w", err)
+	}
+	body, err = c.encoder.Encode(body)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("bucket", bucket)
+	params.Set("org", c.Organization)
+	writeURL := *c.writeURL
+	writeURL.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, writeURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if c.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Token "+c.Token)
+	}
+	for k, v := range c.Headers {
+		if k == "Host" {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusBadRequest:
+		// the server rejected the data, sending it again can not succeed
+		c.Log.Errorf("Failed to write metrics to %s, dropping the batch: %s", bucket, apiError(resp))
+		return nil
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		wait := retryAfter(resp.Header.Get("Retry-After"))
+		c.retryAt = time.Now().Add(wait)
+		return fmt.Errorf("retrying in %s: %w", wait, apiError(resp))
+	default:
+		return apiError(resp)
+	}
+}
+
+// apiError reads the json error of a response
+func apiError(resp *http.Response) *APIError {
+	err := &APIError{
+		StatusCode: resp.StatusCode,
+		Title:      resp.Status,
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var writeResp struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &writeResp) == nil {
+		err.Description = writeResp.Message
+	}
+	return err
+}
+
+// retryAfter is the wait requested by a Retry-After header in seconds, or a
+// default wait
+func retryAfter(header string) time.Duration {
+	wait := defaultRetryWait
+	if header == "" {
+		return wait
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if at, err := http.ParseTime(header); err == nil {
+		wait = time.Until(at)
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > defaultMaxRetryWait {
+		wait = defaultMaxRetryWait
+	}
+	return wait
+}
+
+func (c *httpClient) Close() {
+	c.client.CloseIdleConnections()
+}
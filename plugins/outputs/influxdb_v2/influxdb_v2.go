@@ -0,0 +1,200 @@
+package influxdbv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/influx"
+)
+
+const (
+	defaultURL     = "http://localhost:8086"
+	defaultTimeout = 5 * time.Second
+)
+
+type InfluxDB struct {
+	URLs             []string          `toml:"urls"`
+	Token            string            `toml:"token"`
+	Organization     string            `toml:"organization"`
+	Bucket           string            `toml:"bucket"`
+	BucketTag        string            `toml:"bucket_tag"`
+	ExcludeBucketTag bool              `toml:"exclude_bucket_tag"`
+	Timeout          internal.Duration `toml:"timeout"`
+	HTTPHeaders      map[string]string `toml:"http_headers"`
+	HTTPProxy        string            `toml:"http_proxy"`
+	UserAgent        string            `toml:"user_agent"`
+	ContentEncoding  string            `toml:"content_encoding"`
+	UintSupport      bool              `toml:"influx_uint_support"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	clients []*httpClient
+}
+
+var sampleConfig = `
+  ## The URLs of the InfluxDB cluster nodes.
+  ##
+  ## Multiple URLs can be specified for a single cluster, only ONE of the
+  ## urls will be written to each interval.
+  urls = ["http://127.0.0.1:8086"]
+
+  ## Token for authentication.
+  token = ""
+
+  ## Organization is the name of the organization you wish to write to.
+  organization = ""
+
+  ## Destination bucket to write into.
+  bucket = ""
+
+  ## The value of this tag will be used to determine the bucket.  If this
+  ## tag is not set the 'bucket' option is used as the default.
+  # bucket_tag = ""
+
+  ## If true, the bucket tag will not be added to the metric.
+  # exclude_bucket_tag = false
+
+  ## Timeout for HTTP messages.
+  # timeout = "5s"
+
+  ## Additional HTTP headers
+  # http_headers = {"X-Special-Header" = "Special-Value"}
+
+  ## HTTP Proxy override, if unset values the standard proxy environment
+  ## variables are consulted to determine which proxy, if any, should be used.
+  # http_proxy = "http://corporate.proxy:3128"
+
+  ## HTTP User-Agent
+  # user_agent = "circonus-unified-agent"
+
+  ## Content-Encoding for write request body, can be set to "gzip" to
+  ## compress body or "identity" to apply no encoding.
+  # content_encoding = "gzip"
+
+  ## Enable or disable uint support for writing uints influxdb 2.0.
+  # influx_uint_support = false
+
+  ## Optional TLS Config for use on HTTP connections.
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (i *InfluxDB) SampleConfig() string {
+	return sampleConfig
+}
+
+func (i *InfluxDB) Description() string {
+	return "Configuration for sending metrics to InfluxDB 2.0"
+}
+
+func (i *InfluxDB) Connect() error {
+	if len(i.URLs) == 0 {
+		i.URLs = append(i.URLs, defaultURL)
+	}
+
+	tlsConfig, err := i.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+
+	var proxy *url.URL
+	if i.HTTPProxy != "" {
+		proxy, err = url.Parse(i.HTTPProxy)
+		if err != nil {
+			return fmt.Errorf("error parsing proxy_url (%s): %w", i.HTTPProxy, err)
+		}
+	}
+
+	serializer := influx.NewSerializer()
+	if i.UintSupport {
+		serializer.SetFieldTypeSupport(influx.UintSupport)
+	}
+
+	i.clients = i.clients[:0]
+	for _, u := range i.URLs {
+		parts, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("error parsing url (%s): %w", u, err)
+		}
+		switch parts.Scheme {
+		case "http", "https":
+		default:
+			return fmt.Errorf("unsupported scheme (%s): %q", u, parts.Scheme)
+		}
+
+		client, err := newHTTPClient(&httpConfig{
+			URL:              parts,
+			Token:            i.Token,
+			Organization:     i.Organization,
+			Bucket:           i.Bucket,
+			BucketTag:        i.BucketTag,
+			ExcludeBucketTag: i.ExcludeBucketTag,
+			Timeout:          i.Timeout.Duration,
+			Headers:          i.HTTPHeaders,
+			Proxy:            proxy,
+			UserAgent:        i.UserAgent,
+			ContentEncoding:  i.ContentEncoding,
+			TLSConfig:        tlsConfig,
+			Serializer:       serializer,
+			Log:              i.Log,
+		})
+		if err != nil {
+			return err
+		}
+		i.clients = append(i.clients, client)
+	}
+
+	return nil
+}
+
+func (i *InfluxDB) Close() error {
+	for _, client := range i.clients {
+		client.Close()
+	}
+	return nil
+}
+
+// Write sends metrics to one of the configured servers, logging each
+// unsuccessful. If all servers fail, return an error.
+func (i *InfluxDB) Write(metrics []cua.Metric) (int, error) {
+	ctx := context.Background()
+
+	var err error
+	for _, n := range rand.Perm(len(i.clients)) {
+		client := i.clients[n]
+		err = client.Write(ctx, metrics)
+		if err == nil {
+			return len(metrics), nil
+		}
+
+		i.Log.Errorf("When writing to [%s]: %v", client.URL, err)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == 413 {
+			// the request is too large for any server
+			break
+		}
+	}
+
+	return 0, fmt.Errorf("could not write any address: %w", err)
+}
+
+func init() {
+	outputs.Add("influxdb_v2", func() cua.Output {
+		return &InfluxDB{
+			Timeout:         internal.Duration{Duration: defaultTimeout},
+			ContentEncoding: "gzip",
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package influxdbv2
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type write struct {
+	bucket string
+	org    string
+	body   string
+}
+
+type server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	writes []write
+	status func(body string) (int, http.Header)
+}
+
+func newServer(t *testing.T) *server {
+	s := &server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v2/write", r.URL.Path)
+		require.Equal(t, "Token secret", r.Header.Get("Authorization"))
+
+		body := r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = gz
+		}
+		data, err := ioutil.ReadAll(body)
+		require.NoError(t, err)
+
+		if s.status != nil {
+			code, header := s.status(string(data))
+			if code != http.StatusNoContent {
+				for k, v := range header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(code)
+				_, _ = w.Write([]byte(`{"code":"invalid","message":"rejected"}`))
+				return
+			}
+		}
+
+		s.mu.Lock()
+		s.writes = append(s.writes, write{
+			bucket: r.URL.Query().Get("bucket"),
+			org:    r.URL.Query().Get("org"),
+			body:   string(data),
+		})
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newInfluxDB(t *testing.T, url string) *InfluxDB {
+	i := &InfluxDB{
+		URLs:            []string{url},
+		Token:           "secret",
+		Organization:    "org",
+		Bucket:          "default",
+		ContentEncoding: "gzip",
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, i.Connect())
+	t.Cleanup(func() { _ = i.Close() })
+	return i
+}
+
+func testMetrics() []cua.Metric {
+	return []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"bucket": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"bucket": "a"}, map[string]interface{}{"value": 3.0}, time.Unix(0, 0)),
+	}
+}
+
+func TestWrite(t *testing.T) {
+	s := newServer(t)
+	i := newInfluxDB(t, s.URL)
+
+	_, err := i.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, []write{{
+		bucket: "default",
+		org:    "org",
+		body:   "cpu,bucket=a value=1 0\ncpu value=2 0\ncpu,bucket=a value=3 0\n",
+	}}, s.writes)
+}
+
+func TestWriteBucketTag(t *testing.T) {
+	s := newServer(t)
+	i := newInfluxDB(t, s.URL)
+	i.BucketTag = "bucket"
+	i.ExcludeBucketTag = true
+	require.NoError(t, i.Connect())
+
+	metrics := testMetrics()
+	_, err := i.Write(metrics)
+	require.NoError(t, err)
+	require.Equal(t, []write{
+		{bucket: "a", org: "org", body: "cpu value=1 0\ncpu value=3 0\n"},
+		{bucket: "default", org: "org", body: "cpu value=2 0\n"},
+	}, s.writes)
+
+	// the metrics given to the output are left unchanged
+	require.True(t, metrics[0].HasTag("bucket"))
+}
+
+func TestWriteRetryAfter(t *testing.T) {
+	s := newServer(t)
+	s.status = func(string) (int, http.Header) {
+		return http.StatusTooManyRequests, http.Header{"Retry-After": []string{"30"}}
+	}
+	i := newInfluxDB(t, s.URL)
+
+	_, err := i.Write(testMetrics())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "retrying in 30s")
+
+	// the server is not requested again before the retry time
+	s.status = nil
+	_, err = i.Write(testMetrics())
+	require.Error(t, err)
+	require.Empty(t, s.writes)
+
+	i.clients[0].retryAt = time.Now()
+	_, err = i.Write(testMetrics())
+	require.NoError(t, err)
+	require.Len(t, s.writes, 1)
+}
+
+func TestWriteTooLarge(t *testing.T) {
+	s := newServer(t)
+	s.status = func(body string) (int, http.Header) {
+		if strings.Count(body, "\n") > 1 {
+			return http.StatusRequestEntityTooLarge, nil
+		}
+		return http.StatusNoContent, nil
+	}
+	i := newInfluxDB(t, s.URL)
+
+	_, err := i.Write(testMetrics())
+	require.NoError(t, err)
+	require.Len(t, s.writes, 3)
+}
+
+func TestWriteBadRequest(t *testing.T) {
+	s := newServer(t)
+	s.status = func(string) (int, http.Header) {
+		return http.StatusBadRequest, nil
+	}
+	i := newInfluxDB(t, s.URL)
+
+	// rejected data is dropped instead of being sent again
+	_, err := i.Write(testMetrics())
+	require.NoError(t, err)
+}
+
+func TestWriteServerError(t *testing.T) {
+	s := newServer(t)
+	s.status = func(string) (int, http.Header) {
+		return http.StatusInternalServerError, nil
+	}
+	i := newInfluxDB(t, s.URL)
+
+	_, err := i.Write(testMetrics())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rejected")
+}
+
+func TestRetryAfter(t *testing.T) {
+	require.Equal(t, defaultRetryWait, retryAfter(""))
+	require.Equal(t, 10*time.Second, retryAfter("10"))
+	require.Equal(t, defaultMaxRetryWait, retryAfter("3600"))
+	require.Equal(t, defaultRetryWait, retryAfter("soon"))
+}
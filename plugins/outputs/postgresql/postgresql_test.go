@@ -0,0 +1,217 @@
+package postgresql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type copied struct {
+	table   string
+	columns []string
+	rows    [][]interface{}
+}
+
+type fakeStore struct {
+	tables  map[string]map[string]string
+	execs   []string
+	copies  []copied
+	copyErr error
+}
+
+func (s *fakeStore) exec(sql string) error {
+	s.execs = append(s.execs, sql)
+	return nil
+}
+
+func (s *fakeStore) columns(_, table string) (map[string]string, error) {
+	columns := make(map[string]string)
+	for k, v := range s.tables[table] {
+		columns[k] = v
+	}
+	return columns, nil
+}
+
+func (s *fakeStore) copy(_, table string, columns []string, rows [][]interface{}) error {
+	if s.copyErr != nil {
+		return s.copyErr
+	}
+	s.copies = append(s.copies, copied{table: table, columns: columns, rows: rows})
+	return nil
+}
+
+func (s *fakeStore) close() {}
+
+func newPostgresql(db *fakeStore) *Postgresql {
+	return &Postgresql{
+		Schema:       "public",
+		SchemaType:   schemaWide,
+		NarrowTable:  "metrics",
+		TagPrefix:    "tag_",
+		CreateTables: true,
+		AddColumns:   true,
+		Log:          testutil.Logger{},
+		db:           db,
+	}
+}
+
+var now = time.Unix(1600000000, 0)
+
+func testMetrics() []cua.Metric {
+	return []cua.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "web01"},
+			map[string]interface{}{"usage_idle": 91.5},
+			now),
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "web02", "cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 50.0, "state": "ok"},
+			now),
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"used": int64(42)},
+			now),
+		testutil.MustMetric("swap",
+			map[string]string{},
+			map[string]interface{}{"enabled": true},
+			now),
+	}
+}
+
+func TestWriteWideCreate(t *testing.T) {
+	db := &fakeStore{}
+	p := newPostgresql(db)
+	p.CreateHypertable = true
+	require.NoError(t, p.Connect())
+
+	_, err := p.Write(testMetrics())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		`CREATE TABLE IF NOT EXISTS "public"."cpu" ("time" timestamp with time zone NOT NULL, "tag_host" text, "usage_idle" double precision, "tag_cpu" text, "state" text)`,
+		`SELECT create_hypertable('"public"."cpu"', 'time', if_not_exists => TRUE)`,
+		`CREATE TABLE IF NOT EXISTS "public"."mem" ("time" timestamp with time zone NOT NULL, "used" bigint)`,
+		`SELECT create_hypertable('"public"."mem"', 'time', if_not_exists => TRUE)`,
+		`CREATE TABLE IF NOT EXISTS "public"."swap" ("time" timestamp with time zone NOT NULL, "enabled" boolean)`,
+		`SELECT create_hypertable('"public"."swap"', 'time', if_not_exists => TRUE)`,
+	}, db.execs)
+
+	require.Equal(t, []copied{
+		{
+			table:   "cpu",
+			columns: []string{"time", "tag_host", "usage_idle", "tag_cpu", "state"},
+			rows: [][]interface{}{
+				{now, "web01", 91.5, nil, nil},
+				{now, "web02", 50.0, "cpu0", "ok"},
+			},
+		},
+		{
+			table:   "mem",
+			columns: []string{"time", "used"},
+			rows:    [][]interface{}{{now, int64(42)}},
+		},
+		{
+			table:   "swap",
+			columns: []string{"time", "enabled"},
+			rows:    [][]interface{}{{now, true}},
+		},
+	}, db.copies)
+
+	// the created tables are cached
+	db.execs = nil
+	_, err = p.Write(testMetrics())
+	require.NoError(t, err)
+	require.Empty(t, db.execs)
+}
+
+func TestWriteWideAddColumns(t *testing.T) {
+	db := &fakeStore{tables: map[string]map[string]string{
+		"cpu": {"time": "timestamp with time zone", "tag_host": "text", "usage_idle": "bigint"},
+	}}
+	p := newPostgresql(db)
+	require.NoError(t, p.Connect())
+
+	_, err := p.Write(testMetrics()[:2])
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`ALTER TABLE "public"."cpu" ADD COLUMN IF NOT EXISTS "state" text, ADD COLUMN IF NOT EXISTS "tag_cpu" text`,
+	}, db.execs)
+
+	// the double values are not written in the bigint column
+	require.Equal(t, [][]interface{}{
+		{now, "web01", nil, nil, nil},
+		{now, "web02", nil, "cpu0", "ok"},
+	}, db.copies[0].rows)
+}
+
+func TestWriteWideNoAddColumns(t *testing.T) {
+	db := &fakeStore{tables: map[string]map[string]string{
+		"cpu": {"time": "timestamp with time zone", "tag_host": "text", "usage_idle": "double precision"},
+	}}
+	p := newPostgresql(db)
+	p.AddColumns = false
+	require.NoError(t, p.Connect())
+
+	_, err := p.Write(testMetrics()[:2])
+	require.NoError(t, err)
+	require.Empty(t, db.execs)
+	require.Equal(t, copied{
+		table:   "cpu",
+		columns: []string{"time", "tag_host", "usage_idle"},
+		rows: [][]interface{}{
+			{now, "web01", 91.5},
+			{now, "web02", 50.0},
+		},
+	}, db.copies[0])
+}
+
+func TestWriteNoCreateTables(t *testing.T) {
+	db := &fakeStore{}
+	p := newPostgresql(db)
+	p.CreateTables = false
+	require.NoError(t, p.Connect())
+
+	_, err := p.Write(testMetrics())
+	require.Error(t, err)
+}
+
+func TestWriteNarrow(t *testing.T) {
+	db := &fakeStore{}
+	p := newPostgresql(db)
+	p.SchemaType = schemaNarrow
+	require.NoError(t, p.Connect())
+
+	_, err := p.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`CREATE TABLE IF NOT EXISTS "public"."metrics" ("time" timestamp with time zone NOT NULL, "measurement" text, "tags" jsonb, "field" text, "value" double precision)`,
+	}, db.execs)
+	require.Equal(t, [][]interface{}{
+		{now, "cpu", `{"host":"web01"}`, "usage_idle", 91.5},
+		{now, "cpu", `{"cpu":"cpu0","host":"web02"}`, "usage_idle", 50.0},
+		{now, "mem", `{}`, "used", 42.0},
+		{now, "swap", `{}`, "enabled", 1.0},
+	}, db.copies[0].rows)
+}
+
+func TestWriteCopyError(t *testing.T) {
+	db := &fakeStore{copyErr: errors.New("connection reset")}
+	p := newPostgresql(db)
+	require.NoError(t, p.Connect())
+
+	_, err := p.Write(testMetrics())
+	require.Error(t, err)
+
+	// the columns are read again on the next write
+	require.NotContains(t, p.tables, "cpu")
+}
+
+func TestConnectSchemaType(t *testing.T) {
+	p := newPostgresql(&fakeStore{})
+	p.SchemaType = "tall"
+	require.Error(t, p.Connect())
+}
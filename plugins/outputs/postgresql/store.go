@@ -0,0 +1,62 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx"
+)
+
+// store is the database the metrics are written to
+type store interface {
+	exec(sql string) error
+	// columns returns the column types of a table, none when the table does
+	// not exist
+	columns(schema, table string) (map[string]string, error)
+	copy(schema, table string, columns []string, rows [][]interface{}) error
+	close()
+}
+
+type pgStore struct {
+	pool *pgx.ConnPool
+}
+
+func (s *pgStore) exec(sql string) error {
+	if _, err := s.pool.Exec(sql); err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+	return nil
+}
+
+func (s *pgStore) columns(schema, table string) (map[string]string, error) {
+	rows, err := s.pool.Query(
+		"SELECT column_name, data_type FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2",
+		schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]string)
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		columns[name] = typ
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return columns, nil
+}
+
+func (s *pgStore) copy(schema, table string, columns []string, rows [][]interface{}) error {
+	if _, err := s.pool.CopyFrom(pgx.Identifier{schema, table}, columns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("copy from: %w", err)
+	}
+	return nil
+}
+
+func (s *pgStore) close() {
+	s.pool.Close()
+}
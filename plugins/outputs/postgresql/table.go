@@ -0,0 +1,222 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/jackc/pgx"
+)
+
+const timeColumn = "time"
+
+// batch holds the rows copied into a table
+type batch struct {
+	table   string
+	columns []string
+	types   []string
+	rows    [][]interface{}
+}
+
+// clear writes no value in a column
+func (b *batch) clear(i int) {
+	for _, row := range b.rows {
+		row[i] = nil
+	}
+}
+
+// drop removes columns, the indexes are in increasing order
+func (b *batch) drop(indexes []int) {
+	keep := make([]bool, len(b.columns))
+	for i := range keep {
+		keep[i] = true
+	}
+	for _, i := range indexes {
+		keep[i] = false
+	}
+
+	filter := func(values []interface{}) []interface{} {
+		out := values[:0]
+		for i, v := range values {
+			if keep[i] {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	columns := make([]string, 0, len(b.columns)-len(indexes))
+	types := make([]string, 0, len(b.columns)-len(indexes))
+	for i := range b.columns {
+		if keep[i] {
+			columns = append(columns, b.columns[i])
+			types = append(types, b.types[i])
+		}
+	}
+	for i := range b.rows {
+		b.rows[i] = filter(b.rows[i])
+	}
+	b.columns, b.types = columns, types
+}
+
+// wideBatches groups the metrics by measurement, with the columns of all the
+// tags and fields of the measurement
+func (p *Postgresql) wideBatches(metrics []cua.Metric) []*batch {
+	var batches []*batch
+	byTable := make(map[string]*batch)
+	indexes := make(map[string]map[string]int)
+
+	for _, m := range metrics {
+		b, ok := byTable[m.Name()]
+		if !ok {
+			b = &batch{
+				table:   m.Name(),
+				columns: []string{timeColumn},
+				types:   []string{"timestamp with time zone"},
+			}
+			byTable[m.Name()] = b
+			indexes[m.Name()] = map[string]int{}
+			batches = append(batches, b)
+		}
+		index := indexes[m.Name()]
+
+		column := func(name, typ string) int {
+			i, ok := index[name]
+			if !ok {
+				i = len(b.columns)
+				index[name] = i
+				b.columns = append(b.columns, name)
+				b.types = append(b.types, typ)
+			}
+			return i
+		}
+
+		row := map[int]interface{}{}
+		for _, tag := range m.TagList() {
+			row[column(p.TagPrefix+tag.Key, "text")] = tag.Value
+		}
+		for _, field := range m.FieldList() {
+			typ, value, ok := columnValue(field.Value)
+			if !ok {
+				continue
+			}
+			i := column(field.Key, typ)
+			if b.types[i] != typ {
+				// a field changing type is written in the column type
+				continue
+			}
+			row[i] = value
+		}
+
+		values := make([]interface{}, len(b.columns))
+		values[0] = m.Time()
+		for i, v := range row {
+			values[i] = v
+		}
+		b.rows = append(b.rows, values)
+	}
+
+	// earlier rows miss the columns added after them
+	for _, b := range batches {
+		for i, row := range b.rows {
+			if len(row) < len(b.columns) {
+				b.rows[i] = append(row, make([]interface{}, len(b.columns)-len(row))...)
+			}
+		}
+	}
+	return batches
+}
+
+// narrowBatch has a row per numeric field
+func (p *Postgresql) narrowBatch(metrics []cua.Metric) *batch {
+	b := &batch{
+		table:   p.NarrowTable,
+		columns: []string{timeColumn, "measurement", "tags", "field", "value"},
+		types:   []string{"timestamp with time zone", "text", "jsonb", "text", "double precision"},
+	}
+	for _, m := range metrics {
+		tags, err := json.Marshal(m.Tags())
+		if err != nil {
+			p.Log.Errorf("Could not serialize the tags of %s: %v", m.Name(), err)
+			continue
+		}
+		for _, field := range m.FieldList() {
+			value, ok := floatValue(field.Value)
+			if !ok {
+				continue
+			}
+			b.rows = append(b.rows, []interface{}{m.Time(), m.Name(), string(tags), field.Key, value})
+		}
+	}
+	return b
+}
+
+// columnValue maps a field value to its column type
+func columnValue(v interface{}) (string, interface{}, bool) {
+	switch v := v.(type) {
+	case int64:
+		return "bigint", v, true
+	case uint64:
+		if v > math.MaxInt64 {
+			return "bigint", int64(math.MaxInt64), true
+		}
+		return "bigint", int64(v), true
+	case float64:
+		return "double precision", v, true
+	case bool:
+		return "boolean", v, true
+	case string:
+		return "text", v, true
+	default:
+		return "", nil, false
+	}
+}
+
+func floatValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+func quoteIdent(parts ...string) string {
+	return pgx.Identifier(parts).Sanitize()
+}
+
+func createTableSQL(schema, table string, columns, types []string) string {
+	defs := make([]string, len(columns))
+	for i := range columns {
+		defs[i] = quoteIdent(columns[i]) + " " + types[i]
+		if columns[i] == timeColumn {
+			defs[i] += " NOT NULL"
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quoteIdent(schema, table), strings.Join(defs, ", "))
+}
+
+func addColumnsSQL(schema, table string, columns, types []string) string {
+	defs := make([]string, len(columns))
+	for i := range columns {
+		defs[i] = "ADD COLUMN IF NOT EXISTS " + quoteIdent(columns[i]) + " " + types[i]
+	}
+	sort.Strings(defs)
+	return fmt.Sprintf("ALTER TABLE %s %s", quoteIdent(schema, table), strings.Join(defs, ", "))
+}
+
+func createHypertableSQL(schema, table string) string {
+	return fmt.Sprintf("SELECT create_hypertable('%s', '%s', if_not_exists => TRUE)",
+		strings.ReplaceAll(quoteIdent(schema, table), "'", "''"), timeColumn)
+}
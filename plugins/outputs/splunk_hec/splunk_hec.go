@@ -0,0 +1,378 @@
+package splunkhec
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+)
+
+const (
+	defaultURL         = "https://localhost:8088"
+	defaultTimeout     = 5 * time.Second
+	defaultAckTimeout  = 30 * time.Second
+	defaultAckInterval = time.Second
+)
+
+type SplunkHEC struct {
+	URL             string            `toml:"url"`
+	Token           string            `toml:"token"`
+	Index           string            `toml:"index"`
+	Source          string            `toml:"source"`
+	SourceType      string            `toml:"sourcetype"`
+	HostTag         string            `toml:"host_tag"`
+	MultiMetric     bool              `toml:"multi_metric"`
+	UseAck          bool              `toml:"use_ack"`
+	Channel         string            `toml:"channel"`
+	AckTimeout      internal.Duration `toml:"ack_timeout"`
+	AckInterval     internal.Duration `toml:"ack_interval"`
+	Timeout         internal.Duration `toml:"timeout"`
+	ContentEncoding string            `toml:"content_encoding"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	client  *http.Client
+	encoder internal.ContentEncoder
+	event   *url.URL
+	ack     *url.URL
+}
+
+var sampleConfig = `
+  ## URL of the HTTP Event Collector.
+  url = "https://localhost:8088"
+
+  ## HEC token.
+  token = ""
+
+  ## Optional metadata of the events, the defaults of the token are used
+  ## when not set.
+  # index = ""
+  # source = ""
+  # sourcetype = ""
+
+  ## Tag used as the host of the events, the tag is left out of the dimensions.
+  # host_tag = "host"
+
+  ## Send the fields of a metric as a single multiple-metric event
+  ## (Splunk 8.0+), instead of an event per field.
+  # multi_metric = false
+
+  ## Wait for the indexer acknowledgment of the events, the token must have
+  ## indexer acknowledgment enabled. The channel defaults to a random GUID.
+  # use_ack = false
+  # channel = ""
+  # ack_timeout = "30s"
+  # ack_interval = "1s"
+
+  ## Timeout for HTTP requests.
+  # timeout = "5s"
+
+  ## Content-Encoding for write request body, can be set to "gzip" to
+  ## compress body or "identity" to apply no encoding.
+  # content_encoding = "gzip"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (s *SplunkHEC) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SplunkHEC) Description() string {
+	return "Send metrics to a Splunk HTTP Event Collector"
+}
+
+func (s *SplunkHEC) Connect() error {
+	if s.Token == "" {
+		return fmt.Errorf(`"token" is required`)
+	}
+
+	base, err := url.Parse(s.URL)
+	if err != nil {
+		return fmt.Errorf("error parsing url (%s): %w", s.URL, err)
+	}
+	event := *base
+	event.Path = path.Join(event.Path, "/services/collector/event")
+	s.event = &event
+	ack := *base
+	ack.Path = path.Join(ack.Path, "/services/collector/ack")
+	s.ack = &ack
+
+	if s.UseAck && s.Channel == "" {
+		s.Channel, err = newGUID()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.encoder, err = internal.NewContentEncoder(s.ContentEncoding)
+	if err != nil {
+		return fmt.Errorf("content encoding (%s): %w", s.ContentEncoding, err)
+	}
+
+	tlsConfig, err := s.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	s.client = &http.Client{
+		Timeout: s.Timeout.Duration,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	return nil
+}
+
+func (s *SplunkHEC) Close() error {
+	if s.client != nil {
+		s.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// hecEvent is a metric event of the HTTP Event Collector
+type hecEvent struct {
+	Time       float64                `json:"time"`
+	Event      string                 `json:"event"`
+	Host       string                 `json:"host,omitempty"`
+	Index      string                 `json:"index,omitempty"`
+	Source     string                 `json:"source,omitempty"`
+	SourceType string                 `json:"sourcetype,omitempty"`
+	Fields     map[string]interface{} `json:"fields"`
+}
+
+func (s *SplunkHEC) Write(metrics []cua.Metric) (int, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, m := range metrics {
+		for _, event := range s.events(m) {
+			if err := enc.Encode(event); err != nil {
+				s.Log.Errorf("Could not serialize metric %s: %v", m.Name(), err)
+			}
+		}
+	}
+	if body.Len() == 0 {
+		return 0, nil
+	}
+
+	ackID, err := s.send(body.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	if s.UseAck {
+		if err := s.waitAck(ackID); err != nil {
+			return 0, err
+		}
+	}
+	return len(metrics), nil
+}
+
+// events converts a metric into the metric events of its numeric fields
+func (s *SplunkHEC) events(m cua.Metric) []*hecEvent {
+	dimensions := make(map[string]interface{})
+	host := ""
+	for _, tag := range m.TagList() {
+		if s.HostTag != "" && tag.Key == s.HostTag {
+			host = tag.Value
+			continue
+		}
+		dimensions[tag.Key] = tag.Value
+	}
+
+	newEvent := func() *hecEvent {
+		fields := make(map[string]interface{}, len(dimensions)+2)
+		for k, v := range dimensions {
+			fields[k] = v
+		}
+		return &hecEvent{
+			Time:       float64(m.Time().UnixNano()/int64(time.Millisecond)) / 1000,
+			Event:      "metric",
+			Host:       host,
+			Index:      s.Index,
+			Source:     s.Source,
+			SourceType: s.SourceType,
+			Fields:     fields,
+		}
+	}
+
+	var events []*hecEvent
+	var multi *hecEvent
+	for _, field := range m.FieldList() {
+		value, ok := metricValue(field.Value)
+		if !ok {
+			continue
+		}
+		name := m.Name() + "." + field.Key
+		if s.MultiMetric {
+			if multi == nil {
+				multi = newEvent()
+				events = append(events, multi)
+			}
+			multi.Fields["metric_name:"+name] = value
+			continue
+		}
+		event := newEvent()
+		event.Fields["metric_name"] = name
+		event.Fields["_value"] = value
+		events = append(events, event)
+	}
+	return events
+}
+
+// metricValue is the numeric value of a field, splunk metrics can not hold
+// strings
+func metricValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case int64, uint64:
+		return v, true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return nil, false
+	}
+}
+
+type hecResponse struct {
+	Text  string `json:"text"`
+	Code  int    `json:"code"`
+	AckID *int64 `json:"ackId"`
+}
+
+func (s *SplunkHEC) send(body []byte) (int64, error) {
+	encoded, err := s.encoder.Encode(body)
+	if err != nil {
+		return 0, fmt.Errorf("encode: %w", err)
+	}
+
+	var resp hecResponse
+	if err := s.request(s.event.String(), encoded, true, &resp); err != nil {
+		return 0, err
+	}
+	if s.UseAck && resp.AckID == nil {
+		return 0, fmt.Errorf("no ackId in the response, indexer acknowledgment is not enabled for the token")
+	}
+	if resp.AckID == nil {
+		return 0, nil
+	}
+	return *resp.AckID, nil
+}
+
+// waitAck polls the acknowledgment of the events until they are indexed
+func (s *SplunkHEC) waitAck(ackID int64) error {
+	body, err := json.Marshal(map[string][]int64{"acks": {ackID}})
+	if err != nil {
+		return fmt.Errorf("marshal acks: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.AckTimeout.Duration)
+	defer cancel()
+
+	for {
+		var resp struct {
+			Acks map[string]bool `json:"acks"`
+		}
+		if err := s.request(s.ack.String(), body, false, &resp); err != nil {
+			return err
+		}
+		if resp.Acks[strconv.FormatInt(ackID, 10)] {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("events of ack %d not indexed after %s", ackID, s.AckTimeout.Duration)
+		case <-time.After(s.AckInterval.Duration):
+		}
+	}
+}
+
+func (s *SplunkHEC) request(u string, body []byte, encoded bool, v interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Authorization", "Splunk "+s.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", internal.ProductToken())
+	if encoded && s.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if s.Channel != "" {
+		req.Header.Set("X-Splunk-Request-Channel", s.Channel)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("http do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var hecResp hecResponse
+		if json.Unmarshal(data, &hecResp) == nil && hecResp.Text != "" {
+			return fmt.Errorf("%s: %s (code %d)", resp.Status, hecResp.Text, hecResp.Code)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// newGUID returns a random (version 4) GUID
+func newGUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("channel: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func init() {
+	outputs.Add("splunk_hec", func() cua.Output {
+		return &SplunkHEC{
+			URL:             defaultURL,
+			HostTag:         "host",
+			AckTimeout:      internal.Duration{Duration: defaultAckTimeout},
+			AckInterval:     internal.Duration{Duration: defaultAckInterval},
+			Timeout:         internal.Duration{Duration: defaultTimeout},
+			ContentEncoding: "gzip",
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package splunkhec
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type collector struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	events  []map[string]interface{}
+	channel string
+	// ack polls answered with false before the ack
+	pending int
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Splunk secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"text":"Invalid token","code":4}`))
+			return
+		}
+		c.channel = r.Header.Get("X-Splunk-Request-Channel")
+
+		switch r.URL.Path {
+		case "/services/collector/event":
+			var body io.Reader = r.Body
+			if r.Header.Get("Content-Encoding") == "gzip" {
+				gz, err := gzip.NewReader(r.Body)
+				require.NoError(t, err)
+				body = gz
+			}
+			dec := json.NewDecoder(body)
+			for dec.More() {
+				event := map[string]interface{}{}
+				require.NoError(t, dec.Decode(&event))
+				c.events = append(c.events, event)
+			}
+			_, _ = w.Write([]byte(`{"text":"Success","code":0,"ackId":7}`))
+		case "/services/collector/ack":
+			var req struct {
+				Acks []int64 `json:"acks"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, []int64{7}, req.Acks)
+			if c.pending > 0 {
+				c.pending--
+				_, _ = w.Write([]byte(`{"acks":{"7":false}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"acks":{"7":true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func newSplunkHEC(t *testing.T, url string) *SplunkHEC {
+	s := &SplunkHEC{
+		URL:             url,
+		Token:           "secret",
+		Index:           "metrics",
+		SourceType:      "agent",
+		HostTag:         "host",
+		AckTimeout:      internal.Duration{Duration: time.Second},
+		AckInterval:     internal.Duration{Duration: 10 * time.Millisecond},
+		Timeout:         internal.Duration{Duration: time.Second},
+		ContentEncoding: "gzip",
+		Log:             testutil.Logger{},
+	}
+	return s
+}
+
+func testMetrics() []cua.Metric {
+	return []cua.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "web01", "cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 91.5, "usage_user": int64(2), "state": "ok"},
+			time.Unix(1600000000, 500*int64(time.Millisecond))),
+	}
+}
+
+func TestWrite(t *testing.T) {
+	c := newCollector(t)
+	s := newSplunkHEC(t, c.URL)
+	require.NoError(t, s.Connect())
+
+	_, err := s.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{
+			"time":       1600000000.5,
+			"event":      "metric",
+			"host":       "web01",
+			"index":      "metrics",
+			"sourcetype": "agent",
+			"fields":     map[string]interface{}{"cpu": "cpu0", "metric_name": "cpu.usage_idle", "_value": 91.5},
+		},
+		{
+			"time":       1600000000.5,
+			"event":      "metric",
+			"host":       "web01",
+			"index":      "metrics",
+			"sourcetype": "agent",
+			"fields":     map[string]interface{}{"cpu": "cpu0", "metric_name": "cpu.usage_user", "_value": 2.0},
+		},
+	}, c.events)
+	require.Empty(t, c.channel)
+}
+
+func TestWriteMultiMetric(t *testing.T) {
+	c := newCollector(t)
+	s := newSplunkHEC(t, c.URL)
+	s.MultiMetric = true
+	require.NoError(t, s.Connect())
+
+	_, err := s.Write(testMetrics())
+	require.NoError(t, err)
+	require.Len(t, c.events, 1)
+	require.Equal(t, map[string]interface{}{
+		"cpu":                        "cpu0",
+		"metric_name:cpu.usage_idle": 91.5,
+		"metric_name:cpu.usage_user": 2.0,
+	}, c.events[0]["fields"])
+}
+
+func TestWriteAck(t *testing.T) {
+	c := newCollector(t)
+	c.pending = 2
+	s := newSplunkHEC(t, c.URL)
+	s.UseAck = true
+	require.NoError(t, s.Connect())
+	require.Len(t, s.Channel, 36)
+
+	_, err := s.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, s.Channel, c.channel)
+	require.Zero(t, c.pending)
+}
+
+func TestWriteAckTimeout(t *testing.T) {
+	c := newCollector(t)
+	c.pending = 1000
+	s := newSplunkHEC(t, c.URL)
+	s.UseAck = true
+	s.AckTimeout.Duration = 50 * time.Millisecond
+	require.NoError(t, s.Connect())
+
+	_, err := s.Write(testMetrics())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not indexed")
+}
+
+func TestWriteInvalidToken(t *testing.T) {
+	c := newCollector(t)
+	s := newSplunkHEC(t, c.URL)
+	s.Token = "invalid"
+	require.NoError(t, s.Connect())
+
+	_, err := s.Write(testMetrics())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Invalid token")
+}
+
+func TestGUID(t *testing.T) {
+	guid, err := newGUID()
+	require.NoError(t, err)
+	require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, guid)
+}
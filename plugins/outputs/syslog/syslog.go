@@ -0,0 +1,311 @@
+package syslog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	framing "github.com/circonus-labs/circonus-unified-agent/internal/syslog"
+	tlsint "github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/influxdata/go-syslog/v2/nontransparent"
+	"github.com/influxdata/go-syslog/v2/rfc5424"
+)
+
+const (
+	defaultAddress  = "tcp://127.0.0.1:6514"
+	defaultTimeout  = 5 * time.Second
+	defaultAppname  = "cua"
+	defaultSDID     = "metric@32473"
+	defaultSeverity = 5 // notice
+	defaultFacility = 1 // user-level
+
+	// RFC5424 timestamps have at most microsecond precision
+	timestampFormat = "2006-01-02T15:04:05.999999Z07:00"
+
+	// maximum length of a structured data parameter name (RFC5424 section 6)
+	maxParamNameLength = 32
+)
+
+type Syslog struct {
+	Address             string                     `toml:"address"`
+	Framing             framing.Framing            `toml:"framing"`
+	Trailer             nontransparent.TrailerType `toml:"trailer"`
+	DefaultSeverityCode uint8                      `toml:"default_severity_code"`
+	DefaultFacilityCode uint8                      `toml:"default_facility_code"`
+	DefaultAppname      string                     `toml:"default_appname"`
+	DefaultSDID         string                     `toml:"default_sdid"`
+	HostnameTag         string                     `toml:"hostname_tag"`
+	MessageField        string                     `toml:"message_field"`
+	Timeout             internal.Duration          `toml:"timeout"`
+	tlsint.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	network   string
+	host      string
+	tlsConfig *tls.Config
+	conn      net.Conn
+}
+
+var sampleConfig = `
+  ## URL to the syslog server, the scheme is one of tcp, tcp4, tcp6, udp,
+  ## udp4, udp6 or tls.
+  # address = "tcp://127.0.0.1:6514"
+
+  ## Framing technique used on stream transports, either "octet-counting"
+  ## (RFC5425) or "non-transparent" (RFC6587), ignored for udp.
+  # framing = "octet-counting"
+
+  ## The trailer appended to each message with non-transparent framing,
+  ## either "LF" or "NUL".
+  # trailer = "LF"
+
+  ## Severity and facility of the messages, see RFC5424 section 6.2.1.
+  # default_severity_code = 5
+  # default_facility_code = 1
+
+  ## APP-NAME of the messages
+  # default_appname = "cua"
+
+  ## Structured data element id holding the tags and fields of the metric,
+  ## custom ids have the form name@<private enterprise number>.
+  # default_sdid = "metric@32473"
+
+  ## Tag used as the HOSTNAME of the messages, the tag is not repeated in the
+  ## structured data.
+  # hostname_tag = "host"
+
+  ## String field used as the MSG of the messages, the field is not repeated
+  ## in the structured data.
+  # message_field = "msg"
+
+  ## Timeout for the connection and the writes
+  # timeout = "5s"
+
+  ## Optional TLS Config, used with the tls scheme
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (s *Syslog) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Syslog) Description() string {
+	return "Configuration for Syslog server to send metrics to"
+}
+
+func (s *Syslog) Connect() error {
+	if s.Address == "" {
+		s.Address = defaultAddress
+	}
+	if s.Timeout.Duration == 0 {
+		s.Timeout.Duration = defaultTimeout
+	}
+	if s.DefaultSeverityCode > 7 {
+		return fmt.Errorf("invalid default_severity_code %d", s.DefaultSeverityCode)
+	}
+	if s.DefaultFacilityCode > 23 {
+		return fmt.Errorf("invalid default_facility_code %d", s.DefaultFacilityCode)
+	}
+	if _, err := s.Framing.MarshalText(); err != nil {
+		return fmt.Errorf("framing: %w", err)
+	}
+	if _, err := s.Trailer.Value(); err != nil {
+		return fmt.Errorf("trailer: %w", err)
+	}
+
+	u, err := url.Parse(s.Address)
+	if err != nil {
+		return fmt.Errorf("parse address (%s): %w", s.Address, err)
+	}
+	switch u.Scheme {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		s.network = u.Scheme
+	case "tls":
+		s.network = "tcp"
+		tlsConfig, err := s.ClientConfig.TLSConfig()
+		if err != nil {
+			return fmt.Errorf("TLSConfig: %w", err)
+		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{} //nolint:gosec
+			if err := tlsint.ApplyPolicy(tlsConfig); err != nil {
+				return fmt.Errorf("TLSConfig: %w", err)
+			}
+		}
+		s.tlsConfig = tlsConfig
+	default:
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	s.host = u.Host
+
+	return s.connect()
+}
+
+func (s *Syslog) connect() error {
+	dialer := &net.Dialer{Timeout: s.Timeout.Duration}
+
+	var conn net.Conn
+	var err error
+	if s.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, s.network, s.host, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial(s.network, s.host)
+	}
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *Syslog) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	if err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
+
+// Write sends one syslog message per metric, the connection is closed on a
+// write error and opened again on the next write
+func (s *Syslog) Write(metrics []cua.Metric) (int, error) {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return 0, err
+		}
+	}
+
+	for i, m := range metrics {
+		msg, err := s.message(m)
+		if err != nil {
+			s.Log.Errorf("Could not create syslog message for %s: %v", m.Name(), err)
+			continue
+		}
+
+		if err := s.conn.SetWriteDeadline(time.Now().Add(s.Timeout.Duration)); err != nil {
+			_ = s.Close()
+			return i, fmt.Errorf("set write deadline: %w", err)
+		}
+		if _, err := s.conn.Write(s.frame(msg)); err != nil {
+			_ = s.Close()
+			return i, fmt.Errorf("write: %w", err)
+		}
+	}
+	return len(metrics), nil
+}
+
+// frame adds the framing of stream transports to the message, datagrams
+// hold exactly one message and are left as is
+func (s *Syslog) frame(msg string) []byte {
+	if strings.HasPrefix(s.network, "udp") {
+		return []byte(msg)
+	}
+	if s.Framing == framing.NonTransparent {
+		trailer, _ := s.Trailer.Value()
+		return append([]byte(msg), byte(trailer))
+	}
+	return []byte(strconv.Itoa(len(msg)) + " " + msg)
+}
+
+// message maps the metric to a RFC5424 message, the metric name is the
+// MSGID and the tags and fields are parameters of the structured data
+func (s *Syslog) message(m cua.Metric) (string, error) {
+	msg := &rfc5424.SyslogMessage{}
+	msg.SetPriority(s.DefaultFacilityCode*8 + s.DefaultSeverityCode)
+	msg.SetVersion(1)
+	msg.SetTimestamp(m.Time().UTC().Format(timestampFormat))
+	msg.SetAppname(s.DefaultAppname)
+	msg.SetMsgID(m.Name())
+
+	for _, tag := range m.TagList() {
+		if tag.Key == s.HostnameTag {
+			msg.SetHostname(tag.Value)
+			continue
+		}
+		msg.SetParameter(s.DefaultSDID, paramName(tag.Key), paramValue(tag.Value))
+	}
+	for _, field := range m.FieldList() {
+		if field.Key == s.MessageField {
+			if v, ok := field.Value.(string); ok {
+				msg.SetMessage(v)
+				continue
+			}
+		}
+		msg.SetParameter(s.DefaultSDID, paramName(field.Key), paramValue(formatValue(field.Value)))
+	}
+
+	return msg.String() //nolint:wrapcheck
+}
+
+// paramName replaces the characters not allowed in structured data
+// parameter names and truncates the name to the maximum length
+func paramName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if c <= ' ' || c >= 127 || c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > maxParamNameLength {
+		b = b[:maxParamNameLength]
+	}
+	return string(b)
+}
+
+// paramValue escapes the characters that must be escaped in structured data
+// parameter values, the builder rejects values holding them unescaped
+func paramValue(value string) string {
+	return paramValueEscaper.Replace(value)
+}
+
+var paramValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func init() {
+	outputs.Add("syslog", func() cua.Output {
+		return &Syslog{
+			Address:             defaultAddress,
+			Framing:             framing.OctetCounting,
+			Trailer:             nontransparent.LF,
+			DefaultSeverityCode: defaultSeverity,
+			DefaultFacilityCode: defaultFacility,
+			DefaultAppname:      defaultAppname,
+			DefaultSDID:         defaultSDID,
+			HostnameTag:         "host",
+			MessageField:        "msg",
+			Timeout:             internal.Duration{Duration: defaultTimeout},
+		}
+	})
+}
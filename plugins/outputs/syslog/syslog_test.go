@@ -0,0 +1,181 @@
+package syslog
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	framing "github.com/circonus-labs/circonus-unified-agent/internal/syslog"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/influxdata/go-syslog/v2/nontransparent"
+	"github.com/stretchr/testify/require"
+)
+
+func newSyslog(address string) *Syslog {
+	return &Syslog{
+		Address:             address,
+		Framing:             framing.OctetCounting,
+		Trailer:             nontransparent.LF,
+		DefaultSeverityCode: defaultSeverity,
+		DefaultFacilityCode: defaultFacility,
+		DefaultAppname:      defaultAppname,
+		DefaultSDID:         defaultSDID,
+		HostnameTag:         "host",
+		MessageField:        "msg",
+		Log:                 testutil.Logger{},
+	}
+}
+
+func testMetric() cua.Metric {
+	return testutil.MustMetric(
+		"cpu",
+		map[string]string{"host": "web01", "cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": 91.5, "msg": "cpu usage"},
+		time.Unix(1600000000, 123456789),
+	)
+}
+
+const expected = `<13>1 2020-09-13T12:26:40.123456Z web01 cua - cpu [metric@32473 cpu="cpu0" usage_idle="91.5"] cpu usage`
+
+func TestMessage(t *testing.T) {
+	s := newSyslog("")
+
+	msg, err := s.message(testMetric())
+	require.NoError(t, err)
+	require.Equal(t, expected, msg)
+
+	m := testutil.MustMetric(
+		"disk",
+		map[string]string{"path name": "/", "mode": `r"w]\`},
+		map[string]interface{}{"free": int64(42), "ok": true},
+		time.Unix(1600000000, 0),
+	)
+	msg, err = s.message(m)
+	require.NoError(t, err)
+	require.Equal(t, `<13>1 2020-09-13T12:26:40Z - cua - disk [metric@32473 free="42" mode="r\"w\]\\" ok="true" path_name="/"]`, msg)
+}
+
+func TestParamName(t *testing.T) {
+	require.Equal(t, "a_b_c_d", paramName(`a=b c"d`))
+	require.Len(t, paramName("abcdefghijklmnopqrstuvwxyz0123456789"), maxParamNameLength)
+}
+
+func TestWriteTCP(t *testing.T) {
+	tests := []struct {
+		name    string
+		framing framing.Framing
+		trailer nontransparent.TrailerType
+		want    string
+	}{
+		{
+			name:    "octet counting",
+			framing: framing.OctetCounting,
+			want:    strconv.Itoa(len(expected)) + " " + expected,
+		},
+		{
+			name:    "non-transparent LF",
+			framing: framing.NonTransparent,
+			trailer: nontransparent.LF,
+			want:    expected + "\n",
+		},
+		{
+			name:    "non-transparent NUL",
+			framing: framing.NonTransparent,
+			trailer: nontransparent.NUL,
+			want:    expected + "\x00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer l.Close()
+
+			received := make(chan string, 1)
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				buf := make([]byte, len(tt.want))
+				if _, err := io.ReadFull(conn, buf); err == nil {
+					received <- string(buf)
+				}
+			}()
+
+			s := newSyslog("tcp://" + l.Addr().String())
+			s.Framing = tt.framing
+			s.Trailer = tt.trailer
+			require.NoError(t, s.Connect())
+			defer s.Close()
+
+			n, err := s.Write([]cua.Metric{testMetric()})
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+
+			select {
+			case msg := <-received:
+				require.Equal(t, tt.want, msg)
+			case <-time.After(5 * time.Second):
+				t.Fatal("no message received")
+			}
+		})
+	}
+}
+
+func TestWriteUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	s := newSyslog("udp://" + conn.LocalAddr().String())
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	_, err = s.Write([]cua.Metric{testMetric()})
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(buf[:n]))
+}
+
+func TestWriteReconnects(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := l.Addr().String()
+
+	s := newSyslog("tcp://" + address)
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	// the server going away fails the write and drops the connection
+	s.conn.Close()
+	l.Close()
+	_, err = s.Write([]cua.Metric{testMetric()})
+	require.Error(t, err)
+	require.Nil(t, s.conn)
+
+	l, err = net.Listen("tcp", address)
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, err = s.Write([]cua.Metric{testMetric()})
+	require.NoError(t, err)
+	require.NotNil(t, s.conn)
+}
+
+func TestConnectInvalid(t *testing.T) {
+	s := newSyslog("http://127.0.0.1:6514")
+	require.Error(t, s.Connect())
+
+	s = newSyslog("tcp://127.0.0.1:6514")
+	s.DefaultSeverityCode = 8
+	require.Error(t, s.Connect())
+}
@@ -11,6 +11,8 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/execd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/filepath"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/ifname"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/join"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/math"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/override"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/parser"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/pivot"
@@ -20,6 +22,7 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/rename"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/reverse_dns"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/s2geo"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/slo"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/starlark"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/strings"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/tag_limit"
@@ -1,7 +1,10 @@
 package clone
 
 import (
+	"fmt"
+
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
 )
 
@@ -11,6 +14,14 @@ var sampleConfig = `
   # name_prefix = "new_name_prefix"
   # name_suffix = "new_name_suffix"
 
+  ## Tags to be renamed, from the old to the new name
+  # [processors.clone.rename_tags]
+  #   team = "owner"
+
+  ## Tags to be removed, glob patterns are supported, e.g. to drop the team
+  ## specific tags of a global view
+  # remove_tags = ["team", "squad_*"]
+
   ## Tags to be added (all values must be strings)
   # [processors.clone.tags]
   #   additional_tag = "tag_value"
@@ -21,6 +32,10 @@ type Clone struct {
 	NamePrefix   string
 	NameSuffix   string
 	Tags         map[string]string
+	RenameTags   map[string]string `toml:"rename_tags"`
+	RemoveTags   []string          `toml:"remove_tags"`
+
+	removeTags filter.Filter
 }
 
 func (c *Clone) SampleConfig() string {
@@ -31,6 +46,15 @@ func (c *Clone) Description() string {
 	return "Clone metrics and apply modifications."
 }
 
+func (c *Clone) Init() error {
+	var err error
+	c.removeTags, err = filter.Compile(c.RemoveTags)
+	if err != nil {
+		return fmt.Errorf("compiling remove_tags: %w", err)
+	}
+	return nil
+}
+
 func (c *Clone) Apply(in ...cua.Metric) []cua.Metric {
 	cloned := []cua.Metric{}
 
@@ -46,6 +70,23 @@ func (c *Clone) Apply(in ...cua.Metric) []cua.Metric {
 		if len(c.NameSuffix) > 0 {
 			metric.AddSuffix(c.NameSuffix)
 		}
+		for oldKey, newKey := range c.RenameTags {
+			if value, ok := metric.GetTag(oldKey); ok {
+				metric.RemoveTag(oldKey)
+				metric.AddTag(newKey, value)
+			}
+		}
+		if c.removeTags != nil {
+			var remove []string
+			for _, tag := range metric.TagList() {
+				if c.removeTags.Match(tag.Key) {
+					remove = append(remove, tag.Key)
+				}
+			}
+			for _, key := range remove {
+				metric.RemoveTag(key)
+			}
+		}
 		for key, value := range c.Tags {
 			metric.AddTag(key, value)
 		}
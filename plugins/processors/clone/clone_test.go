@@ -81,3 +81,30 @@ func TestNameSuffix(t *testing.T) {
 	assert.Equal(t, "m1-suff", processed[0].Name(), "Suffix was not applied")
 	assert.Equal(t, "m1", processed[1].Name(), "Original metric was modified")
 }
+
+func TestRenameTags(t *testing.T) {
+	processor := Clone{RenameTags: map[string]string{"metric_tag": "renamed", "absent": "x"}}
+
+	processed := processor.Apply(createTestMetric())
+
+	assert.Equal(t, map[string]string{"renamed": "from_metric"}, processed[0].Tags(), "Tag was not renamed")
+	assert.Equal(t, map[string]string{"metric_tag": "from_metric"}, processed[1].Tags(), "Original metric was modified")
+}
+
+func TestRemoveTags(t *testing.T) {
+	processor := Clone{
+		RemoveTags: []string{"metric_*", "team"},
+		Tags:       map[string]string{"view": "global"},
+	}
+	assert.NoError(t, processor.Init())
+
+	m := createTestMetric()
+	m.AddTag("metric_other", "x")
+	m.AddTag("team", "a")
+	m.AddTag("host", "h")
+
+	processed := processor.Apply(m)
+
+	assert.Equal(t, map[string]string{"host": "h", "view": "global"}, processed[0].Tags(), "Tags were not removed")
+	assert.Equal(t, 4, len(processed[1].Tags()), "Original metric was modified")
+}
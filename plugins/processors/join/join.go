@@ -0,0 +1,259 @@
+package join
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Measurements to join, metrics of other measurements pass through
+  measurements = ["cpu", "mem"]
+
+  ## Name of the joined metric
+  name = "host_overview"
+
+  ## Tags identifying the metrics to join, the joined metric only has these
+  ## tags and metrics missing one of them pass through. When empty the
+  ## metrics must have the same tags.
+  # tags = ["host"]
+
+  ## Metrics with timestamps in the same window are joined, the joined metric
+  ## has the start of the window as timestamp
+  # window = "10s"
+
+  ## Maximum time to wait for all the measurements once the first one of a
+  ## join arrived, an incomplete join is then emitted or dropped
+  # timeout = "30s"
+  # emit_incomplete = false
+
+  ## Prefix the fields of the joined metric with the name of their
+  ## measurement, e.g. cpu_usage_idle
+  # prefix_fields = true
+
+  ## Drop the joined metrics rather than passing them through
+  # drop_original = false
+`
+
+type Join struct {
+	Measurements   []string        `toml:"measurements"`
+	Name           string          `toml:"name"`
+	Tags           []string        `toml:"tags"`
+	Window         config.Duration `toml:"window"`
+	Timeout        config.Duration `toml:"timeout"`
+	EmitIncomplete bool            `toml:"emit_incomplete"`
+	PrefixFields   bool            `toml:"prefix_fields"`
+	DropOriginal   bool            `toml:"drop_original"`
+	Log            cua.Logger      `toml:"-"`
+
+	measurements map[string]bool
+	pending      map[string]*join
+	acc          cua.Accumulator
+	mu           sync.Mutex
+	done         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// join is the fields of the measurements received so far for a set of tags
+// and a window
+type join struct {
+	tags    map[string]string
+	ts      time.Time
+	fields  map[string]interface{}
+	seen    map[string]bool
+	started time.Time
+}
+
+func (j *Join) SampleConfig() string {
+	return sampleConfig
+}
+
+func (j *Join) Description() string {
+	return "Join the fields of metrics of different measurements with the same tags into one metric"
+}
+
+func (j *Join) Init() error {
+	if len(j.Measurements) < 2 {
+		return fmt.Errorf("at least two measurements are required")
+	}
+	if j.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if j.Window <= 0 {
+		return fmt.Errorf("invalid window (%s), must be positive", time.Duration(j.Window))
+	}
+	if j.Timeout <= 0 {
+		return fmt.Errorf("invalid timeout (%s), must be positive", time.Duration(j.Timeout))
+	}
+
+	j.measurements = make(map[string]bool, len(j.Measurements))
+	for _, name := range j.Measurements {
+		j.measurements[name] = true
+	}
+	j.pending = make(map[string]*join)
+	return nil
+}
+
+func (j *Join) Start(acc cua.Accumulator) error {
+	j.acc = acc
+	j.done = make(chan struct{})
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-j.done:
+				return
+			case now := <-ticker.C:
+				j.expire(now)
+			}
+		}
+	}()
+	return nil
+}
+
+func (j *Join) Stop() error {
+	close(j.done)
+	j.wg.Wait()
+	return nil
+}
+
+func (j *Join) Add(m cua.Metric, acc cua.Accumulator) error {
+	if !j.measurements[m.Name()] {
+		acc.AddMetric(m)
+		return nil
+	}
+
+	tags, ok := j.joinTags(m)
+	if !ok {
+		acc.AddMetric(m)
+		return nil
+	}
+
+	ts := m.Time().Truncate(time.Duration(j.Window))
+	key := joinKey(tags, ts)
+
+	j.mu.Lock()
+	jn, ok := j.pending[key]
+	if !ok {
+		jn = &join{
+			tags:    tags,
+			ts:      ts,
+			fields:  make(map[string]interface{}),
+			seen:    make(map[string]bool),
+			started: time.Now(),
+		}
+		j.pending[key] = jn
+	}
+	for _, f := range m.FieldList() {
+		name := f.Key
+		if j.PrefixFields {
+			name = m.Name() + "_" + f.Key
+		}
+		jn.fields[name] = f.Value
+	}
+	jn.seen[m.Name()] = true
+
+	complete := len(jn.seen) == len(j.measurements)
+	if complete {
+		delete(j.pending, key)
+	}
+	j.mu.Unlock()
+
+	if j.DropOriginal {
+		m.Drop()
+	} else {
+		acc.AddMetric(m)
+	}
+
+	if complete {
+		j.emit(acc, jn)
+	}
+	return nil
+}
+
+// expire emits or drops the joins waiting for longer than the timeout
+func (j *Join) expire(now time.Time) {
+	var expired []*join
+	j.mu.Lock()
+	for key, jn := range j.pending {
+		if now.Sub(jn.started) >= time.Duration(j.Timeout) {
+			delete(j.pending, key)
+			expired = append(expired, jn)
+		}
+	}
+	j.mu.Unlock()
+
+	for _, jn := range expired {
+		if !j.EmitIncomplete {
+			j.Log.Debugf("dropping incomplete join of %s at %s, missing measurements", j.Name, jn.ts)
+			continue
+		}
+		j.emit(j.acc, jn)
+	}
+}
+
+func (j *Join) emit(acc cua.Accumulator, jn *join) {
+	m, err := metric.New(j.Name, jn.tags, jn.fields, jn.ts)
+	if err != nil {
+		j.Log.Errorf("creating joined metric: %v", err)
+		return
+	}
+	acc.AddMetric(m)
+}
+
+// joinTags returns the tags of the joined metric, false when the metric is
+// missing one of the configured tags
+func (j *Join) joinTags(m cua.Metric) (map[string]string, bool) {
+	if len(j.Tags) == 0 {
+		return m.Tags(), true
+	}
+
+	tags := make(map[string]string, len(j.Tags))
+	for _, key := range j.Tags {
+		v, ok := m.GetTag(key)
+		if !ok {
+			return nil, false
+		}
+		tags[key] = v
+	}
+	return tags, true
+}
+
+func joinKey(tags map[string]string, ts time.Time) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+		b.WriteByte(',')
+	}
+	b.WriteString(ts.String())
+	return b.String()
+}
+
+func init() {
+	processors.AddStreaming("join", func() cua.StreamingProcessor {
+		return &Join{
+			Window:       config.Duration(10 * time.Second),
+			Timeout:      config.Duration(30 * time.Second),
+			PrefixFields: true,
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package join
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newJoin(t *testing.T) *Join {
+	j := &Join{
+		Measurements: []string{"cpu", "mem"},
+		Name:         "host_overview",
+		Tags:         []string{"host"},
+		Window:       config.Duration(10 * time.Second),
+		Timeout:      config.Duration(30 * time.Second),
+		PrefixFields: true,
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, j.Init())
+	return j
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		join    *Join
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			join: &Join{Measurements: []string{"cpu", "mem"}, Name: "x", Window: config.Duration(time.Second), Timeout: config.Duration(time.Second)},
+		},
+		{
+			name:    "one measurement",
+			join:    &Join{Measurements: []string{"cpu"}, Name: "x", Window: config.Duration(time.Second), Timeout: config.Duration(time.Second)},
+			wantErr: true,
+		},
+		{
+			name:    "missing name",
+			join:    &Join{Measurements: []string{"cpu", "mem"}, Window: config.Duration(time.Second), Timeout: config.Duration(time.Second)},
+			wantErr: true,
+		},
+		{
+			name:    "window",
+			join:    &Join{Measurements: []string{"cpu", "mem"}, Name: "x", Timeout: config.Duration(time.Second)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.join.Init()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	j := newJoin(t)
+	j.DropOriginal = true
+	acc := &testutil.Accumulator{}
+
+	cpu := testutil.MustMetric("cpu",
+		map[string]string{"host": "a", "cpu": "cpu-total"},
+		map[string]interface{}{"usage_idle": 90.0},
+		time.Unix(1600000001, 0),
+	)
+	mem := testutil.MustMetric("mem",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"used_percent": 40.0},
+		time.Unix(1600000005, 0),
+	)
+	disk := testutil.MustMetric("disk",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"used": int64(10)},
+		time.Unix(1600000005, 0),
+	)
+
+	require.NoError(t, j.Add(cpu, acc))
+	require.NoError(t, j.Add(disk, acc))
+	require.NoError(t, j.Add(mem, acc))
+
+	expected := []cua.Metric{
+		disk,
+		testutil.MustMetric("host_overview",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"cpu_usage_idle": 90.0, "mem_used_percent": 40.0},
+			time.Unix(1600000000, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics())
+	require.Empty(t, j.pending)
+}
+
+func TestJoinSeparatesTagsAndWindows(t *testing.T) {
+	j := newJoin(t)
+	j.PrefixFields = false
+	acc := &testutil.Accumulator{}
+
+	metrics := []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 90.0}, time.Unix(1600000001, 0)),
+		testutil.MustMetric("mem", map[string]string{"host": "b"}, map[string]interface{}{"used": 40.0}, time.Unix(1600000001, 0)),
+		testutil.MustMetric("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": 50.0}, time.Unix(1600000011, 0)),
+		testutil.MustMetric("mem", map[string]string{}, map[string]interface{}{"used": 60.0}, time.Unix(1600000001, 0)),
+	}
+	for _, m := range metrics {
+		require.NoError(t, j.Add(m, acc))
+	}
+
+	// the originals pass through, nothing is joined
+	testutil.RequireMetricsEqual(t, metrics, acc.GetCUAMetrics())
+	require.Len(t, j.pending, 3)
+}
+
+func TestExpire(t *testing.T) {
+	for _, emit := range []bool{false, true} {
+		j := newJoin(t)
+		j.DropOriginal = true
+		j.EmitIncomplete = emit
+		acc := &testutil.Accumulator{}
+		j.acc = acc
+
+		cpu := testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage_idle": 90.0},
+			time.Unix(1600000001, 0),
+		)
+		require.NoError(t, j.Add(cpu, acc))
+
+		j.expire(time.Now())
+		require.Len(t, j.pending, 1)
+
+		j.expire(time.Now().Add(time.Minute))
+		require.Empty(t, j.pending)
+
+		var expected []cua.Metric
+		if emit {
+			expected = append(expected, testutil.MustMetric("host_overview",
+				map[string]string{"host": "a"},
+				map[string]interface{}{"cpu_usage_idle": 90.0},
+				time.Unix(1600000000, 0),
+			))
+		}
+		testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics())
+	}
+}
@@ -0,0 +1,305 @@
+package math
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	errDivideByZero = errors.New("divide by zero")
+	errMissingField = errors.New("missing field")
+)
+
+// node is a node of a parsed expression, lookup returns the numeric value of
+// a field of the metric
+type node interface {
+	eval(lookup func(string) (float64, bool)) (float64, error)
+}
+
+type number float64
+
+func (n number) eval(func(string) (float64, bool)) (float64, error) {
+	return float64(n), nil
+}
+
+type field string
+
+func (f field) eval(lookup func(string) (float64, bool)) (float64, error) {
+	v, ok := lookup(string(f))
+	if !ok {
+		return 0, fmt.Errorf("%w %q", errMissingField, string(f))
+	}
+	return v, nil
+}
+
+type negate struct {
+	x node
+}
+
+func (n negate) eval(lookup func(string) (float64, bool)) (float64, error) {
+	v, err := n.x.eval(lookup)
+	return -v, err
+}
+
+type binary struct {
+	op   byte
+	l, r node
+}
+
+func (b binary) eval(lookup func(string) (float64, bool)) (float64, error) {
+	l, err := b.l.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+	r, err := b.r.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, errDivideByZero
+		}
+		return l / r, nil
+	case '%':
+		if r == 0 {
+			return 0, errDivideByZero
+		}
+		return math.Mod(l, r), nil
+	}
+	return 0, fmt.Errorf("unknown operator %q", b.op)
+}
+
+// functions are the functions of the expressions and their number of
+// arguments, 0 for any number of at least one
+var functions = map[string]int{
+	"abs":   1,
+	"round": 1,
+	"min":   0,
+	"max":   0,
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func (c call) eval(lookup func(string) (float64, bool)) (float64, error) {
+	args := make([]float64, 0, len(c.args))
+	for _, a := range c.args {
+		v, err := a.eval(lookup)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, v)
+	}
+
+	switch c.name {
+	case "abs":
+		return math.Abs(args[0]), nil
+	case "round":
+		return math.Round(args[0]), nil
+	case "min", "max":
+		v := args[0]
+		for _, a := range args[1:] {
+			if (c.name == "min" && a < v) || (c.name == "max" && a > v) {
+				v = a
+			}
+		}
+		return v, nil
+	}
+	return 0, fmt.Errorf("unknown function %q", c.name)
+}
+
+// parser is a recursive descent parser of the expressions:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = unary { ("*" | "/" | "%") unary }
+//	unary  = "-" unary | primary
+//	primary = number | field | function "(" expr { "," expr } ")" | "(" expr ")"
+//
+// The fields are identifiers of letters, digits, "_" and ".", or any name
+// quoted with backticks, e.g. `in-octets`.
+type parser struct {
+	s   string
+	pos int
+}
+
+func parse(s string) (node, error) {
+	p := &parser{s: s}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at %d", p.s[p.pos], p.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes the next character if it is one of chars
+func (p *parser) accept(chars string) (byte, bool) {
+	p.skipSpace()
+	if p.pos < len(p.s) && strings.IndexByte(chars, p.s[p.pos]) >= 0 {
+		p.pos++
+		return p.s[p.pos-1], true
+	}
+	return 0, false
+}
+
+func (p *parser) expr() (node, error) {
+	n, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+-")
+		if !ok {
+			return n, nil
+		}
+		r, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		n = binary{op: op, l: n, r: r}
+	}
+}
+
+func (p *parser) term() (node, error) {
+	n, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*/%")
+		if !ok {
+			return n, nil
+		}
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		n = binary{op: op, l: n, r: r}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if _, ok := p.accept("-"); ok {
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negate{x: n}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	c := p.s[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("missing ) at %d", p.pos)
+		}
+		return n, nil
+	case c == '`':
+		end := strings.IndexByte(p.s[p.pos+1:], '`')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated field name at %d", p.pos)
+		}
+		name := p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return field(name), nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.s) && strings.IndexByte("0123456789.eE", p.s[p.pos]) >= 0 {
+			// the sign of an exponent
+			if (p.s[p.pos] == 'e' || p.s[p.pos] == 'E') && p.pos+1 < len(p.s) && (p.s[p.pos+1] == '-' || p.s[p.pos+1] == '+') {
+				p.pos++
+			}
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.s[start:p.pos])
+		}
+		return number(v), nil
+	case isIdentStart(c):
+		start := p.pos
+		for p.pos < len(p.s) && isIdent(p.s[p.pos]) {
+			p.pos++
+		}
+		name := p.s[start:p.pos]
+		if _, ok := p.accept("("); ok {
+			return p.call(name)
+		}
+		return field(name), nil
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", c, p.pos)
+}
+
+func (p *parser) call(name string) (node, error) {
+	nargs, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+
+	var args []node
+	if _, ok := p.accept(")"); !ok {
+		for {
+			n, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, n)
+			if _, ok := p.accept(","); ok {
+				continue
+			}
+			if _, ok := p.accept(")"); ok {
+				break
+			}
+			return nil, fmt.Errorf("missing ) at %d", p.pos)
+		}
+	}
+
+	if (nargs == 0 && len(args) == 0) || (nargs > 0 && len(args) != nargs) {
+		return nil, fmt.Errorf("invalid number of arguments of %s", name)
+	}
+	return call{name: name, args: args}, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || c == '.' || (c >= '0' && c <= '9')
+}
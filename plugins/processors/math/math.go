@@ -0,0 +1,130 @@
+package math
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Apply to the metrics carrying the fields, e.g.
+  # namepass = ["mem", "http_server"]
+
+  ## How to handle a division by zero: "skip" does not add the field, "zero"
+  ## adds the field with a value of 0
+  # divide_by_zero = "skip"
+
+  ## Fields to add, computed in order so an expression can use the fields
+  ## added before it. Expressions support + - * / %, parentheses and the
+  ## functions abs, round, min and max, field names with other characters
+  ## than letters, digits, "_" and "." are quoted with backticks.
+  ## An existing field with the same name is replaced, the field is not
+  ## added when a field of the expression is missing or not numeric.
+  [[processors.math.field]]
+    name = "used_percent"
+    expression = "used / total * 100"
+
+  # [[processors.math.field]]
+  #   name = "error_ratio"
+  #   expression = "errors / requests"
+`
+
+const (
+	divideByZeroSkip = "skip"
+	divideByZeroZero = "zero"
+)
+
+type Field struct {
+	Name       string `toml:"name"`
+	Expression string `toml:"expression"`
+
+	expr node
+}
+
+type Math struct {
+	Fields       []*Field `toml:"field"`
+	DivideByZero string   `toml:"divide_by_zero"`
+}
+
+func (p *Math) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Math) Description() string {
+	return "Add fields computed from expressions over the fields of the same metric"
+}
+
+func (p *Math) Init() error {
+	switch p.DivideByZero {
+	case "":
+		p.DivideByZero = divideByZeroSkip
+	case divideByZeroSkip, divideByZeroZero:
+	default:
+		return fmt.Errorf("invalid divide_by_zero (%s), must be %q or %q", p.DivideByZero, divideByZeroSkip, divideByZeroZero)
+	}
+
+	if len(p.Fields) == 0 {
+		return fmt.Errorf("no fields configured")
+	}
+	for _, f := range p.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("field name is required")
+		}
+		expr, err := parse(f.Expression)
+		if err != nil {
+			return fmt.Errorf("invalid expression of field %s (%s): %w", f.Name, f.Expression, err)
+		}
+		f.expr = expr
+	}
+	return nil
+}
+
+func (p *Math) Apply(in ...cua.Metric) []cua.Metric {
+	for _, m := range in {
+		lookup := func(key string) (float64, bool) {
+			return fieldValue(m, key)
+		}
+		for _, f := range p.Fields {
+			v, err := f.expr.eval(lookup)
+			if err != nil {
+				if !errors.Is(err, errDivideByZero) || p.DivideByZero != divideByZeroZero {
+					continue
+				}
+				v = 0
+			}
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			m.AddField(f.Name, v)
+		}
+	}
+	return in
+}
+
+func fieldValue(m cua.Metric, key string) (float64, bool) {
+	v, ok := m.GetField(key)
+	if !ok {
+		return 0, false
+	}
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	processors.Add("math", func() cua.Processor {
+		return &Math{
+			DivideByZero: divideByZeroSkip,
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package math
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newMath(t *testing.T, divideByZero string, fields ...string) *Math {
+	p := &Math{DivideByZero: divideByZero}
+	for i := 0; i < len(fields); i += 2 {
+		p.Fields = append(p.Fields, &Field{Name: fields[i], Expression: fields[i+1]})
+	}
+	require.NoError(t, p.Init())
+	return p
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    float64
+		wantErr bool
+	}{
+		{expr: "1 + 2 * 3", want: 7},
+		{expr: "(1 + 2) * 3", want: 9},
+		{expr: "10 - 4 - 3", want: 3},
+		{expr: "-2 * -3", want: 6},
+		{expr: "7 % 4", want: 3},
+		{expr: "1.5e2 / 3", want: 50},
+		{expr: "2e-1 * 10", want: 2},
+		{expr: "a * 2", want: 8},
+		{expr: "`b-c` + disk.used", want: 15},
+		{expr: "abs(-3) + round(2.6)", want: 6},
+		{expr: "min(a, 3, 9) + max(a, 3, 9)", want: 12},
+		{expr: "1 +", wantErr: true},
+		{expr: "(1 + 2", wantErr: true},
+		{expr: "1 2", wantErr: true},
+		{expr: "`a", wantErr: true},
+		{expr: "sqrt(4)", wantErr: true},
+		{expr: "abs(1, 2)", wantErr: true},
+		{expr: "max()", wantErr: true},
+		{expr: "", wantErr: true},
+	}
+
+	fields := map[string]float64{"a": 4, "b-c": 5, "disk.used": 10}
+	lookup := func(key string) (float64, bool) {
+		v, ok := fields[key]
+		return v, ok
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			n, err := parse(tt.expr)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			v, err := n.eval(lookup)
+			require.NoError(t, err)
+			require.InDelta(t, tt.want, v, 1e-9)
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		math    Math
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			math: Math{Fields: []*Field{{Name: "x", Expression: "a / b"}}},
+		},
+		{
+			name:    "no fields",
+			math:    Math{},
+			wantErr: true,
+		},
+		{
+			name:    "missing name",
+			math:    Math{Fields: []*Field{{Expression: "a / b"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid expression",
+			math:    Math{Fields: []*Field{{Name: "x", Expression: "a /"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid divide_by_zero",
+			math:    Math{DivideByZero: "nan", Fields: []*Field{{Name: "x", Expression: "a / b"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.math.Init()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	p := newMath(t, divideByZeroSkip,
+		"used_percent", "used / total * 100",
+		"free_percent", "100 - used_percent",
+		"error_ratio", "errors / requests",
+		"missing", "used / nope",
+		"text", "used + name",
+	)
+
+	m := testutil.MustMetric("mem",
+		map[string]string{"host": "a"},
+		map[string]interface{}{
+			"used":     int64(25),
+			"total":    uint64(200),
+			"errors":   1.0,
+			"requests": int64(0),
+			"name":     "x",
+		},
+		now,
+	)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("mem",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"used":         int64(25),
+				"total":        uint64(200),
+				"errors":       1.0,
+				"requests":     int64(0),
+				"name":         "x",
+				"used_percent": 12.5,
+				"free_percent": 87.5,
+			},
+			now,
+		),
+	}
+
+	testutil.RequireMetricsEqual(t, expected, p.Apply(m))
+}
+
+func TestApplyDivideByZero(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	p := newMath(t, divideByZeroZero,
+		"error_ratio", "errors / requests",
+		"rest", "errors % requests",
+	)
+
+	m := testutil.MustMetric("http",
+		map[string]string{},
+		map[string]interface{}{"errors": int64(3), "requests": int64(0)},
+		now,
+	)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("http",
+			map[string]string{},
+			map[string]interface{}{"errors": int64(3), "requests": int64(0), "error_ratio": 0.0, "rest": 0.0},
+			now,
+		),
+	}
+
+	testutil.RequireMetricsEqual(t, expected, p.Apply(m))
+}
+
+func TestApplyReplace(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	p := newMath(t, divideByZeroSkip, "bytes", "bytes * 8")
+
+	m := testutil.MustMetric("net",
+		map[string]string{},
+		map[string]interface{}{"bytes": int64(10)},
+		now,
+	)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("net",
+			map[string]string{},
+			map[string]interface{}{"bytes": 80.0},
+			now,
+		),
+	}
+
+	testutil.RequireMetricsEqual(t, expected, p.Apply(m))
+}
@@ -0,0 +1,266 @@
+package slo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Apply to the metrics carrying the counters, e.g.
+  # namepass = ["http_server"]
+
+  ## Fields with the count of successful events and the count of all events
+  success_field = "requests_success"
+  total_field = "requests_total"
+
+  ## Set to false when the fields are the number of events since the last
+  ## metric rather than ever increasing counters
+  # cumulative = true
+
+  ## The SLO objective, the target ratio of successful events
+  objective = 0.999
+
+  ## Pairs of short and long windows, the availability and the burn rate are
+  ## added for each window, and for each pair the lower of the two burn
+  ## rates, which exceeds a threshold only when both windows do
+  # windows = [["5m", "1h"], ["30m", "6h"]]
+
+  ## Prefix of the added fields
+  # field_prefix = "slo_"
+`
+
+type SLO struct {
+	SuccessField string     `toml:"success_field"`
+	TotalField   string     `toml:"total_field"`
+	FieldPrefix  string     `toml:"field_prefix"`
+	Windows      [][]string `toml:"windows"`
+	Objective    float64    `toml:"objective"`
+	Cumulative   bool       `toml:"cumulative"`
+
+	windows  []window
+	pairs    [][2]int // indexes into windows
+	longest  time.Duration
+	series   map[uint64]*series
+	lastSeen time.Time
+}
+
+type window struct {
+	name     string
+	duration time.Duration
+}
+
+// sample is the number of events since the previous sample of a series
+type sample struct {
+	ts      time.Time
+	success float64
+	total   float64
+}
+
+type series struct {
+	samples     []sample
+	lastSuccess float64
+	lastTotal   float64
+	lastSeen    time.Time
+}
+
+func (s *SLO) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SLO) Description() string {
+	return "Add SLO availability and multi-window burn rates from success and total counters"
+}
+
+func (s *SLO) Init() error {
+	if s.SuccessField == "" || s.TotalField == "" {
+		return fmt.Errorf("success_field and total_field are required")
+	}
+	if s.Objective <= 0 || s.Objective >= 1 {
+		return fmt.Errorf("invalid objective (%v), must be between 0 and 1", s.Objective)
+	}
+	if len(s.Windows) == 0 {
+		return fmt.Errorf("no windows configured")
+	}
+
+	index := make(map[string]int)
+	for _, pair := range s.Windows {
+		if len(pair) != 2 {
+			return fmt.Errorf("invalid window pair %v, expected [short, long]", pair)
+		}
+		var idx [2]int
+		for i, name := range pair {
+			if n, ok := index[name]; ok {
+				idx[i] = n
+				continue
+			}
+			d, err := time.ParseDuration(name)
+			if err != nil {
+				return fmt.Errorf("invalid window (%s): %w", name, err)
+			}
+			if d <= 0 {
+				return fmt.Errorf("invalid window (%s), must be positive", name)
+			}
+			index[name] = len(s.windows)
+			idx[i] = len(s.windows)
+			s.windows = append(s.windows, window{name: name, duration: d})
+			if d > s.longest {
+				s.longest = d
+			}
+		}
+		s.pairs = append(s.pairs, idx)
+	}
+
+	s.series = make(map[uint64]*series)
+	return nil
+}
+
+func (s *SLO) Apply(in ...cua.Metric) []cua.Metric {
+	for _, m := range in {
+		success, ok := fieldValue(m, s.SuccessField)
+		if !ok {
+			continue
+		}
+		total, ok := fieldValue(m, s.TotalField)
+		if !ok {
+			continue
+		}
+
+		id := m.HashID()
+		ser, ok := s.series[id]
+		if !ok {
+			ser = &series{}
+			s.series[id] = ser
+		}
+		ser.add(m.Time(), success, total, s.Cumulative, !ok)
+		ser.expire(m.Time(), s.longest)
+		ser.lastSeen = m.Time()
+
+		s.addFields(m, ser)
+
+		if m.Time().After(s.lastSeen) {
+			s.lastSeen = m.Time()
+		}
+	}
+
+	s.expire()
+	return in
+}
+
+// addFields adds the availability and burn rate of each window, and the
+// lower burn rate of each pair of windows, skipping windows without events
+func (s *SLO) addFields(m cua.Metric, ser *series) {
+	budget := 1 - s.Objective
+	burnRates := make([]float64, len(s.windows))
+	valid := make([]bool, len(s.windows))
+
+	for i, w := range s.windows {
+		success, total := ser.sum(m.Time(), w.duration)
+		if total <= 0 {
+			continue
+		}
+		availability := success / total
+		if availability > 1 {
+			availability = 1
+		}
+		burnRates[i] = (1 - availability) / budget
+		valid[i] = true
+
+		m.AddField(s.FieldPrefix+"availability_"+w.name, availability)
+		m.AddField(s.FieldPrefix+"burn_rate_"+w.name, burnRates[i])
+	}
+
+	for _, pair := range s.pairs {
+		short, long := pair[0], pair[1]
+		if !valid[short] || !valid[long] {
+			continue
+		}
+		rate := burnRates[short]
+		if burnRates[long] < rate {
+			rate = burnRates[long]
+		}
+		m.AddField(s.FieldPrefix+"burn_rate_"+s.windows[short].name+"_"+s.windows[long].name, rate)
+	}
+}
+
+// expire removes series not seen for longer than the longest window
+func (s *SLO) expire() {
+	for id, ser := range s.series {
+		if s.lastSeen.Sub(ser.lastSeen) > s.longest {
+			delete(s.series, id)
+		}
+	}
+}
+
+// add records the events since the previous sample. For cumulative counters
+// the first value is only a baseline, and a counter lower than its previous
+// value has been reset so the value is the number of events since the reset.
+func (ser *series) add(ts time.Time, success, total float64, cumulative, first bool) {
+	if !cumulative {
+		ser.samples = append(ser.samples, sample{ts: ts, success: success, total: total})
+		return
+	}
+
+	if !first {
+		ds, dt := success-ser.lastSuccess, total-ser.lastTotal
+		if ds < 0 || dt < 0 {
+			ds, dt = success, total
+		}
+		ser.samples = append(ser.samples, sample{ts: ts, success: ds, total: dt})
+	}
+	ser.lastSuccess = success
+	ser.lastTotal = total
+}
+
+// expire removes samples older than the longest window
+func (ser *series) expire(now time.Time, longest time.Duration) {
+	n := 0
+	for n < len(ser.samples) && !ser.samples[n].ts.After(now.Add(-longest)) {
+		n++
+	}
+	if n > 0 {
+		ser.samples = append(ser.samples[:0], ser.samples[n:]...)
+	}
+}
+
+// sum returns the number of events in the window ending at now
+func (ser *series) sum(now time.Time, d time.Duration) (success, total float64) {
+	start := now.Add(-d)
+	for i := len(ser.samples) - 1; i >= 0; i-- {
+		if !ser.samples[i].ts.After(start) {
+			break
+		}
+		success += ser.samples[i].success
+		total += ser.samples[i].total
+	}
+	return success, total
+}
+
+func fieldValue(m cua.Metric, key string) (float64, bool) {
+	v, ok := m.GetField(key)
+	if !ok {
+		return 0, false
+	}
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	processors.Add("slo", func() cua.Processor {
+		return &SLO{
+			FieldPrefix: "slo_",
+			Cumulative:  true,
+			Windows:     [][]string{{"5m", "1h"}, {"30m", "6h"}},
+		}
+	})
+}
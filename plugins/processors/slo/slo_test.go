@@ -0,0 +1,180 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newSLO(t *testing.T, windows [][]string, cumulative bool) *SLO {
+	s := &SLO{
+		SuccessField: "ok",
+		TotalField:   "total",
+		FieldPrefix:  "slo_",
+		Objective:    0.99,
+		Windows:      windows,
+		Cumulative:   cumulative,
+	}
+	require.NoError(t, s.Init())
+	return s
+}
+
+func counters(ok, total int64, ts time.Time) cua.Metric {
+	return testutil.MustMetric("http",
+		map[string]string{"server": "a"},
+		map[string]interface{}{"ok": ok, "total": total},
+		ts,
+	)
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		slo     SLO
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			slo:  SLO{SuccessField: "ok", TotalField: "total", Objective: 0.999, Windows: [][]string{{"5m", "1h"}}},
+		},
+		{
+			name:    "missing fields",
+			slo:     SLO{Objective: 0.999, Windows: [][]string{{"5m", "1h"}}},
+			wantErr: true,
+		},
+		{
+			name:    "objective",
+			slo:     SLO{SuccessField: "ok", TotalField: "total", Objective: 1, Windows: [][]string{{"5m", "1h"}}},
+			wantErr: true,
+		},
+		{
+			name:    "pair",
+			slo:     SLO{SuccessField: "ok", TotalField: "total", Objective: 0.999, Windows: [][]string{{"5m"}}},
+			wantErr: true,
+		},
+		{
+			name:    "duration",
+			slo:     SLO{SuccessField: "ok", TotalField: "total", Objective: 0.999, Windows: [][]string{{"5x", "1h"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.slo.Init()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCumulative(t *testing.T) {
+	s := newSLO(t, [][]string{{"1m", "5m"}}, true)
+	start := time.Unix(0, 0)
+
+	// the first value is a baseline
+	out := s.Apply(counters(100, 100, start))
+	require.Len(t, out, 1)
+	_, ok := out[0].GetField("slo_availability_1m")
+	require.False(t, ok)
+
+	// 4 minutes of 100 requests/minute without errors
+	for i := 1; i <= 4; i++ {
+		s.Apply(counters(int64(100+i*100), int64(100+i*100), start.Add(time.Duration(i)*time.Minute)))
+	}
+
+	// then a minute with 10 errors
+	out = s.Apply(counters(590, 600, start.Add(5*time.Minute)))
+
+	expected := map[string]float64{
+		"slo_availability_1m": 0.9,
+		"slo_burn_rate_1m":    10,
+		"slo_availability_5m": 0.98,
+		"slo_burn_rate_5m":    2,
+		"slo_burn_rate_1m_5m": 2,
+	}
+	require.Len(t, out[0].FieldList(), len(expected)+2)
+	for key, want := range expected {
+		v, ok := out[0].GetField(key)
+		require.True(t, ok, key)
+		require.InDelta(t, want, v, 1e-9, key)
+	}
+}
+
+func TestCounterReset(t *testing.T) {
+	s := newSLO(t, [][]string{{"1m", "5m"}}, true)
+	start := time.Unix(0, 0)
+
+	s.Apply(counters(1000, 1000, start))
+	out := s.Apply(counters(45, 50, start.Add(time.Minute)))
+
+	v, ok := out[0].GetField("slo_availability_1m")
+	require.True(t, ok)
+	require.InDelta(t, 0.9, v, 1e-9)
+}
+
+func TestDeltas(t *testing.T) {
+	s := newSLO(t, [][]string{{"1m", "5m"}}, false)
+	start := time.Unix(0, 0)
+
+	s.Apply(counters(100, 100, start))
+	out := s.Apply(counters(50, 100, start.Add(time.Minute)))
+
+	v, ok := out[0].GetField("slo_availability_1m")
+	require.True(t, ok)
+	require.InDelta(t, 0.5, v, 1e-9)
+
+	v, ok = out[0].GetField("slo_availability_5m")
+	require.True(t, ok)
+	require.InDelta(t, 0.75, v, 1e-9)
+
+	v, ok = out[0].GetField("slo_burn_rate_1m_5m")
+	require.True(t, ok)
+	require.InDelta(t, 25.0, v, 1e-9)
+}
+
+func TestWindowExpiry(t *testing.T) {
+	s := newSLO(t, [][]string{{"1m", "5m"}}, false)
+	start := time.Unix(0, 0)
+
+	s.Apply(counters(0, 100, start))
+	out := s.Apply(counters(100, 100, start.Add(10*time.Minute)))
+
+	v, ok := out[0].GetField("slo_availability_5m")
+	require.True(t, ok)
+	require.InDelta(t, 1.0, v, 1e-9)
+	require.Len(t, s.series[out[0].HashID()].samples, 1)
+}
+
+func TestSeriesExpiry(t *testing.T) {
+	s := newSLO(t, [][]string{{"1m", "5m"}}, false)
+	start := time.Unix(0, 0)
+
+	s.Apply(counters(100, 100, start))
+	other := testutil.MustMetric("http",
+		map[string]string{"server": "b"},
+		map[string]interface{}{"ok": int64(1), "total": int64(1)},
+		start.Add(10*time.Minute),
+	)
+	s.Apply(other)
+	require.Len(t, s.series, 1)
+}
+
+func TestMissingFields(t *testing.T) {
+	s := newSLO(t, [][]string{{"1m", "5m"}}, false)
+
+	m := testutil.MustMetric("http",
+		map[string]string{},
+		map[string]interface{}{"ok": "yes", "total": int64(1)},
+		time.Unix(0, 0),
+	)
+	out := s.Apply(m)
+	require.Len(t, out[0].FieldList(), 2)
+	require.Len(t, s.series, 0)
+}